@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -93,11 +94,79 @@ type apihubPerformerMeta struct {
 
 // apihubDownloadJob streams a batch of scenes into the library's download root,
 // reporting progress to the JobManager so it surfaces in the Tasks JobTable.
+//
+// The batch is saved with the job queue, so a restart mid-way through picks it
+// up again: it checkpoints after each scene, and a resumed batch starts at the
+// first scene not yet finished (a part-downloaded one starts over).
 type apihubDownloadJob struct {
 	items   []apihubDownloadItem
 	root    string
 	client  *http.Client
 	history *apihubHistoryStore
+
+	// resumed carries the state of an interrupted batch over to this one
+	resumed apihubDownloadCheckpoint
+}
+
+const apihubDownloadJobType = "apihub-download"
+
+// apihubDownloadJobInput is what an apihubDownloadJob is saved as.
+type apihubDownloadJobInput struct {
+	Items []apihubDownloadItem `json:"items"`
+	Root  string               `json:"root"`
+}
+
+// apihubDownloadCheckpoint is how far a batch has got: the index of the next
+// scene, and the outcomes so far that go into the end-of-batch summary.
+type apihubDownloadCheckpoint struct {
+	Next     int                    `json:"next"`
+	Failures []string               `json:"failures,omitempty"`
+	Results  []apihubDownloadResult `json:"results,omitempty"`
+}
+
+func (j *apihubDownloadJob) JobType() string {
+	return apihubDownloadJobType
+}
+
+func (j *apihubDownloadJob) JobInput() interface{} {
+	return apihubDownloadJobInput{Items: j.items, Root: j.root}
+}
+
+func (j *apihubDownloadJob) Resumable() bool {
+	return true
+}
+
+func (j *apihubDownloadJob) JobLane() job.Lane {
+	return job.LaneNetwork
+}
+
+// registerApihubDownloadJob lets the JobManager recreate download batches
+// interrupted by a restart, recording to history like the batch that started
+// them.
+func registerApihubDownloadJob(history *apihubHistoryStore) {
+	manager.GetInstance().JobManager.RegisterType(apihubDownloadJobType, func(input, checkpoint json.RawMessage) (job.JobExec, error) {
+		var in apihubDownloadJobInput
+		if err := json.Unmarshal(input, &in); err != nil {
+			return nil, err
+		}
+
+		j := &apihubDownloadJob{
+			items:   in.Items,
+			root:    in.Root,
+			client:  &http.Client{},
+			history: history,
+		}
+		if checkpoint != nil {
+			if err := json.Unmarshal(checkpoint, &j.resumed); err != nil {
+				return nil, err
+			}
+		}
+		return j, nil
+	})
+}
+
+func (j *apihubDownloadJob) JobPausable() bool {
+	return true
 }
 
 func (j *apihubDownloadJob) Execute(ctx context.Context, progress *job.Progress) error {
@@ -106,12 +175,24 @@ func (j *apihubDownloadJob) Execute(ctx context.Context, progress *job.Progress)
 		return nil
 	}
 
-	var failures []string
+	failures := j.resumed.Failures
 	// results feeds the end-of-batch Discord summary (see
 	// notifyDiscordJobComplete) — one entry per recordHistory call below.
-	var results []apihubDownloadResult
+	results := j.resumed.Results
+
+	checkpoint := func(next int) {
+		progress.Checkpoint(apihubDownloadCheckpoint{Next: next, Failures: failures, Results: results})
+	}
+
+	if j.resumed.Next > 0 {
+		logger.Infof("[apihub-download] resuming batch at %d/%d", j.resumed.Next+1, total)
+		progress.SetPercent(float64(j.resumed.Next) / float64(total))
+	}
 
 	for i, item := range j.items {
+		if i < j.resumed.Next {
+			continue
+		}
 		if job.IsCancelled(ctx) {
 			break
 		}
@@ -146,6 +227,7 @@ func (j *apihubDownloadJob) Execute(ctx context.Context, progress *job.Progress)
 			failures = append(failures, fmt.Sprintf("%s: %v", title, dlErr))
 			j.recordHistory(item, apihubHistoryFailed, dlErr.Error(), "")
 			results = append(results, apihubDownloadResult{Title: title, Status: apihubHistoryFailed, Error: dlErr.Error()})
+			checkpoint(idx + 1)
 			continue
 		}
 
@@ -223,6 +305,7 @@ func (j *apihubDownloadJob) Execute(ctx context.Context, progress *job.Progress)
 		}
 
 		progress.SetPercent(float64(idx+1) / float64(total))
+		checkpoint(idx + 1)
 	}
 
 	if job.IsCancelled(ctx) {
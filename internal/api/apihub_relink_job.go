@@ -34,6 +34,11 @@ import (
 // only touches the video file, not a sibling gallery zip).
 type apihubRelinkJob struct{}
 
+// startApihubRelink queues an apihubRelinkJob, returning its job ID.
+func startApihubRelink(ctx context.Context) int {
+	return manager.GetInstance().JobManager.Add(ctx, "APIHub: relinking scenes/galleries from manifests", &apihubRelinkJob{})
+}
+
 type apihubRelinkStats struct {
 	manifests       int
 	scenesPatched   int
@@ -41,6 +46,10 @@ type apihubRelinkStats struct {
 	unmatched       int
 }
 
+func (j *apihubRelinkJob) JobPausable() bool {
+	return true
+}
+
 func (j *apihubRelinkJob) Execute(ctx context.Context, progress *job.Progress) error {
 	roots := config.GetInstance().GetStashPaths()
 	if len(roots) == 0 {
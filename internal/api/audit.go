@@ -0,0 +1,371 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/session"
+)
+
+// auditSkippedMutations lists mutations that are not recorded in the audit
+// log. sceneSaveActivity is sent every few seconds during playback.
+var auditSkippedMutations = map[string]bool{
+	"sceneSaveActivity": true,
+}
+
+// auditEntityTypes maps the words naming an entity in a mutation name onto
+// the entity type recorded in the audit log. Two-word names are matched
+// before single words.
+var auditEntityTypes = map[string]string{
+	"scene marker":    "scene_marker",
+	"scene markers":   "scene_marker",
+	"gallery chapter": "gallery_chapter",
+	"saved filter":    "saved_filter",
+	"api key":         "api_key",
+	"scheduled task":  "scheduled_task",
+	"recycle bin":     "recycle_bin",
+	"scene":           "scene",
+	"scenes":          "scene",
+	"image":           "image",
+	"images":          "image",
+	"gallery":         "gallery",
+	"galleries":       "gallery",
+	"performer":       "performer",
+	"performers":      "performer",
+	"studio":          "studio",
+	"studios":         "studio",
+	"movie":           "movie",
+	"movies":          "movie",
+	"group":           "group",
+	"groups":          "group",
+	"tag":             "tag",
+	"tags":            "tag",
+	"file":            "file",
+	"files":           "file",
+	"user":            "user",
+	"users":           "user",
+	"role":            "role",
+	"roles":           "role",
+	"playlist":        "playlist",
+}
+
+// auditEntityIDFields lists the input fields holding the IDs of the entities
+// a mutation changes. source and destination are set by merges.
+var auditEntityIDFields = []string{"id", "ids", "source", "destination"}
+
+// auditMaxValueLength limits the length of string values recorded in the
+// changes of an entry, so that images sent as data URLs are not stored
+const auditMaxValueLength = 256
+
+const auditRedacted = "[redacted]"
+
+type auditLog struct {
+	repository models.Repository
+}
+
+// record writes e to the audit log, along with the user and address of the
+// request. Failures are logged rather than returned, so that they do not
+// affect the change being recorded.
+func (a auditLog) record(ctx context.Context, e *models.AuditEntry) {
+	if info := session.GetCurrentUserInfo(ctx); info != nil {
+		e.UserID = &info.ID
+		e.Username = info.Username
+	} else if userID := session.GetCurrentUserID(ctx); userID != nil {
+		e.Username = *userID
+	}
+
+	if addr, ok := ctx.Value(remoteAddrKey).(string); ok {
+		e.IPAddress = addr
+	}
+
+	e.CreatedAt = time.Now()
+
+	// the request may be cancelled once the response is written
+	ctx = context.WithoutCancel(ctx)
+	if err := a.repository.WithTxn(ctx, func(ctx context.Context) error {
+		return a.repository.AuditEntry.Create(ctx, e)
+	}); err != nil {
+		logger.Warnf("Error recording %s in the audit log: %v", e.Operation, err)
+	}
+}
+
+// AuditMiddleware creates a gqlgen field middleware that records every
+// mutation in the audit log once it has been resolved. The changed entities
+// are loaded before and after the mutation, and the fields that changed are
+// recorded with their old and new values.
+func AuditMiddleware(repo models.Repository) graphql.FieldMiddleware {
+	a := auditLog{repository: repo}
+
+	return func(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+		fc := graphql.GetFieldContext(ctx)
+		if fc == nil || fc.Object != "Mutation" || !fc.IsResolver || auditSkippedMutations[fc.Field.Name] {
+			return next(ctx)
+		}
+
+		args := getArgumentMap(ctx)
+		e := &models.AuditEntry{
+			Operation:  fc.Field.Name,
+			EntityType: auditEntityType(fc.Field.Name),
+			EntityIDs:  auditEntityIDs(args),
+		}
+
+		// load the entities before they are changed, so that the old values
+		// of updated fields and removed entities can be recorded
+		before := loadAuditSnapshots(ctx, repo, e.EntityType, e.EntityIDs)
+
+		res, err := next(ctx)
+
+		if len(e.EntityIDs) == 0 && err == nil {
+			if id := auditResultID(res); id != "" {
+				e.EntityIDs = []string{id}
+			}
+		}
+
+		var after auditSnapshots
+		if err == nil {
+			after = loadAuditSnapshots(context.WithoutCancel(ctx), repo, e.EntityType, e.EntityIDs)
+		}
+
+		switch {
+		case err != nil:
+			e.Error = err.Error()
+			e.Changes = auditChanges(args)
+		case before != nil || after != nil:
+			e.Changes = auditDiff(before, after)
+		default:
+			e.Changes = auditChanges(args)
+		}
+
+		a.record(ctx, e)
+
+		return res, err
+	}
+}
+
+// auditHandler stores the address of the client on the request context for
+// the audit log, and records DELETE requests once they have been served.
+func auditHandler(repo models.Repository) func(http.Handler) http.Handler {
+	a := auditLog{repository: repo}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			addr, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				addr = r.RemoteAddr
+			}
+			ctx := context.WithValue(r.Context(), remoteAddrKey, addr)
+			r = r.WithContext(ctx)
+
+			if r.Method != http.MethodDelete {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			// the route pattern is only known once the request is routed
+			e := &models.AuditEntry{
+				Operation: r.Method + " " + r.URL.Path,
+			}
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				if pattern := rctx.RoutePattern(); pattern != "" {
+					e.Operation = r.Method + " " + pattern
+				}
+				for i, k := range rctx.URLParams.Keys {
+					if k != "*" && rctx.URLParams.Values[i] != "" {
+						e.EntityIDs = append(e.EntityIDs, rctx.URLParams.Values[i])
+					}
+				}
+			}
+			if status := ww.Status(); status >= http.StatusBadRequest {
+				e.Error = http.StatusText(status)
+			}
+
+			a.record(ctx, e)
+		})
+	}
+}
+
+// auditEntityType returns the type of entity changed by the named mutation,
+// or "" if it does not name one.
+func auditEntityType(name string) string {
+	words := splitCamelCase(name)
+
+	for i := 0; i+1 < len(words); i++ {
+		if t, ok := auditEntityTypes[words[i]+" "+words[i+1]]; ok {
+			return t
+		}
+	}
+	for _, w := range words {
+		if t, ok := auditEntityTypes[w]; ok {
+			return t
+		}
+	}
+
+	return ""
+}
+
+// splitCamelCase splits s into its lowercase words
+func splitCamelCase(s string) []string {
+	var ret []string
+	var word strings.Builder
+	for _, c := range s {
+		if unicode.IsUpper(c) && word.Len() > 0 {
+			ret = append(ret, word.String())
+			word.Reset()
+		}
+		word.WriteRune(unicode.ToLower(c))
+	}
+	if word.Len() > 0 {
+		ret = append(ret, word.String())
+	}
+
+	return ret
+}
+
+// auditEntityIDs returns the IDs of the entities named in the arguments of a
+// mutation, in the order they appear.
+func auditEntityIDs(args map[string]interface{}) []string {
+	var ret []string
+	seen := make(map[string]bool)
+	add := func(v interface{}) {
+		var values []interface{}
+		switch v := v.(type) {
+		case nil:
+			return
+		case []interface{}:
+			values = v
+		case []string:
+			for _, s := range v {
+				values = append(values, s)
+			}
+		default:
+			values = []interface{}{v}
+		}
+
+		for _, id := range values {
+			s := fmt.Sprint(id)
+			if s != "" && !seen[s] {
+				seen[s] = true
+				ret = append(ret, s)
+			}
+		}
+	}
+
+	fromMap := func(m map[string]interface{}) {
+		for _, f := range auditEntityIDFields {
+			add(m[f])
+		}
+	}
+
+	fromMap(args)
+	switch input := args[updateInputField].(type) {
+	case map[string]interface{}:
+		fromMap(input)
+	case []interface{}:
+		for _, i := range input {
+			if m, ok := i.(map[string]interface{}); ok {
+				add(m["id"])
+			}
+		}
+	}
+
+	return ret
+}
+
+// auditResultID returns the ID of the entity returned by a mutation, such as
+// the one it created, or "" if it did not return one.
+func auditResultID(res interface{}) string {
+	v := reflect.ValueOf(res)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+
+	f := v.FieldByName("ID")
+	switch f.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(f.Int(), 10)
+	case reflect.String:
+		return f.String()
+	}
+
+	return ""
+}
+
+// auditChanges returns the fields set by a mutation along with their new
+// values. It is recorded when the changed entities cannot be loaded. The fields of a single input object are returned directly.
+func auditChanges(args map[string]interface{}) map[string]interface{} {
+	if len(args) == 1 {
+		if input, ok := args[updateInputField].(map[string]interface{}); ok {
+			args = input
+		}
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	return auditRedact(args).(map[string]interface{})
+}
+
+// auditRedact returns a copy of v with secrets redacted and long strings
+// truncated.
+func auditRedact(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(v))
+		for k, vv := range v {
+			if isAuditSecret(k) && vv != nil {
+				ret[k] = auditRedacted
+				continue
+			}
+			ret[k] = auditRedact(vv)
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, len(v))
+		for i, vv := range v {
+			ret[i] = auditRedact(vv)
+		}
+		return ret
+	case string:
+		if len(v) > auditMaxValueLength {
+			return fmt.Sprintf("%s… (%d bytes)", strings.ToValidUTF8(v[:auditMaxValueLength], ""), len(v))
+		}
+		return v
+	}
+
+	return v
+}
+
+// isAuditSecret returns true if the named field holds a secret, such as a
+// password or API key
+func isAuditSecret(name string) bool {
+	n := strings.ToLower(strings.ReplaceAll(name, "_", ""))
+	for _, s := range []string{"password", "secret", "apikey", "token"} {
+		if strings.Contains(n, s) {
+			return true
+		}
+	}
+	return false
+}
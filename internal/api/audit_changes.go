@@ -0,0 +1,327 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// auditMaxSnapshots limits the number of entities loaded to record the
+// changes of a single mutation, so that bulk changes stay cheap. The input
+// arguments are recorded for larger changes.
+const auditMaxSnapshots = 100
+
+// auditDiffSkippedFields lists fields that are not compared, as they change
+// whenever an entity is saved.
+var auditDiffSkippedFields = map[string]bool{
+	"updated_at": true,
+}
+
+// auditLoader returns the entity with the given ID, with the relationships
+// recorded in the audit log loaded, or nil if it does not exist.
+type auditLoader func(ctx context.Context, r models.Repository, id int) (interface{}, error)
+
+// auditLoaders maps entity types onto the functions loading them. Changes
+// to other entity types are recorded by their input arguments.
+var auditLoaders = map[string]auditLoader{
+	"scene": func(ctx context.Context, r models.Repository, id int) (interface{}, error) {
+		s, err := r.Scene.Find(ctx, id)
+		if err != nil || s == nil {
+			return nil, err
+		}
+		return s, auditLoadAll(
+			func() error { return s.LoadURLs(ctx, r.Scene) },
+			func() error { return s.LoadGalleryIDs(ctx, r.Scene) },
+			func() error { return s.LoadPerformerIDs(ctx, r.Scene) },
+			func() error { return s.LoadTagIDs(ctx, r.Scene) },
+			func() error { return s.LoadGroups(ctx, r.Scene) },
+			func() error { return s.LoadStashIDs(ctx, r.Scene) },
+		)
+	},
+	"image": func(ctx context.Context, r models.Repository, id int) (interface{}, error) {
+		i, err := r.Image.Find(ctx, id)
+		if err != nil || i == nil {
+			return nil, err
+		}
+		return i, auditLoadAll(
+			func() error { return i.LoadURLs(ctx, r.Image) },
+			func() error { return i.LoadGalleryIDs(ctx, r.Image) },
+			func() error { return i.LoadPerformerIDs(ctx, r.Image) },
+			func() error { return i.LoadTagIDs(ctx, r.Image) },
+		)
+	},
+	"gallery": func(ctx context.Context, r models.Repository, id int) (interface{}, error) {
+		g, err := r.Gallery.Find(ctx, id)
+		if err != nil || g == nil {
+			return nil, err
+		}
+		return g, auditLoadAll(
+			func() error { return g.LoadURLs(ctx, r.Gallery) },
+			func() error { return g.LoadSceneIDs(ctx, r.Gallery) },
+			func() error { return g.LoadPerformerIDs(ctx, r.Gallery) },
+			func() error { return g.LoadTagIDs(ctx, r.Gallery) },
+		)
+	},
+	"performer": func(ctx context.Context, r models.Repository, id int) (interface{}, error) {
+		p, err := r.Performer.Find(ctx, id)
+		if err != nil || p == nil {
+			return nil, err
+		}
+		return p, auditLoadAll(
+			func() error { return p.LoadRelationships(ctx, r.Performer) },
+			func() error { return p.LoadURLs(ctx, r.Performer) },
+		)
+	},
+	"studio": func(ctx context.Context, r models.Repository, id int) (interface{}, error) {
+		s, err := r.Studio.Find(ctx, id)
+		if err != nil || s == nil {
+			return nil, err
+		}
+		return s, auditLoadAll(
+			func() error { return s.LoadAliases(ctx, r.Studio) },
+			func() error { return s.LoadURLs(ctx, r.Studio) },
+			func() error { return s.LoadTagIDs(ctx, r.Studio) },
+			func() error { return s.LoadStashIDs(ctx, r.Studio) },
+		)
+	},
+	"group": auditGroupLoader,
+	"movie": auditGroupLoader,
+	"tag": func(ctx context.Context, r models.Repository, id int) (interface{}, error) {
+		t, err := r.Tag.Find(ctx, id)
+		if err != nil || t == nil {
+			return nil, err
+		}
+		return t, auditLoadAll(
+			func() error { return t.LoadAliases(ctx, r.Tag) },
+			func() error { return t.LoadParentIDs(ctx, r.Tag) },
+			func() error { return t.LoadChildIDs(ctx, r.Tag) },
+			func() error { return t.LoadStashIDs(ctx, r.Tag) },
+		)
+	},
+	"scene_marker": func(ctx context.Context, r models.Repository, id int) (interface{}, error) {
+		return auditFind(r.SceneMarker.Find(ctx, id))
+	},
+	"gallery_chapter": func(ctx context.Context, r models.Repository, id int) (interface{}, error) {
+		return auditFind(r.GalleryChapter.Find(ctx, id))
+	},
+	"user": func(ctx context.Context, r models.Repository, id int) (interface{}, error) {
+		return auditFind(r.User.Find(ctx, id))
+	},
+	"role": func(ctx context.Context, r models.Repository, id int) (interface{}, error) {
+		return auditFind(r.Role.Find(ctx, id))
+	},
+	"api_key": func(ctx context.Context, r models.Repository, id int) (interface{}, error) {
+		return auditFind(r.APIKey.Find(ctx, id))
+	},
+	"playlist": func(ctx context.Context, r models.Repository, id int) (interface{}, error) {
+		return auditFind(r.Playlist.Find(ctx, id))
+	},
+}
+
+func auditGroupLoader(ctx context.Context, r models.Repository, id int) (interface{}, error) {
+	g, err := r.Group.Find(ctx, id)
+	if err != nil || g == nil {
+		return nil, err
+	}
+	return g, auditLoadAll(
+		func() error { return g.LoadURLs(ctx, r.Group) },
+		func() error { return g.LoadTagIDs(ctx, r.Group) },
+		func() error { return g.LoadContainingGroupIDs(ctx, r.Group) },
+	)
+}
+
+// auditFind returns v as an interface, which is nil if v is nil.
+func auditFind[T any](v *T, err error) (interface{}, error) {
+	if err != nil || v == nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func auditLoadAll(fns ...func() error) error {
+	for _, fn := range fns {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// auditSnapshots holds the fields of the entities changed by a mutation,
+// keyed by ID. Entities that do not exist are nil.
+type auditSnapshots map[string]map[string]interface{}
+
+// loadAuditSnapshots returns the fields of the entities of the given type
+// with the given IDs. It returns nil if the entity type cannot be loaded,
+// there are too many entities, or loading fails.
+func loadAuditSnapshots(ctx context.Context, r models.Repository, entityType string, ids []string) auditSnapshots {
+	load := auditLoaders[entityType]
+	if load == nil || len(ids) == 0 || len(ids) > auditMaxSnapshots {
+		return nil
+	}
+
+	ret := make(auditSnapshots, len(ids))
+	if err := r.WithReadTxn(ctx, func(ctx context.Context) error {
+		for _, id := range ids {
+			n, err := strconv.Atoi(id)
+			if err != nil {
+				continue
+			}
+
+			v, err := load(ctx, r, n)
+			if err != nil {
+				return fmt.Errorf("loading %s %d: %w", entityType, n, err)
+			}
+
+			ret[id] = auditFields(v)
+		}
+		return nil
+	}); err != nil {
+		logger.Warnf("Error loading changes for the audit log: %v", err)
+		return nil
+	}
+
+	return ret
+}
+
+// auditFields returns the JSON fields of the entity v, or nil if v is nil.
+// Relationships are included if they have been loaded.
+func auditFields(v interface{}) map[string]interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	ret := make(map[string]interface{})
+	addAuditFields(ret, rv)
+	return ret
+}
+
+func addAuditFields(m map[string]interface{}, rv reflect.Value) {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		fv := rv.Field(i)
+		if f.Anonymous && fv.Kind() == reflect.Struct {
+			addAuditFields(m, fv)
+			continue
+		}
+
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		// relationships are only included if loaded
+		if loaded := fv.MethodByName("Loaded"); loaded.IsValid() {
+			if !loaded.Call(nil)[0].Bool() {
+				continue
+			}
+			fv = fv.MethodByName("List").Call(nil)[0]
+		}
+
+		m[name] = auditValue(fv)
+	}
+}
+
+// auditValue returns v as a JSON value.
+func auditValue(v reflect.Value) interface{} {
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.IsNil() {
+		return nil
+	}
+
+	i := v.Interface()
+	switch i := i.(type) {
+	case time.Time, *time.Time:
+		// marshalled as JSON
+	case fmt.Stringer:
+		// such as dates and enums
+		return i.String()
+	}
+
+	data, err := json.Marshal(i)
+	if err != nil {
+		return fmt.Sprint(i)
+	}
+
+	var ret interface{}
+	if err := json.Unmarshal(data, &ret); err != nil {
+		return string(data)
+	}
+	return ret
+}
+
+// auditDiff returns the fields changed between the before and after
+// snapshots of each entity, with their old and new values, keyed by entity
+// ID. Every field of a removed or created entity is included, so that the
+// entity can be traced after it is deleted.
+func auditDiff(before, after auditSnapshots) map[string]interface{} {
+	ret := make(map[string]interface{})
+
+	diff := func(id string) {
+		old, cur := before[id], after[id]
+		changes := make(map[string]interface{})
+		for _, f := range auditFieldNames(old, cur) {
+			if auditDiffSkippedFields[f] && old != nil && cur != nil {
+				continue
+			}
+
+			o, n := old[f], cur[f]
+			if reflect.DeepEqual(o, n) {
+				continue
+			}
+			changes[f] = map[string]interface{}{
+				"old": o,
+				"new": n,
+			}
+		}
+
+		if len(changes) > 0 {
+			ret[id] = changes
+		}
+	}
+
+	for id := range before {
+		diff(id)
+	}
+	for id := range after {
+		if _, ok := before[id]; !ok {
+			diff(id)
+		}
+	}
+
+	if len(ret) == 0 {
+		return nil
+	}
+	return auditRedact(ret).(map[string]interface{})
+}
+
+func auditFieldNames(a, b map[string]interface{}) []string {
+	var ret []string
+	for k := range a {
+		ret = append(ret, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			ret = append(ret, k)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditEntityType(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"performerMerge", "performer"},
+		{"performersDestroy", "performer"},
+		{"bulkSceneUpdate", "scene"},
+		{"sceneMarkerCreate", "scene_marker"},
+		{"addGalleryImages", "gallery"},
+		{"tagsMerge", "tag"},
+		{"apiKeyRevoke", "api_key"},
+		{"deleteFiles", "file"},
+		{"configureGeneral", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, auditEntityType(tt.name))
+		})
+	}
+}
+
+func TestAuditEntityIDs(t *testing.T) {
+	tests := []struct {
+		name string
+		args map[string]interface{}
+		want []string
+	}{
+		{"id", map[string]interface{}{"id": "1"}, []string{"1"}},
+		{"ids", map[string]interface{}{"ids": []interface{}{"1", "2"}}, []string{"1", "2"}},
+		{
+			"merge",
+			map[string]interface{}{"input": map[string]interface{}{
+				"source":      []interface{}{"2", "3"},
+				"destination": "1",
+			}},
+			[]string{"2", "3", "1"},
+		},
+		{
+			"update list",
+			map[string]interface{}{"input": []interface{}{
+				map[string]interface{}{"id": "4"},
+				map[string]interface{}{"id": "5"},
+			}},
+			[]string{"4", "5"},
+		},
+		{"duplicate", map[string]interface{}{"id": "1", "input": map[string]interface{}{"id": "1"}}, []string{"1"}},
+		{"none", map[string]interface{}{"input": map[string]interface{}{"name": "x"}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, auditEntityIDs(tt.args))
+		})
+	}
+}
+
+func TestAuditChanges(t *testing.T) {
+	long := strings.Repeat("a", auditMaxValueLength+1)
+
+	got := auditChanges(map[string]interface{}{
+		"input": map[string]interface{}{
+			"name":     "Jane",
+			"password": "hunter2",
+			"stash_boxes": []interface{}{
+				map[string]interface{}{"endpoint": "https://stashdb.org", "api_key": "key"},
+			},
+			"image": long,
+		},
+	})
+
+	assert.Equal(t, "Jane", got["name"])
+	assert.Equal(t, auditRedacted, got["password"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"endpoint": "https://stashdb.org", "api_key": auditRedacted},
+	}, got["stash_boxes"])
+	assert.True(t, strings.HasSuffix(got["image"].(string), "(257 bytes)"))
+
+	assert.Nil(t, auditChanges(map[string]interface{}{}))
+}
+
+func TestAuditResultID(t *testing.T) {
+	assert.Equal(t, "7", auditResultID(&models.Performer{ID: 7}))
+	assert.Equal(t, "", auditResultID(true))
+	assert.Equal(t, "", auditResultID((*models.Performer)(nil)))
+}
+
+func TestAuditFields(t *testing.T) {
+	date, _ := models.ParseDate("2001-02-03")
+	got := auditFields(&models.Performer{
+		ID:        1,
+		Name:      "Jane",
+		Birthdate: &date,
+		Aliases:   models.NewRelatedStrings([]string{"J"}),
+	})
+
+	assert.Equal(t, "Jane", got["name"])
+	assert.Equal(t, "2001-02-03", got["birthdate"])
+	assert.Nil(t, got["gender"])
+	assert.Equal(t, []interface{}{"J"}, got["aliases"])
+	// relationships that are not loaded are omitted
+	assert.NotContains(t, got, "tag_ids")
+
+	assert.Nil(t, auditFields((*models.Performer)(nil)))
+}
+
+func TestAuditDiff(t *testing.T) {
+	before := auditSnapshots{
+		"1": {"name": "Jane", "rating": 20.0, "updated_at": "a"},
+		"2": {"name": "Janet", "password": "x"},
+		"3": nil,
+	}
+	after := auditSnapshots{
+		"1": {"name": "Jane", "rating": 40.0, "updated_at": "b"},
+		"2": nil,
+		"3": nil,
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"1": map[string]interface{}{
+			"rating": map[string]interface{}{"old": 20.0, "new": 40.0},
+		},
+		// removed entities are recorded whole
+		"2": map[string]interface{}{
+			"name":     map[string]interface{}{"old": "Janet", "new": nil},
+			"password": auditRedacted,
+		},
+	}, auditDiff(before, after))
+
+	assert.Nil(t, auditDiff(after, after))
+}
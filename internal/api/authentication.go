@@ -11,6 +11,7 @@ import (
 	"github.com/stashapp/stash/internal/manager"
 	"github.com/stashapp/stash/internal/manager/config"
 	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/session"
 )
 
@@ -40,7 +41,7 @@ func authenticateHandler() func(http.Handler) http.Handler {
 				return
 			}
 
-			userID, err := manager.GetInstance().SessionStore.Authenticate(w, r)
+			userID, userInfo, err := manager.GetInstance().SessionStore.Authenticate(w, r)
 			if err != nil {
 				if !errors.Is(err, session.ErrUnauthorized) {
 					http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -115,6 +116,53 @@ func authenticateHandler() func(http.Handler) http.Handler {
 
 			ctx = session.SetCurrentUserID(ctx, userID)
 
+			// scope watch history, resume points, O-counts and ratings to the
+			// user. Sessions and named API keys have loaded the user already.
+			if userInfo == nil {
+				userInfo, err = manager.GetInstance().SessionStore.FindUserInfo(ctx, userID)
+				if err != nil {
+					logger.Errorf("Error looking up user %q: %v", userID, err)
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+			}
+			if userInfo != nil {
+				ctx = session.SetCurrentUserInfo(ctx, userInfo)
+				ctx = models.WithUserID(ctx, userInfo.ID)
+
+				// limit the scenes the user can reach, including by ID or stream URL
+				ctx, err = manager.GetInstance().ContentRestrictions().Apply(ctx, userInfo.ID)
+				if err != nil {
+					logger.Errorf("Error applying content restriction for user %q: %v", userID, err)
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+
+				// limit mutations and route groups to the user's role
+				ctx, err = manager.GetInstance().Permissions().Apply(ctx, userInfo.ID)
+				if err != nil {
+					logger.Errorf("Error resolving permissions for user %q: %v", userID, err)
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+			}
+
+			// named API keys only reach the routes their scopes cover
+			if userInfo != nil && userInfo.APIKeyScopes != nil {
+				scopes := userInfo.APIKeyScopes
+				keyScopes := make(models.APIKeyScopes, len(scopes))
+				for i, s := range scopes {
+					keyScopes[i] = models.APIKeyScope(s)
+				}
+
+				if !apiKeyScopeAllows(keyScopes, r) {
+					http.Error(w, ErrNotAuthorized.Error(), http.StatusForbidden)
+					return
+				}
+
+				ctx = models.WithAPIKeyScopes(ctx, keyScopes)
+			}
+
 			r = r.WithContext(ctx)
 
 			next.ServeHTTP(w, r)
@@ -3,6 +3,8 @@ package api
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"strings"
 
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/session"
@@ -106,110 +108,106 @@ func RequireAdmin(ctx context.Context, userRepo models.UserReader) error {
 	return RequireRole(ctx, models.UserRoleAdmin, userRepo)
 }
 
-// CanModify checks if the current user can modify content
-func CanModify(ctx context.Context, userRepo models.UserReader) (bool, error) {
-	user, err := GetCurrentUserFromContext(ctx, userRepo)
-	if err != nil {
-		return false, err
-	}
-	if user == nil {
-		return false, nil
+// HasPermission returns true if the current request holds all of the given
+// permissions. Requests that are not limited to a set of permissions, such as
+// those made in single-user mode, hold every permission.
+func HasPermission(ctx context.Context, permissions ...models.Permission) bool {
+	granted, limited := models.PermissionsFromContext(ctx)
+	if !limited {
+		return true
 	}
-	return user.CanModify(), nil
-}
 
-// CanDelete checks if the current user can delete content
-func CanDelete(ctx context.Context, userRepo models.UserReader) (bool, error) {
-	user, err := GetCurrentUserFromContext(ctx, userRepo)
-	if err != nil {
-		return false, err
-	}
-	if user == nil {
-		return false, nil
-	}
-	return user.CanDelete(), nil
+	return granted.Has(permissions...)
 }
 
-// CanManageUsers checks if the current user can manage other users
-func CanManageUsers(ctx context.Context, userRepo models.UserReader) (bool, error) {
-	user, err := GetCurrentUserFromContext(ctx, userRepo)
-	if err != nil {
-		return false, err
-	}
-	if user == nil {
-		return false, nil
+// RequirePermission ensures the current request holds all of the given
+// permissions
+func RequirePermission(ctx context.Context, permissions ...models.Permission) error {
+	if !HasPermission(ctx, permissions...) {
+		return ErrNotAuthorized
 	}
-	return user.CanManageUsers(), nil
+	return nil
 }
 
-// CanRunTasks checks if the current user can run background tasks
-func CanRunTasks(ctx context.Context, userRepo models.UserReader) (bool, error) {
-	user, err := GetCurrentUserFromContext(ctx, userRepo)
-	if err != nil {
-		return false, err
-	}
-	if user == nil {
-		return false, nil
+// requirePermission returns a middleware that rejects requests not holding all
+// of the given permissions with 403 Forbidden
+func requirePermission(permissions ...models.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !HasPermission(r.Context(), permissions...) {
+				http.Error(w, ErrNotAuthorized.Error(), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
 	}
-	return user.CanRunTasks(), nil
 }
 
-// CanModifySettings checks if the current user can modify system settings
-func CanModifySettings(ctx context.Context, userRepo models.UserReader) (bool, error) {
-	user, err := GetCurrentUserFromContext(ctx, userRepo)
-	if err != nil {
-		return false, err
-	}
-	if user == nil {
-		return false, nil
-	}
-	return user.CanModifySettings(), nil
+// apiKeyStreamPrefixes lists the route groups a stream-only API key may fetch
+// from
+var apiKeyStreamPrefixes = []string{
+	"/scene/",
+	"/image/",
+	"/gallery/",
+	"/performer/",
+	"/studio/",
+	"/group/",
+	"/tag/",
 }
 
-// RequireModifyPermission ensures the current user can modify content
-func RequireModifyPermission(ctx context.Context, userRepo models.UserReader) error {
-	can, err := CanModify(ctx, userRepo)
-	if err != nil {
-		return err
+// apiKeyScopeAllows returns true if an API key holding scopes may make the
+// request. Read-only keys may query GraphQL, and the middleware rejects any
+// mutation they attempt.
+func apiKeyScopeAllows(scopes models.APIKeyScopes, r *http.Request) bool {
+	if scopes.Has(models.APIKeyScopeFull) {
+		return true
 	}
-	if !can {
-		return ErrNotAuthorized
+
+	p := r.URL.Path
+	readOnly := r.Method == http.MethodGet || r.Method == http.MethodHead
+
+	switch {
+	case p == gqlEndpoint:
+		return scopes.Has(models.APIKeyScopeReadOnly)
+	case p == "/handy" || strings.HasPrefix(p, "/handy/"):
+		return scopes.Has(models.APIKeyScopeHandy)
+	case isIPTVPath(p):
+		// a tuner channel scan only rebuilds the lineup
+		return (readOnly || isHDHRScanPath(p)) && scopes.Has(models.APIKeyScopeIPTV)
 	}
-	return nil
-}
 
-// RequireDeletePermission ensures the current user can delete content
-func RequireDeletePermission(ctx context.Context, userRepo models.UserReader) error {
-	can, err := CanDelete(ctx, userRepo)
-	if err != nil {
-		return err
+	if !readOnly {
+		return false
 	}
-	if !can {
-		return ErrNotAuthorized
+
+	for _, prefix := range apiKeyStreamPrefixes {
+		if strings.HasPrefix(p, prefix) {
+			if scopes.Has(models.APIKeyScopeStream) {
+				return true
+			}
+
+			// the XMLTV guide links to scene screenshots as programme art
+			return scopes.Has(models.APIKeyScopeIPTV) && prefix == "/scene/" && strings.HasSuffix(p, "/screenshot")
+		}
 	}
-	return nil
+
+	return false
 }
 
-// RequireTaskPermission ensures the current user can run tasks
-func RequireTaskPermission(ctx context.Context, userRepo models.UserReader) error {
-	can, err := CanRunTasks(ctx, userRepo)
-	if err != nil {
-		return err
-	}
-	if !can {
-		return ErrNotAuthorized
-	}
-	return nil
+// isIPTVPath returns true for the M3U/EPG routes, the Xtream Codes API and
+// the HDHomeRun tuner
+func isIPTVPath(p string) bool {
+	return p == "/iptv" || strings.HasPrefix(p, "/iptv/") ||
+		p == iptvXtreamPlayerAPIPath || p == iptvXtreamXMLTVPath ||
+		strings.HasPrefix(p, iptvXtreamSeriesPathPrefix) ||
+		strings.HasPrefix(p, iptvXtreamLivePathPrefix) ||
+		p == iptvHDHRPathPrefix || strings.HasPrefix(p, iptvHDHRPathPrefix+"/") ||
+		p == "/discover.json"
 }
 
-// RequireSettingsPermission ensures the current user can modify settings
-func RequireSettingsPermission(ctx context.Context, userRepo models.UserReader) error {
-	can, err := CanModifySettings(ctx, userRepo)
-	if err != nil {
-		return err
-	}
-	if !can {
-		return ErrNotAuthorized
-	}
-	return nil
+// isHDHRScanPath returns true for the tuner's channel scan, the one IPTV
+// route that media servers POST to
+func isHDHRScanPath(p string) bool {
+	return strings.HasPrefix(p, iptvHDHRPathPrefix+"/") && strings.HasSuffix(p, "/lineup.post")
 }
@@ -24,6 +24,7 @@
 //go:generate go run github.com/vektah/dataloaden ScenePerformersLoader int []int
 //go:generate go run github.com/vektah/dataloaden SceneGalleryIDsLoader int []int
 //go:generate go run github.com/vektah/dataloaden SceneStashIDsLoader int []github.com/stashapp/stash/pkg/models.StashID
+//go:generate go run github.com/vektah/dataloaden UserDataLoader int *github.com/stashapp/stash/pkg/models.UserData
 package loaders
 
 import (
@@ -62,6 +63,10 @@ type Loaders struct {
 	SceneGalleryIDs *SceneGalleryIDsLoader
 	SceneStashIDs   *SceneStashIDsLoader
 
+	SceneUserData   *UserDataLoader
+	ImageUserData   *UserDataLoader
+	GalleryUserData *UserDataLoader
+
 	ImageFiles   *RelatedFileIDsLoader
 	GalleryFiles *RelatedFileIDsLoader
 
@@ -226,6 +231,21 @@ func (m Middleware) Middleware(next http.Handler) http.Handler {
 				maxBatch: maxBatch,
 				fetch:    m.fetchScenesStashIDs(ctx),
 			},
+			SceneUserData: &UserDataLoader{
+				wait:     wait,
+				maxBatch: maxBatch,
+				fetch:    m.fetchUserData(ctx, m.Repository.Scene),
+			},
+			ImageUserData: &UserDataLoader{
+				wait:     wait,
+				maxBatch: maxBatch,
+				fetch:    m.fetchUserData(ctx, m.Repository.Image),
+			},
+			GalleryUserData: &UserDataLoader{
+				wait:     wait,
+				maxBatch: maxBatch,
+				fetch:    m.fetchUserData(ctx, m.Repository.Gallery),
+			},
 		}
 
 		newCtx := context.WithValue(r.Context(), loadersCtxKey, ldrs)
@@ -248,6 +268,13 @@ func toErrorSlice(err error) []error {
 func (m Middleware) fetchScenes(ctx context.Context) func(keys []int) ([]*models.Scene, []error) {
 	return func(keys []int) (ret []*models.Scene, errs []error) {
 		err := m.Repository.WithDB(ctx, func(ctx context.Context) error {
+			if models.SceneRestrictionFromContext(ctx) != nil {
+				// scenes hidden from the user are loaded as nil
+				var err error
+				ret, err = findVisibleScenes(ctx, m.Repository.Scene, keys)
+				return err
+			}
+
 			var err error
 			ret, err = m.Repository.Scene.FindMany(ctx, keys)
 			return err
@@ -256,6 +283,25 @@ func (m Middleware) fetchScenes(ctx context.Context) func(keys []int) ([]*models
 	}
 }
 
+func findVisibleScenes(ctx context.Context, r models.SceneReader, keys []int) ([]*models.Scene, error) {
+	found, err := r.FindByIDs(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]*models.Scene, len(found))
+	for _, s := range found {
+		byID[s.ID] = s
+	}
+
+	ret := make([]*models.Scene, len(keys))
+	for i, id := range keys {
+		ret[i] = byID[id]
+	}
+
+	return ret, nil
+}
+
 func (m Middleware) fetchSceneIDsByFileID(ctx context.Context) func(keys []models.FileID) ([][]int, []error) {
 	return func(keys []models.FileID) (ret [][]int, errs []error) {
 		err := m.Repository.WithDB(ctx, func(ctx context.Context) error {
@@ -545,3 +591,14 @@ func (m Middleware) fetchScenesStashIDs(ctx context.Context) func(keys []int) ([
 		return ret, toErrorSlice(err)
 	}
 }
+
+func (m Middleware) fetchUserData(ctx context.Context, l models.UserDataLoader) func(keys []int) ([]*models.UserData, []error) {
+	return func(keys []int) (ret []*models.UserData, errs []error) {
+		err := m.Repository.WithDB(ctx, func(ctx context.Context) error {
+			var err error
+			ret, err = l.GetManyUserData(ctx, keys)
+			return err
+		})
+		return ret, toErrorSlice(err)
+	}
+}
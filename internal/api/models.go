@@ -104,6 +104,12 @@ type FindPlaylistsResultType struct {
 	Playlists []*models.Playlist `json:"playlists"`
 }
 
+// FindAuditEntriesResultType is the result type for the findAuditEntries query
+type FindAuditEntriesResultType struct {
+	Count        int                  `json:"count"`
+	AuditEntries []*models.AuditEntry `json:"audit_entries"`
+}
+
 // LibraryPathValidationResult is the result of validating a library path
 type LibraryPathValidationResult struct {
 	// Whether the path is valid and accessible
@@ -2,151 +2,335 @@ package api
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/99designs/gqlgen/graphql"
 	"github.com/stashapp/stash/pkg/models"
-	"github.com/stashapp/stash/pkg/session"
-	"github.com/stashapp/stash/pkg/txn"
 	"github.com/vektah/gqlparser/v2/ast"
 	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
-// viewerAllowedMutations lists mutations that viewers can access
+// viewerAllowedMutations lists mutations that every user can access
 var viewerAllowedMutations = map[string]bool{
 	// Self-service account operations
 	"changeOwnPassword":   true,
 	"regenerateOwnAPIKey": true,
+	"apiKeyCreate":        true,
+	"apiKeyRevoke":        true,
+
+	// Per-user activity. Play history, resume points and O-counters are
+	// recorded for the current user only
+	"sceneSaveActivity":       true,
+	"sceneResetActivity":      true,
+	"sceneIncrementPlayCount": true,
+	"sceneAddPlay":            true,
+	"sceneDeletePlay":         true,
+	"sceneResetPlayCount":     true,
+	"sceneIncrementO":         true,
+	"sceneDecrementO":         true,
+	"sceneAddO":               true,
+	"sceneDeleteO":            true,
+	"sceneResetO":             true,
+	"imageIncrementO":         true,
+	"imageDecrementO":         true,
+	"imageResetO":             true,
+
+	// Sync targets. Users only reach the sync targets they created
+	"syncTargetCreate":      true,
+	"syncTargetUpdate":      true,
+	"syncTargetDestroy":     true,
+	"syncTargetUploadState": true,
 
 	// Login/logout (handled by session, not permission-guarded)
 	// Note: actual login/logout is handled by HTTP handlers, not GraphQL
 }
 
-// adminOnlyMutations lists mutations that require admin role.
-// This is kept for documentation purposes - the middleware logic blocks
-// all non-viewerAllowedMutations for viewer users, so admins get all others.
-var _ = map[string]bool{
-	// User management
-	"userCreate":           true,
-	"userUpdate":           true,
-	"userDestroy":          true,
-	"userRegenerateAPIKey": true,
-	"sessionDestroy":       true,
-	"sessionDestroyByUser": true,
-
-	// System configuration
-	"configureGeneral":    true,
-	"configureInterface":  true,
-	"configureDefaults":   true,
-	"configureScraping":   true,
-	"configureDLNA":       true,
-	"configureUI":         true,
-	"generateAPIKey":      true,
-	"enableDLNA":          true,
-	"disableDLNA":         true,
-	"addTempDLNAIP":       true,
-	"removeTempDLNAIP":    true,
-	"setDefaultFilter":    true,
-	"deleteDefaultFilter": true,
-
-	// Dangerous operations
-	"shutdown":          true,
-	"stopAllJobs":       true,
-	"migrate":           true,
-	"backup":            true,
-	"anonymiseDatabase": true,
-
-	// Plugin management
-	"reloadPlugins":      true,
-	"runPluginTask":      true,
-	"runPluginOperation": true,
-	"setPluginsEnabled":  true,
-	"installPackages":    true,
-	"uninstallPackages":  true,
-	"updatePackages":     true,
-
-	// Scraper management
-	"reloadScrapers": true,
-
-	// System tasks
-	"metadataScan":              true,
-	"metadataIdentify":          true,
-	"metadataAutoTag":           true,
-	"metadataClean":             true,
-	"metadataCleanGenerated":    true,
-	"metadataGenerate":          true,
-	"metadataExport":            true,
-	"exportObjects":             true,
-	"importObjects":             true,
-	"stashBoxBatchPerformerTag": true,
-	"stashBoxBatchStudioTag":    true,
-	"migrateHashNaming":         true,
-	"migrateSceneScreenshots":   true,
-	"migrateBlobs":              true,
-	"optimiseDatabase":          true,
-
-	// Scene-level generation/modification
-	"sceneGenerateScreenshot": true,
-	"sceneGenerateGallery":    true,
-
-	// Job control
-	"stopJob": true,
-
-	// File operations
-	"moveFiles":   true,
-	"deleteFiles": true,
+// mutationPermissions lists the mutations that non-admin users can be granted
+// through a custom role, along with the permissions they require. Mutations
+// that are neither listed here nor in viewerAllowedMutations require the
+// admin role.
+var mutationPermissions = map[string][]models.Permission{
+	// Metadata editing
+	"sceneCreate":                  {models.PermissionEditMetadata},
+	"sceneUpdate":                  {models.PermissionEditMetadata},
+	"sceneMerge":                   {models.PermissionEditMetadata},
+	"bulkSceneUpdate":              {models.PermissionEditMetadata},
+	"scenesUpdate":                 {models.PermissionEditMetadata},
+	"sceneDestroy":                 {models.PermissionEditMetadata},
+	"scenesDestroy":                {models.PermissionEditMetadata},
+	"sceneAssignFile":              {models.PermissionEditMetadata},
+	"sceneDetectFunscripts":        {models.PermissionEditMetadata},
+	"sceneMarkerCreate":            {models.PermissionEditMetadata},
+	"sceneMarkerUpdate":            {models.PermissionEditMetadata},
+	"bulkSceneMarkerUpdate":        {models.PermissionEditMetadata},
+	"sceneMarkerDestroy":           {models.PermissionEditMetadata},
+	"sceneMarkersDestroy":          {models.PermissionEditMetadata},
+	"imageUpdate":                  {models.PermissionEditMetadata},
+	"bulkImageUpdate":              {models.PermissionEditMetadata},
+	"imagesUpdate":                 {models.PermissionEditMetadata},
+	"imageDestroy":                 {models.PermissionEditMetadata},
+	"imagesDestroy":                {models.PermissionEditMetadata},
+	"galleryCreate":                {models.PermissionEditMetadata},
+	"galleryUpdate":                {models.PermissionEditMetadata},
+	"bulkGalleryUpdate":            {models.PermissionEditMetadata},
+	"galleriesUpdate":              {models.PermissionEditMetadata},
+	"galleryDestroy":               {models.PermissionEditMetadata},
+	"addGalleryImages":             {models.PermissionEditMetadata},
+	"removeGalleryImages":          {models.PermissionEditMetadata},
+	"setGalleryCover":              {models.PermissionEditMetadata},
+	"resetGalleryCover":            {models.PermissionEditMetadata},
+	"galleryChapterCreate":         {models.PermissionEditMetadata},
+	"galleryChapterUpdate":         {models.PermissionEditMetadata},
+	"galleryChapterDestroy":        {models.PermissionEditMetadata},
+	"performerCreate":              {models.PermissionEditMetadata},
+	"performersCreate":             {models.PermissionEditMetadata},
+	"performerUpdate":              {models.PermissionEditMetadata},
+	"performerDestroy":             {models.PermissionEditMetadata},
+	"performersDestroy":            {models.PermissionEditMetadata},
+	"bulkPerformerUpdate":          {models.PermissionEditMetadata},
+	"performerMerge":               {models.PermissionEditMetadata},
+	"studioCreate":                 {models.PermissionEditMetadata},
+	"studiosCreate":                {models.PermissionEditMetadata},
+	"studioUpdate":                 {models.PermissionEditMetadata},
+	"studioDestroy":                {models.PermissionEditMetadata},
+	"studiosDestroy":               {models.PermissionEditMetadata},
+	"bulkStudioUpdate":             {models.PermissionEditMetadata},
+	"movieCreate":                  {models.PermissionEditMetadata},
+	"movieUpdate":                  {models.PermissionEditMetadata},
+	"movieDestroy":                 {models.PermissionEditMetadata},
+	"moviesDestroy":                {models.PermissionEditMetadata},
+	"bulkMovieUpdate":              {models.PermissionEditMetadata},
+	"groupCreate":                  {models.PermissionEditMetadata},
+	"groupUpdate":                  {models.PermissionEditMetadata},
+	"groupDestroy":                 {models.PermissionEditMetadata},
+	"groupsDestroy":                {models.PermissionEditMetadata},
+	"bulkGroupUpdate":              {models.PermissionEditMetadata},
+	"addGroupSubGroups":            {models.PermissionEditMetadata},
+	"removeGroupSubGroups":         {models.PermissionEditMetadata},
+	"reorderSubGroups":             {models.PermissionEditMetadata},
+	"tagCreate":                    {models.PermissionEditMetadata},
+	"tagsCreate":                   {models.PermissionEditMetadata},
+	"tagUpdate":                    {models.PermissionEditMetadata},
+	"tagDestroy":                   {models.PermissionEditMetadata},
+	"tagsDestroy":                  {models.PermissionEditMetadata},
+	"tagsMerge":                    {models.PermissionEditMetadata},
+	"bulkTagUpdate":                {models.PermissionEditMetadata},
+	"fileSetFingerprints":          {models.PermissionEditMetadata},
+	"potentialSceneCreate":         {models.PermissionEditMetadata},
+	"potentialSceneDestroy":        {models.PermissionEditMetadata},
+	"submitStashBoxFingerprints":   {models.PermissionEditMetadata},
+	"submitStashBoxSceneDraft":     {models.PermissionEditMetadata},
+	"submitStashBoxPerformerDraft": {models.PermissionEditMetadata},
+
+	// File deletion
+	"deleteFiles":  {models.PermissionDeleteFiles},
+	"destroyFiles": {models.PermissionDeleteFiles},
+
+	// Library jobs
+	"metadataScan":                  {models.PermissionRunJobs},
+	"scanFile":                      {models.PermissionRunJobs},
+	"metadataGenerate":              {models.PermissionRunJobs},
+	"metadataAutoTag":               {models.PermissionRunJobs},
+	"metadataClean":                 {models.PermissionRunJobs},
+	"metadataCleanGenerated":        {models.PermissionRunJobs},
+	"generateOptimizedVersions":     {models.PermissionRunJobs},
+	"cleanOptimizedVersions":        {models.PermissionRunJobs},
+	"metadataIdentify":              {models.PermissionRunJobs},
+	"sceneGenerateScreenshot":       {models.PermissionRunJobs},
+	"sceneGenerateGallery":          {models.PermissionRunJobs},
+	"sceneDestroyGenerated":         {models.PermissionRunJobs},
+	"generatePhash":                 {models.PermissionRunJobs},
+	"stashBoxBatchPerformerTag":     {models.PermissionRunJobs},
+	"stashBoxBatchStudioTag":        {models.PermissionRunJobs},
+	"stashTagBatchAnalyze":          {models.PermissionRunJobs},
+	"stashTagClearJobResult":        {models.PermissionRunJobs},
+	"startScrapePerformerScenesJob": {models.PermissionRunJobs},
+	"startScrapeStudioScenesJob":    {models.PermissionRunJobs},
+	"scheduledTaskCreate":           {models.PermissionRunJobs},
+	"scheduledTaskUpdate":           {models.PermissionRunJobs},
+	"scheduledTaskDestroy":          {models.PermissionRunJobs},
+	"scheduledTaskRun":              {models.PermissionRunJobs},
+	"rebuildCoWatchModel":           {models.PermissionRunJobs},
+	"generateVisualEmbeddings":      {models.PermissionRunJobs},
+	"stopJob":                       {models.PermissionRunJobs},
+	"stopAllJobs":                   {models.PermissionRunJobs},
+	"pauseJob":                      {models.PermissionRunJobs},
+	"resumeJob":                     {models.PermissionRunJobs},
+	"setJobPriority":                {models.PermissionRunJobs},
+	"moveJob":                       {models.PermissionRunJobs},
+	"syncTargetPrepare":             {models.PermissionRunJobs},
+
+	// Plugin management. configurePlugin is resolved by fieldPermissions
+	"reloadPlugins":      {models.PermissionManagePlugins},
+	"runPluginTask":      {models.PermissionManagePlugins},
+	"runPluginOperation": {models.PermissionManagePlugins},
+	"setPluginsEnabled":  {models.PermissionManagePlugins},
+	"installPackages":    {models.PermissionManagePlugins},
+	"uninstallPackages":  {models.PermissionManagePlugins},
+	"updatePackages":     {models.PermissionManagePlugins},
+
+	// IPTV
+	"iptvChannelCreate":  {models.PermissionManageIPTV},
+	"iptvChannelUpdate":  {models.PermissionManageIPTV},
+	"iptvChannelDestroy": {models.PermissionManageIPTV},
+
+	// Recycle bin
+	"restoreRecycleBinEntry": {models.PermissionViewRecycleBin, models.PermissionEditMetadata},
+	"purgeRecycleBinEntry":   {models.PermissionViewRecycleBin, models.PermissionDeleteFiles},
+	"purgeRecycleBin":        {models.PermissionViewRecycleBin, models.PermissionDeleteFiles},
 }
 
-// MutationMiddleware creates a gqlgen middleware that checks user permissions
-// before allowing mutation execution
-func MutationMiddleware(userRepo models.UserReader, txnMgr models.TxnManager) graphql.OperationMiddleware {
-	return func(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
-		oc := graphql.GetOperationContext(ctx)
+// ratingMutations lists the update mutations that only change the current
+// user's rating when their input sets nothing but the rating.
+var ratingMutations = map[string]bool{
+	"sceneUpdate":   true,
+	"imageUpdate":   true,
+	"galleryUpdate": true,
+}
 
-		// Only check mutations
-		if oc.Operation == nil || oc.Operation.Operation != ast.Mutation {
-			return next(ctx)
+// ratingInputFields are the input fields that a rating-only update may set
+var ratingInputFields = map[string]bool{
+	"clientMutationId": true,
+	"id":               true,
+	"rating100":        true,
+}
+
+// isRatingOnly returns true if the input of an update mutation only sets the
+// rating.
+func isRatingOnly(field *ast.Field, vars map[string]interface{}) bool {
+	input, ok := argumentValue(field, "input", vars).(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	for k := range input {
+		if !ratingInputFields[k] {
+			return false
 		}
+	}
+	return true
+}
 
-		// Get current user
-		userID := session.GetCurrentUserID(ctx)
+// deleteFileMutations lists the destroy mutations that also require the
+// delete files permission when their input asks for the files to be deleted.
+var deleteFileMutations = map[string]bool{
+	"sceneDestroy":   true,
+	"scenesDestroy":  true,
+	"imageDestroy":   true,
+	"imagesDestroy":  true,
+	"galleryDestroy": true,
+}
 
-		// If no user is logged in, let the existing auth middleware handle it
-		if userID == nil {
-			return next(ctx)
+// queryPermissions lists the queries that require a permission. Queries that
+// are not listed are available to every user.
+var queryPermissions = map[string][]models.Permission{
+	"recycleBin":             {models.PermissionViewRecycleBin},
+	"recycleBinCount":        {models.PermissionViewRecycleBin},
+	"recycleBinHistory":      {models.PermissionViewRecycleBin},
+	"recycleBinHistoryCount": {models.PermissionViewRecycleBin},
+	"findIPTVChannel":        {models.PermissionManageIPTV},
+	"findIPTVChannels":       {models.PermissionManageIPTV},
+}
+
+// fieldPermissions returns the permissions required by a top-level field of
+// an operation. It returns false if the field requires the admin role.
+func fieldPermissions(op ast.Operation, field *ast.Field, vars map[string]interface{}) ([]models.Permission, bool) {
+	if op != ast.Mutation {
+		return queryPermissions[field.Name], true
+	}
+
+	if viewerAllowedMutations[field.Name] {
+		return nil, true
+	}
+
+	// ratings are per-user, so rating does not edit the metadata
+	if ratingMutations[field.Name] && isRatingOnly(field, vars) {
+		return nil, true
+	}
+
+	if field.Name == "configurePlugin" {
+		// the IPTV channels are configured through their plugin settings
+		if argumentValue(field, "plugin_id", vars) == iptvPluginID {
+			return []models.Permission{models.PermissionManageIPTV}, true
 		}
+		return []models.Permission{models.PermissionManagePlugins}, true
+	}
 
-		// Look up the user to get their role
-		var user *models.User
-		var findErr error
-		if err := txn.WithDatabase(ctx, txnMgr, func(ctx context.Context) error {
-			user, findErr = userRepo.FindByUsername(ctx, *userID)
-			return findErr
-		}); err != nil || user == nil {
-			// User not found in database, might be legacy single-user mode
-			// Allow the mutation (backward compatibility)
+	ret, found := mutationPermissions[field.Name]
+	if !found {
+		return nil, false
+	}
+
+	if deleteFileMutations[field.Name] {
+		if input, ok := argumentValue(field, "input", vars).(map[string]interface{}); ok && input["delete_file"] == true {
+			ret = append([]models.Permission{models.PermissionDeleteFiles}, ret...)
+		}
+	}
+
+	return ret, true
+}
+
+// argumentValue returns the value of the named argument of field, or nil if
+// it is not set.
+func argumentValue(field *ast.Field, name string, vars map[string]interface{}) interface{} {
+	arg := field.Arguments.ForName(name)
+	if arg == nil {
+		return nil
+	}
+
+	v, err := arg.Value.Value(vars)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+func notAuthorizedResponse(ctx context.Context, message string) graphql.ResponseHandler {
+	return graphql.OneShot(&graphql.Response{
+		Errors: gqlerror.List{{
+			Message: "Not Authorized: " + message,
+			Path:    graphql.GetPath(ctx),
+		}},
+	})
+}
+
+// MutationMiddleware creates a gqlgen middleware that checks the permissions
+// of the current user before executing an operation. Admins are granted every
+// permission; other users are granted the permissions of their custom role.
+func MutationMiddleware() graphql.OperationMiddleware {
+	return func(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+		oc := graphql.GetOperationContext(ctx)
+
+		if oc.Operation == nil || oc.Operation.Operation == ast.Subscription {
 			return next(ctx)
 		}
 
-		// Admin users can do anything
-		if user.IsAdmin() {
+		// named API keys without the full scope are read-only
+		if scopes, ok := models.APIKeyScopesFromContext(ctx); ok && oc.Operation.Operation == ast.Mutation && !scopes.Has(models.APIKeyScopeFull) {
+			return notAuthorizedResponse(ctx, "API key is read-only")
+		}
+
+		// requests without a database user, such as those made in
+		// single-user mode, are not limited
+		granted, limited := models.PermissionsFromContext(ctx)
+		if !limited {
 			return next(ctx)
 		}
 
-		// For viewer users, check each mutation field
-		// Get mutation field names from the operation
-		for _, selection := range oc.Operation.SelectionSet {
-			if field, ok := selection.(*ast.Field); ok {
-				mutationName := field.Name
-
-				// Check if viewer is allowed
-				if !viewerAllowedMutations[mutationName] {
-					// Block viewer from this mutation
-					return graphql.OneShot(&graphql.Response{
-						Errors: gqlerror.List{{
-							Message: "Not Authorized: 'Viewer' users cannot perform this action",
-							Path:    graphql.GetPath(ctx),
-						}},
-					})
+		// fields inside fragments are executed too, so they are collected
+		// the same way the executor does
+		for _, collected := range graphql.CollectFields(oc, oc.Operation.SelectionSet, nil) {
+			field := collected.Field
+
+			required, grantable := fieldPermissions(oc.Operation.Operation, field, oc.Variables)
+			if !grantable {
+				return notAuthorizedResponse(ctx, fmt.Sprintf("'%s' requires the admin role", field.Name))
+			}
+
+			for _, p := range required {
+				if !granted.Has(p) {
+					return notAuthorizedResponse(ctx, fmt.Sprintf("'%s' requires the %s permission", field.Name, strings.ToUpper(p.String())))
 				}
 			}
 		}
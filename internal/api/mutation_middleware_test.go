@@ -0,0 +1,284 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+func TestFieldPermissions(t *testing.T) {
+	variableArg := func(name string) *ast.Argument {
+		return &ast.Argument{
+			Name:  name,
+			Value: &ast.Value{Kind: ast.Variable, Raw: name},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		op            ast.Operation
+		field         *ast.Field
+		vars          map[string]interface{}
+		want          []models.Permission
+		wantGrantable bool
+	}{
+		{
+			"unlisted query",
+			ast.Query,
+			&ast.Field{Name: "findScenes"},
+			nil,
+			nil,
+			true,
+		},
+		{
+			"recycle bin query",
+			ast.Query,
+			&ast.Field{Name: "recycleBin"},
+			nil,
+			[]models.Permission{models.PermissionViewRecycleBin},
+			true,
+		},
+		{
+			"self-service mutation",
+			ast.Mutation,
+			&ast.Field{Name: "changeOwnPassword"},
+			nil,
+			nil,
+			true,
+		},
+		{
+			"admin mutation",
+			ast.Mutation,
+			&ast.Field{Name: "userCreate"},
+			nil,
+			nil,
+			false,
+		},
+		{
+			"metadata mutation",
+			ast.Mutation,
+			&ast.Field{Name: "sceneUpdate"},
+			nil,
+			[]models.Permission{models.PermissionEditMetadata},
+			true,
+		},
+		{
+			"activity mutation",
+			ast.Mutation,
+			&ast.Field{Name: "sceneSaveActivity"},
+			nil,
+			nil,
+			true,
+		},
+		{
+			"rating only update",
+			ast.Mutation,
+			&ast.Field{Name: "sceneUpdate", Arguments: ast.ArgumentList{variableArg("input")}},
+			map[string]interface{}{"input": map[string]interface{}{"id": "1", "rating100": 80}},
+			nil,
+			true,
+		},
+		{
+			"rating and title update",
+			ast.Mutation,
+			&ast.Field{Name: "imageUpdate", Arguments: ast.ArgumentList{variableArg("input")}},
+			map[string]interface{}{"input": map[string]interface{}{"id": "1", "rating100": 80, "title": "x"}},
+			[]models.Permission{models.PermissionEditMetadata},
+			true,
+		},
+		{
+			"destroy keeping files",
+			ast.Mutation,
+			&ast.Field{Name: "sceneDestroy", Arguments: ast.ArgumentList{variableArg("input")}},
+			map[string]interface{}{"input": map[string]interface{}{"id": "1", "delete_file": false}},
+			[]models.Permission{models.PermissionEditMetadata},
+			true,
+		},
+		{
+			"destroy deleting files",
+			ast.Mutation,
+			&ast.Field{Name: "sceneDestroy", Arguments: ast.ArgumentList{variableArg("input")}},
+			map[string]interface{}{"input": map[string]interface{}{"id": "1", "delete_file": true}},
+			[]models.Permission{models.PermissionDeleteFiles, models.PermissionEditMetadata},
+			true,
+		},
+		{
+			"configure iptv plugin",
+			ast.Mutation,
+			&ast.Field{Name: "configurePlugin", Arguments: ast.ArgumentList{variableArg("plugin_id")}},
+			map[string]interface{}{"plugin_id": iptvPluginID},
+			[]models.Permission{models.PermissionManageIPTV},
+			true,
+		},
+		{
+			"configure other plugin",
+			ast.Mutation,
+			&ast.Field{Name: "configurePlugin", Arguments: ast.ArgumentList{variableArg("plugin_id")}},
+			map[string]interface{}{"plugin_id": "other"},
+			[]models.Permission{models.PermissionManagePlugins},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, grantable := fieldPermissions(tt.op, tt.field, tt.vars)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantGrantable, grantable)
+		})
+	}
+}
+
+func runMutationMiddleware(t *testing.T, ctx context.Context, query string, vars map[string]interface{}) *graphql.Response {
+	t.Helper()
+
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		t.Fatalf("parsing query: %v", err)
+	}
+
+	ctx = graphql.WithOperationContext(ctx, &graphql.OperationContext{
+		RawQuery:  query,
+		Doc:       doc,
+		Operation: doc.Operations[0],
+		Variables: vars,
+	})
+
+	next := func(ctx context.Context) graphql.ResponseHandler {
+		return graphql.OneShot(&graphql.Response{Data: []byte(`{}`)})
+	}
+
+	return MutationMiddleware()(ctx, next)(ctx)
+}
+
+func TestMutationMiddleware(t *testing.T) {
+	viewerUser := &models.User{Role: models.UserRoleViewer}
+	viewer := models.WithPermissions(context.Background(), viewerUser.Permissions(nil))
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		query   string
+		vars    map[string]interface{}
+		allowed bool
+	}{
+		{"viewer saves activity", viewer, `mutation { sceneSaveActivity(id: 1, resume_time: 12.5) }`, nil, true},
+		{"viewer adds O", viewer, `mutation { sceneAddO(id: 1) { count } }`, nil, true},
+		{"viewer rates", viewer, `mutation ($input: SceneUpdateInput!) { sceneUpdate(input: $input) { id } }`, map[string]interface{}{"input": map[string]interface{}{"id": "1", "rating100": 60}}, true},
+		{"viewer uploads sync state", viewer, `mutation { syncTargetUploadState(input: {id: 1, scene_ids: [1]}) { id } }`, nil, true},
+		{"viewer prepares sync bundle", viewer, `mutation { syncTargetPrepare(id: 1) }`, nil, false},
+		{"viewer edits", viewer, `mutation { sceneUpdate(input: {id: 1, title: "x"}) { id } }`, nil, false},
+		{"viewer admin mutation", viewer, `mutation { userCreate(input: {username: "x"}) { id } }`, nil, false},
+		{"viewer admin mutation in inline fragment", viewer, `mutation { ... on Mutation { userDestroy(input: {id: 1}) } }`, nil, false},
+		{"viewer admin mutation in fragment spread", viewer, `mutation { ...destroy } fragment destroy on Mutation { userDestroy(input: {id: 1}) }`, nil, false},
+		{"viewer sees recycle bin", viewer, `query { recycleBinCount }`, nil, true},
+		{"viewer query in fragment", viewer, `query { ... on Query { findIPTVChannels { id } } }`, nil, false},
+		{"unlimited admin mutation", context.Background(), `mutation { userCreate(input: {username: "x"}) { id } }`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := runMutationMiddleware(t, tt.ctx, tt.query, tt.vars)
+			assert.Equal(t, tt.allowed, len(resp.Errors) == 0, "errors: %v", resp.Errors)
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	viewerPermissions := (&models.User{Role: models.UserRoleViewer}).Permissions(nil)
+	handler := requirePermission(models.PermissionControlHandy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name        string
+		permissions *models.PermissionSet
+		want        int
+	}{
+		{"unlimited", nil, http.StatusOK},
+		{"granted", &models.PermissionSet{models.PermissionControlHandy: true}, http.StatusOK},
+		{"viewer", &viewerPermissions, http.StatusOK},
+		{"not granted", &models.PermissionSet{models.PermissionEditMetadata: true}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/handy/ws", nil)
+			if tt.permissions != nil {
+				r = r.WithContext(models.WithPermissions(r.Context(), *tt.permissions))
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestAPIKeyScopeAllows(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes models.APIKeyScopes
+		method string
+		path   string
+		want   bool
+	}{
+		{"full", models.APIKeyScopes{models.APIKeyScopeFull}, http.MethodPost, "/graphql", true},
+		{"read-only graphql", models.APIKeyScopes{models.APIKeyScopeReadOnly}, http.MethodPost, "/graphql", true},
+		{"read-only stream", models.APIKeyScopes{models.APIKeyScopeReadOnly}, http.MethodGet, "/scene/1/stream", false},
+		{"stream", models.APIKeyScopes{models.APIKeyScopeStream}, http.MethodGet, "/scene/1/stream.mp4", true},
+		{"stream graphql", models.APIKeyScopes{models.APIKeyScopeStream}, http.MethodPost, "/graphql", false},
+		{"stream post", models.APIKeyScopes{models.APIKeyScopeStream}, http.MethodPost, "/scene/1/stream", false},
+		{"iptv playlist", models.APIKeyScopes{models.APIKeyScopeIPTV}, http.MethodGet, "/iptv/playlist.m3u", true},
+		{"iptv xtream", models.APIKeyScopes{models.APIKeyScopeIPTV}, http.MethodGet, "/series/user/key/1.mp4", true},
+		{"iptv screenshot", models.APIKeyScopes{models.APIKeyScopeIPTV}, http.MethodGet, "/scene/1/screenshot", true},
+		{"iptv stream", models.APIKeyScopes{models.APIKeyScopeIPTV}, http.MethodGet, "/scene/1/stream", false},
+		{"handy", models.APIKeyScopes{models.APIKeyScopeHandy}, http.MethodGet, "/handy/ws", true},
+		{"handy iptv", models.APIKeyScopes{models.APIKeyScopeHandy}, http.MethodGet, "/iptv/playlist.m3u", false},
+		{"ui", models.APIKeyScopes{models.APIKeyScopeStream}, http.MethodGet, "/", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			assert.Equal(t, tt.want, apiKeyScopeAllows(tt.scopes, r))
+		})
+	}
+}
+
+func TestCanAccessSyncTarget(t *testing.T) {
+	owner := 1
+	other := 2
+	owned := &models.SyncTarget{UserID: &owner}
+	unowned := &models.SyncTarget{}
+
+	user := models.WithUserID(models.WithPermissions(context.Background(), models.NewPermissionSet()), owner)
+	otherUser := models.WithUserID(models.WithPermissions(context.Background(), models.NewPermissionSet()), other)
+	admin := models.WithUserID(context.Background(), other)
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		target *models.SyncTarget
+		want   bool
+	}{
+		{"owner", user, owned, true},
+		{"other user", otherUser, owned, false},
+		{"user and unowned target", user, unowned, false},
+		{"admin", admin, owned, true},
+		{"single-user mode", context.Background(), unowned, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, canAccessSyncTarget(tt.ctx, tt.target))
+		})
+	}
+}
@@ -39,6 +39,10 @@ type Resolver struct {
 	groupService   manager.GroupService
 
 	hookExecutor hookExecutor
+
+	// iptv is notified when IPTV channels change, so that the lineup does not
+	// wait out its cache. Nil when IPTV is not being served.
+	iptv *iptvRoutes
 }
 
 func (r *Resolver) scraperCache() *scraper.Cache {
@@ -123,12 +127,39 @@ func (r *Resolver) ContentProfile() ContentProfileResolver {
 func (r *Resolver) Recommendation() RecommendationResolver {
 	return &recommendationResolver{r}
 }
+func (r *Resolver) RecommendationBreakdown() RecommendationBreakdownResolver {
+	return &recommendationBreakdownResolver{r}
+}
 func (r *Resolver) Playlist() PlaylistResolver {
 	return &playlistResolver{r}
 }
 func (r *Resolver) PlaylistItem() PlaylistItemResolver {
 	return &playlistItemResolver{r}
 }
+func (r *Resolver) User() UserResolver {
+	return &userResolver{r}
+}
+func (r *Resolver) ContentRestriction() ContentRestrictionResolver {
+	return &contentRestrictionResolver{r}
+}
+func (r *Resolver) APIKey() APIKeyResolver {
+	return &apiKeyResolver{r}
+}
+func (r *Resolver) AuditEntry() AuditEntryResolver {
+	return &auditEntryResolver{r}
+}
+func (r *Resolver) IPTVChannel() IPTVChannelResolver {
+	return &iptvChannelResolver{r}
+}
+func (r *Resolver) IPTVChannelBlock() IPTVChannelBlockResolver {
+	return &iptvChannelBlockResolver{r}
+}
+func (r *Resolver) SyncTarget() SyncTargetResolver {
+	return &syncTargetResolver{r}
+}
+func (r *Resolver) ScheduledTaskRun() ScheduledTaskRunResolver {
+	return &scheduledTaskRunResolver{r}
+}
 
 type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
@@ -158,6 +189,16 @@ type configResultResolver struct{ *Resolver }
 
 type contentProfileResolver struct{ *Resolver }
 type recommendationResolver struct{ *Resolver }
+type recommendationBreakdownResolver struct{ *Resolver }
+
+type userResolver struct{ *Resolver }
+type contentRestrictionResolver struct{ *Resolver }
+type apiKeyResolver struct{ *Resolver }
+type auditEntryResolver struct{ *Resolver }
+type iptvChannelResolver struct{ *Resolver }
+type iptvChannelBlockResolver struct{ *Resolver }
+type syncTargetResolver struct{ *Resolver }
+type scheduledTaskRunResolver struct{ *Resolver }
 
 func (r *Resolver) withTxn(ctx context.Context, fn func(ctx context.Context) error) error {
 	return r.repository.WithTxn(ctx, fn)
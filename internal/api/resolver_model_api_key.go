@@ -0,0 +1,26 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *apiKeyResolver) Scopes(ctx context.Context, obj *models.APIKey) ([]models.APIKeyScope, error) {
+	return obj.Scopes, nil
+}
+
+func (r *apiKeyResolver) User(ctx context.Context, obj *models.APIKey) (ret *models.User, err error) {
+	if err := r.withReadTxn(ctx, func(ctx context.Context) error {
+		ret, err = r.repository.User.Find(ctx, obj.UserID)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	if ret == nil {
+		return nil, ErrUserNotFound
+	}
+
+	return ret, nil
+}
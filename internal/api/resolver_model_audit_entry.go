@@ -0,0 +1,34 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *auditEntryResolver) User(ctx context.Context, obj *models.AuditEntry) (ret *models.User, err error) {
+	if obj.UserID == nil {
+		return nil, nil
+	}
+
+	if err := r.withReadTxn(ctx, func(ctx context.Context) error {
+		ret, err = r.repository.User.Find(ctx, *obj.UserID)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *auditEntryResolver) EntityType(ctx context.Context, obj *models.AuditEntry) (*string, error) {
+	return nilIfEmpty(obj.EntityType), nil
+}
+
+func (r *auditEntryResolver) Error(ctx context.Context, obj *models.AuditEntry) (*string, error) {
+	return nilIfEmpty(obj.Error), nil
+}
+
+func (r *auditEntryResolver) IPAddress(ctx context.Context, obj *models.AuditEntry) (*string, error) {
+	return nilIfEmpty(obj.IPAddress), nil
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/stashapp/stash/internal/api/loaders"
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/sliceutil"
 )
 
 func fingerprintResolver(fp models.Fingerprints, type_ string) (*string, error) {
@@ -63,7 +64,12 @@ func (r *videoFileResolver) Scenes(ctx context.Context, obj *VideoFile) ([]*mode
 
 	var errs []error
 	ret, errs := loaders.From(ctx).SceneByID.LoadAll(sceneIDs)
-	return ret, firstError(errs)
+	if err := firstError(errs); err != nil {
+		return nil, err
+	}
+
+	// scenes hidden from the user are loaded as nil
+	return sliceutil.Delete(ret, nil), nil
 }
 
 func (r *basicFileResolver) ParentFolder(ctx context.Context, obj *BasicFile) (*models.Folder, error) {
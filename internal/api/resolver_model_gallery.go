@@ -10,6 +10,7 @@ import (
 
 	"github.com/stashapp/stash/pkg/image"
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/sliceutil"
 )
 
 func (r *galleryResolver) getFiles(ctx context.Context, obj *models.Gallery) ([]models.File, error) {
@@ -87,7 +88,19 @@ func (r *galleryResolver) Date(ctx context.Context, obj *models.Gallery) (*strin
 }
 
 func (r *galleryResolver) Rating100(ctx context.Context, obj *models.Gallery) (*int, error) {
-	return obj.Rating, nil
+	if _, ok := models.UserIDFromContext(ctx); !ok {
+		return obj.Rating, nil
+	}
+
+	ud, err := loaders.From(ctx).GalleryUserData.Load(obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	if ud == nil {
+		return nil, nil
+	}
+
+	return ud.Rating, nil
 }
 
 func (r *galleryResolver) Scenes(ctx context.Context, obj *models.Gallery) (ret []*models.Scene, err error) {
@@ -101,7 +114,12 @@ func (r *galleryResolver) Scenes(ctx context.Context, obj *models.Gallery) (ret
 
 	var errs []error
 	ret, errs = loaders.From(ctx).SceneByID.LoadAll(obj.SceneIDs.List())
-	return ret, firstError(errs)
+	if err := firstError(errs); err != nil {
+		return nil, err
+	}
+
+	// scenes hidden from the user are loaded as nil
+	return sliceutil.Delete(ret, nil), nil
 }
 
 func (r *galleryResolver) Studio(ctx context.Context, obj *models.Gallery) (ret *models.Studio, err error) {
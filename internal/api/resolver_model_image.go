@@ -93,10 +93,50 @@ func (r *imageResolver) Galleries(ctx context.Context, obj *models.Image) (ret [
 	return ret, firstError(errs)
 }
 
+// userData returns the current user's data for the image. The second return
+// value is false when the request is not scoped to a user, in which case the
+// image's own values apply.
+func (r *imageResolver) userData(ctx context.Context, obj *models.Image) (*models.UserData, bool, error) {
+	if _, ok := models.UserIDFromContext(ctx); !ok {
+		return nil, false, nil
+	}
+
+	ret, err := loaders.From(ctx).ImageUserData.Load(obj.ID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if ret == nil {
+		ret = &models.UserData{}
+	}
+
+	return ret, true, nil
+}
+
 func (r *imageResolver) Rating100(ctx context.Context, obj *models.Image) (*int, error) {
+	ud, ok, err := r.userData(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return ud.Rating, nil
+	}
+
 	return obj.Rating, nil
 }
 
+func (r *imageResolver) OCounter(ctx context.Context, obj *models.Image) (*int, error) {
+	ud, ok, err := r.userData(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return &ud.OCounter, nil
+	}
+
+	return &obj.OCounter, nil
+}
+
 func (r *imageResolver) Studio(ctx context.Context, obj *models.Image) (ret *models.Studio, err error) {
 	if obj.StudioID == nil {
 		return nil, nil
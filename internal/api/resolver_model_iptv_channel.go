@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *iptvChannelResolver) ID(ctx context.Context, obj *models.IPTVChannel) (string, error) {
+	return strconv.Itoa(obj.ID), nil
+}
+
+func (r *iptvChannelResolver) SourceID(ctx context.Context, obj *models.IPTVChannel) (string, error) {
+	return strconv.Itoa(obj.SourceID), nil
+}
+
+func (r *iptvChannelResolver) LogoPath(ctx context.Context, obj *models.IPTVChannel) (string, error) {
+	baseURL, _ := ctx.Value(BaseURLCtxKey).(string)
+	// the timestamp busts client caches when the logo changes
+	return fmt.Sprintf("%s/iptv/logo/%s.png?t=%d", baseURL, iptvCustomChannelKey(obj.ID), obj.UpdatedAt.Unix()), nil
+}
+
+func (r *iptvChannelResolver) SceneCount(ctx context.Context, obj *models.IPTVChannel) (ret int, err error) {
+	if err := r.withReadTxn(ctx, func(ctx context.Context) error {
+		src, err := iptvCustomSource(ctx, &r.repository, obj.SourceType, obj.SourceID, 0)
+		if errors.Is(err, errIPTVChannelSourceNotFound) {
+			// the channel is off the air until its source is replaced
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		ret, err = iptvSourceSceneCount(ctx, &r.repository, src)
+		return err
+	}); err != nil {
+		return 0, err
+	}
+
+	return ret, nil
+}
+
+func (r *iptvChannelResolver) Blocks(ctx context.Context, obj *models.IPTVChannel) (ret []*models.IPTVChannelBlock, err error) {
+	if err := r.withReadTxn(ctx, func(ctx context.Context) error {
+		ret, err = r.repository.IPTVChannel.GetBlocks(ctx, obj.ID)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *iptvChannelBlockResolver) ID(ctx context.Context, obj *models.IPTVChannelBlock) (string, error) {
+	return strconv.Itoa(obj.ID), nil
+}
+
+func (r *iptvChannelBlockResolver) Start(ctx context.Context, obj *models.IPTVChannelBlock) (string, error) {
+	return formatIPTVBlockTime(obj.StartMinute), nil
+}
+
+func (r *iptvChannelBlockResolver) End(ctx context.Context, obj *models.IPTVChannelBlock) (string, error) {
+	return formatIPTVBlockTime(obj.EndMinute), nil
+}
+
+func (r *iptvChannelBlockResolver) SourceID(ctx context.Context, obj *models.IPTVChannelBlock) (string, error) {
+	return strconv.Itoa(obj.SourceID), nil
+}
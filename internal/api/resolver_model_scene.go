@@ -92,18 +92,75 @@ func (r *sceneResolver) Files(ctx context.Context, obj *models.Scene) ([]*VideoF
 	return ret, nil
 }
 
+// userData returns the current user's data for the scene. The second return
+// value is false when the request is not scoped to a user, in which case the
+// scene's own values apply.
+func (r *sceneResolver) userData(ctx context.Context, obj *models.Scene) (*models.UserData, bool, error) {
+	if _, ok := models.UserIDFromContext(ctx); !ok {
+		return nil, false, nil
+	}
+
+	ret, err := loaders.From(ctx).SceneUserData.Load(obj.ID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if ret == nil {
+		ret = &models.UserData{}
+	}
+
+	return ret, true, nil
+}
+
 func (r *sceneResolver) Rating(ctx context.Context, obj *models.Scene) (*int, error) {
-	if obj.Rating != nil {
-		rating := models.Rating100To5(*obj.Rating)
-		return &rating, nil
+	rating, err := r.Rating100(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	if rating != nil {
+		ret := models.Rating100To5(*rating)
+		return &ret, nil
 	}
 	return nil, nil
 }
 
 func (r *sceneResolver) Rating100(ctx context.Context, obj *models.Scene) (*int, error) {
+	ud, ok, err := r.userData(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return ud.Rating, nil
+	}
+
 	return obj.Rating, nil
 }
 
+func (r *sceneResolver) ResumeTime(ctx context.Context, obj *models.Scene) (*float64, error) {
+	ud, ok, err := r.userData(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return &ud.ResumeTime, nil
+	}
+
+	return &obj.ResumeTime, nil
+}
+
+func (r *sceneResolver) PlayDuration(ctx context.Context, obj *models.Scene) (*float64, error) {
+	ud, ok, err := r.userData(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return &ud.PlayDuration, nil
+	}
+
+	return &obj.PlayDuration, nil
+}
+
 func (r *sceneResolver) Paths(ctx context.Context, obj *models.Scene) (*ScenePathsType, error) {
 	baseURL, _ := ctx.Value(BaseURLCtxKey).(string)
 	config := manager.GetInstance().Config
@@ -346,13 +403,21 @@ func (r *sceneResolver) SceneStreams(ctx context.Context, obj *models.Scene) ([]
 		return nil, err
 	}
 
+	var optimized []*models.OptimizedVersion
+	if err := r.withReadTxn(ctx, func(ctx context.Context) error {
+		optimized, err = r.repository.OptimizedVersion.FindBySceneID(ctx, obj.ID)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
 	config := manager.GetInstance().Config
 
 	baseURL, _ := ctx.Value(BaseURLCtxKey).(string)
 	builder := urlbuilders.NewSceneURLBuilder(baseURL, obj)
 	apiKey := config.GetAPIKey()
 
-	return manager.GetSceneStreamPaths(obj, builder.GetStreamURL(apiKey), config.GetMaxStreamingTranscodeSize())
+	return manager.GetSceneStreamPaths(obj, builder.GetStreamURL(apiKey), config.GetMaxStreamingTranscodeSize(), optimized)
 }
 
 func (r *sceneResolver) Interactive(ctx context.Context, obj *models.Scene) (bool, error) {
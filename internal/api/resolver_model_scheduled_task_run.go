@@ -0,0 +1,27 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *scheduledTaskRunResolver) TaskType(ctx context.Context, obj *models.ScheduledTaskRun) (ScheduledTaskType, error) {
+	return ScheduledTaskType(obj.TaskType), nil
+}
+
+func (r *scheduledTaskRunResolver) Trigger(ctx context.Context, obj *models.ScheduledTaskRun) (ScheduledTaskRunTrigger, error) {
+	return ScheduledTaskRunTrigger(obj.Trigger), nil
+}
+
+func (r *scheduledTaskRunResolver) Status(ctx context.Context, obj *models.ScheduledTaskRun) (ScheduledTaskRunStatus, error) {
+	return ScheduledTaskRunStatus(obj.Status), nil
+}
+
+func (r *scheduledTaskRunResolver) Error(ctx context.Context, obj *models.ScheduledTaskRun) (*string, error) {
+	return nilIfEmpty(obj.Error), nil
+}
+
+func (r *scheduledTaskRunResolver) Duration(ctx context.Context, obj *models.ScheduledTaskRun) (float64, error) {
+	return obj.EndedAt.Sub(obj.StartedAt).Seconds(), nil
+}
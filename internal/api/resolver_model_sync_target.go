@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/stashapp/stash/internal/manager"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *syncTargetResolver) ID(ctx context.Context, obj *models.SyncTarget) (string, error) {
+	return strconv.Itoa(obj.ID), nil
+}
+
+func (r *syncTargetResolver) SourceID(ctx context.Context, obj *models.SyncTarget) (string, error) {
+	return strconv.Itoa(obj.SourceID), nil
+}
+
+func (r *syncTargetResolver) items(ctx context.Context, obj *models.SyncTarget) (ret []*models.SyncTargetItem, err error) {
+	if err := r.withReadTxn(ctx, func(ctx context.Context) error {
+		ret, err = r.repository.SyncTarget.GetItems(ctx, obj.ID)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *syncTargetResolver) SceneCount(ctx context.Context, obj *models.SyncTarget) (int, error) {
+	items, err := r.items(ctx, obj)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(items), nil
+}
+
+func (r *syncTargetResolver) Size(ctx context.Context, obj *models.SyncTarget) (int64, error) {
+	items, err := r.items(ctx, obj)
+	if err != nil {
+		return 0, err
+	}
+
+	var ret int64
+	for _, i := range items {
+		ret += i.Size
+	}
+	return ret, nil
+}
+
+func (r *syncTargetResolver) BundleURL(ctx context.Context, obj *models.SyncTarget) (*string, error) {
+	// the URL is all that is needed to download the bundle
+	if !canAccessSyncTarget(ctx, obj) {
+		return nil, nil
+	}
+
+	p := manager.GetInstance().SyncBundles.Get(obj.ID)
+	if p == "" {
+		return nil, nil
+	}
+
+	baseURL, _ := ctx.Value(BaseURLCtxKey).(string)
+	ret := baseURL + "/downloads/" + p
+	return &ret, nil
+}
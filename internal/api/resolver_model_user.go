@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash/internal/api/loaders"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *userResolver) CustomRole(ctx context.Context, obj *models.User) (ret *models.Role, err error) {
+	if obj.CustomRoleID == nil {
+		return nil, nil
+	}
+
+	if err := r.withReadTxn(ctx, func(ctx context.Context) error {
+		ret, err = r.repository.Role.Find(ctx, *obj.CustomRoleID)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *userResolver) ContentRestriction(ctx context.Context, obj *models.User) (*models.ContentRestriction, error) {
+	var ret *models.ContentRestriction
+	if err := r.withReadTxn(ctx, func(ctx context.Context) error {
+		var err error
+		ret, err = r.repository.User.GetContentRestriction(ctx, obj.ID)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	if ret.IsEmpty() {
+		return nil, nil
+	}
+
+	return ret, nil
+}
+
+func (r *contentRestrictionResolver) SavedFilter(ctx context.Context, obj *models.ContentRestriction) (ret *models.SavedFilter, err error) {
+	if obj.SavedFilterID == nil {
+		return nil, nil
+	}
+
+	if err := r.withReadTxn(ctx, func(ctx context.Context) error {
+		ret, err = r.repository.SavedFilter.Find(ctx, *obj.SavedFilterID)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *contentRestrictionResolver) ExcludedTags(ctx context.Context, obj *models.ContentRestriction) (ret []*models.Tag, err error) {
+	var errs []error
+	ret, errs = loaders.From(ctx).TagByID.LoadAll(obj.ExcludedTagIDs)
+	return ret, firstError(errs)
+}
+
+func (r *contentRestrictionResolver) ExcludedStudios(ctx context.Context, obj *models.ContentRestriction) (ret []*models.Studio, err error) {
+	var errs []error
+	ret, errs = loaders.From(ctx).StudioByID.LoadAll(obj.ExcludedStudioIDs)
+	return ret, firstError(errs)
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stashapp/stash/internal/manager"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// APIKeyCreate creates a named API key for the current user, or for another
+// user if the current user is an admin. The key is only ever returned here.
+func (r *mutationResolver) APIKeyCreate(ctx context.Context, input models.APIKeyCreateInput) (*models.APIKeyCreateResult, error) {
+	user, err := r.getCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrNotAuthenticated
+	}
+
+	ownerID := user.ID
+	if input.UserID != nil {
+		ownerID, err = strconv.Atoi(*input.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if ownerID != user.ID && !user.IsAdmin() {
+			return nil, ErrNotAuthorized
+		}
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, errors.New("name cannot be empty")
+	}
+
+	if len(input.Scopes) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+	for _, s := range input.Scopes {
+		if !s.IsValid() {
+			return nil, fmt.Errorf("invalid scope: %s", s)
+		}
+	}
+
+	now := time.Now()
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(now) {
+		return nil, errors.New("expiry must be in the future")
+	}
+
+	key, prefix, hash, err := manager.NewNamedAPIKey()
+	if err != nil {
+		return nil, fmt.Errorf("generating api key: %w", err)
+	}
+
+	newKey := &models.APIKey{
+		UserID:    ownerID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    input.Scopes,
+		ExpiresAt: input.ExpiresAt,
+		CreatedAt: now,
+	}
+
+	if err := r.withTxn(ctx, func(ctx context.Context) error {
+		owner, err := r.repository.User.Find(ctx, ownerID)
+		if err != nil {
+			return err
+		}
+		if owner == nil {
+			return ErrUserNotFound
+		}
+
+		return r.repository.APIKey.Create(ctx, newKey)
+	}); err != nil {
+		return nil, err
+	}
+
+	return &models.APIKeyCreateResult{
+		APIKey: newKey,
+		Key:    key,
+	}, nil
+}
+
+// APIKeyRevoke deletes a named API key. Users may revoke their own keys;
+// admins may revoke any key.
+func (r *mutationResolver) APIKeyRevoke(ctx context.Context, id string) (bool, error) {
+	user, err := r.getCurrentUser(ctx)
+	if err != nil {
+		return false, err
+	}
+	if user == nil {
+		return false, ErrNotAuthenticated
+	}
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		return false, err
+	}
+
+	if err := r.withTxn(ctx, func(ctx context.Context) error {
+		key, err := r.repository.APIKey.Find(ctx, idInt)
+		if err != nil {
+			return err
+		}
+		if key == nil {
+			return ErrAPIKeyNotFound
+		}
+		if key.UserID != user.ID && !user.IsAdmin() {
+			return ErrNotAuthorized
+		}
+
+		return r.repository.APIKey.Destroy(ctx, idInt)
+	}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
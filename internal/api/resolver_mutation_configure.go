@@ -9,6 +9,7 @@ import (
 	"regexp"
 	"strconv"
 
+	"github.com/stashapp/stash/internal/dlna"
 	"github.com/stashapp/stash/internal/manager"
 	"github.com/stashapp/stash/internal/manager/config"
 	"github.com/stashapp/stash/internal/manager/task"
@@ -299,6 +300,13 @@ func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input ConfigGen
 	if input.MaxStreamingTranscodeSize != nil {
 		c.SetString(config.MaxStreamingTranscodeSize, input.MaxStreamingTranscodeSize.String())
 	}
+
+	if input.OptimizedVersionProfiles != nil {
+		if err := models.ValidateOptimizedVersionProfiles(input.OptimizedVersionProfiles); err != nil {
+			return makeConfigGeneralResult(), err
+		}
+		c.SetInterface(config.OptimizedVersionProfiles, input.OptimizedVersionProfiles)
+	}
 	r.setConfigBool(config.WriteImageThumbnails, input.WriteImageThumbnails)
 	r.setConfigBool(config.CreateImageClipsFromVideos, input.CreateImageClipsFromVideos)
 
@@ -336,6 +344,7 @@ func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input ConfigGen
 	}
 
 	r.setConfigInt(config.MaxSessionAge, input.MaxSessionAge)
+	r.setConfigInt(config.AuditLogRetentionDays, input.AuditLogRetentionDays)
 	r.setConfigString(config.LogFile, input.LogFile)
 	r.setConfigBool(config.LogOut, input.LogOut)
 	r.setConfigBool(config.LogAccess, input.LogAccess)
@@ -405,6 +414,16 @@ func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input ConfigGen
 		r.setConfigString(config.PythonPath, input.PythonPath)
 	}
 
+	if input.VisualEmbeddingBackend != nil {
+		switch *input.VisualEmbeddingBackend {
+		case "histogram", "sidecar":
+			r.setConfigString(config.VisualEmbeddingBackend, input.VisualEmbeddingBackend)
+		default:
+			return makeConfigGeneralResult(), fmt.Errorf("invalid visual embedding backend %q, use histogram or sidecar", *input.VisualEmbeddingBackend)
+		}
+	}
+	r.setConfigString(config.VisualEmbeddingModel, input.VisualEmbeddingModel)
+
 	if input.TranscodeInputArgs != nil {
 		c.SetInterface(config.TranscodeInputArgs, input.TranscodeInputArgs)
 	}
@@ -554,6 +573,7 @@ func (r *mutationResolver) ConfigureDlna(ctx context.Context, input ConfigDLNAIn
 	}
 
 	r.setConfigString(config.DLNAVideoSortOrder, input.VideoSortOrder)
+	r.setConfigString(config.DLNAUser, input.User)
 	r.setConfigInt(config.DLNAPort, input.Port)
 
 	refresh := false
@@ -566,6 +586,13 @@ func (r *mutationResolver) ConfigureDlna(ctx context.Context, input ConfigDLNAIn
 		c.SetInterface(config.DLNAInterfaces, input.Interfaces)
 	}
 
+	if input.RendererProfiles != nil {
+		if err := dlna.ValidateRendererProfiles(input.RendererProfiles); err != nil {
+			return makeConfigDLNAResult(), err
+		}
+		c.SetInterface(config.DLNARendererProfiles, input.RendererProfiles)
+	}
+
 	if err := c.Write(); err != nil {
 		return makeConfigDLNAResult(), err
 	}
@@ -0,0 +1,366 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *mutationResolver) getIPTVChannel(ctx context.Context, id int) (ret *models.IPTVChannel, err error) {
+	if err := r.withTxn(ctx, func(ctx context.Context) error {
+		ret, err = r.repository.IPTVChannel.Find(ctx, id)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *mutationResolver) IptvChannelCreate(ctx context.Context, input models.IPTVChannelCreateInput) (*models.IPTVChannel, error) {
+	sourceID, err := strconv.Atoi(input.SourceID)
+	if err != nil {
+		return nil, fmt.Errorf("converting source id: %w", err)
+	}
+
+	newChannel := models.NewIPTVChannel()
+	newChannel.Name = strings.TrimSpace(input.Name)
+	newChannel.Number = input.Number
+	newChannel.SourceType = input.SourceType
+	newChannel.SourceID = sourceID
+	newChannel.ShuffleSeed = input.ShuffleSeed
+
+	blocks, err := iptvChannelBlocksFromInput(input.Blocks)
+	if err != nil {
+		return nil, err
+	}
+
+	var logoData []byte
+	if input.Logo != nil {
+		logoData, err = r.processLocalOrRemoteImage(ctx, *input.Logo)
+		if err != nil {
+			return nil, fmt.Errorf("processing logo: %w", err)
+		}
+	}
+
+	if err := r.withTxn(ctx, func(ctx context.Context) error {
+		if err := validateIPTVChannel(ctx, r.repository, &newChannel); err != nil {
+			return err
+		}
+		if err := validateIPTVChannelBlocks(ctx, r.repository, blocks); err != nil {
+			return err
+		}
+
+		qb := r.repository.IPTVChannel
+		if err := qb.Create(ctx, &newChannel); err != nil {
+			return err
+		}
+
+		if len(blocks) > 0 {
+			if err := qb.UpdateBlocks(ctx, newChannel.ID, blocks); err != nil {
+				return err
+			}
+		}
+
+		if len(logoData) > 0 {
+			if err := qb.UpdateLogo(ctx, newChannel.ID, logoData); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	r.invalidateIPTVChannel(newChannel.ID)
+	return r.getIPTVChannel(ctx, newChannel.ID)
+}
+
+func (r *mutationResolver) IptvChannelUpdate(ctx context.Context, input models.IPTVChannelUpdateInput) (*models.IPTVChannel, error) {
+	channelID, err := strconv.Atoi(input.ID)
+	if err != nil {
+		return nil, fmt.Errorf("converting id: %w", err)
+	}
+
+	translator := changesetTranslator{
+		inputMap: getUpdateInputMap(ctx),
+	}
+
+	var name *string
+	if input.Name != nil {
+		trimmed := strings.TrimSpace(*input.Name)
+		name = &trimmed
+	}
+
+	partial := models.NewIPTVChannelPartial()
+	partial.Name = translator.optionalString(name, "name")
+	partial.Number = translator.optionalInt(input.Number, "number")
+	partial.ShuffleSeed = translator.optionalInt(input.ShuffleSeed, "shuffle_seed")
+	if input.SourceType != nil {
+		partial.SourceType = models.NewOptionalString(input.SourceType.String())
+	}
+	partial.SourceID, err = translator.optionalIntFromString(input.SourceID, "source_id")
+	if err != nil {
+		return nil, fmt.Errorf("converting source id: %w", err)
+	}
+
+	var blocks []*models.IPTVChannelBlock
+	blocksIncluded := translator.hasField("blocks")
+	if blocksIncluded {
+		blocks, err = iptvChannelBlocksFromInput(input.Blocks)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var logoData []byte
+	logoIncluded := translator.hasField("logo")
+	if input.Logo != nil {
+		logoData, err = r.processLocalOrRemoteImage(ctx, *input.Logo)
+		if err != nil {
+			return nil, fmt.Errorf("processing logo: %w", err)
+		}
+	}
+
+	if err := r.withTxn(ctx, func(ctx context.Context) error {
+		qb := r.repository.IPTVChannel
+
+		existing, err := qb.Find(ctx, channelID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return fmt.Errorf("iptv channel with id %d not found", channelID)
+		}
+
+		// validate the channel as it will be after the update
+		updated := *existing
+		applyIPTVChannelPartial(&updated, partial)
+		if err := validateIPTVChannel(ctx, r.repository, &updated); err != nil {
+			return err
+		}
+		if err := validateIPTVChannelBlocks(ctx, r.repository, blocks); err != nil {
+			return err
+		}
+
+		if _, err := qb.UpdatePartial(ctx, channelID, partial); err != nil {
+			return err
+		}
+
+		if blocksIncluded {
+			if err := qb.UpdateBlocks(ctx, channelID, blocks); err != nil {
+				return err
+			}
+		}
+
+		if logoIncluded {
+			if err := qb.UpdateLogo(ctx, channelID, logoData); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	r.invalidateIPTVChannel(channelID)
+	return r.getIPTVChannel(ctx, channelID)
+}
+
+func (r *mutationResolver) IptvChannelDestroy(ctx context.Context, id string) (bool, error) {
+	channelID, err := strconv.Atoi(id)
+	if err != nil {
+		return false, fmt.Errorf("converting id: %w", err)
+	}
+
+	if err := r.withTxn(ctx, func(ctx context.Context) error {
+		return r.repository.IPTVChannel.Destroy(ctx, channelID)
+	}); err != nil {
+		return false, err
+	}
+
+	r.invalidateIPTVChannel(channelID)
+	return true, nil
+}
+
+// invalidateIPTVChannel makes a change to a channel visible to IPTV clients
+// on their next request rather than after the lineup cache expires.
+func (r *mutationResolver) invalidateIPTVChannel(id int) {
+	if r.iptv != nil {
+		r.iptv.invalidateCustomChannel(id)
+	}
+}
+
+func applyIPTVChannelPartial(c *models.IPTVChannel, partial models.IPTVChannelPartial) {
+	if partial.Name.Set {
+		c.Name = partial.Name.Value
+	}
+	if partial.Number.Set {
+		c.Number = partial.Number.Ptr()
+	}
+	if partial.SourceType.Set {
+		c.SourceType = models.IPTVChannelSource(partial.SourceType.Value)
+	}
+	if partial.SourceID.Set {
+		c.SourceID = partial.SourceID.Value
+	}
+	if partial.ShuffleSeed.Set {
+		c.ShuffleSeed = partial.ShuffleSeed.Ptr()
+	}
+}
+
+// validateIPTVChannel checks that a channel has a name, a free number and a
+// source that exists and can be aired.
+func validateIPTVChannel(ctx context.Context, repo models.Repository, c *models.IPTVChannel) error {
+	if c.Name == "" {
+		return errors.New("channel name cannot be empty")
+	}
+
+	if c.Number != nil {
+		if *c.Number < 1 {
+			return fmt.Errorf("channel number must be positive, got %d", *c.Number)
+		}
+
+		existing, err := repo.IPTVChannel.FindByNumber(ctx, *c.Number)
+		if err != nil {
+			return err
+		}
+		if existing != nil && existing.ID != c.ID {
+			return fmt.Errorf("channel number %d is already used by %q", *c.Number, existing.Name)
+		}
+	}
+
+	if c.ShuffleSeed != nil && *c.ShuffleSeed < 0 {
+		return fmt.Errorf("shuffle seed must not be negative, got %d", *c.ShuffleSeed)
+	}
+
+	if !c.SourceType.IsValid() {
+		return fmt.Errorf("invalid channel source %q", c.SourceType)
+	}
+	return validateIPTVChannelSource(ctx, repo, c.SourceType, c.SourceID)
+}
+
+// validateIPTVChannelSource checks that the source of a channel or block
+// exists and can be aired.
+func validateIPTVChannelSource(ctx context.Context, repo models.Repository, sourceType models.IPTVChannelSource, sourceID int) error {
+	src, err := iptvCustomSource(ctx, &repo, sourceType, sourceID, 0)
+	if errors.Is(err, errIPTVChannelSourceNotFound) {
+		return err
+	}
+	if err != nil {
+		// converting a saved filter also checks that it is a scene filter
+		// the channel can query
+		return fmt.Errorf("%s %d cannot be used for a channel: %w", sourceType, sourceID, err)
+	}
+
+	if src.playlist != nil {
+		if _, err := iptvPlaylistCriteria(src.playlist); err != nil {
+			return fmt.Errorf("%s %d cannot be used for a channel: %w", sourceType, sourceID, err)
+		}
+	}
+	return nil
+}
+
+// iptvChannelBlocksFromInput converts block inputs, checking each on its own.
+func iptvChannelBlocksFromInput(input []*models.IPTVChannelBlockInput) ([]*models.IPTVChannelBlock, error) {
+	ret := make([]*models.IPTVChannelBlock, 0, len(input))
+	for i, in := range input {
+		b := &models.IPTVChannelBlock{
+			SourceType: in.SourceType,
+		}
+		if in.Name != nil {
+			b.Name = strings.TrimSpace(*in.Name)
+		}
+
+		label := b.Name
+		if label == "" {
+			label = fmt.Sprintf("#%d", i+1)
+		}
+
+		var err error
+		if b.StartMinute, err = parseIPTVBlockTime(in.Start); err != nil {
+			return nil, fmt.Errorf("block %s: start: %w", label, err)
+		}
+		if b.EndMinute, err = parseIPTVBlockTime(in.End); err != nil {
+			return nil, fmt.Errorf("block %s: end: %w", label, err)
+		}
+		if b.StartMinute == b.EndMinute {
+			return nil, fmt.Errorf("block %s: start and end cannot be the same", label)
+		}
+
+		seen := make(map[int]bool)
+		for _, d := range in.Days {
+			if d < 0 || d > 6 {
+				return nil, fmt.Errorf("block %s: invalid day %d: days run from 0 (Sunday) to 6 (Saturday)", label, d)
+			}
+			if !seen[d] {
+				seen[d] = true
+				b.Days = append(b.Days, d)
+			}
+		}
+		sort.Ints(b.Days)
+
+		if !b.SourceType.IsValid() {
+			return nil, fmt.Errorf("block %s: invalid source %q", label, b.SourceType)
+		}
+		if b.SourceID, err = strconv.Atoi(in.SourceID); err != nil {
+			return nil, fmt.Errorf("block %s: converting source id: %w", label, err)
+		}
+
+		ret = append(ret, b)
+	}
+
+	for i, b := range ret {
+		for _, o := range ret[:i] {
+			if b.Overlaps(*o) {
+				return nil, fmt.Errorf("blocks %s and %s overlap", iptvBlockLabel(o, ret), iptvBlockLabel(b, ret))
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+func iptvBlockLabel(b *models.IPTVChannelBlock, all []*models.IPTVChannelBlock) string {
+	if b.Name != "" {
+		return strconv.Quote(b.Name)
+	}
+	for i, o := range all {
+		if o == b {
+			return fmt.Sprintf("#%d", i+1)
+		}
+	}
+	return "?"
+}
+
+// parseIPTVBlockTime parses an HH:MM time of day into minutes since midnight.
+func parseIPTVBlockTime(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q: expected HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// formatIPTVBlockTime is the inverse of parseIPTVBlockTime.
+func formatIPTVBlockTime(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// validateIPTVChannelBlocks checks that the source of every block exists.
+func validateIPTVChannelBlocks(ctx context.Context, repo models.Repository, blocks []*models.IPTVChannelBlock) error {
+	for _, b := range blocks {
+		if err := validateIPTVChannelSource(ctx, repo, b.SourceType, b.SourceID); err != nil {
+			return fmt.Errorf("block %s: %w", iptvBlockLabel(b, blocks), err)
+		}
+	}
+	return nil
+}
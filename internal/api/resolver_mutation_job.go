@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/stashapp/stash/internal/manager"
+	"github.com/stashapp/stash/pkg/job"
 )
 
 func (r *mutationResolver) StopJob(ctx context.Context, jobID string) (bool, error) {
@@ -22,3 +23,50 @@ func (r *mutationResolver) StopAllJobs(ctx context.Context) (bool, error) {
 	manager.GetInstance().JobManager.CancelAll()
 	return true, nil
 }
+
+func (r *mutationResolver) PauseJob(ctx context.Context, jobID string) (bool, error) {
+	id, err := strconv.Atoi(jobID)
+	if err != nil {
+		return false, fmt.Errorf("converting id: %w", err)
+	}
+
+	return manager.GetInstance().JobManager.PauseJob(id), nil
+}
+
+func (r *mutationResolver) ResumeJob(ctx context.Context, jobID string) (bool, error) {
+	id, err := strconv.Atoi(jobID)
+	if err != nil {
+		return false, fmt.Errorf("converting id: %w", err)
+	}
+
+	return manager.GetInstance().JobManager.ResumeJob(id), nil
+}
+
+func (r *mutationResolver) SetJobPriority(ctx context.Context, jobID string, priority JobPriority) (bool, error) {
+	id, err := strconv.Atoi(jobID)
+	if err != nil {
+		return false, fmt.Errorf("converting id: %w", err)
+	}
+
+	p, ok := jobPriorities[priority]
+	if !ok {
+		return false, fmt.Errorf("invalid priority: %s", priority)
+	}
+
+	return manager.GetInstance().JobManager.SetJobPriority(id, p), nil
+}
+
+func (r *mutationResolver) MoveJob(ctx context.Context, jobID string, position int) (bool, error) {
+	id, err := strconv.Atoi(jobID)
+	if err != nil {
+		return false, fmt.Errorf("converting id: %w", err)
+	}
+
+	return manager.GetInstance().JobManager.MoveJob(id, position), nil
+}
+
+var jobPriorities = map[JobPriority]job.Priority{
+	JobPriorityLow:    job.PriorityLow,
+	JobPriorityNormal: job.PriorityNormal,
+	JobPriorityHigh:   job.PriorityHigh,
+}
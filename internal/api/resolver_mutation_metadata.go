@@ -153,6 +153,21 @@ func (r *mutationResolver) MetadataCleanGenerated(ctx context.Context, input tas
 	return strconv.Itoa(jobID), nil
 }
 
+func (r *mutationResolver) GenerateOptimizedVersions(ctx context.Context, input manager.GenerateOptimizedVersionsInput) (string, error) {
+	jobID, err := manager.GetInstance().GenerateOptimizedVersions(ctx, input)
+
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.Itoa(jobID), nil
+}
+
+func (r *mutationResolver) CleanOptimizedVersions(ctx context.Context) (string, error) {
+	jobID := manager.GetInstance().CleanOptimizedVersions(ctx)
+	return strconv.Itoa(jobID), nil
+}
+
 func (r *mutationResolver) MigrateHashNaming(ctx context.Context) (string, error) {
 	jobID := manager.GetInstance().MigrateHash(ctx)
 	return strconv.Itoa(jobID), nil
@@ -45,6 +45,10 @@ func (r *mutationResolver) Migrate(ctx context.Context, input manager.MigrateInp
 		BackupPath: input.BackupPath,
 		Config:     mgr.Config,
 		Database:   mgr.Database,
+		// the saved job queue could not be read until now
+		PostMigrate: func() {
+			mgr.RestoreJobs(context.Background())
+		},
 	}
 
 	jobID := mgr.JobManager.Add(ctx, "Migrating database...", t)
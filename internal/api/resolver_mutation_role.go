@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// ErrRoleNotFound is returned when a custom role does not exist
+var ErrRoleNotFound = errors.New("role not found")
+
+func validatePermissions(permissions []models.Permission) error {
+	for _, p := range permissions {
+		if !p.IsValid() {
+			return fmt.Errorf("invalid permission: %s", p)
+		}
+	}
+	return nil
+}
+
+// checkRoleName ensures that no role other than the one with the given id
+// already has the name
+func (r *mutationResolver) checkRoleName(ctx context.Context, name string, id int) error {
+	other, err := r.repository.Role.FindByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	if other != nil && other.ID != id {
+		return fmt.Errorf("role '%s' already exists", name)
+	}
+	return nil
+}
+
+// RoleCreate creates a new custom role (admin only)
+func (r *mutationResolver) RoleCreate(ctx context.Context, input models.RoleCreateInput) (*models.Role, error) {
+	if _, err := r.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, errors.New("role name cannot be empty")
+	}
+	if err := validatePermissions(input.Permissions); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	role := &models.Role{
+		Name:        name,
+		Permissions: input.Permissions,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if input.Description != nil {
+		role.Description = *input.Description
+	}
+
+	if err := r.withTxn(ctx, func(ctx context.Context) error {
+		if err := r.checkRoleName(ctx, name, 0); err != nil {
+			return err
+		}
+
+		return r.repository.Role.Create(ctx, role)
+	}); err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// RoleUpdate updates an existing custom role (admin only)
+func (r *mutationResolver) RoleUpdate(ctx context.Context, input models.RoleUpdateInput) (*models.Role, error) {
+	if _, err := r.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	id, err := strconv.Atoi(input.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid role id: %w", err)
+	}
+
+	if err := validatePermissions(input.Permissions); err != nil {
+		return nil, err
+	}
+
+	var role *models.Role
+	if err := r.withTxn(ctx, func(ctx context.Context) error {
+		role, err = r.repository.Role.Find(ctx, id)
+		if err != nil {
+			return err
+		}
+		if role == nil {
+			return ErrRoleNotFound
+		}
+
+		if input.Name != nil {
+			name := strings.TrimSpace(*input.Name)
+			if name == "" {
+				return errors.New("role name cannot be empty")
+			}
+			if err := r.checkRoleName(ctx, name, id); err != nil {
+				return err
+			}
+			role.Name = name
+		}
+
+		if input.Description != nil {
+			role.Description = *input.Description
+		}
+
+		if input.Permissions != nil {
+			role.Permissions = input.Permissions
+		}
+
+		role.UpdatedAt = time.Now()
+
+		return r.repository.Role.Update(ctx, role)
+	}); err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// RoleDestroy deletes a custom role (admin only). Users assigned to the role
+// keep their built-in role.
+func (r *mutationResolver) RoleDestroy(ctx context.Context, id string) (bool, error) {
+	if _, err := r.requireAdmin(ctx); err != nil {
+		return false, err
+	}
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		return false, fmt.Errorf("invalid role id: %w", err)
+	}
+
+	if err := r.withTxn(ctx, func(ctx context.Context) error {
+		role, err := r.repository.Role.Find(ctx, idInt)
+		if err != nil {
+			return err
+		}
+		if role == nil {
+			return ErrRoleNotFound
+		}
+
+		return r.repository.Role.Destroy(ctx, idInt)
+	}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -45,13 +45,23 @@ func (r *mutationResolver) ScheduledTaskCreate(ctx context.Context, input Schedu
 		TaskType:     taskType,
 		Enabled:      enabled,
 		Options:      options,
+		Then:         input.Then,
+	}
+	if input.Retries != nil {
+		task.Retries = *input.Retries
+	}
+	if input.RetryDelay != nil {
+		task.RetryDelay = *input.RetryDelay
+	}
+	if input.SkipIfRunning != nil {
+		task.SkipIfRunning = *input.SkipIfRunning
 	}
 
 	if err := sched.AddTask(task); err != nil {
 		return nil, err
 	}
 
-	return mapScheduledTask(task), nil
+	return mapScheduledTask(sched, task), nil
 }
 
 func (r *mutationResolver) ScheduledTaskUpdate(ctx context.Context, input ScheduledTaskUpdateInput) (*ScheduledTask, error) {
@@ -87,12 +97,24 @@ func (r *mutationResolver) ScheduledTaskUpdate(ctx context.Context, input Schedu
 	if input.Options != nil {
 		task.Options = json.RawMessage(*input.Options)
 	}
+	if input.Then != nil {
+		task.Then = input.Then
+	}
+	if input.Retries != nil {
+		task.Retries = *input.Retries
+	}
+	if input.RetryDelay != nil {
+		task.RetryDelay = *input.RetryDelay
+	}
+	if input.SkipIfRunning != nil {
+		task.SkipIfRunning = *input.SkipIfRunning
+	}
 
 	if err := sched.UpdateTask(task); err != nil {
 		return nil, err
 	}
 
-	return mapScheduledTask(task), nil
+	return mapScheduledTask(sched, task), nil
 }
 
 func (r *mutationResolver) ScheduledTaskDestroy(ctx context.Context, id string) (bool, error) {
@@ -0,0 +1,351 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stashapp/stash/internal/manager"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/sliceutil/stringslice"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// syncTargetOwner returns the user whose sync targets the request is limited
+// to. It returns false if the request can reach every sync target, as those
+// of admins and those made in single-user mode can.
+func syncTargetOwner(ctx context.Context) (int, bool) {
+	if _, limited := models.PermissionsFromContext(ctx); !limited {
+		return 0, false
+	}
+
+	userID, _ := models.UserIDFromContext(ctx)
+	return userID, true
+}
+
+// canAccessSyncTarget returns true if the request can reach the sync target.
+func canAccessSyncTarget(ctx context.Context, t *models.SyncTarget) bool {
+	owner, limited := syncTargetOwner(ctx)
+	return !limited || (t.UserID != nil && *t.UserID == owner)
+}
+
+// findSyncTarget returns the sync target with the id, or nil if it does not
+// exist or the request cannot reach it.
+func findSyncTarget(ctx context.Context, qb models.SyncTargetGetter, id int) (*models.SyncTarget, error) {
+	ret, err := qb.Find(ctx, id)
+	if err != nil || ret == nil || !canAccessSyncTarget(ctx, ret) {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *mutationResolver) getSyncTarget(ctx context.Context, id int) (ret *models.SyncTarget, err error) {
+	if err := r.withTxn(ctx, func(ctx context.Context) error {
+		ret, err = findSyncTarget(ctx, r.repository.SyncTarget, id)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *mutationResolver) SyncTargetCreate(ctx context.Context, input models.SyncTargetCreateInput) (*models.SyncTarget, error) {
+	sourceID, err := strconv.Atoi(input.SourceID)
+	if err != nil {
+		return nil, fmt.Errorf("converting source id: %w", err)
+	}
+
+	newTarget := models.NewSyncTarget()
+	newTarget.Name = strings.TrimSpace(input.Name)
+	newTarget.SourceType = input.SourceType
+	newTarget.SourceID = sourceID
+	if input.Profile != nil {
+		newTarget.Profile = strings.TrimSpace(*input.Profile)
+	}
+	if input.MaxSize != nil {
+		newTarget.MaxSize = *input.MaxSize
+	}
+	if userID, ok := models.UserIDFromContext(ctx); ok {
+		newTarget.UserID = &userID
+	}
+
+	if err := r.withTxn(ctx, func(ctx context.Context) error {
+		if err := manager.ValidateSyncTarget(ctx, r.repository, &newTarget); err != nil {
+			return err
+		}
+
+		return r.repository.SyncTarget.Create(ctx, &newTarget)
+	}); err != nil {
+		return nil, err
+	}
+
+	return r.getSyncTarget(ctx, newTarget.ID)
+}
+
+func (r *mutationResolver) SyncTargetUpdate(ctx context.Context, input models.SyncTargetUpdateInput) (*models.SyncTarget, error) {
+	targetID, err := strconv.Atoi(input.ID)
+	if err != nil {
+		return nil, fmt.Errorf("converting id: %w", err)
+	}
+
+	translator := changesetTranslator{
+		inputMap: getUpdateInputMap(ctx),
+	}
+
+	var name, profile *string
+	if input.Name != nil {
+		trimmed := strings.TrimSpace(*input.Name)
+		name = &trimmed
+	}
+	if input.Profile != nil {
+		trimmed := strings.TrimSpace(*input.Profile)
+		profile = &trimmed
+	}
+
+	partial := models.NewSyncTargetPartial()
+	partial.Name = translator.optionalString(name, "name")
+	partial.Profile = translator.optionalString(profile, "profile")
+	if input.SourceType != nil {
+		partial.SourceType = models.NewOptionalString(input.SourceType.String())
+	}
+	partial.SourceID, err = translator.optionalIntFromString(input.SourceID, "source_id")
+	if err != nil {
+		return nil, fmt.Errorf("converting source id: %w", err)
+	}
+	if input.MaxSize != nil {
+		partial.MaxSize = models.NewOptionalInt64(*input.MaxSize)
+	}
+
+	if err := r.withTxn(ctx, func(ctx context.Context) error {
+		qb := r.repository.SyncTarget
+
+		existing, err := findSyncTarget(ctx, qb, targetID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return fmt.Errorf("sync target with id %d not found", targetID)
+		}
+
+		// validate the target as it will be after the update
+		updated := *existing
+		applySyncTargetPartial(&updated, partial)
+		if err := manager.ValidateSyncTarget(ctx, r.repository, &updated); err != nil {
+			return err
+		}
+
+		_, err = qb.UpdatePartial(ctx, targetID, partial)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return r.getSyncTarget(ctx, targetID)
+}
+
+func (r *mutationResolver) SyncTargetDestroy(ctx context.Context, id string) (bool, error) {
+	targetID, err := strconv.Atoi(id)
+	if err != nil {
+		return false, fmt.Errorf("converting id: %w", err)
+	}
+
+	if err := r.withTxn(ctx, func(ctx context.Context) error {
+		qb := r.repository.SyncTarget
+
+		target, err := findSyncTarget(ctx, qb, targetID)
+		if err != nil {
+			return err
+		}
+		if target == nil {
+			return fmt.Errorf("sync target with id %d not found", targetID)
+		}
+
+		return qb.Destroy(ctx, targetID)
+	}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *mutationResolver) SyncTargetPrepare(ctx context.Context, id string, full *bool) (string, error) {
+	targetID, err := strconv.Atoi(id)
+	if err != nil {
+		return "", fmt.Errorf("converting id: %w", err)
+	}
+
+	target, err := r.getSyncTarget(ctx, targetID)
+	if err != nil {
+		return "", err
+	}
+	if target == nil {
+		return "", fmt.Errorf("sync target with id %d not found", targetID)
+	}
+
+	jobID := manager.GetInstance().PrepareSyncBundle(ctx, targetID, utils.IsTrue(full))
+	return strconv.Itoa(jobID), nil
+}
+
+// SyncTargetUploadState records what a device played while offline and,
+// if given, which scenes it has. Activity is recorded for the current user.
+// The scenes of a bundle are only recorded as on the device once it has been
+// downloaded, or once the device uploads them here.
+func (r *mutationResolver) SyncTargetUploadState(ctx context.Context, input models.SyncTargetStateInput) (*models.SyncTarget, error) {
+	targetID, err := strconv.Atoi(input.ID)
+	if err != nil {
+		return nil, fmt.Errorf("converting id: %w", err)
+	}
+
+	var sceneIDs []int
+	if input.SceneIDs != nil {
+		sceneIDs, err = stringslice.StringSliceToIntSlice(input.SceneIDs)
+		if err != nil {
+			return nil, fmt.Errorf("converting scene ids: %w", err)
+		}
+	}
+
+	if err := r.withTxn(ctx, func(ctx context.Context) error {
+		qb := r.repository.SyncTarget
+
+		target, err := findSyncTarget(ctx, qb, targetID)
+		if err != nil {
+			return err
+		}
+		if target == nil {
+			return fmt.Errorf("sync target with id %d not found", targetID)
+		}
+
+		for _, a := range input.Activity {
+			if err := r.saveSyncSceneActivity(ctx, a); err != nil {
+				return err
+			}
+		}
+
+		if input.SceneIDs == nil {
+			return nil
+		}
+
+		existing, err := qb.GetItems(ctx, targetID)
+		if err != nil {
+			return err
+		}
+
+		// keep what is known of the scenes the device still has, and add
+		// those it got from the latest bundle or some other way
+		byScene := make(map[int]*models.SyncTargetItem, len(existing))
+		for _, i := range manager.GetInstance().SyncBundles.PendingItems(targetID) {
+			byScene[i.SceneID] = i
+		}
+		for _, i := range existing {
+			byScene[i.SceneID] = i
+		}
+
+		now := time.Now()
+		var items []*models.SyncTargetItem
+		seen := make(map[int]bool, len(sceneIDs))
+		for _, id := range sceneIDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			if i := byScene[id]; i != nil {
+				items = append(items, i)
+				continue
+			}
+			items = append(items, &models.SyncTargetItem{
+				SceneID:  id,
+				SyncedAt: now,
+			})
+		}
+
+		return qb.UpdateItems(ctx, targetID, items)
+	}); err != nil {
+		return nil, err
+	}
+
+	return r.getSyncTarget(ctx, targetID)
+}
+
+func (r *mutationResolver) saveSyncSceneActivity(ctx context.Context, a *models.SyncSceneActivityInput) error {
+	sceneID, err := strconv.Atoi(a.SceneID)
+	if err != nil {
+		return fmt.Errorf("converting scene id: %w", err)
+	}
+
+	qb := r.repository.Scene
+
+	if a.ResumeTime != nil || a.PlayDuration != nil {
+		if _, err := qb.SaveActivity(ctx, sceneID, a.ResumeTime, a.PlayDuration); err != nil {
+			return fmt.Errorf("saving activity of scene %d: %w", sceneID, err)
+		}
+	}
+
+	// a device retries an upload that it did not see succeed, so plays and
+	// o-counts that are already in the history are not added again
+	existingViews, err := qb.GetViewDates(ctx, sceneID)
+	if err != nil {
+		return fmt.Errorf("getting plays of scene %d: %w", sceneID, err)
+	}
+	views := newHistoryDates(existingViews, a.PlayHistory)
+	if len(views) > 0 {
+		if _, err := qb.AddViews(ctx, sceneID, views); err != nil {
+			return fmt.Errorf("adding plays of scene %d: %w", sceneID, err)
+		}
+	}
+
+	existingO, err := qb.GetODates(ctx, sceneID)
+	if err != nil {
+		return fmt.Errorf("getting o count of scene %d: %w", sceneID, err)
+	}
+	oTimes := newHistoryDates(existingO, a.OHistory)
+	if len(oTimes) > 0 {
+		if _, err := qb.AddO(ctx, sceneID, oTimes); err != nil {
+			return fmt.Errorf("adding o count of scene %d: %w", sceneID, err)
+		}
+	}
+
+	return nil
+}
+
+// newHistoryDates returns the dates that are not already in existing, to the
+// second that history dates are stored with. The dates are converted to local
+// time, so that sorting is consistent.
+func newHistoryDates(existing []time.Time, dates []*time.Time) []time.Time {
+	seen := make(map[int64]bool, len(existing)+len(dates))
+	for _, t := range existing {
+		seen[t.Unix()] = true
+	}
+
+	var ret []time.Time
+	for _, t := range dates {
+		if t == nil || seen[t.Unix()] {
+			continue
+		}
+		seen[t.Unix()] = true
+		ret = append(ret, t.Local())
+	}
+	return ret
+}
+
+func applySyncTargetPartial(t *models.SyncTarget, partial models.SyncTargetPartial) {
+	if partial.Name.Set {
+		t.Name = partial.Name.Value
+	}
+	if partial.SourceType.Set {
+		t.SourceType = models.SyncTargetSource(partial.SourceType.Value)
+	}
+	if partial.SourceID.Set {
+		t.SourceID = partial.SourceID.Value
+	}
+	if partial.Profile.Set {
+		t.Profile = partial.Profile.Value
+	}
+	if partial.MaxSize.Set {
+		t.MaxSize = partial.MaxSize.Value
+	}
+}
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewHistoryDates(t *testing.T) {
+	played := time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC)
+	later := played.Add(time.Hour)
+
+	// the stored history has second precision
+	existing := []time.Time{played.Local()}
+
+	retried := played.Add(300 * time.Millisecond)
+	got := newHistoryDates(existing, []*time.Time{&retried, &later, &later})
+	if len(got) != 1 || !got[0].Equal(later) {
+		t.Errorf("newHistoryDates() = %v, want [%v]", got, later)
+	}
+
+	if got := newHistoryDates(existing, []*time.Time{&played}); len(got) != 0 {
+		t.Errorf("newHistoryDates() of a retried upload = %v, want none", got)
+	}
+}
@@ -12,7 +12,9 @@ import (
 	"github.com/stashapp/stash/internal/manager"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/plugin/hook"
+	"github.com/stashapp/stash/pkg/savedfilter"
 	"github.com/stashapp/stash/pkg/session"
+	"github.com/stashapp/stash/pkg/sliceutil/stringslice"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -42,7 +44,8 @@ func generateAPIKey() string {
 // UserCreate creates a new user (admin only, or any unauthenticated request when no users exist - setup mode)
 func (r *mutationResolver) UserCreate(ctx context.Context, input models.UserCreateInput) (*models.User, error) {
 	// Allow unauthenticated creation of the first user (setup mode)
-	if manager.GetInstance().GetUserCount() > 0 {
+	firstUser := manager.GetInstance().GetUserCount() == 0
+	if !firstUser {
 		if _, err := r.requireAdmin(ctx); err != nil {
 			return nil, err
 		}
@@ -97,7 +100,24 @@ func (r *mutationResolver) UserCreate(ctx context.Context, input models.UserCrea
 			return fmt.Errorf("username '%s' already exists", username)
 		}
 
-		return r.repository.User.Create(ctx, user)
+		if input.CustomRoleID != nil {
+			customRoleID, err := r.customRoleID(ctx, *input.CustomRoleID)
+			if err != nil {
+				return err
+			}
+			user.CustomRoleID = customRoleID.Ptr()
+		}
+
+		if err := r.repository.User.Create(ctx, user); err != nil {
+			return err
+		}
+
+		// the first admin inherits the single-user watch history and ratings
+		if firstUser {
+			return r.repository.User.ClaimUnownedData(ctx, user.ID)
+		}
+
+		return nil
 	}); err != nil {
 		return nil, err
 	}
@@ -200,8 +220,28 @@ func (r *mutationResolver) UserUpdate(ctx context.Context, input models.UserUpda
 			partial.IsActive = models.NewOptionalBool(*input.IsActive)
 		}
 
+		if input.CustomRoleID != nil {
+			partial.CustomRoleID, err = r.customRoleID(ctx, *input.CustomRoleID)
+			if err != nil {
+				return err
+			}
+		}
+
 		user, err = r.repository.User.Update(ctx, id, partial)
-		return err
+		if err != nil {
+			return err
+		}
+
+		if input.ContentRestriction != nil {
+			restriction, err := r.contentRestriction(ctx, *input.ContentRestriction)
+			if err != nil {
+				return err
+			}
+
+			return r.repository.User.UpdateContentRestriction(ctx, id, *restriction)
+		}
+
+		return nil
 	}); err != nil {
 		return nil, err
 	}
@@ -211,6 +251,69 @@ func (r *mutationResolver) UserUpdate(ctx context.Context, input models.UserUpda
 	return user, nil
 }
 
+// customRoleID validates the ID of a custom role to assign to a user. An
+// empty ID removes the user's custom role.
+func (r *mutationResolver) customRoleID(ctx context.Context, id string) (models.OptionalInt, error) {
+	if id == "" {
+		return models.OptionalInt{Set: true, Null: true}, nil
+	}
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		return models.OptionalInt{}, fmt.Errorf("invalid role id: %w", err)
+	}
+
+	role, err := r.repository.Role.Find(ctx, idInt)
+	if err != nil {
+		return models.OptionalInt{}, err
+	}
+	if role == nil {
+		return models.OptionalInt{}, ErrRoleNotFound
+	}
+
+	return models.NewOptionalInt(idInt), nil
+}
+
+// contentRestriction validates a content restriction input. The attached
+// saved filter, if any, must be a scene filter that can be applied to queries.
+func (r *mutationResolver) contentRestriction(ctx context.Context, input models.ContentRestrictionInput) (*models.ContentRestriction, error) {
+	ret := &models.ContentRestriction{}
+
+	if input.SavedFilterID != nil && *input.SavedFilterID != "" {
+		id, err := strconv.Atoi(*input.SavedFilterID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid saved filter id: %w", err)
+		}
+
+		f, err := r.repository.SavedFilter.Find(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if f == nil {
+			return nil, fmt.Errorf("saved filter with id %d not found", id)
+		}
+
+		if _, err := savedfilter.SceneFilter(f); err != nil {
+			return nil, fmt.Errorf("saved filter %q cannot restrict content: %w", f.Name, err)
+		}
+
+		ret.SavedFilterID = &id
+	}
+
+	var err error
+	ret.ExcludedTagIDs, err = stringslice.StringSliceToIntSlice(input.ExcludedTagIDs)
+	if err != nil {
+		return nil, fmt.Errorf("converting excluded tag ids: %w", err)
+	}
+
+	ret.ExcludedStudioIDs, err = stringslice.StringSliceToIntSlice(input.ExcludedStudioIDs)
+	if err != nil {
+		return nil, fmt.Errorf("converting excluded studio ids: %w", err)
+	}
+
+	return ret, nil
+}
+
 // UserDestroy deletes a user (admin only). Self-deletion is allowed.
 func (r *mutationResolver) UserDestroy(ctx context.Context, id string) (bool, error) {
 	if _, err := r.requireAdmin(ctx); err != nil {
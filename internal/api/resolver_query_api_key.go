@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// FindAPIKeys returns the named API keys of the current user. Admins may list
+// the keys of another user, or of all users.
+func (r *queryResolver) FindAPIKeys(ctx context.Context, userID *string, allUsers *bool) ([]*models.APIKey, error) {
+	user, err := r.getCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrNotAuthenticated
+	}
+
+	ownerID := user.ID
+	if userID != nil {
+		ownerID, err = strconv.Atoi(*userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	all := allUsers != nil && *allUsers
+	if (all || ownerID != user.ID) && !user.IsAdmin() {
+		return nil, ErrNotAuthorized
+	}
+
+	var ret []*models.APIKey
+	if err := r.withReadTxn(ctx, func(ctx context.Context) error {
+		var err error
+		if all {
+			ret, err = r.repository.APIKey.FindAll(ctx)
+		} else {
+			ret, err = r.repository.APIKey.FindByUserID(ctx, ownerID)
+		}
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
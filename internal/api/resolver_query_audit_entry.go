@@ -0,0 +1,29 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// FindAuditEntries queries the audit log (admin only)
+func (r *queryResolver) FindAuditEntries(ctx context.Context, auditFilter *models.AuditEntryFilterType, filter *models.FindFilterType) (*FindAuditEntriesResultType, error) {
+	if _, err := r.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	var entries []*models.AuditEntry
+	var total int
+	if err := r.withReadTxn(ctx, func(ctx context.Context) error {
+		var err error
+		entries, total, err = r.repository.AuditEntry.Query(ctx, auditFilter, filter)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return &FindAuditEntriesResultType{
+		Count:        total,
+		AuditEntries: entries,
+	}, nil
+}
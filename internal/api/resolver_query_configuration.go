@@ -293,6 +293,7 @@ func makeConfigGeneralResult() *ConfigGeneralResult {
 		NativePhashGeneration:         config.GetNativePhashGeneration(),
 		MaxTranscodeSize:              &maxTranscodeSize,
 		MaxStreamingTranscodeSize:     &maxStreamingTranscodeSize,
+		OptimizedVersionProfiles:      config.GetOptimizedVersionProfiles(),
 		WriteImageThumbnails:          config.IsWriteImageThumbnails(),
 		CreateImageClipsFromVideos:    config.IsCreateImageClipsFromVideos(),
 		GalleryCoverRegex:             config.GetGalleryCoverRegex(),
@@ -300,6 +301,7 @@ func makeConfigGeneralResult() *ConfigGeneralResult {
 		Username:                      config.GetUsername(),
 		Password:                      config.GetPasswordHash(),
 		MaxSessionAge:                 config.GetMaxSessionAge(),
+		AuditLogRetentionDays:         config.GetAuditLogRetentionDays(),
 		LogFile:                       &logFile,
 		LogOut:                        config.GetLogOut(),
 		LogLevel:                      config.GetLogLevel(),
@@ -314,6 +316,8 @@ func makeConfigGeneralResult() *ConfigGeneralResult {
 		CustomPerformerImageLocation:  &customPerformerImageLocation,
 		StashBoxes:                    config.GetStashBoxes(),
 		PythonPath:                    config.GetPythonPath(),
+		VisualEmbeddingBackend:        config.GetVisualEmbeddingBackend(),
+		VisualEmbeddingModel:          config.GetVisualEmbeddingModel(),
 		TranscodeInputArgs:            config.GetTranscodeInputArgs(),
 		TranscodeOutputArgs:           config.GetTranscodeOutputArgs(),
 		LiveTranscodeInputArgs:        config.GetLiveTranscodeInputArgs(),
@@ -389,12 +393,14 @@ func makeConfigDLNAResult() *ConfigDLNAResult {
 	config := config.GetInstance()
 
 	return &ConfigDLNAResult{
-		ServerName:     config.GetDLNAServerName(),
-		Enabled:        config.GetDLNADefaultEnabled(),
-		Port:           config.GetDLNAPort(),
-		WhitelistedIPs: config.GetDLNADefaultIPWhitelist(),
-		Interfaces:     config.GetDLNAInterfaces(),
-		VideoSortOrder: config.GetVideoSortOrder(),
+		ServerName:       config.GetDLNAServerName(),
+		Enabled:          config.GetDLNADefaultEnabled(),
+		Port:             config.GetDLNAPort(),
+		WhitelistedIPs:   config.GetDLNADefaultIPWhitelist(),
+		Interfaces:       config.GetDLNAInterfaces(),
+		VideoSortOrder:   config.GetVideoSortOrder(),
+		User:             config.GetDLNAUser(),
+		RendererProfiles: config.GetDLNARendererProfiles(),
 	}
 }
 
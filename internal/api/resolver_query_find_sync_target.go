@@ -0,0 +1,42 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *queryResolver) FindSyncTarget(ctx context.Context, id string) (ret *models.SyncTarget, err error) {
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.withReadTxn(ctx, func(ctx context.Context) error {
+		ret, err = findSyncTarget(ctx, r.repository.SyncTarget, idInt)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// FindSyncTargets returns the sync targets of the current user, or every
+// sync target for admins and in single-user mode.
+func (r *queryResolver) FindSyncTargets(ctx context.Context) (ret []*models.SyncTarget, err error) {
+	if err := r.withReadTxn(ctx, func(ctx context.Context) error {
+		if owner, limited := syncTargetOwner(ctx); limited {
+			ret, err = r.repository.SyncTarget.FindByUserID(ctx, owner)
+			return err
+		}
+
+		ret, err = r.repository.SyncTarget.FindAll(ctx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
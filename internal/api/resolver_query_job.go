@@ -42,6 +42,15 @@ func jobToJobModel(j job.Job) *Job {
 		EndTime:     j.EndTime,
 		AddTime:     j.AddTime,
 		Error:       j.Error,
+		Lane:        JobLane(j.Lane),
+		Pausable:    j.Pausable,
+		Priority:    JobPriorityNormal,
+	}
+
+	for k, v := range jobPriorities {
+		if v == j.Priority {
+			ret.Priority = k
+		}
 	}
 
 	if j.Progress != -1 {
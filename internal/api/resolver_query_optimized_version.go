@@ -0,0 +1,22 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *queryResolver) OptimizedVersionStorage(ctx context.Context) (ret []*models.OptimizedVersionStorage, err error) {
+	if err := r.withReadTxn(ctx, func(ctx context.Context) error {
+		ret, err = r.repository.OptimizedVersion.Storage(ctx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	if ret == nil {
+		ret = []*models.OptimizedVersionStorage{}
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// FindRole returns a custom role by ID (admin only)
+func (r *queryResolver) FindRole(ctx context.Context, id string) (*models.Role, error) {
+	if _, err := r.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var role *models.Role
+	if err := r.withReadTxn(ctx, func(ctx context.Context) error {
+		var err error
+		role, err = r.repository.Role.Find(ctx, idInt)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// FindRoles returns all custom roles (admin only)
+func (r *queryResolver) FindRoles(ctx context.Context) ([]*models.Role, error) {
+	if _, err := r.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	var roles []*models.Role
+	if err := r.withReadTxn(ctx, func(ctx context.Context) error {
+		var err error
+		roles, err = r.repository.Role.FindAll(ctx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
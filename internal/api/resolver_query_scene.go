@@ -18,14 +18,19 @@ func (r *queryResolver) SceneStreams(ctx context.Context, id *string) ([]*manage
 
 	// find the scene
 	var scene *models.Scene
+	var optimized []*models.OptimizedVersion
 	if err := r.withReadTxn(ctx, func(ctx context.Context) error {
 		var err error
 		scene, err = r.repository.Scene.Find(ctx, sceneID)
+		if err != nil || scene == nil {
+			return err
+		}
 
-		if scene != nil {
-			err = scene.LoadPrimaryFile(ctx, r.repository.File)
+		if err := scene.LoadPrimaryFile(ctx, r.repository.File); err != nil {
+			return err
 		}
 
+		optimized, err = r.repository.OptimizedVersion.FindBySceneID(ctx, sceneID)
 		return err
 	}); err != nil {
 		return nil, err
@@ -41,5 +46,5 @@ func (r *queryResolver) SceneStreams(ctx context.Context, id *string) ([]*manage
 	builder := urlbuilders.NewSceneURLBuilder(baseURL, scene)
 	apiKey := config.GetAPIKey()
 
-	return manager.GetSceneStreamPaths(scene, builder.GetStreamURL(apiKey), config.GetMaxStreamingTranscodeSize())
+	return manager.GetSceneStreamPaths(scene, builder.GetStreamURL(apiKey), config.GetMaxStreamingTranscodeSize(), optimized)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/stashapp/stash/internal/manager"
+	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/scheduler"
 )
 
@@ -16,7 +17,7 @@ func (r *queryResolver) ScheduledTasks(ctx context.Context) ([]*ScheduledTask, e
 	tasks := sched.ListTasks()
 	ret := make([]*ScheduledTask, len(tasks))
 	for i, t := range tasks {
-		ret[i] = mapScheduledTask(t)
+		ret[i] = mapScheduledTask(sched, t)
 	}
 	return ret, nil
 }
@@ -31,10 +32,34 @@ func (r *queryResolver) ScheduledTask(ctx context.Context, id string) (*Schedule
 	if task == nil {
 		return nil, nil
 	}
-	return mapScheduledTask(*task), nil
+	return mapScheduledTask(sched, *task), nil
 }
 
-func mapScheduledTask(t scheduler.ScheduledTask) *ScheduledTask {
+// defaultScheduledTaskRunsLimit is the number of runs returned by
+// scheduledTaskRuns when no limit is given
+const defaultScheduledTaskRunsLimit = 50
+
+func (r *queryResolver) ScheduledTaskRuns(ctx context.Context, taskID *string, limit *int) (ret []*models.ScheduledTaskRun, err error) {
+	var id string
+	if taskID != nil {
+		id = *taskID
+	}
+	n := defaultScheduledTaskRunsLimit
+	if limit != nil {
+		n = *limit
+	}
+
+	if err := r.withReadTxn(ctx, func(ctx context.Context) error {
+		ret, err = r.repository.ScheduledTaskRun.FindRecent(ctx, id, n)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func mapScheduledTask(sched *scheduler.Scheduler, t scheduler.ScheduledTask) *ScheduledTask {
 	// Cast TaskType string to enum
 	var taskType ScheduledTaskType
 	switch t.TaskType {
@@ -50,6 +75,20 @@ func mapScheduledTask(t scheduler.ScheduledTask) *ScheduledTask {
 		taskType = ScheduledTaskTypeOptimise
 	case scheduler.ScheduledTaskTypePlugin:
 		taskType = ScheduledTaskTypePlugin
+	case scheduler.ScheduledTaskTypeRebuildProfile:
+		taskType = ScheduledTaskTypeRebuildProfile
+	case scheduler.ScheduledTaskTypeRebuildCoWatch:
+		taskType = ScheduledTaskTypeRebuildCoWatch
+	case scheduler.ScheduledTaskTypeIdentify:
+		taskType = ScheduledTaskTypeIdentify
+	case scheduler.ScheduledTaskTypeBackup:
+		taskType = ScheduledTaskTypeBackup
+	case scheduler.ScheduledTaskTypeExport:
+		taskType = ScheduledTaskTypeExport
+	case scheduler.ScheduledTaskTypeStashBoxTag:
+		taskType = ScheduledTaskTypeStashBoxTag
+	case scheduler.ScheduledTaskTypeAPIHubRelink:
+		taskType = ScheduledTaskTypeAPIHubRelink
 	default:
 		taskType = ScheduledTaskTypeScan // Default/Fallback
 	}
@@ -65,5 +104,11 @@ func mapScheduledTask(t scheduler.ScheduledTask) *ScheduledTask {
 		Options:      &opts,
 		LastRun:      t.LastRun,
 		NextRun:      t.NextRun,
+
+		Then:          append([]string{}, t.Then...),
+		Retries:       t.Retries,
+		RetryDelay:    t.RetryDelay,
+		SkipIfRunning: t.SkipIfRunning,
+		Running:       sched.IsRunning(t.ID),
 	}
 }
@@ -67,16 +67,36 @@ func (r *queryResolver) CurrentUser(ctx context.Context) (*CurrentUser, error) {
 		return nil, nil
 	}
 
+	var role *models.Role
+	if user.CustomRoleID != nil {
+		if err := r.withReadTxn(ctx, func(ctx context.Context) error {
+			var err error
+			role, err = r.repository.Role.Find(ctx, *user.CustomRoleID)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	granted := user.Permissions(role)
+
 	return &CurrentUser{
-		ID:       strconv.Itoa(user.ID),
-		Username: user.Username,
-		Role:     user.Role,
+		ID:         strconv.Itoa(user.ID),
+		Username:   user.Username,
+		Role:       user.Role,
+		CustomRole: role,
 		Permissions: &UserPermissions{
-			CanModify:         user.CanModify(),
-			CanDelete:         user.CanDelete(),
-			CanManageUsers:    user.CanManageUsers(),
-			CanRunTasks:       user.CanRunTasks(),
-			CanModifySettings: user.CanModifySettings(),
+			CanModify:         granted.Has(models.PermissionEditMetadata),
+			CanDelete:         granted.Has(models.PermissionDeleteFiles),
+			CanManageUsers:    user.IsAdmin(),
+			CanRunTasks:       granted.Has(models.PermissionRunJobs),
+			CanModifySettings: user.IsAdmin(),
+			CanManagePlugins:  granted.Has(models.PermissionManagePlugins),
+			CanUseAPIHub:      granted.Has(models.PermissionAPIHubDownloads),
+			CanControlHandy:   granted.Has(models.PermissionControlHandy),
+			CanManageIptv:     granted.Has(models.PermissionManageIPTV),
+			CanViewRecycleBin: granted.Has(models.PermissionViewRecycleBin),
+			ContentRestricted: models.SceneRestrictionFromContext(ctx) != nil,
 		},
 	}, nil
 }
@@ -3,16 +3,18 @@ package api
 import (
 	"context"
 	"fmt"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
-	"time"
 
+	"github.com/stashapp/stash/internal/api/loaders"
 	"github.com/stashapp/stash/internal/manager"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/recommendation"
 	"github.com/stashapp/stash/pkg/recommendation/embedding"
+	"github.com/stashapp/stash/pkg/sliceutil/stringslice"
 	"github.com/stashapp/stash/pkg/stashbox"
 )
 
@@ -53,41 +55,52 @@ func toAttributeWeightMap(slice []models.AttributeWeight) map[string]map[string]
 	return ret
 }
 
-func toTagWeightSlice(profileID int, m map[int]float64) []models.TagWeight {
-	var ret []models.TagWeight
-	for id, w := range m {
-		ret = append(ret, models.TagWeight{ProfileID: profileID, TagID: id, Weight: w})
+// toProfileData converts the loaded weights of a content profile for scoring.
+func toProfileData(profile *models.ContentProfile) *recommendation.ProfileData {
+	return &recommendation.ProfileData{
+		TagWeights:       toTagWeightMap(profile.TagWeights),
+		PerformerWeights: toPerformerWeightMap(profile.PerformerWeights),
+		StudioWeights:    toStudioWeightMap(profile.StudioWeights),
+		AttributeWeights: toAttributeWeightMap(profile.AttributeWeights),
 	}
-	return ret
 }
 
-func toPerformerWeightSlice(profileID int, m map[int]float64) []models.PerformerWeight {
-	var ret []models.PerformerWeight
-	for id, w := range m {
-		ret = append(ret, models.PerformerWeight{ProfileID: profileID, PerformerID: id, Weight: w})
-	}
-	return ret
+// sceneRecommendationWeights are the weights of the factors of scene
+// recommendations, from the options or their defaults.
+type sceneRecommendationWeights struct {
+	tag            float64
+	performer      float64
+	studio         float64
+	coWatch        float64
+	watchedPenalty float64
 }
 
-func toStudioWeightSlice(profileID int, m map[int]float64) []models.StudioWeight {
-	var ret []models.StudioWeight
-	for id, w := range m {
-		ret = append(ret, models.StudioWeight{ProfileID: profileID, StudioID: id, Weight: w})
+func newSceneRecommendationWeights(options *models.RecommendationOptions) sceneRecommendationWeights {
+	// defaults: Tags=0.5, Performers=0.3, Studio=0.2, Co-watch=0.2, no watched penalty
+	ret := sceneRecommendationWeights{
+		tag:       0.5,
+		performer: 0.3,
+		studio:    0.2,
+		coWatch:   0.2,
+	}
+	if options == nil {
+		return ret
 	}
-	return ret
-}
 
-func toAttributeWeightSlice(profileID int, m map[string]map[string]float64) []models.AttributeWeight {
-	var ret []models.AttributeWeight
-	for name, valMap := range m {
-		for val, w := range valMap {
-			ret = append(ret, models.AttributeWeight{
-				ProfileID:      profileID,
-				AttributeName:  name,
-				AttributeValue: val,
-				Weight:         w,
-			})
-		}
+	if options.TagWeight != nil {
+		ret.tag = *options.TagWeight
+	}
+	if options.PerformerWeight != nil {
+		ret.performer = *options.PerformerWeight
+	}
+	if options.StudioWeight != nil {
+		ret.studio = *options.StudioWeight
+	}
+	if options.CoWatchWeight != nil {
+		ret.coWatch = *options.CoWatchWeight
+	}
+	if options.WatchedPenalty != nil {
+		ret.watchedPenalty = math.Max(0, math.Min(1, *options.WatchedPenalty))
 	}
 	return ret
 }
@@ -335,29 +348,83 @@ func (r *recommendationResolver) Studio(ctx context.Context, obj *models.Recomme
 	return ret, err
 }
 
+// --- RecommendationBreakdownResolver implementation ---
+
+func (r *recommendationBreakdownResolver) MatchedTags(ctx context.Context, obj *models.RecommendationBreakdown) ([]*models.WeightedTag, error) {
+	ids := make([]int, len(obj.Tags))
+	for i, m := range obj.Tags {
+		ids[i] = m.ID
+	}
+
+	tags, errs := loaders.From(ctx).TagByID.LoadAll(ids)
+	if err := firstError(errs); err != nil {
+		return nil, err
+	}
+
+	ret := []*models.WeightedTag{}
+	for i, tag := range tags {
+		if tag != nil {
+			ret = append(ret, &models.WeightedTag{Tag: tag, Weight: obj.Tags[i].Weight})
+		}
+	}
+	return ret, nil
+}
+
+func (r *recommendationBreakdownResolver) MatchedPerformers(ctx context.Context, obj *models.RecommendationBreakdown) ([]*models.WeightedPerformer, error) {
+	ids := make([]int, len(obj.Performers))
+	for i, m := range obj.Performers {
+		ids[i] = m.ID
+	}
+
+	performers, errs := loaders.From(ctx).PerformerByID.LoadAll(ids)
+	if err := firstError(errs); err != nil {
+		return nil, err
+	}
+
+	ret := []*models.WeightedPerformer{}
+	for i, performer := range performers {
+		if performer != nil {
+			ret = append(ret, &models.WeightedPerformer{Performer: performer, Weight: obj.Performers[i].Weight})
+		}
+	}
+	return ret, nil
+}
+
+func (r *recommendationBreakdownResolver) MatchedStudio(ctx context.Context, obj *models.RecommendationBreakdown) (*models.WeightedStudio, error) {
+	if obj.Studio == nil {
+		return nil, nil
+	}
+
+	studio, err := loaders.From(ctx).StudioByID.Load(obj.Studio.ID)
+	if err != nil || studio == nil {
+		return nil, err
+	}
+	return &models.WeightedStudio{Studio: studio, Weight: obj.Studio.Weight}, nil
+}
+
 // --- QueryResolver implementation ---
 
-func (r *queryResolver) UserContentProfile(ctx context.Context) (*models.ContentProfile, error) {
-	// Helper to get or build profile
-	// NOTE: Accessed as FIELD now
-	store := r.repository.ContentProfile
+// profileEngine returns an Engine for reading and rebuilding content profiles.
+func (r *Resolver) profileEngine() *recommendation.Engine {
+	return recommendation.NewEngine(
+		r.repository.Scene,
+		r.repository.Performer,
+		r.repository.Studio,
+		r.repository.Tag,
+		r.repository.Gallery,
+		r.repository.Image,
+		r.repository.ContentProfile,
+		nil,
+	)
+}
 
+func (r *queryResolver) UserContentProfile(ctx context.Context) (*models.ContentProfile, error) {
 	var profile *models.ContentProfile
-	err := r.withReadTxn(ctx, func(ctx context.Context) error {
-		profileID := 1
-		if user, _ := GetCurrentUserFromContext(ctx, r.repository.User); user != nil {
-			profileID = user.ID
-		}
+	if err := r.withReadTxn(ctx, func(ctx context.Context) error {
 		var err error
-		profile, err = store.Find(ctx, profileID)
-		if err == nil && profile != nil {
-			// Load weights
-			return store.LoadWeights(ctx, profile)
-		}
+		profile, err = r.profileEngine().UserProfile(ctx)
 		return err
-	})
-
-	if err != nil {
+	}); err != nil {
 		return nil, err
 	}
 
@@ -377,12 +444,7 @@ func (r *queryResolver) RecommendScenes(ctx context.Context, options *models.Rec
 		return nil, err
 	}
 
-	profileData := &recommendation.ProfileData{
-		TagWeights:       toTagWeightMap(profile.TagWeights),
-		PerformerWeights: toPerformerWeightMap(profile.PerformerWeights),
-		StudioWeights:    toStudioWeightMap(profile.StudioWeights),
-		AttributeWeights: toAttributeWeightMap(profile.AttributeWeights),
-	}
+	profileData := toProfileData(profile)
 
 	scorer := recommendation.NewScorer(
 		profileData,
@@ -429,19 +491,9 @@ func (r *queryResolver) RecommendScenes(ctx context.Context, options *models.Rec
 		source = *options.Source
 	}
 
-	// Determine weights (defaults: Tags=0.5, Performers=0.3, Studio=0.2)
-	tagW := 0.5
-	if options != nil && options.TagWeight != nil {
-		tagW = *options.TagWeight
-	}
-	perfW := 0.3
-	if options != nil && options.PerformerWeight != nil {
-		perfW = *options.PerformerWeight
-	}
-	studioW := 0.2
-	if options != nil && options.StudioWeight != nil {
-		studioW = *options.StudioWeight
-	}
+	weights := newSceneRecommendationWeights(options)
+	tagW, perfW, studioW := weights.tag, weights.performer, weights.studio
+	scorer.SetWatchedPenalty(weights.watchedPenalty)
 
 	// Pre-populate seen map with dismissed items and caller-supplied exclude IDs.
 	seen := make(map[string]bool)
@@ -464,8 +516,17 @@ func (r *queryResolver) RecommendScenes(ctx context.Context, options *models.Rec
 	}
 
 	// 1. Local Recommendations
+	var coWatch map[int]float64
 	if source == models.RecommendationSourceLocal || source == models.RecommendationSourceBoth {
 		err = r.withReadTxn(ctx, func(ctx context.Context) error {
+			// Blend in the scenes watched alongside the user's history
+			var err error
+			coWatch, err = r.userCoWatchScores(ctx, weights.coWatch)
+			if err != nil {
+				return err
+			}
+			scorer.SetCoWatch(coWatch, weights.coWatch)
+
 			// Check ExcludeOwned option (default false for local usually, but let's respect the flag)
 			// For local scenes, "ExcludeOwned" loosely translates to "Exclude Watched"
 			// because "Owned" isn't really a concept for local files, but "Watched" is.
@@ -539,89 +600,113 @@ func (r *queryResolver) RecommendScenes(ctx context.Context, options *models.Rec
 	// Only runs for items that have a populated Scene (local results); StashDB
 	// results already carry their own description.
 	if err := r.withReadTxn(ctx, func(ctx context.Context) error {
-		for _, result := range searchResults {
-			if result.Type != "scene" || result.Scene == nil {
-				continue
+		return r.describeSceneRecommendations(ctx, searchResults, profileData, coWatch)
+	}); err != nil {
+		return nil, err
+	}
+
+	return searchResults, nil
+}
+
+// userCoWatchScores returns the co-watch scores of the scenes watched
+// alongside the user's history, or nil if weight is not positive.
+func (r *queryResolver) userCoWatchScores(ctx context.Context, weight float64) (map[int]float64, error) {
+	if weight <= 0 {
+		return nil, nil
+	}
+
+	watched, err := r.repository.CoWatch.WatchedSceneIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return recommendation.CoWatchScores(ctx, r.repository.CoWatch, watched)
+}
+
+// describeSceneRecommendations replaces the reasons of local scene results
+// with the names of the best matching tags, performers and studio.
+func (r *queryResolver) describeSceneRecommendations(ctx context.Context, results []*models.RecommendationResult, profileData *recommendation.ProfileData, coWatch map[int]float64) error {
+	for _, result := range results {
+		if result.Type != "scene" || result.Scene == nil {
+			continue
+		}
+		scene := result.Scene
+		var parts []string
+
+		// Top matching tags from the user profile (highest weight first, ≤3)
+		if scene.TagIDs.Loaded() {
+			type tagW struct {
+				id int
+				w  float64
 			}
-			scene := result.Scene
-			var parts []string
-
-			// Top matching tags from the user profile (highest weight first, ≤3)
-			if scene.TagIDs.Loaded() {
-				type tagW struct {
-					id int
-					w  float64
-				}
-				var matches []tagW
-				for _, tagID := range scene.TagIDs.List() {
-					if w, ok := profileData.TagWeights[tagID]; ok && w > 0 {
-						matches = append(matches, tagW{tagID, w})
-					}
+			var matches []tagW
+			for _, tagID := range scene.TagIDs.List() {
+				if w, ok := profileData.TagWeights[tagID]; ok && w > 0 {
+					matches = append(matches, tagW{tagID, w})
 				}
-				sort.Slice(matches, func(i, j int) bool { return matches[i].w > matches[j].w })
-				var names []string
-				for _, m := range matches {
-					if len(names) >= 3 {
-						break
-					}
-					tag, err := r.repository.Tag.Find(ctx, m.id)
-					if err == nil && tag != nil {
-						names = append(names, tag.Name)
-					}
+			}
+			sort.Slice(matches, func(i, j int) bool { return matches[i].w > matches[j].w })
+			var names []string
+			for _, m := range matches {
+				if len(names) >= 3 {
+					break
 				}
-				if len(names) > 0 {
-					parts = append(parts, strings.Join(names, ", "))
+				tag, err := r.repository.Tag.Find(ctx, m.id)
+				if err == nil && tag != nil {
+					names = append(names, tag.Name)
 				}
 			}
+			if len(names) > 0 {
+				parts = append(parts, strings.Join(names, ", "))
+			}
+		}
 
-			// Top matching performers (≤2)
-			if scene.PerformerIDs.Loaded() {
-				type perfW struct {
-					id int
-					w  float64
-				}
-				var matches []perfW
-				for _, perfID := range scene.PerformerIDs.List() {
-					if w, ok := profileData.PerformerWeights[perfID]; ok && w > 0 {
-						matches = append(matches, perfW{perfID, w})
-					}
+		// Top matching performers (≤2)
+		if scene.PerformerIDs.Loaded() {
+			type perfW struct {
+				id int
+				w  float64
+			}
+			var matches []perfW
+			for _, perfID := range scene.PerformerIDs.List() {
+				if w, ok := profileData.PerformerWeights[perfID]; ok && w > 0 {
+					matches = append(matches, perfW{perfID, w})
 				}
-				sort.Slice(matches, func(i, j int) bool { return matches[i].w > matches[j].w })
-				var names []string
-				for _, m := range matches {
-					if len(names) >= 2 {
-						break
-					}
-					perf, err := r.repository.Performer.Find(ctx, m.id)
-					if err == nil && perf != nil {
-						names = append(names, perf.Name)
-					}
+			}
+			sort.Slice(matches, func(i, j int) bool { return matches[i].w > matches[j].w })
+			var names []string
+			for _, m := range matches {
+				if len(names) >= 2 {
+					break
 				}
-				if len(names) > 0 {
-					parts = append(parts, strings.Join(names, ", "))
+				perf, err := r.repository.Performer.Find(ctx, m.id)
+				if err == nil && perf != nil {
+					names = append(names, perf.Name)
 				}
 			}
+			if len(names) > 0 {
+				parts = append(parts, strings.Join(names, ", "))
+			}
+		}
 
-			// Studio (if in profile)
-			if scene.StudioID != nil {
-				if _, ok := profileData.StudioWeights[*scene.StudioID]; ok {
-					studio, err := r.repository.Studio.Find(ctx, *scene.StudioID)
-					if err == nil && studio != nil {
-						parts = append(parts, studio.Name)
-					}
+		// Studio (if in profile)
+		if scene.StudioID != nil {
+			if _, ok := profileData.StudioWeights[*scene.StudioID]; ok {
+				studio, err := r.repository.Studio.Find(ctx, *scene.StudioID)
+				if err == nil && studio != nil {
+					parts = append(parts, studio.Name)
 				}
 			}
+		}
 
-			if len(parts) > 0 {
-				result.Reason = strings.Join(parts, " · ")
-			}
+		if coWatch[scene.ID] > 0 {
+			parts = append(parts, "viewers also watched")
 		}
-		return nil
-	}); err != nil {
-		return nil, err
-	}
 
-	return searchResults, nil
+		if len(parts) > 0 {
+			result.Reason = strings.Join(parts, " · ")
+		}
+	}
+	return nil
 }
 
 func (r *queryResolver) RecommendPerformers(ctx context.Context, options *models.RecommendationOptions) ([]*models.RecommendationResult, error) {
@@ -797,14 +882,78 @@ func (r *queryResolver) RecommendPerformers(ctx context.Context, options *models
 	return searchResults, nil
 }
 
-func (r *queryResolver) SimilarScenes(ctx context.Context, sceneID string, limit *int) ([]*models.RecommendationResult, error) {
+func (r *queryResolver) ExplainRecommendation(ctx context.Context, sceneID string, similarTo *string, options *models.RecommendationOptions) (*models.RecommendationResult, error) {
+	id, err := strconv.Atoi(sceneID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scene ID: %s", sceneID)
+	}
+
+	// explain the score among the scenes similar to another
+	if similarTo != nil {
+		noLimit := 0
+		results, err := r.SimilarScenes(ctx, *similarTo, &noLimit, options)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range results {
+			if result.ID == sceneID {
+				return result, nil
+			}
+		}
+		return nil, nil
+	}
+
+	// Get profile (this handles its own transactions)
+	profile, err := r.UserContentProfile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	profileData := toProfileData(profile)
+
+	scorer := recommendation.NewScorer(
+		profileData,
+		r.repository.Scene,
+		r.repository.Performer,
+		r.repository.Studio,
+		r.repository.Tag,
+	)
+	weights := newSceneRecommendationWeights(options)
+	scorer.SetWatchedPenalty(weights.watchedPenalty)
+
+	var ret *models.RecommendationResult
+	if err := r.withReadTxn(ctx, func(ctx context.Context) error {
+		scene, err := r.repository.Scene.Find(ctx, id)
+		if err != nil {
+			return err
+		}
+		if scene == nil {
+			return fmt.Errorf("scene with id %d not found", id)
+		}
+
+		coWatch, err := r.userCoWatchScores(ctx, weights.coWatch)
+		if err != nil {
+			return err
+		}
+		scorer.SetCoWatch(coWatch, weights.coWatch)
+
+		result := scorer.ExplainScene(ctx, scene, weights.tag, weights.performer, weights.studio)
+		ret = &result
+		return r.describeSceneRecommendations(ctx, []*models.RecommendationResult{ret}, profileData, coWatch)
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *queryResolver) SimilarScenes(ctx context.Context, sceneID string, limit *int, options *models.RecommendationOptions) ([]*models.RecommendationResult, error) {
 	// Signal weights — must sum to 1.0
 	const (
 		wMeta           = 0.40 // tag / performer / studio co-occurrence
 		wPhash          = 0.35 // perceptual-hash Hamming distance
-		wVisual         = 0.25 // HSV colour-histogram cosine similarity
+		wVisual         = 0.25 // frame embedding / colour-histogram cosine similarity
 		phashMaxDist    = 10   // max Hamming bits to consider a pHash match
-		visualFullThr   = 0.80 // stored-signature cosine threshold (full visual search)
+		visualFullThr   = 0.80 // stored-embedding similarity threshold (full visual search)
 		visualEnrichThr = 0.50 // on-the-fly cosine threshold (candidate enrichment)
 		scoreFloor      = 0.05 // discard anything below this blended score
 	)
@@ -818,6 +967,13 @@ func (r *queryResolver) SimilarScenes(ctx context.Context, sceneID string, limit
 		l = *limit
 	}
 
+	// The co-watch weight takes its share from the other signals, which keep
+	// their relative weights.
+	coWatchW := 0.2
+	if options != nil && options.CoWatchWeight != nil {
+		coWatchW = math.Max(0, math.Min(1, *options.CoWatchWeight))
+	}
+
 	scorer := recommendation.NewScorer(
 		nil,
 		r.repository.Scene,
@@ -826,13 +982,15 @@ func (r *queryResolver) SimilarScenes(ctx context.Context, sceneID string, limit
 		r.repository.Tag,
 	)
 
-	// Per-scene accumulator for the three signals.
+	// Per-scene accumulator for the signals.
 	type signals struct {
-		meta   float64
-		phash  float64
-		visual float64
-		scene  *models.Scene // populated once the scene object is known
-		labels []string      // human-readable reason fragments
+		meta     float64
+		metaInfo *models.RecommendationBreakdown // matched tags / performers / studio
+		phash    float64
+		visual   float64
+		coWatch  float64
+		scene    *models.Scene // populated once the scene object is known
+		labels   []string      // human-readable reason fragments
 	}
 	acc := make(map[int]*signals)
 	entry := func(sid int) *signals {
@@ -856,6 +1014,7 @@ func (r *queryResolver) SimilarScenes(ctx context.Context, sceneID string, limit
 			sid, _ := strconv.Atoi(res.ID)
 			e := entry(sid)
 			e.meta = res.Score
+			e.metaInfo = res.Breakdown
 			e.scene = res.Scene
 			if res.Reason != "" {
 				e.labels = append(e.labels, res.Reason)
@@ -877,38 +1036,47 @@ func (r *queryResolver) SimilarScenes(ctx context.Context, sceneID string, limit
 			}
 		}
 
-		// ── Signal 3: visual colour-histogram similarity ────────────────────────
+		// ── Signal 3: visual similarity ────────────────────────────────────────
+		// 3a – nearest neighbours among the stored frame embeddings, from the
+		// configured backend or else the colour-histogram fallback
+		mgr := manager.GetInstance()
+		for _, model := range dedupStrings([]string{mgr.VisualEmbeddingModel(), embedding.HistogramModel}) {
+			src, embErr := r.repository.VisualEmbedding.Find(ctx, id, model)
+			if embErr != nil {
+				logger.Warnf("[SimilarScenes] visual embedding DB error: %v", embErr)
+				break
+			}
+			if src == nil {
+				continue
+			}
+
+			neighbours, embErr := r.visualNeighbours(ctx, src)
+			if embErr != nil {
+				logger.Warnf("[SimilarScenes] visual embedding search error: %v", embErr)
+				break
+			}
+			for _, n := range neighbours {
+				if n.Similarity < visualFullThr {
+					continue
+				}
+				e := entry(n.ID)
+				if n.Similarity > e.visual {
+					e.visual = n.Similarity
+				}
+				e.labels = append(e.labels, fmt.Sprintf("visual %.0f%%", n.Similarity*100))
+			}
+			break
+		}
+
 		srcCover, coverErr := r.repository.Scene.GetCover(ctx, id)
 		if coverErr == nil && len(srcCover) > 0 {
 			srcSig := embedding.ComputeFromImage(srcCover)
 			if len(srcSig) > 0 {
-				// 3a – full visual search across all pre-computed stored signatures
-				if r.repository.VisualSignature != nil {
-					allSigs, sigErr := r.repository.VisualSignature.GetAll(ctx)
-					if sigErr != nil {
-						logger.Warnf("[SimilarScenes] visual signature DB error: %v", sigErr)
-					} else {
-						for candID, candSig := range allSigs {
-							if candID == id {
-								continue
-							}
-							cos := embedding.Cosine(srcSig, candSig)
-							if cos < visualFullThr {
-								continue
-							}
-							e := entry(candID)
-							if cos > e.visual {
-								e.visual = cos
-							}
-							e.labels = append(e.labels, fmt.Sprintf("visual %.0f%%", cos*100))
-						}
-					}
-				}
-
-				// 3b – on-the-fly enrichment for candidates already identified
+				// 3b – on-the-fly cover histograms for candidates already
+				// identified without a stored embedding match
 				for candID, e := range acc {
 					if e.visual > 0 {
-						continue // already scored from stored sigs
+						continue // already scored from stored embeddings
 					}
 					candCover, err := r.repository.Scene.GetCover(ctx, candID)
 					if err != nil || len(candCover) == 0 {
@@ -929,6 +1097,23 @@ func (r *queryResolver) SimilarScenes(ctx context.Context, sceneID string, limit
 			}
 		}
 
+		// ── Signal 4: scenes watched alongside the source scene ────────────────
+		if coWatchW > 0 {
+			coWatch, coWatchErr := recommendation.CoWatchScores(ctx, r.repository.CoWatch, []int{id})
+			if coWatchErr != nil {
+				return coWatchErr
+			}
+			for candID, score := range coWatch {
+				e := entry(candID)
+				e.coWatch = score
+				e.labels = append(e.labels, "viewers also watched")
+			}
+			// without co-watch data the other signals keep their full weight
+			if len(coWatch) == 0 {
+				coWatchW = 0
+			}
+		}
+
 		// ── Fetch scene objects for candidates without one yet ─────────────────
 		var missing []int
 		for sid, e := range acc {
@@ -948,18 +1133,44 @@ func (r *queryResolver) SimilarScenes(ctx context.Context, sceneID string, limit
 
 		// ── Blend signals → final results ──────────────────────────────────────
 		for sid, e := range acc {
-			blended := e.meta*wMeta + e.phash*wPhash + e.visual*wVisual
+			blended := (e.meta*wMeta+e.phash*wPhash+e.visual*wVisual)*(1-coWatchW) + e.coWatch*coWatchW
 			if blended < scoreFloor || e.scene == nil {
 				continue
 			}
 			reason := strings.Join(dedupStrings(e.labels), " · ")
+
+			breakdown := &models.RecommendationBreakdown{}
+			if e.metaInfo != nil {
+				breakdown.Tags = e.metaInfo.Tags
+				breakdown.Performers = e.metaInfo.Performers
+				breakdown.Studio = e.metaInfo.Studio
+			}
+			for _, f := range []struct {
+				name          string
+				score, weight float64
+			}{
+				{"metadata", e.meta, wMeta * (1 - coWatchW)},
+				{"phash", e.phash, wPhash * (1 - coWatchW)},
+				{"visual", e.visual, wVisual * (1 - coWatchW)},
+				{"co_watch", e.coWatch, coWatchW},
+			} {
+				if f.score > 0 {
+					breakdown.AddFactor(f.name, f.score, f.weight)
+				}
+			}
+			if e.visual > 0 {
+				visual := e.visual
+				breakdown.VisualSimilarity = &visual
+			}
+
 			searchResults = append(searchResults, &models.RecommendationResult{
-				Type:   "scene",
-				ID:     strconv.Itoa(sid),
-				Name:   e.scene.GetTitle(),
-				Score:  blended,
-				Reason: reason,
-				Scene:  e.scene,
+				Type:      "scene",
+				ID:        strconv.Itoa(sid),
+				Name:      e.scene.GetTitle(),
+				Score:     blended,
+				Reason:    reason,
+				Scene:     e.scene,
+				Breakdown: breakdown,
 			})
 		}
 
@@ -975,6 +1186,21 @@ func (r *queryResolver) SimilarScenes(ctx context.Context, sceneID string, limit
 	return searchResults, err
 }
 
+// visualNeighbourCount is the number of nearest stored embeddings considered
+// by SimilarScenes.
+const visualNeighbourCount = 50
+
+// visualNeighbours returns the scenes nearest to src by their stored
+// embeddings of the same model.
+func (r *queryResolver) visualNeighbours(ctx context.Context, src *models.VisualEmbedding) ([]embedding.Neighbour, error) {
+	index, err := manager.GetInstance().VisualEmbeddingIndex.Get(ctx, r.repository.VisualEmbedding, src.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	return embedding.SimilarScenes(ctx, r.repository.VisualEmbedding, index, src, visualNeighbourCount)
+}
+
 // dedupStrings returns a new slice with consecutive duplicate strings removed.
 func dedupStrings(in []string) []string {
 	seen := make(map[string]bool, len(in))
@@ -1025,66 +1251,28 @@ func (r *queryResolver) SimilarPerformers(ctx context.Context, performerID strin
 
 func (r *mutationResolver) RebuildContentProfile(ctx context.Context) (*models.ContentProfile, error) {
 	var profile *models.ContentProfile
-
 	err := r.withTxn(ctx, func(ctx context.Context) error {
-		// Initialize builder
-		builder := recommendation.NewProfileBuilder(
-			r.repository.Scene,
-			r.repository.Performer,
-			r.repository.Tag,
-			r.repository.Studio,
-		)
-
-		// Build profile data
-		data, err := builder.BuildUserProfile(ctx)
-		if err != nil {
-			return err
-		}
-
-		profileID := 1
-		if user, _ := GetCurrentUserFromContext(ctx, r.repository.User); user != nil {
-			profileID = user.ID
-		}
-
-		// Save to DB
-		profile = &models.ContentProfile{
-			ID:               profileID,
-			ProfileType:      "user",
-			TagWeights:       toTagWeightSlice(profileID, data.TagWeights),
-			PerformerWeights: toPerformerWeightSlice(profileID, data.PerformerWeights),
-			StudioWeights:    toStudioWeightSlice(profileID, data.StudioWeights),
-			AttributeWeights: toAttributeWeightSlice(profileID, data.AttributeWeights),
-		}
-
-		// Save using repository
-		store := r.repository.ContentProfile
-
-		// Check if exists first
-		existing, _ := store.Find(ctx, profileID)
-		currentTime := time.Now()
-		profile.UpdatedAt = currentTime
+		var err error
+		profile, err = r.profileEngine().RebuildUserProfile(ctx)
+		return err
+	})
 
-		if existing != nil {
-			profile.CreatedAt = existing.CreatedAt
-			if err := store.Update(ctx, profile); err != nil {
-				return err
-			}
-		} else {
-			profile.CreatedAt = currentTime
-			if err := store.Create(ctx, profile); err != nil {
-				return err
-			}
-		}
+	return profile, err
+}
 
-		// Save weights
-		if err := store.SaveWeights(ctx, profile); err != nil {
-			return err
-		}
+func (r *mutationResolver) RebuildCoWatchModel(ctx context.Context, full *bool) (string, error) {
+	jobID := manager.GetInstance().RebuildCoWatchModel(ctx, full != nil && *full)
+	return strconv.Itoa(jobID), nil
+}
 
-		return nil
-	})
+func (r *mutationResolver) GenerateVisualEmbeddings(ctx context.Context, input GenerateVisualEmbeddingsInput) (string, error) {
+	sceneIDs, err := stringslice.StringSliceToIntSlice(input.SceneIds)
+	if err != nil {
+		return "", fmt.Errorf("converting scene ids: %w", err)
+	}
 
-	return profile, err
+	jobID := manager.GetInstance().GenerateVisualEmbeddings(ctx, sceneIDs, input.Overwrite != nil && *input.Overwrite)
+	return strconv.Itoa(jobID), nil
 }
 
 func (r *mutationResolver) DismissRecommendation(ctx context.Context, entityType string, entityKey string) (bool, error) {
@@ -1108,7 +1296,6 @@ func (r *mutationResolver) UndismissRecommendation(ctx context.Context, entityTy
 func (r *mutationResolver) LikeRecommendation(ctx context.Context, entityType string, entityKey string) (bool, error) {
 	const likeBoost = 0.1
 	const performerLikeBoost = 0.2
-	const profileID = 1
 
 	if err := r.withTxn(ctx, func(ctx context.Context) error {
 		// Record the like (idempotent)
@@ -1126,6 +1313,12 @@ func (r *mutationResolver) LikeRecommendation(ctx context.Context, entityType st
 		}
 
 		cp := r.repository.ContentProfile
+		profile, err := cp.GetOrCreateUserProfile(ctx)
+		if err != nil {
+			return err
+		}
+		profileID := profile.ID
+
 		switch entityType {
 		case "scene":
 			scene, err := r.repository.Scene.Find(ctx, localID)
@@ -1164,7 +1357,6 @@ func (r *mutationResolver) LikeRecommendation(ctx context.Context, entityType st
 func (r *mutationResolver) UnlikeRecommendation(ctx context.Context, entityType string, entityKey string) (bool, error) {
 	const likeBoost = 0.1
 	const performerLikeBoost = 0.2
-	const profileID = 1
 
 	if err := r.withTxn(ctx, func(ctx context.Context) error {
 		if err := r.repository.LikedRecommendation.Unlike(ctx, entityType, entityKey); err != nil {
@@ -1181,6 +1373,12 @@ func (r *mutationResolver) UnlikeRecommendation(ctx context.Context, entityType
 		}
 
 		cp := r.repository.ContentProfile
+		profile, err := cp.GetOrCreateUserProfile(ctx)
+		if err != nil {
+			return err
+		}
+		profileID := profile.ID
+
 		switch entityType {
 		case "scene":
 			scene, err := r.repository.Scene.Find(ctx, localID)
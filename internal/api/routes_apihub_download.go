@@ -89,7 +89,7 @@ func (rs apihubDownloadRoutes) Start(w http.ResponseWriter, r *http.Request) {
 // left to go on. Run a normal library Scan first if the files haven't been
 // (re)imported yet; this job only patches rows that already exist.
 func (rs apihubDownloadRoutes) Relink(w http.ResponseWriter, r *http.Request) {
-	jobID := manager.GetInstance().JobManager.Add(r.Context(), "APIHub: relinking scenes/galleries from manifests", &apihubRelinkJob{})
+	jobID := startApihubRelink(r.Context())
 	writeJSON(w, apihubDownloadStartResponse{JobID: strconv.Itoa(jobID)})
 }
 
@@ -2,6 +2,9 @@ package api
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
@@ -29,17 +32,12 @@ import (
 // ordinary IPTV clients (TiviMate, VLC, Kodi's PVR IPTV Simple, OTT Navigator)
 // can consume over the LAN.
 //
-// The channel stream is *not* a redirect to a scene's own VOD playlist. It is a
-// rolling live playlist stitched by this file: a sliding window of segments with
-// a monotonically increasing media sequence and no EXT-X-ENDLIST, so a channel
-// crosses from one scene into the next without the player ever seeing the stream
-// terminate. Scene changes are announced with EXT-X-DISCONTINUITY, which is
-// precisely what that tag is for.
-//
-// Segments themselves are not re-encoded here. Each one is mapped back onto the
-// owning scene's own segment index and handed to Stash's existing StreamManager,
-// which already owns ffmpeg process lifecycle, look-ahead buffering and idle
-// reaping. That keeps this file to playlist arithmetic and access control.
+// A channel is served two ways. The one IPTV apps get is a single never-ending
+// MPEG-TS body (ChannelStream), remuxed programme after programme into the same
+// response so the player never sees the stream terminate. The other is a
+// rolling live HLS playlist for browsers and Apple devices, whose segments are
+// mapped back onto each scene's own segment index and handed to Stash's
+// existing StreamManager; see routes_iptv_hls.go.
 type iptvRoutes struct {
 	routes
 	repository *models.Repository
@@ -47,8 +45,14 @@ type iptvRoutes struct {
 
 	cycles   *iptvCycleCache
 	channels *iptvChannelCache
+	// scoped holds a separate lineup per content restriction; see iptvScope.
+	scoped   *iptvScopedChannelCaches
 	logos    *iptvLogoCache
 	networks iptvNetworks
+	tuners   *iptvTuners
+	hls      *iptvHLSTimelines
+	// broadcasts share one pipeline among a channel's live viewers.
+	broadcasts *iptvBroadcasts
 }
 
 const (
@@ -110,8 +114,12 @@ func newIPTVRoutes(repo *models.Repository, cfg *config.Config) iptvRoutes {
 		config:     cfg,
 		cycles:     &iptvCycleCache{entries: make(map[string]*iptvCycleEntry)},
 		channels:   &iptvChannelCache{},
+		scoped:     &iptvScopedChannelCaches{caches: make(map[string]*iptvChannelCache)},
 		logos:      &iptvLogoCache{entries: make(map[string]iptvLogoEntry)},
 		networks:   newIPTVNetworks(),
+		tuners:     &iptvTuners{},
+		hls:        newIPTVHLSTimelines(),
+		broadcasts: newIPTVBroadcasts(),
 	}
 }
 
@@ -122,8 +130,8 @@ func (rs iptvRoutes) Routes() chi.Router {
 	r.Get("/playlist.m3u8", rs.Playlist)
 	r.Get("/xmltv.xml", rs.XMLTV)
 	r.Get("/channels.json", rs.ChannelsJSON)
-	r.Get("/rewarm", rs.Rewarm)
-	r.Post("/rewarm", rs.Rewarm)
+	r.With(requirePermission(models.PermissionManageIPTV)).Get("/rewarm", rs.Rewarm)
+	r.With(requirePermission(models.PermissionManageIPTV)).Post("/rewarm", rs.Rewarm)
 
 	// A channel is a single continuous MPEG-TS body. The .ts alias exists
 	// because some clients decide how to demux from the URL suffix rather than
@@ -131,6 +139,11 @@ func (rs iptvRoutes) Routes() chi.Router {
 	r.Get("/ch/{channelId}", rs.ChannelStream)
 	r.Get("/ch/{channelId}.ts", rs.ChannelStream)
 
+	// The same channel as live HLS, for clients that cannot play an endless
+	// response; see routes_iptv_hls.go.
+	r.Get("/hls/{channelId}.m3u8", rs.ChannelHLS)
+	r.Get("/hls/{channelId}/{segment}.ts", rs.ChannelHLSSegment)
+
 	// Logos go out through here rather than straight from /studio/{id}/image so
 	// that SVGs get rasterised first — see ChannelLogo.
 	r.Get("/logo/{channelId}.png", rs.ChannelLogo)
@@ -155,6 +168,17 @@ type iptvSettings struct {
 	// while a network one is ~53KB fetched over the internet.
 	NetworkMinScenes int
 	NetworkPrograms  int
+
+	// TunerCount caps how many channel streams may run at once; zero means no
+	// cap. HDHRDiscovery announces the emulated HDHomeRun tuner over SSDP, with
+	// HDHRAPIKey in its URLs on a server that requires one.
+	TunerCount    int
+	HDHRDiscovery bool
+	HDHRAPIKey    string
+
+	// ArchiveDays is how far back catch-up reaches; zero turns it off. See
+	// routes_iptv_catchup.go.
+	ArchiveDays int
 }
 
 func (rs iptvRoutes) settings() iptvSettings {
@@ -166,6 +190,7 @@ func (rs iptvRoutes) settings() iptvSettings {
 		EPGHours:         iptvDefaultEPGHours,
 		NetworkMinScenes: iptvNetMinReleases,
 		NetworkPrograms:  iptvNetDefaultPrograms,
+		ArchiveDays:      iptvDefaultArchiveDays,
 	}
 
 	pc := rs.config.GetPluginConfiguration(iptvPluginID)
@@ -191,6 +216,18 @@ func (rs iptvRoutes) settings() iptvSettings {
 	if v, ok := pc["groupTitle"].(string); ok && v != "" {
 		s.GroupTitle = v
 	}
+	if v, ok := iptvSettingInt(pc["tunerCount"]); ok && v >= 0 {
+		s.TunerCount = v
+	}
+	if v, ok := iptvSettingInt(pc["archiveDays"]); ok && v >= 0 {
+		s.ArchiveDays = v
+	}
+	if v, ok := pc["hdhrDiscovery"].(bool); ok {
+		s.HDHRDiscovery = v
+	}
+	if v, ok := pc["hdhrApiKey"].(string); ok {
+		s.HDHRAPIKey = strings.TrimSpace(v)
+	}
 	if v, ok := pc["resolution"].(string); ok && v != "" {
 		// An unrecognised value would silently fall through to "no scaling",
 		// which for a 4K library means a channel nobody can play.
@@ -221,12 +258,13 @@ func iptvSettingInt(v interface{}) (int, bool) {
 
 // ─── channel list ─────────────────────────────────────────────────────────────
 
-// iptvChannel is one entry in the lineup. A channel is either a library studio
-// or an API Hub network, distinguished by Source; Key is what appears in URLs
-// and is the only identifier the rest of the pipeline needs.
+// iptvChannel is one entry in the lineup. A channel is a library studio, a
+// user-defined channel or an API Hub network, distinguished by Source; Key is
+// what appears in URLs and is the only identifier the rest of the pipeline
+// needs.
 type iptvChannel struct {
 	Number int `json:"number"`
-	// Source is iptvSourceLibrary or iptvSourceAylo.
+	// Source is iptvSourceLibrary, iptvSourceCustom or a network source.
 	Source string `json:"source"`
 	// Key identifies the channel in a URL. Library channels keep their bare
 	// studio id so playlists configured before networks existed keep working.
@@ -239,6 +277,10 @@ type iptvChannel struct {
 	BrandSlug    string `json:"brand_slug,omitempty"`
 	BrandLabel   string `json:"brand_label,omitempty"`
 	CollectionID int    `json:"collection_id,omitempty"`
+	// Custom is the definition of a user-defined channel, and Blocks its
+	// programming blocks.
+	Custom *models.IPTVChannel        `json:"-"`
+	Blocks []*models.IPTVChannelBlock `json:"-"`
 	// LogoStudioID is the studio whose image represents this channel, which for
 	// a network is the matching library studio when one exists. Zero means fall
 	// back to the placeholder.
@@ -253,7 +295,7 @@ type iptvChannel struct {
 // programme is resolved — and always for the same underlying reason, so it is
 // one predicate rather than a comparison against each provider in turn.
 func (ch iptvChannel) isNetwork() bool {
-	return ch.Source != "" && ch.Source != iptvSourceLibrary
+	return ch.Source != "" && ch.Source != iptvSourceLibrary && ch.Source != iptvSourceCustom
 }
 
 type iptvChannelCache struct {
@@ -279,7 +321,8 @@ type iptvChannelCache struct {
 
 // channelList returns the lineup, rebuilding it at most once per TTL. Channel
 // numbers are assigned by studio name so that adding a studio shifts numbers
-// predictably instead of reshuffling the whole lineup.
+// predictably instead of reshuffling the whole lineup; user-defined channels
+// with a fixed number keep it (see iptvNumberChannels).
 func (rs iptvRoutes) channelList(r *http.Request, s iptvSettings) ([]iptvChannel, map[string]iptvChannel, error) {
 	// Before the cache check, not after, and this is the drive loop for every
 	// provider's background work.
@@ -296,7 +339,10 @@ func (rs iptvRoutes) channelList(r *http.Request, s iptvSettings) ([]iptvChannel
 	// on every playlist, guide and panel request costs a goroutine per provider.
 	rs.networks.warmAll(s)
 
-	c := rs.channels
+	c, err := rs.channelCache(r.Context())
+	if err != nil {
+		return nil, nil, err
+	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -339,11 +385,12 @@ func (rs iptvRoutes) channelList(r *http.Request, s iptvSettings) ([]iptvChannel
 
 	list := make([]iptvChannel, 0, len(studios))
 	studioByName := make(map[string]int, len(studios))
+	var custom []iptvChannel
 	if err := rs.withReadTxn(r, func(ctx context.Context) error {
 		for _, st := range studios {
 			studioByName[strings.ToLower(st.Name)] = st.ID
 
-			count, err := rs.studioSceneCount(ctx, st.ID)
+			count, err := rs.sceneCount(ctx, iptvStudioFilter(st.ID))
 			if err != nil {
 				return err
 			}
@@ -360,7 +407,12 @@ func (rs iptvRoutes) channelList(r *http.Request, s iptvSettings) ([]iptvChannel
 				SceneCount:   count,
 			})
 		}
-		return nil
+
+		// MinScenes does not apply: a user who built a channel wants to see it,
+		// however small.
+		var err error
+		custom, err = rs.customChannels(ctx)
+		return err
 	}); err != nil {
 		return nil, nil, err
 	}
@@ -369,15 +421,19 @@ func (rs iptvRoutes) channelList(r *http.Request, s iptvSettings) ([]iptvChannel
 		return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name)
 	})
 
+	// User-defined channels follow the studios, in the order FindAll returns
+	// them.
+	list = append(list, custom...)
+
 	// Network channels are appended after the library rather than sorted in
 	// among it, so they occupy a stable block at the end of the lineup: adding
 	// or losing one cannot renumber every studio channel on the TV.
 	list = append(list, rs.networks.channels(s, studioByName)...)
 
+	list = iptvNumberChannels(list)
 	byKey := make(map[string]iptvChannel, len(list))
-	for i := range list {
-		list[i].Number = i + 1
-		byKey[list[i].Key] = list[i]
+	for _, ch := range list {
+		byKey[ch.Key] = ch
 	}
 
 	c.list, c.byKey, c.built, c.minSc, c.loaded = list, byKey, time.Now(), s.MinScenes, true
@@ -386,6 +442,69 @@ func (rs iptvRoutes) channelList(r *http.Request, s iptvSettings) ([]iptvChannel
 	return list, byKey, nil
 }
 
+// iptvScopedChannelCaches holds the lineups of restricted users, keyed by
+// iptvScope.
+type iptvScopedChannelCaches struct {
+	mu     sync.Mutex
+	caches map[string]*iptvChannelCache
+}
+
+// iptvScope identifies the content restriction of a request, or returns an
+// empty string for an unrestricted one. Lineups and schedules are cached per
+// scope: a restricted user's channels must not include scenes hidden from
+// them, nor be served to anyone else. Users sharing a restriction share a
+// scope, so the caches stay bounded by the number of distinct restrictions.
+func iptvScope(ctx context.Context) (string, error) {
+	f := models.SceneRestrictionFromContext(ctx)
+	if f == nil {
+		return "", nil
+	}
+
+	data, err := json.Marshal(f)
+	if err != nil {
+		return "", fmt.Errorf("encoding content restriction: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// channelCache returns the lineup cache for the scope of ctx.
+func (rs iptvRoutes) channelCache(ctx context.Context) (*iptvChannelCache, error) {
+	scope, err := iptvScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if scope == "" {
+		return rs.channels, nil
+	}
+
+	sc := rs.scoped
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	c, ok := sc.caches[scope]
+	if !ok {
+		c = &iptvChannelCache{}
+		sc.caches[scope] = c
+	}
+	return c, nil
+}
+
+// invalidateChannels forces every lineup to be rebuilt on its next request.
+func (rs iptvRoutes) invalidateChannels() {
+	rs.channels.mu.Lock()
+	rs.channels.loaded = false
+	rs.channels.mu.Unlock()
+
+	rs.scoped.mu.Lock()
+	defer rs.scoped.mu.Unlock()
+	for _, c := range rs.scoped.caches {
+		c.mu.Lock()
+		c.loaded = false
+		c.mu.Unlock()
+	}
+}
+
 // channelByKey resolves a URL channel id to its lineup entry. A nil channel
 // with a nil error means the id is simply not in the lineup.
 func (rs iptvRoutes) channelByKey(r *http.Request, key string, s iptvSettings) (*iptvChannel, error) {
@@ -419,13 +538,13 @@ func iptvGroupTitle(ch iptvChannel, s iptvSettings) string {
 	return s.GroupTitle + " Networks"
 }
 
-func (rs iptvRoutes) studioSceneCount(ctx context.Context, studioID int) (int, error) {
+func (rs iptvRoutes) sceneCount(ctx context.Context, filter *models.SceneFilterType) (int, error) {
 	result, err := rs.repository.Scene.Query(ctx, models.SceneQueryOptions{
 		QueryOptions: models.QueryOptions{
 			FindFilter: models.BatchFindFilter(0),
 			Count:      true,
 		},
-		SceneFilter: iptvStudioFilter(studioID),
+		SceneFilter: filter,
 	})
 	if err != nil {
 		return 0, err
@@ -477,7 +596,17 @@ func (rs iptvRoutes) cycle(r *http.Request, ch iptvChannel, s iptvSettings, allo
 		max = s.NetworkPrograms
 	}
 
-	if e, ok := c.entries[ch.Key]; ok && e.max == max && time.Since(e.built) < iptvCycleTTL {
+	// library schedules depend on which scenes the request may see
+	scope, err := iptvScope(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	cacheKey := ch.Key
+	if scope != "" && !ch.isNetwork() {
+		cacheKey = scope + ":" + ch.Key
+	}
+
+	if e, ok := c.entries[cacheKey]; ok && e.max == max && time.Since(e.built) < iptvCycleTTL {
 		return e.cycle, nil
 	}
 
@@ -504,72 +633,181 @@ func (rs iptvRoutes) cycle(r *http.Request, ch iptvChannel, s iptvSettings, allo
 		}
 		scenes = entries
 	} else if err := rs.withReadTxn(r, func(ctx context.Context) error {
-		studioID := ch.StudioID
+		src, err := rs.librarySource(ctx, ch)
+		if err != nil {
+			return err
+		}
+		scenes, err = rs.libraryScenes(ctx, src, s.MaxPrograms)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	channelID := ch.StudioID
+	if ch.isNetwork() || ch.Custom != nil {
+		channelID = iptvNetChannelSeed(ch.Key)
+	}
+	cycle := iptv.BuildCycle(channelID, scenes)
+	if !volatile {
+		c.entries[cacheKey] = &iptvCycleEntry{cycle: cycle, built: time.Now(), max: max}
+	}
+
+	logger.Debugf("[iptv] built schedule for channel %s (%s): %d programmes, %d segments (%s)",
+		ch.Key, ch.Name, len(cycle.Programs), cycle.TotalSegs,
+		(time.Duration(cycle.TotalSegs) * iptv.SegmentSeconds * time.Second).Round(time.Minute))
+
+	return cycle, nil
+}
+
+// schedule returns a channel's weekly grid: its cycle, with the channel's
+// programming blocks laid over it at local time. A block that cannot be built
+// is left out, so that its hours fall back to the channel's own rotation
+// rather than taking the channel off the air.
+func (rs iptvRoutes) schedule(r *http.Request, ch iptvChannel, s iptvSettings, allowFetch bool) (*iptv.Grid, error) {
+	base, err := rs.cycle(r, ch, s, allowFetch)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := make([]iptv.Block, 0, len(ch.Blocks))
+	for _, b := range ch.Blocks {
+		c, err := rs.blockCycle(r, ch, b, s)
+		if err != nil {
+			logger.Warnf("[iptv] channel %s: leaving out block %q: %v", ch.Key, b.Name, err)
+			continue
+		}
+		blocks = append(blocks, iptvGridBlock(b, c))
+	}
+
+	return iptv.NewGrid(base, blocks, time.Local), nil
+}
+
+// libraryScenes returns the rotation of a source airing local scenes, capped
+// at max programmes.
+func (rs iptvRoutes) libraryScenes(ctx context.Context, src iptvLibrarySource, max int) ([]iptv.SceneEntry, error) {
+	var found []*models.Scene
+
+	if src.playlist != nil {
+		criteria, err := iptvPlaylistCriteria(src.playlist)
+		if err != nil {
+			return nil, err
+		}
+
+		if criteria != nil {
+			// a dynamic playlist airs in its own sort order, and no more
+			// scenes than it is limited to
+			var findFilter models.FindFilterType
+			if criteria.FindFilter != nil {
+				findFilter = *criteria.FindFilter
+			}
+			page := 1
+			perPage := max
+			if findFilter.PerPage != nil && *findFilter.PerPage > 0 && *findFilter.PerPage < perPage {
+				perPage = *findFilter.PerPage
+			}
+			findFilter.Page = &page
+			findFilter.PerPage = &perPage
+
+			found, err = rs.queryScenes(ctx, criteria.SceneFilter, &findFilter)
+		} else {
+			found, err = rs.playlistScenes(ctx, src.playlist.ID, max)
+		}
+		if err != nil {
+			return nil, err
+		}
+	} else {
 		// Stash's `random_<seed>` sort is a deterministic function of the row id,
 		// so a fixed per-channel seed gives a stable shuffle that also survives
 		// the LIMIT — letting us cap the rotation without reading the whole
 		// studio into memory just to shuffle it here.
-		sortBy := fmt.Sprintf("random_%d", iptv.ShuffleSeed(studioID))
+		sortBy := fmt.Sprintf("random_%d", src.seed)
 		page := 1
-		perPage := s.MaxPrograms
-
-		result, err := rs.repository.Scene.Query(ctx, models.SceneQueryOptions{
-			QueryOptions: models.QueryOptions{
-				FindFilter: &models.FindFilterType{
-					Sort:    &sortBy,
-					Page:    &page,
-					PerPage: &perPage,
-				},
-			},
-			SceneFilter: iptvStudioFilter(studioID),
+		perPage := max
+
+		var err error
+		found, err = rs.queryScenes(ctx, src.filter, &models.FindFilterType{
+			Sort:    &sortBy,
+			Page:    &page,
+			PerPage: &perPage,
 		})
 		if err != nil {
-			return err
+			return nil, err
 		}
+	}
 
-		found, err := result.Resolve(ctx)
-		if err != nil {
-			return err
+	scenes := make([]iptv.SceneEntry, 0, len(found))
+	for _, scene := range found {
+		if err := scene.LoadFiles(ctx, rs.repository.Scene); err != nil {
+			return nil, err
 		}
 
-		scenes = make([]iptv.SceneEntry, 0, len(found))
-		for _, scene := range found {
-			if err := scene.LoadFiles(ctx, rs.repository.Scene); err != nil {
-				return err
-			}
+		f := scene.Files.Primary()
+		if f == nil || f.Duration <= 0 {
+			continue
+		}
 
-			f := scene.Files.Primary()
-			if f == nil || f.Duration <= 0 {
-				continue
-			}
+		scenes = append(scenes, iptv.SceneEntry{
+			SceneID:  scene.ID,
+			Title:    scene.GetTitle(),
+			Details:  scene.Details,
+			Date:     iptvSceneDate(scene),
+			Duration: f.Duration,
+		})
+	}
+	return scenes, nil
+}
 
-			scenes = append(scenes, iptv.SceneEntry{
-				SceneID:  scene.ID,
-				Title:    scene.GetTitle(),
-				Details:  scene.Details,
-				Date:     iptvSceneDate(scene),
-				Duration: f.Duration,
-			})
-		}
-		return nil
-	}); err != nil {
+func (rs iptvRoutes) queryScenes(ctx context.Context, filter *models.SceneFilterType, findFilter *models.FindFilterType) ([]*models.Scene, error) {
+	result, err := rs.repository.Scene.Query(ctx, models.SceneQueryOptions{
+		QueryOptions: models.QueryOptions{
+			FindFilter: findFilter,
+		},
+		SceneFilter: filter,
+	})
+	if err != nil {
 		return nil, err
 	}
+	return result.Resolve(ctx)
+}
 
-	channelID := ch.StudioID
-	if ch.isNetwork() {
-		channelID = iptvNetChannelSeed(ch.Key)
+// playlistScenes returns the scenes of a static playlist in playlist order,
+// skipping its other media and any scene the request may not see. A scene
+// listed twice airs twice.
+func (rs iptvRoutes) playlistScenes(ctx context.Context, playlistID int, max int) ([]*models.Scene, error) {
+	items, err := rs.repository.Playlist.FindItems(ctx, playlistID)
+	if err != nil {
+		return nil, err
 	}
-	cycle := iptv.BuildCycle(channelID, scenes)
-	if !volatile {
-		c.entries[ch.Key] = &iptvCycleEntry{cycle: cycle, built: time.Now(), max: max}
+
+	var ids []int
+	for _, item := range items {
+		if item.MediaType == models.PlaylistMediaTypeScene && item.SceneID != nil {
+			ids = append(ids, *item.SceneID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
 	}
 
-	logger.Debugf("[iptv] built schedule for channel %s (%s): %d programmes, %d segments (%s)",
-		ch.Key, ch.Name, len(cycle.Programs), cycle.TotalSegs,
-		(time.Duration(cycle.TotalSegs) * iptv.SegmentSeconds * time.Second).Round(time.Minute))
+	found, err := rs.repository.Scene.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int]*models.Scene, len(found))
+	for _, scene := range found {
+		byID[scene.ID] = scene
+	}
 
-	return cycle, nil
+	ret := make([]*models.Scene, 0, len(ids))
+	for _, id := range ids {
+		if scene, ok := byID[id]; ok {
+			ret = append(ret, scene)
+			if len(ret) == max {
+				break
+			}
+		}
+	}
+	return ret, nil
 }
 
 func iptvSceneDate(scene *models.Scene) string {
@@ -593,12 +831,19 @@ func (rs iptvRoutes) Playlist(w http.ResponseWriter, r *http.Request) {
 
 	base := iptvBaseURL(r)
 	apiKey := r.URL.Query().Get("apikey")
+	// ?format=hls lists the live HLS variant of each channel that has one,
+	// for players that cannot hold an endless response open.
+	hls := r.URL.Query().Get("format") == "hls"
 
 	var b strings.Builder
 	fmt.Fprintf(&b, "#EXTM3U x-tvg-url=%q\n", iptvURL(base, "/iptv/xmltv.xml", apiKey))
 
 	for _, ch := range channels {
-		streamURL := iptvURL(base, fmt.Sprintf("/iptv/ch/%s.ts", ch.Key), apiKey)
+		tsURL := iptvURL(base, fmt.Sprintf("/iptv/ch/%s.ts", ch.Key), apiKey)
+		streamURL := tsURL
+		if hls && !ch.isNetwork() {
+			streamURL = iptvURL(base, fmt.Sprintf("/iptv/hls/%s.m3u8", ch.Key), apiKey)
+		}
 		logoURL := iptvURL(base, fmt.Sprintf("/iptv/logo/%s.png", ch.Key), apiKey)
 
 		// A raw MPEG-TS body needs no adaptive-manifest hints — every one of
@@ -606,13 +851,22 @@ func (rs iptvRoutes) Playlist(w http.ResponseWriter, r *http.Request) {
 		// since the stream is paced in real time and has no segments to prefetch.
 		b.WriteString("#EXTVLCOPT:network-caching=1500\n")
 
+		// Any past programme can be replayed (see routes_iptv_catchup.go), so
+		// every channel advertises the same archive.
+		var catchup string
+		if s.ArchiveDays > 0 {
+			catchup = fmt.Sprintf(` catchup="default" catchup-days="%d" catchup-source=%q`,
+				s.ArchiveDays, iptvCatchupSource(tsURL))
+		}
+
 		fmt.Fprintf(&b,
-			"#EXTINF:-1 tvg-id=%q tvg-chno=%q tvg-name=%q tvg-logo=%q group-title=%q,%s\n",
+			"#EXTINF:-1 tvg-id=%q tvg-chno=%q tvg-name=%q tvg-logo=%q group-title=%q%s,%s\n",
 			ch.TvgID,
 			strconv.Itoa(ch.Number),
 			iptvEscapeAttr(ch.Name),
 			logoURL,
 			iptvEscapeAttr(iptvGroupTitle(ch, s)),
+			catchup,
 			iptvEscapeAttr(ch.Name),
 		)
 		b.WriteString(streamURL)
@@ -627,6 +881,18 @@ func (rs iptvRoutes) Playlist(w http.ResponseWriter, r *http.Request) {
 
 // ─── channel stream ───────────────────────────────────────────────────────────
 
+// streamChannel serves ChannelStream for the channel with the given key, for
+// routes that identify channels some other way. ChannelStream reads the key
+// via chi.URLParam(r, "channelId"), so this hands it a fresh chi route context
+// carrying that param.
+func (rs iptvRoutes) streamChannel(w http.ResponseWriter, r *http.Request, key string) {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("channelId", key)
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	rs.ChannelStream(w, r)
+}
+
 // ChannelStream serves a channel as one never-ending MPEG-TS response.
 //
 // The handler loops: it asks the schedule what is on air right now, runs ffmpeg
@@ -644,6 +910,9 @@ func (rs iptvRoutes) Playlist(w http.ResponseWriter, r *http.Request) {
 // earlier bytes to request. And it is paced by `-re`, so the server hands over
 // frames at playback speed instead of letting a client drain a whole scene in
 // seconds and run ahead of the schedule.
+//
+// Everyone watching a channel live sees the same frames, so they share one
+// pipeline rather than running one each; see routes_iptv_broadcast.go.
 func (rs iptvRoutes) ChannelStream(w http.ResponseWriter, r *http.Request) {
 	s := rs.settings()
 
@@ -661,7 +930,7 @@ func (rs iptvRoutes) ChannelStream(w http.ResponseWriter, r *http.Request) {
 	// The one caller that may go to the network: a viewer tuning in can wait a
 	// moment, and refusing to play because a schedule had not been warmed yet
 	// would just be a broken channel.
-	cycle, err := rs.cycle(r, *ch, s, true)
+	grid, err := rs.schedule(r, *ch, s, true)
 	if err != nil {
 		// A channel that is still being prepared has not failed, and saying so
 		// with a 500 is actively misleading — both to whoever reads the log and
@@ -679,17 +948,57 @@ func (rs iptvRoutes) ChannelStream(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "error building schedule", http.StatusInternalServerError)
 		return
 	}
-	if cycle.Empty() {
+	if grid.Empty() {
 		http.Error(w, "channel has no playable content", http.StatusNotFound)
 		return
 	}
 
+	shift, err := iptvParseTimeshift(r.URL.Query(), time.Now(), s.ArchiveDays)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errIPTVCatchupDisabled) || errors.Is(err, errIPTVOutsideArchive) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
 	ff := manager.GetInstance().FFMpeg
 	if ff == nil {
 		http.Error(w, "ffmpeg is not configured", http.StatusServiceUnavailable)
 		return
 	}
 
+	// A live viewer joins the channel's broadcast, which takes a tuner only if
+	// it is not on air already. A catch-up stream is the viewer's alone.
+	var (
+		viewer  *iptvViewer
+		release func()
+		ok      bool
+	)
+	if shift.live() {
+		scope, err := iptvScope(r.Context())
+		if err != nil {
+			logger.Errorf("[iptv] scoping channel %s: %v", ch.Key, err)
+			http.Error(w, "error resolving channel", http.StatusInternalServerError)
+			return
+		}
+		viewer, ok = rs.broadcasts.join(r, scope, ch.Key, func() (func(), bool) {
+			return rs.tuners.acquire(s.TunerCount)
+		}, func(ctx context.Context, r *http.Request, out io.Writer) {
+			rs.airChannel(ctx, ff, out, r, *ch, grid, s, shift)
+		})
+	} else {
+		release, ok = rs.tuners.acquire(s.TunerCount)
+	}
+	if !ok {
+		// HDHomeRun's error header is harmless to everyone else, and lets a
+		// media server tell a busy tuner from a broken channel.
+		w.Header().Set("X-HDHomeRun-Error", iptvHDHRAllTunersInUse)
+		http.Error(w, "all tuners are in use", http.StatusServiceUnavailable)
+		return
+	}
+
 	w.Header().Set("Content-Type", "video/mp2t")
 	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
 	w.Header().Set("Pragma", "no-cache")
@@ -701,16 +1010,62 @@ func (rs iptvRoutes) ChannelStream(w http.ResponseWriter, r *http.Request) {
 		f.Flush()
 	}
 
-	ctx := r.Context()
+	if viewer != nil {
+		defer rs.broadcasts.leave(viewer)
+		viewer.copyTo(r.Context(), out)
+		return
+	}
+
+	defer release()
+	rs.airChannel(r.Context(), ff, out, r, *ch, grid, s, shift)
+}
+
+// airChannel runs a channel into out until ctx ends or the channel fails,
+// one programme after another.
+func (rs iptvRoutes) airChannel(
+	ctx context.Context,
+	ff *ffmpeg.FFMpeg,
+	out io.Writer,
+	r *http.Request,
+	ch iptvChannel,
+	grid *iptv.Grid,
+	s iptvSettings,
+	shift iptvTimeshift,
+) {
 	failures := 0
 
+	// A catch-up stream is the same loop run against a clock held a fixed
+	// distance behind the real one.
+	var delay time.Duration
+	if !shift.live() {
+		delay = time.Since(shift.Start)
+	}
+
 	for ctx.Err() == nil {
-		program, offset, ok := iptvOnAir(cycle, time.Now())
+		// A broadcast can stay on air for days, well past the schedule it
+		// started with, so each programme is looked up on a current one.
+		if g, err := rs.schedule(r, ch, s, false); err == nil && !g.Empty() {
+			grid = g
+		}
+
+		now := time.Now().Add(-delay)
+		if !shift.End.IsZero() && !now.Before(shift.End) {
+			return
+		}
+
+		airing, offset, ok := iptvOnAir(grid, now)
 		if !ok {
 			return
 		}
+		program := airing.Program
 
-		remaining := float64(program.Segments*iptv.SegmentSeconds) - offset
+		// Measured to the end of the airing rather than of the programme: a
+		// programming block can cut a programme short.
+		end := airing.End
+		if !shift.End.IsZero() && shift.End.Before(end) {
+			end = shift.End
+		}
+		remaining := end.Sub(now).Seconds()
 
 		// Barely any airtime left in this slot: waiting it out lands cleanly on
 		// the next programme instead of spawning an ffmpeg only to kill it.
@@ -727,9 +1082,9 @@ func (rs iptvRoutes) ChannelStream(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
-		written, err := rs.pipeProgram(ctx, ff, out, r, *ch, program, offset, remaining, s)
+		written, err := rs.pipeProgram(ctx, ff, out, r, ch, program, offset, remaining, s)
 		if ctx.Err() != nil {
-			return // client hung up; not an error
+			return // nobody is watching any more; not an error
 		}
 
 		if written >= iptvMinUsefulBytes {
@@ -766,21 +1121,18 @@ func (rs iptvRoutes) ChannelStream(w http.ResponseWriter, r *http.Request) {
 // forward across programmes, so a stream that drifts — a slow read, a scene
 // truncated to whole segments — is pulled back into line at each boundary
 // instead of accumulating error over an evening.
-func iptvOnAir(cycle *iptv.Cycle, now time.Time) (iptv.Program, float64, bool) {
-	abs := iptv.AbsSegment(now)
-
-	slot, ok := cycle.Locate(abs)
+func iptvOnAir(grid *iptv.Grid, now time.Time) (iptv.Airing, float64, bool) {
+	a, ok := grid.At(now)
 	if !ok {
-		return iptv.Program{}, 0, false
+		return iptv.Airing{}, 0, false
 	}
 
-	programStart := iptv.SegmentTime(abs - int64(slot.LocalSeg))
-	offset := now.Sub(programStart).Seconds()
+	offset := a.Offset + now.Sub(a.Start).Seconds()
 	if offset < 0 {
 		offset = 0
 	}
 
-	return slot.Program, offset, true
+	return a, offset, true
 }
 
 // programSource is everything the streamer needs about a scene's file. Codecs
@@ -1035,7 +1387,8 @@ type iptvLogoCache struct {
 //
 // Network channels have no studio of their own, so they borrow the image of the
 // like-named library studio when one exists (LogoStudioID) and fall back to the
-// same placeholder a logo-less studio gets.
+// same placeholder a logo-less studio gets. User-defined channels use their own
+// logo, or the image of their source.
 func (rs iptvRoutes) ChannelLogo(w http.ResponseWriter, r *http.Request) {
 	key := chi.URLParam(r, "channelId")
 
@@ -1050,7 +1403,16 @@ func (rs iptvRoutes) ChannelLogo(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var stored []byte
-	if ch != nil && ch.LogoStudioID > 0 {
+	if ch != nil && ch.Custom != nil {
+		custom := ch.Custom
+		if err := rs.withReadTxn(r, func(ctx context.Context) error {
+			var err error
+			stored, err = rs.customChannelLogo(ctx, custom)
+			return err
+		}); err != nil {
+			logger.Warnf("[iptv] reading logo for channel %d: %v", custom.ID, err)
+		}
+	} else if ch != nil && ch.LogoStudioID > 0 {
 		studioID := ch.LogoStudioID
 		if err := rs.withReadTxn(r, func(ctx context.Context) error {
 			var err error
@@ -1121,8 +1483,7 @@ func (rs iptvRoutes) XMLTV(w http.ResponseWriter, r *http.Request) {
 	base := iptvBaseURL(r)
 	apiKey := r.URL.Query().Get("apikey")
 
-	from := time.Now().Add(-1 * time.Hour) // include what is already in progress
-	to := time.Now().Add(time.Duration(s.EPGHours) * time.Hour)
+	now := time.Now()
 
 	var b strings.Builder
 	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
@@ -1142,12 +1503,12 @@ func (rs iptvRoutes) XMLTV(w http.ResponseWriter, r *http.Request) {
 		// hundred-odd network catalogs read synchronously is minutes of stall.
 		// Channels whose schedule is still warming are simply left out of this
 		// guide and appear in the next one.
-		cycle, err := rs.cycle(r, ch, s, false)
-		if err != nil || cycle.Empty() {
+		grid, err := rs.schedule(r, ch, s, false)
+		if err != nil || grid.Empty() {
 			continue
 		}
 
-		for _, a := range cycle.Airings(from, to, iptvMaxEPGEntries) {
+		for _, a := range iptvGuideAirings(grid, now, s) {
 			fmt.Fprintf(&b, "  <programme start=%q stop=%q channel=%q>\n",
 				a.Start.Format(iptvXMLTVTimeFormat),
 				a.End.Format(iptvXMLTVTimeFormat),
@@ -1174,6 +1535,9 @@ func (rs iptvRoutes) XMLTV(w http.ResponseWriter, r *http.Request) {
 				fmt.Fprintf(&b, "    <date>%s</date>\n",
 					iptvEscapeXML(strings.ReplaceAll(a.Program.Date, "-", "")))
 			}
+			if a.Block != "" {
+				fmt.Fprintf(&b, "    <category>%s</category>\n", iptvEscapeXML(a.Block))
+			}
 			fmt.Fprintf(&b, "    <category>%s</category>\n", iptvEscapeXML(ch.Name))
 
 			b.WriteString("  </programme>\n")
@@ -1191,7 +1555,10 @@ func (rs iptvRoutes) XMLTV(w http.ResponseWriter, r *http.Request) {
 
 type iptvNowPlaying struct {
 	iptvChannel
-	LogoURL   string `json:"logo_url"`
+	LogoURL string `json:"logo_url"`
+	HLSURL  string `json:"hls_url,omitempty"`
+	// Viewers counts who is watching the channel live, not on catch-up.
+	Viewers   int    `json:"viewers"`
 	Programs  int    `json:"programs"`
 	CycleSecs int    `json:"cycle_seconds"`
 	SceneID   int    `json:"scene_id,omitempty"`
@@ -1199,6 +1566,8 @@ type iptvNowPlaying struct {
 	StartedAt string `json:"started_at,omitempty"`
 	EndsAt    string `json:"ends_at,omitempty"`
 	Progress  int    `json:"progress_percent"`
+	// Block names the programming block on air, if any.
+	Block string `json:"block,omitempty"`
 
 	// Status and StatusDetail explain a channel that is not showing anything.
 	// Without them the panel has one blank state for four quite different
@@ -1230,6 +1599,10 @@ func (rs iptvRoutes) ChannelsJSON(w http.ResponseWriter, r *http.Request) {
 			// real lineup rather than a second rendering path.
 			LogoURL: iptvURL(base, fmt.Sprintf("/iptv/logo/%s.png", ch.Key), apiKey),
 		}
+		entry.Viewers = rs.broadcasts.viewers(ch.Key)
+		if !ch.isNetwork() {
+			entry.HLSURL = iptvURL(base, fmt.Sprintf("/iptv/hls/%s.m3u8", ch.Key), apiKey)
+		}
 
 		if ns := rs.networks.bySource(ch.Source); ns != nil {
 			entry.Status, entry.StatusDetail = ns.channelStatus(ch.Key, s)
@@ -1238,21 +1611,21 @@ func (rs iptvRoutes) ChannelsJSON(w http.ResponseWriter, r *http.Request) {
 		// Same reasoning as the guide: the panel lists every channel, so a
 		// channel still warming shows without now-playing rather than blocking
 		// the whole page on its catalog.
-		cycle, err := rs.cycle(r, ch, s, false)
-		if err == nil && !cycle.Empty() {
-			entry.Programs = len(cycle.Programs)
-			entry.CycleSecs = cycle.TotalSegs * iptv.SegmentSeconds
-
-			if slot, ok := cycle.Locate(iptv.AbsSegment(now)); ok {
-				started := iptv.SegmentTime(iptv.AbsSegment(now) - int64(slot.LocalSeg))
-				entry.SceneID = slot.Program.SceneID
-				entry.Title = slot.Program.Title
-				entry.StartedAt = started.Format(time.RFC3339)
-				entry.EndsAt = started.
-					Add(time.Duration(slot.Program.Segments) * iptv.SegmentSeconds * time.Second).
-					Format(time.RFC3339)
-				if slot.Program.Segments > 0 {
-					entry.Progress = slot.LocalSeg * 100 / slot.Program.Segments
+		grid, err := rs.schedule(r, ch, s, false)
+		if err == nil && !grid.Empty() {
+			if !grid.Base.Empty() {
+				entry.Programs = len(grid.Base.Programs)
+				entry.CycleSecs = grid.Base.TotalSegs * iptv.SegmentSeconds
+			}
+
+			if a, ok := grid.At(now); ok {
+				entry.SceneID = a.Program.SceneID
+				entry.Title = a.Program.Title
+				entry.Block = a.Block
+				entry.StartedAt = a.Start.Format(time.RFC3339)
+				entry.EndsAt = a.End.Format(time.RFC3339)
+				if d := a.End.Sub(a.Start); d > 0 {
+					entry.Progress = int(now.Sub(a.Start) * 100 / d)
 				}
 			}
 		}
@@ -1264,9 +1637,15 @@ func (rs iptvRoutes) ChannelsJSON(w http.ResponseWriter, r *http.Request) {
 		"playlist_url": iptvURL(base, "/iptv/playlist.m3u", apiKey),
 		"epg_url":      iptvURL(base, "/iptv/xmltv.xml", apiKey),
 		"lan_urls":     iptvLANPlaylistURLs(r, apiKey),
-		"resolution":   s.Resolution,
-		"networks":     rs.networks.statuses(s),
-		"channels":     out,
+		"hdhr_url":     hdhrBaseURL(r),
+		"archive_days": s.ArchiveDays,
+		"tuners": map[string]int{
+			"in_use": rs.tuners.count(),
+			"count":  s.TunerCount,
+		},
+		"resolution": s.Resolution,
+		"networks":   rs.networks.statuses(s),
+		"channels":   out,
 	})
 }
 
@@ -1276,9 +1655,7 @@ func (rs iptvRoutes) Rewarm(w http.ResponseWriter, r *http.Request) {
 	s := rs.settings()
 	rs.networks.forceWarm(s, source)
 
-	rs.channels.mu.Lock()
-	rs.channels.loaded = false
-	rs.channels.mu.Unlock()
+	rs.invalidateChannels()
 
 	logger.Infof("[iptv] forced re-warm triggered for network source %q", source)
 	rs.ChannelsJSON(w, r)
@@ -0,0 +1,422 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+// Broadcasts.
+//
+// What a channel airs is a function of the clock, not of who is watching, so
+// every live viewer of a channel would otherwise run an identical ffmpeg. A
+// broadcast runs it once: airChannel writes into a ring buffer of MPEG-TS, and
+// each viewer copies out of the ring at its own pace. A viewer tuning in part
+// way through starts at the most recent keyframe in the ring, preceded by the
+// stream's PAT and PMT, so the player has everything it needs to start
+// decoding straight away.
+//
+// The broadcast outlives its last viewer by iptvBroadcastGrace, which covers
+// the common case of a player reconnecting or a viewer flicking away and
+// back, then stops. A viewer that falls so far behind the ring is overwritten
+// under it skips forward to the latest keyframe, which a TS player shows as a
+// glitch rather than a stall that would hold everyone else back.
+
+const (
+	// iptvBroadcastBuffer is the size of a broadcast's ring buffer: several
+	// seconds of any plausible stream, so it always holds a keyframe to join
+	// at and some slack for a slow viewer.
+	iptvBroadcastBuffer = 16 << 20
+
+	iptvBroadcastGrace = 20 * time.Second
+
+	iptvTSPacketSize = 188
+	iptvTSSyncByte   = 0x47
+)
+
+// iptvBroadcasts holds the channels on air, one broadcast per channel and
+// content scope.
+type iptvBroadcasts struct {
+	mu      sync.Mutex
+	entries map[string]*iptvBroadcast
+	grace   time.Duration
+}
+
+type iptvBroadcast struct {
+	key     string
+	channel string
+	ring    *iptvTSRing
+	cancel  context.CancelFunc
+
+	// viewers and idle are guarded by iptvBroadcasts.mu.
+	viewers int
+	idle    *time.Timer
+}
+
+// iptvViewer is one viewer's place in a broadcast.
+type iptvViewer struct {
+	broadcast *iptvBroadcast
+	pos       int64
+	synced    bool
+}
+
+func newIPTVBroadcasts() *iptvBroadcasts {
+	return &iptvBroadcasts{
+		entries: make(map[string]*iptvBroadcast),
+		grace:   iptvBroadcastGrace,
+	}
+}
+
+// join adds a viewer to the broadcast of channel for scope (see iptvScope), starting
+// the broadcast if it is not on air. A new broadcast first calls acquire for a
+// tuner, and join fails if that does; it then runs run until the last viewer
+// has gone. run gets a copy of r that is cancelled with the broadcast rather
+// than with the request that happened to start it.
+func (bs *iptvBroadcasts) join(
+	r *http.Request,
+	scope string,
+	channel string,
+	acquire func() (release func(), ok bool),
+	run func(ctx context.Context, r *http.Request, out io.Writer),
+) (*iptvViewer, bool) {
+	key := scope + ":" + channel
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	b := bs.entries[key]
+	if b == nil || b.ring.isClosed() {
+		release, ok := acquire()
+		if !ok {
+			return nil, false
+		}
+
+		ctx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+		b = &iptvBroadcast{
+			key:     key,
+			channel: channel,
+			ring:    newIPTVTSRing(iptvBroadcastBuffer),
+			cancel:  cancel,
+		}
+		bs.entries[key] = b
+
+		logger.Debugf("[iptv] channel %s: starting broadcast", channel)
+		go func() {
+			defer release()
+			run(ctx, r.WithContext(ctx), b.ring)
+			b.ring.close()
+			cancel()
+
+			bs.mu.Lock()
+			if bs.entries[key] == b {
+				delete(bs.entries, key)
+			}
+			bs.mu.Unlock()
+			logger.Debugf("[iptv] channel %s: broadcast ended", channel)
+		}()
+	}
+
+	if b.idle != nil {
+		b.idle.Stop()
+		b.idle = nil
+	}
+	b.viewers++
+
+	return &iptvViewer{broadcast: b}, true
+}
+
+// leave removes a viewer, stopping the broadcast after the grace period if it
+// was the last.
+func (bs *iptvBroadcasts) leave(v *iptvViewer) {
+	b := v.broadcast
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	b.viewers--
+	if b.viewers > 0 {
+		return
+	}
+
+	b.idle = time.AfterFunc(bs.grace, func() {
+		bs.mu.Lock()
+		defer bs.mu.Unlock()
+
+		// a viewer may have joined while the timer fired
+		if b.viewers > 0 {
+			return
+		}
+		if bs.entries[b.key] == b {
+			delete(bs.entries, b.key)
+		}
+		b.cancel()
+	})
+}
+
+// viewers returns how many are watching a channel live, across every scope.
+func (bs *iptvBroadcasts) viewers(channel string) int {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	n := 0
+	for _, b := range bs.entries {
+		if b.channel == channel {
+			n += b.viewers
+		}
+	}
+	return n
+}
+
+// copyTo copies the broadcast into out until ctx ends, out fails or the
+// broadcast ends.
+func (v *iptvViewer) copyTo(ctx context.Context, out io.Writer) {
+	for {
+		chunks, ok := v.read(ctx)
+		if !ok {
+			return
+		}
+		for _, c := range chunks {
+			if _, err := out.Write(c); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// read waits for and returns what the viewer has not yet seen.
+func (v *iptvViewer) read(ctx context.Context) ([][]byte, bool) {
+	ring := v.broadcast.ring
+	for {
+		chunks, wake, ok := ring.since(v)
+		if len(chunks) > 0 {
+			return chunks, true
+		}
+		if !ok {
+			return nil, false
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-wake:
+		}
+	}
+}
+
+// ─── ring buffer ──────────────────────────────────────────────────────────────
+
+// iptvTSRing is a bounded buffer of MPEG-TS, kept in chunks that start at
+// packet boundaries. A chunk starting with a keyframe is marked, so that a
+// viewer can be started on one.
+type iptvTSRing struct {
+	mu      sync.Mutex
+	chunks  []iptvTSChunk
+	first   int64 // sequence number of chunks[0]
+	size    int
+	max     int
+	partial []byte
+	closed  bool
+	// wake is closed, and replaced, whenever something is written.
+	wake chan struct{}
+
+	// The latest PAT and PMT, which a viewer needs before anything else.
+	pat    []byte
+	pmt    []byte
+	pmtPID int
+}
+
+type iptvTSChunk struct {
+	data []byte
+	key  bool
+}
+
+func newIPTVTSRing(max int) *iptvTSRing {
+	return &iptvTSRing{max: max, pmtPID: -1, wake: make(chan struct{})}
+}
+
+// Write appends whole packets of p to the ring. A packet split across writes
+// is held back until the rest of it arrives.
+func (t *iptvTSRing) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	data := append(t.partial, p...)
+	t.partial = nil
+
+	var (
+		cur    []byte
+		curKey bool
+	)
+	emit := func() {
+		if len(cur) > 0 {
+			t.chunks = append(t.chunks, iptvTSChunk{data: cur, key: curKey})
+			t.size += len(cur)
+		}
+		cur, curKey = nil, false
+	}
+
+	for len(data) >= iptvTSPacketSize {
+		// Each programme is a new ffmpeg, and one killed at the end of its slot
+		// can leave half a packet behind. Resynchronise on the next sync byte
+		// rather than passing misaligned packets on. Where it can be seen, the
+		// next packet must start with one too, or this one is a fragment that
+		// happens to.
+		aligned := data[0] == iptvTSSyncByte &&
+			(len(data) == iptvTSPacketSize || data[iptvTSPacketSize] == iptvTSSyncByte)
+		if !aligned {
+			i := 1
+			for i < len(data) && data[i] != iptvTSSyncByte {
+				i++
+			}
+			data = data[i:]
+			continue
+		}
+
+		pkt := data[:iptvTSPacketSize]
+		data = data[iptvTSPacketSize:]
+
+		if iptvTSKeyframe(pkt) {
+			emit()
+			curKey = true
+		}
+		t.remember(pkt)
+		cur = append(cur, pkt...)
+	}
+	emit()
+	t.partial = append([]byte(nil), data...)
+
+	for t.size > t.max && len(t.chunks) > 1 {
+		t.size -= len(t.chunks[0].data)
+		t.chunks = t.chunks[1:]
+		t.first++
+	}
+
+	close(t.wake)
+	t.wake = make(chan struct{})
+
+	return len(p), nil
+}
+
+// remember keeps the latest PAT and PMT packets.
+func (t *iptvTSRing) remember(pkt []byte) {
+	pid := int(pkt[1]&0x1f)<<8 | int(pkt[2])
+	switch {
+	case pid == 0:
+		// copied afresh, not overwritten: viewers hold on to the old one
+		t.pat = append([]byte(nil), pkt...)
+		if pmt, ok := iptvTSPMTPID(pkt); ok {
+			t.pmtPID = pmt
+		}
+	case pid == t.pmtPID:
+		t.pmt = append([]byte(nil), pkt...)
+	}
+}
+
+func (t *iptvTSRing) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.closed {
+		t.closed = true
+		close(t.wake)
+	}
+}
+
+func (t *iptvTSRing) isClosed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.closed
+}
+
+// since returns the chunks v has not seen, moving it past them, or a channel
+// that is closed when there may be more. ok is false once the ring is closed
+// and v has seen everything.
+func (t *iptvTSRing) since(v *iptvViewer) (chunks [][]byte, wake <-chan struct{}, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	end := t.first + int64(len(t.chunks))
+	if v.synced && v.pos < t.first {
+		// overwritten before this viewer got to it
+		v.synced = false
+	}
+
+	if !v.synced {
+		key := int64(-1)
+		for i := len(t.chunks) - 1; i >= 0; i-- {
+			if t.chunks[i].key {
+				key = t.first + int64(i)
+				break
+			}
+		}
+		if key < 0 {
+			return nil, t.wake, !t.closed
+		}
+		v.pos = key
+		v.synced = true
+		if t.pat != nil {
+			chunks = append(chunks, t.pat)
+		}
+		if t.pmt != nil {
+			chunks = append(chunks, t.pmt)
+		}
+	}
+
+	for ; v.pos < end; v.pos++ {
+		chunks = append(chunks, t.chunks[v.pos-t.first].data)
+	}
+	return chunks, t.wake, !t.closed
+}
+
+// iptvTSKeyframe reports whether a packet opens a keyframe: a payload unit
+// start with the adaptation field's random access indicator set, which is how
+// ffmpeg's muxer marks one.
+func iptvTSKeyframe(pkt []byte) bool {
+	if pkt[1]&0x40 == 0 {
+		return false
+	}
+	if pkt[3]&0x20 == 0 || pkt[4] == 0 {
+		return false // no adaptation field
+	}
+	return pkt[5]&0x40 != 0
+}
+
+// iptvTSPMTPID reads the PID of the first programme's PMT from a PAT packet.
+func iptvTSPMTPID(pkt []byte) (int, bool) {
+	if pkt[1]&0x40 == 0 {
+		return 0, false // not the start of the table
+	}
+
+	off := 4
+	if pkt[3]&0x20 != 0 {
+		off += 1 + int(pkt[4])
+	}
+	if off >= len(pkt) {
+		return 0, false
+	}
+	off += 1 + int(pkt[off]) // pointer field
+	if off+8 > len(pkt) || pkt[off] != 0 {
+		return 0, false // not a PAT
+	}
+
+	sectionLen := int(pkt[off+1]&0x0f)<<8 | int(pkt[off+2])
+	end := off + 3 + sectionLen - 4 // less the CRC
+	if end > len(pkt) {
+		end = len(pkt)
+	}
+	for i := off + 8; i+4 <= end; i += 4 {
+		program := int(pkt[i])<<8 | int(pkt[i+1])
+		if program != 0 { // 0 is the network PID
+			return int(pkt[i+2]&0x1f)<<8 | int(pkt[i+3]), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,274 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tsPacket builds a 188-byte packet on pid, tagged with marker so tests can
+// tell packets apart.
+func tsPacket(pid int, keyframe bool, marker byte) []byte {
+	p := make([]byte, iptvTSPacketSize)
+	p[0] = iptvTSSyncByte
+	p[1] = byte(pid>>8) & 0x1f
+	p[2] = byte(pid)
+	p[3] = 0x10 // payload only
+	if keyframe {
+		p[1] |= 0x40
+		p[3] = 0x30 // adaptation field and payload
+		p[4] = 1
+		p[5] = 0x40
+	}
+	p[len(p)-1] = marker
+	return p
+}
+
+// patPacket is a PAT announcing programme 1 with its PMT on pmtPID.
+func patPacket(pmtPID int) []byte {
+	p := make([]byte, iptvTSPacketSize)
+	p[0] = iptvTSSyncByte
+	p[1] = 0x40
+	p[3] = 0x10
+	copy(p[4:], []byte{
+		0,        // pointer field
+		0,        // table id
+		0xb0, 13, // section length
+		0, 1, 0xc1, // transport stream id, version
+		0, 0, // section numbers
+		0, 1, 0xe0 | byte(pmtPID>>8), byte(pmtPID),
+		0, 0, 0, 0, // CRC
+	})
+	return p
+}
+
+func packets(pkts ...[]byte) []byte { return bytes.Join(pkts, nil) }
+
+func markers(chunks [][]byte) []byte {
+	var ret []byte
+	for _, c := range chunks {
+		for i := 0; i+iptvTSPacketSize <= len(c); i += iptvTSPacketSize {
+			ret = append(ret, c[i+iptvTSPacketSize-1])
+		}
+	}
+	return ret
+}
+
+func TestTSPMTPIDFromPAT(t *testing.T) {
+	pid, ok := iptvTSPMTPID(patPacket(0x1000))
+	if !ok || pid != 0x1000 {
+		t.Errorf("got %#x, %v; want 0x1000", pid, ok)
+	}
+}
+
+// A viewer joining part way through gets the PAT and PMT first, then the
+// stream from the most recent keyframe.
+func TestTSRingJoinsAtLatestKeyframe(t *testing.T) {
+	ring := newIPTVTSRing(1 << 20)
+	_, _ = ring.Write(packets(
+		patPacket(0x1000),
+		tsPacket(0x1000, false, 'm'),
+		tsPacket(0x100, true, 'a'),
+		tsPacket(0x100, false, 'b'),
+		tsPacket(0x100, true, 'c'),
+		tsPacket(0x100, false, 'd'),
+	))
+
+	v := &iptvViewer{broadcast: &iptvBroadcast{ring: ring}}
+	chunks, _, _ := ring.since(v)
+
+	if got := markers(chunks); string(got) != "\x00mcd" {
+		t.Errorf("viewer got %q, want the PAT, PMT, then from keyframe c", got)
+	}
+
+	_, _ = ring.Write(tsPacket(0x100, false, 'e'))
+	chunks, _, _ = ring.since(v)
+	if got := markers(chunks); string(got) != "e" {
+		t.Errorf("viewer then got %q, want just e", got)
+	}
+}
+
+func TestTSRingWaitsForFirstKeyframe(t *testing.T) {
+	ring := newIPTVTSRing(1 << 20)
+	_, _ = ring.Write(tsPacket(0x100, false, 'a'))
+
+	v := &iptvViewer{broadcast: &iptvBroadcast{ring: ring}}
+	if chunks, _, ok := ring.since(v); len(chunks) != 0 || !ok {
+		t.Fatalf("got %q, %v before any keyframe", markers(chunks), ok)
+	}
+
+	_, _ = ring.Write(tsPacket(0x100, true, 'k'))
+	chunks, _, _ := ring.since(v)
+	if got := markers(chunks); string(got) != "k" {
+		t.Errorf("got %q, want k", got)
+	}
+}
+
+func TestTSRingReassemblesSplitPackets(t *testing.T) {
+	ring := newIPTVTSRing(1 << 20)
+	data := packets(tsPacket(0x100, true, 'a'), tsPacket(0x100, false, 'b'))
+
+	_, _ = ring.Write(data[:100])
+	_, _ = ring.Write(data[100:300])
+	_, _ = ring.Write(data[300:])
+
+	v := &iptvViewer{broadcast: &iptvBroadcast{ring: ring}}
+	chunks, _, _ := ring.since(v)
+	if got := markers(chunks); string(got) != "ab" {
+		t.Errorf("got %q, want ab", got)
+	}
+}
+
+// A programme killed mid-packet leaves a fragment ahead of the next
+// programme's first packet.
+func TestTSRingResynchronisesAfterFragment(t *testing.T) {
+	ring := newIPTVTSRing(1 << 20)
+	_, _ = ring.Write(packets(tsPacket(0x100, true, 'a'), tsPacket(0x100, false, 'b')[:50]))
+	_, _ = ring.Write(tsPacket(0x100, true, 'c'))
+
+	v := &iptvViewer{broadcast: &iptvBroadcast{ring: ring}, synced: true}
+	chunks, _, _ := ring.since(v)
+	if got := markers(chunks); string(got) != "ac" {
+		t.Errorf("got %q, want ac", got)
+	}
+}
+
+func TestTSRingSkipsSlowViewerForward(t *testing.T) {
+	ring := newIPTVTSRing(4 * iptvTSPacketSize)
+	v := &iptvViewer{broadcast: &iptvBroadcast{ring: ring}}
+
+	_, _ = ring.Write(tsPacket(0x100, true, 'a'))
+	_, _, _ = ring.since(v)
+
+	for _, m := range []byte("bcdef") {
+		_, _ = ring.Write(tsPacket(0x100, m == 'e', m))
+	}
+
+	chunks, _, _ := ring.since(v)
+	if got := markers(chunks); string(got) != "ef" {
+		t.Errorf("slow viewer got %q, want to rejoin at keyframe e", got)
+	}
+}
+
+func TestTSRingEndsViewerAfterClose(t *testing.T) {
+	ring := newIPTVTSRing(1 << 20)
+	_, _ = ring.Write(tsPacket(0x100, true, 'a'))
+	ring.close()
+
+	v := &iptvViewer{broadcast: &iptvBroadcast{ring: ring}}
+	var out bytes.Buffer
+	v.copyTo(context.Background(), &out)
+
+	if got := markers([][]byte{out.Bytes()}); string(got) != "a" {
+		t.Errorf("got %q, want what was left in the ring", got)
+	}
+}
+
+// ─── broadcasts ───────────────────────────────────────────────────────────────
+
+func TestBroadcastSharedByViewers(t *testing.T) {
+	bs := newIPTVBroadcasts()
+	bs.grace = 10 * time.Millisecond
+	r := httptest.NewRequest("GET", "/iptv/ch/a.ts", nil)
+
+	var acquired, started atomic.Int32
+	acquire := func() (func(), bool) {
+		acquired.Add(1)
+		return func() {}, true
+	}
+	stopped := make(chan struct{})
+	run := func(ctx context.Context, _ *http.Request, _ io.Writer) {
+		started.Add(1)
+		<-ctx.Done()
+		close(stopped)
+	}
+
+	first, ok := bs.join(r, "", "a", acquire, run)
+	if !ok {
+		t.Fatal("first viewer refused")
+	}
+	second, _ := bs.join(r, "", "a", acquire, run)
+
+	if acquired.Load() != 1 {
+		t.Errorf("acquired %d tuners for one channel", acquired.Load())
+	}
+	if n := bs.viewers("a"); n != 2 {
+		t.Errorf("viewers = %d, want 2", n)
+	}
+
+	bs.leave(first)
+	bs.leave(second)
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("broadcast still running after the grace period")
+	}
+	if started.Load() != 1 {
+		t.Errorf("started %d pipelines for one channel", started.Load())
+	}
+}
+
+// The request that starts a broadcast ending must not take the broadcast
+// with it while others are still watching.
+func TestBroadcastOutlivesStartingRequest(t *testing.T) {
+	bs := newIPTVBroadcasts()
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest("GET", "/iptv/ch/a.ts", nil).WithContext(ctx)
+
+	running := make(chan context.Context, 1)
+	v, _ := bs.join(r, "", "a", func() (func(), bool) { return func() {}, true },
+		func(ctx context.Context, _ *http.Request, _ io.Writer) {
+			running <- ctx
+			<-ctx.Done()
+		})
+	defer bs.leave(v)
+
+	runCtx := <-running
+	cancel()
+	if runCtx.Err() != nil {
+		t.Error("broadcast cancelled with the request that started it")
+	}
+}
+
+func TestBroadcastJoinFailsWithoutTuner(t *testing.T) {
+	bs := newIPTVBroadcasts()
+	r := httptest.NewRequest("GET", "/iptv/ch/a.ts", nil)
+
+	_, ok := bs.join(r, "", "a", func() (func(), bool) { return nil, false }, nil)
+	if ok {
+		t.Error("joined a broadcast with no tuner free")
+	}
+	if n := bs.viewers("a"); n != 0 {
+		t.Errorf("viewers = %d, want 0", n)
+	}
+}
+
+// A viewer coming back within the grace period rejoins the same broadcast.
+func TestBroadcastRejoinWithinGrace(t *testing.T) {
+	bs := newIPTVBroadcasts()
+	bs.grace = time.Hour
+	r := httptest.NewRequest("GET", "/iptv/ch/a.ts", nil)
+
+	var started atomic.Int32
+	run := func(ctx context.Context, _ *http.Request, _ io.Writer) {
+		started.Add(1)
+		<-ctx.Done()
+	}
+	acquire := func() (func(), bool) { return func() {}, true }
+
+	v, _ := bs.join(r, "", "a", acquire, run)
+	bs.leave(v)
+	v, _ = bs.join(r, "", "a", acquire, run)
+	defer bs.leave(v)
+
+	time.Sleep(10 * time.Millisecond)
+	if started.Load() != 1 {
+		t.Errorf("started %d pipelines, want the first one reused", started.Load())
+	}
+}
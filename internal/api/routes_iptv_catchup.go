@@ -0,0 +1,149 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stashapp/stash/pkg/iptv"
+)
+
+// Catch-up and timeshift.
+//
+// Nothing is recorded to make this work. What a channel aired is a pure
+// function of the wall clock (see package iptv), so a programme from last
+// Tuesday is replayed by running the same stream loop against a clock set back
+// to last Tuesday. The one caveat is that the schedule is rebuilt from the
+// library as it is now: a scene deleted since it aired leaves a gap, and a
+// channel whose contents changed replays what it would have aired with them.
+//
+// Clients ask for it in one of two shapes. M3U players (TiviMate, OTT
+// Navigator) fill in the catchup-source template on each channel, which asks
+// for ?utc={start}&lutc={now}; Xtream clients call /timeshift/, which
+// routes_iptv_xtream.go turns into ?start=&duration=. Both end up in
+// iptvParseTimeshift.
+
+const (
+	iptvDefaultArchiveDays = 7
+
+	// iptvMaxArchiveEntries caps the past half of each channel's guide, the
+	// counterpart of iptvMaxEPGEntries for what is still to come.
+	iptvMaxArchiveEntries = 2000
+
+	// iptvXtreamTimeshiftFormat is how Xtream clients write the start of a
+	// timeshift, in the server timezone the account info reports.
+	iptvXtreamTimeshiftFormat = "2006-01-02:15-04"
+)
+
+var (
+	errIPTVCatchupDisabled = errors.New("catch-up is disabled")
+	errIPTVOutsideArchive  = errors.New("programme is outside the archive window")
+)
+
+// iptvTimeshift is a request to play a channel from a point in the past. The
+// zero value is the live channel.
+type iptvTimeshift struct {
+	Start time.Time
+	// End is where playback stops, or zero to carry on indefinitely, one
+	// constant delay behind live.
+	End time.Time
+}
+
+func (t iptvTimeshift) live() bool { return t.Start.IsZero() }
+
+// iptvParseTimeshift reads a catch-up request from a channel stream's query.
+// utc or start is the unix time to start from (start may also be in the Xtream
+// format), and duration the number of seconds to play. lutc, the client's own
+// clock, is accepted but not needed: utc comes from our guide, so it is
+// already in server time.
+//
+// A start that is not in the past is the live channel.
+func iptvParseTimeshift(q url.Values, now time.Time, archiveDays int) (iptvTimeshift, error) {
+	raw := q.Get("utc")
+	if raw == "" {
+		raw = q.Get("start")
+	}
+	if raw == "" {
+		return iptvTimeshift{}, nil
+	}
+
+	start, err := iptvParseTimeshiftStart(raw)
+	if err != nil {
+		return iptvTimeshift{}, err
+	}
+	if !start.Before(now) {
+		return iptvTimeshift{}, nil
+	}
+
+	if archiveDays <= 0 {
+		return iptvTimeshift{}, errIPTVCatchupDisabled
+	}
+	if start.Before(now.AddDate(0, 0, -archiveDays)) {
+		return iptvTimeshift{}, errIPTVOutsideArchive
+	}
+
+	ret := iptvTimeshift{Start: start}
+	if raw := q.Get("duration"); raw != "" {
+		secs, err := strconv.Atoi(raw)
+		if err != nil || secs < 0 {
+			return iptvTimeshift{}, fmt.Errorf("invalid duration %q", raw)
+		}
+		if secs > 0 {
+			ret.End = start.Add(time.Duration(secs) * time.Second)
+		}
+	}
+
+	return ret, nil
+}
+
+func iptvParseTimeshiftStart(raw string) (time.Time, error) {
+	if secs, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+	if t, err := time.ParseInLocation(iptvXtreamTimeshiftFormat, raw, time.UTC); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid start time %q", raw)
+}
+
+// iptvCatchupSource is the catchup-source template for a channel's stream
+// URL. Players substitute {utc} and {lutc} themselves.
+func iptvCatchupSource(streamURL string) string {
+	sep := "?"
+	if strings.Contains(streamURL, "?") {
+		sep = "&"
+	}
+	return streamURL + sep + "utc={utc}&lutc={lutc}"
+}
+
+// iptvGuideAirings returns a channel's guide: everything still to come in the
+// next EPGHours, and, when catch-up is on, what aired over the archive window,
+// since a programme can only be replayed from a guide that lists it.
+func iptvGuideAirings(grid *iptv.Grid, now time.Time, s iptvSettings) []iptv.Airing {
+	from := now.Add(-1 * time.Hour) // include what is already in progress
+	to := now.Add(time.Duration(s.EPGHours) * time.Hour)
+
+	live := grid.Airings(from, to, iptvMaxEPGEntries)
+	if s.ArchiveDays <= 0 || len(live) == 0 {
+		return live
+	}
+
+	past := iptvArchiveAirings(grid, now.AddDate(0, 0, -s.ArchiveDays), live[0].Start, iptvMaxArchiveEntries)
+	return append(past, live...)
+}
+
+// iptvArchiveAirings returns the airings in [from, to), keeping the most recent
+// max of them. A grid can only be walked forwards, so a window too full to
+// list is halved towards its end until it fits.
+func iptvArchiveAirings(grid *iptv.Grid, from, to time.Time, max int) []iptv.Airing {
+	for {
+		airings := grid.Airings(from, to, max+1)
+		if len(airings) <= max {
+			return airings
+		}
+		from = to.Add(-to.Sub(from) / 2)
+	}
+}
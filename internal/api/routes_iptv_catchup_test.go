@@ -0,0 +1,139 @@
+package api
+
+import (
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/iptv"
+)
+
+var catchupNow = time.Date(2024, time.March, 10, 20, 0, 0, 0, time.UTC)
+
+func TestTimeshiftWithoutParamsIsLive(t *testing.T) {
+	ts, err := iptvParseTimeshift(url.Values{}, catchupNow, 7)
+	if err != nil || !ts.live() {
+		t.Errorf("got %+v, %v; want the live channel", ts, err)
+	}
+}
+
+func TestTimeshiftReadsUTC(t *testing.T) {
+	start := catchupNow.Add(-2 * time.Hour)
+	q := url.Values{"utc": {"1710093600"}, "lutc": {"1710100800"}}
+
+	ts, err := iptvParseTimeshift(q, catchupNow, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ts.Start.Equal(start) {
+		t.Errorf("start = %v, want %v", ts.Start, start)
+	}
+	if !ts.End.IsZero() {
+		t.Errorf("end = %v, want none without a duration", ts.End)
+	}
+}
+
+func TestTimeshiftReadsXtreamStartAndDuration(t *testing.T) {
+	q := url.Values{"start": {"2024-03-09:21-30"}, "duration": {"1800"}}
+
+	ts, err := iptvParseTimeshift(q, catchupNow, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2024, time.March, 9, 21, 30, 0, 0, time.UTC)
+	if !ts.Start.Equal(want) {
+		t.Errorf("start = %v, want %v", ts.Start, want)
+	}
+	if !ts.End.Equal(want.Add(30 * time.Minute)) {
+		t.Errorf("end = %v, want %v", ts.End, want.Add(30*time.Minute))
+	}
+}
+
+// Players fill in {utc} with the current time when the viewer returns to
+// live; that has to be the live channel rather than an error.
+func TestTimeshiftFromNowIsLive(t *testing.T) {
+	q := url.Values{"utc": {"1710100800"}}
+	ts, err := iptvParseTimeshift(q, catchupNow, 7)
+	if err != nil || !ts.live() {
+		t.Errorf("got %+v, %v; want the live channel", ts, err)
+	}
+}
+
+func TestTimeshiftRejectsOutsideArchive(t *testing.T) {
+	eightDays := url.Values{"utc": {"1709409600"}}
+	if _, err := iptvParseTimeshift(eightDays, catchupNow, 7); !errors.Is(err, errIPTVOutsideArchive) {
+		t.Errorf("err = %v, want %v", err, errIPTVOutsideArchive)
+	}
+
+	hourAgo := url.Values{"utc": {"1710097200"}}
+	if _, err := iptvParseTimeshift(hourAgo, catchupNow, 0); !errors.Is(err, errIPTVCatchupDisabled) {
+		t.Errorf("err = %v, want %v", err, errIPTVCatchupDisabled)
+	}
+}
+
+func TestTimeshiftRejectsMalformedParams(t *testing.T) {
+	for _, q := range []url.Values{
+		{"utc": {"yesterday"}},
+		{"start": {"1710097200"}, "duration": {"-5"}},
+	} {
+		if _, err := iptvParseTimeshift(q, catchupNow, 7); err == nil {
+			t.Errorf("%v: expected an error", q)
+		}
+	}
+}
+
+func TestCatchupSourceAppendsToExistingQuery(t *testing.T) {
+	if got, want := iptvCatchupSource("http://h/iptv/ch/a.ts"), "http://h/iptv/ch/a.ts?utc={utc}&lutc={lutc}"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := iptvCatchupSource("http://h/iptv/ch/a.ts?apikey=k"), "http://h/iptv/ch/a.ts?apikey=k&utc={utc}&lutc={lutc}"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// The archive half of the guide keeps the programmes nearest to now: those
+// are the ones anybody wants to catch up on.
+func TestArchiveAiringsKeepsMostRecent(t *testing.T) {
+	grid := iptv.NewGrid(testCycle(600, 600, 600), nil, time.UTC)
+	from := catchupNow.Add(-24 * time.Hour)
+
+	airings := iptvArchiveAirings(grid, from, catchupNow, 50)
+	if len(airings) == 0 || len(airings) > 50 {
+		t.Fatalf("got %d airings, want between 1 and 50", len(airings))
+	}
+	if last := airings[len(airings)-1]; !last.End.Equal(catchupNow) {
+		t.Errorf("archive ends at %v, want it to reach %v", last.End, catchupNow)
+	}
+}
+
+func TestGuideIncludesArchiveWhenEnabled(t *testing.T) {
+	grid := iptv.NewGrid(testCycle(600, 900, 300), nil, time.UTC)
+	s := iptvSettings{EPGHours: 2, ArchiveDays: 1}
+
+	airings := iptvGuideAirings(grid, catchupNow, s)
+	if len(airings) == 0 {
+		t.Fatal("empty guide")
+	}
+	if first := airings[0].Start; first.After(catchupNow.Add(-23 * time.Hour)) {
+		t.Errorf("guide starts at %v, want it to cover the day-long archive", first)
+	}
+	for i := 1; i < len(airings); i++ {
+		if !airings[i-1].End.Equal(airings[i].Start) {
+			t.Fatalf("gap or overlap between airing %d and %d", i-1, i)
+		}
+	}
+
+	s.ArchiveDays = 0
+	if first := iptvGuideAirings(grid, catchupNow, s)[0].Start; first.Before(catchupNow.Add(-time.Hour - 15*time.Minute)) {
+		t.Errorf("guide starts at %v with catch-up off", first)
+	}
+}
+
+func TestXtreamCredentialFromTimeshiftPath(t *testing.T) {
+	r := httptest.NewRequest("GET", "/timeshift/user/secret/30/2024-03-09:21-30/123.ts", nil)
+	if got := iptvXtreamCredential(r); got != "secret" {
+		t.Errorf("credential = %q, want %q", got, "secret")
+	}
+}
@@ -0,0 +1,378 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stashapp/stash/pkg/iptv"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/savedfilter"
+)
+
+// User-defined channels.
+//
+// A library channel airs one studio and a network channel a provider's
+// catalog. A custom channel airs whatever its source matches — a saved scene
+// filter, a tag, a performer, a group or a playlist — under its own name,
+// number, logo and shuffle seed. They are rows in the database, managed through GraphQL, rather
+// than entries in the plugin settings: a channel is something a user builds
+// and renames, not a tuning knob.
+//
+// Everything downstream of the lineup treats them as library channels. Their
+// programmes are local scenes, so the schedule, the stream pipeline and the
+// guide are exactly the ones a studio channel gets; only the scene filter and
+// the seed differ.
+//
+// A custom channel may also have programming blocks: weekly windows that air
+// a different source — features after 22:00, say — over the channel's own.
+// Each block gets a cycle of its own, and iptv.Grid works out from the clock
+// which one is on.
+
+const (
+	iptvSourceCustom = "custom"
+
+	// iptvCustomKeyPrefix namespaces custom channel ids away from studio ids,
+	// the same way the network prefixes do.
+	iptvCustomKeyPrefix = "custom-"
+)
+
+func iptvCustomChannelKey(id int) string {
+	return iptvCustomKeyPrefix + strconv.Itoa(id)
+}
+
+// iptvBlockKey identifies the cycle of a programming block.
+func iptvBlockKey(channelKey string, blockID int) string {
+	return channelKey + "@" + strconv.Itoa(blockID)
+}
+
+// customChannels returns the lineup entries of the user-defined channels. A
+// channel whose source no longer exists is left out of the lineup rather than
+// failing it: deleting a tag should not take every other channel off the air.
+func (rs iptvRoutes) customChannels(ctx context.Context) ([]iptvChannel, error) {
+	channels, err := rs.repository.IPTVChannel.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]iptvChannel, 0, len(channels))
+	for _, c := range channels {
+		src, err := iptvCustomSource(ctx, rs.repository, c.SourceType, c.SourceID, iptvCustomShuffleSeed(c))
+		if err != nil {
+			logger.Warnf("[iptv] leaving channel %q out of the lineup: %v", c.Name, err)
+			continue
+		}
+
+		count, err := iptvSourceSceneCount(ctx, rs.repository, src)
+		if err != nil {
+			return nil, err
+		}
+
+		blocks, err := rs.repository.IPTVChannel.GetBlocks(ctx, c.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, iptvChannel{
+			Source:     iptvSourceCustom,
+			Key:        iptvCustomChannelKey(c.ID),
+			Custom:     c,
+			Blocks:     blocks,
+			TvgID:      fmt.Sprintf("vexxx-custom-%d", c.ID),
+			Name:       c.Name,
+			SceneCount: count,
+		})
+	}
+
+	return ret, nil
+}
+
+var errIPTVChannelSourceNotFound = errors.New("channel source not found")
+
+// iptvLibrarySource is what a library channel, or a block of one, airs:
+// either the scenes matching a filter, in a shuffle fixed by seed, or a
+// playlist, in the playlist's own order.
+type iptvLibrarySource struct {
+	filter   *models.SceneFilterType
+	seed     uint64
+	playlist *models.Playlist
+}
+
+// librarySource returns what a channel airing local scenes airs.
+func (rs iptvRoutes) librarySource(ctx context.Context, ch iptvChannel) (iptvLibrarySource, error) {
+	if ch.Custom != nil {
+		return iptvCustomSource(ctx, rs.repository, ch.Custom.SourceType, ch.Custom.SourceID, iptvCustomShuffleSeed(ch.Custom))
+	}
+	return iptvLibrarySource{
+		filter: iptvStudioFilter(ch.StudioID),
+		seed:   iptv.ShuffleSeed(ch.StudioID),
+	}, nil
+}
+
+// iptvCustomSource resolves the source of a custom channel or block. It fails
+// with errIPTVChannelSourceNotFound if the source no longer exists.
+func iptvCustomSource(ctx context.Context, repo *models.Repository, sourceType models.IPTVChannelSource, sourceID int, seed uint64) (iptvLibrarySource, error) {
+	ret := iptvLibrarySource{seed: seed}
+
+	id := []string{strconv.Itoa(sourceID)}
+	depth := -1 // include descendants, as studio channels do
+
+	var (
+		found bool
+		err   error
+	)
+	switch sourceType {
+	case models.IPTVChannelSourceSavedFilter:
+		var f *models.SavedFilter
+		f, err = repo.SavedFilter.Find(ctx, sourceID)
+		if err == nil && f != nil {
+			found = true
+			ret.filter, err = savedfilter.SceneFilter(f)
+		}
+	case models.IPTVChannelSourceTag:
+		var t *models.Tag
+		t, err = repo.Tag.Find(ctx, sourceID)
+		found = t != nil
+		ret.filter = &models.SceneFilterType{
+			Tags: &models.HierarchicalMultiCriterionInput{
+				Value:    id,
+				Modifier: models.CriterionModifierIncludes,
+				Depth:    &depth,
+			},
+		}
+	case models.IPTVChannelSourcePerformer:
+		var p *models.Performer
+		p, err = repo.Performer.Find(ctx, sourceID)
+		found = p != nil
+		ret.filter = &models.SceneFilterType{
+			Performers: &models.MultiCriterionInput{
+				Value:    id,
+				Modifier: models.CriterionModifierIncludes,
+			},
+		}
+	case models.IPTVChannelSourceGroup:
+		var g *models.Group
+		g, err = repo.Group.Find(ctx, sourceID)
+		found = g != nil
+		ret.filter = &models.SceneFilterType{
+			Groups: &models.HierarchicalMultiCriterionInput{
+				Value:    id,
+				Modifier: models.CriterionModifierIncludes,
+				Depth:    &depth,
+			},
+		}
+	case models.IPTVChannelSourcePlaylist:
+		ret.playlist, err = repo.Playlist.Find(ctx, sourceID)
+		found = ret.playlist != nil
+	default:
+		return ret, fmt.Errorf("unknown channel source %q", sourceType)
+	}
+
+	if err != nil {
+		return ret, err
+	}
+	if !found {
+		return ret, fmt.Errorf("%s %d: %w", sourceType, sourceID, errIPTVChannelSourceNotFound)
+	}
+	return ret, nil
+}
+
+// iptvPlaylistCriteria returns the criteria of a dynamic playlist, or nil for
+// a playlist of fixed items.
+func iptvPlaylistCriteria(p *models.Playlist) (*models.PlaylistCriteria, error) {
+	if p.Criteria == nil || strings.TrimSpace(*p.Criteria) == "" {
+		return nil, nil
+	}
+
+	var ret models.PlaylistCriteria
+	if err := json.Unmarshal([]byte(*p.Criteria), &ret); err != nil {
+		return nil, fmt.Errorf("invalid criteria of playlist %d: %w", p.ID, err)
+	}
+	return &ret, nil
+}
+
+// iptvSourceSceneCount counts the scenes a source airs.
+func iptvSourceSceneCount(ctx context.Context, repo *models.Repository, src iptvLibrarySource) (int, error) {
+	if src.playlist == nil {
+		return repo.Scene.QueryCount(ctx, src.filter, nil)
+	}
+
+	criteria, err := iptvPlaylistCriteria(src.playlist)
+	if err != nil {
+		return 0, err
+	}
+	if criteria != nil {
+		return repo.Scene.QueryCount(ctx, criteria.SceneFilter, nil)
+	}
+
+	// images and galleries in a playlist have nothing to air
+	return repo.Playlist.CountByMediaType(ctx, src.playlist.ID, models.PlaylistMediaTypeScene)
+}
+
+// iptvCustomShuffleSeed is the rotation seed of a custom channel. An explicit
+// seed is used as given, so that a user can pick a different order by picking
+// a different number; otherwise it is derived from the channel id as a studio
+// channel's is from the studio id.
+func iptvCustomShuffleSeed(c *models.IPTVChannel) uint64 {
+	if c.ShuffleSeed != nil {
+		// sqlite.getRandomSort caps the seed anyway
+		return uint64(*c.ShuffleSeed) % 1e8
+	}
+	return iptv.ShuffleSeed(c.ID)
+}
+
+// iptvBlockShuffleSeed is the rotation seed of a block. It follows the
+// channel's, so that reseeding a channel reshuffles its blocks too, but
+// differs per block, so that two blocks airing the same source do not air it
+// in the same order.
+func iptvBlockShuffleSeed(c *models.IPTVChannel, b *models.IPTVChannelBlock) uint64 {
+	return (iptvCustomShuffleSeed(c) + iptv.ShuffleSeed(b.ID)) % 1e8
+}
+
+// blockCycle returns the rotation of a programming block, cached alongside
+// the channel cycles and rebuilt on the same TTL.
+func (rs iptvRoutes) blockCycle(r *http.Request, ch iptvChannel, b *models.IPTVChannelBlock, s iptvSettings) (*iptv.Cycle, error) {
+	c := rs.cycles
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := iptvBlockKey(ch.Key, b.ID)
+	scope, err := iptvScope(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	cacheKey := key
+	if scope != "" {
+		cacheKey = scope + ":" + key
+	}
+
+	if e, ok := c.entries[cacheKey]; ok && e.max == s.MaxPrograms && time.Since(e.built) < iptvCycleTTL {
+		return e.cycle, nil
+	}
+
+	var scenes []iptv.SceneEntry
+	if err := rs.withReadTxn(r, func(ctx context.Context) error {
+		src, err := iptvCustomSource(ctx, rs.repository, b.SourceType, b.SourceID, iptvBlockShuffleSeed(ch.Custom, b))
+		if err != nil {
+			return err
+		}
+		scenes, err = rs.libraryScenes(ctx, src, s.MaxPrograms)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	cycle := iptv.BuildCycle(iptvNetChannelSeed(key), scenes)
+	c.entries[cacheKey] = &iptvCycleEntry{cycle: cycle, built: time.Now(), max: s.MaxPrograms}
+
+	logger.Debugf("[iptv] built schedule for block %q of channel %s: %d programmes",
+		b.Name, ch.Key, len(cycle.Programs))
+
+	return cycle, nil
+}
+
+// iptvGridBlock places a programming block on the grid.
+func iptvGridBlock(b *models.IPTVChannelBlock, c *iptv.Cycle) iptv.Block {
+	days := make([]time.Weekday, len(b.Days))
+	for i, d := range b.Days {
+		days[i] = time.Weekday(d)
+	}
+
+	return iptv.Block{
+		Name:  b.Name,
+		Days:  days,
+		Start: time.Duration(b.StartMinute) * time.Minute,
+		End:   time.Duration(b.EndMinute) * time.Minute,
+		Cycle: c,
+	}
+}
+
+// customChannelLogo returns the stored logo of a custom channel, or else the
+// image of its source, so that a performer channel shows the performer
+// without anyone having to upload anything.
+func (rs iptvRoutes) customChannelLogo(ctx context.Context, c *models.IPTVChannel) ([]byte, error) {
+	logo, err := rs.repository.IPTVChannel.GetLogo(ctx, c.ID)
+	if err != nil || len(logo) > 0 {
+		return logo, err
+	}
+
+	switch c.SourceType {
+	case models.IPTVChannelSourceTag:
+		return rs.repository.Tag.GetImage(ctx, c.SourceID)
+	case models.IPTVChannelSourcePerformer:
+		return rs.repository.Performer.GetImage(ctx, c.SourceID)
+	case models.IPTVChannelSourceGroup:
+		return rs.repository.Group.GetFrontImage(ctx, c.SourceID)
+	}
+	return nil, nil
+}
+
+// iptvNumberChannels numbers a lineup. Custom channels given a number keep
+// it; everything else is numbered in order around them, so pinning a channel
+// to a number cannot collide with a studio that happened to be there.
+func iptvNumberChannels(list []iptvChannel) []iptvChannel {
+	reserved := make(map[int]bool)
+	for _, ch := range list {
+		if n := ch.fixedNumber(); n > 0 {
+			reserved[n] = true
+		}
+	}
+
+	next := 1
+	for i := range list {
+		if n := list[i].fixedNumber(); n > 0 {
+			list[i].Number = n
+			continue
+		}
+		for reserved[next] {
+			next++
+		}
+		list[i].Number = next
+		next++
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Number < list[j].Number
+	})
+	return list
+}
+
+// fixedNumber is the number a custom channel was pinned to, or zero.
+func (ch iptvChannel) fixedNumber() int {
+	if ch.Custom == nil || ch.Custom.Number == nil {
+		return 0
+	}
+	return *ch.Custom.Number
+}
+
+// invalidateCustomChannel drops everything cached about a custom channel
+// after it changed, so that the next request sees the change immediately.
+func (rs iptvRoutes) invalidateCustomChannel(id int) {
+	key := iptvCustomChannelKey(id)
+
+	rs.invalidateChannels()
+
+	rs.cycles.mu.Lock()
+	for cacheKey := range rs.cycles.entries {
+		// scoped schedules are keyed scope:key, and blocks key@block
+		k := cacheKey
+		if i := strings.IndexByte(k, ':'); i >= 0 {
+			k = k[i+1:]
+		}
+		if k == key || strings.HasPrefix(k, key+"@") {
+			delete(rs.cycles.entries, cacheKey)
+		}
+	}
+	rs.cycles.mu.Unlock()
+
+	rs.logos.mu.Lock()
+	delete(rs.logos.entries, key)
+	rs.logos.mu.Unlock()
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func customChannel(key string, number *int) iptvChannel {
+	return iptvChannel{Source: iptvSourceCustom, Key: key, Custom: &models.IPTVChannel{Number: number}}
+}
+
+// A pinned channel keeps its number, and the rest of the lineup is numbered
+// around it rather than colliding with it.
+func TestNumberChannelsReservesPinnedNumbers(t *testing.T) {
+	two := 2
+	list := iptvNumberChannels([]iptvChannel{
+		{Source: iptvSourceLibrary, Key: "1"},
+		{Source: iptvSourceLibrary, Key: "2"},
+		customChannel("custom-1", nil),
+		customChannel("custom-2", &two),
+	})
+
+	want := map[string]int{"1": 1, "2": 3, "custom-1": 4, "custom-2": 2}
+	for i, ch := range list {
+		if ch.Number != want[ch.Key] {
+			t.Errorf("channel %s numbered %d, want %d", ch.Key, ch.Number, want[ch.Key])
+		}
+		if i > 0 && list[i-1].Number > ch.Number {
+			t.Errorf("lineup not sorted by number at %s", ch.Key)
+		}
+	}
+}
+
+// Custom channels air local scenes: they must not be mistaken for a network.
+func TestCustomChannelIsNotNetwork(t *testing.T) {
+	if customChannel("custom-1", nil).isNetwork() {
+		t.Error("custom channel reported as a network channel")
+	}
+}
+
+func blockInput(start, end string, days ...int) *models.IPTVChannelBlockInput {
+	return &models.IPTVChannelBlockInput{
+		Days:       days,
+		Start:      start,
+		End:        end,
+		SourceType: models.IPTVChannelSourceTag,
+		SourceID:   "1",
+	}
+}
+
+func TestBlocksFromInputParsesTimes(t *testing.T) {
+	blocks, err := iptvChannelBlocksFromInput([]*models.IPTVChannelBlockInput{
+		blockInput("22:00", "02:30", 5, 5, 6),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := blocks[0]
+	if b.StartMinute != 22*60 || b.EndMinute != 2*60+30 {
+		t.Errorf("block runs %d–%d, want %d–%d", b.StartMinute, b.EndMinute, 22*60, 2*60+30)
+	}
+	if len(b.Days) != 2 {
+		t.Errorf("days = %v, want the duplicate dropped", b.Days)
+	}
+	if got := formatIPTVBlockTime(b.EndMinute); got != "02:30" {
+		t.Errorf("end formats as %q, want 02:30", got)
+	}
+}
+
+func TestBlocksFromInputRejectsInvalidBlocks(t *testing.T) {
+	for name, in := range map[string]*models.IPTVChannelBlockInput{
+		"bad time":  blockInput("25:00", "02:00"),
+		"empty":     blockInput("10:00", "10:00"),
+		"bad day":   blockInput("10:00", "11:00", 7),
+		"no source": {Start: "10:00", End: "11:00", SourceType: "studio", SourceID: "1"},
+	} {
+		if _, err := iptvChannelBlocksFromInput([]*models.IPTVChannelBlockInput{in}); err == nil {
+			t.Errorf("%s: block accepted", name)
+		}
+	}
+}
+
+// A Saturday block running past midnight collides with a Sunday morning one,
+// even though Sunday starts the week.
+func TestBlocksFromInputRejectsOverlapAcrossWeekEnd(t *testing.T) {
+	_, err := iptvChannelBlocksFromInput([]*models.IPTVChannelBlockInput{
+		blockInput("23:00", "02:00", int(time.Saturday)),
+		blockInput("01:00", "03:00", int(time.Sunday)),
+	})
+	if err == nil {
+		t.Error("overlapping blocks accepted")
+	}
+
+	_, err = iptvChannelBlocksFromInput([]*models.IPTVChannelBlockInput{
+		blockInput("23:00", "02:00", int(time.Saturday)),
+		blockInput("02:00", "03:00", int(time.Sunday)),
+	})
+	if err != nil {
+		t.Errorf("adjacent blocks rejected: %v", err)
+	}
+}
@@ -0,0 +1,400 @@
+package api
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anacrolix/dms/upnp"
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stashapp/stash/internal/dlna"
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+// HDHomeRun tuner emulation.
+//
+// Plex, Jellyfin and Emby all have an M3U/XMLTV path for Live TV, but the one
+// they detect and set up reliably is a SiliconDust HDHomeRun network tuner.
+// This makes the lineup look like one: a device that answers /discover.json,
+// lists its channels in /lineup.json and streams channel N from /auto/vN. The
+// stream is ChannelStream, unchanged — an HDHomeRun sends MPEG-TS over plain
+// HTTP, which is exactly what a channel already is.
+//
+// Discovery is SSDP, announced with the same code the DLNA server uses, so a
+// media server scanning for tuners finds this one without being told where
+// to look. The announce is off unless enabled in the plugin settings: it
+// advertises the server to everything on the network.
+//
+// Media servers cannot add an api key to the URLs they build, so on a server
+// that needs one the device lives under /hdhr/k/{apikey}, and
+// iptvHDHRAuthBridge moves the key into the query parameter the
+// authentication handler checks — the same trick the Xtream routes play with
+// their password field.
+
+const (
+	iptvHDHRPathPrefix = "/hdhr"
+	iptvHDHRKeyPrefix  = iptvHDHRPathPrefix + "/k/"
+
+	// The device model Plex and Jellyfin know best. FirmwareName and
+	// ModelNumber decide which features a media server expects; these are a
+	// plain cable tuner's, which is to say MPEG-TS over HTTP and nothing else.
+	iptvHDHRManufacturer    = "Silicondust"
+	iptvHDHRModelNumber     = "HDTC-2US"
+	iptvHDHRFirmwareName    = "hdhomeruntc_atsc"
+	iptvHDHRFirmwareVersion = "20200101"
+	iptvHDHRDeviceType      = "urn:schemas-upnp-org:device:MediaServer:1"
+
+	// iptvHDHRUnlimitedTuners is the tuner count reported when no limit is
+	// set. The protocol has no way to say unlimited, and media servers stop
+	// offering channels once they believe every tuner is busy.
+	iptvHDHRUnlimitedTuners = 8
+
+	// HDHomeRun's own error for a request beyond its tuner count. Plex shows
+	// it as "all tuners in use" rather than as a broken channel.
+	iptvHDHRAllTunersInUse = "805 All Tuners In Use"
+
+	iptvHDHRNotifyInterval = 30 * time.Second
+	// iptvHDHRDiscoveryCheck is how often the announcer rereads its settings,
+	// which is how long enabling or disabling discovery takes to apply.
+	iptvHDHRDiscoveryCheck = time.Minute
+)
+
+// iptvHDHRAuthBridge copies the api key out of an /hdhr/k/{apikey} path into
+// the apikey query parameter. Registered in server.go before
+// authenticateHandler, for the same reason and on the same condition as
+// iptvXtreamAuthBridge.
+func iptvHDHRAuthBridge(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rest, ok := strings.CutPrefix(r.URL.Path, iptvHDHRKeyPrefix); ok && xtreamCredentialsRequired() {
+			key, _, _ := strings.Cut(rest, "/")
+			q := r.URL.Query()
+			if key != "" && q.Get("apikey") == "" {
+				q.Set("apikey", key)
+				r.URL.RawQuery = q.Encode()
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hdhrRoutes returns the device endpoints, mounted at /hdhr. Every endpoint is
+// also available under /k/{apikey}.
+func (rs iptvRoutes) hdhrRoutes() chi.Router {
+	r := chi.NewRouter()
+
+	register := func(r chi.Router) {
+		r.Get("/discover.json", rs.HDHRDiscover)
+		r.Get("/device.xml", rs.HDHRDeviceXML)
+		r.Get("/lineup.json", rs.HDHRLineup)
+		r.Get("/lineup_status.json", rs.HDHRLineupStatus)
+		r.Post("/lineup.post", rs.HDHRLineupPost)
+		r.Get("/auto/v{number}", rs.HDHRStream)
+	}
+	register(r)
+	r.Route("/k/{apikey}", register)
+
+	return r
+}
+
+// hdhrBaseURL is the base of every device URL, carrying the request's api
+// key in the path if it has one.
+func hdhrBaseURL(r *http.Request) string {
+	base := iptvBaseURL(r) + iptvHDHRPathPrefix
+	if apiKey := r.URL.Query().Get("apikey"); apiKey != "" {
+		base += "/k/" + url.PathEscape(apiKey)
+	}
+	return base
+}
+
+// iptvHDHRDeviceID is the device's stable identity. Media servers key their
+// tuner configuration on it, so it must not change across restarts, and two
+// servers on the same network must not share one.
+func iptvHDHRDeviceID(port int) string {
+	host, _ := os.Hostname()
+	h := fnv.New32a()
+	_, _ = fmt.Fprintf(h, "vexxx-hdhr|%s|%d", host, port)
+	return fmt.Sprintf("%08X", h.Sum32())
+}
+
+func iptvHDHRTunerCount(s iptvSettings) int {
+	if s.TunerCount > 0 {
+		return s.TunerCount
+	}
+	return iptvHDHRUnlimitedTuners
+}
+
+type iptvHDHRDiscovery struct {
+	FriendlyName    string `json:"FriendlyName"`
+	Manufacturer    string `json:"Manufacturer"`
+	ModelNumber     string `json:"ModelNumber"`
+	FirmwareName    string `json:"FirmwareName"`
+	FirmwareVersion string `json:"FirmwareVersion"`
+	DeviceID        string `json:"DeviceID"`
+	DeviceAuth      string `json:"DeviceAuth"`
+	BaseURL         string `json:"BaseURL"`
+	LineupURL       string `json:"LineupURL"`
+	TunerCount      int    `json:"TunerCount"`
+}
+
+func (rs iptvRoutes) HDHRDiscover(w http.ResponseWriter, r *http.Request) {
+	s := rs.settings()
+	base := hdhrBaseURL(r)
+
+	writeJSON(w, iptvHDHRDiscovery{
+		FriendlyName:    s.GroupTitle,
+		Manufacturer:    iptvHDHRManufacturer,
+		ModelNumber:     iptvHDHRModelNumber,
+		FirmwareName:    iptvHDHRFirmwareName,
+		FirmwareVersion: iptvHDHRFirmwareVersion,
+		DeviceID:        iptvHDHRDeviceID(rs.config.GetPort()),
+		DeviceAuth:      "vexxx",
+		BaseURL:         base,
+		LineupURL:       base + "/lineup.json",
+		TunerCount:      iptvHDHRTunerCount(s),
+	})
+}
+
+// HDHRDeviceXML is the UPnP description SSDP points at. Media servers read
+// URLBase from it and carry on with /discover.json.
+func (rs iptvRoutes) HDHRDeviceXML(w http.ResponseWriter, r *http.Request) {
+	s := rs.settings()
+	id := iptvHDHRDeviceID(rs.config.GetPort())
+
+	desc := struct {
+		upnp.DeviceDesc
+		URLBase string `xml:"URLBase"`
+	}{
+		DeviceDesc: upnp.DeviceDesc{
+			SpecVersion: upnp.SpecVersion{Major: 1, Minor: 0},
+			Device: upnp.Device{
+				DeviceType:   iptvHDHRDeviceType,
+				FriendlyName: s.GroupTitle,
+				Manufacturer: iptvHDHRManufacturer,
+				ModelName:    iptvHDHRModelNumber,
+				UDN:          dlna.DeviceUUID(id),
+			},
+		},
+		URLBase: hdhrBaseURL(r),
+	}
+
+	out, err := xml.MarshalIndent(desc, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	_, _ = w.Write([]byte(xml.Header))
+	_, _ = w.Write(out)
+}
+
+type iptvHDHRLineupEntry struct {
+	GuideNumber string `json:"GuideNumber"`
+	GuideName   string `json:"GuideName"`
+	URL         string `json:"URL"`
+}
+
+func (rs iptvRoutes) HDHRLineup(w http.ResponseWriter, r *http.Request) {
+	s := rs.settings()
+
+	channels, _, err := rs.channelList(r, s)
+	if err != nil {
+		logger.Errorf("[iptv] hdhr: building channel list: %v", err)
+		http.Error(w, "error building channel list", http.StatusInternalServerError)
+		return
+	}
+
+	base := hdhrBaseURL(r)
+	out := make([]iptvHDHRLineupEntry, 0, len(channels))
+	for _, ch := range channels {
+		number := strconv.Itoa(ch.Number)
+		out = append(out, iptvHDHRLineupEntry{
+			GuideNumber: number,
+			GuideName:   ch.Name,
+			URL:         base + "/auto/v" + number,
+		})
+	}
+
+	writeJSON(w, out)
+}
+
+// HDHRLineupStatus reports that no channel scan is running. The lineup is
+// always current, so a scan has nothing to find.
+func (rs iptvRoutes) HDHRLineupStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"ScanInProgress": 0,
+		"ScanPossible":   1,
+		"Source":         "Cable",
+		"SourceList":     []string{"Cable"},
+	})
+}
+
+// HDHRLineupPost is a channel scan. It rebuilds the lineup, which is the
+// closest thing there is to rescanning.
+func (rs iptvRoutes) HDHRLineupPost(w http.ResponseWriter, r *http.Request) {
+	rs.invalidateChannels()
+	w.WriteHeader(http.StatusOK)
+}
+
+// HDHRStream tunes to a channel by its number.
+func (rs iptvRoutes) HDHRStream(w http.ResponseWriter, r *http.Request) {
+	number, err := strconv.Atoi(chi.URLParam(r, "number"))
+	if err != nil {
+		http.Error(w, "invalid channel number", http.StatusBadRequest)
+		return
+	}
+
+	s := rs.settings()
+	channels, _, err := rs.channelList(r, s)
+	if err != nil {
+		logger.Errorf("[iptv] hdhr: building channel list: %v", err)
+		http.Error(w, "error building channel list", http.StatusInternalServerError)
+		return
+	}
+
+	for _, ch := range channels {
+		if ch.Number == number {
+			rs.streamChannel(w, r, ch.Key)
+			return
+		}
+	}
+	http.Error(w, "unknown channel", http.StatusNotFound)
+}
+
+// ─── tuners ───────────────────────────────────────────────────────────────────
+
+// iptvTuners counts the channel pipelines running, so that their number can be
+// capped like a real tuner's. Every client counts, not only HDHomeRun ones: the
+// limit exists to protect the machine doing the remuxing, which cannot tell
+// them apart. Live viewers of one channel share a broadcast and so a tuner;
+// each catch-up stream takes its own.
+type iptvTuners struct {
+	mu    sync.Mutex
+	inUse int
+}
+
+// acquire takes a tuner, failing if limit are already in use. A limit of zero
+// or less means no limit.
+func (t *iptvTuners) acquire(limit int) (release func(), ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if limit > 0 && t.inUse >= limit {
+		return nil, false
+	}
+	t.inUse++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			t.inUse--
+			t.mu.Unlock()
+		})
+	}, true
+}
+
+func (t *iptvTuners) count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.inUse
+}
+
+// ─── discovery ────────────────────────────────────────────────────────────────
+
+// startHDHRDiscovery runs the SSDP announcer for the emulated tuner whenever
+// it is enabled in the plugin settings. It returns immediately; the work runs
+// in its own goroutine for the process lifetime.
+func (rs iptvRoutes) startHDHRDiscovery() {
+	go func() {
+		var (
+			closed  chan struct{}
+			running string
+			lastErr string
+		)
+
+		for {
+			location, err := rs.hdhrDiscoveryLocation()
+			if err != nil && err.Error() != lastErr {
+				logger.Warnf("[iptv] hdhr: not announcing the tuner: %v", err)
+			}
+			lastErr = ""
+			if err != nil {
+				lastErr = err.Error()
+			}
+
+			if location != running {
+				if closed != nil {
+					close(closed)
+					closed = nil
+				}
+				if location != "" {
+					closed = make(chan struct{})
+					rs.announceHDHR(location, closed)
+				}
+				running = location
+			}
+
+			time.Sleep(iptvHDHRDiscoveryCheck)
+		}
+	}()
+}
+
+// hdhrDiscoveryLocation returns the path of the device description to
+// announce, or an empty string if nothing should be announced.
+//
+// The api key announced is a separate setting rather than anything taken
+// from a request: SSDP announces it to the whole network, so it should be a
+// key made for the purpose, limited to the IPTV scope.
+func (rs iptvRoutes) hdhrDiscoveryLocation() (string, error) {
+	s := rs.settings()
+	if !s.HDHRDiscovery {
+		return "", nil
+	}
+
+	if !xtreamCredentialsRequired() {
+		return iptvHDHRPathPrefix + "/device.xml", nil
+	}
+	if s.HDHRAPIKey == "" {
+		return "", errors.New("this server requires an api key and none is set for discovery")
+	}
+	return iptvHDHRKeyPrefix + url.PathEscape(s.HDHRAPIKey) + "/device.xml", nil
+}
+
+func (rs iptvRoutes) announceHDHR(location string, closed chan struct{}) {
+	ifs, err := dlna.Interfaces(nil)
+	if err != nil {
+		logger.Errorf("[iptv] hdhr: listing network interfaces: %v", err)
+		return
+	}
+
+	scheme := "http"
+	if rs.config.HasTLSConfig() {
+		scheme = "https"
+	}
+	port := rs.config.GetPort()
+	id := iptvHDHRDeviceID(port)
+
+	logger.Infof("[iptv] hdhr: announcing tuner %s", id)
+
+	go dlna.SSDPAnnouncer{
+		Interfaces: ifs,
+		Devices:    []string{iptvHDHRDeviceType},
+		Location: func(ip net.IP) string {
+			return scheme + "://" + (&net.TCPAddr{IP: ip, Port: port}).String() + location
+		},
+		Server:         "HDHomeRun/1.0 UPnP/1.0",
+		UUID:           dlna.DeviceUUID(id),
+		NotifyInterval: iptvHDHRNotifyInterval,
+	}.Run(closed)
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func TestTunersRefuseBeyondLimit(t *testing.T) {
+	var tuners iptvTuners
+
+	first, ok := tuners.acquire(2)
+	if !ok {
+		t.Fatal("first tuner refused")
+	}
+	if _, ok := tuners.acquire(2); !ok {
+		t.Fatal("second tuner refused")
+	}
+	if _, ok := tuners.acquire(2); ok {
+		t.Fatal("third tuner granted with a limit of two")
+	}
+
+	first()
+	if _, ok := tuners.acquire(2); !ok {
+		t.Error("tuner not freed by release")
+	}
+}
+
+// A stream's release runs from a defer and possibly from cleanup too; freeing
+// a tuner twice would let the count drift below the streams actually running.
+func TestTunerReleaseIsIdempotent(t *testing.T) {
+	var tuners iptvTuners
+
+	release, _ := tuners.acquire(1)
+	_, _ = tuners.acquire(0)
+	release()
+	release()
+
+	if n := tuners.count(); n != 1 {
+		t.Errorf("count = %d after releasing one of two tuners twice, want 1", n)
+	}
+}
+
+func TestTunersUnlimitedWithoutLimit(t *testing.T) {
+	var tuners iptvTuners
+	for i := 0; i < 50; i++ {
+		if _, ok := tuners.acquire(0); !ok {
+			t.Fatalf("tuner %d refused with no limit set", i)
+		}
+	}
+}
+
+func TestHDHRTunerCountReportsLimitOrDefault(t *testing.T) {
+	if n := iptvHDHRTunerCount(iptvSettings{TunerCount: 3}); n != 3 {
+		t.Errorf("tuner count = %d, want the configured 3", n)
+	}
+	if n := iptvHDHRTunerCount(iptvSettings{}); n != iptvHDHRUnlimitedTuners {
+		t.Errorf("tuner count = %d with no limit, want %d", n, iptvHDHRUnlimitedTuners)
+	}
+}
+
+// Media servers build every later URL from BaseURL, so the api key has to be
+// part of it rather than a query parameter they would drop.
+func TestHDHRBaseURLCarriesAPIKeyInPath(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://tv.local:9999/discover.json?apikey=a%2Fb", nil)
+	if got, want := hdhrBaseURL(r), "http://tv.local:9999/hdhr/k/a%2Fb"; got != want {
+		t.Errorf("base URL = %q, want %q", got, want)
+	}
+
+	r = httptest.NewRequest("GET", "http://tv.local:9999/hdhr/discover.json", nil)
+	if got, want := hdhrBaseURL(r), "http://tv.local:9999/hdhr"; got != want {
+		t.Errorf("base URL = %q, want %q", got, want)
+	}
+}
+
+func TestHDHRDeviceIDIsStablePerPort(t *testing.T) {
+	if iptvHDHRDeviceID(9999) != iptvHDHRDeviceID(9999) {
+		t.Error("device id changes between calls")
+	}
+	if iptvHDHRDeviceID(9999) == iptvHDHRDeviceID(9998) {
+		t.Error("two servers on one host share a device id")
+	}
+	if id := iptvHDHRDeviceID(9999); len(id) != 8 {
+		t.Errorf("device id %q is not eight hex digits", id)
+	}
+}
+
+// The key hdhrDiscoveryLocation tells admins to create is IPTV-scoped, so
+// that scope has to reach every tuner endpoint, including the channel scan.
+func TestHDHRAllowsIPTVScopedKey(t *testing.T) {
+	iptv := models.APIKeyScopes{models.APIKeyScopeIPTV}
+	stream := models.APIKeyScopes{models.APIKeyScopeStream}
+
+	tests := []struct {
+		method string
+		path   string
+		scopes models.APIKeyScopes
+		want   bool
+	}{
+		{http.MethodGet, "/hdhr/k/key/lineup.json", iptv, true},
+		{http.MethodGet, "/hdhr/k/key/discover.json", iptv, true},
+		{http.MethodGet, "/hdhr/k/key/auto/v1", iptv, true},
+		{http.MethodPost, "/hdhr/k/key/lineup.post", iptv, true},
+		{http.MethodGet, "/discover.json", iptv, true},
+		{http.MethodGet, "/hdhr/lineup.json", stream, false},
+		{http.MethodPost, "/hdhr/k/key/lineup.json", iptv, false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		if got := apiKeyScopeAllows(tt.scopes, r); got != tt.want {
+			t.Errorf("%s %s with %v allowed = %v, want %v", tt.method, tt.path, tt.scopes, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,355 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stashapp/stash/internal/manager"
+	"github.com/stashapp/stash/internal/manager/config"
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/iptv"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// Live HLS.
+//
+// ChannelStream's never-ending MPEG-TS body is what IPTV apps want, and what
+// browsers, Apple TV and anything behind a buffering proxy cannot play. For
+// those a channel is also a live HLS playlist: a rolling window of short
+// segments with a media sequence that only ever grows, and a discontinuity
+// wherever one programme gives way to the next.
+//
+// The schedule's segment grid was made for this. A channel segment is
+// SegmentSeconds long and aligned to Epoch, and so is every programme boundary,
+// so channel segment n is always exactly one whole segment of one scene — the
+// one StreamManager would serve for that scene's own HLS stream. The segment
+// handler maps n back to (scene, segment) and hands the request to
+// StreamManager, which already owns transcoding, look-ahead and idle reaping.
+// Everybody watching a channel asks for the same segments of the same scene,
+// so StreamManager runs one ffmpeg for all of them rather than one each.
+//
+// Only library channels have it. StreamManager reads local files; a network
+// programme is a signed URL that needs its own request headers and expires,
+// which is a different pipeline (pipeProgram) altogether.
+
+const (
+	// iptvHLSWindow is how many segments a live playlist lists. Players start
+	// about three segments from the end, so this is mostly how far back a
+	// player that stalled can still catch up from.
+	iptvHLSWindow = 10
+
+	// iptvHLSLookahead is how far past the live edge a segment may be asked
+	// for. Anything further is not on air yet, and transcoding it on request
+	// would let a client run the channel ahead of its schedule.
+	iptvHLSLookahead = 2
+
+	// iptvMaxDiscontinuityCount caps the programme changes counted between
+	// two playlist requests. Only a channel nobody has watched for a long time
+	// gets near it, and for that one any increase will do.
+	iptvMaxDiscontinuityCount = 10000
+
+	// iptvHLSTimelineIdle is how long a channel's discontinuity count is kept
+	// after its playlist was last asked for. By then every segment a player
+	// could still hold has scrolled off, so starting the count again is safe.
+	iptvHLSTimelineIdle = 2 * iptvHLSWindow * iptv.SegmentSeconds * time.Second
+)
+
+func (rs iptvRoutes) hlsChannel(w http.ResponseWriter, r *http.Request) (*iptvChannel, *iptv.Grid, iptvSettings, bool) {
+	s := rs.settings()
+
+	ch, err := rs.channelByKey(r, chi.URLParam(r, "channelId"), s)
+	if err != nil {
+		logger.Errorf("[iptv] resolving channel: %v", err)
+		http.Error(w, "error resolving channel", http.StatusInternalServerError)
+		return nil, nil, s, false
+	}
+	if ch == nil {
+		http.Error(w, "unknown channel", http.StatusNotFound)
+		return nil, nil, s, false
+	}
+	if ch.isNetwork() {
+		http.Error(w, "HLS is only available for library channels", http.StatusNotFound)
+		return nil, nil, s, false
+	}
+
+	grid, err := rs.schedule(r, *ch, s, false)
+	if err != nil {
+		logger.Errorf("[iptv] building schedule for channel %s: %v", ch.Key, err)
+		http.Error(w, "error building schedule", http.StatusInternalServerError)
+		return nil, nil, s, false
+	}
+	if grid.Empty() {
+		http.Error(w, "channel has no playable content", http.StatusNotFound)
+		return nil, nil, s, false
+	}
+
+	return ch, grid, s, true
+}
+
+// ChannelHLS serves a channel's live playlist.
+func (rs iptvRoutes) ChannelHLS(w http.ResponseWriter, r *http.Request) {
+	ch, grid, _, ok := rs.hlsChannel(w, r)
+	if !ok {
+		return
+	}
+
+	segs := iptvHLSSegments(grid, time.Now(), iptvHLSWindow)
+	if len(segs) == 0 {
+		http.Error(w, "nothing is on air", http.StatusNotFound)
+		return
+	}
+
+	scope, err := iptvScope(r.Context())
+	if err != nil {
+		logger.Errorf("[iptv] scoping channel %s: %v", ch.Key, err)
+		http.Error(w, "error resolving channel", http.StatusInternalServerError)
+		return
+	}
+	disc := rs.hls.discontinuitySeq(scope+":"+ch.Key, grid, segs[0].Seq)
+
+	base := iptvBaseURL(r)
+	apiKey := r.URL.Query().Get("apikey")
+	playlist := iptvHLSPlaylist(segs, disc, func(seq int64) string {
+		return iptvURL(base, fmt.Sprintf("/iptv/hls/%s/%d.ts", ch.Key, seq), apiKey)
+	})
+
+	w.Header().Set("Content-Type", ffmpeg.MimeHLS)
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = w.Write([]byte(playlist))
+}
+
+// ChannelHLSSegment serves one segment of a channel's live playlist.
+func (rs iptvRoutes) ChannelHLSSegment(w http.ResponseWriter, r *http.Request) {
+	seq, err := strconv.ParseInt(chi.URLParam(r, "segment"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid segment", http.StatusBadRequest)
+		return
+	}
+
+	ch, grid, s, ok := rs.hlsChannel(w, r)
+	if !ok {
+		return
+	}
+
+	// Only what a current playlist can list: anything else would be a way
+	// to transcode arbitrary stretches of the schedule on demand.
+	live := iptv.AbsSegment(time.Now())
+	if seq > live+iptvHLSLookahead || seq <= live-2*iptvHLSWindow {
+		http.Error(w, "segment is not on air", http.StatusNotFound)
+		return
+	}
+
+	seg, ok := iptvHLSSegmentAt(grid, seq)
+	if !ok {
+		http.Error(w, "segment is not on air", http.StatusNotFound)
+		return
+	}
+
+	streamManager := manager.GetInstance().StreamManager
+	if streamManager == nil {
+		http.Error(w, "Live transcoding disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	var (
+		f    *models.VideoFile
+		hash string
+	)
+	if err := rs.withReadTxn(r, func(ctx context.Context) error {
+		scene, err := rs.repository.Scene.Find(ctx, seg.SceneID)
+		if err != nil {
+			return err
+		}
+		if scene == nil {
+			return fmt.Errorf("scene %d not found", seg.SceneID)
+		}
+		if err := scene.LoadFiles(ctx, rs.repository.Scene); err != nil {
+			return err
+		}
+		f = scene.Files.Primary()
+		if f == nil {
+			return fmt.Errorf("scene %d has no primary file", seg.SceneID)
+		}
+		hash = scene.GetHash(config.GetInstance().GetVideoFileNamingAlgorithm())
+		return nil
+	}); err != nil {
+		logger.Warnf("[iptv] channel %s: segment %d: %v", ch.Key, seq, err)
+		http.Error(w, "error resolving segment", http.StatusInternalServerError)
+		return
+	}
+
+	streamManager.ServeSegment(w, r, ffmpeg.StreamOptions{
+		StreamType: ffmpeg.StreamTypeHLS,
+		VideoFile:  f,
+		Resolution: s.Resolution,
+		Hash:       hash,
+		Segment:    strconv.Itoa(seg.LocalSeg),
+	})
+}
+
+// iptvHLSSegment is one channel segment: absolute segment Seq of the
+// timeline, which is segment LocalSeg of scene SceneID.
+type iptvHLSSegment struct {
+	Seq      int64
+	SceneID  int
+	LocalSeg int
+	// Discontinuity marks the first segment of an airing.
+	Discontinuity bool
+}
+
+// iptvHLSSegmentAt returns the channel segment at absolute segment seq.
+func iptvHLSSegmentAt(grid *iptv.Grid, seq int64) (iptvHLSSegment, bool) {
+	start := iptv.SegmentTime(seq)
+	a, offset, ok := iptvOnAir(grid, start)
+	if !ok {
+		return iptvHLSSegment{}, false
+	}
+
+	// offset is a whole number of segments; rounding only guards the float
+	return iptvHLSSegment{
+		Seq:           seq,
+		SceneID:       a.Program.SceneID,
+		LocalSeg:      int(offset+0.5) / iptv.SegmentSeconds,
+		Discontinuity: a.Start.Equal(start),
+	}, true
+}
+
+// iptvHLSSegments returns the live window at now: up to n segments ending with
+// the one on air. A window never spans time with nothing on air, since the
+// media sequence could not account for the gap.
+func iptvHLSSegments(grid *iptv.Grid, now time.Time, n int) []iptvHLSSegment {
+	live := iptv.AbsSegment(now)
+
+	ret := make([]iptvHLSSegment, 0, n)
+	for seq := live; seq > live-int64(n); seq-- {
+		seg, ok := iptvHLSSegmentAt(grid, seq)
+		if !ok {
+			break
+		}
+		ret = append(ret, seg)
+	}
+
+	for i, j := 0, len(ret)-1; i < j; i, j = i+1, j-1 {
+		ret[i], ret[j] = ret[j], ret[i]
+	}
+	return ret
+}
+
+// iptvHLSPlaylist writes a live playlist of segs.
+func iptvHLSPlaylist(segs []iptvHLSSegment, discontinuitySeq int64, segmentURL func(seq int64) string) string {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	b.WriteString("#EXT-X-VERSION:3\n")
+	fmt.Fprintf(&b, "#EXT-X-TARGETDURATION:%d\n", iptv.SegmentSeconds)
+	if len(segs) > 0 {
+		fmt.Fprintf(&b, "#EXT-X-MEDIA-SEQUENCE:%d\n", segs[0].Seq)
+	}
+	fmt.Fprintf(&b, "#EXT-X-DISCONTINUITY-SEQUENCE:%d\n", discontinuitySeq)
+
+	for _, seg := range segs {
+		if seg.Discontinuity {
+			b.WriteString("#EXT-X-DISCONTINUITY\n")
+		}
+		fmt.Fprintf(&b, "#EXTINF:%d.000,\n", iptv.SegmentSeconds)
+		b.WriteString(segmentURL(seg.Seq))
+		b.WriteString("\n")
+	}
+
+	// no EXT-X-ENDLIST: a channel does not end
+	return b.String()
+}
+
+// iptvHLSTimelines tracks the discontinuity sequence of each channel's live
+// playlist.
+//
+// HLS requires EXT-X-DISCONTINUITY-SEQUENCE to grow by one for each
+// discontinuity that scrolls off the top of the playlist. Cycle can work that
+// out from the wall clock alone, but once blocks cut into the cycle a count of
+// programme changes since Epoch would mean walking the whole grid since 2024.
+// The count only has to be consistent among the playlists players currently
+// hold, though, so it is kept from when the channel was first watched and
+// carried forward as the window moves, and dropped once nobody has asked for
+// the playlist for iptvHLSTimelineIdle.
+type iptvHLSTimelines struct {
+	mu      sync.Mutex
+	entries map[string]*iptvHLSTimeline
+	idle    time.Duration
+}
+
+type iptvHLSTimeline struct {
+	// seq is the window start disc was last worked out for.
+	seq  int64
+	disc int64
+
+	// used and expiry are guarded by iptvHLSTimelines.mu.
+	used   time.Time
+	expiry *time.Timer
+}
+
+func newIPTVHLSTimelines() *iptvHLSTimelines {
+	return &iptvHLSTimelines{
+		entries: make(map[string]*iptvHLSTimeline),
+		idle:    iptvHLSTimelineIdle,
+	}
+}
+
+func (t *iptvHLSTimelines) discontinuitySeq(key string, grid *iptv.Grid, start int64) int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e := t.entries[key]
+	if e == nil {
+		e = &iptvHLSTimeline{seq: start}
+		t.entries[key] = e
+		e.expiry = time.AfterFunc(t.idle, func() { t.expire(key, e) })
+	}
+	e.used = time.Now()
+
+	switch {
+	case start > e.seq:
+		e.disc += iptvAiringStarts(grid, e.seq, start)
+	case start < e.seq:
+		e.disc -= iptvAiringStarts(grid, start, e.seq)
+	}
+	e.seq = start
+
+	return e.disc
+}
+
+// expire drops a timeline that has been idle for long enough, or checks again
+// when it will have been.
+func (t *iptvHLSTimelines) expire(key string, e *iptvHLSTimeline) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.entries[key] != e {
+		return
+	}
+
+	if idle := time.Since(e.used); idle < t.idle {
+		e.expiry.Reset(t.idle - idle)
+		return
+	}
+	delete(t.entries, key)
+}
+
+// iptvAiringStarts counts the airings starting in segments [from, to).
+func iptvAiringStarts(grid *iptv.Grid, from, to int64) int64 {
+	start := iptv.SegmentTime(from)
+
+	var n int64
+	for _, a := range grid.Airings(start, iptv.SegmentTime(to), iptvMaxDiscontinuityCount) {
+		if !a.Start.Before(start) {
+			n++
+		}
+	}
+	return n
+}
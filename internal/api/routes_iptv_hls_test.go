@@ -0,0 +1,168 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/iptv"
+)
+
+// hlsGrid airs programmes of 10s, i.e. five segments each.
+func hlsGrid() *iptv.Grid {
+	return iptv.NewGrid(testCycle(10, 10, 10), nil, time.UTC)
+}
+
+func TestHLSWindowEndsAtLiveSegment(t *testing.T) {
+	now := iptv.Epoch.Add(1000*time.Hour + 7*time.Second)
+	segs := iptvHLSSegments(hlsGrid(), now, iptvHLSWindow)
+
+	if len(segs) != iptvHLSWindow {
+		t.Fatalf("got %d segments, want %d", len(segs), iptvHLSWindow)
+	}
+	live := iptv.AbsSegment(now)
+	for i, seg := range segs {
+		if want := live - int64(len(segs)-1-i); seg.Seq != want {
+			t.Errorf("segment %d has seq %d, want %d", i, seg.Seq, want)
+		}
+	}
+}
+
+// A channel segment has to be exactly the scene segment StreamManager would
+// serve, and every programme change a discontinuity.
+func TestHLSSegmentsMapOntoSceneSegments(t *testing.T) {
+	now := iptv.Epoch.Add(1000 * time.Hour) // a programme boundary
+	segs := iptvHLSSegments(hlsGrid(), now.Add(9*time.Second), 10)
+
+	for i, seg := range segs {
+		if want := i % 5; seg.LocalSeg != want {
+			t.Errorf("segment %d is scene segment %d, want %d", i, seg.LocalSeg, want)
+		}
+		if seg.Discontinuity != (seg.LocalSeg == 0) {
+			t.Errorf("segment %d (scene segment %d): discontinuity = %v", i, seg.LocalSeg, seg.Discontinuity)
+		}
+	}
+	if segs[0].SceneID == segs[5].SceneID {
+		t.Error("window does not cross into the next programme")
+	}
+}
+
+// After a block the base cycle rejoins a programme in progress, so the first
+// segment after the block is a discontinuity part way into its scene.
+func TestHLSSegmentRejoinsSceneAfterBlock(t *testing.T) {
+	base := testCycle(7 * 60)
+	block := testCycle(600)
+	grid := iptv.NewGrid(base, []iptv.Block{{Start: 10 * time.Hour, End: 11 * time.Hour, Cycle: block}}, time.UTC)
+
+	end := time.Date(2024, time.February, 1, 11, 0, 0, 0, time.UTC)
+	seg, ok := iptvHLSSegmentAt(grid, iptv.AbsSegment(end))
+	if !ok {
+		t.Fatal("nothing on air after the block")
+	}
+	if !seg.Discontinuity || seg.LocalSeg == 0 {
+		t.Errorf("got %+v, want a discontinuity into the middle of the scene", seg)
+	}
+
+	next, _ := iptvHLSSegmentAt(grid, seg.Seq+1)
+	if next.Discontinuity || next.LocalSeg != seg.LocalSeg+1 {
+		t.Errorf("next segment %+v does not continue the scene", next)
+	}
+}
+
+func TestHLSWindowStopsAtDeadAir(t *testing.T) {
+	block := testCycle(600)
+	grid := iptv.NewGrid(&iptv.Cycle{}, []iptv.Block{{Start: 20 * time.Hour, End: 21 * time.Hour, Cycle: block}}, time.UTC)
+
+	opened := time.Date(2024, time.March, 4, 20, 0, 0, 0, time.UTC)
+	segs := iptvHLSSegments(grid, opened.Add(5*time.Second), iptvHLSWindow)
+	if len(segs) != 3 {
+		t.Fatalf("got %d segments, want the 3 since the block opened", len(segs))
+	}
+	if !segs[0].Discontinuity {
+		t.Error("block's first segment is not a discontinuity")
+	}
+
+	if segs := iptvHLSSegments(grid, opened.Add(-time.Minute), iptvHLSWindow); len(segs) != 0 {
+		t.Errorf("got %d segments with nothing on air", len(segs))
+	}
+}
+
+func TestHLSPlaylistFormat(t *testing.T) {
+	segs := []iptvHLSSegment{
+		{Seq: 41, SceneID: 1, LocalSeg: 9},
+		{Seq: 42, SceneID: 2, LocalSeg: 0, Discontinuity: true},
+	}
+	got := iptvHLSPlaylist(segs, 7, func(seq int64) string {
+		return "/s/" + strconv.FormatInt(seq, 10) + ".ts"
+	})
+
+	for _, want := range []string{
+		"#EXT-X-MEDIA-SEQUENCE:41\n",
+		"#EXT-X-DISCONTINUITY-SEQUENCE:7\n",
+		"#EXT-X-TARGETDURATION:2\n",
+		"/s/41.ts\n#EXT-X-DISCONTINUITY\n#EXTINF:2.000,\n/s/42.ts\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("playlist lacks %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "#EXT-X-ENDLIST") {
+		t.Error("live playlist ends")
+	}
+}
+
+// The discontinuity sequence must grow by exactly the number of programme
+// changes that scrolled off the top of the window.
+func TestHLSDiscontinuitySequenceCountsScrolledOffChanges(t *testing.T) {
+	grid := hlsGrid()
+	timelines := newIPTVHLSTimelines()
+
+	start := iptv.AbsSegment(iptv.Epoch.Add(1000 * time.Hour))
+	first := timelines.discontinuitySeq("c", grid, start)
+	if again := timelines.discontinuitySeq("c", grid, start); again != first {
+		t.Errorf("same window got %d, then %d", first, again)
+	}
+
+	// 12 segments on: the starts at +0, +5 and +10 have scrolled off
+	if got := timelines.discontinuitySeq("c", grid, start+12); got != first+3 {
+		t.Errorf("got %d, want %d", got, first+3)
+	}
+	// another channel keeps its own count
+	if got := timelines.discontinuitySeq("d", grid, start+12); got != 0 {
+		t.Errorf("new channel starts at %d, want 0", got)
+	}
+}
+
+// A timeline nobody asks for is dropped, and one still in use is not.
+func TestHLSTimelinesExpireWhenIdle(t *testing.T) {
+	grid := hlsGrid()
+	timelines := newIPTVHLSTimelines()
+	timelines.idle = 20 * time.Millisecond
+
+	start := iptv.AbsSegment(iptv.Epoch.Add(1000 * time.Hour))
+	timelines.discontinuitySeq("idle", grid, start)
+	timelines.discontinuitySeq("watched", grid, start)
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		timelines.discontinuitySeq("watched", grid, start)
+		time.Sleep(5 * time.Millisecond)
+
+		timelines.mu.Lock()
+		_, idle := timelines.entries["idle"]
+		timelines.mu.Unlock()
+		if !idle {
+			break
+		}
+	}
+
+	timelines.mu.Lock()
+	defer timelines.mu.Unlock()
+	if _, ok := timelines.entries["idle"]; ok {
+		t.Error("idle timeline not dropped")
+	}
+	if _, ok := timelines.entries["watched"]; !ok {
+		t.Error("timeline in use dropped")
+	}
+}
@@ -229,13 +229,13 @@ func TestOnAirReportsSubSecondOffset(t *testing.T) {
 
 	// 100.75s into the cycle: inside the first programme.
 	now := iptv.Epoch.Add(100750 * time.Millisecond)
-	program, offset, ok := iptvOnAir(c, now)
+	a, offset, ok := iptvOnAir(iptv.NewGrid(c, nil, time.UTC), now)
 	if !ok {
 		t.Fatal("nothing on air")
 	}
 
-	if program.SceneID != 500 {
-		t.Errorf("on air scene = %d, want 500", program.SceneID)
+	if a.Program.SceneID != 500 {
+		t.Errorf("on air scene = %d, want 500", a.Program.SceneID)
 	}
 	// The offset must carry the fraction, not be rounded to the segment grid —
 	// a whole-segment offset would let viewers drift up to two seconds apart.
@@ -249,13 +249,13 @@ func TestOnAirCrossesIntoNextProgramme(t *testing.T) {
 
 	// One second past the end of the first programme.
 	now := iptv.Epoch.Add(601 * time.Second)
-	program, offset, ok := iptvOnAir(c, now)
+	a, offset, ok := iptvOnAir(iptv.NewGrid(c, nil, time.UTC), now)
 	if !ok {
 		t.Fatal("nothing on air")
 	}
 
-	if program.SceneID != 501 {
-		t.Errorf("on air scene = %d, want 501 (the second programme)", program.SceneID)
+	if a.Program.SceneID != 501 {
+		t.Errorf("on air scene = %d, want 501 (the second programme)", a.Program.SceneID)
 	}
 	if offset < 0.9 || offset > 1.1 {
 		t.Errorf("offset into second programme = %v, want ~1", offset)
@@ -267,7 +267,7 @@ func TestOnAirNeverReturnsNegativeOffset(t *testing.T) {
 	c := testCycle(600, 600)
 
 	for _, delta := range []time.Duration{0, -time.Millisecond, -time.Hour, -99 * time.Hour} {
-		_, offset, ok := iptvOnAir(c, iptv.Epoch.Add(delta))
+		_, offset, ok := iptvOnAir(iptv.NewGrid(c, nil, time.UTC), iptv.Epoch.Add(delta))
 		if !ok {
 			t.Fatalf("nothing on air at %v", delta)
 		}
@@ -278,11 +278,38 @@ func TestOnAirNeverReturnsNegativeOffset(t *testing.T) {
 }
 
 func TestOnAirFailsOnEmptyCycle(t *testing.T) {
-	if _, _, ok := iptvOnAir(testCycle(1, 1), time.Now()); ok {
+	if _, _, ok := iptvOnAir(iptv.NewGrid(testCycle(1, 1), nil, time.UTC), time.Now()); ok {
 		t.Error("a cycle with no schedulable programmes should report nothing on air")
 	}
 }
 
+// After a programming block the channel rejoins its own rotation part way
+// through a programme, and the stream must start that far into the file rather
+// than at the point the programme was cut in.
+func TestOnAirRejoinsProgrammeInProgressAfterBlock(t *testing.T) {
+	block := testCycle(600)
+	grid := iptv.NewGrid(testCycle(420, 420), []iptv.Block{{
+		Start: 10 * time.Hour,
+		End:   11 * time.Hour,
+		Cycle: block,
+	}}, time.UTC)
+
+	end := time.Date(2024, time.February, 1, 11, 0, 0, 0, time.UTC)
+	now := end.Add(5 * time.Second)
+	a, offset, ok := iptvOnAir(grid, now)
+	if !ok {
+		t.Fatal("nothing on air")
+	}
+
+	// both programmes are 210 segments, so they start on multiples of it
+	abs := iptv.AbsSegment(now)
+	programStart := iptv.SegmentTime(abs - abs%210)
+	want := now.Sub(programStart).Seconds()
+	if offset < want-0.01 || offset > want+0.01 {
+		t.Errorf("offset = %v, want %v (start of the airing at %v)", offset, want, a.Start)
+	}
+}
+
 // ─── formatting helpers ───────────────────────────────────────────────────────
 
 // M3U attributes are double-quoted with no escape mechanism, so a quote or a
@@ -1,7 +1,6 @@
 package api
 
 import (
-	"context"
 	"fmt"
 	"io"
 	"net"
@@ -15,6 +14,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 
+	"github.com/stashapp/stash/internal/manager"
 	"github.com/stashapp/stash/internal/manager/config"
 	"github.com/stashapp/stash/pkg/logger"
 )
@@ -43,7 +43,10 @@ const (
 	iptvXtreamPlayerAPIPath    = "/player_api.php"
 	iptvXtreamSeriesPathPrefix = "/series/"
 	iptvXtreamLivePathPrefix   = "/live/"
-	iptvXtreamXMLTVPath        = "/xmltv.php"
+	// /timeshift/{username}/{password}/{minutes}/{start}/{streamId} — catch-up,
+	// see routes_iptv_catchup.go.
+	iptvXtreamTimeshiftPathPrefix = "/timeshift/"
+	iptvXtreamXMLTVPath           = "/xmltv.php"
 
 	// adultTimeSeriesCategoryID is the one category every movie/series lives
 	// in. There's nothing meaningful to group movies by beyond "Adult Time"
@@ -83,10 +86,13 @@ const (
 // 401, while the identical request with no password/apikey at all answered
 // 200. When a real key *is* configured, whatever the client sent is bridged
 // through unconditionally and stands or falls on its own — this only ever
-// widens access on a server that already has none.
+// widens access on a server that already has none. With multiple users the
+// password may also be a per-user or named API key; a revocable key with only
+// the IPTV or stream scope is the one to hand to an Xtream client, since the
+// credential ends up in every URL it builds.
 func iptvXtreamAuthBridge(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if cred := iptvXtreamCredential(r); cred != "" && config.GetInstance().GetAPIKey() != "" {
+		if cred := iptvXtreamCredential(r); cred != "" && xtreamCredentialsRequired() {
 			q := r.URL.Query()
 			if q.Get("apikey") == "" {
 				q.Set("apikey", cred)
@@ -97,14 +103,20 @@ func iptvXtreamAuthBridge(next http.Handler) http.Handler {
 	})
 }
 
+// xtreamCredentialsRequired returns true if the server authenticates API
+// keys, through either the configured key or its users.
+func xtreamCredentialsRequired() bool {
+	return config.GetInstance().GetAPIKey() != "" || manager.GetInstance().SessionStore.IsMultiUserEnabled()
+}
+
 // iptvXtreamCredential extracts the Xtream "password" field from whichever of
 // three request shapes this is: a query param (player_api.php, xmltv.php) or
-// a path segment (/series/..., /live/...).
+// a path segment (/series/..., /live/..., /timeshift/...).
 func iptvXtreamCredential(r *http.Request) string {
 	if r.URL.Path == iptvXtreamPlayerAPIPath || r.URL.Path == iptvXtreamXMLTVPath {
 		return r.URL.Query().Get("password")
 	}
-	for _, prefix := range [...]string{iptvXtreamSeriesPathPrefix, iptvXtreamLivePathPrefix} {
+	for _, prefix := range [...]string{iptvXtreamSeriesPathPrefix, iptvXtreamLivePathPrefix, iptvXtreamTimeshiftPathPrefix} {
 		if rest, ok := strings.CutPrefix(r.URL.Path, prefix); ok {
 			parts := strings.SplitN(rest, "/", 3)
 			if len(parts) >= 2 {
@@ -141,6 +153,7 @@ func (rs iptvXtreamRoutes) Register(r chi.Router) {
 	// LiveChannelStream for why live needs its own handler rather than
 	// reusing ChannelStream directly).
 	r.Get(iptvXtreamLivePathPrefix+"{username}/{password}/{streamId}", rs.LiveChannelStream)
+	r.Get(iptvXtreamTimeshiftPathPrefix+"{username}/{password}/{duration}/{start}/{streamId}", rs.TimeshiftChannelStream)
 	// The standard Xtream EPG endpoint — TiviMate requests this
 	// unconditionally for any Xtream playlist. Answered with the exact same
 	// guide /iptv/xmltv.xml already generates rather than a stub, so live
@@ -281,6 +294,7 @@ type xtreamLiveStream struct {
 	EPGChannelID       string `json:"epg_channel_id"`
 	CategoryID         string `json:"category_id"`
 	TvArchive          int    `json:"tv_archive"`
+	TvArchiveDuration  int    `json:"tv_archive_duration"`
 	ContainerExtension string `json:"container_extension"`
 	DirectSource       string `json:"direct_source"`
 }
@@ -299,6 +313,13 @@ func (rs iptvXtreamRoutes) liveStreams(w http.ResponseWriter, r *http.Request) {
 	username := r.URL.Query().Get("username")
 	password := r.URL.Query().Get("password")
 
+	// Every channel can be replayed over the same window; see
+	// routes_iptv_catchup.go.
+	archive := 0
+	if s.ArchiveDays > 0 {
+		archive = 1
+	}
+
 	out := make([]xtreamLiveStream, 0, len(channels))
 	for _, ch := range channels {
 		name := iptvGroupTitle(ch, s)
@@ -321,6 +342,8 @@ func (rs iptvXtreamRoutes) liveStreams(w http.ResponseWriter, r *http.Request) {
 			StreamIcon:         iptvURL(base, fmt.Sprintf("/iptv/logo/%s.png", ch.Key), ""),
 			EPGChannelID:       ch.TvgID,
 			CategoryID:         cid,
+			TvArchive:          archive,
+			TvArchiveDuration:  s.ArchiveDays,
 			ContainerExtension: "ts",
 			DirectSource:       iptvXtreamLiveURL(base, username, password, ch.Key),
 		})
@@ -517,10 +540,8 @@ func (rs iptvXtreamRoutes) seriesInfo(w http.ResponseWriter, r *http.Request) {
 // LiveChannelStream resolves a synthetic numeric stream id (see liveStreams)
 // back to the channel's real key and hands off to the exact same handler
 // /iptv/ch/{channelId}.ts uses — not a reimplementation, a redirect at the Go
-// level. ChannelStream reads the channel key via chi.URLParam(r, "channelId"),
-// so this constructs a fresh chi route context carrying that param instead of
-// duplicating everything after key resolution (schedule lookup, ffmpeg pipe,
-// failure backoff — all of it).
+// level (see streamChannel), instead of duplicating everything after key
+// resolution (schedule lookup, ffmpeg pipe, failure backoff — all of it).
 //
 // This exists as a separate handler (rather than registering ChannelStream
 // directly, which the M3U-facing /iptv/ch/ route can do because it always
@@ -529,12 +550,44 @@ func (rs iptvXtreamRoutes) seriesInfo(w http.ResponseWriter, r *http.Request) {
 // number — so a synthetic id has to exist somewhere, and this is where it's
 // reversed.
 func (rs iptvXtreamRoutes) LiveChannelStream(w http.ResponseWriter, r *http.Request) {
+	if key, ok := rs.liveChannelKey(w, r); ok {
+		rs.iptv.streamChannel(w, r, key)
+	}
+}
+
+// TimeshiftChannelStream replays a channel from a point in the past. The
+// duration is in minutes and the start in iptvXtreamTimeshiftFormat; both
+// are handed to ChannelStream as the start/duration parameters it takes from
+// M3U players, so there is one catch-up implementation, not two.
+func (rs iptvXtreamRoutes) TimeshiftChannelStream(w http.ResponseWriter, r *http.Request) {
+	minutes, err := strconv.Atoi(chi.URLParam(r, "duration"))
+	if err != nil || minutes < 0 {
+		http.Error(w, "invalid duration", http.StatusBadRequest)
+		return
+	}
+
+	key, ok := rs.liveChannelKey(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	q.Set("start", chi.URLParam(r, "start"))
+	q.Set("duration", strconv.Itoa(minutes*60))
+	r.URL.RawQuery = q.Encode()
+
+	rs.iptv.streamChannel(w, r, key)
+}
+
+// liveChannelKey reverses the streamId path parameter to a channel key,
+// writing the error response if it cannot.
+func (rs iptvXtreamRoutes) liveChannelKey(w http.ResponseWriter, r *http.Request) (string, bool) {
 	raw := chi.URLParam(r, "streamId")
 	raw = strings.TrimSuffix(raw, path.Ext(raw))
 	numID, err := strconv.Atoi(raw)
 	if err != nil {
 		http.Error(w, "invalid stream id", http.StatusBadRequest)
-		return
+		return "", false
 	}
 
 	s := rs.iptv.settings()
@@ -542,26 +595,16 @@ func (rs iptvXtreamRoutes) LiveChannelStream(w http.ResponseWriter, r *http.Requ
 	if err != nil {
 		logger.Errorf("[iptv] xtream: building channel list: %v", err)
 		http.Error(w, "error building channel list", http.StatusInternalServerError)
-		return
+		return "", false
 	}
 
-	var key string
 	for _, ch := range channels {
 		if iptvNetChannelSeed(ch.Key) == numID {
-			key = ch.Key
-			break
+			return ch.Key, true
 		}
 	}
-	if key == "" {
-		http.Error(w, "unknown channel", http.StatusNotFound)
-		return
-	}
-
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("channelId", key)
-	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
-
-	rs.iptv.ChannelStream(w, r)
+	http.Error(w, "unknown channel", http.StatusNotFound)
+	return "", false
 }
 
 // ─── stream proxy ───────────────────────────────────────────────────────────
@@ -50,15 +50,20 @@ type SceneFunscriptFinder interface {
 	GetSceneFunscripts(ctx context.Context, sceneID int) ([]*models.SceneFunscript, error)
 }
 
+type OptimizedVersionFinder interface {
+	Find(ctx context.Context, id int) (*models.OptimizedVersion, error)
+}
+
 type sceneRoutes struct {
 	routes
-	sceneFinder          SceneFinder
-	fileGetter           models.FileGetter
-	captionFinder        CaptionFinder
-	sceneCaptionFinder   SceneCaptionFinder
-	sceneFunscriptFinder SceneFunscriptFinder
-	sceneMarkerFinder    SceneMarkerFinder
-	tagFinder            SceneMarkerTagFinder
+	sceneFinder            SceneFinder
+	fileGetter             models.FileGetter
+	captionFinder          CaptionFinder
+	sceneCaptionFinder     SceneCaptionFinder
+	sceneFunscriptFinder   SceneFunscriptFinder
+	sceneMarkerFinder      SceneMarkerFinder
+	tagFinder              SceneMarkerTagFinder
+	optimizedVersionFinder OptimizedVersionFinder
 }
 
 func (rs sceneRoutes) Routes() chi.Router {
@@ -69,6 +74,7 @@ func (rs sceneRoutes) Routes() chi.Router {
 
 		// streaming endpoints
 		r.Get("/stream", rs.StreamDirect)
+		r.Get("/stream/optimized/{versionId:[0-9]+}", rs.StreamOptimized)
 		r.Get("/stream.mp4", rs.StreamMp4)
 		r.Get("/stream.webm", rs.StreamWebM)
 		r.Get("/stream.mkv", rs.StreamMKV)
@@ -77,6 +83,11 @@ func (rs sceneRoutes) Routes() chi.Router {
 		r.Get("/stream.mpd", rs.StreamDASH)
 		r.Get("/stream.mpd/{segment}_v.webm", rs.StreamDASHVideoSegment)
 		r.Get("/stream.mpd/{segment}_a.webm", rs.StreamDASHAudioSegment)
+		r.Get("/stream_adaptive.m3u8", rs.StreamHLSAdaptive)
+		r.Get("/stream_adaptive.mpd", rs.StreamDASHAdaptive)
+		r.Get("/trickplay.m3u8", rs.TrickPlayIFramePlaylist)
+		r.Get("/trickplay.mp4", rs.TrickPlayIFrames)
+		r.Get("/trickplay.bif", rs.TrickPlayBIF)
 
 		r.Get("/screenshot", rs.Screenshot)
 		r.Get("/screenshot.jpg", rs.Screenshot)
@@ -139,6 +150,38 @@ func (rs sceneRoutes) StreamDirect(w http.ResponseWriter, r *http.Request) {
 	ss.StreamSceneDirect(scene, w, r)
 }
 
+func (rs sceneRoutes) StreamOptimized(w http.ResponseWriter, r *http.Request) {
+	scene := r.Context().Value(sceneKey).(*models.Scene)
+
+	versionID, err := strconv.Atoi(chi.URLParam(r, "versionId"))
+	if err != nil {
+		http.Error(w, "bad version id", http.StatusBadRequest)
+		return
+	}
+
+	var version *models.OptimizedVersion
+	readTxnErr := rs.withReadTxn(r, func(ctx context.Context) error {
+		version, err = rs.optimizedVersionFinder.Find(ctx, versionID)
+		return err
+	})
+	if errors.Is(readTxnErr, context.Canceled) {
+		return
+	}
+	if readTxnErr != nil {
+		logger.Warnf("read transaction error on fetch optimized version: %v", readTxnErr)
+		http.Error(w, readTxnErr.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if version == nil || version.SceneID != scene.ID {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", ffmpeg.MimeMp4Video)
+	utils.ServeStaticFile(w, r, manager.GetInstance().Paths.Scene.GetOptimizedVersionPath(version.Basename))
+}
+
 func (rs sceneRoutes) StreamMp4(w http.ResponseWriter, r *http.Request) {
 	rs.streamTranscode(w, r, ffmpeg.StreamTypeMP4)
 }
@@ -233,8 +276,44 @@ func (rs sceneRoutes) streamManifest(w http.ResponseWriter, r *http.Request, str
 
 	resolution := r.Form.Get("resolution")
 
+	var options ffmpeg.ManifestOptions
+	if streamType == ffmpeg.StreamTypeHLS {
+		options.Chapters = rs.hlsChapters(r, scene)
+	}
+
 	logger.Debugf("[transcode] returning %s manifest for scene %d", logName, scene.ID)
-	streamManager.ServeManifest(w, r, streamType, f, resolution)
+	streamManager.ServeManifest(w, r, streamType, f, resolution, options)
+}
+
+func (rs sceneRoutes) StreamHLSAdaptive(w http.ResponseWriter, r *http.Request) {
+	rs.streamAdaptiveManifest(w, r, ffmpeg.StreamTypeHLS, "HLS")
+}
+
+func (rs sceneRoutes) StreamDASHAdaptive(w http.ResponseWriter, r *http.Request) {
+	rs.streamAdaptiveManifest(w, r, ffmpeg.StreamTypeDASHVideo, "DASH")
+}
+
+func (rs sceneRoutes) streamAdaptiveManifest(w http.ResponseWriter, r *http.Request, streamType *ffmpeg.StreamType, logName string) {
+	scene := r.Context().Value(sceneKey).(*models.Scene)
+
+	streamManager := manager.GetInstance().StreamManager
+	if streamManager == nil {
+		http.Error(w, "Live transcoding disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	f := scene.Files.Primary()
+	if f == nil {
+		return
+	}
+
+	var options ffmpeg.ManifestOptions
+	if streamType == ffmpeg.StreamTypeHLS {
+		options.IFrames = rs.iframeStream(scene, f)
+	}
+
+	logger.Debugf("[transcode] returning adaptive %s manifest for scene %d", logName, scene.ID)
+	streamManager.ServeAdaptiveManifest(w, r, streamType, f, options)
 }
 
 func (rs sceneRoutes) StreamHLSSegment(w http.ResponseWriter, r *http.Request) {
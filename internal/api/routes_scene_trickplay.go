@@ -0,0 +1,174 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+
+	"github.com/stashapp/stash/internal/manager"
+	"github.com/stashapp/stash/internal/manager/config"
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scene/generate"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+const (
+	trickPlayPlaylistURI = "trickplay.m3u8"
+	trickPlayIFramesURI  = "trickplay.mp4"
+)
+
+func trickPlaySceneHash(scene *models.Scene) string {
+	return scene.GetHash(config.GetInstance().GetVideoFileNamingAlgorithm())
+}
+
+// hlsChapters returns the markers of the scene as chapters of its HLS
+// playlists.
+func (rs sceneRoutes) hlsChapters(r *http.Request, scene *models.Scene) []ffmpeg.Chapter {
+	var sceneMarkers []*models.SceneMarker
+	if err := rs.withReadTxn(r, func(ctx context.Context) error {
+		var err error
+		sceneMarkers, err = rs.sceneMarkerFinder.FindBySceneID(ctx, scene.ID)
+		return err
+	}); err != nil {
+		if !errors.Is(err, context.Canceled) {
+			logger.Warnf("[transcode] error getting chapters of scene %d: %v", scene.ID, err)
+		}
+		return nil
+	}
+
+	ret := make([]ffmpeg.Chapter, 0, len(sceneMarkers))
+	for _, marker := range sceneMarkers {
+		c := ffmpeg.Chapter{
+			ID:    "marker-" + strconv.Itoa(marker.ID),
+			Start: marker.Seconds,
+		}
+		if marker.EndSeconds != nil {
+			c.End = *marker.EndSeconds
+		}
+
+		title, err := rs.getChapterVttTitle(r, marker)
+		if err != nil {
+			logger.Warnf("[transcode] error getting title of marker %d: %v", marker.ID, err)
+		} else {
+			c.Title = *title
+		}
+
+		ret = append(ret, c)
+	}
+
+	return ret
+}
+
+// iframeStream returns the I-frame playlist of the scene for its master
+// playlist, or nil if its trick play files have not been generated.
+func (rs sceneRoutes) iframeStream(scene *models.Scene, f *models.VideoFile) *ffmpeg.IFrameStream {
+	paths := manager.GetInstance().Paths.Scene
+	hash := trickPlaySceneHash(scene)
+
+	info, err := os.Stat(paths.GetTrickPlayIFramePath(hash))
+	if err != nil {
+		return nil
+	}
+	frames, err := generate.ReadSpriteVTT(paths.GetSpriteVttFilePath(hash))
+	if err != nil || len(frames) == 0 {
+		return nil
+	}
+
+	ret := &ffmpeg.IFrameStream{
+		URI: trickPlayPlaylistURI,
+		// the frames are encoded with even dimensions
+		Width:     frames[0].Rect.Dx() / 2 * 2,
+		Height:    frames[0].Rect.Dy() / 2 * 2,
+		Bandwidth: 1,
+	}
+	if f.Duration > 0 {
+		ret.Bandwidth = max(1, int(float64(info.Size()*8)/f.Duration))
+	}
+
+	return ret
+}
+
+// TrickPlayIFramePlaylist serves an HLS I-frame playlist of the scene's
+// sprite frames, with its markers as chapters.
+func (rs sceneRoutes) TrickPlayIFramePlaylist(w http.ResponseWriter, r *http.Request) {
+	scene := r.Context().Value(sceneKey).(*models.Scene)
+	paths := manager.GetInstance().Paths.Scene
+	hash := trickPlaySceneHash(scene)
+
+	file, err := os.Open(paths.GetTrickPlayIFramePath(hash))
+	if err != nil {
+		http.Error(w, "trick play files have not been generated", http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+
+	init, fragments, err := ffmpeg.ReadFragments(file)
+	if err != nil {
+		logger.Warnf("[transcode] error reading I-frames of scene %d: %v", scene.ID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	spriteFrames, err := generate.ReadSpriteVTT(paths.GetSpriteVttFilePath(hash))
+	if err != nil {
+		logger.Warnf("[transcode] error reading sprite frames of scene %d: %v", scene.ID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// the I-frames are encoded from the sprite frames, so they only differ
+	// if the sprite was regenerated without them
+	if len(spriteFrames) != len(fragments) {
+		http.Error(w, "trick play files are out of date", http.StatusNotFound)
+		return
+	}
+
+	frames := make([]ffmpeg.IFrame, len(fragments))
+	for i, fr := range spriteFrames {
+		frames[i] = ffmpeg.IFrame{
+			Duration: fr.End - fr.Start,
+			Range:    fragments[i],
+		}
+	}
+	// the first frame stands for everything before the second
+	frames[0].Duration = spriteFrames[0].End
+
+	uri := trickPlayIFramesURI
+	if apikey := r.URL.Query().Get("apikey"); apikey != "" {
+		uri += "?" + url.Values{"apikey": {apikey}}.Encode()
+	}
+
+	var buf bytes.Buffer
+	if err := ffmpeg.WriteIFramePlaylist(&buf, uri, init, frames, rs.hlsChapters(r, scene)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ffmpeg.MimeHLS)
+	utils.ServeStaticContent(w, r, buf.Bytes())
+}
+
+// TrickPlayIFrames serves the fragmented MP4 that the I-frame playlist
+// addresses by byte range.
+func (rs sceneRoutes) TrickPlayIFrames(w http.ResponseWriter, r *http.Request) {
+	scene := r.Context().Value(sceneKey).(*models.Scene)
+	filepath := manager.GetInstance().Paths.Scene.GetTrickPlayIFramePath(trickPlaySceneHash(scene))
+
+	w.Header().Set("Content-Type", "video/mp4")
+	utils.ServeStaticFile(w, r, filepath)
+}
+
+// TrickPlayBIF serves the BIF archive of the scene's sprite frames.
+func (rs sceneRoutes) TrickPlayBIF(w http.ResponseWriter, r *http.Request) {
+	scene := r.Context().Value(sceneKey).(*models.Scene)
+	filepath := manager.GetInstance().Paths.Scene.GetTrickPlayBIFPath(trickPlaySceneHash(scene))
+
+	w.Header().Set("Content-Type", generate.MimeBIF)
+	utils.ServeStaticFile(w, r, filepath)
+}
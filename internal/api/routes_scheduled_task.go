@@ -2,7 +2,9 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -22,6 +24,7 @@ func (rs scheduledTaskRoutes) Routes() chi.Router {
 	r.Put("/{taskId}", rs.UpdateTask)
 	r.Delete("/{taskId}", rs.DeleteTask)
 	r.Post("/{taskId}/run", rs.RunTask)
+	r.Get("/{taskId}/runs", rs.ListRuns)
 
 	return r
 }
@@ -72,6 +75,11 @@ type CreateTaskInput struct {
 	TaskType     scheduler.ScheduledTaskType `json:"taskType"`
 	Enabled      *bool                       `json:"enabled"`
 	Options      json.RawMessage             `json:"options,omitempty"`
+
+	Then          []string `json:"then,omitempty"`
+	Retries       int      `json:"retries,omitempty"`
+	RetryDelay    int      `json:"retryDelay,omitempty"`
+	SkipIfRunning bool     `json:"skipIfRunning,omitempty"`
 }
 
 // CreateTask creates a new scheduled task
@@ -92,10 +100,6 @@ func (rs scheduledTaskRoutes) CreateTask(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "name is required", http.StatusBadRequest)
 		return
 	}
-	if input.CronSchedule == "" {
-		http.Error(w, "cronSchedule is required", http.StatusBadRequest)
-		return
-	}
 	if input.TaskType == "" {
 		http.Error(w, "taskType is required", http.StatusBadRequest)
 		return
@@ -108,6 +112,11 @@ func (rs scheduledTaskRoutes) CreateTask(w http.ResponseWriter, r *http.Request)
 		TaskType:     input.TaskType,
 		Enabled:      true,
 		Options:      input.Options,
+
+		Then:          input.Then,
+		Retries:       input.Retries,
+		RetryDelay:    input.RetryDelay,
+		SkipIfRunning: input.SkipIfRunning,
 	}
 
 	if input.Enabled != nil {
@@ -133,6 +142,11 @@ type UpdateTaskInput struct {
 	TaskType     *scheduler.ScheduledTaskType `json:"taskType"`
 	Enabled      *bool                        `json:"enabled"`
 	Options      json.RawMessage              `json:"options,omitempty"`
+
+	Then          []string `json:"then"`
+	Retries       *int     `json:"retries"`
+	RetryDelay    *int     `json:"retryDelay"`
+	SkipIfRunning *bool    `json:"skipIfRunning"`
 }
 
 // UpdateTask updates an existing scheduled task
@@ -173,6 +187,18 @@ func (rs scheduledTaskRoutes) UpdateTask(w http.ResponseWriter, r *http.Request)
 	if input.Options != nil {
 		task.Options = input.Options
 	}
+	if input.Then != nil {
+		task.Then = input.Then
+	}
+	if input.Retries != nil {
+		task.Retries = *input.Retries
+	}
+	if input.RetryDelay != nil {
+		task.RetryDelay = *input.RetryDelay
+	}
+	if input.SkipIfRunning != nil {
+		task.SkipIfRunning = *input.SkipIfRunning
+	}
 
 	if err := sched.UpdateTask(task); err != nil {
 		http.Error(w, "failed to update task: "+err.Error(), http.StatusBadRequest)
@@ -223,6 +249,10 @@ func (rs scheduledTaskRoutes) RunTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	jobID, err := sched.RunTask(taskID)
+	if errors.Is(err, scheduler.ErrTaskRunning) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
 		http.Error(w, "failed to run task: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -233,3 +263,35 @@ func (rs scheduledTaskRoutes) RunTask(w http.ResponseWriter, r *http.Request) {
 		logger.Errorf("Error encoding run task response: %v", err)
 	}
 }
+
+// ListRuns returns the most recent runs of a scheduled task, newest first.
+// The limit query parameter defaults to 50.
+func (rs scheduledTaskRoutes) ListRuns(w http.ResponseWriter, r *http.Request) {
+	sched := manager.GetInstance().Scheduler
+	if sched == nil {
+		http.Error(w, "scheduler not initialized", http.StatusServiceUnavailable)
+		return
+	}
+
+	limit := defaultScheduledTaskRunsLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	runs, err := sched.ListRuns(r.Context(), chi.URLParam(r, "taskId"), limit)
+	if err != nil {
+		logger.Errorf("Error listing scheduled task runs: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(runs); err != nil {
+		logger.Errorf("Error encoding scheduled task runs: %v", err)
+	}
+}
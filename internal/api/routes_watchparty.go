@@ -0,0 +1,331 @@
+package api
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stashapp/stash/pkg/handy"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/session"
+	"github.com/stashapp/stash/pkg/txn"
+	"github.com/stashapp/stash/pkg/watchparty"
+)
+
+const (
+	// watchPartyClockBurst clock messages are sent watchPartyClockBurstInterval
+	// apart after joining, so that a member can follow the room accurately
+	// straight away. After that the estimate is refreshed every
+	// watchPartyClockInterval.
+	watchPartyClockBurst         = 4
+	watchPartyClockBurstInterval = 250 * time.Millisecond
+	watchPartyClockInterval      = 15 * time.Second
+)
+
+// watchPartyRoutes serves the WebSocket of watch-party rooms, whose members
+// watch the same scene in sync. Members send the same kind of small JSON ops
+// as the Handy bridge (see routes_handy.go); the server keeps the room's
+// timeline and sends every member each change, with the offset of the
+// member's clock so that it can project the position itself.
+//
+// Connect with /watch-party/ws?room=<id>&name=<name>. Without a room, a new
+// one is created and its ID sent in the joined message.
+type watchPartyRoutes struct {
+	// handy resolves the funscripts the local Handy plays when it follows
+	// a room
+	handy handyRoutes
+}
+
+func (rs watchPartyRoutes) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/ws", rs.WebSocket)
+	return r
+}
+
+// watchPartyOp is a client → server operation.
+type watchPartyOp struct {
+	Op       string  `json:"op"`
+	Seq      int64   `json:"seq"`
+	SceneID  int     `json:"sceneId,omitempty"`
+	Position int64   `json:"position,omitempty"` // ms
+	Rate     float64 `json:"rate,omitempty"`
+	// At is the client's time in unix ms that Position was sampled at. The
+	// time the op arrives is used if it is not set.
+	At      int64 `json:"at,omitempty"`
+	Enabled bool  `json:"enabled,omitempty"`
+	// ServerTime and ClientTime answer a clock message
+	ServerTime int64 `json:"serverTime,omitempty"`
+	ClientTime int64 `json:"clientTime,omitempty"`
+}
+
+type watchPartyJoinedMsg struct {
+	Type     string `json:"type"` // "joined"
+	Room     string `json:"room"`
+	MemberID string `json:"memberId"`
+}
+
+// watchPartyStateMsg is a snapshot of the room. State.At is server time;
+// adding Offset converts it to the client's clock.
+type watchPartyStateMsg struct {
+	Type string `json:"type"` // "state"
+	watchparty.Update
+	// Offset is how far the client's clock is ahead of the server's in ms
+	Offset int64 `json:"offset"`
+	// RTT is the round trip time of the offset estimate. Synced is false
+	// until the client has answered a clock message.
+	RTT    int64 `json:"rtt"`
+	Synced bool  `json:"synced"`
+}
+
+type watchPartyClockMsg struct {
+	Type       string `json:"type"` // "clock"
+	ServerTime int64  `json:"serverTime"`
+}
+
+func (rs watchPartyRoutes) WebSocket(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		if username := session.GetCurrentUserID(ctx); username != nil {
+			name = *username
+		}
+	}
+
+	// only members that may control the Handy can make it follow the room,
+	// or drive it once it does
+	mgr := watchparty.GetManager()
+	room, member, err := mgr.Join(r.URL.Query().Get("room"), name, HasPermission(ctx, models.PermissionControlHandy))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// leaving may stop the Handy, which must not be skipped because the
+	// request is over
+	defer mgr.Leave(context.WithoutCancel(r.Context()), room, member)
+
+	conn, err := handyUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Warnf("[watch-party] websocket upgrade failed: %v", err)
+		return
+	}
+
+	// written before the writer starts, so that it comes before any state
+	if err := conn.WriteJSON(watchPartyJoinedMsg{Type: "joined", Room: room.ID, MemberID: member.ID}); err != nil {
+		conn.Close()
+		return
+	}
+
+	var clock watchparty.Clock
+	outbound := make(chan interface{}, 32)
+	ops := make(chan watchPartyOp, 32)
+	done := make(chan struct{})
+
+	stateMsg := func(u watchparty.Update) watchPartyStateMsg {
+		offset, rtt, synced := clock.Offset()
+		return watchPartyStateMsg{Type: "state", Update: u, Offset: offset, RTT: rtt, Synced: synced}
+	}
+
+	// single writer goroutine (gorilla allows one concurrent writer)
+	go func() {
+		clockTimer := time.NewTimer(0)
+		defer clockTimer.Stop()
+		clocks := 0
+
+		for {
+			select {
+			case msg := <-outbound:
+				if err := conn.WriteJSON(msg); err != nil {
+					return
+				}
+			case u := <-member.Updates():
+				if err := conn.WriteJSON(stateMsg(u)); err != nil {
+					return
+				}
+			case <-clockTimer.C:
+				if err := conn.WriteJSON(watchPartyClockMsg{Type: "clock", ServerTime: time.Now().UnixMilli()}); err != nil {
+					return
+				}
+				clocks++
+				if clocks < watchPartyClockBurst {
+					clockTimer.Reset(watchPartyClockBurstInterval)
+				} else {
+					clockTimer.Reset(watchPartyClockInterval)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	// Ops run one at a time and in order, since a seek and the play after it
+	// must not swap. Following a room can wait on the Handy, so they run off
+	// the read loop to keep clock answers timely.
+	go func() {
+		for {
+			select {
+			case op := <-ops:
+				rs.handleOp(r.Context(), room, member, &clock, op, outbound, done)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	defer close(done)
+	defer conn.Close()
+
+	for {
+		var op watchPartyOp
+		if err := conn.ReadJSON(&op); err != nil {
+			return
+		}
+
+		if op.Op == "clock" {
+			clock.Add(time.UnixMilli(op.ServerTime), time.Now(), op.ClientTime)
+			continue
+		}
+
+		select {
+		case ops <- op:
+		case <-done:
+			return
+		}
+	}
+}
+
+func (rs watchPartyRoutes) handleOp(ctx context.Context, room *watchparty.Room, member *watchparty.Member, clock *watchparty.Clock, op watchPartyOp, outbound chan<- interface{}, done <-chan struct{}) {
+	reply := func(msg handyReply) {
+		select {
+		case outbound <- msg:
+		case <-done:
+		}
+	}
+
+	// outside the middleware chain's recover, as with the Handy ops
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("[watch-party] panic in op %q: %v\n%s", op.Op, r, debug.Stack())
+			reply(handyReply{Type: "error", Seq: op.Seq, Message: "internal error handling op"})
+		}
+	}()
+
+	if err := rs.execute(ctx, room, member, clock, op); err != nil {
+		logger.Debugf("[watch-party] room %s: op %q failed: %v", room.ID, op.Op, err)
+		reply(handyReply{Type: "error", Seq: op.Seq, Message: err.Error()})
+		return
+	}
+	reply(handyReply{Type: "ack", Seq: op.Seq})
+}
+
+func (rs watchPartyRoutes) execute(ctx context.Context, room *watchparty.Room, member *watchparty.Member, clock *watchparty.Clock, op watchPartyOp) error {
+	// the time the position was sampled, on the server's clock
+	at := time.Now()
+	if op.At != 0 {
+		if t := clock.ToServer(op.At); t.Before(at) {
+			at = t
+		}
+	}
+
+	var err error
+	switch op.Op {
+	case "load":
+		if err := rs.checkScene(ctx, op.SceneID); err != nil {
+			return err
+		}
+		_, err = room.Load(ctx, member, op.SceneID, at)
+	case "play":
+		_, err = room.Play(ctx, member, op.Position, at)
+	case "pause":
+		_, err = room.Pause(ctx, member, op.Position, at)
+	case "seek":
+		_, err = room.Seek(ctx, member, op.Position, at)
+	case "rate":
+		_, err = room.SetRate(ctx, member, op.Rate, at)
+	case "handy":
+		mgr := watchparty.GetManager()
+		if !op.Enabled {
+			mgr.Unfollow(ctx, room, member)
+			return nil
+		}
+		err = mgr.Follow(ctx, room, member, &watchPartyHandy{routes: rs.handy})
+	case "status":
+		return nil // ack triggers nothing; state flows via the room
+	default:
+		err = errUnknownHandyOp(op.Op)
+	}
+	return err
+}
+
+// checkScene returns an error if the scene does not exist or is hidden from
+// the member by their content restriction.
+func (rs watchPartyRoutes) checkScene(ctx context.Context, sceneID int) error {
+	return txn.WithReadTxn(ctx, rs.handy.txnManager, func(ctx context.Context) error {
+		scene, err := rs.handy.sceneFinder.Find(ctx, sceneID)
+		if err != nil {
+			return err
+		}
+		if scene == nil {
+			return &notFoundError{"scene " + strconv.Itoa(sceneID)}
+		}
+		return nil
+	})
+}
+
+// watchPartyHandy makes the local Handy follow a room's timeline.
+type watchPartyHandy struct {
+	routes handyRoutes
+	// loaded is the scene whose funscript the Handy has
+	loaded int
+}
+
+func (h *watchPartyHandy) Follow(ctx context.Context, prev watchparty.State, next watchparty.State) error {
+	eng := handy.GetManager().Engine()
+
+	if next.SceneID == 0 {
+		h.loaded = 0
+		if prev.Playing {
+			return eng.Stop(ctx)
+		}
+		return nil
+	}
+
+	if next.SceneID != h.loaded {
+		points, err := h.routes.loadScenePoints(ctx, next.SceneID, nil)
+		if err != nil {
+			h.loaded = 0
+			return err
+		}
+		if err := eng.LoadScript(ctx, points); err != nil {
+			h.loaded = 0
+			return err
+		}
+		h.loaded = next.SceneID
+		// the new script has to be started even if the room was playing
+		prev.Playing = false
+	}
+
+	if !next.Playing {
+		if prev.Playing {
+			return eng.Pause(ctx)
+		}
+		return nil
+	}
+
+	// a rate change rebases the timeline without moving it, so the
+	// device only needs the new rate
+	if prev.Playing && prev.Rate != next.Rate {
+		return eng.SetRate(ctx, float32(next.Rate))
+	}
+
+	pos := next.PositionAt(time.Now())
+	if pos > math.MaxInt32 {
+		pos = math.MaxInt32
+	}
+	return eng.Play(ctx, int32(pos), float32(next.Rate), false)
+}
@@ -44,6 +44,7 @@ import (
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/megaface"
 	"github.com/stashapp/stash/pkg/metrics"
+	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/plugin"
 	"github.com/stashapp/stash/pkg/stashface"
 	"github.com/stashapp/stash/pkg/stashtag"
@@ -53,11 +54,13 @@ import (
 )
 
 const (
-	loginEndpoint       = "/login"
-	loginLocaleEndpoint = loginEndpoint + "/locale"
-	logoutEndpoint      = "/logout"
-	gqlEndpoint         = "/graphql"
-	playgroundEndpoint  = "/playground"
+	loginEndpoint        = "/login"
+	loginLocaleEndpoint  = loginEndpoint + "/locale"
+	oidcLoginEndpoint    = loginEndpoint + "/oidc"
+	oidcCallbackEndpoint = oidcLoginEndpoint + "/callback"
+	logoutEndpoint       = "/logout"
+	gqlEndpoint          = "/graphql"
+	playgroundEndpoint   = "/playground"
 )
 
 type Server struct {
@@ -163,7 +166,10 @@ func Initialize() (*Server, error) {
 	// param, only username/password, so this bridges their password into the
 	// apikey param the auth handler already knows how to check.
 	r.Use(iptvXtreamAuthBridge)
+	// Likewise for HDHomeRun clients, which carry the key in the path.
+	r.Use(iptvHDHRAuthBridge)
 	r.Use(authenticateHandler())
+	r.Use(auditHandler(mgr.Repository))
 	visitedPluginHandler := mgr.SessionStore.VisitedPluginHandler()
 	r.Use(visitedPluginHandler)
 
@@ -202,6 +208,13 @@ func Initialize() (*Server, error) {
 	imageService := mgr.ImageService
 	galleryService := mgr.GalleryService
 	groupService := mgr.GroupService
+
+	// Constructed once and shared with the resolver and the Xtream routes below
+	// rather than each building its own: iptvRoutes owns the channel-lineup and
+	// schedule caches, and two independent instances would mean two independent
+	// background warms of every network catalog for the same lineup.
+	iptvRts := newIPTVRoutes(&repo, cfg)
+
 	resolver := &Resolver{
 		repository:     repo,
 		sceneService:   sceneService,
@@ -209,13 +222,17 @@ func Initialize() (*Server, error) {
 		galleryService: galleryService,
 		groupService:   groupService,
 		hookExecutor:   pluginCache,
+		iptv:           &iptvRts,
 	}
 
 	gqlSrv := gqlHandler.New(NewExecutableSchema(Config{Resolvers: resolver}))
 	gqlSrv.SetRecoverFunc(recoverFunc)
 
 	// Add mutation authorization middleware for multi-user support
-	gqlSrv.AroundOperations(MutationMiddleware(repo.User, repo.TxnManager))
+	gqlSrv.AroundOperations(MutationMiddleware())
+
+	// Record who changed what in the audit log
+	gqlSrv.AroundFields(AuditMiddleware(mgr.Repository))
 
 	gqlSrv.AddTransport(gqlTransport.Websocket{
 		Upgrader: websocket.Upgrader{
@@ -272,21 +289,22 @@ func Initialize() (*Server, error) {
 	r.Mount("/faptap", server.getFaptapRoutes())
 	r.Mount("/pmvhaven", server.getPmvhavenRoutes())
 	r.Mount("/plugin", server.getPluginRoutes())
-	r.Mount("/scheduled-tasks", server.getScheduledTaskRoutes())
+	r.With(requirePermission(models.PermissionRunJobs)).Mount("/scheduled-tasks", server.getScheduledTaskRoutes())
 	r.Mount("/proxy", server.getProxyRoutes())
-	r.Mount("/apihub-connect", server.getApihubConnectRoutes())
-	r.Mount("/apihub-download", server.getApihubDownloadRoutes())
-	r.Mount("/apihub-newsensations", server.getApihubNewSensationsRoutes())
+	// API Hub connection and downloads are limited to users holding the
+	// permission; the banners below are plain images anyone can see.
+	r.Group(func(r chi.Router) {
+		r.Use(requirePermission(models.PermissionAPIHubDownloads))
+		r.Mount("/apihub-connect", server.getApihubConnectRoutes())
+		r.Mount("/apihub-download", server.getApihubDownloadRoutes())
+		r.Mount("/apihub-newsensations", server.getApihubNewSensationsRoutes())
+	})
 	r.Mount("/apihub-banners", server.getApihubBannerRoutes())
 	r.Mount("/stashface", server.getStashFaceRoutes())
 	r.Mount("/stashtag", server.getStashTagRoutes())
 	r.Mount("/megaface", server.getMegaFaceRoutes())
-	r.Mount("/handy", server.getHandyRoutes())
-	// Constructed once and shared with the Xtream routes below rather than
-	// each building its own: iptvRoutes owns the channel-lineup and schedule
-	// caches, and two independent instances would mean two independent
-	// background warms of every network catalog for the same lineup.
-	iptvRts := newIPTVRoutes(&repo, cfg)
+	r.With(requirePermission(models.PermissionControlHandy)).Mount("/handy", server.getHandyRoutes())
+	r.Mount("/watch-party", server.getWatchPartyRoutes())
 	r.Mount("/iptv", iptvRts.Routes())
 	// Xtream Codes API alongside the M3U/EPG above, registered at root —
 	// client apps construct /player_api.php, /live/..., /series/... literally,
@@ -295,6 +313,12 @@ func Initialize() (*Server, error) {
 	// Series (movie = series, scene = episode — see routes_iptv_xtream.go for
 	// why VOD/Movies has no good answer for a multi-scene collection).
 	newIPTVXtreamRoutes(iptvRts).Register(r)
+	// The lineup again, as an HDHomeRun tuner (see routes_iptv_hdhr.go).
+	// Entering a tuner by address makes Plex ask for /discover.json at the
+	// root, so that one endpoint is registered there too; everything it
+	// points at lives under /hdhr.
+	r.Mount(iptvHDHRPathPrefix, iptvRts.hdhrRoutes())
+	r.Get("/discover.json", iptvRts.HDHRDiscover)
 
 	// DeoVR routes — grouped under /deovr prefix so tunnel sub-paths
 	// are resolved before the catch-all /* UI handler.
@@ -346,6 +370,8 @@ func Initialize() (*Server, error) {
 	r.Post(loginEndpoint, handleLoginPost())
 	r.Get(logoutEndpoint, handleLogout())
 	r.Get(loginLocaleEndpoint, handleLoginLocale(cfg))
+	r.Get(oidcLoginEndpoint, handleOIDCLogin())
+	r.Get(oidcCallbackEndpoint, handleOIDCCallback())
 	r.HandleFunc(loginEndpoint+"/*", func(w http.ResponseWriter, r *http.Request) {
 		r.URL.Path = strings.TrimPrefix(r.URL.Path, loginEndpoint)
 		w.Header().Set("Cache-Control", "no-cache")
@@ -436,6 +462,16 @@ func Initialize() (*Server, error) {
 	// apihub_gamma_keepalive_scheduler.go.
 	startApihubGammaKeepaliveScheduler()
 
+	// Announce the emulated HDHomeRun tuner over SSDP while it is enabled.
+	iptvRts.startHDHRDiscovery()
+
+	// Let scheduled tasks run the API Hub relink job, which lives here.
+	manager.GetInstance().APIHubRelink = startApihubRelink
+
+	// Pick up the jobs a restart interrupted, now that every kind of job is
+	// registered.
+	manager.GetInstance().RestoreJobs(context.Background())
+
 	return server, nil
 }
 
@@ -772,14 +808,15 @@ func (s *Server) getPerformerRoutes() chi.Router {
 func (s *Server) getSceneRoutes() chi.Router {
 	repo := s.manager.Repository
 	return sceneRoutes{
-		routes:               routes{txnManager: repo.TxnManager},
-		sceneFinder:          repo.Scene,
-		fileGetter:           repo.File,
-		captionFinder:        repo.File,
-		sceneCaptionFinder:   repo.Scene,
-		sceneFunscriptFinder: repo.Scene,
-		sceneMarkerFinder:    repo.SceneMarker,
-		tagFinder:            repo.Tag,
+		routes:                 routes{txnManager: repo.TxnManager},
+		sceneFinder:            repo.Scene,
+		fileGetter:             repo.File,
+		captionFinder:          repo.File,
+		sceneCaptionFinder:     repo.Scene,
+		sceneFunscriptFinder:   repo.Scene,
+		sceneMarkerFinder:      repo.SceneMarker,
+		tagFinder:              repo.Tag,
+		optimizedVersionFinder: repo.OptimizedVersion,
 	}.Routes()
 }
 
@@ -792,6 +829,17 @@ func (s *Server) getHandyRoutes() chi.Router {
 	}.Routes()
 }
 
+func (s *Server) getWatchPartyRoutes() chi.Router {
+	repo := s.manager.Repository
+	return watchPartyRoutes{
+		handy: handyRoutes{
+			routes:               routes{txnManager: repo.TxnManager},
+			sceneFinder:          repo.Scene,
+			sceneFunscriptFinder: repo.Scene,
+		},
+	}.Routes()
+}
+
 func (s *Server) getGalleryRoutes() chi.Router {
 	repo := s.manager.Repository
 	return galleryRoutes{
@@ -960,9 +1008,12 @@ func (s *Server) getApihubDownloadRoutes() chi.Router {
 	historyDir := func() string {
 		return filepath.Join(config.GetInstance().GetConfigPath(), "apihub")
 	}
+	history := newApihubHistoryStore(historyDir)
+	registerApihubDownloadJob(history)
+
 	return apihubDownloadRoutes{
 		routes:  routes{txnManager: repo.TxnManager},
-		history: newApihubHistoryStore(historyDir),
+		history: history,
 	}.Routes()
 }
 
@@ -1265,8 +1316,9 @@ func mediaAwareCompress(level int) func(http.Handler) http.Handler {
 			}
 
 			// WebSocket upgrades need http.Hijacker, which the gzip writer
-			// does not implement — bypass compression for the Handy WS.
-			if strings.HasPrefix(p, "/handy/") {
+			// does not implement — bypass compression for the Handy and
+			// watch-party WSs.
+			if strings.HasPrefix(p, "/handy/") || strings.HasPrefix(p, "/watch-party/") {
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -34,6 +34,7 @@ func getLoginPage() []byte {
 type loginTemplateData struct {
 	URL   string
 	Error string
+	SSO   bool
 }
 
 func serveLoginPage(w http.ResponseWriter, r *http.Request, returnURL string, loginError string) {
@@ -48,7 +49,11 @@ func serveLoginPage(w http.ResponseWriter, r *http.Request, returnURL string, lo
 	}
 
 	buffer := bytes.Buffer{}
-	err = templ.Execute(&buffer, loginTemplateData{URL: returnURL, Error: loginError})
+	err = templ.Execute(&buffer, loginTemplateData{
+		URL:   returnURL,
+		Error: loginError,
+		SSO:   manager.GetInstance().SessionStore.OIDCEnabled(),
+	})
 	if err != nil {
 		http.Error(w, fmt.Sprintf("error: %s", err), http.StatusInternalServerError)
 		return
@@ -143,6 +148,59 @@ func handleLoginPost() http.HandlerFunc {
 	}
 }
 
+func handleOIDCLogin() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// only return to pages of this server
+		returnURL := r.URL.Query().Get(returnURLParam)
+		if !strings.HasPrefix(returnURL, "/") || strings.HasPrefix(returnURL, "//") {
+			returnURL = getProxyPrefix(r) + "/"
+		}
+
+		baseURL, _ := r.Context().Value(BaseURLCtxKey).(string)
+		redirectURL := baseURL + oidcCallbackEndpoint
+
+		u, err := manager.GetInstance().SessionStore.OIDCLogin(w, r, redirectURL, returnURL)
+		if err != nil {
+			logger.Errorf("Error starting single sign-on: %v", err)
+			if errors.Is(err, session.ErrOIDCDisabled) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			serveLoginPage(w, r, returnURL, "Single sign-on is unavailable. See logs")
+			return
+		}
+
+		http.Redirect(w, r, u, http.StatusFound)
+	}
+}
+
+func handleOIDCCallback() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		returnURL, err := manager.GetInstance().SessionStore.OIDCCallback(w, r)
+		if err != nil {
+			// always log the error
+			logger.Errorf("Error logging in with single sign-on: %v from IP: %s", err, r.RemoteAddr)
+
+			var invalidCredentialsError *session.InvalidCredentialsError
+			switch {
+			case errors.As(err, &invalidCredentialsError):
+				serveLoginPage(w, r, "", "You are not allowed to access this server")
+			case errors.Is(err, session.ErrUserDisabled):
+				serveLoginPage(w, r, "", "Your account is disabled")
+			default:
+				// don't expose the error to the user
+				serveLoginPage(w, r, "", "Single sign-on failed. See logs")
+			}
+			return
+		}
+
+		if returnURL == "" {
+			returnURL = getProxyPrefix(r) + "/"
+		}
+		http.Redirect(w, r, returnURL, http.StatusFound)
+	}
+}
+
 func handleLogout() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if err := manager.GetInstance().SessionStore.Logout(w, r); err != nil {
@@ -41,6 +41,7 @@ import (
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/scene"
+	"github.com/stashapp/stash/pkg/scene/generate"
 )
 
 var pageSize = 100
@@ -76,12 +77,12 @@ func (me *contentDirectoryService) updateIDString() string {
 	return fmt.Sprintf("%d", uint32(os.Getpid()))
 }
 
-func sceneToContainer(scene *models.Scene, parent string, host string) interface{} {
+func sceneToContainer(scene *models.Scene, parent string, client renderer) interface{} {
 	// make stash server URL
 	// TODO - fix this
 	iconURI := (&url.URL{
 		Scheme: "http",
-		Host:   host,
+		Host:   client.host,
 		Path:   iconPath,
 		RawQuery: url.Values{
 			"scene": {strconv.Itoa(scene.ID)},
@@ -112,36 +113,80 @@ func sceneToContainer(scene *models.Scene, parent string, host string) interface
 		duration int64
 	)
 
+	pb := playDirect
 	f := scene.Files.Primary()
 	if f != nil {
 		size = int(f.Size)
 		bitrate = uint(f.BitRate)
 		duration = int64(f.Duration)
-	}
+		pb = client.profile.playback(f, func() int {
+			return client.bitDepth(f)
+		})
+	}
+
+	if pb == playDirect {
+		item.Res = append(item.Res, upnpav.Resource{
+			URL: (&url.URL{
+				Scheme: "http",
+				Host:   client.host,
+				Path:   resPath,
+				RawQuery: url.Values{
+					"scene": {strconv.Itoa(scene.ID)},
+				}.Encode(),
+			}).String(),
+			ProtocolInfo: fmt.Sprintf("http-get:*:%s:%s", mimeType, dlna.ContentFeatures{
+				SupportRange: true,
+			}.String()),
+			Bitrate:  bitrate,
+			Duration: formatDurationSexagesimal(time.Duration(duration) * time.Second),
+			Size:     uint64(size),
+			// Resolution: resolution,
+		})
+	} else {
+		// the renderer can't play the file, so offer a live transcode
+		// instead, which it can seek by time but not by bytes
+		query := url.Values{
+			"scene":     {strconv.Itoa(scene.ID)},
+			"transcode": {transcodeEncode},
+		}
+		if pb == playRemux {
+			query.Set("transcode", transcodeRemux)
+		} else if client.profile.MaxResolution != nil {
+			query.Set("resolution", client.profile.MaxResolution.String())
+		}
 
-	item.Res = append(item.Res, upnpav.Resource{
-		URL: (&url.URL{
-			Scheme: "http",
-			Host:   host,
-			Path:   resPath,
-			RawQuery: url.Values{
-				"scene": {strconv.Itoa(scene.ID)},
-			}.Encode(),
-		}).String(),
-		ProtocolInfo: fmt.Sprintf("http-get:*:%s:%s", mimeType, dlna.ContentFeatures{
-			SupportRange: true,
-		}.String()),
-		Bitrate:  bitrate,
-		Duration: formatDurationSexagesimal(time.Duration(duration) * time.Second),
-		Size:     uint64(size),
-		// Resolution: resolution,
-	})
+		item.Res = append(item.Res, upnpav.Resource{
+			URL: (&url.URL{
+				Scheme:   "http",
+				Host:     client.host,
+				Path:     resPath,
+				RawQuery: query.Encode(),
+			}).String(),
+			ProtocolInfo: fmt.Sprintf("http-get:*:%s:%s", transcodeMimeType, transcodeContentFeatures),
+			Duration:     formatDurationSexagesimal(time.Duration(duration) * time.Second),
+		})
+	}
 
 	item.Res = append(item.Res, upnpav.Resource{
 		URL:          iconURI,
 		ProtocolInfo: "http-get:*:image/jpeg:DLNA.ORG_PN=JPEG_MED",
 	})
 
+	// renderers that support trick play find the frames by their extension
+	if client.hasTrickPlay != nil && client.hasTrickPlay(scene) {
+		item.Res = append(item.Res, upnpav.Resource{
+			URL: (&url.URL{
+				Scheme: "http",
+				Host:   client.host,
+				Path:   trickPlayPath,
+				RawQuery: url.Values{
+					"scene": {strconv.Itoa(scene.ID)},
+				}.Encode(),
+			}).String(),
+			ProtocolInfo: "http-get:*:" + generate.MimeBIF + ":*",
+		})
+	}
+
 	return item
 }
 
@@ -174,8 +219,7 @@ func childPath(paths []string) []string {
 
 func (me *contentDirectoryService) Handle(action string, argsXML []byte, r *http.Request) (map[string]string, error) {
 	ctx := r.Context()
-	host := r.Host
-	// userAgent := r.UserAgent()
+	client := me.renderer(r)
 	switch action {
 	case "GetSystemUpdateID":
 		return map[string]string{
@@ -198,9 +242,9 @@ func (me *contentDirectoryService) Handle(action string, argsXML []byte, r *http
 
 		switch browse.BrowseFlag {
 		case "BrowseDirectChildren":
-			return me.handleBrowseDirectChildren(ctx, obj, host)
+			return me.handleBrowseDirectChildren(ctx, obj, client)
 		case "BrowseMetadata":
-			return me.handleBrowseMetadata(ctx, obj, host)
+			return me.handleBrowseMetadata(ctx, obj, client)
 		default:
 			return nil, upnp.Errorf(upnp.ArgumentValueInvalidErrorCode, "unhandled browse flag: %v", browse.BrowseFlag)
 		}
@@ -227,7 +271,7 @@ func (me *contentDirectoryService) Handle(action string, argsXML []byte, r *http
 	}
 }
 
-func (me *contentDirectoryService) handleBrowseDirectChildren(ctx context.Context, obj object, host string) (map[string]string, error) {
+func (me *contentDirectoryService) handleBrowseDirectChildren(ctx context.Context, obj object, client renderer) (map[string]string, error) {
 	var objs []interface{}
 
 	if obj.IsRoot() {
@@ -238,13 +282,13 @@ func (me *contentDirectoryService) handleBrowseDirectChildren(ctx context.Contex
 
 	// All videos
 	if obj.Path == "all" {
-		objs = me.getAllScenes(ctx, host)
+		objs = me.getAllScenes(ctx, client)
 	}
 
 	if strings.HasPrefix(obj.Path, "all/") {
 		page := getPageFromID(paths)
 		if page != nil {
-			objs = me.getPageVideos(ctx, &models.SceneFilterType{}, "all", *page, host)
+			objs = me.getPageVideos(ctx, &models.SceneFilterType{}, "all", *page, client)
 		}
 	}
 
@@ -281,7 +325,7 @@ func (me *contentDirectoryService) handleBrowseDirectChildren(ctx context.Contex
 	// 		data := models.QueryScenesFull(r)
 
 	// 		for i := range data.Scenes {
-	// 			objs = append(objs, me.sceneToContainer(data.Scenes[i], "sites/"+id[1], host))
+	// 			objs = append(objs, me.sceneToContainer(data.Scenes[i], "sites/"+id[1], client))
 	// 		}
 	// 	}
 	// }
@@ -292,7 +336,7 @@ func (me *contentDirectoryService) handleBrowseDirectChildren(ctx context.Contex
 	}
 
 	if strings.HasPrefix(obj.Path, "studios/") {
-		objs = me.getStudioScenes(ctx, childPath(paths), host)
+		objs = me.getStudioScenes(ctx, childPath(paths), client)
 	}
 
 	// Tags
@@ -301,7 +345,7 @@ func (me *contentDirectoryService) handleBrowseDirectChildren(ctx context.Contex
 	}
 
 	if strings.HasPrefix(obj.Path, "tags/") {
-		objs = me.getTagScenes(ctx, childPath(paths), host)
+		objs = me.getTagScenes(ctx, childPath(paths), client)
 	}
 
 	// Performers
@@ -310,7 +354,7 @@ func (me *contentDirectoryService) handleBrowseDirectChildren(ctx context.Contex
 	}
 
 	if strings.HasPrefix(obj.Path, "performers/") {
-		objs = me.getPerformerScenes(ctx, childPath(paths), host)
+		objs = me.getPerformerScenes(ctx, childPath(paths), client)
 	}
 
 	// Groups - deprecated
@@ -319,7 +363,7 @@ func (me *contentDirectoryService) handleBrowseDirectChildren(ctx context.Contex
 	}
 
 	if strings.HasPrefix(obj.Path, "groups/") {
-		objs = me.getGroupScenes(ctx, childPath(paths), host)
+		objs = me.getGroupScenes(ctx, childPath(paths), client)
 	}
 
 	// Rating
@@ -328,13 +372,13 @@ func (me *contentDirectoryService) handleBrowseDirectChildren(ctx context.Contex
 	}
 
 	if strings.HasPrefix(obj.Path, "rating/") {
-		objs = me.getRatingScenes(ctx, childPath(paths), host)
+		objs = me.getRatingScenes(ctx, childPath(paths), client)
 	}
 
 	return makeBrowseResult(objs, me.updateIDString())
 }
 
-func (me *contentDirectoryService) handleBrowseMetadata(ctx context.Context, obj object, host string) (map[string]string, error) {
+func (me *contentDirectoryService) handleBrowseMetadata(ctx context.Context, obj object, client renderer) (map[string]string, error) {
 	var objs []interface{}
 	var updateID string
 
@@ -372,7 +416,7 @@ func (me *contentDirectoryService) handleBrowseMetadata(ctx context.Context, obj
 		}
 
 		if scene != nil {
-			upnpObject := sceneToContainer(scene, "-1", host)
+			upnpObject := sceneToContainer(scene, "-1", client)
 			objs = []interface{}{upnpObject}
 
 			// http://upnp.org/specs/av/UPnP-av-ContentDirectory-v1-Service.pdf
@@ -445,7 +489,7 @@ func getSortDirection(sort string) models.SortDirectionEnum {
 	return direction
 }
 
-func (me *contentDirectoryService) getVideos(ctx context.Context, sceneFilter *models.SceneFilterType, parentID string, host string) []interface{} {
+func (me *contentDirectoryService) getVideos(ctx context.Context, sceneFilter *models.SceneFilterType, parentID string, client renderer) []interface{} {
 	var objs []interface{}
 
 	r := me.repository
@@ -479,7 +523,7 @@ func (me *contentDirectoryService) getVideos(ctx context.Context, sceneFilter *m
 					return err
 				}
 
-				objs = append(objs, sceneToContainer(s, parentID, host))
+				objs = append(objs, sceneToContainer(s, parentID, client))
 			}
 		}
 
@@ -491,7 +535,7 @@ func (me *contentDirectoryService) getVideos(ctx context.Context, sceneFilter *m
 	return objs
 }
 
-func (me *contentDirectoryService) getPageVideos(ctx context.Context, sceneFilter *models.SceneFilterType, parentID string, page int, host string) []interface{} {
+func (me *contentDirectoryService) getPageVideos(ctx context.Context, sceneFilter *models.SceneFilterType, parentID string, page int, client renderer) []interface{} {
 	var objs []interface{}
 
 	r := me.repository
@@ -504,7 +548,7 @@ func (me *contentDirectoryService) getPageVideos(ctx context.Context, sceneFilte
 		sort := me.VideoSortOrder
 		direction := getSortDirection(sort)
 		var err error
-		objs, err = pager.getPageVideos(ctx, r.SceneFinder, r.FileGetter, page, host, sort, direction)
+		objs, err = pager.getPageVideos(ctx, r.SceneFinder, r.FileGetter, page, client, sort, direction)
 		if err != nil {
 			return err
 		}
@@ -531,8 +575,8 @@ func getPageFromID(paths []string) *int {
 	return &ret
 }
 
-func (me *contentDirectoryService) getAllScenes(ctx context.Context, host string) []interface{} {
-	return me.getVideos(ctx, &models.SceneFilterType{}, "all", host)
+func (me *contentDirectoryService) getAllScenes(ctx context.Context, client renderer) []interface{} {
+	return me.getVideos(ctx, &models.SceneFilterType{}, "all", client)
 }
 
 func (me *contentDirectoryService) getStudios(ctx context.Context) []interface{} {
@@ -557,7 +601,7 @@ func (me *contentDirectoryService) getStudios(ctx context.Context) []interface{}
 	return objs
 }
 
-func (me *contentDirectoryService) getStudioScenes(ctx context.Context, paths []string, host string) []interface{} {
+func (me *contentDirectoryService) getStudioScenes(ctx context.Context, paths []string, client renderer) []interface{} {
 	sceneFilter := &models.SceneFilterType{
 		Studios: &models.HierarchicalMultiCriterionInput{
 			Modifier: models.CriterionModifierIncludes,
@@ -569,10 +613,10 @@ func (me *contentDirectoryService) getStudioScenes(ctx context.Context, paths []
 
 	page := getPageFromID(paths)
 	if page != nil {
-		return me.getPageVideos(ctx, sceneFilter, parentID, *page, host)
+		return me.getPageVideos(ctx, sceneFilter, parentID, *page, client)
 	}
 
-	return me.getVideos(ctx, sceneFilter, parentID, host)
+	return me.getVideos(ctx, sceneFilter, parentID, client)
 }
 
 func (me *contentDirectoryService) getTags(ctx context.Context) []interface{} {
@@ -597,7 +641,7 @@ func (me *contentDirectoryService) getTags(ctx context.Context) []interface{} {
 	return objs
 }
 
-func (me *contentDirectoryService) getTagScenes(ctx context.Context, paths []string, host string) []interface{} {
+func (me *contentDirectoryService) getTagScenes(ctx context.Context, paths []string, client renderer) []interface{} {
 	sceneFilter := &models.SceneFilterType{
 		Tags: &models.HierarchicalMultiCriterionInput{
 			Modifier: models.CriterionModifierIncludes,
@@ -609,10 +653,10 @@ func (me *contentDirectoryService) getTagScenes(ctx context.Context, paths []str
 
 	page := getPageFromID(paths)
 	if page != nil {
-		return me.getPageVideos(ctx, sceneFilter, parentID, *page, host)
+		return me.getPageVideos(ctx, sceneFilter, parentID, *page, client)
 	}
 
-	return me.getVideos(ctx, sceneFilter, parentID, host)
+	return me.getVideos(ctx, sceneFilter, parentID, client)
 }
 
 func (me *contentDirectoryService) getPerformers(ctx context.Context) []interface{} {
@@ -637,7 +681,7 @@ func (me *contentDirectoryService) getPerformers(ctx context.Context) []interfac
 	return objs
 }
 
-func (me *contentDirectoryService) getPerformerScenes(ctx context.Context, paths []string, host string) []interface{} {
+func (me *contentDirectoryService) getPerformerScenes(ctx context.Context, paths []string, client renderer) []interface{} {
 	sceneFilter := &models.SceneFilterType{
 		Performers: &models.MultiCriterionInput{
 			Modifier: models.CriterionModifierIncludes,
@@ -649,10 +693,10 @@ func (me *contentDirectoryService) getPerformerScenes(ctx context.Context, paths
 
 	page := getPageFromID(paths)
 	if page != nil {
-		return me.getPageVideos(ctx, sceneFilter, parentID, *page, host)
+		return me.getPageVideos(ctx, sceneFilter, parentID, *page, client)
 	}
 
-	return me.getVideos(ctx, sceneFilter, parentID, host)
+	return me.getVideos(ctx, sceneFilter, parentID, client)
 }
 
 func (me *contentDirectoryService) getGroups(ctx context.Context) []interface{} {
@@ -677,7 +721,7 @@ func (me *contentDirectoryService) getGroups(ctx context.Context) []interface{}
 	return objs
 }
 
-func (me *contentDirectoryService) getGroupScenes(ctx context.Context, paths []string, host string) []interface{} {
+func (me *contentDirectoryService) getGroupScenes(ctx context.Context, paths []string, client renderer) []interface{} {
 	sceneFilter := &models.SceneFilterType{
 		Groups: &models.HierarchicalMultiCriterionInput{
 			Modifier: models.CriterionModifierIncludes,
@@ -689,10 +733,10 @@ func (me *contentDirectoryService) getGroupScenes(ctx context.Context, paths []s
 
 	page := getPageFromID(paths)
 	if page != nil {
-		return me.getPageVideos(ctx, sceneFilter, parentID, *page, host)
+		return me.getPageVideos(ctx, sceneFilter, parentID, *page, client)
 	}
 
-	return me.getVideos(ctx, sceneFilter, parentID, host)
+	return me.getVideos(ctx, sceneFilter, parentID, client)
 }
 
 func (me *contentDirectoryService) getRating() []interface{} {
@@ -706,7 +750,7 @@ func (me *contentDirectoryService) getRating() []interface{} {
 	return objs
 }
 
-func (me *contentDirectoryService) getRatingScenes(ctx context.Context, paths []string, host string) []interface{} {
+func (me *contentDirectoryService) getRatingScenes(ctx context.Context, paths []string, client renderer) []interface{} {
 	r, err := strconv.Atoi(paths[0])
 	if err != nil {
 		return nil
@@ -723,10 +767,10 @@ func (me *contentDirectoryService) getRatingScenes(ctx context.Context, paths []
 
 	page := getPageFromID(paths)
 	if page != nil {
-		return me.getPageVideos(ctx, sceneFilter, parentID, *page, host)
+		return me.getPageVideos(ctx, sceneFilter, parentID, *page, client)
 	}
 
-	return me.getVideos(ctx, sceneFilter, parentID, host)
+	return me.getVideos(ctx, sceneFilter, parentID, client)
 }
 
 // Represents a ContentDirectory object.
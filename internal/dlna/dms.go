@@ -39,16 +39,17 @@ import (
 	"net/url"
 	"path"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 
+	"github.com/anacrolix/dms/dlna"
 	"github.com/anacrolix/dms/soap"
-	"github.com/anacrolix/dms/ssdp"
 	"github.com/anacrolix/dms/upnp"
 
+	"github.com/stashapp/stash/pkg/ffmpeg"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scene/generate"
 )
 
 type SceneFinder interface {
@@ -78,6 +79,7 @@ const (
 	rootDeviceModelName         = "dms 1.0xb"
 	resPath                     = "/res"
 	iconPath                    = "/icon"
+	trickPlayPath               = "/trickplay.bif"
 	rootDescPath                = "/rootDesc.xml"
 	contentDirectoryEventSubURL = "/evt/ContentDirectory"
 	serviceControlURL           = "/ctl"
@@ -162,6 +164,18 @@ func (me *Server) serveHTTP() error {
 			}
 			w.Header().Set("Ext", "")
 			w.Header().Set("Server", serverField)
+
+			// browse as the configured user so that their content restriction applies
+			if scoper := me.repository.UserScoper; scoper != nil && me.config != nil {
+				ctx, err := scoper.ScopeToUser(r.Context(), me.config.GetDLNAUser())
+				if err != nil {
+					logger.Errorf("error scoping DLNA request to user: %v", err)
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				r = r.WithContext(ctx)
+			}
+
 			me.httpServeMux.ServeHTTP(&mitmRespWriter{
 				ResponseWriter: w,
 				logHeader:      me.LogHeaders,
@@ -177,72 +191,16 @@ func (me *Server) serveHTTP() error {
 	}
 }
 
-// An interface with these flags should be valid for SSDP.
-const ssdpInterfaceFlags = net.FlagUp | net.FlagMulticast
-
 func (me *Server) doSSDP() {
-	active := 0
-	stopped := make(chan struct{})
-	for _, if_ := range me.Interfaces {
-		active++
-		go func(if_ net.Interface) {
-			defer func() {
-				stopped <- struct{}{}
-			}()
-			me.ssdpInterface(if_)
-		}(if_)
-	}
-	for active > 0 {
-		<-stopped
-		active--
-	}
-}
-
-// Run SSDP server on an interface.
-func (me *Server) ssdpInterface(if_ net.Interface) {
-	s := ssdp.Server{
-		Interface: if_,
-		Devices:   devices(),
-		Services:  serviceTypes(),
-		Location: func(ip net.IP) string {
-			return me.location(ip)
-		},
+	SSDPAnnouncer{
+		Interfaces:     me.Interfaces,
+		Devices:        devices(),
+		Services:       serviceTypes(),
+		Location:       me.location,
 		Server:         serverField,
 		UUID:           me.rootDeviceUUID,
 		NotifyInterval: me.NotifyInterval,
-	}
-	if err := s.Init(); err != nil {
-		if if_.Flags&ssdpInterfaceFlags != ssdpInterfaceFlags {
-			// Didn't expect it to work anyway.
-			return
-		}
-		if strings.Contains(err.Error(), "listen") {
-			// OSX has a lot of dud interfaces. Failure to create a socket on
-			// the interface are what we're expecting if the interface is no
-			// good.
-			return
-		}
-		logger.Errorf("error creating ssdp server on %s: %s", if_.Name, err)
-		return
-	}
-	defer s.Close()
-	logger.Debugf("started SSDP on %s", if_.Name)
-	stopped := make(chan struct{})
-	go func() {
-		defer close(stopped)
-		// FIXME - this currently blocks forever unless it encounters an error
-		// See https://github.com/anacrolix/dms/pull/150
-		// Needs to be fixed upstream
-		//nolint:staticcheck
-		if err := s.Serve(); err != nil {
-			logger.Errorf("%q: %q\n", if_.Name, err)
-		}
-	}()
-	select {
-	case <-me.closed:
-		// Returning will close the server.
-	case <-stopped:
-	}
+	}.Run(me.closed)
 }
 
 var (
@@ -279,8 +237,12 @@ type Server struct {
 	sceneServer        sceneServer
 	ipWhitelistManager *ipWhitelistManager
 	activityTracker    *ActivityTracker
+	config             Config
 	VideoSortOrder     string
 
+	// bitDepths caches the bit depths of probed video files
+	bitDepths sync.Map
+
 	subscribeLock sync.Mutex
 }
 
@@ -465,6 +427,41 @@ func (me *Server) serveIcon(w http.ResponseWriter, r *http.Request) {
 	me.sceneServer.ServeScreenshot(scene, w, r)
 }
 
+func (me *Server) serveTrickPlay(w http.ResponseWriter, r *http.Request) {
+	sceneId := r.URL.Query().Get("scene")
+	if sceneId == "" {
+		return
+	}
+
+	var scene *models.Scene
+	repo := me.repository
+	err := repo.WithReadTxn(r.Context(), func(ctx context.Context) error {
+		idInt, err := strconv.Atoi(sceneId)
+		if err != nil {
+			return nil
+		}
+		scene, _ = repo.SceneFinder.Find(ctx, idInt)
+		return nil
+	})
+	if err != nil {
+		logger.Warnf("failed to execute read transaction while trying to serve trick play frames: %v", err)
+	}
+
+	if scene == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	filepath := me.sceneServer.TrickPlayBIF(scene)
+	if filepath == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", generate.MimeBIF)
+	http.ServeFile(w, r, filepath)
+}
+
 func (me *Server) contentDirectoryInitialEvent(ctx context.Context, urls []*url.URL, sid string) {
 	body := xmlMarshalOrPanic(upnp.PropertySet{
 		Properties: []upnp.Property{
@@ -595,6 +592,7 @@ func (me *Server) initMux(mux *http.ServeMux) {
 	})
 	mux.HandleFunc(contentDirectoryEventSubURL, me.contentDirectoryEventSubHandler)
 	mux.HandleFunc(iconPath, me.serveIcon)
+	mux.HandleFunc(trickPlayPath, me.serveTrickPlay)
 	mux.HandleFunc(resPath, func(w http.ResponseWriter, r *http.Request) {
 		sceneId := r.URL.Query().Get("scene")
 		var scene *models.Scene
@@ -626,7 +624,11 @@ func (me *Server) initMux(mux *http.ServeMux) {
 		}
 
 		w.Header().Set("transferMode.dlna.org", "Streaming")
-		w.Header().Set("contentFeatures.dlna.org", "DLNA.ORG_OP=01;DLNA.ORG_CI=0;DLNA.ORG_FLAGS=01500000000000000000000000000000")
+
+		transcode := r.URL.Query().Get("transcode")
+		if transcode == "" {
+			w.Header().Set("contentFeatures.dlna.org", "DLNA.ORG_OP=01;DLNA.ORG_CI=0;DLNA.ORG_FLAGS=01500000000000000000000000000000")
+		}
 
 		// Track activity - uses time-based tracking, updated on each request
 		if me.activityTracker != nil {
@@ -635,6 +637,11 @@ func (me *Server) initMux(mux *http.ServeMux) {
 			me.activityTracker.RecordRequest(sceneIdInt, clientIP, videoDuration)
 		}
 
+		if transcode != "" {
+			me.serveTranscode(scene, transcode, w, r)
+			return
+		}
+
 		me.sceneServer.StreamSceneDirect(scene, w, r)
 	})
 	mux.HandleFunc(rootDescPath, func(w http.ResponseWriter, r *http.Request) {
@@ -656,6 +663,50 @@ func (me *Server) initMux(mux *http.ServeMux) {
 	}
 }
 
+// serveTranscode serves the scene transcoded for a renderer that cannot play
+// it directly, from the time requested in the TimeSeekRange.dlna.org header.
+func (me *Server) serveTranscode(scene *models.Scene, transcode string, w http.ResponseWriter, r *http.Request) {
+	f := scene.Files.Primary()
+	if f == nil {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	duration := time.Duration(f.Duration * float64(time.Second))
+
+	var start time.Duration
+	if v := r.Header.Get(dlna.TimeSeekRangeDomain); v != "" {
+		var err error
+		start, err = parseTimeSeekRange(v)
+		if err != nil || start >= duration {
+			http.Error(w, http.StatusText(http.StatusRequestedRangeNotSatisfiable), http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+
+		w.Header().Set(dlna.TimeSeekRangeDomain, fmt.Sprintf("npt=%s-%s/%s",
+			dlna.FormatNPTTime(start), dlna.FormatNPTTime(duration), dlna.FormatNPTTime(duration)))
+	}
+
+	w.Header().Set(dlna.ContentFeaturesDomain, transcodeContentFeatures)
+
+	// renderers check the headers before playing
+	if r.Method == http.MethodHead {
+		w.Header().Set("Content-Type", transcodeMimeType)
+		return
+	}
+
+	options := ffmpeg.TranscodeOptions{
+		StreamType: ffmpeg.StreamTypeMPEGTS,
+		VideoFile:  f,
+		Resolution: r.URL.Query().Get("resolution"),
+		StartTime:  start.Seconds(),
+		Remux:      transcode == transcodeRemux,
+	}
+
+	logger.Debugf("[dlna] transcoding scene %d from %s", scene.ID, dlna.FormatNPTTime(start))
+	me.sceneServer.ServeTranscode(w, r, options)
+}
+
 func (me *Server) initServices() {
 	me.services = map[string]UPnPService{
 		"ContentDirectory": &contentDirectoryService{
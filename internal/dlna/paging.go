@@ -78,7 +78,7 @@ func (p *scenePager) getPages(ctx context.Context, r models.SceneQueryer, total
 	return objs, nil
 }
 
-func (p *scenePager) getPageVideos(ctx context.Context, r SceneFinder, f models.FileGetter, page int, host string, sort string, direction models.SortDirectionEnum) ([]interface{}, error) {
+func (p *scenePager) getPageVideos(ctx context.Context, r SceneFinder, f models.FileGetter, page int, client renderer, sort string, direction models.SortDirectionEnum) ([]interface{}, error) {
 	var objs []interface{}
 
 	findFilter := &models.FindFilterType{
@@ -98,7 +98,7 @@ func (p *scenePager) getPageVideos(ctx context.Context, r SceneFinder, f models.
 			return nil, err
 		}
 
-		objs = append(objs, sceneToContainer(s, p.parentID, host))
+		objs = append(objs, sceneToContainer(s, p.parentID, client))
 	}
 
 	return objs, nil
@@ -0,0 +1,319 @@
+package dlna
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anacrolix/dms/dlna"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// RendererProfile describes the media that a DLNA renderer can play. Files
+// that a renderer cannot play are advertised to it as a live transcode. Empty
+// lists allow anything.
+type RendererProfile struct {
+	Name string `json:"name"`
+	// UserAgent is a regular expression matched against the User-Agent of the
+	// renderer's requests.
+	UserAgent string `json:"userAgent"`
+	// FriendlyName is a regular expression matched against the name the
+	// renderer gives in its requests. If both are set, both must match.
+	FriendlyName string `json:"friendlyName"`
+	// Containers are file formats, as stored for video files, such as mp4,
+	// matroska or mpegts
+	Containers  []string `json:"containers"`
+	VideoCodecs []string `json:"videoCodecs"`
+	AudioCodecs []string `json:"audioCodecs"`
+	// MaxResolution is the largest resolution the renderer plays. Nil for any.
+	MaxResolution *models.StreamingResolutionEnum `json:"maxResolution"`
+	// MaxBitDepth is the most bits per sample of video the renderer plays. 0
+	// for any.
+	MaxBitDepth int `json:"maxBitDepth"`
+}
+
+func resolution(r models.StreamingResolutionEnum) *models.StreamingResolutionEnum {
+	return &r
+}
+
+// defaultRendererProfiles are used for renderers that match none of the
+// configured profiles.
+var defaultRendererProfiles = []*RendererProfile{
+	{
+		Name:          "Samsung TV",
+		UserAgent:     `SEC_HHP_|SamsungWiselinkPro|Samsung.*DTV`,
+		Containers:    []string{"mp4", "matroska", "mpegts", "avi"},
+		VideoCodecs:   []string{"h264", "hevc", "mpeg4", "vp9"},
+		AudioCodecs:   []string{"aac", "mp3", "ac3", "eac3"},
+		MaxResolution: resolution(models.StreamingResolutionEnumFourK),
+	},
+	{
+		Name:          "LG TV",
+		UserAgent:     `LGE_DLNA_SDK|webOS`,
+		Containers:    []string{"mp4", "matroska", "mpegts"},
+		VideoCodecs:   []string{"h264", "hevc", "vp9"},
+		AudioCodecs:   []string{"aac", "mp3", "ac3", "eac3"},
+		MaxResolution: resolution(models.StreamingResolutionEnumFourK),
+	},
+	{
+		Name:          "Sony Bravia",
+		FriendlyName:  `BRAVIA`,
+		Containers:    []string{"mp4", "mpegts"},
+		VideoCodecs:   []string{"h264", "hevc"},
+		AudioCodecs:   []string{"aac", "mp3", "ac3"},
+		MaxResolution: resolution(models.StreamingResolutionEnumFourK),
+	},
+	{
+		Name:          "Panasonic Viera",
+		UserAgent:     `Panasonic MIL DLNA|VIERA`,
+		Containers:    []string{"mp4", "matroska", "mpegts"},
+		VideoCodecs:   []string{"h264", "hevc"},
+		AudioCodecs:   []string{"aac", "mp3", "ac3"},
+		MaxResolution: resolution(models.StreamingResolutionEnumFourK),
+	},
+	{
+		Name:          "Xbox",
+		UserAgent:     `Xbox`,
+		Containers:    []string{"mp4", "matroska", "mpegts", "avi"},
+		VideoCodecs:   []string{"h264", "hevc"},
+		AudioCodecs:   []string{"aac", "mp3", "ac3"},
+		MaxResolution: resolution(models.StreamingResolutionEnumFourK),
+	},
+	{
+		Name:          "PlayStation 3",
+		UserAgent:     `PLAYSTATION 3`,
+		Containers:    []string{"mp4", "mpegts"},
+		VideoCodecs:   []string{"h264"},
+		AudioCodecs:   []string{"aac", "mp3", "ac3"},
+		MaxResolution: resolution(models.StreamingResolutionEnumFullHd),
+		MaxBitDepth:   8,
+	},
+}
+
+// ValidateRendererProfiles returns an error if any of the profiles is unnamed
+// or has an invalid pattern.
+func ValidateRendererProfiles(profiles []*RendererProfile) error {
+	for _, p := range profiles {
+		if p.Name == "" {
+			return errors.New("renderer profile name is required")
+		}
+		if p.UserAgent == "" && p.FriendlyName == "" {
+			return fmt.Errorf("renderer profile %q matches no renderer: user agent or friendly name is required", p.Name)
+		}
+		for _, pattern := range []string{p.UserAgent, p.FriendlyName} {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("renderer profile %q: invalid pattern %q: %w", p.Name, pattern, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// rendererName returns the name that the renderer gives in its request
+func rendererName(r *http.Request) string {
+	return strings.Join([]string{
+		r.Header.Get("FriendlyName.DLNA.ORG"),
+		r.Header.Get("X-AV-Client-Info"),
+		r.Header.Get("X-AV-Physical-Unit-Info"),
+	}, " ")
+}
+
+func matchPattern(pattern string, s string) bool {
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		logger.Warnf("[dlna] invalid renderer profile pattern %q: %v", pattern, err)
+		return false
+	}
+	return re.MatchString(s)
+}
+
+// matches returns true if the request is from a renderer that the profile
+// describes.
+func (p *RendererProfile) matches(r *http.Request) bool {
+	if p.UserAgent == "" && p.FriendlyName == "" {
+		return false
+	}
+
+	if p.UserAgent != "" && !matchPattern(p.UserAgent, r.UserAgent()) {
+		return false
+	}
+
+	return p.FriendlyName == "" || matchPattern(p.FriendlyName, rendererName(r))
+}
+
+// matchRendererProfile returns the first of the profiles, then of the default
+// profiles, that matches the request, or nil if none match.
+func matchRendererProfile(profiles []*RendererProfile, r *http.Request) *RendererProfile {
+	for _, p := range slices.Concat(profiles, defaultRendererProfiles) {
+		if p.matches(r) {
+			return p
+		}
+	}
+	return nil
+}
+
+// codecAliases maps other names for formats and codecs to those stored for
+// video files
+var codecAliases = map[string]string{
+	"mkv":  "matroska",
+	"ts":   "mpegts",
+	"avc":  "h264",
+	"h265": "hevc",
+}
+
+func allows(list []string, v string) bool {
+	if len(list) == 0 {
+		return true
+	}
+
+	v = strings.ToLower(v)
+	return slices.ContainsFunc(list, func(s string) bool {
+		s = strings.ToLower(s)
+		if alias, ok := codecAliases[s]; ok {
+			s = alias
+		}
+		return s == v
+	})
+}
+
+// renderer is the client of a content directory request
+type renderer struct {
+	// host is the address the renderer reached the server on
+	host string
+	// profile is nil if the renderer is not known
+	profile *RendererProfile
+	// bitDepth returns the bit depth of the video in a file, or 0 if unknown
+	bitDepth func(f *models.VideoFile) int
+	// hasTrickPlay returns whether the scene has trick play frames
+	hasTrickPlay func(scene *models.Scene) bool
+}
+
+const (
+	// transcodeRemux and transcodeEncode are the values of the transcode
+	// parameter of a resource URL
+	transcodeRemux  = "remux"
+	transcodeEncode = "encode"
+
+	transcodeMimeType = "video/mpeg"
+)
+
+// transcodeContentFeatures are those of a transcoded resource, which can be
+// seeked by time but not by bytes
+var transcodeContentFeatures = dlna.ContentFeatures{
+	SupportTimeSeek: true,
+	Transcoded:      true,
+}.String() + ";DLNA.ORG_FLAGS=01500000000000000000000000000000"
+
+// renderer returns the renderer that made the request
+func (me *Server) renderer(r *http.Request) renderer {
+	var profiles []*RendererProfile
+	if me.config != nil {
+		profiles = me.config.GetDLNARendererProfiles()
+	}
+
+	return renderer{
+		host:     r.Host,
+		profile:  matchRendererProfile(profiles, r),
+		bitDepth: me.videoBitDepth,
+		hasTrickPlay: func(scene *models.Scene) bool {
+			return me.sceneServer != nil && me.sceneServer.TrickPlayBIF(scene) != ""
+		},
+	}
+}
+
+// videoBitDepth returns the bit depth of the video in the file, probing it
+// the first time it is asked for.
+func (me *Server) videoBitDepth(f *models.VideoFile) int {
+	if me.sceneServer == nil {
+		return 0
+	}
+
+	key := fmt.Sprintf("%d:%d", f.ID, f.ModTime.Unix())
+	if v, ok := me.bitDepths.Load(key); ok {
+		return v.(int)
+	}
+
+	ret := me.sceneServer.VideoBitDepth(f)
+	me.bitDepths.Store(key, ret)
+	return ret
+}
+
+// playback is how a file is served to a renderer
+type playback int
+
+const (
+	// playDirect serves the file as it is
+	playDirect playback = iota
+	// playRemux copies the video stream into a container the renderer plays
+	playRemux
+	// playTranscode encodes the video in a format the renderer plays
+	playTranscode
+)
+
+// playback returns how the file is best served to the renderer. bitDepth
+// returns the bit depth of the video, and is only called if the profile
+// limits it.
+func (p *RendererProfile) playback(f *models.VideoFile, bitDepth func() int) playback {
+	if p == nil {
+		return playDirect
+	}
+
+	if !allows(p.VideoCodecs, f.VideoCodec) {
+		return playTranscode
+	}
+
+	if p.MaxResolution != nil {
+		if limit := p.MaxResolution.GetMaxResolution(); limit > 0 && min(f.Width, f.Height) > limit {
+			return playTranscode
+		}
+	}
+
+	if p.MaxBitDepth > 0 && bitDepth() > p.MaxBitDepth {
+		return playTranscode
+	}
+
+	if !allows(p.Containers, f.Format) || (f.AudioCodec != "" && !allows(p.AudioCodecs, f.AudioCodec)) {
+		return playRemux
+	}
+
+	return playDirect
+}
+
+// parseTimeSeekRange returns the start of the TimeSeekRange.dlna.org header
+// value, such as "npt=417.33-" or "npt=00:06:57.330-00:10:00".
+func parseTimeSeekRange(v string) (time.Duration, error) {
+	v = strings.TrimSpace(v)
+	npt, ok := strings.CutPrefix(v, "npt=")
+	if !ok {
+		return 0, fmt.Errorf("invalid time seek range: %q", v)
+	}
+
+	start, _, _ := strings.Cut(npt, "-")
+	return parseNPTTime(start)
+}
+
+// parseNPTTime parses a normal play time, given either in seconds or as
+// hours:minutes:seconds.
+func parseNPTTime(v string) (time.Duration, error) {
+	if v == "" {
+		return 0, nil
+	}
+
+	var secs float64
+	for _, part := range strings.Split(v, ":") {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid npt time: %q", v)
+		}
+		secs = secs*60 + n
+	}
+
+	return time.Duration(secs * float64(time.Second)), nil
+}
@@ -0,0 +1,106 @@
+package dlna
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchRendererProfile(t *testing.T) {
+	configured := []*RendererProfile{
+		{Name: "Bedroom TV", UserAgent: "SEC_HHP_", FriendlyName: "bedroom"},
+	}
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+	}{
+		{"unknown", map[string]string{"User-Agent": "VLC/3.0"}, ""},
+		{"configured first", map[string]string{"User-Agent": "SEC_HHP_[TV] Samsung/1.0", "FriendlyName.DLNA.ORG": "Bedroom"}, "Bedroom TV"},
+		{"built-in by user agent", map[string]string{"User-Agent": "SEC_HHP_[TV] Samsung/1.0", "FriendlyName.DLNA.ORG": "Lounge"}, "Samsung TV"},
+		{"built-in by friendly name", map[string]string{"X-AV-Client-Info": `av=5.0; cn="Sony Corporation"; mn="BRAVIA KD-55X85J"`}, "Sony Bravia"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := http.NewRequest(http.MethodPost, "/ctl", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			got := matchRendererProfile(configured, r)
+			if tt.want == "" {
+				assert.Nil(t, got)
+			} else if assert.NotNil(t, got) {
+				assert.Equal(t, tt.want, got.Name)
+			}
+		})
+	}
+}
+
+func TestRendererProfilePlayback(t *testing.T) {
+	fullHD := models.StreamingResolutionEnumFullHd
+	profile := &RendererProfile{
+		Containers:    []string{"mp4", "ts"},
+		VideoCodecs:   []string{"avc"},
+		AudioCodecs:   []string{"aac"},
+		MaxResolution: &fullHD,
+		MaxBitDepth:   8,
+	}
+
+	h264 := func(format string, height int, audio string) *models.VideoFile {
+		return &models.VideoFile{Format: format, VideoCodec: "h264", AudioCodec: audio, Width: height * 16 / 9, Height: height}
+	}
+
+	tests := []struct {
+		name     string
+		profile  *RendererProfile
+		f        *models.VideoFile
+		bitDepth int
+		want     playback
+	}{
+		{"unknown renderer", nil, &models.VideoFile{Format: "matroska", VideoCodec: "hevc"}, 10, playDirect},
+		{"compatible", profile, h264("mp4", 1080, "aac"), 8, playDirect},
+		{"aliased container", profile, h264("mpegts", 720, "aac"), 8, playDirect},
+		{"no audio", profile, h264("mp4", 720, ""), 8, playDirect},
+		{"container", profile, h264("matroska", 1080, "aac"), 8, playRemux},
+		{"audio codec", profile, h264("mp4", 1080, "opus"), 8, playRemux},
+		{"video codec", profile, &models.VideoFile{Format: "mp4", VideoCodec: "hevc", Width: 1920, Height: 1080}, 8, playTranscode},
+		{"resolution", profile, h264("mp4", 2160, "aac"), 8, playTranscode},
+		{"bit depth", profile, h264("matroska", 1080, "aac"), 10, playTranscode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.profile.playback(tt.f, func() int { return tt.bitDepth })
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseTimeSeekRange(t *testing.T) {
+	tests := []struct {
+		v       string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"npt=0-", 0, false},
+		{"npt=417.33-", 417330 * time.Millisecond, false},
+		{"npt=00:06:57.330-00:10:00", 417330 * time.Millisecond, false},
+		{"npt=1:02:03-", time.Hour + 2*time.Minute + 3*time.Second, false},
+		{"bytes=0-", 0, true},
+		{"npt=abc-", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.v, func(t *testing.T) {
+			got, err := parseTimeSeekRange(tt.v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseTimeSeekRange() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.InDelta(t, tt.want, got, float64(time.Millisecond))
+		})
+	}
+}
@@ -2,13 +2,13 @@ package dlna
 
 import (
 	"context"
-	"fmt"
 	"net"
 	"net/http"
 	"path/filepath"
 	"sync"
 	"time"
 
+	"github.com/stashapp/stash/pkg/ffmpeg"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/txn"
@@ -23,6 +23,15 @@ type Repository struct {
 	TagFinder       TagFinder
 	PerformerFinder PerformerFinder
 	GroupFinder     GroupFinder
+
+	// UserScoper scopes requests to the configured DLNA user, if set.
+	UserScoper UserScoper
+}
+
+// UserScoper scopes a request context to a user of the library, limiting the
+// scenes visible to the content that user may see.
+type UserScoper interface {
+	ScopeToUser(ctx context.Context, username string) (context.Context, error)
 }
 
 func NewRepository(repo models.Repository) Repository {
@@ -69,6 +78,14 @@ type dmsConfig struct {
 type sceneServer interface {
 	StreamSceneDirect(scene *models.Scene, w http.ResponseWriter, r *http.Request)
 	ServeScreenshot(scene *models.Scene, w http.ResponseWriter, r *http.Request)
+	// ServeTranscode serves a live transcode of a video file
+	ServeTranscode(w http.ResponseWriter, r *http.Request, options ffmpeg.TranscodeOptions)
+	// VideoBitDepth returns the bit depth of the video in the file, or 0 if
+	// it is not known
+	VideoBitDepth(f *models.VideoFile) int
+	// TrickPlayBIF returns the path of the scene's BIF archive of trick play
+	// frames, or an empty string if it has not been generated
+	TrickPlayBIF(scene *models.Scene) string
 }
 
 type Config interface {
@@ -78,6 +95,8 @@ type Config interface {
 	GetVideoSortOrder() string
 	GetDLNAPortAsString() string
 	GetDLNAActivityTrackingEnabled() bool
+	GetDLNAUser() string
+	GetDLNARendererProfiles() []*RendererProfile
 }
 
 // activityConfig wraps Config to implement ActivityConfig.
@@ -112,38 +131,7 @@ type Service struct {
 }
 
 func (s *Service) getInterfaces() ([]net.Interface, error) {
-	var ifs []net.Interface
-	var err error
-	ifNames := s.config.GetDLNAInterfaces()
-
-	if len(ifNames) == 0 {
-		ifs, err = net.Interfaces()
-	} else {
-		for _, n := range ifNames {
-			if_, err := net.InterfaceByName(n)
-			if err != nil {
-				return nil, fmt.Errorf("error getting interface for name %s: %s", n, err.Error())
-			}
-
-			if if_ != nil {
-				ifs = append(ifs, *if_)
-			}
-		}
-	}
-
-	if err != nil {
-		return nil, err
-	}
-
-	var tmp []net.Interface
-	for _, if_ := range ifs {
-		if if_.Flags&net.FlagUp == 0 || if_.MTU <= 0 {
-			continue
-		}
-		tmp = append(tmp, if_)
-	}
-	ifs = tmp
-	return ifs, nil
+	return Interfaces(s.config.GetDLNAInterfaces())
 }
 
 func (s *Service) init() error {
@@ -172,6 +160,7 @@ func (s *Service) init() error {
 		sceneServer:        s.sceneServer,
 		ipWhitelistManager: s.ipWhitelistMgr,
 		activityTracker:    s.activityTracker,
+		config:             s.config,
 		Interfaces:         interfaces,
 		HTTPConn: func() net.Listener {
 			conn, err := net.Listen("tcp", dmsConfig.Http)
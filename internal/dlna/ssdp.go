@@ -0,0 +1,135 @@
+package dlna
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/anacrolix/dms/ssdp"
+
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+// An interface with these flags should be valid for SSDP.
+const ssdpInterfaceFlags = net.FlagUp | net.FlagMulticast
+
+// SSDPAnnouncer advertises a UPnP root device over SSDP. It is what makes the
+// DLNA server show up on renderers without being configured, and is shared
+// with anything else that needs to be discovered the same way.
+type SSDPAnnouncer struct {
+	Interfaces []net.Interface
+	Devices    []string
+	Services   []string
+	// Location returns the URL of the device description, as reachable from
+	// the given local address.
+	Location func(ip net.IP) string
+	Server   string
+	UUID     string
+	// Time interval between SSDP announces
+	NotifyInterval time.Duration
+}
+
+// Run announces on every interface until closed is closed.
+func (a SSDPAnnouncer) Run(closed <-chan struct{}) {
+	active := 0
+	stopped := make(chan struct{})
+	for _, if_ := range a.Interfaces {
+		active++
+		go func(if_ net.Interface) {
+			defer func() {
+				stopped <- struct{}{}
+			}()
+			a.runInterface(if_, closed)
+		}(if_)
+	}
+	for active > 0 {
+		<-stopped
+		active--
+	}
+}
+
+// Run SSDP server on an interface.
+func (a SSDPAnnouncer) runInterface(if_ net.Interface, closed <-chan struct{}) {
+	s := ssdp.Server{
+		Interface:      if_,
+		Devices:        a.Devices,
+		Services:       a.Services,
+		Location:       a.Location,
+		Server:         a.Server,
+		UUID:           a.UUID,
+		NotifyInterval: a.NotifyInterval,
+	}
+	if err := s.Init(); err != nil {
+		if if_.Flags&ssdpInterfaceFlags != ssdpInterfaceFlags {
+			// Didn't expect it to work anyway.
+			return
+		}
+		if strings.Contains(err.Error(), "listen") {
+			// OSX has a lot of dud interfaces. Failure to create a socket on
+			// the interface are what we're expecting if the interface is no
+			// good.
+			return
+		}
+		logger.Errorf("error creating ssdp server on %s: %s", if_.Name, err)
+		return
+	}
+	defer s.Close()
+	logger.Debugf("started SSDP on %s", if_.Name)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		// FIXME - this currently blocks forever unless it encounters an error
+		// See https://github.com/anacrolix/dms/pull/150
+		// Needs to be fixed upstream
+		//nolint:staticcheck
+		if err := s.Serve(); err != nil {
+			logger.Errorf("%q: %q\n", if_.Name, err)
+		}
+	}()
+	select {
+	case <-closed:
+		// Returning will close the server.
+	case <-stopped:
+	}
+}
+
+// Interfaces returns the named network interfaces, or all of them if names is
+// empty, leaving out any that are down.
+func Interfaces(names []string) ([]net.Interface, error) {
+	var ifs []net.Interface
+	var err error
+
+	if len(names) == 0 {
+		ifs, err = net.Interfaces()
+	} else {
+		for _, n := range names {
+			if_, err := net.InterfaceByName(n)
+			if err != nil {
+				return nil, fmt.Errorf("error getting interface for name %s: %s", n, err.Error())
+			}
+
+			if if_ != nil {
+				ifs = append(ifs, *if_)
+			}
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var tmp []net.Interface
+	for _, if_ := range ifs {
+		if if_.Flags&net.FlagUp == 0 || if_.MTU <= 0 {
+			continue
+		}
+		tmp = append(tmp, if_)
+	}
+	return tmp, nil
+}
+
+// DeviceUUID derives a stable UPnP device UUID from a unique string.
+func DeviceUUID(unique string) string {
+	return makeDeviceUuid(unique)
+}
@@ -1,6 +1,10 @@
 package manager
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"time"
 
@@ -53,3 +57,30 @@ func GetUserIDFromAPIKey(apiKey string) (string, error) {
 
 	return claims.UserID, nil
 }
+
+// namedAPIKeyPrefix marks named API keys, telling them apart from the
+// legacy per-user keys
+const namedAPIKeyPrefix = "stash_"
+
+// apiKeyLastUsedInterval limits how often the last used time of an API key
+// is written, since streams authenticate every segment request
+const apiKeyLastUsedInterval = time.Minute
+
+// NewNamedAPIKey returns a new random named API key, along with the prefix
+// shown to tell keys apart and the hash stored in the database.
+func NewNamedAPIKey() (key, prefix, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+
+	key = namedAPIKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	prefix = key[:len(namedAPIKeyPrefix)+6]
+	return key, prefix, HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the hash of a named API key, as stored in the database.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,54 @@
+package manager
+
+import (
+	"context"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+// auditLogPruneInterval is how often audit log entries older than the
+// retention period are deleted
+const auditLogPruneInterval = 24 * time.Hour
+
+// runAuditLogPruner deletes the audit log entries older than the configured
+// retention period, now and then once a day.
+func (s *Manager) runAuditLogPruner(ctx context.Context) {
+	ticker := time.NewTicker(auditLogPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		s.pruneAuditLog(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pruneAuditLog deletes the audit log entries older than the configured
+// retention period. Entries are kept forever if the period is 0 or less.
+func (s *Manager) pruneAuditLog(ctx context.Context) {
+	days := s.Config.GetAuditLogRetentionDays()
+	if days <= 0 {
+		return
+	}
+
+	before := time.Now().AddDate(0, 0, -days)
+
+	var deleted int64
+	if err := s.Repository.WithTxn(ctx, func(ctx context.Context) error {
+		var err error
+		deleted, err = s.Repository.AuditEntry.DestroyBefore(ctx, before)
+		return err
+	}); err != nil {
+		logger.Warnf("Error pruning audit log: %v", err)
+		return
+	}
+
+	if deleted > 0 {
+		logger.Infof("Pruned %d audit log entries older than %d days", deleted, days)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/knadh/koanf/providers/file"
 	"github.com/knadh/koanf/v2"
 
+	"github.com/stashapp/stash/internal/dlna"
 	"github.com/stashapp/stash/internal/identify"
 	"github.com/stashapp/stash/pkg/fsutil"
 	"github.com/stashapp/stash/pkg/hash"
@@ -45,6 +46,9 @@ const (
 	Password            = "password"
 	MaxSessionAge       = "max_session_age"
 
+	// AuditLogRetentionDays is the number of days audit log entries are kept
+	AuditLogRetentionDays = "audit_log_retention_days"
+
 	// SFWContentMode mode config key
 	SFWContentMode = "sfw_content_mode"
 
@@ -55,6 +59,8 @@ const (
 
 	DefaultMaxSessionAge = 60 * 60 * 1 // 1 hours
 
+	DefaultAuditLogRetentionDays = 90
+
 	Database = "database"
 
 	Exclude      = "exclude"
@@ -75,6 +81,7 @@ const (
 
 	MaxTranscodeSize          = "max_transcode_size"
 	MaxStreamingTranscodeSize = "max_streaming_transcode_size"
+	OptimizedVersionProfiles  = "optimized_version_profiles"
 
 	// ffmpeg extra args options
 	TranscodeInputArgs      = "ffmpeg.transcode.input_args"
@@ -145,6 +152,14 @@ const (
 
 	PythonPath = "python_path"
 
+	// VisualEmbeddingBackend selects the backend computing the visual
+	// embeddings of scenes: "histogram" or "sidecar"
+	VisualEmbeddingBackend        = "visual_embedding.backend"
+	VisualEmbeddingBackendDefault = "histogram"
+	// VisualEmbeddingModel is the ONNX file or open_clip model name run by
+	// the sidecar backend
+	VisualEmbeddingModel = "visual_embedding.model"
+
 	// plugin options
 	PluginsPath          = "plugins_path"
 	PluginsSetting       = "plugins.settings"
@@ -241,6 +256,32 @@ const (
 	SecurityTripwireAccessedFromPublicInternet        = "security_tripwire_accessed_from_public_internet"
 	securityTripwireAccessedFromPublicInternetDefault = ""
 
+	// Single sign-on through an OpenID Connect provider
+	OIDCIssuerURL            = "oidc.issuer_url"
+	OIDCClientID             = "oidc.client_id"
+	OIDCClientSecret         = "oidc.client_secret"
+	OIDCRedirectURL          = "oidc.redirect_url"
+	OIDCScopes               = "oidc.scopes"
+	OIDCUsernameClaim        = "oidc.username_claim"
+	oidcUsernameClaimDefault = "preferred_username"
+	OIDCGroupsClaim          = "oidc.groups_claim"
+	oidcGroupsClaimDefault   = "groups"
+
+	// Users authenticated by a trusted reverse proxy such as Authelia
+	ProxyAuthUserHeader          = "proxy_auth.user_header"
+	proxyAuthUserHeaderDefault   = "Remote-User"
+	ProxyAuthGroupsHeader        = "proxy_auth.groups_header"
+	proxyAuthGroupsHeaderDefault = "Remote-Groups"
+	ProxyAuthTrustedProxies      = "proxy_auth.trusted_proxies"
+
+	// Provisioning of single sign-on users
+	SSOAutoProvision        = "sso.auto_provision"
+	ssoAutoProvisionDefault = true
+	SSOLinkExistingUsers    = "sso.link_existing_users"
+	SSOGroupRoles           = "sso.group_roles"
+	SSODefaultRole          = "sso.default_role"
+	ssoDefaultRoleDefault   = "viewer"
+
 	sslCertPath = "ssl_cert_path"
 	sslKeyPath  = "ssl_key_path"
 
@@ -256,6 +297,8 @@ const (
 	DLNADefaultEnabled     = "dlna.default_enabled"
 	DLNADefaultIPWhitelist = "dlna.default_whitelist"
 	DLNAInterfaces         = "dlna.interfaces"
+	DLNAUser               = "dlna.user"
+	DLNARendererProfiles   = "dlna.renderer_profiles"
 
 	DLNAVideoSortOrder        = "dlna.video_sort_order"
 	dlnaVideoSortOrderDefault = "title"
@@ -1111,6 +1154,20 @@ func (i *Config) GetPythonPath() string {
 	return i.getString(PythonPath)
 }
 
+// GetVisualEmbeddingBackend returns the backend used to compute the visual
+// embeddings of scenes.
+func (i *Config) GetVisualEmbeddingBackend() string {
+	ret := i.getString(VisualEmbeddingBackend)
+	if ret == "" {
+		ret = VisualEmbeddingBackendDefault
+	}
+	return ret
+}
+
+func (i *Config) GetVisualEmbeddingModel() string {
+	return i.getString(VisualEmbeddingModel)
+}
+
 func (i *Config) GetHost() string {
 	ret := i.getString(Host)
 	if ret == "" {
@@ -1213,6 +1270,17 @@ func (i *Config) GetMaxTranscodeSize() models.StreamingResolutionEnum {
 	return models.StreamingResolutionEnum(ret)
 }
 
+// GetOptimizedVersionProfiles returns the profiles of the versions of scenes
+// that are transcoded ahead of time.
+func (i *Config) GetOptimizedVersionProfiles() []*models.OptimizedVersionProfile {
+	var ret []*models.OptimizedVersionProfile
+	if err := i.unmarshalKey(OptimizedVersionProfiles, &ret); err != nil {
+		logger.Warnf("error in unmarshalkey: %v", err)
+	}
+
+	return ret
+}
+
 func (i *Config) GetMaxStreamingTranscodeSize() models.StreamingResolutionEnum {
 	ret := i.getString(MaxStreamingTranscodeSize)
 
@@ -1352,6 +1420,21 @@ func (i *Config) GetMaxSessionAge() int {
 	return ret
 }
 
+// GetAuditLogRetentionDays gets the number of days audit log entries are
+// kept. Entries are kept forever if it is 0 or less.
+func (i *Config) GetAuditLogRetentionDays() int {
+	i.RLock()
+	defer i.RUnlock()
+
+	ret := DefaultAuditLogRetentionDays
+	v := i.forKey(AuditLogRetentionDays)
+	if v.Exists(AuditLogRetentionDays) {
+		ret = v.Int(AuditLogRetentionDays)
+	}
+
+	return ret
+}
+
 // GetCustomServedFolders gets the map of custom paths to their applicable
 // filesystem locations
 func (i *Config) GetCustomServedFolders() utils.URLMap {
@@ -1783,6 +1866,105 @@ func (i *Config) GetSecurityTripwireAccessedFromPublicInternet() string {
 	return i.getString(SecurityTripwireAccessedFromPublicInternet)
 }
 
+// GetOIDCIssuerURL returns the URL of the OpenID Connect provider used for
+// single sign-on. Single sign-on is disabled if empty.
+func (i *Config) GetOIDCIssuerURL() string {
+	return i.getString(OIDCIssuerURL)
+}
+
+func (i *Config) GetOIDCClientID() string {
+	return i.getString(OIDCClientID)
+}
+
+func (i *Config) GetOIDCClientSecret() string {
+	return i.getString(OIDCClientSecret)
+}
+
+// GetOIDCRedirectURL returns the callback URL registered with the provider.
+// If empty, it is derived from the URL stash was accessed with.
+func (i *Config) GetOIDCRedirectURL() string {
+	return i.getString(OIDCRedirectURL)
+}
+
+// GetOIDCScopes returns the scopes requested in addition to openid.
+func (i *Config) GetOIDCScopes() []string {
+	ret := i.getStringSlice(OIDCScopes)
+	if len(ret) == 0 {
+		ret = []string{"profile", "email", "groups"}
+	}
+	return ret
+}
+
+// GetOIDCUsernameClaim returns the ID token claim holding the username.
+func (i *Config) GetOIDCUsernameClaim() string {
+	if ret := i.getString(OIDCUsernameClaim); ret != "" {
+		return ret
+	}
+	return oidcUsernameClaimDefault
+}
+
+// GetOIDCGroupsClaim returns the ID token claim holding the groups of the
+// user, which are mapped onto roles with GetSSOGroupRoles.
+func (i *Config) GetOIDCGroupsClaim() string {
+	if ret := i.getString(OIDCGroupsClaim); ret != "" {
+		return ret
+	}
+	return oidcGroupsClaimDefault
+}
+
+// GetProxyAuthUserHeader returns the header a trusted reverse proxy sets to
+// the name of the authenticated user.
+func (i *Config) GetProxyAuthUserHeader() string {
+	if ret := i.getString(ProxyAuthUserHeader); ret != "" {
+		return ret
+	}
+	return proxyAuthUserHeaderDefault
+}
+
+// GetProxyAuthGroupsHeader returns the header a trusted reverse proxy sets to
+// the comma-separated groups of the authenticated user.
+func (i *Config) GetProxyAuthGroupsHeader() string {
+	if ret := i.getString(ProxyAuthGroupsHeader); ret != "" {
+		return ret
+	}
+	return proxyAuthGroupsHeaderDefault
+}
+
+// GetProxyAuthTrustedProxies returns the IP addresses and CIDR ranges of the
+// reverse proxies whose user header is trusted. The header is ignored if
+// empty.
+func (i *Config) GetProxyAuthTrustedProxies() []string {
+	return i.getStringSlice(ProxyAuthTrustedProxies)
+}
+
+// GetSSOAutoProvision returns true if users authenticated by single sign-on
+// are created on their first login.
+func (i *Config) GetSSOAutoProvision() bool {
+	return i.getBoolDefault(SSOAutoProvision, ssoAutoProvisionDefault)
+}
+
+// GetSSOLinkExistingUsers returns true if a local user that is not linked to
+// single sign-on is linked on the first single sign-on login with their
+// username. Otherwise such logins are refused.
+func (i *Config) GetSSOLinkExistingUsers() bool {
+	return i.getBool(SSOLinkExistingUsers)
+}
+
+// GetSSOGroupRoles returns the mapping of single sign-on groups onto roles.
+// Values are admin, viewer or the name of a custom role.
+func (i *Config) GetSSOGroupRoles() map[string]string {
+	return i.getStringMapString(SSOGroupRoles)
+}
+
+// GetSSODefaultRole returns the role of single sign-on users not in a mapped
+// group: admin, viewer, the name of a custom role, or none to deny them.
+func (i *Config) GetSSODefaultRole() string {
+	if ret := i.getString(SSODefaultRole); ret != "" {
+		return ret
+	}
+	return ssoDefaultRoleDefault
+}
+
 // GetDLNAServerName returns the visible name of the DLNA server. If empty,
 // "stash" will be used.
 func (i *Config) GetDLNAServerName() string {
@@ -1806,6 +1988,24 @@ func (i *Config) GetDLNAInterfaces() []string {
 	return i.getStringSlice(DLNAInterfaces)
 }
 
+// GetDLNAUser returns the name of the user that DLNA clients browse as. The
+// user's content restriction applies to the DLNA content directory. If
+// empty, DLNA clients see the whole library.
+func (i *Config) GetDLNAUser() string {
+	return i.getString(DLNAUser)
+}
+
+// GetDLNARendererProfiles returns the profiles of DLNA renderers, which are
+// matched before the built-in profiles.
+func (i *Config) GetDLNARendererProfiles() []*dlna.RendererProfile {
+	var ret []*dlna.RendererProfile
+	if err := i.unmarshalKey(DLNARendererProfiles, &ret); err != nil {
+		logger.Warnf("error in unmarshalkey: %v", err)
+	}
+
+	return ret
+}
+
 // GetDLNAPort returns the port to run the DLNA server on. If empty, 1338
 // will be used.
 func (i *Config) GetDLNAPort() int {
@@ -2151,6 +2351,11 @@ type ScheduledTaskConfig struct {
 	Enabled      bool    `json:"enabled"`
 	Options      string  `json:"options,omitempty"`
 	LastRun      *string `json:"lastRun,omitempty"`
+
+	Then          []string `json:"then,omitempty"`
+	Retries       int      `json:"retries,omitempty"`
+	RetryDelay    int      `json:"retryDelay,omitempty"`
+	SkipIfRunning bool     `json:"skipIfRunning,omitempty"`
 }
 
 func (i *Config) GetScheduledTasks() []ScheduledTaskConfig {
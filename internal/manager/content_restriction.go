@@ -0,0 +1,104 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/savedfilter"
+)
+
+// ContentRestrictions resolves the content restrictions of users into the
+// scene filters applied to their requests.
+type ContentRestrictions struct {
+	Repository models.Repository
+}
+
+// ContentRestrictions returns the content restrictions of the library users.
+func (s *Manager) ContentRestrictions() ContentRestrictions {
+	return ContentRestrictions{Repository: s.Repository}
+}
+
+// SceneRestriction returns the filter limiting the scenes visible to the
+// user, or nil if the user may see the whole library.
+func (c ContentRestrictions) SceneRestriction(ctx context.Context, userID int) (*models.SceneFilterType, error) {
+	r := c.Repository
+
+	var ret *models.SceneFilterType
+	if err := r.WithReadTxn(ctx, func(ctx context.Context) error {
+		restriction, err := r.User.GetContentRestriction(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if restriction.IsEmpty() {
+			return nil
+		}
+
+		var saved *models.SceneFilterType
+		if restriction.SavedFilterID != nil {
+			f, err := r.SavedFilter.Find(ctx, *restriction.SavedFilterID)
+			if err != nil {
+				return err
+			}
+			if f == nil {
+				return fmt.Errorf("saved filter %d not found", *restriction.SavedFilterID)
+			}
+
+			saved, err = savedfilter.SceneFilter(f)
+			if err != nil {
+				return fmt.Errorf("converting saved filter %q: %w", f.Name, err)
+			}
+		}
+
+		ret = restriction.SceneFilter(saved)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("getting content restriction for user %d: %w", userID, err)
+	}
+
+	return ret, nil
+}
+
+// Apply returns a copy of ctx in which scene reads only return the scenes
+// visible to the user.
+func (c ContentRestrictions) Apply(ctx context.Context, userID int) (context.Context, error) {
+	f, err := c.SceneRestriction(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if f != nil {
+		ctx = models.WithSceneRestriction(ctx, f)
+	}
+
+	return ctx, nil
+}
+
+// ScopeToUser returns a copy of ctx acting as the named user, for services
+// such as DLNA that have no login of their own. Scene reads are limited to
+// the scenes visible to the user, and user data such as ratings is read for
+// them. ctx is returned unchanged if username is empty.
+func (c ContentRestrictions) ScopeToUser(ctx context.Context, username string) (context.Context, error) {
+	if username == "" {
+		return ctx, nil
+	}
+
+	r := c.Repository
+
+	var user *models.User
+	if err := r.WithReadTxn(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = r.User.FindByUsername(ctx, username)
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("finding user %q: %w", username, err)
+	}
+
+	if user == nil || !user.IsActive {
+		return nil, fmt.Errorf("user %q not found or inactive", username)
+	}
+
+	ctx = models.WithUserID(ctx, user.ID)
+	return c.Apply(ctx, user.ID)
+}
@@ -1,6 +1,9 @@
 package manager
 
 import (
+	"errors"
+	"io"
+	"io/fs"
 	"net/http"
 	"os"
 	"sync"
@@ -22,6 +25,10 @@ type storeFile struct {
 	keep        bool
 	wg          sync.WaitGroup
 	once        sync.Once
+
+	// served is called once the whole file has been downloaded
+	served     func()
+	servedOnce sync.Once
 }
 
 func NewDownloadStore() *DownloadStore {
@@ -31,6 +38,26 @@ func NewDownloadStore() *DownloadStore {
 }
 
 func (s *DownloadStore) RegisterFile(fp string, contentType string, keep bool) (string, error) {
+	return s.register(&storeFile{
+		path:        fp,
+		contentType: contentType,
+		keep:        keep,
+	})
+}
+
+// RegisterFileOnServed registers a file like RegisterFile, calling served
+// once the whole file has been downloaded. It is not called for downloads of
+// part of the file, or those that are interrupted.
+func (s *DownloadStore) RegisterFileOnServed(fp string, contentType string, keep bool, served func()) (string, error) {
+	return s.register(&storeFile{
+		path:        fp,
+		contentType: contentType,
+		keep:        keep,
+		served:      served,
+	})
+}
+
+func (s *DownloadStore) register(f *storeFile) (string, error) {
 	const keyLength = 4
 	const attempts = 100
 
@@ -41,6 +68,8 @@ func (s *DownloadStore) RegisterFile(fp string, contentType string, keep bool) (
 	a := 0
 
 	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	for generate && a < attempts {
 		var err error
 		h, err = hash.GenerateRandomKey(keyLength)
@@ -51,16 +80,21 @@ func (s *DownloadStore) RegisterFile(fp string, contentType string, keep bool) (
 		a++
 	}
 
-	s.m[h] = &storeFile{
-		path:        fp,
-		contentType: contentType,
-		keep:        keep,
-	}
-	s.mutex.Unlock()
+	s.m[h] = f
 
 	return h, nil
 }
 
+// Has returns true if the file registered with the hash can still be
+// downloaded.
+func (s *DownloadStore) Has(hash string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, ok := s.m[hash]
+	return ok
+}
+
 func (s *DownloadStore) Serve(hash string, w http.ResponseWriter, r *http.Request) {
 	s.mutex.Lock()
 	f, ok := s.m[hash]
@@ -81,7 +115,40 @@ func (s *DownloadStore) Serve(hash string, w http.ResponseWriter, r *http.Reques
 		w.Header().Add("Content-Type", f.contentType)
 	}
 	w.Header().Set("Cache-Control", "no-store")
-	http.ServeFile(w, r, f.path)
+
+	if f.served == nil {
+		http.ServeFile(w, r, f.path)
+		return
+	}
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	cw := &countingWriter{ResponseWriter: w}
+	http.ServeFile(cw, r, f.path)
+	if cw.status == http.StatusOK && cw.written == info.Size() && r.Context().Err() == nil {
+		f.servedOnce.Do(f.served)
+	}
+}
+
+// Remove unregisters the file with the hash and deletes it, whether or not
+// it has been downloaded.
+func (s *DownloadStore) Remove(hash string) {
+	s.mutex.Lock()
+	f, ok := s.m[hash]
+	delete(s.m, hash)
+	s.mutex.Unlock()
+
+	if !ok {
+		return
+	}
+
+	if err := os.Remove(f.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		logger.Errorf("error removing %s: %v", f.path, err)
+	}
 }
 
 func (s *DownloadStore) waitAndRemoveFile(hash string, r *http.Request) {
@@ -105,6 +172,11 @@ func (s *DownloadStore) waitAndRemoveFile(hash string, r *http.Request) {
 		s.mutex.Lock()
 		defer s.mutex.Unlock()
 
+		// it may have been removed while it was being downloaded
+		if s.m[hash] != f {
+			return
+		}
+
 		delete(s.m, hash)
 		err := os.Remove(f.path)
 		if err != nil {
@@ -112,3 +184,36 @@ func (s *DownloadStore) waitAndRemoveFile(hash string, r *http.Request) {
 		}
 	})
 }
+
+// countingWriter counts the bytes of the body of a response, and records its
+// status.
+type countingWriter struct {
+	http.ResponseWriter
+	status  int
+	written int64
+}
+
+func (w *countingWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.written += int64(n)
+	return n, err
+}
+
+// ReadFrom lets the file be copied with the ResponseWriter's ReadFrom, which
+// can send it without copying it through user space.
+func (w *countingWriter) ReadFrom(r io.Reader) (int64, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := io.Copy(w.ResponseWriter, r)
+	w.written += n
+	return n, err
+}
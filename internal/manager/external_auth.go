@@ -0,0 +1,298 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/session"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	ssoRoleAdmin  = "admin"
+	ssoRoleViewer = "viewer"
+	ssoRoleNone   = "none"
+)
+
+// GetOIDCSettings returns the OpenID Connect provider configuration
+func (a *MultiUserConfigAdapter) GetOIDCSettings() session.OIDCSettings {
+	return session.OIDCSettings{
+		IssuerURL:     a.GetOIDCIssuerURL(),
+		ClientID:      a.GetOIDCClientID(),
+		ClientSecret:  a.GetOIDCClientSecret(),
+		RedirectURL:   a.GetOIDCRedirectURL(),
+		Scopes:        a.GetOIDCScopes(),
+		UsernameClaim: a.GetOIDCUsernameClaim(),
+		GroupsClaim:   a.GetOIDCGroupsClaim(),
+	}
+}
+
+// GetProxyAuthSettings returns the trusted reverse proxy configuration
+func (a *MultiUserConfigAdapter) GetProxyAuthSettings() session.ProxyAuthSettings {
+	return session.ProxyAuthSettings{
+		UserHeader:     a.GetProxyAuthUserHeader(),
+		GroupsHeader:   a.GetProxyAuthGroupsHeader(),
+		TrustedProxies: a.GetProxyAuthTrustedProxies(),
+	}
+}
+
+// externalRole maps the groups of an external identity onto a built-in role
+// and, optionally, the name of a custom role. Admin groups take precedence,
+// then the first group mapped onto a custom role. Identities in no mapped
+// group get defaultRole. ok is false if the identity may not log in.
+func externalRole(groupRoles map[string]string, defaultRole string, groups []string) (role models.UserRole, customRole string, ok bool) {
+	matched := false
+	for _, g := range groups {
+		mapped, found := groupRoles[g]
+		if !found {
+			continue
+		}
+
+		matched = true
+		switch strings.ToLower(mapped) {
+		case ssoRoleAdmin:
+			return models.UserRoleAdmin, "", true
+		case ssoRoleViewer, ssoRoleNone:
+		default:
+			if customRole == "" {
+				customRole = mapped
+			}
+		}
+	}
+
+	if matched {
+		return models.UserRoleViewer, customRole, true
+	}
+
+	switch strings.ToLower(defaultRole) {
+	case ssoRoleNone:
+		return "", "", false
+	case ssoRoleAdmin:
+		return models.UserRoleAdmin, "", true
+	case "", ssoRoleViewer:
+		return models.UserRoleViewer, "", true
+	}
+
+	return models.UserRoleViewer, defaultRole, true
+}
+
+// customRoleID returns the ID of the named custom role, or nil if it does
+// not exist.
+func (a *MultiUserConfigAdapter) customRoleID(ctx context.Context, name string) (*int, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	role, err := a.repository.Role.FindByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		logger.Warnf("Single sign-on role %q does not exist", name)
+		return nil, nil
+	}
+
+	return &role.ID, nil
+}
+
+// ProvisionExternalUser returns the user of an identity authenticated by an
+// OpenID Connect provider or a trusted reverse proxy. Unknown users are
+// created if auto-provisioning is enabled. A local user with the same name is
+// only linked to the identity if linking existing users is enabled. If group
+// roles are configured, the role of existing users is kept in sync with their
+// groups.
+func (a *MultiUserConfigAdapter) ProvisionExternalUser(ctx context.Context, identity session.ExternalIdentity) (*session.UserInfo, error) {
+	groupRoles := a.GetSSOGroupRoles()
+	role, customRole, ok := externalRole(groupRoles, a.GetSSODefaultRole(), identity.Groups)
+	if !ok {
+		logger.Infof("Single sign-on user '%s' is not in an allowed group", identity.Username)
+		return nil, nil
+	}
+
+	// this runs on every request authenticated by a proxy, so only write if
+	// something has changed
+	r := a.repository
+	var (
+		user         *models.User
+		customRoleID *int
+	)
+	if err := r.WithReadTxn(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = r.User.FindByUsername(ctx, identity.Username)
+		if err != nil {
+			return err
+		}
+
+		customRoleID, err = a.customRoleID(ctx, customRole)
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("finding user '%s': %w", identity.Username, err)
+	}
+
+	var err error
+	if user != nil {
+		user, err = a.updateExternalUser(ctx, user, len(groupRoles) > 0, role, customRoleID)
+	} else {
+		user, err = a.createExternalUser(ctx, identity.Username, role, customRoleID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("provisioning user '%s': %w", identity.Username, err)
+	}
+
+	if user == nil {
+		return nil, nil
+	}
+
+	return &session.UserInfo{
+		ID:       user.ID,
+		Username: user.Username,
+		Role:     string(user.Role),
+		APIKey:   user.APIKey,
+		IsActive: user.IsActive,
+	}, nil
+}
+
+// updateExternalUser returns the existing user of an external identity. A
+// local user that is not linked to single sign-on is linked to it if linking
+// is enabled, and nil is returned otherwise, so that an identity provider
+// cannot take over a local account by naming it. If syncRole is true, the
+// roles of the user are updated if they differ from the roles mapped from
+// their groups.
+func (a *MultiUserConfigAdapter) updateExternalUser(ctx context.Context, user *models.User, syncRole bool, role models.UserRole, customRoleID *int) (*models.User, error) {
+	link := !user.ExternalAuth
+	if link && !a.GetSSOLinkExistingUsers() {
+		logger.Warnf("Single sign-on user '%s' matches a local user that is not linked to single sign-on", user.Username)
+		return nil, nil
+	}
+
+	sameCustomRole := (user.CustomRoleID == nil && customRoleID == nil) ||
+		(user.CustomRoleID != nil && customRoleID != nil && *user.CustomRoleID == *customRoleID)
+	syncRole = syncRole && (user.Role != role || !sameCustomRole)
+
+	if !link && !syncRole {
+		return user, nil
+	}
+
+	partial := models.NewUserPartial()
+	if link {
+		partial.ExternalAuth = models.NewOptionalBool(true)
+	}
+	if syncRole {
+		partial.Role = models.NewOptionalString(string(role))
+		if customRoleID != nil {
+			partial.CustomRoleID = models.NewOptionalInt(*customRoleID)
+		} else {
+			partial.CustomRoleID = models.OptionalInt{Set: true, Null: true}
+		}
+	}
+
+	r := a.repository
+	var updated *models.User
+	if err := r.WithTxn(ctx, func(ctx context.Context) error {
+		var err error
+		updated, err = r.User.Update(ctx, user.ID, partial)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	if link {
+		logger.Infof("Linked local user '%s' to single sign-on", user.Username)
+	}
+	if syncRole {
+		logger.Infof("Updated role of single sign-on user '%s' to %s", user.Username, role)
+	}
+
+	return updated, nil
+}
+
+// createExternalUser creates the user of an external identity if
+// auto-provisioning is enabled, returning nil otherwise.
+func (a *MultiUserConfigAdapter) createExternalUser(ctx context.Context, username string, role models.UserRole, customRoleID *int) (*models.User, error) {
+	if !a.GetSSOAutoProvision() {
+		logger.Infof("Single sign-on user '%s' does not exist and auto-provisioning is disabled", username)
+		return nil, nil
+	}
+
+	r := a.repository
+	var user *models.User
+	created := false
+	if err := r.WithTxn(ctx, func(ctx context.Context) error {
+		// another request may have created the user since it was looked up
+		existing, err := r.User.FindByUsername(ctx, username)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			if existing.ExternalAuth {
+				user = existing
+			}
+			return nil
+		}
+
+		count, err := r.User.Count(ctx)
+		if err != nil {
+			return err
+		}
+		firstUser := count == 0
+		if firstUser && role != models.UserRoleAdmin {
+			// as with setup, the first user must be an admin
+			logger.Infof("Single sign-on user '%s' cannot be the first user without the admin role", username)
+			return nil
+		}
+
+		user, err = newExternalUser(username, role, customRoleID)
+		if err != nil {
+			return err
+		}
+		if err := r.User.Create(ctx, user); err != nil {
+			return err
+		}
+		created = true
+
+		// the first admin inherits the single-user watch history and ratings
+		if firstUser {
+			return r.User.ClaimUnownedData(ctx, user.ID)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if created {
+		logger.Infof("Created user '%s' from single sign-on (role: %s)", user.Username, user.Role)
+		a.SetMultiUserEnabled(true)
+	}
+
+	return user, nil
+}
+
+// newExternalUser returns a user for a single sign-on identity. The user gets
+// a random password, so can only log in through single sign-on until an
+// admin sets one.
+func newExternalUser(username string, role models.UserRole, customRoleID *int) (*models.User, error) {
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(uuid.New().String()), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("hashing password: %w", err)
+	}
+
+	apiKey := uuid.New().String()
+	now := time.Now()
+	return &models.User{
+		Username:     username,
+		PasswordHash: string(passwordHash),
+		Role:         role,
+		APIKey:       &apiKey,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		IsActive:     true,
+		CustomRoleID: customRoleID,
+		ExternalAuth: true,
+	}, nil
+}
@@ -0,0 +1,60 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stashapp/stash/internal/manager/config"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func TestExternalRole(t *testing.T) {
+	groupRoles := map[string]string{
+		"stash-admins":  "admin",
+		"stash-editors": "Editors",
+		"stash-viewers": "viewer",
+	}
+
+	tests := []struct {
+		name           string
+		defaultRole    string
+		groups         []string
+		wantRole       models.UserRole
+		wantCustomRole string
+		wantOK         bool
+	}{
+		{"admin group wins", "viewer", []string{"stash-editors", "stash-admins"}, models.UserRoleAdmin, "", true},
+		{"custom role group", "viewer", []string{"family", "stash-editors"}, models.UserRoleViewer, "Editors", true},
+		{"viewer group", "none", []string{"stash-viewers"}, models.UserRoleViewer, "", true},
+		{"unmapped with default viewer", "viewer", []string{"family"}, models.UserRoleViewer, "", true},
+		{"unmapped with default none", "none", []string{"family"}, "", "", false},
+		{"unmapped with default custom role", "Editors", nil, models.UserRoleViewer, "Editors", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			role, customRole, ok := externalRole(groupRoles, tt.defaultRole, tt.groups)
+			if role != tt.wantRole || customRole != tt.wantCustomRole || ok != tt.wantOK {
+				t.Errorf("externalRole() = %q, %q, %v, want %q, %q, %v", role, customRole, ok, tt.wantRole, tt.wantCustomRole, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestUpdateExternalUserWithoutChanges(t *testing.T) {
+	// the repository is empty, so any write fails the test
+	a := &MultiUserConfigAdapter{Config: config.InitializeEmpty()}
+	ctx := context.Background()
+
+	local := &models.User{ID: 1, Username: "alice", Role: models.UserRoleAdmin}
+	got, err := a.updateExternalUser(ctx, local, true, models.UserRoleAdmin, nil)
+	if err != nil || got != nil {
+		t.Errorf("updateExternalUser() of an unlinked local user = %v, %v, want it refused", got, err)
+	}
+
+	linked := &models.User{ID: 2, Username: "bob", Role: models.UserRoleViewer, ExternalAuth: true}
+	got, err = a.updateExternalUser(ctx, linked, true, models.UserRoleViewer, nil)
+	if err != nil || got != linked {
+		t.Errorf("updateExternalUser() of an unchanged user = %v, %v, want the user", got, err)
+	}
+}
@@ -95,9 +95,23 @@ func (g *SpriteGenerator) Generate(ctx context.Context) error {
 	if err := g.generateSpriteVTT(ctx); err != nil {
 		return err
 	}
+
+	// trick play is an extra for native players, so the sprite is still
+	// usable without it
+	if err := g.generateTrickPlay(ctx); err != nil {
+		logger.Warnf("[generator] error generating trick play files for %s: %v", g.Info.VideoFile.Path, err)
+	}
 	return nil
 }
 
+// generateTrickPlay generates the BIF and I-frame files of the scene from the
+// sprite frames.
+func (g *SpriteGenerator) generateTrickPlay(ctx context.Context) error {
+	gen := *g.g
+	gen.Overwrite = g.Overwrite
+	return gen.TrickPlay(ctx, g.VideoChecksum)
+}
+
 func (g *SpriteGenerator) generateSpriteImage(ctx context.Context) error {
 	if !g.Overwrite && g.imageExists() {
 		return nil
@@ -25,6 +25,7 @@ import (
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/models/paths"
 	"github.com/stashapp/stash/pkg/plugin"
+	"github.com/stashapp/stash/pkg/recommendation/embedding"
 	"github.com/stashapp/stash/pkg/scene"
 	"github.com/stashapp/stash/pkg/scraper"
 	"github.com/stashapp/stash/pkg/session"
@@ -81,6 +82,7 @@ func Initialize(cfg *config.Config, l *log.Logger) (*Manager, error) {
 	}
 
 	dlnaRepository := dlna.NewRepository(repo)
+	dlnaRepository.UserScoper = ContentRestrictions{Repository: repo}
 	dlnaService := dlna.NewService(dlnaRepository, cfg, sceneServer, repo.Scene, cfg.GetMinimumPlayPercent())
 
 	mgr := &Manager{
@@ -101,6 +103,8 @@ func Initialize(cfg *config.Config, l *log.Logger) (*Manager, error) {
 
 		DLNAService: dlnaService,
 
+		VisualEmbeddingIndex: embedding.NewIndexCache(),
+
 		Database:   db,
 		Repository: repo,
 
@@ -111,6 +115,7 @@ func Initialize(cfg *config.Config, l *log.Logger) (*Manager, error) {
 
 		scanSubs: &subscriptionManager{},
 	}
+	mgr.SyncBundles = NewSyncBundleStore(mgr.DownloadStore, repo)
 
 	if !cfg.IsNewSystem() {
 		logger.Infof("using config file: %s", cfg.GetConfigFile())
@@ -289,6 +294,11 @@ func (s *Manager) postInit(ctx context.Context) error {
 		go s.scanVRSceneFunscripts(context.Background())
 	}
 
+	// Background: delete audit log entries past the retention period
+	if !migrationNeeded {
+		go s.runAuditLogPruner(context.Background())
+	}
+
 	return nil
 }
 
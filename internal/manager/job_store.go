@@ -0,0 +1,155 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// DatabaseJobStore implements job.Store using the database
+type DatabaseJobStore struct {
+	repository models.Repository
+}
+
+func NewDatabaseJobStore(r models.Repository) *DatabaseJobStore {
+	return &DatabaseJobStore{repository: r}
+}
+
+func (s *DatabaseJobStore) SaveJob(ctx context.Context, j job.StoredJob) error {
+	return s.repository.WithTxn(ctx, func(ctx context.Context) error {
+		return s.repository.QueuedJob.Save(ctx, &models.QueuedJob{
+			ID:          j.ID,
+			Type:        j.Type,
+			Description: j.Description,
+			Input:       j.Input,
+			Checkpoint:  j.Checkpoint,
+			Started:     j.Started,
+			AddedAt:     j.AddTime,
+		})
+	})
+}
+
+func (s *DatabaseJobStore) SetStarted(ctx context.Context, id int) error {
+	return s.repository.WithTxn(ctx, func(ctx context.Context) error {
+		return s.repository.QueuedJob.SetStarted(ctx, id)
+	})
+}
+
+func (s *DatabaseJobStore) SetCheckpoint(ctx context.Context, id int, checkpoint json.RawMessage) error {
+	return s.repository.WithTxn(ctx, func(ctx context.Context) error {
+		return s.repository.QueuedJob.SetCheckpoint(ctx, id, checkpoint)
+	})
+}
+
+func (s *DatabaseJobStore) DeleteJob(ctx context.Context, id int) error {
+	return s.repository.WithTxn(ctx, func(ctx context.Context) error {
+		return s.repository.QueuedJob.Destroy(ctx, id)
+	})
+}
+
+func (s *DatabaseJobStore) AllJobs(ctx context.Context) ([]job.StoredJob, error) {
+	var jobs []*models.QueuedJob
+	if err := s.repository.WithReadTxn(ctx, func(ctx context.Context) error {
+		var err error
+		jobs, err = s.repository.QueuedJob.All(ctx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	ret := make([]job.StoredJob, len(jobs))
+	for i, j := range jobs {
+		ret[i] = job.StoredJob{
+			ID:          j.ID,
+			Type:        j.Type,
+			Description: j.Description,
+			Input:       j.Input,
+			Checkpoint:  j.Checkpoint,
+			Started:     j.Started,
+			AddTime:     j.AddedAt,
+		}
+	}
+	return ret, nil
+}
+
+// RestoreJobs queues the persistent jobs that had not ended when the server
+// last stopped, and saves persistent jobs from then on. Jobs of types
+// registered outside this package must be registered with the JobManager
+// first. It does nothing until the database is ready, so it is called again
+// once setup or a migration has made it so.
+func (s *Manager) RestoreJobs(ctx context.Context) {
+	if s.Database == nil || s.Database.Ready() != nil {
+		return
+	}
+
+	s.registerJobTypes()
+
+	if err := s.JobManager.Restore(ctx, NewDatabaseJobStore(s.Repository)); err != nil {
+		logger.Errorf("Failed to restore jobs: %v", err)
+	}
+}
+
+// registerJobTypes registers the persistent jobs of this package with the
+// JobManager
+func (s *Manager) registerJobTypes() {
+	s.JobManager.RegisterType(scanJobType, func(input, checkpoint json.RawMessage) (job.JobExec, error) {
+		var in ScanMetadataInput
+		if err := json.Unmarshal(input, &in); err != nil {
+			return nil, err
+		}
+		if err := s.validateFFmpeg(); err != nil {
+			return nil, err
+		}
+
+		j := s.newScanJob(in)
+		if checkpoint != nil {
+			var c scanCheckpoint
+			if err := json.Unmarshal(checkpoint, &c); err != nil {
+				return nil, err
+			}
+			j.resumeAfter = c.After
+		}
+		return j, nil
+	})
+
+	s.JobManager.RegisterType(generateJobType, func(input, checkpoint json.RawMessage) (job.JobExec, error) {
+		var in GenerateMetadataInput
+		if err := json.Unmarshal(input, &in); err != nil {
+			return nil, err
+		}
+		if err := s.validateFFmpeg(); err != nil {
+			return nil, err
+		}
+
+		j := &GenerateJob{
+			repository: s.Repository,
+			input:      in,
+		}
+		if checkpoint != nil {
+			var c generateCheckpoint
+			if err := json.Unmarshal(checkpoint, &c); err != nil {
+				return nil, err
+			}
+			j.resumeFrom = c.Done
+		}
+		return j, nil
+	})
+
+	s.JobManager.RegisterType(optimizedVersionsJobType, func(input, checkpoint json.RawMessage) (job.JobExec, error) {
+		var in GenerateOptimizedVersionsInput
+		if err := json.Unmarshal(input, &in); err != nil {
+			return nil, err
+		}
+		if err := s.validateFFmpeg(); err != nil {
+			return nil, err
+		}
+
+		return &GenerateOptimizedVersionsJob{
+			repository: s.Repository,
+			input:      in,
+		}, nil
+	})
+}
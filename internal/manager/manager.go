@@ -25,6 +25,7 @@ import (
 	"github.com/stashapp/stash/pkg/models/paths"
 	"github.com/stashapp/stash/pkg/pkg"
 	"github.com/stashapp/stash/pkg/plugin"
+	"github.com/stashapp/stash/pkg/recommendation/embedding"
 	"github.com/stashapp/stash/pkg/scheduler"
 	"github.com/stashapp/stash/pkg/scraper"
 	"github.com/stashapp/stash/pkg/session"
@@ -62,6 +63,7 @@ type Manager struct {
 	ReadLockManager *fsutil.ReadLockManager
 
 	DownloadStore *DownloadStore
+	SyncBundles   *SyncBundleStore
 	SessionStore  *session.Store
 
 	PluginCache  *plugin.Cache
@@ -72,6 +74,10 @@ type Manager struct {
 
 	DLNAService *dlna.Service
 
+	// VisualEmbeddingIndex caches the nearest-neighbour index of the scene
+	// visual embeddings of each model
+	VisualEmbeddingIndex *embedding.IndexCache
+
 	Database   *sqlite.Database
 	Repository models.Repository
 
@@ -81,6 +87,9 @@ type Manager struct {
 	GroupService   GroupService
 
 	Scheduler *scheduler.Scheduler
+	// APIHubRelink queues the API Hub relink job for the scheduler. The job
+	// belongs to the API server, which sets this as it starts.
+	APIHubRelink func(ctx context.Context) int
 
 	scanSubs *subscriptionManager
 }
@@ -150,6 +159,9 @@ func (s *Manager) RefreshConfig() {
 		if err := fsutil.EnsureDir(s.Paths.Generated.Transcodes); err != nil {
 			logger.Warnf("could not create transcodes directory: %v", err)
 		}
+		if err := fsutil.EnsureDir(s.Paths.Generated.OptimizedVersions); err != nil {
+			logger.Warnf("could not create optimized versions directory: %v", err)
+		}
 		if err := fsutil.EnsureDir(s.Paths.Generated.Downloads); err != nil {
 			logger.Warnf("could not create downloads directory: %v", err)
 		}
@@ -348,6 +360,10 @@ func (s *Manager) Setup(ctx context.Context, input SetupInput) error {
 
 	cfg.FinalizeSetup()
 
+	// the job queue is saved from now on, unless the database must be
+	// migrated first
+	s.RestoreJobs(context.Background())
+
 	return nil
 }
 
@@ -110,6 +110,10 @@ func (s *Manager) Scan(ctx context.Context, input ScanMetadataInput) (int, error
 		return 0, err
 	}
 
+	return s.JobManager.Add(ctx, "Scanning...", s.newScanJob(input)), nil
+}
+
+func (s *Manager) newScanJob(input ScanMetadataInput) *ScanJob {
 	cfg := config.GetInstance()
 
 	scanner := &file.Scanner{
@@ -136,13 +140,11 @@ func (s *Manager) Scan(ctx context.Context, input ScanMetadataInput) (int, error
 		Rescan: input.Rescan,
 	}
 
-	scanJob := ScanJob{
+	return &ScanJob{
 		scanner:       scanner,
 		input:         input,
 		subscriptions: s.scanSubs,
 	}
-
-	return s.JobManager.Add(ctx, "Scanning...", &scanJob), nil
 }
 
 // ScanFileInput is the input for the synchronous ScanFile operation
@@ -773,6 +775,44 @@ func (s *Manager) Generate(ctx context.Context, input GenerateMetadataInput) (in
 	return s.JobManager.Add(ctx, "Generating...", j), nil
 }
 
+// GenerateOptimizedVersions queues a job transcoding scenes with the
+// optimized version profiles.
+func (s *Manager) GenerateOptimizedVersions(ctx context.Context, input GenerateOptimizedVersionsInput) (int, error) {
+	if err := s.validateFFmpeg(); err != nil {
+		return 0, err
+	}
+
+	j := &GenerateOptimizedVersionsJob{
+		repository: s.Repository,
+		input:      input,
+	}
+
+	return s.JobManager.Add(ctx, "Generating optimized versions...", j), nil
+}
+
+// CleanOptimizedVersions queues a job deleting the optimized versions of
+// profiles that have been removed, and files no version refers to.
+func (s *Manager) CleanOptimizedVersions(ctx context.Context) int {
+	j := &CleanOptimizedVersionsJob{
+		repository: s.Repository,
+	}
+
+	return s.JobManager.Add(ctx, "Cleaning optimized versions...", j)
+}
+
+// PrepareSyncBundle queues a job preparing the next bundle of a sync target.
+// A full bundle has the files of every scene, including those the device
+// already has.
+func (s *Manager) PrepareSyncBundle(ctx context.Context, targetID int, full bool) int {
+	j := &SyncBundleJob{
+		repository: s.Repository,
+		targetID:   targetID,
+		full:       full,
+	}
+
+	return s.JobManager.Add(ctx, "Preparing sync bundle...", j)
+}
+
 func (s *Manager) GenerateDefaultScreenshot(ctx context.Context, sceneId string) int {
 	return s.generateScreenshot(ctx, sceneId, nil)
 }
@@ -890,7 +930,36 @@ func (s *Manager) RebuildContentProfile(ctx context.Context) int {
 		Repository: s.Repository,
 	}
 
-	return s.JobManager.Add(ctx, "Rebuilding content profile...", &j)
+	return s.JobManager.Add(ctx, "Rebuilding content profiles...", &j)
+}
+
+// RebuildCoWatchModel starts a job adding the history recorded since the last
+// run to the co-watch model. When full is true, the model is rebuilt from the
+// start of the history.
+func (s *Manager) RebuildCoWatchModel(ctx context.Context, full bool) int {
+	j := RebuildCoWatchJob{
+		Repository: s.Repository,
+		Full:       full,
+	}
+
+	return s.JobManager.Add(ctx, j.GetDescription()+"...", &j)
+}
+
+// GenerateVisualEmbeddings queues a job computing the visual embeddings of
+// the given scenes, or of all scenes if none are given, with the configured
+// backend.
+func (s *Manager) GenerateVisualEmbeddings(ctx context.Context, sceneIDs []int, overwrite bool) int {
+	j := GenerateVisualEmbeddingsJob{
+		Repository:          s.Repository,
+		Backend:             s.visualEmbeddingBackend(),
+		Paths:               s.Paths,
+		FileNamingAlgorithm: s.Config.GetVideoFileNamingAlgorithm(),
+		Index:               s.VisualEmbeddingIndex,
+		SceneIDs:            sceneIDs,
+		Overwrite:           overwrite,
+	}
+
+	return s.JobManager.Add(ctx, "Generating visual embeddings...", &j)
 }
 
 func (s *Manager) MigrateHash(ctx context.Context) int {
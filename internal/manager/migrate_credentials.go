@@ -56,7 +56,12 @@ func (s *Manager) MigrateLegacyCredentials(ctx context.Context) error {
 	}
 
 	if err := s.Repository.WithTxn(ctx, func(ctx context.Context) error {
-		return s.Repository.User.Create(ctx, user)
+		if err := s.Repository.User.Create(ctx, user); err != nil {
+			return err
+		}
+
+		// the migrated admin inherits the single-user watch history and ratings
+		return s.Repository.User.ClaimUnownedData(ctx, user.ID)
 	}); err != nil {
 		return err
 	}
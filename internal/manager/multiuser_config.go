@@ -2,8 +2,11 @@ package manager
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/stashapp/stash/internal/manager/config"
+	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/session"
 	"golang.org/x/crypto/bcrypt"
@@ -71,12 +74,17 @@ func (a *MultiUserConfigAdapter) ValidateUserCredentials(ctx context.Context, us
 	}, nil
 }
 
-// FindUserByAPIKey looks up a user by their API key
+// FindUserByAPIKey looks up a user by their API key, or by one of their
+// named API keys. Named keys limit the user to the scopes of the key.
 func (a *MultiUserConfigAdapter) FindUserByAPIKey(ctx context.Context, apiKey string) (*session.UserInfo, error) {
 	if !a.enabled {
 		return nil, nil
 	}
 
+	if strings.HasPrefix(apiKey, namedAPIKeyPrefix) {
+		return a.findUserByNamedAPIKey(ctx, apiKey)
+	}
+
 	var user *models.User
 	err := a.repository.WithReadTxn(ctx, func(ctx context.Context) error {
 		var findErr error
@@ -101,6 +109,52 @@ func (a *MultiUserConfigAdapter) FindUserByAPIKey(ctx context.Context, apiKey st
 	}, nil
 }
 
+func (a *MultiUserConfigAdapter) findUserByNamedAPIKey(ctx context.Context, apiKey string) (*session.UserInfo, error) {
+	r := a.repository
+
+	var key *models.APIKey
+	var user *models.User
+	if err := r.WithReadTxn(ctx, func(ctx context.Context) error {
+		var err error
+		key, err = r.APIKey.FindByHash(ctx, HashAPIKey(apiKey))
+		if err != nil || key == nil {
+			return err
+		}
+
+		user, err = r.User.Find(ctx, key.UserID)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if key == nil || user == nil || key.IsExpired(now) {
+		return nil, nil
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyLastUsedInterval {
+		if err := r.WithTxn(ctx, func(ctx context.Context) error {
+			return r.APIKey.UpdateLastUsed(ctx, key.ID, now)
+		}); err != nil {
+			logger.Warnf("Error updating last use of API key %q: %v", key.Name, err)
+		}
+	}
+
+	scopes := make([]string, len(key.Scopes))
+	for i, s := range key.Scopes {
+		scopes[i] = string(s)
+	}
+
+	return &session.UserInfo{
+		ID:           user.ID,
+		Username:     user.Username,
+		Role:         string(user.Role),
+		APIKey:       user.APIKey,
+		IsActive:     user.IsActive,
+		APIKeyScopes: scopes,
+	}, nil
+}
+
 // FindUserByUsername looks up a user by username
 func (a *MultiUserConfigAdapter) FindUserByUsername(ctx context.Context, username string) (*session.UserInfo, error) {
 	if !a.enabled {
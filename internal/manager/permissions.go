@@ -0,0 +1,72 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// Permissions resolves the roles of users into the permissions granted to
+// their requests.
+type Permissions struct {
+	Repository models.Repository
+}
+
+// Permissions returns the permissions of the library users.
+func (s *Manager) Permissions() Permissions {
+	return Permissions{Repository: s.Repository}
+}
+
+// userRole returns the user along with their custom role, if any.
+func (p Permissions) userRole(ctx context.Context, userID int) (*models.User, *models.Role, error) {
+	r := p.Repository
+
+	var user *models.User
+	var role *models.Role
+	if err := r.WithReadTxn(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = r.User.Find(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return fmt.Errorf("user %d not found", userID)
+		}
+
+		if user.CustomRoleID != nil {
+			role, err = r.Role.Find(ctx, *user.CustomRoleID)
+		}
+		return err
+	}); err != nil {
+		return nil, nil, fmt.Errorf("getting role of user %d: %w", userID, err)
+	}
+
+	return user, role, nil
+}
+
+// UserPermissions returns the permissions granted to the user by their
+// built-in and custom roles.
+func (p Permissions) UserPermissions(ctx context.Context, userID int) (models.PermissionSet, error) {
+	user, role, err := p.userRole(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return user.Permissions(role), nil
+}
+
+// Apply returns a copy of ctx limited to the permissions of the user. Admins
+// are not limited, and keep access to the operations reserved to them.
+func (p Permissions) Apply(ctx context.Context, userID int) (context.Context, error) {
+	user, role, err := p.userRole(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.IsAdmin() {
+		return ctx, nil
+	}
+
+	return models.WithPermissions(ctx, user.Permissions(role)), nil
+}
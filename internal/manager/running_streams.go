@@ -62,6 +62,44 @@ func (s *SceneServer) StreamSceneDirect(scene *models.Scene, w http.ResponseWrit
 	http.ServeFile(w, r, fp)
 }
 
+// ServeTranscode serves a live transcode of a video file, for clients that
+// cannot play it directly.
+func (s *SceneServer) ServeTranscode(w http.ResponseWriter, r *http.Request, options ffmpeg.TranscodeOptions) {
+	streamManager := GetInstance().StreamManager
+	if streamManager == nil {
+		http.Error(w, "Live transcoding disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	streamManager.ServeTranscode(w, r, options)
+}
+
+// VideoBitDepth probes the video file for the bit depth of its video. It
+// returns 0 if it could not be probed.
+func (s *SceneServer) VideoBitDepth(f *models.VideoFile) int {
+	ffprobe := GetInstance().FFProbe
+	if ffprobe == nil {
+		return 0
+	}
+
+	probed, err := ffprobe.NewVideoFile(f.Path)
+	if err != nil {
+		logger.Warnf("error probing %s: %v", f.Path, err)
+		return 0
+	}
+
+	return probed.BitDepth()
+}
+
+func (s *SceneServer) TrickPlayBIF(scene *models.Scene) string {
+	sceneHash := scene.GetHash(config.GetInstance().GetVideoFileNamingAlgorithm())
+	filepath := GetInstance().Paths.Scene.GetTrickPlayBIFPath(sceneHash)
+	if exists, _ := fsutil.FileExists(filepath); !exists {
+		return ""
+	}
+	return filepath
+}
+
 func (s *SceneServer) ServeScreenshot(scene *models.Scene, w http.ResponseWriter, r *http.Request) {
 	var cover []byte
 	readTxnErr := txn.WithReadTxn(r.Context(), s.TxnManager, func(ctx context.Context) error {
@@ -3,6 +3,7 @@ package manager
 import (
 	"fmt"
 	"net/url"
+	"strconv"
 
 	"github.com/stashapp/stash/internal/manager/config"
 	"github.com/stashapp/stash/pkg/ffmpeg"
@@ -54,6 +55,16 @@ var (
 		mimeType:  ffmpeg.MimeDASH,
 		extension: ".mpd",
 	}
+	hlsAdaptiveEndpointType = endpointType{
+		label:     "HLS Adaptive",
+		mimeType:  ffmpeg.MimeHLS,
+		extension: "_adaptive.m3u8",
+	}
+	dashAdaptiveEndpointType = endpointType{
+		label:     "DASH Adaptive",
+		mimeType:  ffmpeg.MimeDASH,
+		extension: "_adaptive.mpd",
+	}
 )
 
 func GetVideoFileContainer(file *models.VideoFile) (ffmpeg.Container, error) {
@@ -75,7 +86,10 @@ func GetVideoFileContainer(file *models.VideoFile) (ffmpeg.Container, error) {
 	return container, nil
 }
 
-func GetSceneStreamPaths(scene *models.Scene, directStreamURL *url.URL, maxStreamingTranscodeSize models.StreamingResolutionEnum) ([]*SceneStreamEndpoint, error) {
+// GetSceneStreamPaths returns the endpoints the scene can be streamed from, in
+// order of preference. The optimized versions of the scene are offered ahead of
+// live transcodes.
+func GetSceneStreamPaths(scene *models.Scene, directStreamURL *url.URL, maxStreamingTranscodeSize models.StreamingResolutionEnum, optimized []*models.OptimizedVersion) ([]*SceneStreamEndpoint, error) {
 	if scene == nil {
 		return nil, fmt.Errorf("nil scene")
 	}
@@ -164,6 +178,10 @@ func GetSceneStreamPaths(scene *models.Scene, directStreamURL *url.URL, maxStrea
 		endpoints = append(endpoints, makeStreamEndpoint(mkvEndpointType, ""))
 	}
 
+	for _, v := range optimized {
+		endpoints = append(endpoints, makeOptimizedStreamEndpoint(directStreamURL, v))
+	}
+
 	mp4Streams := []*SceneStreamEndpoint{}
 	webmStreams := []*SceneStreamEndpoint{}
 	hlsStreams := []*SceneStreamEndpoint{}
@@ -211,6 +229,13 @@ func GetSceneStreamPaths(scene *models.Scene, directStreamURL *url.URL, maxStrea
 		dashStreams = append(dashStreams, makeStreamEndpoint(dashEndpointType, models.StreamingResolutionEnumLow))
 	}
 
+	// adaptive streams are only worthwhile if there is more than one rendition
+	// to switch between
+	if len(ffmpeg.AdaptiveRenditions(pf, maxStreamingTranscodeSize)) > 1 {
+		hlsStreams = append([]*SceneStreamEndpoint{makeStreamEndpoint(hlsAdaptiveEndpointType, "")}, hlsStreams...)
+		dashStreams = append([]*SceneStreamEndpoint{makeStreamEndpoint(dashAdaptiveEndpointType, "")}, dashStreams...)
+	}
+
 	endpoints = append(endpoints, mp4Streams...)
 	endpoints = append(endpoints, webmStreams...)
 	endpoints = append(endpoints, hlsStreams...)
@@ -219,6 +244,20 @@ func GetSceneStreamPaths(scene *models.Scene, directStreamURL *url.URL, maxStrea
 	return endpoints, nil
 }
 
+func makeOptimizedStreamEndpoint(directStreamURL *url.URL, v *models.OptimizedVersion) *SceneStreamEndpoint {
+	url := *directStreamURL
+	url.Path += "/optimized/" + strconv.Itoa(v.ID)
+
+	mimeType := ffmpeg.MimeMp4Video
+	label := "Optimized " + v.Profile
+
+	return &SceneStreamEndpoint{
+		URL:      url.String(),
+		MimeType: &mimeType,
+		Label:    &label,
+	}
+}
+
 // HasTranscode returns true if a transcoded video exists for the provided
 // scene. It will check using the OSHash of the scene first, then fall back
 // to the checksum.
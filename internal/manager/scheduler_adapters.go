@@ -3,13 +3,23 @@ package manager
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/stashapp/stash/internal/identify"
 	"github.com/stashapp/stash/internal/manager/config"
+	"github.com/stashapp/stash/pkg/job"
 	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/scheduler"
 )
 
+// scheduledTaskRunsKept is the number of runs kept in the history of each
+// scheduled task
+const scheduledTaskRunsKept = 100
+
 // ManagerTaskExecutor adapts the Manager to the scheduler.TaskExecutor interface
 type ManagerTaskExecutor struct {
 	manager *Manager
@@ -63,6 +73,14 @@ func (e *ManagerTaskExecutor) ExecuteOptimise(ctx context.Context) (int, error)
 	return e.manager.OptimiseDatabase(ctx), nil
 }
 
+func (e *ManagerTaskExecutor) ExecuteRebuildProfile(ctx context.Context) (int, error) {
+	return e.manager.RebuildContentProfile(ctx), nil
+}
+
+func (e *ManagerTaskExecutor) ExecuteRebuildCoWatch(ctx context.Context) (int, error) {
+	return e.manager.RebuildCoWatchModel(ctx, false), nil
+}
+
 type PluginTaskInput struct {
 	PluginID    string                 `json:"pluginId"`
 	TaskName    string                 `json:"taskName"`
@@ -88,6 +106,128 @@ func (e *ManagerTaskExecutor) ExecutePlugin(ctx context.Context, options json.Ra
 	return e.manager.RunPluginTask(ctx, input.PluginID, taskName, description, input.Args), nil
 }
 
+// ExecuteIdentify identifies scenes with the sources in options, or with the
+// saved default identify settings if options has none.
+func (e *ManagerTaskExecutor) ExecuteIdentify(ctx context.Context, options json.RawMessage) (int, error) {
+	var input identify.Options
+	if len(options) > 0 {
+		if err := json.Unmarshal(options, &input); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(input.Sources) == 0 {
+		defaults := e.manager.Config.GetDefaultIdentifySettings()
+		if defaults == nil || len(defaults.Sources) == 0 {
+			return 0, errors.New("no identify sources given and no default identify settings saved")
+		}
+		input.Sources = defaults.Sources
+		if input.Options == nil {
+			input.Options = defaults.Options
+		}
+	}
+
+	return e.manager.JobManager.Add(ctx, "Identifying...", CreateIdentifyJob(input)), nil
+}
+
+func (e *ManagerTaskExecutor) ExecuteBackup(ctx context.Context) (int, error) {
+	j := job.MakeJobExec(func(ctx context.Context, progress *job.Progress) error {
+		backupPath, _, err := e.manager.BackupDatabase(false)
+		if err != nil {
+			return err
+		}
+		logger.Infof("Successfully backed up database to: %s", backupPath)
+		return nil
+	})
+	return e.manager.JobManager.Add(ctx, "Backing up database...", j), nil
+}
+
+func (e *ManagerTaskExecutor) ExecuteExport(ctx context.Context) (int, error) {
+	return e.manager.Export(ctx)
+}
+
+// StashBoxTagTaskInput is the options of a STASH_BOX_TAG task: a batch tag of
+// performers or studios against a stash-box instance
+type StashBoxTagTaskInput struct {
+	// Type is "performer" or "studio"
+	Type string `json:"type"`
+	StashBoxBatchTagInput
+}
+
+func (e *ManagerTaskExecutor) ExecuteStashBoxTag(ctx context.Context, options json.RawMessage) (int, error) {
+	var input StashBoxTagTaskInput
+	if err := json.Unmarshal(options, &input); err != nil {
+		return 0, err
+	}
+
+	box, err := e.stashBox(input.StashBoxEndpoint, input.Endpoint) //nolint:staticcheck
+	if err != nil {
+		return 0, err
+	}
+
+	switch strings.ToLower(input.Type) {
+	case "performer":
+		return e.manager.StashBoxBatchPerformerTag(ctx, box, input.StashBoxBatchTagInput), nil
+	case "studio":
+		return e.manager.StashBoxBatchStudioTag(ctx, box, input.StashBoxBatchTagInput), nil
+	default:
+		return 0, fmt.Errorf("invalid stash-box tag type %q: must be performer or studio", input.Type)
+	}
+}
+
+// stashBox returns the configured stash-box with the given endpoint, or at the
+// given index if no endpoint is given
+func (e *ManagerTaskExecutor) stashBox(endpoint *string, index *int) (*models.StashBox, error) {
+	boxes := e.manager.Config.GetStashBoxes()
+
+	if endpoint != nil {
+		for _, box := range boxes {
+			if strings.EqualFold(*endpoint, box.Endpoint) {
+				return box, nil
+			}
+		}
+		return nil, fmt.Errorf("stash box %s not found", *endpoint)
+	}
+
+	if index != nil {
+		if *index < 0 || *index >= len(boxes) {
+			return nil, fmt.Errorf("invalid stash box index %d", *index)
+		}
+		return boxes[*index], nil
+	}
+
+	return nil, errors.New("stash_box_endpoint not provided")
+}
+
+func (e *ManagerTaskExecutor) ExecuteAPIHubRelink(ctx context.Context) (int, error) {
+	if e.manager.APIHubRelink == nil {
+		return 0, errors.New("API Hub is not available")
+	}
+	return e.manager.APIHubRelink(ctx), nil
+}
+
+func (e *ManagerTaskExecutor) WaitJob(ctx context.Context, jobID int) error {
+	j, err := e.manager.JobManager.Wait(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	if j == nil {
+		return fmt.Errorf("job %d not found", jobID)
+	}
+
+	switch j.Status {
+	case job.StatusFinished:
+		return nil
+	case job.StatusCancelled:
+		return scheduler.ErrJobCancelled
+	default:
+		if j.Error != nil {
+			return errors.New(*j.Error)
+		}
+		return fmt.Errorf("job %s", strings.ToLower(string(j.Status)))
+	}
+}
+
 // ConfigTaskStorage implements scheduler.TaskStorage using the config file
 type ConfigTaskStorage struct {
 	cfg *config.Config
@@ -108,6 +248,11 @@ func (s *ConfigTaskStorage) GetScheduledTasks() []scheduler.ScheduledTask {
 			TaskType:     scheduler.ScheduledTaskType(ct.TaskType),
 			Enabled:      ct.Enabled,
 			Options:      json.RawMessage(ct.Options),
+
+			Then:          ct.Then,
+			Retries:       ct.Retries,
+			RetryDelay:    ct.RetryDelay,
+			SkipIfRunning: ct.SkipIfRunning,
 		}
 		if ct.LastRun != nil {
 			if t, err := time.Parse(time.RFC3339, *ct.LastRun); err == nil {
@@ -128,6 +273,11 @@ func (s *ConfigTaskStorage) SaveScheduledTasks(tasks []scheduler.ScheduledTask)
 			TaskType:     string(t.TaskType),
 			Enabled:      t.Enabled,
 			Options:      string(t.Options),
+
+			Then:          t.Then,
+			Retries:       t.Retries,
+			RetryDelay:    t.RetryDelay,
+			SkipIfRunning: t.SkipIfRunning,
 		}
 		if t.LastRun != nil {
 			lr := t.LastRun.Format(time.RFC3339)
@@ -151,12 +301,85 @@ func (s *ConfigTaskStorage) UpdateTaskLastRun(taskID string, lastRun time.Time)
 	return s.cfg.Write()
 }
 
+// DatabaseRunHistory implements scheduler.RunHistory using the database
+type DatabaseRunHistory struct {
+	repository models.Repository
+}
+
+func NewDatabaseRunHistory(r models.Repository) *DatabaseRunHistory {
+	return &DatabaseRunHistory{repository: r}
+}
+
+// RecordRun adds a run to the history, keeping only the most recent
+// scheduledTaskRunsKept runs of the task
+func (h *DatabaseRunHistory) RecordRun(ctx context.Context, run *scheduler.TaskRun) error {
+	r := models.ScheduledTaskRun{
+		TaskID:    run.TaskID,
+		TaskName:  run.TaskName,
+		TaskType:  string(run.TaskType),
+		Trigger:   string(run.Trigger),
+		Attempt:   run.Attempt,
+		JobID:     run.JobID,
+		Status:    string(run.Status),
+		Error:     run.Error,
+		StartedAt: run.StartedAt,
+		EndedAt:   run.EndedAt,
+	}
+
+	return h.repository.WithTxn(ctx, func(ctx context.Context) error {
+		qb := h.repository.ScheduledTaskRun
+		if err := qb.Create(ctx, &r); err != nil {
+			return err
+		}
+		run.ID = r.ID
+
+		_, err := qb.Prune(ctx, run.TaskID, scheduledTaskRunsKept)
+		return err
+	})
+}
+
+func (h *DatabaseRunHistory) ListRuns(ctx context.Context, taskID string, limit int) ([]scheduler.TaskRun, error) {
+	var runs []*models.ScheduledTaskRun
+	if err := h.repository.WithReadTxn(ctx, func(ctx context.Context) error {
+		var err error
+		runs, err = h.repository.ScheduledTaskRun.FindRecent(ctx, taskID, limit)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	ret := make([]scheduler.TaskRun, len(runs))
+	for i, r := range runs {
+		ret[i] = scheduler.TaskRun{
+			ID:        r.ID,
+			TaskID:    r.TaskID,
+			TaskName:  r.TaskName,
+			TaskType:  scheduler.ScheduledTaskType(r.TaskType),
+			Trigger:   scheduler.TaskRunTrigger(r.Trigger),
+			Attempt:   r.Attempt,
+			JobID:     r.JobID,
+			Status:    scheduler.TaskRunStatus(r.Status),
+			Error:     r.Error,
+			StartedAt: r.StartedAt,
+			EndedAt:   r.EndedAt,
+		}
+	}
+	return ret, nil
+}
+
+func (h *DatabaseRunHistory) DeleteRuns(ctx context.Context, taskID string) error {
+	return h.repository.WithTxn(ctx, func(ctx context.Context) error {
+		return h.repository.ScheduledTaskRun.DestroyByTask(ctx, taskID)
+	})
+}
+
 // initScheduler initializes and starts the scheduler
 func (m *Manager) initScheduler() {
 	executor := NewManagerTaskExecutor(m)
 	storage := NewConfigTaskStorage(m.Config)
+	history := NewDatabaseRunHistory(m.Repository)
 
-	sched := scheduler.New(executor, storage)
+	sched := scheduler.New(executor, storage, history)
 	if err := sched.Start(); err != nil {
 		logger.Errorf("Failed to start scheduler: %v", err)
 		return
@@ -21,6 +21,9 @@ type MigrateJob struct {
 	BackupPath string
 	Config     migrateJobConfig
 	Database   *sqlite.Database
+	// PostMigrate, if set, is called once the database has been migrated and
+	// is ready to use
+	PostMigrate func()
 }
 
 type databaseSchemaInfo struct {
@@ -102,6 +105,10 @@ func (s *MigrateJob) Execute(ctx context.Context, progress *job.Progress) error
 
 	logger.Infof("Database migration complete")
 
+	if s.PostMigrate != nil {
+		s.PostMigrate()
+	}
+
 	return nil
 }
 
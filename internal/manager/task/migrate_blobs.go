@@ -26,6 +26,10 @@ type MigrateBlobsJob struct {
 	DeleteOld  bool
 }
 
+func (j *MigrateBlobsJob) JobPausable() bool {
+	return true
+}
+
 func (j *MigrateBlobsJob) Execute(ctx context.Context, progress *job.Progress) error {
 	var (
 		count int
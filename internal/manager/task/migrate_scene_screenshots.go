@@ -22,6 +22,10 @@ type MigrateSceneScreenshotsJob struct {
 	TxnManager      txn.Manager
 }
 
+func (j *MigrateSceneScreenshotsJob) JobPausable() bool {
+	return true
+}
+
 func (j *MigrateSceneScreenshotsJob) Execute(ctx context.Context, progress *job.Progress) error {
 	var err error
 	progress.ExecuteTask("Counting files", func() {
@@ -32,6 +32,10 @@ type cleanJob struct {
 	scanSubs     *subscriptionManager
 }
 
+func (j *cleanJob) JobPausable() bool {
+	return true
+}
+
 func (j *cleanJob) Execute(ctx context.Context, progress *job.Progress) error {
 	logger.Infof("Starting cleaning of tracked files")
 	start := time.Now()
@@ -494,10 +494,6 @@ func (t *ExportTask) exportScene(ctx context.Context, wg *sync.WaitGroup, jobCha
 
 	r := t.repository
 	sceneReader := r.Scene
-	studioReader := r.Studio
-	groupReader := r.Group
-	galleryReader := r.Gallery
-	performerReader := r.Performer
 	tagReader := r.Tag
 	sceneMarkerReader := r.SceneMarker
 
@@ -508,64 +504,14 @@ func (t *ExportTask) exportScene(ctx context.Context, wg *sync.WaitGroup, jobCha
 			logger.Errorf("[scenes] <%s> error loading scene relationships: %v", sceneHash, err)
 		}
 
-		newSceneJSON, err := scene.ToBasicJSON(ctx, sceneReader, s)
-		if err != nil {
-			logger.Errorf("[scenes] <%s> error getting scene JSON: %v", sceneHash, err)
-			continue
-		}
-
 		// export files
 		for _, f := range s.Files.List() {
 			t.exportFile(f)
 		}
 
-		newSceneJSON.Studio, err = scene.GetStudioName(ctx, studioReader, s)
-		if err != nil {
-			logger.Errorf("[scenes] <%s> error getting scene studio name: %v", sceneHash, err)
-			continue
-		}
-
-		galleries, err := galleryReader.FindBySceneID(ctx, s.ID)
-		if err != nil {
-			logger.Errorf("[scenes] <%s> error getting scene gallery checksums: %v", sceneHash, err)
-			continue
-		}
-
-		for _, g := range galleries {
-			if err := g.LoadFiles(ctx, galleryReader); err != nil {
-				logger.Errorf("[scenes] <%s> error getting scene gallery files: %v", sceneHash, err)
-				continue
-			}
-		}
-
-		newSceneJSON.Galleries = gallery.GetRefs(galleries)
-
-		newSceneJSON.ResumeTime = s.ResumeTime
-		newSceneJSON.PlayDuration = s.PlayDuration
-
-		performers, err := performerReader.FindBySceneID(ctx, s.ID)
-		if err != nil {
-			logger.Errorf("[scenes] <%s> error getting scene performer names: %v", sceneHash, err)
-			continue
-		}
-
-		newSceneJSON.Performers = performer.GetNames(performers)
-
-		newSceneJSON.Tags, err = scene.GetTagNames(ctx, tagReader, s)
-		if err != nil {
-			logger.Errorf("[scenes] <%s> error getting scene tag names: %v", sceneHash, err)
-			continue
-		}
-
-		newSceneJSON.Markers, err = scene.GetSceneMarkersJSON(ctx, sceneMarkerReader, tagReader, s)
-		if err != nil {
-			logger.Errorf("[scenes] <%s> error getting scene markers JSON: %v", sceneHash, err)
-			continue
-		}
-
-		newSceneJSON.Groups, err = scene.GetSceneGroupsJSON(ctx, groupReader, s)
+		newSceneJSON, galleries, performers, err := exportSceneJSON(ctx, r, s)
 		if err != nil {
-			logger.Errorf("[scenes] <%s> error getting scene groups JSON: %v", sceneHash, err)
+			logger.Errorf("[scenes] <%s> %v", sceneHash, err)
 			continue
 		}
 
@@ -604,6 +550,64 @@ func (t *ExportTask) exportScene(ctx context.Context, wg *sync.WaitGroup, jobCha
 	}
 }
 
+// exportSceneJSON returns the export JSON of a scene, whose relationships
+// must be loaded, along with the galleries and performers it refers to.
+func exportSceneJSON(ctx context.Context, r models.Repository, s *models.Scene) (*jsonschema.Scene, []*models.Gallery, []*models.Performer, error) {
+	galleryReader := r.Gallery
+	tagReader := r.Tag
+
+	newSceneJSON, err := scene.ToBasicJSON(ctx, r.Scene, s)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error getting scene JSON: %w", err)
+	}
+
+	newSceneJSON.Studio, err = scene.GetStudioName(ctx, r.Studio, s)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error getting scene studio name: %w", err)
+	}
+
+	galleries, err := galleryReader.FindBySceneID(ctx, s.ID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error getting scene gallery checksums: %w", err)
+	}
+
+	for _, g := range galleries {
+		if err := g.LoadFiles(ctx, galleryReader); err != nil {
+			logger.Errorf("[scenes] <%d> error getting scene gallery files: %v", s.ID, err)
+			continue
+		}
+	}
+
+	newSceneJSON.Galleries = gallery.GetRefs(galleries)
+
+	newSceneJSON.ResumeTime = s.ResumeTime
+	newSceneJSON.PlayDuration = s.PlayDuration
+
+	performers, err := r.Performer.FindBySceneID(ctx, s.ID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error getting scene performer names: %w", err)
+	}
+
+	newSceneJSON.Performers = performer.GetNames(performers)
+
+	newSceneJSON.Tags, err = scene.GetTagNames(ctx, tagReader, s)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error getting scene tag names: %w", err)
+	}
+
+	newSceneJSON.Markers, err = scene.GetSceneMarkersJSON(ctx, r.SceneMarker, tagReader, s)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error getting scene markers JSON: %w", err)
+	}
+
+	newSceneJSON.Groups, err = scene.GetSceneGroupsJSON(ctx, r.Group, s)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error getting scene groups JSON: %w", err)
+	}
+
+	return newSceneJSON, galleries, performers, nil
+}
+
 func (t *ExportTask) ExportImages(ctx context.Context, workers int) {
 	var imagesWg sync.WaitGroup
 
@@ -72,6 +72,50 @@ type GenerateJob struct {
 	fileNamingAlgo models.HashAlgorithm
 
 	totals totalsGenerate
+
+	// resumeFrom is the number of tasks done before an overwriting generate
+	// was interrupted, if this is resuming it
+	resumeFrom int
+	marks      job.Watermark
+}
+
+// generateJobType is the job type of GenerateJob, which is persistent and
+// resumable
+const generateJobType = "generate"
+
+// generateCheckpoint is the point a generate has reached.
+//
+// Without overwrite, only tasks for missing files are queued, so a generate
+// started again picks up where it left off by itself. With overwrite, every
+// task is queued, in the same order each time, so the checkpoint is the number
+// of them done.
+type generateCheckpoint struct {
+	Done int `json:"done"`
+}
+
+func (j *GenerateJob) JobType() string {
+	return generateJobType
+}
+
+func (j *GenerateJob) JobInput() interface{} {
+	return j.input
+}
+
+func (j *GenerateJob) Resumable() bool {
+	return true
+}
+
+func (j *GenerateJob) JobLane() job.Lane {
+	return job.LaneCompute
+}
+
+// JobPriority puts generation for chosen items ahead of whole-library jobs
+func (j *GenerateJob) JobPriority() job.Priority {
+	in := j.input
+	if len(in.SceneIDs) > 0 || len(in.MarkerIDs) > 0 || len(in.ImageIDs) > 0 || len(in.GalleryIDs) > 0 {
+		return job.PriorityHigh
+	}
+	return job.PriorityNormal
 }
 
 type totalsGenerate struct {
@@ -91,6 +135,10 @@ type totalsGenerate struct {
 	tasks int
 }
 
+func (j *GenerateJob) JobPausable() bool {
+	return true
+}
+
 func (j *GenerateJob) Execute(ctx context.Context, progress *job.Progress) error {
 	var scenes []*models.Scene
 	var markers []*models.SceneMarker
@@ -135,8 +183,10 @@ func (j *GenerateJob) Execute(ctx context.Context, progress *job.Progress) error
 			Overwrite:    j.overwrite,
 		}
 
+		// don't use a transaction to query, since queueing blocks while the
+		// job is paused
 		r := j.repository
-		if err := r.WithReadTxn(ctx, func(ctx context.Context) error {
+		if err := r.WithDB(ctx, func(ctx context.Context) error {
 			qb := r.Scene
 			if len(j.input.SceneIDs) == 0 &&
 				len(j.input.MarkerIDs) == 0 &&
@@ -265,10 +315,20 @@ func (j *GenerateJob) Execute(ctx context.Context, progress *job.Progress) error
 		}
 	}()
 
+	if j.resumeFrom > 0 {
+		logger.Infof("Resuming generate after %d tasks", j.resumeFrom)
+	}
+
 	for f := range queue {
 		if job.IsCancelled(ctx) {
 			// keep draining the queue so the producer goroutine can finish
-			// and release its read transaction, otherwise the DB stays locked
+			continue
+		}
+
+		mark := j.marks.Add("")
+		if mark < j.resumeFrom {
+			j.marks.Done(mark)
+			progress.Increment()
 			continue
 		}
 
@@ -280,6 +340,11 @@ func (j *GenerateJob) Execute(ctx context.Context, progress *job.Progress) error
 			localTask.Start(ctx)
 			wg.Done()
 			progress.Increment()
+
+			if j.overwrite && !job.IsCancelled(ctx) && j.marks.Done(mark) {
+				done, _ := j.marks.Reached()
+				progress.Checkpoint(generateCheckpoint{Done: done})
+			}
 		})
 	}
 
@@ -82,8 +82,13 @@ func (t *GenerateSpriteTask) doesSpriteExist(sceneChecksum string) bool {
 	imagePath := instance.Paths.Scene.GetSpriteImageFilePath(sceneChecksum)
 	vttPath := instance.Paths.Scene.GetSpriteVttFilePath(sceneChecksum)
 
+	bifPath := instance.Paths.Scene.GetTrickPlayBIFPath(sceneChecksum)
+	iframePath := instance.Paths.Scene.GetTrickPlayIFramePath(sceneChecksum)
+
 	imageExists, _ := fsutil.FileExists(imagePath)
 	vttExists, _ := fsutil.FileExists(vttPath)
+	bifExists, _ := fsutil.FileExists(bifPath)
+	iframeExists, _ := fsutil.FileExists(iframePath)
 
-	return imageExists && vttExists
+	return imageExists && vttExists && bifExists && iframeExists
 }
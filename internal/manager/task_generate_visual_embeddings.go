@@ -0,0 +1,278 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"path/filepath"
+	"time"
+
+	"github.com/stashapp/stash/pkg/fsutil"
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/paths"
+	"github.com/stashapp/stash/pkg/recommendation/embedding"
+)
+
+const (
+	// visualEmbeddingFrames is the number of sprite frames embedded per scene
+	visualEmbeddingFrames = 16
+
+	// visualEmbeddingBatchSize is the number of scenes whose frames are
+	// embedded together, so that the sidecar model is loaded once per batch
+	visualEmbeddingBatchSize = 16
+)
+
+// statusBackend is implemented by backends that depend on external models,
+// which may be unavailable.
+type statusBackend interface {
+	Status(ctx context.Context) error
+}
+
+// GenerateVisualEmbeddingsJob computes the visual embeddings of scenes with
+// Backend and stores them for similar scene searches. Frames are taken from
+// the scene sprite, or from the cover for scenes without one.
+type GenerateVisualEmbeddingsJob struct {
+	Repository          models.Repository
+	Backend             embedding.Backend
+	Paths               *paths.Paths
+	FileNamingAlgorithm models.HashAlgorithm
+	Index               *embedding.IndexCache
+
+	// SceneIDs limits the job to the given scenes. All scenes are processed
+	// if it is empty.
+	SceneIDs []int
+	// Overwrite recomputes existing embeddings of the backend's model
+	Overwrite bool
+}
+
+func (j *GenerateVisualEmbeddingsJob) Execute(ctx context.Context, progress *job.Progress) error {
+	// fall back to colour histograms if the backend model cannot be loaded
+	if b, ok := j.Backend.(statusBackend); ok {
+		if err := b.Status(ctx); err != nil {
+			logger.Warnf("Visual embedding backend %s unavailable, falling back to colour histograms: %v", j.Backend.Name(), err)
+			j.Backend = embedding.HistogramBackend{}
+		}
+	}
+
+	model := j.Backend.Name()
+	logger.Infof("Starting visual embedding generation with %s", model)
+
+	start := time.Now()
+	r := j.Repository
+
+	var sceneIDs []int
+	if err := r.WithReadTxn(ctx, func(ctx context.Context) error {
+		var err error
+		sceneIDs, err = j.scenesToProcess(ctx, model)
+		return err
+	}); err != nil {
+		return fmt.Errorf("finding scenes: %w", err)
+	}
+
+	progress.SetTotal(len(sceneIDs))
+
+	generated := 0
+	for len(sceneIDs) > 0 {
+		if job.IsCancelled(ctx) {
+			logger.Info("Stopping due to user request")
+			return nil
+		}
+
+		batch := sceneIDs[:min(visualEmbeddingBatchSize, len(sceneIDs))]
+		sceneIDs = sceneIDs[len(batch):]
+
+		n, err := j.processBatch(ctx, model, batch)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				logger.Info("Stopping due to user request")
+				return nil
+			}
+			return fmt.Errorf("generating visual embeddings: %w", err)
+		}
+
+		generated += n
+		progress.AddProcessed(len(batch))
+	}
+
+	// build the search index now rather than on the next search
+	if j.Index != nil {
+		if err := r.WithReadTxn(ctx, func(ctx context.Context) error {
+			_, err := j.Index.Get(ctx, r.VisualEmbedding, model)
+			return err
+		}); err != nil {
+			logger.Warnf("Error updating visual embedding index: %v", err)
+		}
+	}
+
+	logger.Infof("Finished generating %d visual embeddings in %s", generated, time.Since(start))
+
+	return nil
+}
+
+func (j *GenerateVisualEmbeddingsJob) scenesToProcess(ctx context.Context, model string) ([]int, error) {
+	sceneIDs := j.SceneIDs
+	if len(sceneIDs) == 0 {
+		all := -1
+		result, err := j.Repository.Scene.Query(ctx, models.SceneQueryOptions{
+			QueryOptions: models.QueryOptions{
+				FindFilter: &models.FindFilterType{PerPage: &all},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		sceneIDs = result.IDs
+	}
+
+	if j.Overwrite {
+		return sceneIDs, nil
+	}
+
+	existing, err := j.Repository.VisualEmbedding.SceneIDs(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+	skip := make(map[int]bool, len(existing))
+	for _, id := range existing {
+		skip[id] = true
+	}
+
+	var ret []int
+	for _, id := range sceneIDs {
+		if !skip[id] {
+			ret = append(ret, id)
+		}
+	}
+	return ret, nil
+}
+
+// processBatch embeds the frames of the given scenes in a single call of the
+// backend and stores the results. It returns the number of embeddings stored.
+func (j *GenerateVisualEmbeddingsJob) processBatch(ctx context.Context, model string, sceneIDs []int) (int, error) {
+	r := j.Repository
+
+	type sceneFrames struct {
+		sceneID int
+		count   int
+	}
+	var scenes []sceneFrames
+	var frames []image.Image
+
+	if err := r.WithReadTxn(ctx, func(ctx context.Context) error {
+		found, err := r.Scene.FindMany(ctx, sceneIDs)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range found {
+			f, err := j.sceneFrames(ctx, s)
+			if err != nil {
+				logger.Warnf("Error reading frames of scene %d: %v", s.ID, err)
+				continue
+			}
+			if len(f) == 0 {
+				logger.Debugf("Skipping visual embedding of scene %d: no sprite or cover", s.ID)
+				continue
+			}
+			scenes = append(scenes, sceneFrames{s.ID, len(f)})
+			frames = append(frames, f...)
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	if len(frames) == 0 {
+		return 0, nil
+	}
+
+	vectors, err := j.Backend.Embed(ctx, frames)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	if err := r.WithTxn(ctx, func(ctx context.Context) error {
+		for _, s := range scenes {
+			sceneVectors := vectors[:s.count]
+			vectors = vectors[s.count:]
+
+			if err := r.VisualEmbedding.Set(ctx, &models.VisualEmbedding{
+				SceneID:   s.sceneID,
+				Model:     model,
+				Frames:    sceneVectors,
+				Mean:      embedding.Mean(sceneVectors),
+				UpdatedAt: now,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	return len(scenes), nil
+}
+
+// sceneFrames returns the frames of the scene sprite, or the cover if the
+// scene has no sprite.
+func (j *GenerateVisualEmbeddingsJob) sceneFrames(ctx context.Context, s *models.Scene) ([]image.Image, error) {
+	if err := s.LoadPrimaryFile(ctx, j.Repository.File); err != nil {
+		return nil, err
+	}
+
+	if hash := s.GetHash(j.FileNamingAlgorithm); hash != "" {
+		spritePath := j.Paths.Scene.GetSpriteImageFilePath(hash)
+		vttPath := j.Paths.Scene.GetSpriteVttFilePath(hash)
+
+		spriteExists, _ := fsutil.FileExists(spritePath)
+		vttExists, _ := fsutil.FileExists(vttPath)
+		if spriteExists && vttExists {
+			return embedding.SpriteFrames(spritePath, vttPath, visualEmbeddingFrames)
+		}
+	}
+
+	cover, err := j.Repository.Scene.GetCover(ctx, s.ID)
+	if err != nil || len(cover) == 0 {
+		return nil, err
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(cover))
+	if err != nil {
+		return nil, fmt.Errorf("decoding cover: %w", err)
+	}
+
+	return []image.Image{img}, nil
+}
+
+func (j *GenerateVisualEmbeddingsJob) GetDescription() string {
+	return "Generating visual embeddings"
+}
+
+// visualEmbeddingBackend returns the configured visual embedding backend.
+func (s *Manager) visualEmbeddingBackend() embedding.Backend {
+	cfg := s.Config
+	if cfg.GetVisualEmbeddingBackend() != "sidecar" {
+		return embedding.HistogramBackend{}
+	}
+
+	return embedding.NewSidecarBackend(
+		cfg.GetPythonPath(),
+		cfg.GetVisualEmbeddingModel(),
+		filepath.Join(cfg.GetGeneratedPath(), "embedding"),
+	)
+}
+
+// VisualEmbeddingModel returns the model name of the configured visual
+// embedding backend, without checking that it is available.
+func (s *Manager) VisualEmbeddingModel() string {
+	if s.Config.GetVisualEmbeddingBackend() == "sidecar" {
+		return embedding.SidecarModelName(s.Config.GetVisualEmbeddingModel())
+	}
+	return embedding.HistogramModel
+}
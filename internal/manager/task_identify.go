@@ -37,6 +37,14 @@ func CreateIdentifyJob(input identify.Options) *IdentifyJob {
 	}
 }
 
+func (j *IdentifyJob) JobLane() job.Lane {
+	return job.LaneNetwork
+}
+
+func (j *IdentifyJob) JobPausable() bool {
+	return true
+}
+
 func (j *IdentifyJob) Execute(ctx context.Context, progress *job.Progress) error {
 	j.progress = progress
 
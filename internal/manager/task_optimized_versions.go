@@ -0,0 +1,409 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/fsutil"
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scene/generate"
+	"github.com/stashapp/stash/pkg/sliceutil/stringslice"
+)
+
+// optimizedVersionsJobType is the job type of GenerateOptimizedVersionsJob,
+// which is persistent and resumable
+const optimizedVersionsJobType = "optimized_versions"
+
+type GenerateOptimizedVersionsInput struct {
+	// SceneIDs limits generation to the given scenes. All scenes if empty.
+	SceneIDs []string `json:"sceneIDs"`
+	// Profiles limits generation to the named profiles. All profiles if
+	// empty.
+	Profiles []string `json:"profiles"`
+	// Overwrite generates versions again even if they exist
+	Overwrite bool `json:"overwrite"`
+}
+
+// GenerateOptimizedVersionsJob transcodes scenes with the optimized version
+// profiles, and records the versions so that they are streamed in place of
+// live transcodes.
+//
+// Existing versions are skipped unless overwriting, so a job started again
+// after a restart continues where it left off.
+type GenerateOptimizedVersionsJob struct {
+	repository models.Repository
+	input      GenerateOptimizedVersionsInput
+}
+
+func (j *GenerateOptimizedVersionsJob) JobType() string {
+	return optimizedVersionsJobType
+}
+
+func (j *GenerateOptimizedVersionsJob) JobInput() interface{} {
+	return j.input
+}
+
+func (j *GenerateOptimizedVersionsJob) Resumable() bool {
+	return !j.input.Overwrite
+}
+
+func (j *GenerateOptimizedVersionsJob) JobLane() job.Lane {
+	return job.LaneCompute
+}
+
+// JobPriority puts generation for chosen scenes ahead of whole-library jobs
+func (j *GenerateOptimizedVersionsJob) JobPriority() job.Priority {
+	if len(j.input.SceneIDs) > 0 {
+		return job.PriorityHigh
+	}
+	return job.PriorityNormal
+}
+
+func (j *GenerateOptimizedVersionsJob) JobPausable() bool {
+	return true
+}
+
+// optimizedVersionTask is a version of a scene to generate
+type optimizedVersionTask struct {
+	sceneID int
+	profile *models.OptimizedVersionProfile
+}
+
+func (j *GenerateOptimizedVersionsJob) profiles() []*models.OptimizedVersionProfile {
+	profiles := instance.Config.GetOptimizedVersionProfiles()
+	if len(j.input.Profiles) == 0 {
+		return profiles
+	}
+
+	var ret []*models.OptimizedVersionProfile
+	for _, p := range profiles {
+		if slices.Contains(j.input.Profiles, p.Name) {
+			ret = append(ret, p)
+		}
+	}
+	return ret
+}
+
+func (j *GenerateOptimizedVersionsJob) Execute(ctx context.Context, progress *job.Progress) error {
+	profiles := j.profiles()
+	if len(profiles) == 0 {
+		logger.Info("No optimized version profiles to generate")
+		return nil
+	}
+
+	start := time.Now()
+	r := j.repository
+
+	var tasks []optimizedVersionTask
+	if err := r.WithReadTxn(ctx, func(ctx context.Context) error {
+		var err error
+		tasks, err = j.queueTasks(ctx, profiles)
+		return err
+	}); err != nil {
+		return fmt.Errorf("finding scenes: %w", err)
+	}
+
+	if err := fsutil.EnsureDir(instance.Paths.Generated.OptimizedVersions); err != nil {
+		return fmt.Errorf("creating optimized versions directory: %w", err)
+	}
+
+	logger.Infof("Generating %d optimized versions", len(tasks))
+	progress.SetTotal(len(tasks))
+
+	g := newOptimizedVersionGenerator()
+
+	generated := 0
+	for _, t := range tasks {
+		if job.IsCancelled(ctx) {
+			logger.Info("Stopping due to user request")
+			return nil
+		}
+
+		progress.ExecuteTask(fmt.Sprintf("Generating %s version of scene %d", t.profile.Name, t.sceneID), func() {
+			if _, err := generateOptimizedVersion(ctx, r, g, t.sceneID, t.profile); err != nil {
+				if !errors.Is(err, context.Canceled) {
+					logger.Errorf("error generating %s version of scene %d: %v", t.profile.Name, t.sceneID, err)
+				}
+				return
+			}
+			generated++
+		})
+		progress.Increment()
+	}
+
+	logger.Infof("Finished generating %d optimized versions in %s", generated, time.Since(start))
+	return nil
+}
+
+// queueTasks returns the versions to generate. Without overwrite, versions
+// that exist are skipped.
+func (j *GenerateOptimizedVersionsJob) queueTasks(ctx context.Context, profiles []*models.OptimizedVersionProfile) ([]optimizedVersionTask, error) {
+	r := j.repository
+
+	sceneIDs, err := stringslice.StringSliceToIntSlice(j.input.SceneIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sceneIDs) == 0 {
+		all := models.PerPageAll
+		result, err := r.Scene.Query(ctx, models.SceneQueryOptions{
+			QueryOptions: models.QueryOptions{
+				FindFilter: &models.FindFilterType{PerPage: &all},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		sceneIDs = result.IDs
+	}
+
+	existing := make(map[optimizedVersionKey]bool)
+	if !j.input.Overwrite {
+		versions, err := r.OptimizedVersion.All(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range versions {
+			existing[optimizedVersionKey{v.SceneID, v.Profile}] = true
+		}
+	}
+
+	var ret []optimizedVersionTask
+	for _, id := range sceneIDs {
+		for _, p := range profiles {
+			if !existing[optimizedVersionKey{id, p.Name}] {
+				ret = append(ret, optimizedVersionTask{sceneID: id, profile: p})
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+type optimizedVersionKey struct {
+	sceneID int
+	profile string
+}
+
+// optimizedVersionBasename returns the name of the file of a version. The
+// profile name is hashed, as it may contain anything.
+func optimizedVersionBasename(sceneID int, profile string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(profile))
+	return fmt.Sprintf("%d_%08x.mp4", sceneID, h.Sum32())
+}
+
+func newOptimizedVersionGenerator() *generate.Generator {
+	return &generate.Generator{
+		Encoder:      instance.FFMpeg,
+		FFMpegConfig: instance.Config,
+		LockManager:  instance.ReadLockManager,
+		ScenePaths:   instance.Paths.Scene,
+	}
+}
+
+// generateOptimizedVersion transcodes a scene with a profile and records the
+// version, replacing any that exists. It returns nil if the scene has no
+// video file.
+func generateOptimizedVersion(ctx context.Context, r models.Repository, g *generate.Generator, sceneID int, p *models.OptimizedVersionProfile) (*models.OptimizedVersion, error) {
+	var f *models.VideoFile
+	if err := r.WithReadTxn(ctx, func(ctx context.Context) error {
+		s, err := r.Scene.Find(ctx, sceneID)
+		if err != nil || s == nil {
+			return err
+		}
+		if err := s.LoadPrimaryFile(ctx, r.File); err != nil {
+			return err
+		}
+		f = s.Files.Primary()
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if f == nil {
+		logger.Debugf("Skipping optimized version of scene %d: no video file", sceneID)
+		return nil, nil
+	}
+
+	options := generate.OptimizedVersionOptions{
+		VideoCodec:   ffmpeg.VideoCodecLibX264,
+		VideoBitrate: p.VideoBitrate,
+		AudioBitrate: p.AudioBitrate,
+		NoAudio:      ffmpeg.ProbeAudioCodec(f.AudioCodec) == ffmpeg.MissingUnsupported,
+	}
+	if p.VideoCodec == models.OptimizedVideoCodecHEVC {
+		options.VideoCodec = ffmpeg.VideoCodecLibX265
+	}
+	if size := p.GetMaxResolution(); size > 0 {
+		vf := ffmpeg.VideoFile{Width: f.Width, Height: f.Height}
+		options.Width, options.Height = vf.TranscodeScale(size)
+	}
+
+	basename := optimizedVersionBasename(sceneID, p.Name)
+	output := instance.Paths.Scene.GetOptimizedVersionPath(basename)
+	if err := g.OptimizedVersion(ctx, f.Path, output, options); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(output)
+	if err != nil {
+		return nil, err
+	}
+
+	version := &models.OptimizedVersion{
+		SceneID:    sceneID,
+		Profile:    p.Name,
+		Basename:   basename,
+		Size:       info.Size(),
+		VideoCodec: p.VideoCodec,
+		CreatedAt:  time.Now(),
+	}
+
+	probe, err := instance.FFProbe.NewVideoFile(output)
+	if err != nil {
+		logger.Warnf("error reading optimized version %s: %v", output, err)
+	} else {
+		version.Width = probe.Width
+		version.Height = probe.Height
+	}
+
+	if err := r.WithTxn(ctx, func(ctx context.Context) error {
+		return r.OptimizedVersion.Create(ctx, version)
+	}); err != nil {
+		return nil, err
+	}
+
+	return version, nil
+}
+
+// CleanOptimizedVersionsJob deletes the versions of profiles that are no
+// longer configured, records of versions whose files are missing, and files
+// that no version refers to, such as those of deleted scenes. Versions may be
+// generated while it runs, so files written since it started are kept.
+type CleanOptimizedVersionsJob struct {
+	repository models.Repository
+}
+
+func (j *CleanOptimizedVersionsJob) Execute(ctx context.Context, progress *job.Progress) error {
+	r := j.repository
+	start := time.Now()
+
+	profiles := make(map[string]bool)
+	for _, p := range instance.Config.GetOptimizedVersionProfiles() {
+		profiles[p.Name] = true
+	}
+
+	var versions []*models.OptimizedVersion
+	if err := r.WithReadTxn(ctx, func(ctx context.Context) error {
+		var err error
+		versions, err = r.OptimizedVersion.All(ctx)
+		return err
+	}); err != nil {
+		return fmt.Errorf("finding optimized versions: %w", err)
+	}
+
+	progress.SetTotal(len(versions) + 1)
+
+	var freed int64
+	removed := 0
+	for _, v := range versions {
+		if job.IsCancelled(ctx) {
+			logger.Info("Stopping due to user request")
+			return nil
+		}
+
+		path := instance.Paths.Scene.GetOptimizedVersionPath(v.Basename)
+		exists, _ := fsutil.FileExists(path)
+
+		if profiles[v.Profile] && exists {
+			progress.Increment()
+			continue
+		}
+
+		if exists {
+			logger.Infof("Deleting %s version of scene %d", v.Profile, v.SceneID)
+			if err := os.Remove(path); err != nil {
+				logger.Errorf("error deleting optimized version %s: %v", path, err)
+				progress.Increment()
+				continue
+			}
+			freed += v.Size
+		}
+
+		if err := r.WithTxn(ctx, func(ctx context.Context) error {
+			return r.OptimizedVersion.Destroy(ctx, v.ID)
+		}); err != nil {
+			return err
+		}
+		removed++
+		progress.Increment()
+	}
+
+	// read the versions again, to keep those recorded since they were first
+	// read
+	keep := make(map[string]bool)
+	if err := r.WithReadTxn(ctx, func(ctx context.Context) error {
+		var err error
+		versions, err = r.OptimizedVersion.All(ctx)
+		return err
+	}); err != nil {
+		return fmt.Errorf("finding optimized versions: %w", err)
+	}
+	for _, v := range versions {
+		keep[v.Basename] = true
+	}
+
+	progress.ExecuteTask("Deleting unreferenced files", func() {
+		dir := instance.Paths.Generated.OptimizedVersions
+		if err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() || keep[d.Name()] {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+
+			// the version may not have been recorded yet
+			if info.ModTime().After(start) {
+				return nil
+			}
+
+			logger.Infof("Deleting unreferenced optimized version %s", path)
+			if err := os.Remove(path); err != nil {
+				logger.Errorf("error deleting %s: %v", path, err)
+				return nil
+			}
+			freed += info.Size()
+			return nil
+		}); err != nil {
+			logger.Errorf("error cleaning optimized versions directory: %v", err)
+		}
+	})
+	progress.Increment()
+
+	logger.Infof("Removed %d optimized versions, freeing %.1f MB", removed, float64(freed)/(1<<20))
+	return nil
+}
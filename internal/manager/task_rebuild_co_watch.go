@@ -0,0 +1,155 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/recommendation"
+)
+
+// RebuildCoWatchJob adds the play and O history recorded since its last run
+// to the co-watch recommendation model, and takes out the history removed
+// since. When Full is set, the model is rebuilt from the start of the history
+// instead.
+type RebuildCoWatchJob struct {
+	Repository models.Repository
+	Full       bool
+}
+
+func (j *RebuildCoWatchJob) Execute(ctx context.Context, progress *job.Progress) error {
+	logger.Info("Starting co-watch model update")
+
+	start := time.Now()
+	store := j.Repository.CoWatch
+
+	// the model and its cursor are updated together, so that history is
+	// never added twice
+	var users []*int
+	err := j.Repository.WithTxn(ctx, func(ctx context.Context) error {
+		if j.Full {
+			if err := store.Reset(ctx); err != nil {
+				return err
+			}
+		}
+
+		after, err := store.GetCursor(ctx)
+		if err != nil {
+			return err
+		}
+		upTo, err := store.HistoryEnd(ctx)
+		if err != nil {
+			return err
+		}
+
+		// removed history is taken out relative to the history that was in
+		// the model along with it, before anything is added
+		if err := j.removeHistory(ctx, after); err != nil {
+			return err
+		}
+
+		users, err = store.HistoryUsers(ctx, after, upTo)
+		if err != nil {
+			return err
+		}
+
+		progress.SetTotal(len(users))
+		for _, userID := range users {
+			if job.IsCancelled(ctx) {
+				return context.Canceled
+			}
+
+			events, err := store.UserHistory(ctx, userID, after, upTo)
+			if err != nil {
+				return err
+			}
+
+			// history recorded without a user may come from anyone
+			countUsers := userID != nil
+			if err := store.AddPairs(ctx, recommendation.CoWatchPairs(events, countUsers)); err != nil {
+				return err
+			}
+
+			progress.Increment()
+		}
+
+		return store.SetCursor(ctx, upTo)
+	})
+
+	if errors.Is(err, context.Canceled) {
+		logger.Info("Stopping due to user request")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("updating co-watch model: %w", err)
+	}
+
+	logger.Infof("Finished updating co-watch model from the history of %d users in %s", len(users), time.Since(start))
+
+	return nil
+}
+
+// removeHistory takes the counts that the removed history had added out of
+// the model, which has been built up to cursor.
+func (j *RebuildCoWatchJob) removeHistory(ctx context.Context, cursor models.CoWatchCursor) error {
+	store := j.Repository.CoWatch
+
+	removed, err := store.RemovedHistory(ctx)
+	if err != nil {
+		return err
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	byUser := make(map[int][]*models.WatchEvent)
+	var users []*int
+	for _, e := range removed {
+		k := 0
+		if e.UserID != nil {
+			k = *e.UserID
+		}
+		if _, seen := byUser[k]; !seen {
+			users = append(users, e.UserID)
+		}
+		byUser[k] = append(byUser[k], e)
+	}
+
+	for _, userID := range users {
+		k := 0
+		if userID != nil {
+			k = *userID
+		}
+
+		// the history that remains in the model, none of which is new
+		events, err := store.UserHistory(ctx, userID, cursor, cursor)
+		if err != nil {
+			return err
+		}
+		events = append(events, byUser[k]...)
+		sort.SliceStable(events, func(i, j int) bool {
+			return events[i].Date.Before(events[j].Date)
+		})
+
+		countUsers := userID != nil
+		if err := store.RemovePairs(ctx, recommendation.CoWatchPairs(events, countUsers)); err != nil {
+			return err
+		}
+	}
+
+	logger.Infof("Removed %d deleted plays and O's from the co-watch model", len(removed))
+
+	return store.ClearRemovedHistory(ctx)
+}
+
+func (j *RebuildCoWatchJob) GetDescription() string {
+	if j.Full {
+		return "Rebuild Co-Watch Model"
+	}
+	return "Update Co-Watch Model"
+}
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime/debug"
+	"strings"
 	"sync"
 	"time"
 
@@ -34,8 +35,58 @@ type ScanJob struct {
 	input         ScanMetadataInput
 	subscriptions *subscriptionManager
 
-	fileQueue chan file.ScannedFile
+	fileQueue chan scanQueueItem
 	count     int
+
+	// resumeAfter is the last file of the scan that was interrupted, if this
+	// is resuming it. Files up to it in walk order are not scanned again.
+	resumeAfter string
+	// marks tracks the files queued, to checkpoint the last before which
+	// every file has been scanned
+	marks job.Watermark
+}
+
+type scanQueueItem struct {
+	file.ScannedFile
+	mark int
+}
+
+// scanJobType is the job type of ScanJob, which is persistent and resumable
+const scanJobType = "scan"
+
+// scanCheckpoint is the point a scan has reached
+type scanCheckpoint struct {
+	// After is the path of the last file before which every file has been
+	// scanned
+	After string `json:"after"`
+}
+
+func (j *ScanJob) JobType() string {
+	return scanJobType
+}
+
+func (j *ScanJob) JobInput() interface{} {
+	return j.input
+}
+
+func (j *ScanJob) Resumable() bool {
+	return true
+}
+
+func (j *ScanJob) JobLane() job.Lane {
+	return job.LaneIO
+}
+
+// JobPriority puts scans of chosen folders ahead of whole-library jobs
+func (j *ScanJob) JobPriority() job.Priority {
+	if len(j.input.Paths) > 0 {
+		return job.PriorityHigh
+	}
+	return job.PriorityNormal
+}
+
+func (j *ScanJob) JobPausable() bool {
+	return true
 }
 
 func (j *ScanJob) Execute(ctx context.Context, progress *job.Progress) error {
@@ -179,7 +230,7 @@ func (j *ScanJob) runJob(ctx context.Context, paths []string, nTasks int, progre
 	var wg sync.WaitGroup
 	wg.Add(1)
 
-	j.fileQueue = make(chan file.ScannedFile, scanQueueSize)
+	j.fileQueue = make(chan scanQueueItem, scanQueueSize)
 
 	go func() {
 		defer func() {
@@ -221,6 +272,8 @@ func (j *ScanJob) queueFiles(ctx context.Context, paths []string, progress *job.
 		progress.Definite()
 	}()
 
+	paths = j.resumePaths(paths)
+
 	var err error
 	progress.ExecuteTask("Walking directory tree", func() {
 		for _, p := range paths {
@@ -228,12 +281,49 @@ func (j *ScanJob) queueFiles(ctx context.Context, paths []string, progress *job.
 			if err != nil {
 				return
 			}
+
+			// only the first path is part way through
+			j.resumeAfter = ""
 		}
 	})
 
 	return err
 }
 
+// resumePaths returns the paths still to be walked by a resumed scan: the
+// path the scan was interrupted in, and those after it. The interrupted
+// scan's last file must be in one of them, or the scan starts over.
+func (j *ScanJob) resumePaths(paths []string) []string {
+	if j.resumeAfter == "" {
+		return paths
+	}
+
+	for i, p := range paths {
+		if fsutil.IsPathInDir(p, j.resumeAfter) {
+			logger.Infof("Resuming scan after %s", j.resumeAfter)
+			return paths[i:]
+		}
+	}
+
+	j.resumeAfter = ""
+	return paths
+}
+
+// scanPathAfter returns true if a is walked after b. Directories are walked
+// in lexical order of their entries, so this compares the paths an element at
+// a time.
+func scanPathAfter(a, b string) bool {
+	ae := strings.Split(filepath.Clean(a), string(filepath.Separator))
+	be := strings.Split(filepath.Clean(b), string(filepath.Separator))
+
+	for i := 0; i < len(ae) && i < len(be); i++ {
+		if ae[i] != be[i] {
+			return ae[i] > be[i]
+		}
+	}
+	return len(ae) > len(be)
+}
+
 func (j *ScanJob) queueFileFunc(ctx context.Context, f models.FS, zipFile *file.ScannedFile, progress *job.Progress) fs.WalkDirFunc {
 	return func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -304,8 +394,10 @@ func (j *ScanJob) queueFileFunc(ctx context.Context, f models.FS, zipFile *file.
 		}
 
 		// if zip file is present, we handle immediately
+		// the zip file is scanned in a transaction, so its contents are
+		// nested tasks, which are not paused
 		if zipFile != nil {
-			progress.ExecuteTask("Scanning "+path, func() {
+			progress.ExecuteNestedTask("Scanning "+path, func() {
 				// don't increment progress in zip files
 				if err := j.handleFile(ctx, ff, nil); err != nil {
 					if !errors.Is(err, context.Canceled) {
@@ -318,8 +410,13 @@ func (j *ScanJob) queueFileFunc(ctx context.Context, f models.FS, zipFile *file.
 			return nil
 		}
 
+		if j.resumeAfter != "" && !scanPathAfter(path, j.resumeAfter) {
+			logger.Tracef("Skipping file %s scanned before the scan was interrupted", path)
+			return nil
+		}
+
 		logger.Tracef("Queueing file %s for scanning", path)
-		j.fileQueue <- ff
+		j.fileQueue <- scanQueueItem{ScannedFile: ff, mark: j.marks.Add(path)}
 
 		j.count++
 
@@ -357,7 +454,12 @@ func (j *ScanJob) processQueue(ctx context.Context, parallelTasks int, progress
 			ff := f
 			go func() {
 				defer wg.Done()
-				j.processQueueItem(ctx, ff, progress)
+				j.processQueueItem(ctx, ff.ScannedFile, progress)
+
+				if ctx.Err() == nil && j.marks.Done(ff.mark) {
+					_, after := j.marks.Reached()
+					progress.Checkpoint(scanCheckpoint{After: after})
+				}
 			}()
 		}
 	}()
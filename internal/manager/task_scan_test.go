@@ -0,0 +1,29 @@
+package manager
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestScanPathAfterFollowsWalkOrder(t *testing.T) {
+	p := filepath.FromSlash
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"/lib/b.mp4", "/lib/a.mp4", true},
+		{"/lib/a.mp4", "/lib/b.mp4", false},
+		{"/lib/a.mp4", "/lib/a.mp4", false},
+		// a directory's contents are walked before its later siblings, though
+		// "a-b" sorts before "a/x" as a string
+		{"/lib/a-b.mp4", "/lib/a/x.mp4", true},
+		{"/lib/a/x.mp4", "/lib/a-b.mp4", false},
+		{"/lib/a/x.mp4", "/lib/a", true},
+	}
+
+	for _, tt := range tests {
+		if got := scanPathAfter(p(tt.a), p(tt.b)); got != tt.want {
+			t.Errorf("scanPathAfter(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
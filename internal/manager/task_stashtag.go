@@ -100,6 +100,10 @@ type stashTagBatchJob struct {
 	jobIDCh chan string // buffered(1): populated by StashTagBatchAnalyze before Execute() runs
 }
 
+func (j *stashTagBatchJob) JobPausable() bool {
+	return true
+}
+
 func (j *stashTagBatchJob) Execute(ctx context.Context, progress *job.Progress) error {
 	// Receive the job ID that was placed in the channel before this goroutine started.
 	jobIDStr := <-j.jobIDCh
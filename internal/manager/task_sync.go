@@ -0,0 +1,741 @@
+package manager
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/file/video"
+	"github.com/stashapp/stash/pkg/fsutil"
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	modelsjson "github.com/stashapp/stash/pkg/models/json"
+	"github.com/stashapp/stash/pkg/models/jsonschema"
+	"github.com/stashapp/stash/pkg/savedfilter"
+	"github.com/stashapp/stash/pkg/scene/generate"
+)
+
+// syncBundleExpiry is how long a bundle can be downloaded for
+const syncBundleExpiry = 24 * time.Hour
+
+// SyncBundleStore holds the download of the latest bundle prepared for each
+// sync target, along with the scenes that the device will have once it has
+// downloaded it. Bundles are kept by the DownloadStore until they are replaced
+// by the next bundle of their target or expire, so that an interrupted
+// download can be resumed.
+type SyncBundleStore struct {
+	downloads *DownloadStore
+	expiry    time.Duration
+	// record records the scenes of a bundle as those the device of the
+	// target has, once the whole bundle has been downloaded
+	record func(targetID int, items []*models.SyncTargetItem)
+	m      map[int]*syncBundleDownload
+	mutex  sync.Mutex
+}
+
+// syncBundleDownload is a bundle waiting to be downloaded.
+type syncBundleDownload struct {
+	hash     string
+	filename string
+	// items are the scenes the device will have once it has the bundle
+	items  []*models.SyncTargetItem
+	expiry *time.Timer
+}
+
+func NewSyncBundleStore(downloads *DownloadStore, repository models.Repository) *SyncBundleStore {
+	return &SyncBundleStore{
+		downloads: downloads,
+		expiry:    syncBundleExpiry,
+		record: func(targetID int, items []*models.SyncTargetItem) {
+			recordSyncTargetItems(repository, targetID, items)
+		},
+		m: make(map[int]*syncBundleDownload),
+	}
+}
+
+// add registers the bundle at path for download, removing the previous
+// bundle of the target. items are recorded as the scenes the device has once
+// the whole bundle has been downloaded.
+func (s *SyncBundleStore) add(targetID int, path string, filename string, items []*models.SyncTargetItem) error {
+	b := &syncBundleDownload{
+		filename: filename,
+		items:    items,
+	}
+
+	// the bundle is removed here rather than by the DownloadStore, which
+	// would remove it soon after any request, whole or not
+	hash, err := s.downloads.RegisterFileOnServed(path, "application/zip", true, func() {
+		s.record(targetID, b.items)
+	})
+	if err != nil {
+		return err
+	}
+	b.hash = hash
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if old := s.m[targetID]; old != nil {
+		old.expiry.Stop()
+		s.downloads.Remove(old.hash)
+	}
+
+	s.m[targetID] = b
+	b.expiry = time.AfterFunc(s.expiry, func() {
+		s.expire(targetID, b)
+	})
+
+	return nil
+}
+
+// recordSyncTargetItems records the scenes of a bundle as those the device of
+// the target has.
+func recordSyncTargetItems(r models.Repository, targetID int, items []*models.SyncTargetItem) {
+	ctx := context.Background()
+
+	if err := r.WithTxn(ctx, func(ctx context.Context) error {
+		// the target may have been deleted since
+		target, err := r.SyncTarget.Find(ctx, targetID)
+		if err != nil || target == nil {
+			return err
+		}
+
+		return r.SyncTarget.UpdateItems(ctx, targetID, items)
+	}); err != nil {
+		logger.Errorf("error recording scenes synced to sync target %d: %v", targetID, err)
+	}
+}
+
+// expire removes a bundle once it can no longer be downloaded.
+func (s *SyncBundleStore) expire(targetID int, b *syncBundleDownload) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.m[targetID] == b {
+		delete(s.m, targetID)
+	}
+	s.downloads.Remove(b.hash)
+}
+
+// Get returns the path of the latest bundle of the target under the
+// downloads route, or an empty string if there is none to download.
+func (s *SyncBundleStore) Get(targetID int) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	b, ok := s.m[targetID]
+	if !ok {
+		return ""
+	}
+
+	if !s.downloads.Has(b.hash) {
+		b.expiry.Stop()
+		delete(s.m, targetID)
+		return ""
+	}
+
+	return b.hash + "/" + b.filename
+}
+
+// PendingItems returns the scenes that the device of the target will have
+// once it has downloaded the latest bundle, or nil if there is none to
+// download.
+func (s *SyncBundleStore) PendingItems(targetID int) []*models.SyncTargetItem {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if b, ok := s.m[targetID]; ok {
+		return b.items
+	}
+	return nil
+}
+
+// SyncBundleJob prepares a bundle of the scenes of a sync target that fit in
+// its size budget, with their media, export JSON, covers, captions and
+// funscripts. The files of scenes that the device already has are left out
+// unless the bundle is full.
+type SyncBundleJob struct {
+	repository models.Repository
+	targetID   int
+	full       bool
+}
+
+func (j *SyncBundleJob) JobLane() job.Lane {
+	return job.LaneCompute
+}
+
+func (j *SyncBundleJob) JobPausable() bool {
+	return true
+}
+
+func (j *SyncBundleJob) Execute(ctx context.Context, progress *job.Progress) error {
+	r := j.repository
+	start := time.Now()
+
+	var (
+		target   *models.SyncTarget
+		sceneIDs []int
+		existing []*models.SyncTargetItem
+	)
+	if err := r.WithReadTxn(ctx, func(ctx context.Context) error {
+		var err error
+		target, err = r.SyncTarget.Find(ctx, j.targetID)
+		if err != nil {
+			return err
+		}
+		if target == nil {
+			return fmt.Errorf("sync target %d not found", j.targetID)
+		}
+
+		sceneIDs, err = syncTargetSceneIDs(ctx, r, target)
+		if err != nil {
+			return err
+		}
+
+		existing, err = r.SyncTarget.GetItems(ctx, target.ID)
+		return err
+	}); err != nil {
+		return fmt.Errorf("finding scenes to sync: %w", err)
+	}
+
+	profile, err := syncTargetProfile(target)
+	if err != nil {
+		return err
+	}
+	if profile != nil {
+		if err := instance.validateFFmpeg(); err != nil {
+			return err
+		}
+		if err := fsutil.EnsureDir(instance.Paths.Generated.OptimizedVersions); err != nil {
+			return fmt.Errorf("creating optimized versions directory: %w", err)
+		}
+	}
+
+	if err := fsutil.EnsureDir(instance.Paths.Generated.Downloads); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(instance.Paths.Generated.Downloads, "sync*.zip")
+	if err != nil {
+		return err
+	}
+
+	b := &syncBundle{
+		repository: r,
+		generator:  newOptimizedVersionGenerator(),
+		profile:    profile,
+		z:          zip.NewWriter(f),
+		has:        make(map[int]*models.SyncTargetItem),
+	}
+	if !j.full {
+		for _, i := range existing {
+			b.has[i.SceneID] = i
+		}
+	}
+
+	items, manifest, err := j.writeBundle(ctx, progress, b, target, sceneIDs, existing)
+	if err == nil {
+		err = b.z.Close()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || job.IsCancelled(ctx) {
+		if removeErr := os.Remove(f.Name()); removeErr != nil {
+			logger.Warnf("error removing %s: %v", f.Name(), removeErr)
+		}
+		if err != nil {
+			return fmt.Errorf("preparing bundle of %s: %w", target.Name, err)
+		}
+		logger.Info("Stopping due to user request")
+		return nil
+	}
+
+	// the scenes are recorded as on the device once it has downloaded the
+	// bundle, or uploaded its state
+	filename := fmt.Sprintf("%s.zip", fsutil.SanitiseBasename(target.Name))
+	if err := instance.SyncBundles.add(target.ID, f.Name(), filename, items); err != nil {
+		if removeErr := os.Remove(f.Name()); removeErr != nil {
+			logger.Warnf("error removing %s: %v", f.Name(), removeErr)
+		}
+		return fmt.Errorf("error registering file for download: %w", err)
+	}
+
+	if err := r.WithTxn(ctx, func(ctx context.Context) error {
+		partial := models.NewSyncTargetPartial()
+		partial.LastSyncedAt = models.NewOptionalTime(start)
+		_, err := r.SyncTarget.UpdatePartial(ctx, target.ID, partial)
+		return err
+	}); err != nil {
+		return fmt.Errorf("recording sync time: %w", err)
+	}
+
+	sent := 0
+	for _, s := range manifest.Scenes {
+		if s.Media != "" {
+			sent++
+		}
+	}
+	logger.Infof("Prepared bundle of %s in %s: %d scenes, %d new, %d to remove", target.Name, time.Since(start), len(manifest.Scenes), sent, len(manifest.Remove))
+	return nil
+}
+
+// writeBundle writes the scenes that fit in the budget of the target, in
+// order, followed by the manifest. It returns the scenes the device will
+// have after syncing. existing are the scenes the device had before.
+func (j *SyncBundleJob) writeBundle(ctx context.Context, progress *job.Progress, b *syncBundle, target *models.SyncTarget, sceneIDs []int, existing []*models.SyncTargetItem) ([]*models.SyncTargetItem, *jsonschema.SyncManifest, error) {
+	now := time.Now()
+	manifest := &jsonschema.SyncManifest{
+		Target:    target.Name,
+		CreatedAt: modelsjson.JSONTime{Time: now},
+		Full:      j.full,
+		Scenes:    []jsonschema.SyncScene{},
+	}
+
+	progress.SetTotal(len(sceneIDs))
+
+	var (
+		items []*models.SyncTargetItem
+		total int64
+		err   error
+	)
+	for _, id := range sceneIDs {
+		if job.IsCancelled(ctx) {
+			return nil, nil, nil
+		}
+
+		var (
+			entry *jsonschema.SyncScene
+			item  *models.SyncTargetItem
+		)
+		progress.ExecuteTask(fmt.Sprintf("Syncing scene %d", id), func() {
+			entry, item, err = b.addScene(ctx, id, target.MaxSize-total, target.MaxSize > 0)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if entry == nil {
+			// scenes after the first that does not fit are left out, so that
+			// the device has the start of the source
+			if item != nil {
+				logger.Infof("Scene %d does not fit in the size budget of %s", id, target.Name)
+				break
+			}
+			progress.Increment()
+			continue
+		}
+
+		if item.SyncedAt.IsZero() {
+			item.SyncedAt = now
+		}
+		items = append(items, item)
+		manifest.Scenes = append(manifest.Scenes, *entry)
+		total += item.Size
+		progress.Increment()
+	}
+
+	synced := make(map[int]bool)
+	for _, i := range items {
+		synced[i.SceneID] = true
+	}
+	for _, i := range existing {
+		if !synced[i.SceneID] {
+			manifest.Remove = append(manifest.Remove, i.SceneID)
+		}
+	}
+
+	data, err := jsonschema.MarshalSyncManifest(manifest)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := b.addData(jsonschema.SyncManifestFilename, data); err != nil {
+		return nil, nil, err
+	}
+
+	return items, manifest, nil
+}
+
+// ValidateSyncTarget checks that a sync target has a name, a size budget that
+// is not negative, a configured profile and a source that exists and can be
+// synced.
+func ValidateSyncTarget(ctx context.Context, r models.Repository, target *models.SyncTarget) error {
+	if target.Name == "" {
+		return errors.New("sync target name cannot be empty")
+	}
+	if target.MaxSize < 0 {
+		return fmt.Errorf("max size must not be negative, got %d", target.MaxSize)
+	}
+	if _, err := syncTargetProfile(target); err != nil {
+		return err
+	}
+
+	switch target.SourceType {
+	case models.SyncTargetSourceSavedFilter:
+		f, err := r.SavedFilter.Find(ctx, target.SourceID)
+		if err != nil {
+			return err
+		}
+		if f == nil {
+			return fmt.Errorf("saved filter %d not found", target.SourceID)
+		}
+		// converting also checks that it is a scene filter
+		if _, err := savedfilter.SceneFilter(f); err != nil {
+			return fmt.Errorf("saved filter %d cannot be synced: %w", target.SourceID, err)
+		}
+	case models.SyncTargetSourcePlaylist:
+		p, err := r.Playlist.Find(ctx, target.SourceID)
+		if err != nil {
+			return err
+		}
+		if p == nil {
+			return fmt.Errorf("playlist %d not found", target.SourceID)
+		}
+	default:
+		return fmt.Errorf("invalid sync target source %q", target.SourceType)
+	}
+
+	return nil
+}
+
+// syncTargetProfile returns the optimized version profile of the target, or
+// nil if it syncs the original files.
+func syncTargetProfile(target *models.SyncTarget) (*models.OptimizedVersionProfile, error) {
+	if target.Profile == "" {
+		return nil, nil
+	}
+
+	for _, p := range instance.Config.GetOptimizedVersionProfiles() {
+		if p.Name == target.Profile {
+			return p, nil
+		}
+	}
+
+	return nil, fmt.Errorf("optimized version profile %q is not configured", target.Profile)
+}
+
+// syncTargetSceneIDs returns the scenes of the target's source, in the order
+// of the source.
+func syncTargetSceneIDs(ctx context.Context, r models.Repository, target *models.SyncTarget) ([]int, error) {
+	switch target.SourceType {
+	case models.SyncTargetSourceSavedFilter:
+		f, err := r.SavedFilter.Find(ctx, target.SourceID)
+		if err != nil {
+			return nil, err
+		}
+		if f == nil {
+			return nil, fmt.Errorf("saved filter %d not found", target.SourceID)
+		}
+
+		sceneFilter, err := savedfilter.SceneFilter(f)
+		if err != nil {
+			return nil, err
+		}
+
+		return querySyncSceneIDs(ctx, r, sceneFilter, f.FindFilter)
+	case models.SyncTargetSourcePlaylist:
+		p, err := r.Playlist.Find(ctx, target.SourceID)
+		if err != nil {
+			return nil, err
+		}
+		if p == nil {
+			return nil, fmt.Errorf("playlist %d not found", target.SourceID)
+		}
+
+		if p.Criteria != nil && strings.TrimSpace(*p.Criteria) != "" {
+			var criteria models.PlaylistCriteria
+			if err := json.Unmarshal([]byte(*p.Criteria), &criteria); err != nil {
+				return nil, fmt.Errorf("invalid criteria of playlist %d: %w", p.ID, err)
+			}
+			return querySyncSceneIDs(ctx, r, criteria.SceneFilter, criteria.FindFilter)
+		}
+
+		items, err := r.Playlist.FindItems(ctx, p.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		// images, galleries and groups in a playlist are not synced
+		var ret []int
+		for _, i := range items {
+			if i.MediaType == models.PlaylistMediaTypeScene && i.SceneID != nil {
+				ret = append(ret, *i.SceneID)
+			}
+		}
+		return ret, nil
+	}
+
+	return nil, fmt.Errorf("unknown sync target source %q", target.SourceType)
+}
+
+func querySyncSceneIDs(ctx context.Context, r models.Repository, sceneFilter *models.SceneFilterType, findFilter *models.FindFilterType) ([]int, error) {
+	var ff models.FindFilterType
+	if findFilter != nil {
+		ff = *findFilter
+	}
+	all := models.PerPageAll
+	ff.PerPage = &all
+
+	result, err := r.Scene.Query(ctx, models.SceneQueryOptions{
+		QueryOptions: models.QueryOptions{
+			FindFilter: &ff,
+		},
+		SceneFilter: sceneFilter,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result.IDs, nil
+}
+
+// syncBundle writes the files of scenes to the zip of a bundle.
+type syncBundle struct {
+	repository models.Repository
+	generator  *generate.Generator
+	profile    *models.OptimizedVersionProfile
+	z          *zip.Writer
+
+	// has are the scenes the device has, keyed by scene id
+	has map[int]*models.SyncTargetItem
+}
+
+// addScene adds a scene to the bundle if its media fits in the remaining
+// budget. It returns a nil entry and item if the scene has no media, and a
+// nil entry with the item that would have been synced if it does not fit.
+func (b *syncBundle) addScene(ctx context.Context, sceneID int, remaining int64, limited bool) (*jsonschema.SyncScene, *models.SyncTargetItem, error) {
+	r := b.repository
+
+	var (
+		s            *models.Scene
+		sceneJSON    *jsonschema.Scene
+		cover        []byte
+		captions     map[string]string
+		funscriptSrc string
+	)
+	if err := r.WithReadTxn(ctx, func(ctx context.Context) error {
+		var err error
+		s, err = r.Scene.Find(ctx, sceneID)
+		if err != nil || s == nil {
+			return err
+		}
+
+		if err := s.LoadRelationships(ctx, r.Scene); err != nil {
+			return err
+		}
+
+		sceneJSON, _, _, err = exportSceneJSON(ctx, r, s)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := b.has[sceneID]; ok {
+			return nil
+		}
+
+		cover, err = r.Scene.GetCover(ctx, sceneID)
+		if err != nil {
+			return err
+		}
+
+		captions, err = b.captions(ctx, s)
+		if err != nil {
+			return err
+		}
+
+		funscriptSrc = video.GetFunscriptPath(s.Path)
+		if s.FunscriptPath != nil {
+			funscriptSrc = *s.FunscriptPath
+		}
+		return nil
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	if s == nil {
+		return nil, nil, nil
+	}
+
+	id := strconv.Itoa(sceneID)
+	entry := &jsonschema.SyncScene{
+		ID:       sceneID,
+		Metadata: "scenes/" + id + ".json",
+	}
+
+	item, has := b.has[sceneID]
+	if !has {
+		mediaPath, size, err := b.media(ctx, s)
+		if err != nil {
+			return nil, nil, err
+		}
+		if mediaPath == "" {
+			return nil, nil, nil
+		}
+
+		item = &models.SyncTargetItem{
+			SceneID: sceneID,
+			Size:    size,
+		}
+		if limited && size > remaining {
+			return nil, item, nil
+		}
+
+		entry.Media = "media/" + id + filepath.Ext(mediaPath)
+		if err := b.addFile(entry.Media, mediaPath, zip.Store); err != nil {
+			return nil, nil, err
+		}
+	} else if limited && item.Size > remaining {
+		return nil, item, nil
+	}
+	entry.Size = item.Size
+
+	// the cover is written separately
+	sceneJSON.Cover = ""
+	data, err := jsonschema.MarshalScene(sceneJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := b.addData(entry.Metadata, data); err != nil {
+		return nil, nil, err
+	}
+
+	if has {
+		return entry, item, nil
+	}
+
+	if len(cover) > 0 {
+		entry.Cover = "covers/" + id + ".jpg"
+		if err := b.addData(entry.Cover, cover); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for name, src := range captions {
+		p := "captions/" + id + "." + name
+		if err := b.addFile(p, src, zip.Deflate); err != nil {
+			logger.Warnf("error adding caption %s of scene %d: %v", src, sceneID, err)
+			continue
+		}
+		entry.Captions = append(entry.Captions, p)
+	}
+
+	if exists, _ := fsutil.FileExists(funscriptSrc); exists {
+		entry.Funscript = "funscripts/" + id + ".funscript"
+		if err := b.addFile(entry.Funscript, funscriptSrc, zip.Deflate); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return entry, item, nil
+}
+
+// media returns the path and size of the media of a scene for the bundle's
+// profile, generating the optimized version if it does not exist. It
+// returns an empty path if the scene has no video file.
+func (b *syncBundle) media(ctx context.Context, s *models.Scene) (string, int64, error) {
+	if b.profile == nil {
+		f := s.Files.Primary()
+		if f == nil {
+			return "", 0, nil
+		}
+		return f.Path, f.Size, nil
+	}
+
+	r := b.repository
+
+	var version *models.OptimizedVersion
+	if err := r.WithReadTxn(ctx, func(ctx context.Context) error {
+		versions, err := r.OptimizedVersion.FindBySceneID(ctx, s.ID)
+		for _, v := range versions {
+			if v.Profile == b.profile.Name {
+				version = v
+			}
+		}
+		return err
+	}); err != nil {
+		return "", 0, err
+	}
+
+	if version != nil {
+		path := instance.Paths.Scene.GetOptimizedVersionPath(version.Basename)
+		if exists, _ := fsutil.FileExists(path); exists {
+			return path, version.Size, nil
+		}
+	}
+
+	version, err := generateOptimizedVersion(ctx, r, b.generator, s.ID, b.profile)
+	if err != nil || version == nil {
+		return "", 0, err
+	}
+
+	return instance.Paths.Scene.GetOptimizedVersionPath(version.Basename), version.Size, nil
+}
+
+// captions returns the caption files of a scene keyed by language and type,
+// with those linked to the scene overriding those found next to its file.
+func (b *syncBundle) captions(ctx context.Context, s *models.Scene) (map[string]string, error) {
+	ret := make(map[string]string)
+
+	if f := s.Files.Primary(); f != nil {
+		fileCaptions, err := b.repository.File.GetCaptions(ctx, f.Base().ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range fileCaptions {
+			ret[c.LanguageCode+"."+c.CaptionType] = c.Path(s.Path)
+		}
+	}
+
+	sceneCaptions, err := b.repository.Scene.GetSceneCaptions(ctx, s.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range sceneCaptions {
+		ret[c.LanguageCode+"."+c.CaptionType] = c.Filepath
+	}
+
+	return ret, nil
+}
+
+func (b *syncBundle) addData(name string, data []byte) error {
+	w, err := b.z.Create(name)
+	if err != nil {
+		return fmt.Errorf("error creating zip entry for %s: %w", name, err)
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// addFile copies a file into the bundle. Video is stored rather than
+// compressed, as it does not compress.
+func (b *syncBundle) addFile(name string, path string, method uint16) error {
+	w, err := b.z.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   method,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating zip entry for %s: %w", name, err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(w, f); err != nil {
+		return fmt.Errorf("error writing %s to zip: %w", path, err)
+	}
+
+	return nil
+}
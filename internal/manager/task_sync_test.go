@@ -0,0 +1,110 @@
+package manager
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/fsutil"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func writeTestBundle(t *testing.T, name string) string {
+	t.Helper()
+
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte("bundle"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestSyncBundleStoreRemovesSupersededBundles(t *testing.T) {
+	s := NewSyncBundleStore(NewDownloadStore(), models.Repository{})
+
+	first := writeTestBundle(t, "first.zip")
+	second := writeTestBundle(t, "second.zip")
+
+	if err := s.add(1, first, "phone.zip", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.add(1, second, "phone.zip", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if exists, _ := fsutil.FileExists(first); exists {
+		t.Errorf("superseded bundle %s was not removed", first)
+	}
+	if exists, _ := fsutil.FileExists(second); !exists {
+		t.Errorf("latest bundle %s was removed", second)
+	}
+
+	if got := s.Get(1); got != s.m[1].hash+"/phone.zip" {
+		t.Errorf("Get(1) = %q, want the latest bundle", got)
+	}
+}
+
+func TestSyncBundleStoreExpiresBundles(t *testing.T) {
+	s := NewSyncBundleStore(NewDownloadStore(), models.Repository{})
+	s.expiry = 10 * time.Millisecond
+
+	p := writeTestBundle(t, "bundle.zip")
+	if err := s.add(1, p, "phone.zip", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if got := s.Get(1); got != "" {
+		t.Errorf("Get(1) = %q after expiry, want none", got)
+	}
+	if exists, _ := fsutil.FileExists(p); exists {
+		t.Errorf("expired bundle %s was not removed", p)
+	}
+}
+
+func TestSyncBundleStoreKeepsBundlesUntilDownloaded(t *testing.T) {
+	s := NewSyncBundleStore(NewDownloadStore(), models.Repository{})
+
+	recorded := 0
+	s.record = func(targetID int, items []*models.SyncTargetItem) {
+		recorded++
+	}
+
+	p := writeTestBundle(t, "bundle.zip")
+	if err := s.add(1, p, "phone.zip", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	hash := s.m[1].hash
+	if f := s.downloads.m[hash]; f == nil || !f.keep {
+		t.Fatalf("bundle is not kept by the download store after it is served")
+	}
+
+	url := "/downloads/" + s.Get(1)
+	head := httptest.NewRequest(http.MethodHead, url, nil)
+	s.downloads.Serve(hash, httptest.NewRecorder(), head)
+	partial := httptest.NewRequest(http.MethodGet, url, nil)
+	partial.Header.Set("Range", "bytes=0-1")
+	s.downloads.Serve(hash, httptest.NewRecorder(), partial)
+	if recorded != 0 {
+		t.Errorf("recorded after an incomplete download")
+	}
+
+	for i := 0; i < 2; i++ {
+		s.downloads.Serve(hash, httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, url, nil))
+	}
+	if recorded != 1 {
+		t.Errorf("recorded %d times after whole downloads, want 1", recorded)
+	}
+
+	if exists, _ := fsutil.FileExists(p); !exists {
+		t.Errorf("downloaded bundle %s was removed before it expired", p)
+	}
+	if got := s.Get(1); got == "" {
+		t.Errorf("Get(1) is empty after download, want the bundle until it expires")
+	}
+}
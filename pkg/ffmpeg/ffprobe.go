@@ -115,6 +115,31 @@ type VideoFile struct {
 	AudioCodec string
 }
 
+// BitDepth returns the number of bits per sample of the video stream, or 0 if
+// it is not known.
+func (v *VideoFile) BitDepth() int {
+	if v.VideoStream == nil {
+		return 0
+	}
+
+	if n, err := strconv.Atoi(v.VideoStream.BitsPerRawSample); err == nil && n > 0 {
+		return n
+	}
+
+	// otherwise it is given by the pixel format, such as yuv420p10le
+	m := pixFmtBitDepthRE.FindStringSubmatch(v.VideoStream.PixFmt)
+	if m == nil {
+		if v.VideoStream.PixFmt != "" {
+			return 8
+		}
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+var pixFmtBitDepthRE = regexp.MustCompile(`p(9|10|12|14|16)(le|be)$`)
+
 // TranscodeScale calculates the dimension scaling for a transcode, where maxSize is the maximum size of the longest dimension of the input video.
 // If no scaling is required, then returns 0, 0.
 // Returns -2 for the dimension that will scale to maintain aspect ratio.
@@ -0,0 +1,26 @@
+package ffmpeg
+
+import "testing"
+
+func TestVideoFile_BitDepth(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream *FFProbeStream
+		want   int
+	}{
+		{"no video stream", nil, 0},
+		{"unknown", &FFProbeStream{}, 0},
+		{"bits per raw sample", &FFProbeStream{BitsPerRawSample: "10", PixFmt: "yuv420p"}, 10},
+		{"8-bit pixel format", &FFProbeStream{PixFmt: "yuv420p"}, 8},
+		{"10-bit pixel format", &FFProbeStream{PixFmt: "yuv420p10le"}, 10},
+		{"12-bit pixel format", &FFProbeStream{PixFmt: "yuv444p12be"}, 12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &VideoFile{VideoStream: tt.stream}
+			if got := v.BitDepth(); got != tt.want {
+				t.Errorf("VideoFile.BitDepth() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
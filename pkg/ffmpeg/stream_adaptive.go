@@ -0,0 +1,231 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+
+	"github.com/zencoder/go-dash/v3/mpd"
+)
+
+// adaptiveResolutions are the resolutions offered by an adaptive stream below
+// the original resolution
+var adaptiveResolutions = []models.StreamingResolutionEnum{
+	models.StreamingResolutionEnumFullHd,
+	models.StreamingResolutionEnumStandardHd,
+	models.StreamingResolutionEnumStandard,
+	models.StreamingResolutionEnumLow,
+}
+
+const (
+	// bitsPerPixel is used to estimate the bandwidth of a rendition from its
+	// size and frame rate
+	bitsPerPixel     = 0.1
+	defaultFrameRate = 30
+	// audioBandwidth is that of the AAC or Opus audio of a rendition
+	audioBandwidth = 128000
+)
+
+// Rendition is one of the qualities of an adaptive stream. Renditions are
+// transcoded lazily by the StreamManager as segments of them are requested,
+// and share a segment grid so that players can switch between them at any
+// segment.
+type Rendition struct {
+	Resolution models.StreamingResolutionEnum
+	// Width and Height are 0 if the size of the file is unknown
+	Width  int
+	Height int
+	// Bandwidth is the estimated bit rate of the rendition
+	Bandwidth int
+}
+
+func newRendition(vf *models.VideoFile, resolution models.StreamingResolutionEnum, size int) Rendition {
+	ret := Rendition{
+		Resolution: resolution,
+		Width:      vf.Width,
+		Height:     vf.Height,
+	}
+
+	// scale the smaller dimension to size, as the transcode does
+	if videoSize := min(vf.Width, vf.Height); size > 0 && size < videoSize {
+		scale := float64(size) / float64(videoSize)
+		ret.Width = int(math.Round(float64(vf.Width)*scale/2)) * 2
+		ret.Height = int(math.Round(float64(vf.Height)*scale/2)) * 2
+	}
+
+	frameRate := vf.FrameRateFinite()
+	if frameRate <= 0 {
+		frameRate = defaultFrameRate
+	}
+	ret.Bandwidth = int(float64(ret.Width*ret.Height) * frameRate * bitsPerPixel)
+	if ProbeAudioCodec(vf.AudioCodec) != MissingUnsupported {
+		ret.Bandwidth += audioBandwidth
+	}
+
+	return ret
+}
+
+// AdaptiveRenditions returns the renditions of an adaptive stream of the
+// file, largest first, limited to maxSize. The original resolution is
+// re-encoded rather than copied, so that its segments fall on the same grid as
+// the others.
+func AdaptiveRenditions(vf *models.VideoFile, maxSize models.StreamingResolutionEnum) []Rendition {
+	limit := maxSize.GetMaxResolution()
+	videoSize := min(vf.Width, vf.Height)
+
+	// without the size of the file, only the largest rendition allowed can be
+	// offered
+	if videoSize == 0 {
+		return []Rendition{newRendition(vf, maxSize, limit)}
+	}
+
+	var ret []Rendition
+	if limit == 0 || videoSize <= limit {
+		ret = append(ret, newRendition(vf, models.StreamingResolutionEnumOriginal, 0))
+	}
+
+	for _, resolution := range adaptiveResolutions {
+		size := resolution.GetMaxResolution()
+		if size >= videoSize || (limit != 0 && size > limit) {
+			continue
+		}
+		ret = append(ret, newRendition(vf, resolution, size))
+	}
+
+	return ret
+}
+
+func (sm *StreamManager) adaptiveRenditions(vf *models.VideoFile) []Rendition {
+	return AdaptiveRenditions(vf, sm.config.GetMaxStreamingTranscodeSize())
+}
+
+// ServeAdaptiveManifest serves a manifest offering the file in each of its
+// adaptive renditions, so that the player can switch between them as its
+// bandwidth allows. The manifest must be served alongside the single rendition
+// manifest of streamType, which serves the segments of each rendition.
+func (sm *StreamManager) ServeAdaptiveManifest(w http.ResponseWriter, r *http.Request, streamType *StreamType, vf *models.VideoFile, options ManifestOptions) {
+	switch streamType {
+	case StreamTypeHLS:
+		serveHLSMasterPlaylist(sm, w, r, vf, options.IFrames)
+	case StreamTypeDASHVideo:
+		serveDASHAdaptiveManifest(sm, w, r, vf)
+	default:
+		http.Error(w, fmt.Sprintf("adaptive streaming is not supported for %s", streamType), http.StatusBadRequest)
+	}
+}
+
+// serveHLSMasterPlaylist serves an HLS master playlist of the renditions. The
+// URLs of the rendition playlists are of the form
+// stream.m3u8?resolution={resolution}{&urlQuery}, relative to r.URL. iframes,
+// if set, is advertised for trick play.
+func serveHLSMasterPlaylist(sm *StreamManager, w http.ResponseWriter, r *http.Request, vf *models.VideoFile, iframes *IFrameStream) {
+	if sm.cacheDir == "" {
+		logger.Error("[transcode] cannot live transcode with HLS because cache dir is unset")
+		http.Error(w, "cannot live transcode with HLS because cache dir is unset", http.StatusServiceUnavailable)
+		return
+	}
+
+	apikey := r.URL.Query().Get(apiKeyParamKey)
+
+	var buf bytes.Buffer
+
+	fmt.Fprint(&buf, "#EXTM3U\n")
+	fmt.Fprint(&buf, "#EXT-X-VERSION:3\n")
+
+	for _, rendition := range sm.adaptiveRenditions(vf) {
+		urlQuery := url.Values{}
+		urlQuery.Set(resolutionParamKey, rendition.Resolution.String())
+
+		// TODO - this needs to be handled outside of this package
+		if apikey != "" {
+			urlQuery.Set(apiKeyParamKey, apikey)
+		}
+
+		fmt.Fprintf(&buf, "#EXT-X-STREAM-INF:BANDWIDTH=%d", rendition.Bandwidth)
+		if rendition.Width != 0 && rendition.Height != 0 {
+			fmt.Fprintf(&buf, ",RESOLUTION=%dx%d", rendition.Width, rendition.Height)
+		}
+		fmt.Fprintf(&buf, ",NAME=\"%s\"\n", rendition.Resolution)
+		fmt.Fprintf(&buf, "stream.m3u8?%s\n", urlQuery.Encode())
+	}
+
+	if iframes != nil {
+		var query string
+		if apikey != "" {
+			query = url.Values{apiKeyParamKey: {apikey}}.Encode()
+		}
+		writeIFrameStreamInf(&buf, iframes, query)
+	}
+
+	w.Header().Set("Content-Type", MimeHLS)
+	utils.ServeStaticContent(w, r, buf.Bytes())
+}
+
+// serveDASHAdaptiveManifest serves a DASH manifest with a video representation
+// for each rendition, identified by its resolution. Segments are fetched from
+// stream.mpd/, relative to r.URL.
+func serveDASHAdaptiveManifest(sm *StreamManager, w http.ResponseWriter, r *http.Request, vf *models.VideoFile) {
+	if sm.cacheDir == "" {
+		logger.Error("[transcode] cannot live transcode with DASH because cache dir is unset")
+		http.Error(w, "cannot live transcode files with DASH because cache dir is unset", http.StatusServiceUnavailable)
+		return
+	}
+
+	probeResult, err := sm.ffprobe.NewVideoFile(vf.Path)
+	if err != nil {
+		logger.Warnf("[transcode] error generating DASH manifest: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	framerate := dashFramerate(probeResult, vf)
+
+	urlQuery := url.Values{}
+
+	// TODO - this needs to be handled outside of this package
+	apikey := r.URL.Query().Get(apiKeyParamKey)
+	if apikey != "" {
+		urlQuery.Set(apiKeyParamKey, apikey)
+	}
+
+	audioQueryString := ""
+	if len(urlQuery) > 0 {
+		audioQueryString = "?" + urlQuery.Encode()
+	}
+
+	// the representation id is substituted into the segment URLs, so must not
+	// be escaped
+	videoQueryString := "?" + resolutionParamKey + "=$RepresentationID$"
+	if len(urlQuery) > 0 {
+		videoQueryString += "&" + urlQuery.Encode()
+	}
+
+	mediaDuration := mpd.Duration(time.Duration(probeResult.FileDuration * float64(time.Second)))
+	m := mpd.NewMPD(mpd.DASH_PROFILE_LIVE, mediaDuration.String(), "PT4.0S")
+	m.BaseURL = "stream.mpd/"
+
+	video, _ := m.AddNewAdaptationSetVideo(MimeWebmVideo, "progressive", true, 1)
+	_, _ = video.SetNewSegmentTemplate(2, "init_v.webm"+videoQueryString, "$Number$_v.webm"+videoQueryString, 0, 1)
+	for _, rendition := range sm.adaptiveRenditions(vf) {
+		_, _ = video.AddNewRepresentationVideo(int64(rendition.Bandwidth), "vp09.00.40.08", rendition.Resolution.String(), framerate, int64(rendition.Width), int64(rendition.Height))
+	}
+
+	if ProbeAudioCodec(vf.AudioCodec) != MissingUnsupported {
+		audio, _ := m.AddNewAdaptationSetAudio(MimeWebmAudio, true, 1, "und")
+		_, _ = audio.SetNewSegmentTemplate(2, "init_a.webm"+audioQueryString, "$Number$_a.webm"+audioQueryString, 0, 1)
+		_, _ = audio.AddNewRepresentationAudio(48000, 96000, "opus", "1")
+	}
+
+	var buf bytes.Buffer
+	_ = m.Write(&buf)
+
+	w.Header().Set("Content-Type", MimeDASH)
+	utils.ServeStaticContent(w, r, buf.Bytes())
+}
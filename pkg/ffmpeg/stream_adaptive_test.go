@@ -0,0 +1,57 @@
+package ffmpeg
+
+import (
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdaptiveRenditions(t *testing.T) {
+	const (
+		original   = models.StreamingResolutionEnumOriginal
+		fullHD     = models.StreamingResolutionEnumFullHd
+		standardHD = models.StreamingResolutionEnumStandardHd
+		standard   = models.StreamingResolutionEnumStandard
+		low        = models.StreamingResolutionEnumLow
+	)
+
+	tests := []struct {
+		name    string
+		vf      *models.VideoFile
+		maxSize models.StreamingResolutionEnum
+		want    []models.StreamingResolutionEnum
+	}{
+		{"4k", &models.VideoFile{Width: 3840, Height: 2160}, original, []models.StreamingResolutionEnum{original, fullHD, standardHD, standard, low}},
+		{"1080p", &models.VideoFile{Width: 1920, Height: 1080}, original, []models.StreamingResolutionEnum{original, standardHD, standard, low}},
+		{"portrait", &models.VideoFile{Width: 720, Height: 1280}, original, []models.StreamingResolutionEnum{original, standard, low}},
+		{"limited", &models.VideoFile{Width: 1920, Height: 1080}, standardHD, []models.StreamingResolutionEnum{standardHD, standard, low}},
+		{"within limit", &models.VideoFile{Width: 1280, Height: 720}, fullHD, []models.StreamingResolutionEnum{original, standard, low}},
+		{"unknown size", &models.VideoFile{}, standardHD, []models.StreamingResolutionEnum{standardHD}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []models.StreamingResolutionEnum
+			for _, r := range AdaptiveRenditions(tt.vf, tt.maxSize) {
+				got = append(got, r.Resolution)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAdaptiveRenditionSize(t *testing.T) {
+	vf := &models.VideoFile{Width: 1920, Height: 1080, FrameRate: 30, AudioCodec: "aac"}
+	renditions := AdaptiveRenditions(vf, models.StreamingResolutionEnumOriginal)
+
+	assert.Equal(t, 1920, renditions[0].Width)
+	assert.Equal(t, 1080, renditions[0].Height)
+
+	// 720p
+	assert.Equal(t, 1280, renditions[1].Width)
+	assert.Equal(t, 720, renditions[1].Height)
+
+	for i := 1; i < len(renditions); i++ {
+		assert.Less(t, renditions[i].Bandwidth, renditions[i-1].Bandwidth)
+	}
+}
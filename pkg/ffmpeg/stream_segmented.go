@@ -55,7 +55,7 @@ const (
 type StreamType struct {
 	Name          string
 	SegmentType   *SegmentType
-	ServeManifest func(sm *StreamManager, w http.ResponseWriter, r *http.Request, vf *models.VideoFile, resolution string)
+	ServeManifest func(sm *StreamManager, w http.ResponseWriter, r *http.Request, vf *models.VideoFile, resolution string, options ManifestOptions)
 	Args          func(codec VideoCodec, segment int, videoFilter VideoFilter, videoOnly bool, outputDir string) Args
 }
 
@@ -412,7 +412,7 @@ func segmentExists(path string) bool {
 
 // serveHLSManifest serves a generated HLS playlist. The URLs for the segments
 // are of the form {r.URL}/%d.ts{?urlQuery} where %d is the segment index.
-func serveHLSManifest(sm *StreamManager, w http.ResponseWriter, r *http.Request, vf *models.VideoFile, resolution string) {
+func serveHLSManifest(sm *StreamManager, w http.ResponseWriter, r *http.Request, vf *models.VideoFile, resolution string, options ManifestOptions) {
 	if sm.cacheDir == "" {
 		logger.Error("[transcode] cannot live transcode with HLS because cache dir is unset")
 		http.Error(w, "cannot live transcode with HLS because cache dir is unset", http.StatusServiceUnavailable)
@@ -457,6 +457,10 @@ func serveHLSManifest(sm *StreamManager, w http.ResponseWriter, r *http.Request,
 	fmt.Fprint(&buf, "#EXT-X-MEDIA-SEQUENCE:0\n")
 	fmt.Fprintf(&buf, "#EXT-X-TARGETDURATION:%d\n", segmentLength)
 	fmt.Fprint(&buf, "#EXT-X-PLAYLIST-TYPE:VOD\n")
+	if len(options.Chapters) > 0 {
+		writeHLSProgramDate(&buf)
+		writeHLSChapters(&buf, options.Chapters)
+	}
 
 	leftover := probeResult.FileDuration
 	segment := 0
@@ -481,7 +485,7 @@ func serveHLSManifest(sm *StreamManager, w http.ResponseWriter, r *http.Request,
 }
 
 // serveDASHManifest serves a generated DASH manifest.
-func serveDASHManifest(sm *StreamManager, w http.ResponseWriter, r *http.Request, vf *models.VideoFile, resolution string) {
+func serveDASHManifest(sm *StreamManager, w http.ResponseWriter, r *http.Request, vf *models.VideoFile, resolution string, options ManifestOptions) {
 	if sm.cacheDir == "" {
 		logger.Error("[transcode] cannot live transcode with DASH because cache dir is unset")
 		http.Error(w, "cannot live transcode files with DASH because cache dir is unset", http.StatusServiceUnavailable)
@@ -495,36 +499,12 @@ func serveDASHManifest(sm *StreamManager, w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	var framerate string
-	var videoWidth int
-	var videoHeight int
-	videoStream := probeResult.VideoStream
-	if videoStream != nil {
-		framerate = videoStream.AvgFrameRate
+	framerate := dashFramerate(probeResult, vf)
+	videoWidth := vf.Width
+	videoHeight := vf.Height
+	if videoStream := probeResult.VideoStream; videoStream != nil {
 		videoWidth = videoStream.Width
 		videoHeight = videoStream.Height
-	} else {
-		// extract the framerate fraction from the file framerate
-		// framerates 0.1% below round numbers are common,
-		// attempt to infer when this is the case
-		fileFramerate := vf.FrameRate
-		rate1001, off1001 := math.Modf(fileFramerate * 1.001)
-		var numerator int
-		var denominator int
-		switch {
-		case off1001 < 0.005:
-			numerator = int(rate1001) * 1000
-			denominator = 1001
-		case off1001 > 0.995:
-			numerator = (int(rate1001) + 1) * 1000
-			denominator = 1001
-		default:
-			numerator = int(fileFramerate * 1000)
-			denominator = 1000
-		}
-		framerate = fmt.Sprintf("%d/%d", numerator, denominator)
-		videoHeight = vf.Height
-		videoWidth = vf.Width
 	}
 
 	urlQuery := url.Values{}
@@ -585,8 +565,35 @@ func serveDASHManifest(sm *StreamManager, w http.ResponseWriter, r *http.Request
 	utils.ServeStaticContent(w, r, buf.Bytes())
 }
 
-func (sm *StreamManager) ServeManifest(w http.ResponseWriter, r *http.Request, streamType *StreamType, vf *models.VideoFile, resolution string) {
-	streamType.ServeManifest(sm, w, r, vf, resolution)
+// dashFramerate returns the frame rate of the video as a fraction
+func dashFramerate(probeResult *VideoFile, vf *models.VideoFile) string {
+	if videoStream := probeResult.VideoStream; videoStream != nil {
+		return videoStream.AvgFrameRate
+	}
+
+	// extract the framerate fraction from the file framerate
+	// framerates 0.1% below round numbers are common,
+	// attempt to infer when this is the case
+	fileFramerate := vf.FrameRate
+	rate1001, off1001 := math.Modf(fileFramerate * 1.001)
+	var numerator int
+	var denominator int
+	switch {
+	case off1001 < 0.005:
+		numerator = int(rate1001) * 1000
+		denominator = 1001
+	case off1001 > 0.995:
+		numerator = (int(rate1001) + 1) * 1000
+		denominator = 1001
+	default:
+		numerator = int(fileFramerate * 1000)
+		denominator = 1000
+	}
+	return fmt.Sprintf("%d/%d", numerator, denominator)
+}
+
+func (sm *StreamManager) ServeManifest(w http.ResponseWriter, r *http.Request, streamType *StreamType, vf *models.VideoFile, resolution string, options ManifestOptions) {
+	streamType.ServeManifest(sm, w, r, vf, resolution, options)
 }
 
 func (sm *StreamManager) serveWaitingSegment(w http.ResponseWriter, r *http.Request, segment *waitingSegment) {
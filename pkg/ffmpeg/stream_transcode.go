@@ -145,6 +145,23 @@ var (
 			return
 		},
 	}
+	// StreamTypeMPEGTS is H.264 and AAC in MPEG-TS, which DLNA renderers
+	// play most widely
+	StreamTypeMPEGTS = StreamFormat{
+		MimeType: MimeMpegTS,
+		Args: func(codec VideoCodec, videoFilter VideoFilter, videoOnly bool) (args Args) {
+			args = CodecInit(codec)
+			args = args.VideoFilter(videoFilter)
+			if videoOnly {
+				args = args.SkipAudio()
+			} else {
+				args = args.AudioCodec(AudioCodecAAC)
+				args = append(args, "-ac", "2")
+			}
+			args = args.Format(FormatMpegTS)
+			return
+		},
+	}
 )
 
 type TranscodeOptions struct {
@@ -152,6 +169,10 @@ type TranscodeOptions struct {
 	VideoFile  *models.VideoFile
 	Resolution string
 	StartTime  float64
+	// Remux copies the video stream into the new container rather than
+	// encoding it. Only used for MPEG-TS, where the client decides whether
+	// the video can be copied.
+	Remux bool
 }
 
 func (o TranscodeOptions) FileGetCodec(sm *StreamManager, maxTranscodeSize int) (codec VideoCodec) {
@@ -184,6 +205,14 @@ func (o TranscodeOptions) FileGetCodec(sm *StreamManager, maxTranscodeSize int)
 		}
 	case MimeMkvVideo:
 		codec = VideoCodecCopy
+	case MimeMpegTS:
+		if o.Remux {
+			return VideoCodecCopy
+		}
+		codec = VideoCodecLibX264
+		if hwcodec := sm.encoder.hwCodecHLSCompatible(); hwcodec != nil && sm.config.GetTranscodeHardwareAcceleration() {
+			codec = *hwcodec
+		}
 	}
 
 	return codec
@@ -214,7 +243,11 @@ func (o TranscodeOptions) makeStreamArgs(sm *StreamManager) Args {
 
 	videoOnly := ProbeAudioCodec(o.VideoFile.AudioCodec) == MissingUnsupported
 
-	videoFilter := sm.encoder.hwMaxResFilter(codec, o.VideoFile, maxTranscodeSize, fullhw)
+	var videoFilter VideoFilter
+	// a copied stream cannot be filtered
+	if codec != VideoCodecCopy {
+		videoFilter = sm.encoder.hwMaxResFilter(codec, o.VideoFile, maxTranscodeSize, fullhw)
+	}
 
 	args = append(args, o.StreamType.Args(codec, videoFilter, videoOnly)...)
 
@@ -0,0 +1,190 @@
+package ffmpeg
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// ManifestOptions are what a manifest advertises besides the stream itself.
+type ManifestOptions struct {
+	// Chapters are written to HLS media playlists as EXT-X-DATERANGE tags
+	Chapters []Chapter
+	// IFrames is the I-frame playlist advertised by HLS master playlists, if
+	// the scene has one
+	IFrames *IFrameStream
+}
+
+// Chapter is a section of a scene, such as a scene marker.
+type Chapter struct {
+	ID    string
+	Title string
+	// Start and End are in seconds. End is 0 if the chapter has no end.
+	Start float64
+	End   float64
+}
+
+// IFrameStream describes an I-frame playlist to the master playlist.
+type IFrameStream struct {
+	// URI is relative to the master playlist
+	URI    string
+	Width  int
+	Height int
+	// Bandwidth is the bit rate of the I-frames over the length of the scene
+	Bandwidth int
+}
+
+// ByteRange is a range of bytes of a file.
+type ByteRange struct {
+	Offset int64
+	Length int64
+}
+
+func (r ByteRange) String() string {
+	return fmt.Sprintf("%d@%d", r.Length, r.Offset)
+}
+
+// IFrame is an I-frame in an I-frame playlist.
+type IFrame struct {
+	// Duration is the time in seconds until the next I-frame
+	Duration float64
+	Range    ByteRange
+}
+
+// hlsChapterEpoch is the program date of the start of a scene. Chapters are
+// date ranges, so their times are dates relative to it.
+var hlsChapterEpoch = time.Unix(0, 0).UTC()
+
+const (
+	hlsDateFormat   = "2006-01-02T15:04:05.000Z07:00"
+	hlsChapterClass = "org.stashapp.chapter"
+)
+
+// ReadFragments returns the initialization section of a fragmented MP4 and
+// its fragments, each being a moof box and the boxes after it up to the next
+// moof.
+func ReadFragments(r io.ReadSeeker) (init ByteRange, fragments []ByteRange, err error) {
+	var offset int64
+	header := make([]byte, 16)
+	for {
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return init, nil, fmt.Errorf("reading box at %d: %w", offset, err)
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		boxType := string(header[4:8])
+		switch size {
+		case 0:
+			// the box runs to the end of the file
+			end, err := r.Seek(0, io.SeekEnd)
+			if err != nil {
+				return init, nil, err
+			}
+			size = end - offset
+		case 1:
+			if _, err := io.ReadFull(r, header[8:16]); err != nil {
+				return init, nil, fmt.Errorf("reading box at %d: %w", offset, err)
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+		}
+		if size < 8 {
+			return init, nil, fmt.Errorf("invalid size %d of %q box at %d", size, boxType, offset)
+		}
+
+		if boxType == "moof" {
+			fragments = append(fragments, ByteRange{Offset: offset})
+		}
+
+		offset += size
+		if n := len(fragments); n > 0 {
+			fragments[n-1].Length = offset - fragments[n-1].Offset
+		} else {
+			init.Length = offset
+		}
+
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return init, nil, err
+		}
+	}
+
+	if init.Length == 0 || len(fragments) == 0 {
+		return init, nil, errors.New("not a fragmented MP4")
+	}
+
+	return init, fragments, nil
+}
+
+// hlsQuotedString makes s safe to use as a quoted string attribute, which
+// cannot contain double quotes or line breaks.
+func hlsQuotedString(s string) string {
+	return strings.NewReplacer("\"", "'", "\r", " ", "\n", " ").Replace(s)
+}
+
+// writeHLSChapters writes chapters as EXT-X-DATERANGE tags. Date ranges
+// require a program date, so the playlist must have an
+// EXT-X-PROGRAM-DATE-TIME of hlsChapterEpoch at its start.
+func writeHLSChapters(w io.Writer, chapters []Chapter) {
+	for _, c := range chapters {
+		start := hlsChapterEpoch.Add(time.Duration(c.Start * float64(time.Second)))
+		fmt.Fprintf(w, "#EXT-X-DATERANGE:ID=\"%s\",CLASS=\"%s\",START-DATE=\"%s\"", hlsQuotedString(c.ID), hlsChapterClass, start.Format(hlsDateFormat))
+		if c.End > c.Start {
+			fmt.Fprintf(w, ",DURATION=%.3f", c.End-c.Start)
+		}
+		fmt.Fprintf(w, ",X-TITLE=\"%s\"\n", hlsQuotedString(c.Title))
+	}
+}
+
+func writeHLSProgramDate(w io.Writer) {
+	fmt.Fprintf(w, "#EXT-X-PROGRAM-DATE-TIME:%s\n", hlsChapterEpoch.Format(hlsDateFormat))
+}
+
+// WriteIFramePlaylist writes an HLS I-frame playlist of frames, which are
+// byte ranges of the fragmented MP4 at uri, whose initialization section is
+// init.
+func WriteIFramePlaylist(w io.Writer, uri string, init ByteRange, frames []IFrame, chapters []Chapter) error {
+	var targetDuration float64
+	for _, f := range frames {
+		targetDuration = math.Max(targetDuration, f.Duration)
+	}
+
+	fmt.Fprint(w, "#EXTM3U\n")
+	// fragmented MP4 segments need version 7
+	fmt.Fprint(w, "#EXT-X-VERSION:7\n")
+	fmt.Fprintf(w, "#EXT-X-TARGETDURATION:%d\n", int(math.Ceil(targetDuration)))
+	fmt.Fprint(w, "#EXT-X-MEDIA-SEQUENCE:0\n")
+	fmt.Fprint(w, "#EXT-X-PLAYLIST-TYPE:VOD\n")
+	fmt.Fprint(w, "#EXT-X-I-FRAMES-ONLY\n")
+	fmt.Fprintf(w, "#EXT-X-MAP:URI=\"%s\",BYTERANGE=\"%s\"\n", uri, init)
+	if len(chapters) > 0 {
+		writeHLSProgramDate(w)
+		writeHLSChapters(w, chapters)
+	}
+
+	for _, f := range frames {
+		fmt.Fprintf(w, "#EXTINF:%f,\n", f.Duration)
+		fmt.Fprintf(w, "#EXT-X-BYTERANGE:%s\n", f.Range)
+		fmt.Fprintf(w, "%s\n", uri)
+	}
+
+	_, err := fmt.Fprint(w, "#EXT-X-ENDLIST\n")
+	return err
+}
+
+func writeIFrameStreamInf(w io.Writer, s *IFrameStream, query string) {
+	fmt.Fprintf(w, "#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=%d", s.Bandwidth)
+	if s.Width != 0 && s.Height != 0 {
+		fmt.Fprintf(w, ",RESOLUTION=%dx%d", s.Width, s.Height)
+	}
+	uri := s.URI
+	if query != "" {
+		uri += "?" + query
+	}
+	fmt.Fprintf(w, ",URI=\"%s\"\n", uri)
+}
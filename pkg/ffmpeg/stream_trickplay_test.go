@@ -0,0 +1,80 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mp4Box(boxType string, payload int) []byte {
+	b := make([]byte, 8+payload)
+	binary.BigEndian.PutUint32(b, uint32(len(b)))
+	copy(b[4:], boxType)
+	return b
+}
+
+func TestReadFragments(t *testing.T) {
+	var buf bytes.Buffer
+	for _, b := range [][]byte{
+		mp4Box("ftyp", 16), // 0-24
+		mp4Box("moov", 92), // 24-124
+		mp4Box("moof", 32), // 124-164
+		mp4Box("mdat", 60), // 164-232
+		mp4Box("moof", 32), // 232-272
+		mp4Box("mdat", 20), // 272-300
+	} {
+		buf.Write(b)
+	}
+
+	init, fragments, err := ReadFragments(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, ByteRange{Offset: 0, Length: 124}, init)
+	assert.Equal(t, []ByteRange{{Offset: 124, Length: 108}, {Offset: 232, Length: 68}}, fragments)
+
+	_, _, err = ReadFragments(bytes.NewReader(mp4Box("ftyp", 16)))
+	assert.Error(t, err, "unfragmented file")
+}
+
+func TestWriteIFramePlaylist(t *testing.T) {
+	var buf bytes.Buffer
+	frames := []IFrame{
+		{Duration: 12.5, Range: ByteRange{Offset: 124, Length: 108}},
+		{Duration: 10, Range: ByteRange{Offset: 232, Length: 68}},
+	}
+	chapters := []Chapter{
+		{ID: "1", Title: "The \"start\"", Start: 0},
+		{ID: "2", Title: "Middle", Start: 61.5, End: 70},
+	}
+
+	err := WriteIFramePlaylist(&buf, "trickplay.mp4", ByteRange{Length: 124}, frames, chapters)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	want := strings.Join([]string{
+		"#EXTM3U",
+		"#EXT-X-VERSION:7",
+		"#EXT-X-TARGETDURATION:13",
+		"#EXT-X-MEDIA-SEQUENCE:0",
+		"#EXT-X-PLAYLIST-TYPE:VOD",
+		"#EXT-X-I-FRAMES-ONLY",
+		`#EXT-X-MAP:URI="trickplay.mp4",BYTERANGE="124@0"`,
+		"#EXT-X-PROGRAM-DATE-TIME:1970-01-01T00:00:00.000Z",
+		`#EXT-X-DATERANGE:ID="1",CLASS="org.stashapp.chapter",START-DATE="1970-01-01T00:00:00.000Z",X-TITLE="The 'start'"`,
+		`#EXT-X-DATERANGE:ID="2",CLASS="org.stashapp.chapter",START-DATE="1970-01-01T00:01:01.500Z",DURATION=8.500,X-TITLE="Middle"`,
+		"#EXTINF:12.500000,",
+		"#EXT-X-BYTERANGE:108@124",
+		"trickplay.mp4",
+		"#EXTINF:10.000000,",
+		"#EXT-X-BYTERANGE:68@232",
+		"trickplay.mp4",
+		"#EXT-X-ENDLIST",
+		"",
+	}, "\n")
+	assert.Equal(t, want, buf.String())
+}
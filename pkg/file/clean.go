@@ -189,7 +189,9 @@ func (j *cleanJob) assessFiles(ctx context.Context, toDelete *deleteSet) error {
 	// true = zip exists, false = zip is missing.
 	zipExistsCache := make(map[string]bool)
 
-	if err := r.WithReadTxn(ctx, func(ctx context.Context) error {
+	// don't use a transaction, so that one is not held open while the job is
+	// paused
+	if err := r.WithDB(ctx, func(ctx context.Context) error {
 		for more {
 			if job.IsCancelled(ctx) {
 				return nil
@@ -299,7 +301,9 @@ func (j *cleanJob) assessFolders(ctx context.Context, toDelete *deleteSet) error
 
 	includeZipContents := !j.options.IgnoreZipFileContents
 
-	if err := r.WithReadTxn(ctx, func(ctx context.Context) error {
+	// don't use a transaction, so that one is not held open while the job is
+	// paused
+	if err := r.WithDB(ctx, func(ctx context.Context) error {
 		for more {
 			if job.IsCancelled(ctx) {
 				return nil
@@ -0,0 +1,325 @@
+package iptv
+
+import (
+	"time"
+)
+
+// Dayparting.
+//
+// A Cycle airs the same mix around the clock. A Grid lays a week of blocks
+// over it — short scenes in the afternoon, features after 22:00 — each with
+// its own Cycle, and airs the channel's base cycle whenever no block is on.
+//
+// It keeps the property everything else here depends on: what is on air is a
+// pure function of the wall clock. A block's rotation does not restart every
+// time the block comes round, which would air the same opening programmes
+// every evening; instead each airing of a block picks up where the previous
+// one stopped, by counting how much airtime the block has had since Epoch.
+// That count is arithmetic on the calendar, so it needs no state either.
+
+// Block is a weekly window airing its own cycle.
+type Block struct {
+	// Name labels the block's programmes in the guide.
+	Name string
+	// Days are the days the block starts on. Empty means every day.
+	Days []time.Weekday
+	// Start and End are times of day, as offsets from midnight. An End at or
+	// before Start runs past midnight into the next day.
+	Start time.Duration
+	End   time.Duration
+	Cycle *Cycle
+}
+
+// length is the nominal duration of one airing of the block. A daylight
+// saving change makes the real one an hour longer or shorter, which only
+// moves where the block's last programme is cut.
+func (b Block) length() time.Duration {
+	l := b.End - b.Start
+	if l <= 0 {
+		l += 24 * time.Hour
+	}
+	return l
+}
+
+func (b Block) airsOn(day time.Weekday) bool {
+	if len(b.Days) == 0 {
+		return true
+	}
+	for _, d := range b.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Grid is a channel's weekly schedule: blocks at fixed local times of day, with
+// Base airing whenever no block is on.
+//
+// Blocks are expected not to overlap. Where they do, the earlier one in Blocks
+// wins, but the later one is not cut short for it.
+type Grid struct {
+	Base   *Cycle
+	Blocks []Block
+	// Location the blocks' times of day are in. Nil means time.Local.
+	Location *time.Location
+}
+
+// NewGrid returns a grid of blocks over base. A block with nothing to air is
+// dropped, so that its time falls back to base instead of going dark.
+func NewGrid(base *Cycle, blocks []Block, loc *time.Location) *Grid {
+	g := &Grid{Base: base, Location: loc}
+	for _, b := range blocks {
+		if !b.Cycle.Empty() {
+			g.Blocks = append(g.Blocks, b)
+		}
+	}
+	return g
+}
+
+// Empty reports whether the grid has nothing to air at any time.
+func (g *Grid) Empty() bool {
+	if g == nil {
+		return true
+	}
+	if !g.Base.Empty() {
+		return false
+	}
+	for _, b := range g.Blocks {
+		if !b.Cycle.Empty() {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *Grid) location() *time.Location {
+	if g.Location == nil {
+		return time.Local
+	}
+	return g.Location
+}
+
+// occurrence is one airing of a block.
+type occurrence struct {
+	block      int
+	start, end time.Time
+	// index counts the airings of the block between Epoch and this one.
+	index int64
+}
+
+// dayNumber counts calendar days since Epoch. It is taken from the date
+// rather than from elapsed time so that a daylight saving change cannot shift
+// it.
+func dayNumber(y int, m time.Month, d int) int64 {
+	return int64(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Sub(Epoch) / (24 * time.Hour))
+}
+
+// occurrence returns the airing of a block starting on the given date, if the
+// block airs that day.
+func (g *Grid) occurrence(bi int, y int, m time.Month, d int) (occurrence, bool) {
+	b := g.Blocks[bi]
+	loc := g.location()
+
+	// normalise the date first: d may be out of range
+	date := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	y, m, d = date.Date()
+	if !b.airsOn(date.Weekday()) {
+		return occurrence{}, false
+	}
+
+	// time.Date applies the offset to the wall clock, so 22:00 stays 22:00
+	// across a daylight saving change
+	start := time.Date(y, m, d, 0, 0, 0, int(b.Start), loc)
+	endDay := d
+	if b.End <= b.Start {
+		endDay++
+	}
+	end := time.Date(y, m, endDay, 0, 0, 0, int(b.End), loc)
+
+	dn := dayNumber(y, m, d)
+	var index int64
+	if len(b.Days) == 0 {
+		index = dn
+	} else {
+		// Epoch is a Monday, so this is the position within a Monday-first week
+		week := floorDiv(dn, 7)
+		pos := int(dn - week*7)
+
+		var days [7]bool
+		for _, wd := range b.Days {
+			days[(int(wd)+6)%7] = true
+		}
+		perWeek, before := 0, 0
+		for i, on := range days {
+			if !on {
+				continue
+			}
+			perWeek++
+			if i < pos {
+				before++
+			}
+		}
+		index = week*int64(perWeek) + int64(before)
+	}
+
+	return occurrence{block: bi, start: start, end: end, index: index}, true
+}
+
+// around returns the airings of every block starting within a week of t
+// either way, which covers the airing containing t, the last one before it
+// and the next one after it.
+func (g *Grid) around(t time.Time) []occurrence {
+	if len(g.Blocks) == 0 {
+		return nil
+	}
+
+	y, m, d := t.In(g.location()).Date()
+	var ret []occurrence
+	for off := -8; off <= 8; off++ {
+		for bi := range g.Blocks {
+			if o, ok := g.occurrence(bi, y, m, d+off); ok {
+				ret = append(ret, o)
+			}
+		}
+	}
+	return ret
+}
+
+// At returns the programme airing at t. Start and End are where the airing
+// begins and ends on this channel, which for a programme cut by a block
+// boundary is not where the programme itself would: Offset says how far into
+// the programme Start is.
+func (g *Grid) At(t time.Time) (Airing, bool) {
+	a, ok, _ := g.at(t)
+	return a, ok
+}
+
+// at is At, also returning when the next block starts, or the zero time if
+// none does within a week.
+func (g *Grid) at(t time.Time) (Airing, bool, time.Time) {
+	var (
+		active    = -1
+		prevEnd   time.Time
+		nextStart time.Time
+	)
+
+	occs := g.around(t)
+	for i, o := range occs {
+		if !o.start.After(t) && t.Before(o.end) {
+			if active < 0 || o.block < occs[active].block {
+				active = i
+			}
+		}
+		if !o.end.After(t) && o.end.After(prevEnd) {
+			prevEnd = o.end
+		}
+		if o.start.After(t) && (nextStart.IsZero() || o.start.Before(nextStart)) {
+			nextStart = o.start
+		}
+	}
+
+	if active >= 0 {
+		a, ok := g.blockAiring(occs[active], t)
+		return a, ok, nextStart
+	}
+
+	if g.Base.Empty() {
+		return Airing{}, false, nextStart
+	}
+
+	abs := AbsSegment(t)
+	slot, ok := g.Base.Locate(abs)
+	if !ok {
+		return Airing{}, false, nextStart
+	}
+
+	programStart := abs - int64(slot.LocalSeg)
+	a := Airing{
+		Program: slot.Program,
+		Start:   SegmentTime(programStart),
+		End:     SegmentTime(programStart + int64(slot.Program.Segments)),
+	}
+
+	// The base cycle runs on the absolute timeline underneath the blocks, so
+	// after a block it rejoins whatever programme has reached that point, and
+	// is cut when the next block starts.
+	if a.Start.Before(prevEnd) {
+		a.Offset = prevEnd.Sub(a.Start).Seconds()
+		a.Start = prevEnd
+	}
+	if !nextStart.IsZero() && a.End.After(nextStart) {
+		a.End = nextStart
+	}
+
+	return a, true, nextStart
+}
+
+// blockAiring returns the programme a block airs at t, within one of its
+// airings.
+//
+// Every airing of a block has the same nominal length, so the rotation
+// position at the start of airing n is simply n times that length. The
+// programme covering that position is restarted from its beginning rather
+// than joined part way through: it was cut off at the end of the previous
+// airing, and starting a block mid-programme is exactly what dayparting is
+// meant to avoid.
+func (g *Grid) blockAiring(o occurrence, t time.Time) (Airing, bool) {
+	b := g.Blocks[o.block]
+	c := b.Cycle
+
+	segs := int64(b.length() / (SegmentSeconds * time.Second))
+	first, ok := c.Locate(o.index * segs)
+	if !ok {
+		return Airing{}, false
+	}
+	rotationStart := o.index*segs - int64(first.LocalSeg)
+
+	occStart := AbsSegment(o.start)
+	pos := rotationStart + AbsSegment(t) - occStart
+
+	slot, ok := c.Locate(pos)
+	if !ok {
+		return Airing{}, false
+	}
+
+	programStart := occStart + pos - int64(slot.LocalSeg) - rotationStart
+	a := Airing{
+		Program: slot.Program,
+		Start:   SegmentTime(programStart),
+		End:     SegmentTime(programStart + int64(slot.Program.Segments)),
+		Block:   b.Name,
+	}
+	if a.End.After(o.end) {
+		a.End = o.end
+	}
+
+	return a, true
+}
+
+// Airings returns every airing overlapping [from, to), capped at max entries,
+// as Cycle.Airings does.
+func (g *Grid) Airings(from, to time.Time, max int) []Airing {
+	if g.Empty() || !to.After(from) || max <= 0 {
+		return nil
+	}
+
+	out := make([]Airing, 0, 16)
+	cursor := from
+	for len(out) < max && cursor.Before(to) {
+		a, ok, next := g.at(cursor)
+		if !ok {
+			// nothing on until the next block
+			if next.IsZero() {
+				break
+			}
+			cursor = next
+			continue
+		}
+
+		out = append(out, a)
+		cursor = a.End
+	}
+
+	return out
+}
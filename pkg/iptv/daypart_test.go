@@ -0,0 +1,204 @@
+package iptv
+
+import (
+	"testing"
+	"time"
+)
+
+// lateNight is a grid over a base of 10-minute scenes, with a 22:00–02:00
+// block of 25-minute scenes.
+func lateNight(days ...time.Weekday) *Grid {
+	base := BuildCycle(1, entries(600, 600, 600, 600))
+	features := BuildCycle(2, []SceneEntry{
+		{SceneID: 1, Duration: 1500},
+		{SceneID: 2, Duration: 1500},
+		{SceneID: 3, Duration: 1500},
+		{SceneID: 4, Duration: 1500},
+		{SceneID: 5, Duration: 1500},
+		{SceneID: 6, Duration: 1500},
+		{SceneID: 7, Duration: 1500},
+	})
+
+	return NewGrid(base, []Block{{
+		Name:  "Late",
+		Days:  days,
+		Start: 22 * time.Hour,
+		End:   2 * time.Hour,
+		Cycle: features,
+	}}, time.UTC)
+}
+
+func TestGridWithoutBlocksMatchesCycle(t *testing.T) {
+	c := BuildCycle(1, entries(600, 900, 300))
+	g := NewGrid(c, nil, time.UTC)
+
+	from := Epoch.Add(1234 * time.Second)
+	to := from.Add(3 * time.Hour)
+
+	want := c.Airings(from, to, 100)
+	got := g.Airings(from, to, 100)
+	if len(got) != len(want) {
+		t.Fatalf("got %d airings, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Program.SceneID != want[i].Program.SceneID || !got[i].Start.Equal(want[i].Start) || !got[i].End.Equal(want[i].End) {
+			t.Errorf("airing %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestGridBlockStartsWithWholeProgramme(t *testing.T) {
+	g := lateNight()
+	blockStart := time.Date(2024, time.March, 5, 22, 0, 0, 0, time.UTC)
+
+	a, ok := g.At(blockStart)
+	if !ok {
+		t.Fatal("nothing on air at block start")
+	}
+	if a.Block != "Late" {
+		t.Fatalf("airing at 22:00 is not in the block: %+v", a)
+	}
+	if !a.Start.Equal(blockStart) || a.Offset != 0 {
+		t.Errorf("block opens mid-programme: start %v offset %v", a.Start, a.Offset)
+	}
+
+	// the base programme before the block is cut at the block start
+	before, ok := g.At(blockStart.Add(-time.Second))
+	if !ok || before.Block != "" {
+		t.Fatalf("expected a base programme before the block, got %+v", before)
+	}
+	if !before.End.Equal(blockStart) {
+		t.Errorf("base programme ends at %v, want it cut at %v", before.End, blockStart)
+	}
+}
+
+func TestGridBlockCrossesMidnightAndEnds(t *testing.T) {
+	g := lateNight()
+	blockEnd := time.Date(2024, time.March, 6, 2, 0, 0, 0, time.UTC)
+
+	in, ok := g.At(blockEnd.Add(-time.Second))
+	if !ok || in.Block != "Late" {
+		t.Fatalf("expected the block to still be on at 01:59:59, got %+v", in)
+	}
+	if in.End.After(blockEnd) {
+		t.Errorf("block programme runs past the block end: %v", in.End)
+	}
+
+	after, ok := g.At(blockEnd)
+	if !ok || after.Block != "" {
+		t.Fatalf("expected the base cycle at 02:00, got %+v", after)
+	}
+	if !after.Start.Equal(blockEnd) {
+		t.Errorf("base programme after the block starts at %v, want %v", after.Start, blockEnd)
+	}
+	// 02:00 falls on a 10-minute boundary of the base, so it rejoins at the
+	// start of a programme
+	if after.Offset != 0 {
+		t.Errorf("offset = %v, want 0", after.Offset)
+	}
+}
+
+func TestGridRejoinsBaseInProgress(t *testing.T) {
+	base := BuildCycle(1, entries(7*60)) // 7 minutes, not a divisor of the hour
+	block := BuildCycle(2, entries(600))
+	g := NewGrid(base, []Block{{Start: 10 * time.Hour, End: 11 * time.Hour, Cycle: block}}, time.UTC)
+
+	end := time.Date(2024, time.February, 1, 11, 0, 0, 0, time.UTC)
+	a, ok := g.At(end)
+	if !ok {
+		t.Fatal("nothing on air after the block")
+	}
+	if !a.Start.Equal(end) {
+		t.Errorf("start = %v, want the block end %v", a.Start, end)
+	}
+	natural := end.Add(-time.Duration(a.Offset * float64(time.Second)))
+	if a.Offset <= 0 || natural.Sub(Epoch)%(7*time.Minute) != 0 {
+		t.Errorf("offset %v does not lead back to a programme boundary", a.Offset)
+	}
+}
+
+// Each airing of a block continues the rotation rather than restarting it, so
+// the same programmes do not open the block every night.
+func TestGridBlockRotationContinuesAcrossDays(t *testing.T) {
+	g := lateNight()
+
+	first := func(day int) int {
+		a, ok := g.At(time.Date(2024, time.March, day, 22, 0, 0, 0, time.UTC))
+		if !ok {
+			t.Fatalf("nothing on air on day %d", day)
+		}
+		return a.Program.SceneID
+	}
+
+	if first(5) == first(6) {
+		t.Errorf("block opened with scene %d on consecutive nights", first(5))
+	}
+
+	// a grid built afresh, as after a restart, airs the same thing
+	at := time.Date(2024, time.March, 5, 23, 17, 0, 0, time.UTC)
+	a, _ := g.At(at)
+	b, _ := lateNight().At(at)
+	if a != b {
+		t.Errorf("rebuilt grid airs %+v, want %+v", b, a)
+	}
+}
+
+func TestGridBlockOnlyAirsOnItsDays(t *testing.T) {
+	g := lateNight(time.Friday)
+
+	friday := time.Date(2024, time.March, 8, 23, 0, 0, 0, time.UTC)
+	if a, _ := g.At(friday); a.Block != "Late" {
+		t.Errorf("block not on air on Friday night: %+v", a)
+	}
+	// the Friday airing runs into Saturday morning
+	if a, _ := g.At(friday.Add(2 * time.Hour)); a.Block != "Late" {
+		t.Errorf("block not on air early Saturday: %+v", a)
+	}
+
+	thursday := friday.Add(-24 * time.Hour)
+	if a, _ := g.At(thursday); a.Block != "" {
+		t.Errorf("block on air on Thursday: %+v", a)
+	}
+}
+
+func TestGridAiringsAreContiguous(t *testing.T) {
+	g := lateNight(time.Monday, time.Wednesday)
+
+	from := time.Date(2024, time.March, 4, 18, 0, 0, 0, time.UTC)
+	to := from.Add(36 * time.Hour)
+	airings := g.Airings(from, to, 1000)
+	if len(airings) == 0 {
+		t.Fatal("no airings")
+	}
+
+	blocks := 0
+	for i, a := range airings {
+		if !a.End.After(a.Start) {
+			t.Errorf("airing %d is empty: %+v", i, a)
+		}
+		if i > 0 && !airings[i-1].End.Equal(a.Start) {
+			t.Errorf("gap or overlap between airing %d (ends %v) and %d (starts %v)",
+				i-1, airings[i-1].End, i, a.Start)
+		}
+		if a.Block != "" {
+			blocks++
+		}
+	}
+	if blocks == 0 {
+		t.Error("guide does not include the block")
+	}
+}
+
+func TestGridWithEmptyBaseSkipsToNextBlock(t *testing.T) {
+	block := BuildCycle(2, entries(600))
+	g := NewGrid(&Cycle{}, []Block{{Start: 20 * time.Hour, End: 21 * time.Hour, Cycle: block}}, time.UTC)
+
+	from := time.Date(2024, time.March, 4, 12, 0, 0, 0, time.UTC)
+	airings := g.Airings(from, from.Add(12*time.Hour), 100)
+	if len(airings) != 6 {
+		t.Fatalf("got %d airings, want the six programmes of the 20:00 block", len(airings))
+	}
+	if want := time.Date(2024, time.March, 4, 20, 0, 0, 0, time.UTC); !airings[0].Start.Equal(want) {
+		t.Errorf("first airing starts %v, want %v", airings[0].Start, want)
+	}
+}
@@ -218,6 +218,11 @@ type Airing struct {
 	Program Program
 	Start   time.Time
 	End     time.Time
+	// Offset is how far into the programme, in seconds, the airing starts.
+	// It is non-zero only where a Grid rejoins a programme already in progress.
+	Offset float64
+	// Block names the Grid block the airing belongs to, if any.
+	Block string
 }
 
 // Airings walks the cycle forward from `from`, returning every programme that
@@ -37,6 +37,8 @@ const (
 	StatusReady Status = "READY"
 	// StatusRunning means that the job is currently running.
 	StatusRunning Status = "RUNNING"
+	// StatusPaused means that the job has been started and is paused.
+	StatusPaused Status = "PAUSED"
 	// StatusStopping means that the job is cancelled but is still running.
 	StatusStopping Status = "STOPPING"
 	// StatusFinished means that the job was completed.
@@ -47,6 +49,52 @@ const (
 	StatusFailed Status = "FAILED"
 )
 
+// Priority is the priority of a Job. Jobs are queued ahead of those with a
+// lower priority.
+type Priority int
+
+const (
+	PriorityLow Priority = iota - 1
+	PriorityNormal
+	PriorityHigh
+)
+
+// Lane is the resource that a Job mostly uses. Jobs in different lanes run
+// concurrently, each lane running as many jobs at a time as the Manager
+// allows it.
+type Lane string
+
+const (
+	// LaneDefault is the lane of jobs that do not declare one.
+	LaneDefault Lane = "DEFAULT"
+	// LaneIO is for jobs bound by disk access, such as scans.
+	LaneIO Lane = "IO"
+	// LaneCompute is for jobs bound by the CPU or GPU, such as generation.
+	LaneCompute Lane = "COMPUTE"
+	// LaneNetwork is for jobs bound by the network, such as downloads.
+	LaneNetwork Lane = "NETWORK"
+)
+
+// Laned is implemented by a JobExec that runs in a lane other than
+// LaneDefault.
+type Laned interface {
+	JobLane() Lane
+}
+
+// Prioritised is implemented by a JobExec that is queued at a priority other
+// than PriorityNormal.
+type Prioritised interface {
+	JobPriority() Priority
+}
+
+// Pausable is implemented by a JobExec that can be paused. A job is only
+// paused when it next calls Progress.ExecuteTask or Progress.WaitIfPaused, so
+// only a job that does so between its units of work, and never while it holds
+// a transaction open, should be pausable.
+type Pausable interface {
+	JobPausable() bool
+}
+
 // Job represents the status of a queued or running job.
 type Job struct {
 	ID     int
@@ -60,10 +108,26 @@ type Job struct {
 	EndTime   *time.Time
 	AddTime   time.Time
 	Error     *string
+	Priority  Priority
+	Lane      Lane
+	// Pausable is true if the job can be paused
+	Pausable bool
 
 	outerCtx   context.Context
 	exec       JobExec
 	cancelFunc context.CancelFunc
+	// done is closed once the job has left the queue
+	done chan struct{}
+	// resume is closed when the job is resumed, and is nil unless it is
+	// paused
+	resume chan struct{}
+
+	// persistent is exec, if it is saved with the queue
+	persistent Persistent
+	// checkpoint is the latest checkpoint not yet written to the store
+	checkpoint      []byte
+	checkpointSaved time.Time
+	checkpointTimer *time.Timer
 }
 
 // statusCopy returns a copy of the Job with only the fields needed for
@@ -80,6 +144,9 @@ func (j *Job) statusCopy() Job {
 		EndTime:     j.EndTime,
 		AddTime:     j.AddTime,
 		Error:       j.Error,
+		Priority:    j.Priority,
+		Lane:        j.Lane,
+		Pausable:    j.Pausable,
 	}
 }
 
@@ -102,6 +169,9 @@ func (j *Job) cancel() {
 		j.Status = StatusCancelled
 	case StatusRunning:
 		j.Status = StatusStopping
+	case StatusPaused:
+		j.Status = StatusStopping
+		j.unpause()
 	}
 
 	if j.cancelFunc != nil {
@@ -109,6 +179,44 @@ func (j *Job) cancel() {
 	}
 }
 
+// setExec sets the JobExec of the job, along with the lane, priority and
+// whether it can be paused, as it declares.
+func (j *Job) setExec(e JobExec) {
+	j.exec = e
+	j.persistent, _ = e.(Persistent)
+
+	j.Lane = LaneDefault
+	if l, ok := e.(Laned); ok {
+		j.Lane = l.JobLane()
+	}
+
+	j.Priority = PriorityNormal
+	if p, ok := e.(Prioritised); ok {
+		j.Priority = p.JobPriority()
+	}
+
+	j.Pausable = false
+	if p, ok := e.(Pausable); ok {
+		j.Pausable = p.JobPausable()
+	}
+}
+
+// isActive returns true if the job has been started and has not returned.
+func (j *Job) isActive() bool {
+	switch j.Status {
+	case StatusRunning, StatusPaused, StatusStopping:
+		return true
+	}
+	return false
+}
+
+func (j *Job) unpause() {
+	if j.resume != nil {
+		close(j.resume)
+		j.resume = nil
+	}
+}
+
 func (j *Job) error(err error) {
 	errStr := err.Error()
 	j.Error = &errStr
@@ -12,13 +12,16 @@ import (
 const maxGraveyardSize = 10
 const defaultThrottleLimit = 100 * time.Millisecond
 
-// Manager maintains a queue of jobs. Jobs are executed one at a time.
+// Manager maintains a queue of jobs. Jobs are started in the order of the
+// queue, which puts jobs of a higher priority first, as their lane allows.
 type Manager struct {
 	queue     []*Job
 	graveyard []*Job
 
-	mutex    sync.Mutex
-	notEmpty *sync.Cond
+	mutex sync.Mutex
+	// changed is signalled when a job is queued or ends, and so another may
+	// be able to start
+	changed  *sync.Cond
 	stop     chan struct{}
 	stopOnce sync.Once
 
@@ -27,6 +30,15 @@ type Manager struct {
 	subscriptions       []*ManagerSubscription
 	updateThrottleLimit time.Duration
 
+	// store saves persistent jobs, once Restore has been called
+	store     Store
+	factories map[string]Factory
+	// stopping is set once the manager is stopping, so that the jobs it
+	// interrupts are kept in the store
+	stopping bool
+
+	// MaxConcurrentJobs is the number of jobs in LaneDefault that may run at
+	// once. The other lanes run one job at a time.
 	MaxConcurrentJobs int
 }
 
@@ -38,7 +50,7 @@ func NewManager() *Manager {
 		MaxConcurrentJobs:   3,
 	}
 
-	ret.notEmpty = sync.NewCond(&ret.mutex)
+	ret.changed = sync.NewCond(&ret.mutex)
 
 	go ret.dispatcher()
 
@@ -48,14 +60,16 @@ func NewManager() *Manager {
 // Stop is used to stop the dispatcher thread. Once Stop is called, no
 // more Jobs will be processed.
 func (m *Manager) Stop() {
+	m.setStopping()
 	m.CancelAll()
-	m.stopOnce.Do(func() { close(m.stop) })
+	m.stopDispatcher()
 }
 
 // StopAndWait cancels all in-flight jobs, waits for running jobs to finish
 // (up to timeout), then stops the dispatcher. Prefer this over Stop when a
 // clean shutdown is needed.
 func (m *Manager) StopAndWait(timeout time.Duration) {
+	m.setStopping()
 	m.CancelAll()
 
 	deadline := time.Now().Add(timeout)
@@ -63,7 +77,8 @@ func (m *Manager) StopAndWait(timeout time.Duration) {
 		m.mutex.Lock()
 		running := false
 		for _, j := range m.queue {
-			if j.Status == StatusRunning {
+			// cancelled jobs are stopping rather than running until they return
+			if j.isActive() {
 				running = true
 				break
 			}
@@ -75,59 +90,98 @@ func (m *Manager) StopAndWait(timeout time.Duration) {
 		time.Sleep(100 * time.Millisecond)
 	}
 
+	m.stopDispatcher()
+}
+
+func (m *Manager) stopDispatcher() {
 	m.stopOnce.Do(func() { close(m.stop) })
+
+	// wake the dispatcher so that it sees the stop signal
+	m.mutex.Lock()
+	m.changed.Broadcast()
+	m.mutex.Unlock()
 }
 
-// Add queues a job.
-func (m *Manager) Add(ctx context.Context, description string, e JobExec) int {
+func (m *Manager) setStopping() {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
+	m.stopping = true
+}
 
-	t := time.Now()
-
-	j := Job{
+// newJob makes a job with the next ID, saving it to the store if it is
+// persistent.
+func (m *Manager) newJob(ctx context.Context, description string, e JobExec) *Job {
+	m.mutex.Lock()
+	j := &Job{
 		ID:          m.nextID(),
 		Status:      StatusReady,
 		Description: description,
-		AddTime:     t,
-		exec:        e,
+		AddTime:     time.Now(),
 		outerCtx:    ctx,
+		done:        make(chan struct{}),
 	}
+	j.setExec(e)
+	m.mutex.Unlock()
 
-	m.queue = append(m.queue, &j)
+	// saved before it is queued, so that it cannot end, and be removed from
+	// the store, first
+	m.save(j)
+
+	return j
+}
+
+// Add queues a job, after the waiting jobs of the same or a higher priority.
+// A JobExec sets its lane and priority by implementing Laned and Prioritised.
+func (m *Manager) Add(ctx context.Context, description string, e JobExec) int {
+	j := m.newJob(ctx, description, e)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.enqueue(j)
 
 	// notify that there is now a job in the queue
 	// We must always broadcast, even if queue > 1, because the dispatcher
 	// might be waiting if it previously found the queue empty or drained it.
-	m.notEmpty.Broadcast()
+	m.changed.Broadcast()
 
-	m.notifyNewJob(&j)
+	m.notifyNewJob(j)
 
 	return j.ID
 }
 
 // Start adds a job and starts it immediately, concurrently with any other
-// jobs.
+// jobs, regardless of its lane.
 func (m *Manager) Start(ctx context.Context, description string, e JobExec) int {
+	j := m.newJob(ctx, description, e)
+
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	t := time.Now()
+	m.queue = append(m.queue, j)
+	m.notifyNewJob(j)
 
-	j := Job{
-		ID:          m.nextID(),
-		Status:      StatusReady,
-		Description: description,
-		AddTime:     t,
-		exec:        e,
-		outerCtx:    ctx,
-	}
+	m.dispatch(j)
 
-	m.queue = append(m.queue, &j)
+	return j.ID
+}
 
-	m.dispatch(ctx, &j)
+// enqueue adds the job to the queue, after the waiting jobs of the same or a
+// higher priority, so that the queue is in the order jobs will start.
+func (m *Manager) enqueue(j *Job) {
+	// assumes lock held
+	i := len(m.queue)
+	for i > 0 {
+		prev := m.queue[i-1]
+		if prev.Status != StatusReady || prev.Priority >= j.Priority {
+			break
+		}
+		i--
+	}
 
-	return j.ID
+	m.queue = append(m.queue, nil)
+	copy(m.queue[i+1:], m.queue[i:])
+	m.queue[i] = j
 }
 
 func (m *Manager) notifyNewJob(j *Job) {
@@ -146,10 +200,32 @@ func (m *Manager) nextID() int {
 	return m.lastID
 }
 
+func (m *Manager) laneLimit(l Lane) int {
+	if l != LaneDefault {
+		return 1
+	}
+
+	// ensure at least 1
+	limit := m.MaxConcurrentJobs
+	if limit < 1 {
+		limit = 1
+	}
+	return limit
+}
+
+// getReadyJob returns the first job in the queue that is ready and whose lane
+// has room for it. Paused jobs keep their place in the lane.
 func (m *Manager) getReadyJob() *Job {
 	// assumes lock held
+	active := make(map[Lane]int)
 	for _, j := range m.queue {
-		if j.Status == StatusReady {
+		if j.isActive() {
+			active[j.Lane]++
+		}
+	}
+
+	for _, j := range m.queue {
+		if j.Status == StatusReady && active[j.Lane] < m.laneLimit(j.Lane) {
 			return j
 		}
 	}
@@ -159,67 +235,24 @@ func (m *Manager) getReadyJob() *Job {
 
 func (m *Manager) dispatcher() {
 	m.mutex.Lock()
-
-	// semaphore to limit concurrent jobs
-	// ensure at least 1
-	limit := m.MaxConcurrentJobs
-	if limit < 1 {
-		limit = 1
-	}
-	sem := make(chan struct{}, limit)
+	defer m.mutex.Unlock()
 
 	for {
-		// wait until we have something to process
-		j := m.getReadyJob()
-
-		for j == nil {
-			m.notEmpty.Wait()
-
-			// it's possible that we have been stopped - check here
-			select {
-			case <-m.stop:
-				m.mutex.Unlock()
-				return
-			default:
-				// keep going
-				j = m.getReadyJob()
-			}
-		}
-
-		// acquire semaphore
-		m.mutex.Unlock()
-		sem <- struct{}{}
-		m.mutex.Lock()
-
-		// re-check stop signal after blocking on semaphore
+		// it's possible that we have been stopped - check here
 		select {
 		case <-m.stop:
-			m.mutex.Unlock()
 			return
 		default:
 		}
 
-		if j.Status != StatusReady {
-			<-sem // release
+		// wait until we have something to process
+		j := m.getReadyJob()
+		if j == nil {
+			m.changed.Wait()
 			continue
 		}
 
-		// Mark as running immediately to prevent getReadyJob from picking it up again
-		// in the next iteration while the goroutine is starting.
-		// dispatch() will update the start time.
-		j.Status = StatusRunning
-
-		// dispatch in background
-		go func(job *Job) {
-			defer func() { <-sem }() // release semaphore on finish
-
-			done := m.dispatch(job.outerCtx, job)
-			<-done
-
-			m.mutex.Lock()
-			m.removeJob(job)
-			m.mutex.Unlock()
-		}(j)
+		m.dispatch(j)
 	}
 }
 
@@ -233,32 +266,24 @@ func (m *Manager) newProgress(j *Job) *Progress {
 	}
 }
 
-func (m *Manager) dispatch(ctx context.Context, j *Job) (done chan struct{}) {
+func (m *Manager) dispatch(j *Job) {
 	// assumes lock held
-	// or called from dispatcher with exclusive right to this job
-
-	m.mutex.Lock()
 	t := time.Now()
 	j.StartTime = &t
 	j.Status = StatusRunning
-	m.mutex.Unlock()
 
 	// create a cancellable context for the job that is not canceled by the outer context
-	ctx, cancelFunc := context.WithCancel(context.WithoutCancel(ctx))
+	ctx, cancelFunc := context.WithCancel(context.WithoutCancel(j.outerCtx))
 	j.cancelFunc = cancelFunc
 
-	done = make(chan struct{})
-	go m.executeJob(ctx, j, done)
+	go m.executeJob(ctx, j)
 
-	m.mutex.Lock()
 	m.notifyJobUpdate(j)
-	m.mutex.Unlock()
-
-	return
 }
 
-func (m *Manager) executeJob(ctx context.Context, j *Job, done chan struct{}) {
-	defer close(done)
+func (m *Manager) executeJob(ctx context.Context, j *Job) {
+	defer m.endJob(j)
+	defer m.settle(j)
 	defer m.onJobFinish(j)
 	defer func() {
 		if p := recover(); p != nil {
@@ -272,6 +297,8 @@ func (m *Manager) executeJob(ctx context.Context, j *Job, done chan struct{}) {
 		}
 	}()
 
+	m.markStarted(j)
+
 	progress := m.newProgress(j)
 	if err := j.exec.Execute(ctx, progress); err != nil {
 		logger.Errorf("task failed due to error: %v", err)
@@ -292,6 +319,16 @@ func (m *Manager) onJobFinish(job *Job) {
 	job.EndTime = &t
 }
 
+// endJob moves the job from the queue to the graveyard once it has returned,
+// freeing its place in its lane.
+func (m *Manager) endJob(job *Job) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.removeJob(job)
+	m.changed.Broadcast()
+}
+
 func (m *Manager) removeJob(job *Job) {
 	// assumes lock held
 	index, _ := m.getJob(m.queue, job.ID)
@@ -311,6 +348,8 @@ func (m *Manager) removeJob(job *Job) {
 		m.graveyard = m.graveyard[1:]
 	}
 
+	close(job.done)
+
 	// notify job removed
 	for _, s := range m.subscriptions {
 		// don't block if channel is full
@@ -349,6 +388,10 @@ func (m *Manager) CancelJob(id int) {
 		if j.Status == StatusCancelled {
 			// remove from the queue
 			m.removeJob(j)
+
+			if !m.stopping && j.persistent != nil {
+				go m.forget(j.ID)
+			}
 		}
 	}
 }
@@ -370,6 +413,94 @@ func (m *Manager) CancelAll() {
 	}
 }
 
+// PauseJob pauses the running job with the provided id. Pausing is
+// cooperative: the job carries on until it next checks, through its Progress,
+// whether it has been paused. A paused job keeps its place in its lane. It
+// returns false if there is no running job with the provided id, or if the job
+// is not Pausable.
+func (m *Manager) PauseJob(id int) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	_, j := m.getJob(m.queue, id)
+	if j == nil || j.Status != StatusRunning || !j.Pausable {
+		return false
+	}
+
+	j.Status = StatusPaused
+	j.resume = make(chan struct{})
+	m.notifyJobUpdate(j)
+
+	return true
+}
+
+// ResumeJob resumes the paused job with the provided id. It returns false if
+// there is no paused job with the provided id.
+func (m *Manager) ResumeJob(id int) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	_, j := m.getJob(m.queue, id)
+	if j == nil || j.Status != StatusPaused {
+		return false
+	}
+
+	j.Status = StatusRunning
+	j.unpause()
+	m.notifyJobUpdate(j)
+
+	return true
+}
+
+// SetJobPriority changes the priority of the waiting job with the provided id,
+// moving it after the waiting jobs of the same or a higher priority. It
+// returns false if there is no waiting job with the provided id.
+func (m *Manager) SetJobPriority(id int, priority Priority) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	index, j := m.getJob(m.queue, id)
+	if j == nil || j.Status != StatusReady {
+		return false
+	}
+
+	m.queue = append(m.queue[:index], m.queue[index+1:]...)
+	j.Priority = priority
+	m.enqueue(j)
+	m.notifyJobUpdate(j)
+
+	return true
+}
+
+// MoveJob moves the waiting job with the provided id to position in the queue,
+// regardless of its priority. Positions are those of the jobs returned by
+// GetQueue, and are clamped to the queue. It returns false if there is no
+// waiting job with the provided id.
+func (m *Manager) MoveJob(id int, position int) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	index, j := m.getJob(m.queue, id)
+	if j == nil || j.Status != StatusReady {
+		return false
+	}
+
+	m.queue = append(m.queue[:index], m.queue[index+1:]...)
+
+	if position < 0 {
+		position = 0
+	} else if position > len(m.queue) {
+		position = len(m.queue)
+	}
+
+	m.queue = append(m.queue, nil)
+	copy(m.queue[position+1:], m.queue[position:])
+	m.queue[position] = j
+	m.notifyJobUpdate(j)
+
+	return true
+}
+
 // GetJob returns a copy of the Job for the provided id. Returns nil if the job
 // does not exist.
 func (m *Manager) GetJob(id int) *Job {
@@ -386,6 +517,31 @@ func (m *Manager) GetJob(id int) *Job {
 	return nil
 }
 
+// Wait blocks until the job with the provided id has ended and returns a copy
+// of it in its final state. It returns nil if the job does not exist, or ended
+// so long ago that it is no longer kept, and an error if ctx ends first.
+func (m *Manager) Wait(ctx context.Context, id int) (*Job, error) {
+	m.mutex.Lock()
+	_, j := m.getJob(append(m.queue, m.graveyard...), id)
+	m.mutex.Unlock()
+
+	if j == nil {
+		return nil, nil
+	}
+
+	select {
+	case <-j.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	ret := j.statusCopy()
+	return &ret, nil
+}
+
 // GetQueue returns a copy of the current job queue.
 func (m *Manager) GetQueue() []Job {
 	m.mutex.Lock()
@@ -459,6 +615,15 @@ func (u *updater) notifyUpdate() {
 	u.updateTimer = nil
 }
 
+// resumed returns the channel that is closed when the job is resumed, or nil
+// if the job is not paused.
+func (u *updater) resumed() <-chan struct{} {
+	u.m.mutex.Lock()
+	defer u.m.mutex.Unlock()
+
+	return u.job.resume
+}
+
 func (u *updater) updateProgress(progress float64, details []string) {
 	u.m.mutex.Lock()
 	defer u.m.mutex.Unlock()
@@ -346,3 +346,240 @@ func TestSubscribe(t *testing.T) {
 
 	cancel()
 }
+
+func TestWait(t *testing.T) {
+	m := NewManager()
+	m.MaxConcurrentJobs = 1
+
+	assert := assert.New(t)
+
+	exec1 := newTestExec(make(chan struct{}))
+	jobID := m.Add(context.Background(), "test job", exec1)
+
+	waited := make(chan *Job)
+	go func() {
+		j, err := m.Wait(context.Background(), jobID)
+		assert.NoError(err)
+		waited <- j
+	}()
+
+	select {
+	case <-waited:
+		t.Fatal("wait returned before the job finished")
+	case <-time.After(sleepTime):
+	}
+
+	close(exec1.finish)
+
+	select {
+	case j := <-waited:
+		assert.Equal(StatusFinished, j.Status)
+	case <-time.After(time.Second):
+		t.Fatal("wait did not return after the job finished")
+	}
+
+	// a job that has already ended returns straight away
+	j, err := m.Wait(context.Background(), jobID)
+	assert.NoError(err)
+	assert.Equal(StatusFinished, j.Status)
+
+	// cancelled before it started
+	exec2 := newTestExec(make(chan struct{}))
+	exec3 := newTestExec(make(chan struct{}))
+	job2ID := m.Add(context.Background(), "test job", exec2)
+	job3ID := m.Add(context.Background(), "test job", exec3)
+	m.CancelJob(job3ID)
+
+	j, err = m.Wait(context.Background(), job3ID)
+	assert.NoError(err)
+	assert.Equal(StatusCancelled, j.Status)
+
+	ctx, cancel := context.WithTimeout(context.Background(), sleepTime)
+	defer cancel()
+	_, err = m.Wait(ctx, job2ID)
+	assert.ErrorIs(err, context.DeadlineExceeded)
+	close(exec2.finish)
+
+	j, err = m.Wait(context.Background(), 1000)
+	assert.NoError(err)
+	assert.Nil(j)
+}
+
+type lanedExec struct {
+	*testExec
+	lane     Lane
+	priority Priority
+}
+
+func (e *lanedExec) JobLane() Lane         { return e.lane }
+func (e *lanedExec) JobPriority() Priority { return e.priority }
+
+func queueIDs(m *Manager) []int {
+	var ret []int
+	for _, j := range m.GetQueue() {
+		ret = append(ret, j.ID)
+	}
+	return ret
+}
+
+func TestLanesRunConcurrently(t *testing.T) {
+	m := NewManager()
+	defer m.Stop()
+	m.MaxConcurrentJobs = 1
+
+	finish := make(chan struct{})
+	defer close(finish)
+
+	generate := &lanedExec{testExec: newTestExec(finish), lane: LaneCompute}
+	m.Add(context.Background(), "generate", generate)
+	otherGenerate := &lanedExec{testExec: newTestExec(finish), lane: LaneCompute}
+	otherID := m.Add(context.Background(), "other generate", otherGenerate)
+	scan := &lanedExec{testExec: newTestExec(finish), lane: LaneIO}
+	m.Add(context.Background(), "scan", scan)
+
+	time.Sleep(sleepTime)
+
+	select {
+	case <-scan.started:
+		// ok
+	default:
+		t.Error("scan waited behind a job in another lane")
+	}
+
+	assert.Equal(t, StatusReady, m.GetJob(otherID).Status, "second job in a full lane started")
+}
+
+func TestPriorityQueuedAhead(t *testing.T) {
+	m := NewManager()
+	defer m.Stop()
+	m.MaxConcurrentJobs = 1
+
+	finish := make(chan struct{})
+	defer close(finish)
+
+	running := m.Add(context.Background(), "running", newTestExec(finish))
+	time.Sleep(sleepTime)
+
+	low := m.Add(context.Background(), "low", &lanedExec{testExec: newTestExec(finish), priority: PriorityLow})
+	normal := m.Add(context.Background(), "normal", newTestExec(finish))
+	high := m.Add(context.Background(), "high", &lanedExec{testExec: newTestExec(finish), priority: PriorityHigh})
+
+	time.Sleep(sleepTime)
+
+	// the running job keeps its place
+	assert.Equal(t, []int{running, high, normal, low}, queueIDs(m))
+
+	assert.True(t, m.SetJobPriority(low, PriorityHigh))
+	assert.Equal(t, []int{running, high, low, normal}, queueIDs(m))
+	assert.False(t, m.SetJobPriority(running, PriorityLow), "priority of a running job changed")
+
+	assert.True(t, m.MoveJob(normal, 1))
+	assert.Equal(t, []int{running, normal, high, low}, queueIDs(m))
+	assert.True(t, m.MoveJob(normal, 100))
+	assert.Equal(t, []int{running, high, low, normal}, queueIDs(m))
+	assert.False(t, m.MoveJob(running, 3), "running job moved")
+}
+
+type pausableExec struct {
+	JobExec
+}
+
+func (pausableExec) JobPausable() bool { return true }
+
+func TestPauseAndResume(t *testing.T) {
+	m := NewManager()
+	defer m.Stop()
+
+	step := make(chan struct{})
+	tasks := make(chan int)
+	id := m.Add(context.Background(), "tasks", pausableExec{MakeJobExec(func(ctx context.Context, p *Progress) error {
+		for i := 0; i < 2; i++ {
+			<-step
+			p.ExecuteTask("task", func() {
+				tasks <- i
+			})
+		}
+		return nil
+	})})
+
+	time.Sleep(sleepTime)
+
+	assert.True(t, m.GetJob(id).Pausable)
+	assert.True(t, m.PauseJob(id))
+	assert.Equal(t, StatusPaused, m.GetJob(id).Status)
+	assert.False(t, m.PauseJob(id), "paused job paused again")
+
+	step <- struct{}{}
+	select {
+	case <-tasks:
+		t.Fatal("task executed while paused")
+	case <-time.After(sleepTime):
+	}
+
+	assert.True(t, m.ResumeJob(id))
+	assert.Equal(t, 0, <-tasks)
+
+	// cancelling a paused job lets it return
+	assert.True(t, m.PauseJob(id))
+	step <- struct{}{}
+	m.CancelJob(id)
+	select {
+	case <-tasks:
+	case <-time.After(time.Second):
+		t.Fatal("cancelled job still paused")
+	}
+
+	j, err := m.Wait(context.Background(), id)
+	if assert.NoError(t, err) && assert.NotNil(t, j) {
+		assert.Equal(t, StatusCancelled, j.Status)
+	}
+}
+
+func TestNestedTaskNotPaused(t *testing.T) {
+	m := NewManager()
+	defer m.Stop()
+
+	step := make(chan struct{})
+	tasks := make(chan string)
+	id := m.Add(context.Background(), "nested", pausableExec{MakeJobExec(func(ctx context.Context, p *Progress) error {
+		<-step
+		p.ExecuteNestedTask("nested", func() {
+			tasks <- "nested"
+		})
+		p.ExecuteTask("task", func() {
+			tasks <- "task"
+		})
+		return nil
+	})})
+
+	time.Sleep(sleepTime)
+
+	assert.True(t, m.PauseJob(id))
+	step <- struct{}{}
+	assert.Equal(t, "nested", <-tasks)
+
+	select {
+	case <-tasks:
+		t.Fatal("task executed while paused")
+	case <-time.After(sleepTime):
+	}
+
+	assert.True(t, m.ResumeJob(id))
+	assert.Equal(t, "task", <-tasks)
+}
+
+func TestPauseJobNotPausable(t *testing.T) {
+	m := NewManager()
+	defer m.Stop()
+
+	finish := make(chan struct{})
+	id := m.Add(context.Background(), "not pausable", newTestExec(finish))
+
+	time.Sleep(sleepTime)
+
+	assert.False(t, m.GetJob(id).Pausable)
+	assert.False(t, m.PauseJob(id))
+	assert.Equal(t, StatusRunning, m.GetJob(id).Status)
+
+	close(finish)
+}
@@ -0,0 +1,311 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+// checkpointInterval is the most often a job's checkpoint is written to the
+// store. A checkpoint made sooner is held back and written when the interval
+// is up, so a job can checkpoint after every unit of work without thrashing the
+// database.
+const checkpointInterval = 5 * time.Second
+
+// Persistent is implemented by a JobExec that is saved with the queue, so that
+// it survives a restart of the server. Jobs that do not implement it are lost
+// when the server stops, as before.
+type Persistent interface {
+	JobExec
+
+	// JobType names the Factory, registered with Manager.RegisterType, that
+	// recreates the job.
+	JobType() string
+
+	// JobInput returns what the factory needs to recreate the job. It is saved
+	// as JSON.
+	JobInput() interface{}
+
+	// Resumable reports whether the job may be started again after being
+	// interrupted part way through, continuing from its last checkpoint. A job
+	// that is not is only restored if it had not yet started.
+	Resumable() bool
+}
+
+// Factory recreates a persistent job from its saved input and the last
+// checkpoint it made with Progress.Checkpoint, which is nil if it made none.
+type Factory func(input json.RawMessage, checkpoint json.RawMessage) (JobExec, error)
+
+// StoredJob is a persistent job as it is saved in a Store.
+type StoredJob struct {
+	ID          int
+	Type        string
+	Description string
+	Input       json.RawMessage
+	Checkpoint  json.RawMessage
+	// Started is true once the job has been started, and so must be resumable
+	// to be restored.
+	Started bool
+	AddTime time.Time
+}
+
+// Store saves the persistent jobs in the queue.
+type Store interface {
+	// SaveJob creates or replaces a job.
+	SaveJob(ctx context.Context, j StoredJob) error
+	SetStarted(ctx context.Context, id int) error
+	SetCheckpoint(ctx context.Context, id int, checkpoint json.RawMessage) error
+	DeleteJob(ctx context.Context, id int) error
+	// AllJobs returns the saved jobs in the order they were added.
+	AllJobs(ctx context.Context) ([]StoredJob, error)
+}
+
+// RegisterType registers the factory that recreates persistent jobs of the
+// given type. It must be called before Restore.
+func (m *Manager) RegisterType(jobType string, f Factory) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.factories == nil {
+		m.factories = make(map[string]Factory)
+	}
+	m.factories[jobType] = f
+}
+
+// Restore starts saving persistent jobs to store, and queues the jobs saved
+// there that did not end before the server last stopped. A job that had
+// started is only queued again if it is resumable, and then continues from its
+// last checkpoint. Restored jobs run with ctx, and keep their IDs unless the
+// manager has given them to other jobs already, as it has if the database only
+// became ready after the manager started. Restore does nothing if the manager
+// already has a store.
+func (m *Manager) Restore(ctx context.Context, store Store) error {
+	if m.getStore() != nil {
+		return nil
+	}
+
+	saved, err := store.AllJobs(ctx)
+	if err != nil {
+		return fmt.Errorf("loading saved jobs: %w", err)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.store != nil {
+		return nil
+	}
+	m.store = store
+
+	issued := m.lastID
+	for _, s := range saved {
+		if s.ID > m.lastID {
+			m.lastID = s.ID
+		}
+	}
+
+	for _, s := range saved {
+		if s.ID <= issued {
+			oldID := s.ID
+			s.ID = m.nextID()
+			if err := store.SaveJob(ctx, s); err != nil {
+				logger.Warnf("Not restoring job %d - %s: %v", oldID, s.Description, err)
+				continue
+			}
+			if err := store.DeleteJob(ctx, oldID); err != nil {
+				logger.Warnf("Deleting saved job %d - %s: %v", oldID, s.Description, err)
+			}
+		}
+
+		e, err := m.recreate(s)
+		if err != nil {
+			logger.Warnf("Not restoring job %d - %s: %v", s.ID, s.Description, err)
+			go m.forget(s.ID)
+			continue
+		}
+
+		logger.Infof("Restoring job %d - %s", s.ID, s.Description)
+
+		j := &Job{
+			ID:          s.ID,
+			Status:      StatusReady,
+			Description: s.Description,
+			AddTime:     s.AddTime,
+			outerCtx:    ctx,
+			done:        make(chan struct{}),
+		}
+		j.setExec(e)
+		m.enqueue(j)
+		m.notifyNewJob(j)
+	}
+
+	m.changed.Broadcast()
+
+	return nil
+}
+
+// recreate makes the JobExec of a saved job.
+func (m *Manager) recreate(s StoredJob) (JobExec, error) {
+	// assumes lock held
+	f := m.factories[s.Type]
+	if f == nil {
+		return nil, fmt.Errorf("unknown job type %q", s.Type)
+	}
+
+	e, err := f(s.Input, s.Checkpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	p, ok := e.(Persistent)
+	if !ok {
+		return nil, fmt.Errorf("job type %q is not persistent", s.Type)
+	}
+	if s.Started && !p.Resumable() {
+		return nil, fmt.Errorf("it was interrupted and cannot be resumed")
+	}
+
+	return e, nil
+}
+
+// save adds a new persistent job to the store.
+func (m *Manager) save(j *Job) {
+	store := m.getStore()
+	if store == nil || j.persistent == nil {
+		return
+	}
+
+	input, err := json.Marshal(j.persistent.JobInput())
+	if err == nil {
+		err = store.SaveJob(context.Background(), StoredJob{
+			ID:          j.ID,
+			Type:        j.persistent.JobType(),
+			Description: j.Description,
+			Input:       input,
+			AddTime:     j.AddTime,
+		})
+	}
+	if err != nil {
+		logger.Errorf("Saving job %d - %s: %v", j.ID, j.Description, err)
+	}
+}
+
+// markStarted records that a persistent job has started.
+func (m *Manager) markStarted(j *Job) {
+	store := m.getStore()
+	if store == nil || j.persistent == nil {
+		return
+	}
+
+	if err := store.SetStarted(context.Background(), j.ID); err != nil {
+		logger.Errorf("Saving job %d - %s: %v", j.ID, j.Description, err)
+	}
+}
+
+// settle updates the store once a job has run. A job that ended is forgotten,
+// while one interrupted by the manager stopping is kept, with its latest
+// checkpoint, to be restored. A job that fails while the manager is stopping is
+// taken to have been interrupted, since many fail with the cancelled context.
+func (m *Manager) settle(j *Job) {
+	m.mutex.Lock()
+	store := m.store
+	interrupted := m.stopping && j.Status != StatusFinished
+	if j.checkpointTimer != nil {
+		j.checkpointTimer.Stop()
+		j.checkpointTimer = nil
+	}
+	checkpoint := j.checkpoint
+	j.checkpoint = nil
+	m.mutex.Unlock()
+
+	if store == nil || j.persistent == nil {
+		return
+	}
+
+	if !interrupted {
+		m.forget(j.ID)
+		return
+	}
+
+	if checkpoint != nil {
+		if err := store.SetCheckpoint(context.Background(), j.ID, checkpoint); err != nil {
+			logger.Errorf("Saving checkpoint of job %d - %s: %v", j.ID, j.Description, err)
+		}
+	}
+}
+
+// forget removes a job from the store.
+func (m *Manager) forget(id int) {
+	store := m.getStore()
+	if store == nil {
+		return
+	}
+
+	if err := store.DeleteJob(context.Background(), id); err != nil {
+		logger.Errorf("Removing saved job %d: %v", id, err)
+	}
+}
+
+func (m *Manager) getStore() Store {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.store
+}
+
+// setCheckpoint holds the latest checkpoint of a job, writing it to the store
+// at most once every checkpointInterval.
+func (m *Manager) setCheckpoint(j *Job, checkpoint json.RawMessage) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.store == nil || j.persistent == nil || !j.persistent.Resumable() {
+		return
+	}
+
+	j.checkpoint = checkpoint
+	if j.checkpointTimer != nil {
+		return
+	}
+
+	wait := checkpointInterval - time.Since(j.checkpointSaved)
+	if wait < 0 {
+		wait = 0
+	}
+	j.checkpointTimer = time.AfterFunc(wait, func() {
+		m.mutex.Lock()
+		store := m.store
+		checkpoint := j.checkpoint
+		j.checkpoint = nil
+		j.checkpointTimer = nil
+		j.checkpointSaved = time.Now()
+		m.mutex.Unlock()
+
+		if checkpoint == nil {
+			return
+		}
+		if err := store.SetCheckpoint(context.Background(), j.ID, checkpoint); err != nil {
+			logger.Errorf("Saving checkpoint of job %d - %s: %v", j.ID, j.Description, err)
+		}
+	})
+}
+
+// Checkpoint saves v as the point a resumable job has reached, so that if the
+// server stops before the job ends it continues from here rather than from the
+// start. v is passed, as JSON, to the job's Factory when it is restored. It has
+// no effect on a job that is not resumable.
+func (p *Progress) Checkpoint(v interface{}) {
+	if p.updater == nil {
+		return
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		logger.Errorf("Saving checkpoint of job %d: %v", p.updater.job.ID, err)
+		return
+	}
+
+	p.updater.m.setCheckpoint(p.updater.job, data)
+}
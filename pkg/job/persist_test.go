@@ -0,0 +1,278 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memStore struct {
+	mu   sync.Mutex
+	jobs map[int]StoredJob
+}
+
+func newMemStore(jobs ...StoredJob) *memStore {
+	s := &memStore{jobs: make(map[int]StoredJob)}
+	for _, j := range jobs {
+		s.jobs[j.ID] = j
+	}
+	return s
+}
+
+func (s *memStore) SaveJob(_ context.Context, j StoredJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[j.ID] = j
+	return nil
+}
+
+func (s *memStore) SetStarted(_ context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if j, ok := s.jobs[id]; ok {
+		j.Started = true
+		s.jobs[id] = j
+	}
+	return nil
+}
+
+func (s *memStore) SetCheckpoint(_ context.Context, id int, checkpoint json.RawMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if j, ok := s.jobs[id]; ok {
+		j.Checkpoint = checkpoint
+		s.jobs[id] = j
+	}
+	return nil
+}
+
+func (s *memStore) DeleteJob(_ context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jobs, id)
+	return nil
+}
+
+func (s *memStore) AllJobs(context.Context) ([]StoredJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ret []StoredJob
+	for _, j := range s.jobs {
+		ret = append(ret, j)
+	}
+	sort.Slice(ret, func(i, k int) bool { return ret[i].ID < ret[k].ID })
+	return ret, nil
+}
+
+func (s *memStore) get(id int) (StoredJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	return j, ok
+}
+
+// countExec counts from its checkpoint to its input, checkpointing each step,
+// until it reaches the end or is cancelled.
+type countExec struct {
+	to        int
+	from      int
+	resumable bool
+	step      chan struct{}
+	reached   chan int
+}
+
+func (e *countExec) JobType() string       { return "count" }
+func (e *countExec) JobInput() interface{} { return e.to }
+func (e *countExec) Resumable() bool       { return e.resumable }
+
+func (e *countExec) Execute(ctx context.Context, p *Progress) error {
+	for i := e.from; i < e.to; i++ {
+		if e.step != nil {
+			select {
+			case <-e.step:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		p.Checkpoint(i + 1)
+		if e.reached != nil {
+			e.reached <- i + 1
+		}
+	}
+	return nil
+}
+
+func countFactory(started chan<- *countExec) Factory {
+	return func(input, checkpoint json.RawMessage) (JobExec, error) {
+		e := &countExec{resumable: true}
+		if err := json.Unmarshal(input, &e.to); err != nil {
+			return nil, err
+		}
+		if checkpoint != nil {
+			if err := json.Unmarshal(checkpoint, &e.from); err != nil {
+				return nil, err
+			}
+		}
+		if started != nil {
+			started <- e
+		}
+		return e, nil
+	}
+}
+
+func TestPersistentJobForgottenOnceFinished(t *testing.T) {
+	store := newMemStore()
+	m := NewManager()
+	defer m.Stop()
+	if err := m.Restore(context.Background(), store); err != nil {
+		t.Fatal(err)
+	}
+
+	step := make(chan struct{})
+	id := m.Add(context.Background(), "count", &countExec{to: 1, resumable: true, step: step})
+
+	saved, ok := store.get(id)
+	if !ok || saved.Type != "count" || string(saved.Input) != "1" {
+		t.Fatalf("saved job = %+v, %v", saved, ok)
+	}
+
+	close(step)
+	if _, err := m.Wait(context.Background(), id); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.get(id); ok {
+		t.Error("finished job still saved")
+	}
+}
+
+func TestResumableJobRestoredFromCheckpoint(t *testing.T) {
+	store := newMemStore()
+	m := NewManager()
+	if err := m.Restore(context.Background(), store); err != nil {
+		t.Fatal(err)
+	}
+
+	step := make(chan struct{})
+	reached := make(chan int, 10)
+	id := m.Add(context.Background(), "count", &countExec{to: 5, resumable: true, step: step, reached: reached})
+
+	step <- struct{}{}
+	step <- struct{}{}
+	<-reached
+	<-reached
+	m.StopAndWait(5 * time.Second)
+
+	saved, ok := store.get(id)
+	if !ok || !saved.Started || string(saved.Checkpoint) != "2" {
+		t.Fatalf("saved job = %+v, %v; want it started with checkpoint 2", saved, ok)
+	}
+
+	// a restart
+	started := make(chan *countExec, 1)
+	m = NewManager()
+	defer m.Stop()
+	m.RegisterType("count", countFactory(started))
+	if err := m.Restore(context.Background(), store); err != nil {
+		t.Fatal(err)
+	}
+
+	e := <-started
+	if e.from != 2 || e.to != 5 {
+		t.Errorf("restored to count from %d to %d, want 2 to 5", e.from, e.to)
+	}
+	if j, err := m.Wait(context.Background(), id); err != nil || j == nil || j.Status != StatusFinished {
+		t.Errorf("restored job = %+v, %v", j, err)
+	}
+	if next := m.Add(context.Background(), "other", newTestExec(nil)); next <= id {
+		t.Errorf("new job given ID %d, not after restored job %d", next, id)
+	}
+}
+
+func TestNonResumableJobNotRestoredOnceStarted(t *testing.T) {
+	store := newMemStore(
+		StoredJob{ID: 3, Type: "count", Input: json.RawMessage("1"), Started: true},
+		StoredJob{ID: 4, Type: "count", Input: json.RawMessage("1")},
+	)
+
+	m := NewManager()
+	defer m.Stop()
+	m.MaxConcurrentJobs = 1
+	m.RegisterType("count", func(input, checkpoint json.RawMessage) (JobExec, error) {
+		return &countExec{to: 1, step: make(chan struct{})}, nil
+	})
+	if err := m.Restore(context.Background(), store); err != nil {
+		t.Fatal(err)
+	}
+
+	queue := m.GetQueue()
+	assert.Len(t, queue, 1)
+	if len(queue) == 1 {
+		assert.Equal(t, 4, queue[0].ID)
+	}
+
+	time.Sleep(sleepTime)
+	if _, ok := store.get(3); ok {
+		t.Error("interrupted non-resumable job still saved")
+	}
+}
+
+func TestCancelledJobForgotten(t *testing.T) {
+	store := newMemStore()
+	m := NewManager()
+	defer m.Stop()
+	m.MaxConcurrentJobs = 1
+	if err := m.Restore(context.Background(), store); err != nil {
+		t.Fatal(err)
+	}
+
+	m.Add(context.Background(), "blocker", newTestExec(make(chan struct{})))
+	id := m.Add(context.Background(), "count", &countExec{to: 1, resumable: true})
+
+	m.CancelJob(id)
+	time.Sleep(sleepTime)
+	if _, ok := store.get(id); ok {
+		t.Error("job cancelled by the user still saved")
+	}
+}
+
+// The database may only become ready after jobs have been added, as it does
+// when it is migrated first. Saved jobs must not take the IDs of those jobs,
+// and restoring again must not queue them twice.
+func TestRestoreAfterJobsAdded(t *testing.T) {
+	store := newMemStore(StoredJob{ID: 1, Type: "count", Description: "count", Input: json.RawMessage("1")})
+	m := NewManager()
+	defer m.Stop()
+	m.MaxConcurrentJobs = 1
+
+	blocker := m.Add(context.Background(), "blocker", newTestExec(make(chan struct{})))
+	if blocker != 1 {
+		t.Fatalf("first job given ID %d", blocker)
+	}
+
+	m.RegisterType("count", countFactory(nil))
+	if err := m.Restore(context.Background(), store); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Restore(context.Background(), store); err != nil {
+		t.Fatal(err)
+	}
+
+	queue := m.GetQueue()
+	if assert.Len(t, queue, 2) {
+		assert.Equal(t, blocker, queue[0].ID)
+		assert.NotEqual(t, blocker, queue[1].ID)
+		assert.Equal(t, "count", queue[1].Description)
+
+		if _, ok := store.get(queue[1].ID); !ok {
+			t.Error("restored job not saved under its new ID")
+		}
+	}
+	if saved, ok := store.get(blocker); ok {
+		t.Errorf("restored job still saved under the ID of another: %+v", saved)
+	}
+}
@@ -163,9 +163,35 @@ func (p *Progress) removeTask(t *task) {
 	}
 }
 
+// WaitIfPaused blocks while the job is paused, returning once it is resumed
+// or cancelled. Jobs that do not use ExecuteTask should call it between units
+// of work, so that they can be paused.
+func (p *Progress) WaitIfPaused() {
+	if resume := p.updater.resumed(); resume != nil {
+		<-resume
+	}
+}
+
 // ExecuteTask executes a task as part of a job. The description is used to
-// populate the Details slice in the parent Job.
+// populate the Details slice in the parent Job. If the job is paused, the task
+// waits until it is resumed.
 func (p *Progress) ExecuteTask(description string, fn func()) {
+	p.WaitIfPaused()
+
+	t := &task{
+		description: description,
+	}
+
+	p.addTask(t)
+	defer p.removeTask(t)
+	fn()
+}
+
+// ExecuteNestedTask executes a task that is part of another task, such as a
+// file within a zip file. Unlike ExecuteTask, it does not wait if the job is
+// paused, since the enclosing task may hold a transaction open, and the job
+// is paused at its next task instead.
+func (p *Progress) ExecuteNestedTask(description string, fn func()) {
 	t := &task{
 		description: description,
 	}
@@ -0,0 +1,69 @@
+package job
+
+import "sync"
+
+// Watermark tracks work items that are queued in order but may finish out of
+// order, as when a job hands them to parallel workers, to find how far the job
+// has safely got: the point before which every item is done. It is what a
+// resumable job checkpoints.
+type Watermark struct {
+	mu      sync.Mutex
+	next    int
+	pending map[int]*watermarkItem
+	count   int
+	key     string
+}
+
+type watermarkItem struct {
+	key  string
+	done bool
+}
+
+// Add queues an item, identified to the job by key, returning its position.
+func (w *Watermark) Add(key string) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.pending == nil {
+		w.pending = make(map[int]*watermarkItem)
+	}
+
+	n := w.next
+	w.next++
+	w.pending[n] = &watermarkItem{key: key}
+	return n
+}
+
+// Done marks the item at position n finished. It returns true if that moved
+// the watermark, which is then given by Reached.
+func (w *Watermark) Done(n int) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	item := w.pending[n]
+	if item == nil {
+		return false
+	}
+	item.done = true
+
+	moved := false
+	for {
+		item := w.pending[w.count]
+		if item == nil || !item.done {
+			break
+		}
+		delete(w.pending, w.count)
+		w.key = item.key
+		w.count++
+		moved = true
+	}
+	return moved
+}
+
+// Reached returns how many items from the start are done, and the key of the
+// last of them.
+func (w *Watermark) Reached() (count int, key string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.count, w.key
+}
@@ -0,0 +1,25 @@
+package job
+
+import "testing"
+
+func TestWatermarkWaitsForEarlierItems(t *testing.T) {
+	var w Watermark
+	a := w.Add("a")
+	b := w.Add("b")
+	c := w.Add("c")
+
+	if w.Done(b) {
+		t.Error("watermark moved past unfinished a")
+	}
+	if !w.Done(a) {
+		t.Error("watermark did not move once a was done")
+	}
+	if count, key := w.Reached(); count != 2 || key != "b" {
+		t.Errorf("reached %d, %q; want 2, b", count, key)
+	}
+
+	w.Done(c)
+	if count, key := w.Reached(); count != 3 || key != "c" {
+		t.Errorf("reached %d, %q; want 3, c", count, key)
+	}
+}
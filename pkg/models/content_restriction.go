@@ -0,0 +1,76 @@
+package models
+
+import (
+	"context"
+
+	"github.com/stashapp/stash/pkg/sliceutil/intslice"
+)
+
+// ContentRestriction limits the scenes a user may see. A scene is visible
+// when it matches the saved scene filter, if one is set, and carries none of
+// the excluded tags or studios, including their children.
+type ContentRestriction struct {
+	SavedFilterID     *int  `json:"saved_filter_id"`
+	ExcludedTagIDs    []int `json:"excluded_tag_ids"`
+	ExcludedStudioIDs []int `json:"excluded_studio_ids"`
+}
+
+// IsEmpty returns true if the restriction does not hide any scenes.
+func (r ContentRestriction) IsEmpty() bool {
+	return r.SavedFilterID == nil && len(r.ExcludedTagIDs) == 0 && len(r.ExcludedStudioIDs) == 0
+}
+
+// SceneFilter combines the tag and studio exclusions with the scene filter
+// of the attached saved filter, if any. It returns nil if the restriction is
+// empty.
+func (r ContentRestriction) SceneFilter(saved *SceneFilterType) *SceneFilterType {
+	if r.IsEmpty() {
+		return nil
+	}
+
+	allDescendants := -1
+	ret := &SceneFilterType{}
+	ret.And = saved
+
+	if len(r.ExcludedTagIDs) > 0 {
+		ret.Tags = &HierarchicalMultiCriterionInput{
+			Value:    intslice.IntSliceToStringSlice(r.ExcludedTagIDs),
+			Modifier: CriterionModifierExcludes,
+			Depth:    &allDescendants,
+		}
+	}
+
+	if len(r.ExcludedStudioIDs) > 0 {
+		ret.Studios = &HierarchicalMultiCriterionInput{
+			Value:    intslice.IntSliceToStringSlice(r.ExcludedStudioIDs),
+			Modifier: CriterionModifierExcludes,
+			Depth:    &allDescendants,
+		}
+	}
+
+	return ret
+}
+
+// ContentRestrictionInput is the input used to set a user's content
+// restriction. A nil SavedFilterID detaches the saved filter.
+type ContentRestrictionInput struct {
+	SavedFilterID     *string  `json:"saved_filter_id"`
+	ExcludedTagIDs    []string `json:"excluded_tag_ids"`
+	ExcludedStudioIDs []string `json:"excluded_studio_ids"`
+}
+
+type sceneRestrictionContextKey struct{}
+
+// WithSceneRestriction returns a copy of ctx in which scene reads only return
+// scenes matching the given filter. Passing a nil filter lifts any
+// restriction set on ctx.
+func WithSceneRestriction(ctx context.Context, f *SceneFilterType) context.Context {
+	return context.WithValue(ctx, sceneRestrictionContextKey{}, f)
+}
+
+// SceneRestrictionFromContext returns the filter set by WithSceneRestriction.
+// It returns nil when scene reads are unrestricted.
+func SceneRestrictionFromContext(ctx context.Context) *SceneFilterType {
+	f, _ := ctx.Value(sceneRestrictionContextKey{}).(*SceneFilterType)
+	return f
+}
@@ -0,0 +1,56 @@
+package jsonschema
+
+import (
+	"fmt"
+
+	"github.com/stashapp/stash/pkg/models/json"
+)
+
+// SyncManifestFilename is the name of the manifest at the root of a sync
+// bundle.
+const SyncManifestFilename = "manifest.json"
+
+// SyncManifest describes the contents of a bundle of scenes synced to a
+// device. Paths are relative to the root of the bundle.
+type SyncManifest struct {
+	Target    string        `json:"target"`
+	CreatedAt json.JSONTime `json:"created_at"`
+	// Full is set if the bundle has the files of every scene, rather than
+	// only of those that the device does not have.
+	Full bool `json:"full"`
+	// Scenes are every scene the device should have after syncing, in order
+	Scenes []SyncScene `json:"scenes"`
+	// Remove are the ids of scenes that the device should delete
+	Remove []int `json:"remove,omitempty"`
+}
+
+type SyncScene struct {
+	ID int `json:"id"`
+	// Metadata is the path of the scene's JSON, which is in the format of
+	// the scene export JSON, without the cover.
+	Metadata string `json:"metadata"`
+	// Size is the size of the scene's media
+	Size int64 `json:"size"`
+	// Media and the paths after it are only set if the scene's files are in
+	// the bundle. Otherwise the device has them from an earlier sync.
+	Media     string   `json:"media,omitempty"`
+	Cover     string   `json:"cover,omitempty"`
+	Captions  []string `json:"captions,omitempty"`
+	Funscript string   `json:"funscript,omitempty"`
+}
+
+// MarshalSyncManifest returns the JSON of a sync manifest.
+func MarshalSyncManifest(m *SyncManifest) ([]byte, error) {
+	if m == nil {
+		return nil, fmt.Errorf("sync manifest must not be nil")
+	}
+	return encode(m)
+}
+
+// MarshalScene returns the JSON of a scene, as it is written to an export.
+func MarshalScene(s *Scene) ([]byte, error) {
+	if s == nil {
+		return nil, fmt.Errorf("scene must not be nil")
+	}
+	return encode(s)
+}
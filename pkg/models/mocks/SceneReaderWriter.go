@@ -1076,6 +1076,29 @@ func (_m *SceneReaderWriter) GetManyTagIDs(ctx context.Context, ids []int) ([][]
 	return r0, r1
 }
 
+// GetManyUserData provides a mock function with given fields: ctx, ids
+func (_m *SceneReaderWriter) GetManyUserData(ctx context.Context, ids []int) ([]*models.UserData, error) {
+	ret := _m.Called(ctx, ids)
+
+	var r0 []*models.UserData
+	if rf, ok := ret.Get(0).(func(context.Context, []int) []*models.UserData); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.UserData)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []int) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetManyViewCount provides a mock function with given fields: ctx, ids
 func (_m *SceneReaderWriter) GetManyViewCount(ctx context.Context, ids []int) ([]int, error) {
 	ret := _m.Called(ctx, ids)
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIKeyScope limits what a named API key can be used for
+type APIKeyScope string
+
+const (
+	// APIKeyScopeFull allows everything the owner of the key can do
+	APIKeyScopeFull APIKeyScope = "full"
+	// APIKeyScopeReadOnly allows GraphQL queries, but not mutations
+	APIKeyScopeReadOnly APIKeyScope = "read_only"
+	// APIKeyScopeStream allows streaming scenes and fetching images
+	APIKeyScopeStream APIKeyScope = "stream"
+	// APIKeyScopeIPTV allows the IPTV playlists, guides and channel streams,
+	// including the Xtream endpoints
+	APIKeyScopeIPTV APIKeyScope = "iptv"
+	// APIKeyScopeHandy allows controlling the Handy
+	APIKeyScopeHandy APIKeyScope = "handy"
+)
+
+var AllAPIKeyScope = []APIKeyScope{
+	APIKeyScopeFull,
+	APIKeyScopeReadOnly,
+	APIKeyScopeStream,
+	APIKeyScopeIPTV,
+	APIKeyScopeHandy,
+}
+
+// IsValid checks if the scope is a valid APIKeyScope
+func (s APIKeyScope) IsValid() bool {
+	switch s {
+	case APIKeyScopeFull, APIKeyScopeReadOnly, APIKeyScopeStream, APIKeyScopeIPTV, APIKeyScopeHandy:
+		return true
+	}
+	return false
+}
+
+func (s APIKeyScope) String() string {
+	return string(s)
+}
+
+func (s *APIKeyScope) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	// Convert from GraphQL uppercase to database lowercase
+	*s = APIKeyScope(strings.ToLower(str))
+	if !s.IsValid() {
+		return fmt.Errorf("%s is not a valid APIKeyScope", str)
+	}
+	return nil
+}
+
+func (s APIKeyScope) MarshalGQL(w io.Writer) {
+	// Convert from database lowercase to GraphQL uppercase
+	fmt.Fprint(w, strconv.Quote(strings.ToUpper(s.String())))
+}
+
+// APIKeyScopes is the set of scopes of an API key
+type APIKeyScopes []APIKeyScope
+
+// Has returns true if the scopes include scope, or are full.
+func (s APIKeyScopes) Has(scope APIKeyScope) bool {
+	for _, v := range s {
+		if v == scope || v == APIKeyScopeFull {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey is a named API key a user issues to a third-party tool. Only a hash
+// of the key is stored; the key itself is shown once, when it is created.
+type APIKey struct {
+	ID     int    `json:"id"`
+	UserID int    `json:"user_id"`
+	Name   string `json:"name"`
+	// Prefix is the start of the key, to tell keys apart
+	Prefix     string       `json:"prefix"`
+	KeyHash    string       `json:"-"`
+	Scopes     APIKeyScopes `json:"scopes"`
+	ExpiresAt  *time.Time   `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time   `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+// IsExpired returns true if the key has expired at t
+func (k *APIKey) IsExpired(t time.Time) bool {
+	return k.ExpiresAt != nil && !t.Before(*k.ExpiresAt)
+}
+
+// APIKeyCreateInput contains all data needed to create a new API key
+type APIKeyCreateInput struct {
+	Name      string        `json:"name"`
+	Scopes    []APIKeyScope `json:"scopes"`
+	ExpiresAt *time.Time    `json:"expires_at,omitempty"`
+	// UserID is the owner of the key. Defaults to the current user; only
+	// admins may create keys for other users.
+	UserID *string `json:"user_id,omitempty"`
+}
+
+// APIKeyCreateResult is a newly created API key along with the key itself
+type APIKeyCreateResult struct {
+	APIKey *APIKey `json:"api_key"`
+	Key    string  `json:"key"`
+}
+
+type apiKeyScopesContextKey struct{}
+
+// WithAPIKeyScopes returns a copy of ctx in which requests are limited to the
+// scopes of the API key they were authenticated with.
+func WithAPIKeyScopes(ctx context.Context, scopes APIKeyScopes) context.Context {
+	return context.WithValue(ctx, apiKeyScopesContextKey{}, scopes)
+}
+
+// APIKeyScopesFromContext returns the API key scopes set on ctx. It returns
+// false if the request was not authenticated with a named API key.
+func APIKeyScopesFromContext(ctx context.Context) (APIKeyScopes, bool) {
+	ret, ok := ctx.Value(apiKeyScopesContextKey{}).(APIKeyScopes)
+	return ret, ok
+}
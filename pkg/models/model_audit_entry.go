@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// AuditEntry records a change made through a GraphQL mutation or a
+// destructive REST call, along with who made it.
+type AuditEntry struct {
+	ID int `json:"id"`
+	// UserID is the user who made the change. It is nil if the change was
+	// made without a database user, or the user has since been deleted.
+	UserID *int `json:"user_id"`
+	// Username is kept alongside UserID so that entries outlive the user
+	Username  string `json:"username"`
+	Operation string `json:"operation"`
+	// EntityType is the type of entity changed, such as "performer". It is
+	// empty if the operation does not target an entity type.
+	EntityType string   `json:"entity_type"`
+	EntityIDs  []string `json:"entity_ids"`
+	// Changes holds the changed fields of each entity, keyed by entity ID,
+	// as objects with "old" and "new" values. Every field of a created or
+	// removed entity is included. For entities that are not loaded, such as
+	// the configuration, it holds the input fields set by the operation
+	// instead. Secrets are redacted.
+	Changes map[string]interface{} `json:"changes"`
+	// Error is set if the operation failed
+	Error     string    `json:"error"`
+	IPAddress string    `json:"ip_address"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AuditEntryFilterType filters audit log entries
+type AuditEntryFilterType struct {
+	UserID     *string                  `json:"user_id"`
+	Operation  *string                  `json:"operation"`
+	EntityType *string                  `json:"entity_type"`
+	EntityID   *string                  `json:"entity_id"`
+	CreatedAt  *TimestampCriterionInput `json:"created_at"`
+}
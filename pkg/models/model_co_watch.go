@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// WatchEvent is a play or O recorded in the history of a scene.
+type WatchEvent struct {
+	// UserID is nil for history recorded without a user
+	UserID  *int
+	SceneID int
+	Date    time.Time
+	// New is true if the event has not yet been added to the co-watch model
+	New bool
+}
+
+// CoWatchCursor is the position in the play and O history up to which the
+// co-watch model has been built. Each value is the id of the last history row
+// added.
+type CoWatchCursor struct {
+	ViewDateID int
+	ODateID    int
+}
+
+// CoWatchPair counts how often two scenes have been watched together.
+type CoWatchPair struct {
+	SceneID      int
+	OtherSceneID int
+	// Users is the number of users who have watched both scenes
+	Users int
+	// Sessions is the number of times the scenes were watched in the same
+	// session
+	Sessions int
+}
@@ -8,6 +8,7 @@ import (
 // A profile aggregates weighted preferences across tags, performers, studios, and physical attributes.
 type ContentProfile struct {
 	ID          int       `json:"id"`
+	UserID      *int      `json:"user_id"`      // Owning user; nil in single-user mode
 	ProfileType string    `json:"profile_type"` // "user" (global), "performer", "studio"
 	ProfileKey  *string   `json:"profile_key"`  // For sub-profiles, stores the entity ID
 	CreatedAt   time.Time `json:"created_at"`
@@ -89,6 +90,43 @@ type RecommendationResult struct {
 	StashDBScene     *ScrapedScene     `json:"stash_db_scene"`     // Populated if type is "stashdb_scene"
 	Performer        *Performer        `json:"performer"`          // Populated if type is "performer"
 	StashDBPerformer *ScrapedPerformer `json:"stash_db_performer"` // Populated if type is "stashdb_performer"
+
+	Breakdown *RecommendationBreakdown `json:"breakdown"` // How the score was computed; nil for StashDB results
+}
+
+// RecommendationBreakdown explains how the score of a recommendation was computed.
+type RecommendationBreakdown struct {
+	Factors          []*RecommendationFactor `json:"factors"`           // Per-factor contributions to the score
+	Tags             []RecommendationMatch   `json:"tags"`              // Matching tags with their profile weights
+	Performers       []RecommendationMatch   `json:"performers"`        // Matching performers with their profile weights
+	Studio           *RecommendationMatch    `json:"studio"`            // Matching studio with its profile weight
+	VisualSimilarity *float64                `json:"visual_similarity"` // Visual similarity, if it was considered
+	WatchedPenalty   float64                 `json:"watched_penalty"`   // Amount subtracted because the item was watched
+}
+
+// RecommendationFactor is the contribution of a single signal to a recommendation score.
+type RecommendationFactor struct {
+	Name         string  `json:"name"`         // "tags", "performers", "studio", "co_watch", "metadata", "phash", "visual"
+	Score        float64 `json:"score"`        // Signal value before weighting
+	Weight       float64 `json:"weight"`       // Weight applied to the signal
+	Contribution float64 `json:"contribution"` // Score * Weight, as added to the total
+}
+
+// RecommendationMatch is an entity of the recommended item that matched the
+// profile, with the profile weight of the entity.
+type RecommendationMatch struct {
+	ID     int
+	Weight float64
+}
+
+// AddFactor appends a factor with the given signal value and weight.
+func (b *RecommendationBreakdown) AddFactor(name string, score float64, weight float64) {
+	b.Factors = append(b.Factors, &RecommendationFactor{
+		Name:         name,
+		Score:        score,
+		Weight:       weight,
+		Contribution: score * weight,
+	})
 }
 
 // RecommendationOptions configures recommendation queries.
@@ -100,6 +138,8 @@ type RecommendationOptions struct {
 	TagWeight       *float64              `json:"tag_weight"`       // Weight override for tags (0-1)
 	PerformerWeight *float64              `json:"performer_weight"` // Weight override for performers (0-1)
 	StudioWeight    *float64              `json:"studio_weight"`    // Weight override for studios (0-1)
+	CoWatchWeight   *float64              `json:"co_watch_weight"`  // Weight override for scenes watched alongside (0-1)
+	WatchedPenalty  *float64              `json:"watched_penalty"`  // Fraction of the score removed for watched scenes (0-1)
 	ExcludeIds      []string              `json:"exclude_ids"`      // IDs to omit from results (cross-row dedup)
 }
 
@@ -0,0 +1,195 @@
+package models
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IPTVChannelSource is the kind of entity whose scenes a user-defined IPTV
+// channel airs.
+type IPTVChannelSource string
+
+const (
+	IPTVChannelSourceSavedFilter IPTVChannelSource = "saved_filter"
+	IPTVChannelSourceTag         IPTVChannelSource = "tag"
+	IPTVChannelSourcePerformer   IPTVChannelSource = "performer"
+	IPTVChannelSourceGroup       IPTVChannelSource = "group"
+	IPTVChannelSourcePlaylist    IPTVChannelSource = "playlist"
+)
+
+func (e IPTVChannelSource) IsValid() bool {
+	switch e {
+	case IPTVChannelSourceSavedFilter, IPTVChannelSourceTag, IPTVChannelSourcePerformer, IPTVChannelSourceGroup, IPTVChannelSourcePlaylist:
+		return true
+	}
+	return false
+}
+
+func (e IPTVChannelSource) String() string {
+	return string(e)
+}
+
+func (e *IPTVChannelSource) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	// Convert from GraphQL uppercase to database lowercase
+	*e = IPTVChannelSource(strings.ToLower(str))
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid IPTVChannelSource", str)
+	}
+	return nil
+}
+
+func (e IPTVChannelSource) MarshalGQL(w io.Writer) {
+	// Convert from database lowercase to GraphQL uppercase
+	fmt.Fprint(w, strconv.Quote(strings.ToUpper(e.String())))
+}
+
+// IPTVChannel is a user-defined IPTV channel. It airs the scenes matched by
+// its source, in an order fixed by its shuffle seed.
+type IPTVChannel struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Number is the fixed channel number. Nil numbers the channel after the
+	// library studio channels.
+	Number     *int              `json:"number"`
+	SourceType IPTVChannelSource `json:"source_type"`
+	SourceID   int               `json:"source_id"`
+	// ShuffleSeed orders the rotation. Nil derives it from the channel id.
+	ShuffleSeed *int      `json:"shuffle_seed"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// NewIPTVChannel creates a new IPTVChannel with default values.
+func NewIPTVChannel() IPTVChannel {
+	currentTime := time.Now()
+	return IPTVChannel{
+		CreatedAt: currentTime,
+		UpdatedAt: currentTime,
+	}
+}
+
+// IPTVChannelPartial represents part of an IPTVChannel for partial updates.
+type IPTVChannelPartial struct {
+	Name        OptionalString
+	Number      OptionalInt
+	SourceType  OptionalString
+	SourceID    OptionalInt
+	ShuffleSeed OptionalInt
+	UpdatedAt   OptionalTime
+}
+
+// NewIPTVChannelPartial creates a new IPTVChannelPartial with UpdatedAt set.
+func NewIPTVChannelPartial() IPTVChannelPartial {
+	return IPTVChannelPartial{
+		UpdatedAt: NewOptionalTime(time.Now()),
+	}
+}
+
+// IPTVChannelBlock is a weekly time-of-day block of an IPTV channel. While
+// it is on, the channel airs the scenes of the block's source instead of its
+// own.
+type IPTVChannelBlock struct {
+	ID        int    `json:"id"`
+	ChannelID int    `json:"channel_id"`
+	Name      string `json:"name"`
+	// Days are the days the block airs on, 0 being Sunday as in time.Weekday.
+	// Empty means every day.
+	Days []int `json:"days"`
+	// StartMinute and EndMinute are minutes since local midnight. An end at or
+	// before the start runs past midnight into the next day.
+	StartMinute int               `json:"start_minute"`
+	EndMinute   int               `json:"end_minute"`
+	SourceType  IPTVChannelSource `json:"source_type"`
+	SourceID    int               `json:"source_id"`
+}
+
+// Length returns the duration of the block in minutes.
+func (b IPTVChannelBlock) Length() int {
+	l := b.EndMinute - b.StartMinute
+	if l <= 0 {
+		l += 24 * 60
+	}
+	return l
+}
+
+// AirsOn reports whether the block starts on the given day.
+func (b IPTVChannelBlock) AirsOn(day time.Weekday) bool {
+	if len(b.Days) == 0 {
+		return true
+	}
+	for _, d := range b.Days {
+		if time.Weekday(d) == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Overlaps reports whether two blocks are ever on at the same time.
+func (b IPTVChannelBlock) Overlaps(o IPTVChannelBlock) bool {
+	const day, week = 24 * 60, 7 * 24 * 60
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if !b.AirsOn(d) {
+			continue
+		}
+		start := int(d)*day + b.StartMinute
+		end := start + b.Length()
+		for e := time.Sunday; e <= time.Saturday; e++ {
+			if !o.AirsOn(e) {
+				continue
+			}
+			oStart := int(e)*day + o.StartMinute
+			oEnd := oStart + o.Length()
+			// compare in the week before, the same week and the week after, so
+			// that Saturday night blocks meet Sunday morning ones
+			for shift := -week; shift <= week; shift += week {
+				if start < oEnd+shift && oStart+shift < end {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+type IPTVChannelBlockInput struct {
+	Name *string `json:"name"`
+	Days []int   `json:"days"`
+	// Start and End are times of day formatted as HH:MM
+	Start      string            `json:"start"`
+	End        string            `json:"end"`
+	SourceType IPTVChannelSource `json:"source_type"`
+	SourceID   string            `json:"source_id"`
+}
+
+type IPTVChannelCreateInput struct {
+	Name        string            `json:"name"`
+	Number      *int              `json:"number"`
+	SourceType  IPTVChannelSource `json:"source_type"`
+	SourceID    string            `json:"source_id"`
+	ShuffleSeed *int              `json:"shuffle_seed"`
+	// This should be a URL or a base64 encoded data URL
+	Logo   *string                  `json:"logo"`
+	Blocks []*IPTVChannelBlockInput `json:"blocks"`
+}
+
+type IPTVChannelUpdateInput struct {
+	ID          string             `json:"id"`
+	Name        *string            `json:"name"`
+	Number      *int               `json:"number"`
+	SourceType  *IPTVChannelSource `json:"source_type"`
+	SourceID    *string            `json:"source_id"`
+	ShuffleSeed *int               `json:"shuffle_seed"`
+	// This should be a URL or a base64 encoded data URL
+	Logo *string `json:"logo"`
+	// Blocks replaces all of the channel's blocks when set
+	Blocks []*IPTVChannelBlockInput `json:"blocks"`
+}
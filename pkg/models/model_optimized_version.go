@@ -0,0 +1,99 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	OptimizedVideoCodecH264 = "h264"
+	OptimizedVideoCodecHEVC = "hevc"
+)
+
+// OptimizedVersionProfile describes a version of scenes that is transcoded
+// ahead of time, so that it can be streamed without transcoding live.
+type OptimizedVersionProfile struct {
+	// Name identifies the profile and the versions generated with it
+	Name string `json:"name"`
+	// VideoCodec is OptimizedVideoCodecH264 or OptimizedVideoCodecHEVC
+	VideoCodec string `json:"videoCodec"`
+	// MaxResolution is the largest resolution of the version. Nil for the
+	// resolution of the scene.
+	MaxResolution *StreamingResolutionEnum `json:"maxResolution"`
+	// VideoBitrate is the target bit rate of the video in kbps. 0 encodes at
+	// a constant quality instead.
+	VideoBitrate int `json:"videoBitrate"`
+	// AudioBitrate is the bit rate of the audio in kbps. 0 for the default.
+	AudioBitrate int `json:"audioBitrate"`
+}
+
+// GetMaxResolution returns the largest size of the smaller dimension of the
+// version, or 0 for the size of the scene.
+func (p OptimizedVersionProfile) GetMaxResolution() int {
+	if p.MaxResolution == nil {
+		return 0
+	}
+	return p.MaxResolution.GetMaxResolution()
+}
+
+func (p OptimizedVersionProfile) validate() error {
+	if p.Name == "" {
+		return errors.New("optimized version profile name is required")
+	}
+
+	switch p.VideoCodec {
+	case OptimizedVideoCodecH264, OptimizedVideoCodecHEVC:
+	default:
+		return fmt.Errorf("optimized version profile %q: unsupported video codec %q", p.Name, p.VideoCodec)
+	}
+
+	if p.MaxResolution != nil && !p.MaxResolution.IsValid() {
+		return fmt.Errorf("optimized version profile %q: invalid resolution %q", p.Name, *p.MaxResolution)
+	}
+
+	if p.VideoBitrate < 0 || p.AudioBitrate < 0 {
+		return fmt.Errorf("optimized version profile %q: bit rates must not be negative", p.Name)
+	}
+
+	return nil
+}
+
+// ValidateOptimizedVersionProfiles returns an error if any of the profiles is
+// invalid, or if two share a name.
+func ValidateOptimizedVersionProfiles(profiles []*OptimizedVersionProfile) error {
+	names := make(map[string]bool)
+	for _, p := range profiles {
+		if err := p.validate(); err != nil {
+			return err
+		}
+		if names[p.Name] {
+			return fmt.Errorf("duplicate optimized version profile %q", p.Name)
+		}
+		names[p.Name] = true
+	}
+
+	return nil
+}
+
+// OptimizedVersion is a file of a scene generated with an
+// OptimizedVersionProfile
+type OptimizedVersion struct {
+	ID      int    `json:"id"`
+	SceneID int    `json:"scene_id"`
+	Profile string `json:"profile"`
+	// Basename is the name of the file in the optimized versions directory
+	Basename   string    `json:"basename"`
+	Size       int64     `json:"size"`
+	Width      int       `json:"width"`
+	Height     int       `json:"height"`
+	VideoCodec string    `json:"video_codec"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// OptimizedVersionStorage is the storage used by the versions of a profile
+type OptimizedVersionStorage struct {
+	Profile string `json:"profile"`
+	Count   int    `json:"count"`
+	Size    int64  `json:"size"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// QueuedJob is a persistent job in the job queue, saved so that it can be
+// restored after a restart
+type QueuedJob struct {
+	// ID is the ID of the job in the queue
+	ID          int    `json:"id"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	// Input is the JSON the job is recreated from
+	Input []byte `json:"input"`
+	// Checkpoint is the JSON of the point the job last reached, or nil if it
+	// has not made a checkpoint
+	Checkpoint []byte    `json:"checkpoint"`
+	Started    bool      `json:"started"`
+	AddedAt    time.Time `json:"added_at"`
+}
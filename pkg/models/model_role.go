@@ -0,0 +1,148 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Permission is a capability that can be granted to users through a role
+type Permission string
+
+const (
+	// PermissionEditMetadata allows creating and editing scenes, performers,
+	// studios, tags and the other library objects
+	PermissionEditMetadata Permission = "edit_metadata"
+	// PermissionDeleteFiles allows deleting files from disk
+	PermissionDeleteFiles Permission = "delete_files"
+	// PermissionRunJobs allows running scan, generate and other library jobs
+	PermissionRunJobs Permission = "run_jobs"
+	// PermissionManagePlugins allows installing, configuring and running plugins
+	PermissionManagePlugins Permission = "manage_plugins"
+	// PermissionAPIHubDownloads allows connecting to and downloading from API Hub
+	PermissionAPIHubDownloads Permission = "api_hub_downloads"
+	// PermissionControlHandy allows controlling the Handy
+	PermissionControlHandy Permission = "control_handy"
+	// PermissionManageIPTV allows configuring the IPTV channels
+	PermissionManageIPTV Permission = "manage_iptv"
+	// PermissionViewRecycleBin allows seeing and restoring recycle bin entries
+	PermissionViewRecycleBin Permission = "view_recycle_bin"
+)
+
+var AllPermission = []Permission{
+	PermissionEditMetadata,
+	PermissionDeleteFiles,
+	PermissionRunJobs,
+	PermissionManagePlugins,
+	PermissionAPIHubDownloads,
+	PermissionControlHandy,
+	PermissionManageIPTV,
+	PermissionViewRecycleBin,
+}
+
+// ViewerPermissions are granted to every user, with or without a custom role.
+// They keep the access that viewers had before permissions could be granted.
+var ViewerPermissions = []Permission{
+	PermissionControlHandy,
+	PermissionViewRecycleBin,
+}
+
+// IsValid checks if the permission is a valid Permission
+func (p Permission) IsValid() bool {
+	switch p {
+	case PermissionEditMetadata, PermissionDeleteFiles, PermissionRunJobs, PermissionManagePlugins,
+		PermissionAPIHubDownloads, PermissionControlHandy, PermissionManageIPTV, PermissionViewRecycleBin:
+		return true
+	}
+	return false
+}
+
+func (p Permission) String() string {
+	return string(p)
+}
+
+func (p *Permission) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	// Convert from GraphQL uppercase to database lowercase
+	*p = Permission(strings.ToLower(str))
+	if !p.IsValid() {
+		return fmt.Errorf("%s is not a valid Permission", str)
+	}
+	return nil
+}
+
+func (p Permission) MarshalGQL(w io.Writer) {
+	// Convert from database lowercase to GraphQL uppercase
+	fmt.Fprint(w, strconv.Quote(strings.ToUpper(p.String())))
+}
+
+// PermissionSet is a set of granted permissions
+type PermissionSet map[Permission]bool
+
+// NewPermissionSet returns a set containing the given permissions
+func NewPermissionSet(permissions ...Permission) PermissionSet {
+	ret := make(PermissionSet, len(permissions))
+	for _, p := range permissions {
+		ret[p] = true
+	}
+	return ret
+}
+
+// Has returns true if all of the given permissions are in the set
+func (s PermissionSet) Has(permissions ...Permission) bool {
+	for _, p := range permissions {
+		if !s[p] {
+			return false
+		}
+	}
+	return true
+}
+
+// Role is an admin-defined set of permissions that can be assigned to users
+// in addition to their built-in role
+type Role struct {
+	ID          int          `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// RoleCreateInput contains all data needed to create a new role
+type RoleCreateInput struct {
+	Name        string       `json:"name"`
+	Description *string      `json:"description,omitempty"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// RoleUpdateInput contains data for updating an existing role
+type RoleUpdateInput struct {
+	ID          string       `json:"id"`
+	Name        *string      `json:"name,omitempty"`
+	Description *string      `json:"description,omitempty"`
+	Permissions []Permission `json:"permissions,omitempty"`
+}
+
+type permissionsContextKey struct{}
+
+// WithPermissions returns a copy of ctx in which requests are limited to the
+// given permissions.
+func WithPermissions(ctx context.Context, permissions PermissionSet) context.Context {
+	return context.WithValue(ctx, permissionsContextKey{}, permissions)
+}
+
+// PermissionsFromContext returns the permissions set on ctx. It returns false
+// if the request is not limited to a set of permissions, as is the case in
+// single-user mode.
+func PermissionsFromContext(ctx context.Context) (PermissionSet, bool) {
+	ret, ok := ctx.Value(permissionsContextKey{}).(PermissionSet)
+	return ret, ok
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// ScheduledTaskRun records one attempt at running a scheduled task
+type ScheduledTaskRun struct {
+	ID int `json:"id"`
+	// TaskID is the ID of the scheduled task in the configuration. The name
+	// and type of the task at the time are kept alongside it.
+	TaskID   string `json:"task_id"`
+	TaskName string `json:"task_name"`
+	TaskType string `json:"task_type"`
+	// Trigger is what started the run: the schedule, a user, or the chain of
+	// another task
+	Trigger string `json:"trigger"`
+	Attempt int    `json:"attempt"`
+	// JobID is nil if the run failed before starting a job
+	JobID     *int      `json:"job_id"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+}
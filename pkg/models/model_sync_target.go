@@ -0,0 +1,144 @@
+package models
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SyncTargetSource is the kind of entity whose scenes a sync target takes
+// offline.
+type SyncTargetSource string
+
+const (
+	SyncTargetSourceSavedFilter SyncTargetSource = "saved_filter"
+	SyncTargetSourcePlaylist    SyncTargetSource = "playlist"
+)
+
+func (e SyncTargetSource) IsValid() bool {
+	switch e {
+	case SyncTargetSourceSavedFilter, SyncTargetSourcePlaylist:
+		return true
+	}
+	return false
+}
+
+func (e SyncTargetSource) String() string {
+	return string(e)
+}
+
+func (e *SyncTargetSource) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	// Convert from GraphQL uppercase to database lowercase
+	*e = SyncTargetSource(strings.ToLower(str))
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SyncTargetSource", str)
+	}
+	return nil
+}
+
+func (e SyncTargetSource) MarshalGQL(w io.Writer) {
+	// Convert from database lowercase to GraphQL uppercase
+	fmt.Fprint(w, strconv.Quote(strings.ToUpper(e.String())))
+}
+
+// SyncTarget is a device that a subset of the library is synced to for
+// offline viewing. Each sync sends the device a bundle of the scenes of the
+// target's source that fit in its size budget, leaving out those the device
+// already has.
+type SyncTarget struct {
+	ID         int              `json:"id"`
+	Name       string           `json:"name"`
+	SourceType SyncTargetSource `json:"source_type"`
+	SourceID   int              `json:"source_id"`
+	// Profile is the name of the optimized version profile the scenes are
+	// transcoded with. Empty syncs the original files.
+	Profile string `json:"profile"`
+	// MaxSize is the size budget of the device in bytes. 0 for no limit.
+	MaxSize int64 `json:"max_size"`
+	// LastSyncedAt is when the last bundle was prepared. Nil if never.
+	LastSyncedAt *time.Time `json:"last_synced_at"`
+	// UserID is the user the target belongs to. Nil for targets created in
+	// single-user mode.
+	UserID    *int      `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewSyncTarget creates a new SyncTarget with default values.
+func NewSyncTarget() SyncTarget {
+	currentTime := time.Now()
+	return SyncTarget{
+		CreatedAt: currentTime,
+		UpdatedAt: currentTime,
+	}
+}
+
+// SyncTargetPartial represents part of a SyncTarget for partial updates.
+type SyncTargetPartial struct {
+	Name         OptionalString
+	SourceType   OptionalString
+	SourceID     OptionalInt
+	Profile      OptionalString
+	MaxSize      OptionalInt64
+	LastSyncedAt OptionalTime
+	UpdatedAt    OptionalTime
+}
+
+// NewSyncTargetPartial creates a new SyncTargetPartial with UpdatedAt set.
+func NewSyncTargetPartial() SyncTargetPartial {
+	return SyncTargetPartial{
+		UpdatedAt: NewOptionalTime(time.Now()),
+	}
+}
+
+// SyncTargetItem is a scene that a sync target's device has.
+type SyncTargetItem struct {
+	SceneID int `json:"scene_id"`
+	// Size is the size of the scene's media on the device
+	Size     int64     `json:"size"`
+	SyncedAt time.Time `json:"synced_at"`
+}
+
+type SyncTargetCreateInput struct {
+	Name       string           `json:"name"`
+	SourceType SyncTargetSource `json:"source_type"`
+	SourceID   string           `json:"source_id"`
+	Profile    *string          `json:"profile"`
+	MaxSize    *int64           `json:"max_size"`
+}
+
+type SyncTargetUpdateInput struct {
+	ID         string            `json:"id"`
+	Name       *string           `json:"name"`
+	SourceType *SyncTargetSource `json:"source_type"`
+	SourceID   *string           `json:"source_id"`
+	Profile    *string           `json:"profile"`
+	MaxSize    *int64            `json:"max_size"`
+}
+
+// SyncSceneActivityInput is what a device played of a scene while offline.
+type SyncSceneActivityInput struct {
+	SceneID    string   `json:"scene_id"`
+	ResumeTime *float64 `json:"resume_time"`
+	// PlayDuration is the time played since the last upload, in seconds
+	PlayDuration *float64 `json:"play_duration"`
+	// PlayHistory and OHistory are the times since the last upload
+	PlayHistory []*time.Time `json:"play_history"`
+	OHistory    []*time.Time `json:"o_history"`
+}
+
+type SyncTargetStateInput struct {
+	ID string `json:"id"`
+	// SceneIDs are the scenes on the device. When set, they replace the
+	// scenes the device is recorded as having, so that the next bundle sends
+	// any that were deleted from the device again.
+	SceneIDs []string                  `json:"scene_ids"`
+	Activity []*SyncSceneActivityInput `json:"activity"`
+}
@@ -66,6 +66,11 @@ type User struct {
 	UpdatedAt    time.Time  `json:"updated_at"`
 	LastLoginAt  *time.Time `json:"last_login_at,omitempty"`
 	IsActive     bool       `json:"is_active"`
+	// CustomRoleID is the admin-defined role granting the user additional
+	// permissions, if any
+	CustomRoleID *int `json:"custom_role_id,omitempty"`
+	// ExternalAuth is true if the user can log in through single sign-on
+	ExternalAuth bool `json:"external_auth"`
 }
 
 // NewUser creates a new User with default values
@@ -88,6 +93,8 @@ type UserPartial struct {
 	UpdatedAt    OptionalTime
 	LastLoginAt  OptionalTime
 	IsActive     OptionalBool
+	CustomRoleID OptionalInt
+	ExternalAuth OptionalBool
 }
 
 // NewUserPartial creates a new UserPartial with the current time set for UpdatedAt
@@ -100,9 +107,10 @@ func NewUserPartial() UserPartial {
 
 // UserCreateInput contains all data needed to create a new user
 type UserCreateInput struct {
-	Username string   `json:"username"`
-	Password string   `json:"password"`
-	Role     UserRole `json:"role"`
+	Username     string   `json:"username"`
+	Password     string   `json:"password"`
+	Role         UserRole `json:"role"`
+	CustomRoleID *string  `json:"custom_role_id,omitempty"`
 }
 
 // UserUpdateInput contains data for updating an existing user
@@ -112,6 +120,10 @@ type UserUpdateInput struct {
 	Password *string   `json:"password,omitempty"`
 	Role     *UserRole `json:"role,omitempty"`
 	IsActive *bool     `json:"is_active,omitempty"`
+	// ContentRestriction replaces the user's content restriction when set
+	ContentRestriction *ContentRestrictionInput `json:"content_restriction,omitempty"`
+	// CustomRoleID sets the user's custom role. An empty value removes it
+	CustomRoleID *string `json:"custom_role_id,omitempty"`
 }
 
 // Permission helpers - these methods determine what a user can do
@@ -121,29 +133,22 @@ func (u *User) IsAdmin() bool {
 	return u.Role == UserRoleAdmin
 }
 
-// CanModify returns true if the user can modify content (create, update)
-func (u *User) CanModify() bool {
-	return u.Role == UserRoleAdmin
-}
-
-// CanDelete returns true if the user can delete content
-func (u *User) CanDelete() bool {
-	return u.Role == UserRoleAdmin
-}
-
-// CanManageUsers returns true if the user can manage other users
-func (u *User) CanManageUsers() bool {
-	return u.Role == UserRoleAdmin
-}
+// Permissions returns the ViewerPermissions along with those granted to the
+// user by their custom role, which may be nil. Admins are granted every
+// permission.
+func (u *User) Permissions(role *Role) PermissionSet {
+	if u.IsAdmin() {
+		return NewPermissionSet(AllPermission...)
+	}
 
-// CanRunTasks returns true if the user can run background tasks
-func (u *User) CanRunTasks() bool {
-	return u.Role == UserRoleAdmin
-}
+	ret := NewPermissionSet(ViewerPermissions...)
+	if role != nil {
+		for _, p := range role.Permissions {
+			ret[p] = true
+		}
+	}
 
-// CanModifySettings returns true if the user can modify system settings
-func (u *User) CanModifySettings() bool {
-	return u.Role == UserRoleAdmin
+	return ret
 }
 
 // UserSession represents an active login session
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// VisualEmbedding holds the visual embedding vectors of a scene produced by a
+// single model.
+type VisualEmbedding struct {
+	SceneID int
+	// Model identifies the backend and model that produced the vectors
+	Model string
+	// Frames holds one vector per frame sampled from the scene
+	Frames [][]float32
+	// Mean is the normalised mean of Frames, used to search for similar
+	// scenes
+	Mean      []float32
+	UpdatedAt time.Time
+}
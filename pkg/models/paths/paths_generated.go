@@ -18,6 +18,7 @@ type generatedPaths struct {
 	Vtt                string
 	Markers            string
 	Transcodes         string
+	OptimizedVersions  string
 	Downloads          string
 	Tmp                string
 	InteractiveHeatmap string
@@ -30,6 +31,7 @@ func newGeneratedPaths(path string) *generatedPaths {
 	gp.Vtt = filepath.Join(path, "vtt")
 	gp.Markers = filepath.Join(path, "markers")
 	gp.Transcodes = filepath.Join(path, "transcodes")
+	gp.OptimizedVersions = filepath.Join(path, "optimized")
 	gp.Downloads = filepath.Join(path, "download_stage")
 	gp.Tmp = filepath.Join(path, "tmp")
 	gp.InteractiveHeatmap = filepath.Join(path, "interactive_heatmaps")
@@ -25,6 +25,12 @@ func (sp *scenePaths) GetTranscodePath(checksum string) string {
 	return filepath.Join(sp.Transcodes, checksum+".mp4")
 }
 
+// GetOptimizedVersionPath returns the path of the optimized version file with
+// the given basename
+func (sp *scenePaths) GetOptimizedVersionPath(basename string) string {
+	return filepath.Join(sp.OptimizedVersions, basename)
+}
+
 func (sp *scenePaths) GetStreamPath(scenePath string, checksum string) string {
 	transcodePath := sp.GetTranscodePath(checksum)
 	transcodeExists, _ := fsutil.FileExists(transcodePath)
@@ -50,6 +56,18 @@ func (sp *scenePaths) GetSpriteVttFilePath(checksum string) string {
 	return filepath.Join(sp.Vtt, checksum+"_thumbs.vtt")
 }
 
+// GetTrickPlayBIFPath returns the path of the Roku-style BIF archive of the
+// scene's sprite frames.
+func (sp *scenePaths) GetTrickPlayBIFPath(checksum string) string {
+	return filepath.Join(sp.Vtt, checksum+"_trickplay.bif")
+}
+
+// GetTrickPlayIFramePath returns the path of the fragmented MP4 of the scene's
+// sprite frames, which HLS I-frame playlists refer to.
+func (sp *scenePaths) GetTrickPlayIFramePath(checksum string) string {
+	return filepath.Join(sp.Vtt, checksum+"_trickplay.mp4")
+}
+
 func (sp *scenePaths) GetInteractiveHeatmapPath(checksum string) string {
 	return filepath.Join(sp.InteractiveHeatmap, checksum+".png")
 }
@@ -30,11 +30,20 @@ type Repository struct {
 	PotentialScene          PotentialSceneRepository
 	ContentProfile          ContentProfileReaderWriter
 	User                    UserReaderWriter
+	Role                    RoleReaderWriter
+	APIKey                  APIKeyReaderWriter
+	AuditEntry              AuditEntryReaderWriter
 	Playlist                PlaylistReaderWriter
 	RecycleBin              RecycleBinReaderWriter
 	DismissedRecommendation DismissedRecommendationReaderWriter
 	LikedRecommendation     LikedRecommendationReaderWriter
-	VisualSignature         VisualSignatureReaderWriter
+	VisualEmbedding         VisualEmbeddingReaderWriter
+	CoWatch                 CoWatchReaderWriter
+	IPTVChannel             IPTVChannelReaderWriter
+	ScheduledTaskRun        ScheduledTaskRunReaderWriter
+	QueuedJob               QueuedJobReaderWriter
+	OptimizedVersion        OptimizedVersionReaderWriter
+	SyncTarget              SyncTargetReaderWriter
 	Analytics               AnalyticsReader
 }
 
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// APIKeyGetter provides methods to get API keys by ID
+type APIKeyGetter interface {
+	Find(ctx context.Context, id int) (*APIKey, error)
+}
+
+// APIKeyFinder provides methods to find API keys
+type APIKeyFinder interface {
+	APIKeyGetter
+	FindByHash(ctx context.Context, keyHash string) (*APIKey, error)
+	FindByUserID(ctx context.Context, userID int) ([]*APIKey, error)
+}
+
+// APIKeyQueryer provides methods to query API keys
+type APIKeyQueryer interface {
+	FindAll(ctx context.Context) ([]*APIKey, error)
+}
+
+// APIKeyCreator provides methods to create API keys
+type APIKeyCreator interface {
+	Create(ctx context.Context, newKey *APIKey) error
+}
+
+// APIKeyUpdater provides methods to update API keys
+type APIKeyUpdater interface {
+	UpdateLastUsed(ctx context.Context, id int, t time.Time) error
+}
+
+// APIKeyDestroyer provides methods to destroy API keys
+type APIKeyDestroyer interface {
+	Destroy(ctx context.Context, id int) error
+}
+
+// APIKeyReader provides all read methods for API keys
+type APIKeyReader interface {
+	APIKeyFinder
+	APIKeyQueryer
+}
+
+// APIKeyWriter provides all write methods for API keys
+type APIKeyWriter interface {
+	APIKeyCreator
+	APIKeyUpdater
+	APIKeyDestroyer
+}
+
+// APIKeyReaderWriter provides all methods for API keys
+type APIKeyReaderWriter interface {
+	APIKeyReader
+	APIKeyWriter
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// AuditEntryQueryer provides methods to query audit log entries
+type AuditEntryQueryer interface {
+	Query(ctx context.Context, auditFilter *AuditEntryFilterType, findFilter *FindFilterType) ([]*AuditEntry, int, error)
+}
+
+// AuditEntryCreator provides methods to create audit log entries
+type AuditEntryCreator interface {
+	Create(ctx context.Context, newEntry *AuditEntry) error
+}
+
+// AuditEntryDestroyer provides methods to destroy audit log entries
+type AuditEntryDestroyer interface {
+	// DestroyBefore deletes the entries created before t, returning the
+	// number deleted.
+	DestroyBefore(ctx context.Context, t time.Time) (int64, error)
+}
+
+// AuditEntryReader provides all read methods for audit log entries
+type AuditEntryReader interface {
+	AuditEntryQueryer
+}
+
+// AuditEntryWriter provides all write methods for audit log entries
+type AuditEntryWriter interface {
+	AuditEntryCreator
+	AuditEntryDestroyer
+}
+
+// AuditEntryReaderWriter provides all methods for audit log entries
+type AuditEntryReaderWriter interface {
+	AuditEntryReader
+	AuditEntryWriter
+}
@@ -0,0 +1,50 @@
+package models
+
+import "context"
+
+// CoWatchReader provides read access to the co-watch model and the history it
+// is built from.
+type CoWatchReader interface {
+	// Related returns the pairs of each of the given scenes with the scenes
+	// watched alongside it. SceneID is set to the given scene.
+	Related(ctx context.Context, sceneIDs []int) ([]*CoWatchPair, error)
+	// WatchedSceneIDs returns the scenes played or O'd by the current user,
+	// or by anyone if the context is not scoped to a user.
+	WatchedSceneIDs(ctx context.Context) ([]int, error)
+
+	// GetCursor returns the position up to which the model has been built.
+	GetCursor(ctx context.Context) (CoWatchCursor, error)
+	// HistoryEnd returns the position of the last play and O history rows.
+	HistoryEnd(ctx context.Context) (CoWatchCursor, error)
+	// HistoryUsers returns the users with history rows between after and
+	// upTo. A nil entry stands for history recorded without a user.
+	HistoryUsers(ctx context.Context, after CoWatchCursor, upTo CoWatchCursor) ([]*int, error)
+	// UserHistory returns the history of a user up to upTo, ordered by date.
+	// Events after the after cursor are marked as new.
+	UserHistory(ctx context.Context, userID *int, after CoWatchCursor, upTo CoWatchCursor) ([]*WatchEvent, error)
+	// RemovedHistory returns the history removed since it was added to the
+	// model, as new events ordered by date.
+	RemovedHistory(ctx context.Context) ([]*WatchEvent, error)
+}
+
+// CoWatchWriter provides write access to the co-watch model.
+type CoWatchWriter interface {
+	// AddPairs adds the counts of the given pairs to the model.
+	AddPairs(ctx context.Context, pairs []*CoWatchPair) error
+	// RemovePairs subtracts the counts of the given pairs from the model.
+	RemovePairs(ctx context.Context, pairs []*CoWatchPair) error
+	// ClearRemovedHistory forgets the removed history, once it has been
+	// taken out of the model.
+	ClearRemovedHistory(ctx context.Context) error
+	// SetCursor records the position up to which the model has been built.
+	SetCursor(ctx context.Context, cursor CoWatchCursor) error
+	// Reset removes every pair from the model and the removed history, and
+	// rewinds the cursor.
+	Reset(ctx context.Context) error
+}
+
+// CoWatchReaderWriter provides all methods for the co-watch model.
+type CoWatchReaderWriter interface {
+	CoWatchReader
+	CoWatchWriter
+}
@@ -9,6 +9,11 @@ type ContentProfileReaderWriter interface {
 	Find(ctx context.Context, id int) (*ContentProfile, error)
 	FindAll(ctx context.Context) ([]*ContentProfile, error)
 
+	// FindUserProfile and GetOrCreateUserProfile operate on the profile of the
+	// user the context is scoped to.
+	FindUserProfile(ctx context.Context) (*ContentProfile, error)
+	GetOrCreateUserProfile(ctx context.Context) (*ContentProfile, error)
+
 	// Weight management
 	SaveWeights(ctx context.Context, profile *ContentProfile) error
 	LoadWeights(ctx context.Context, profile *ContentProfile) error
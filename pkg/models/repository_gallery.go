@@ -71,6 +71,7 @@ type GalleryReader interface {
 	PerformerIDLoader
 	TagIDLoader
 	FileLoader
+	UserDataLoader
 
 	All(ctx context.Context) ([]*Gallery, error)
 
@@ -80,6 +80,7 @@ type ImageReader interface {
 	PerformerIDLoader
 	TagIDLoader
 	FileLoader
+	UserDataLoader
 
 	GalleryCoverFinder
 
@@ -0,0 +1,53 @@
+package models
+
+import "context"
+
+// IPTVChannelGetter provides methods to get IPTV channels by ID
+type IPTVChannelGetter interface {
+	Find(ctx context.Context, id int) (*IPTVChannel, error)
+}
+
+// IPTVChannelFinder provides methods to find IPTV channels
+type IPTVChannelFinder interface {
+	IPTVChannelGetter
+	FindAll(ctx context.Context) ([]*IPTVChannel, error)
+	FindByNumber(ctx context.Context, number int) (*IPTVChannel, error)
+}
+
+// IPTVChannelCreator provides methods to create IPTV channels
+type IPTVChannelCreator interface {
+	Create(ctx context.Context, newChannel *IPTVChannel) error
+}
+
+// IPTVChannelUpdater provides methods to update IPTV channels
+type IPTVChannelUpdater interface {
+	UpdatePartial(ctx context.Context, id int, partial IPTVChannelPartial) (*IPTVChannel, error)
+	UpdateLogo(ctx context.Context, id int, image []byte) error
+	UpdateBlocks(ctx context.Context, id int, blocks []*IPTVChannelBlock) error
+}
+
+// IPTVChannelDestroyer provides methods to destroy IPTV channels
+type IPTVChannelDestroyer interface {
+	Destroy(ctx context.Context, id int) error
+}
+
+// IPTVChannelReader provides all read methods for IPTV channels
+type IPTVChannelReader interface {
+	IPTVChannelFinder
+	GetLogo(ctx context.Context, id int) ([]byte, error)
+	HasLogo(ctx context.Context, id int) (bool, error)
+	GetBlocks(ctx context.Context, id int) ([]*IPTVChannelBlock, error)
+}
+
+// IPTVChannelWriter provides all write methods for IPTV channels
+type IPTVChannelWriter interface {
+	IPTVChannelCreator
+	IPTVChannelUpdater
+	IPTVChannelDestroyer
+}
+
+// IPTVChannelReaderWriter provides all methods for IPTV channels
+type IPTVChannelReaderWriter interface {
+	IPTVChannelReader
+	IPTVChannelWriter
+}
@@ -0,0 +1,27 @@
+package models
+
+import "context"
+
+// OptimizedVersionReader provides methods to read optimized versions
+type OptimizedVersionReader interface {
+	Find(ctx context.Context, id int) (*OptimizedVersion, error)
+	// FindBySceneID returns the versions of a scene in order of profile
+	FindBySceneID(ctx context.Context, sceneID int) ([]*OptimizedVersion, error)
+	All(ctx context.Context) ([]*OptimizedVersion, error)
+	// Storage returns the storage used by each profile's versions, in order
+	// of profile
+	Storage(ctx context.Context) ([]*OptimizedVersionStorage, error)
+}
+
+// OptimizedVersionWriter provides methods to write optimized versions
+type OptimizedVersionWriter interface {
+	// Create adds the version, replacing any of the same scene and profile
+	Create(ctx context.Context, version *OptimizedVersion) error
+	Destroy(ctx context.Context, id int) error
+}
+
+// OptimizedVersionReaderWriter provides all methods for optimized versions
+type OptimizedVersionReaderWriter interface {
+	OptimizedVersionReader
+	OptimizedVersionWriter
+}
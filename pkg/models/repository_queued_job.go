@@ -0,0 +1,24 @@
+package models
+
+import "context"
+
+// QueuedJobReader provides methods to read queued jobs
+type QueuedJobReader interface {
+	// All returns every queued job in the order they were added
+	All(ctx context.Context) ([]*QueuedJob, error)
+}
+
+// QueuedJobWriter provides methods to write queued jobs
+type QueuedJobWriter interface {
+	// Save creates the job, replacing any with the same ID
+	Save(ctx context.Context, job *QueuedJob) error
+	SetStarted(ctx context.Context, id int) error
+	SetCheckpoint(ctx context.Context, id int, checkpoint []byte) error
+	Destroy(ctx context.Context, id int) error
+}
+
+// QueuedJobReaderWriter provides all methods for queued jobs
+type QueuedJobReaderWriter interface {
+	QueuedJobReader
+	QueuedJobWriter
+}
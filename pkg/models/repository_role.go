@@ -0,0 +1,53 @@
+package models
+
+import "context"
+
+// RoleGetter provides methods to get roles by ID
+type RoleGetter interface {
+	Find(ctx context.Context, id int) (*Role, error)
+}
+
+// RoleFinder provides methods to find roles
+type RoleFinder interface {
+	RoleGetter
+	FindByName(ctx context.Context, name string) (*Role, error)
+}
+
+// RoleQueryer provides methods to query roles
+type RoleQueryer interface {
+	FindAll(ctx context.Context) ([]*Role, error)
+}
+
+// RoleCreator provides methods to create roles
+type RoleCreator interface {
+	Create(ctx context.Context, newRole *Role) error
+}
+
+// RoleUpdater provides methods to update roles
+type RoleUpdater interface {
+	Update(ctx context.Context, updatedRole *Role) error
+}
+
+// RoleDestroyer provides methods to destroy roles
+type RoleDestroyer interface {
+	Destroy(ctx context.Context, id int) error
+}
+
+// RoleReader provides all read methods for roles
+type RoleReader interface {
+	RoleFinder
+	RoleQueryer
+}
+
+// RoleWriter provides all write methods for roles
+type RoleWriter interface {
+	RoleCreator
+	RoleUpdater
+	RoleDestroyer
+}
+
+// RoleReaderWriter provides all methods for roles
+type RoleReaderWriter interface {
+	RoleReader
+	RoleWriter
+}
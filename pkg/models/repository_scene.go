@@ -120,6 +120,7 @@ type SceneReader interface {
 	SceneGroupLoader
 	StashIDLoader
 	VideoFileLoader
+	UserDataLoader
 
 	GetManyTagIDs(ctx context.Context, ids []int) ([][]int, error)
 	GetManyPerformerIDs(ctx context.Context, ids []int) ([][]int, error)
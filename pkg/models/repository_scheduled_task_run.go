@@ -0,0 +1,27 @@
+package models
+
+import "context"
+
+// ScheduledTaskRunReader provides methods to read scheduled task runs
+type ScheduledTaskRunReader interface {
+	// FindRecent returns the most recent runs, newest first, of the task with
+	// the given ID or of every task if it is empty. A limit of 0 or less
+	// returns every run.
+	FindRecent(ctx context.Context, taskID string, limit int) ([]*ScheduledTaskRun, error)
+}
+
+// ScheduledTaskRunWriter provides methods to write scheduled task runs
+type ScheduledTaskRunWriter interface {
+	Create(ctx context.Context, newRun *ScheduledTaskRun) error
+	// DestroyByTask deletes every run of a task
+	DestroyByTask(ctx context.Context, taskID string) error
+	// Prune deletes all but the most recent keep runs of a task, returning
+	// the number deleted
+	Prune(ctx context.Context, taskID string, keep int) (int64, error)
+}
+
+// ScheduledTaskRunReaderWriter provides all methods for scheduled task runs
+type ScheduledTaskRunReaderWriter interface {
+	ScheduledTaskRunReader
+	ScheduledTaskRunWriter
+}
@@ -0,0 +1,52 @@
+package models
+
+import "context"
+
+// SyncTargetGetter provides methods to get sync targets by ID
+type SyncTargetGetter interface {
+	Find(ctx context.Context, id int) (*SyncTarget, error)
+}
+
+// SyncTargetFinder provides methods to find sync targets
+type SyncTargetFinder interface {
+	SyncTargetGetter
+	FindAll(ctx context.Context) ([]*SyncTarget, error)
+	FindByUserID(ctx context.Context, userID int) ([]*SyncTarget, error)
+}
+
+// SyncTargetCreator provides methods to create sync targets
+type SyncTargetCreator interface {
+	Create(ctx context.Context, newTarget *SyncTarget) error
+}
+
+// SyncTargetUpdater provides methods to update sync targets
+type SyncTargetUpdater interface {
+	UpdatePartial(ctx context.Context, id int, partial SyncTargetPartial) (*SyncTarget, error)
+	// UpdateItems replaces the scenes the target's device has
+	UpdateItems(ctx context.Context, id int, items []*SyncTargetItem) error
+}
+
+// SyncTargetDestroyer provides methods to destroy sync targets
+type SyncTargetDestroyer interface {
+	Destroy(ctx context.Context, id int) error
+}
+
+// SyncTargetReader provides all read methods for sync targets
+type SyncTargetReader interface {
+	SyncTargetFinder
+	// GetItems returns the scenes the target's device has
+	GetItems(ctx context.Context, id int) ([]*SyncTargetItem, error)
+}
+
+// SyncTargetWriter provides all write methods for sync targets
+type SyncTargetWriter interface {
+	SyncTargetCreator
+	SyncTargetUpdater
+	SyncTargetDestroyer
+}
+
+// SyncTargetReaderWriter provides all methods for sync targets
+type SyncTargetReaderWriter interface {
+	SyncTargetReader
+	SyncTargetWriter
+}
@@ -15,6 +15,12 @@ type UserFinder interface {
 	FindByAPIKey(ctx context.Context, apiKey string) (*User, error)
 }
 
+// UserContentRestrictionGetter provides methods to get the content
+// restriction of a user
+type UserContentRestrictionGetter interface {
+	GetContentRestriction(ctx context.Context, userID int) (*ContentRestriction, error)
+}
+
 // UserQueryer provides methods to query users
 type UserQueryer interface {
 	FindAll(ctx context.Context) ([]*User, error)
@@ -37,6 +43,18 @@ type UserUpdater interface {
 	UpdateLastLogin(ctx context.Context, id int) error
 }
 
+// UserDataClaimer provides methods to attribute library-wide play history,
+// resume points, O-counts and ratings to a user
+type UserDataClaimer interface {
+	ClaimUnownedData(ctx context.Context, userID int) error
+}
+
+// UserContentRestrictionUpdater provides methods to set the content
+// restriction of a user
+type UserContentRestrictionUpdater interface {
+	UpdateContentRestriction(ctx context.Context, userID int, restriction ContentRestriction) error
+}
+
 // UserDestroyer provides methods to destroy users
 type UserDestroyer interface {
 	Destroy(ctx context.Context, id int) error
@@ -47,6 +65,7 @@ type UserReader interface {
 	UserFinder
 	UserQueryer
 	UserCounter
+	UserContentRestrictionGetter
 }
 
 // UserWriter provides all write methods for users
@@ -54,6 +73,8 @@ type UserWriter interface {
 	UserCreator
 	UserUpdater
 	UserDestroyer
+	UserDataClaimer
+	UserContentRestrictionUpdater
 }
 
 // UserReaderWriter provides all methods for users
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// VisualEmbeddingReader provides methods to read scene visual embeddings.
+type VisualEmbeddingReader interface {
+	// Find returns the embedding of a scene produced by the given model, or
+	// nil if none exists.
+	Find(ctx context.Context, sceneID int, model string) (*VisualEmbedding, error)
+	// FindMany returns the embeddings of the given scenes produced by the
+	// given model. Scenes without one are omitted.
+	FindMany(ctx context.Context, sceneIDs []int, model string) ([]*VisualEmbedding, error)
+	// Means returns the mean vectors of the embeddings of the given model
+	// updated at or after since, keyed by scene ID.
+	Means(ctx context.Context, model string, since time.Time) (map[int][]float32, error)
+	// Count returns the number of scenes with an embedding of the given
+	// model.
+	Count(ctx context.Context, model string) (int, error)
+	// SceneIDs returns the scenes with an embedding of the given model.
+	SceneIDs(ctx context.Context, model string) ([]int, error)
+}
+
+// VisualEmbeddingWriter provides methods to modify scene visual embeddings.
+type VisualEmbeddingWriter interface {
+	// Set inserts or replaces the embedding of a scene for its model.
+	Set(ctx context.Context, e *VisualEmbedding) error
+	// Delete removes the embeddings of a scene for every model.
+	Delete(ctx context.Context, sceneID int) error
+}
+
+// VisualEmbeddingReaderWriter provides all methods to read and modify scene
+// visual embeddings.
+type VisualEmbeddingReaderWriter interface {
+	VisualEmbeddingReader
+	VisualEmbeddingWriter
+}
@@ -0,0 +1,39 @@
+package models
+
+import "context"
+
+// UserData holds one user's own state for a scene, image or gallery.
+// Fields that do not apply to the entity type are left at their zero value:
+// scenes use Rating, ResumeTime and PlayDuration, images use Rating and
+// OCounter, and galleries use Rating only.
+type UserData struct {
+	ID           int     `json:"id"`
+	UserID       int     `json:"user_id"`
+	Rating       *int    `json:"rating"`
+	ResumeTime   float64 `json:"resume_time"`
+	PlayDuration float64 `json:"play_duration"`
+	OCounter     int     `json:"o_counter"`
+}
+
+// UserDataLoader loads the current user's data for the given entity IDs.
+// The returned slice matches the order of ids; an entry is nil when the user
+// has no data for that entity or when the context is not scoped to a user.
+type UserDataLoader interface {
+	GetManyUserData(ctx context.Context, ids []int) ([]*UserData, error)
+}
+
+type userIDContextKey struct{}
+
+// WithUserID returns a copy of ctx in which play history, resume points,
+// O history and ratings are read and written for the given user.
+func WithUserID(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, userIDContextKey{}, userID)
+}
+
+// UserIDFromContext returns the user set by WithUserID. It returns false when
+// the context is not scoped to a user, in which case per-user data falls back
+// to the library-wide values.
+func UserIDFromContext(ctx context.Context) (int, bool) {
+	userID, ok := ctx.Value(userIDContextKey{}).(int)
+	return userID, ok
+}
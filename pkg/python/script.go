@@ -0,0 +1,68 @@
+package python
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+// ServiceScript is the client script of a Python service, such as stashtag.
+type ServiceScript struct {
+	// Name is the name of the service in log messages
+	Name string
+	// EnvVar is the environment variable that may set the path of the
+	// script, as in Docker images
+	EnvVar string
+	// Dir is the directory of the script, under python-services next to
+	// the executable and under /usr/lib/stash
+	Dir string
+	// SourceDir is the directory of the script in the source tree, relative
+	// to its root
+	SourceDir string
+}
+
+// Find returns the path of client.py of the service. The path set by EnvVar is
+// used if it exists, then the first of these that exists: the python-services
+// folder next to the executable, the source tree from the working directory
+// or up to two levels above it, and the Docker location. The path in the
+// source tree is returned if none exist.
+func (s ServiceScript) Find() string {
+	if envPath := os.Getenv(s.EnvVar); envPath != "" {
+		if _, err := os.Stat(envPath); err == nil {
+			logger.Debugf("%s: Using script from env var: %s", s.Name, envPath)
+			return envPath
+		}
+	}
+
+	const script = "client.py"
+	sourcePath := filepath.Join(append(strings.Split(s.SourceDir, "/"), script)...)
+
+	wd, _ := os.Getwd()
+	// Get executable directory for native builds
+	exePath, _ := os.Executable()
+	exeDir := filepath.Dir(exePath)
+
+	searchPaths := []string{
+		// Native build: python-services folder next to executable
+		filepath.Join(exeDir, "python-services", s.Dir, script),
+		// Development paths
+		filepath.Join(wd, sourcePath),
+		filepath.Join(wd, "..", sourcePath),
+		filepath.Join(wd, "..", "..", sourcePath),
+		// Docker path
+		filepath.Join("/usr/lib/stash", s.Dir, script),
+	}
+
+	for _, p := range searchPaths {
+		if _, err := os.Stat(p); err == nil {
+			logger.Debugf("%s: Found %s at %s", s.Name, script, p)
+			return p
+		}
+	}
+
+	ret := filepath.Join(wd, sourcePath)
+	logger.Warnf("%s: %s not found in search paths, defaulting to %s", s.Name, script, ret)
+	return ret
+}
@@ -0,0 +1,193 @@
+package recommendation
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const (
+	// coWatchSessionGap is the longest time between two plays by the same
+	// user for them to count as part of the same session.
+	coWatchSessionGap = 3 * time.Hour
+
+	// coWatchUserWindow is the longest time between plays of two scenes by
+	// the same user for the user to count towards the pair.
+	coWatchUserWindow = 7 * 24 * time.Hour
+
+	// coWatchSessionWeight is the strength of a shared session relative to
+	// a shared user.
+	coWatchSessionWeight = 0.5
+
+	// coWatchCandidateLimit caps the number of co-watched scenes added to the
+	// candidates sampled by RecommendScenes.
+	coWatchCandidateLimit = 100
+
+	coWatchReason = "viewers also watched"
+)
+
+// CoWatchPairs returns the counts to add to the co-watch model for the new
+// events in the history of a single user. events must be ordered by date.
+// Each pair has the lower scene ID first.
+//
+// Each pair of scenes is counted once per user who has played both within
+// coWatchUserWindow of each other, so that a user adds pairs in proportion to
+// how much they watch at a time rather than to the size of their history.
+// When countUsers is false, as for history recorded without a user, only
+// sessions are counted. Each pair of plays within coWatchSessionGap of each
+// other counts as a shared session.
+//
+// The counts that removed history had added to the model are found the same
+// way, with the removed events as the new ones.
+func CoWatchPairs(events []*models.WatchEvent, countUsers bool) []*models.CoWatchPair {
+	counts := make(map[coWatchKey]*models.CoWatchPair)
+	add := func(k coWatchKey, users, sessions int) {
+		p := counts[k]
+		if p == nil {
+			p = &models.CoWatchPair{SceneID: k.a, OtherSceneID: k.b}
+			counts[k] = p
+		}
+		p.Users += users
+		p.Sessions += sessions
+	}
+
+	if countUsers {
+		for k := range coWatchUserPairs(events) {
+			add(k, 1, 0)
+		}
+	}
+
+	for i, e := range events {
+		if !e.New {
+			continue
+		}
+
+		// pair this event with every earlier event, and with the later
+		// events that are not new, so that two new events are paired once
+		for j := i - 1; j >= 0 && e.Date.Sub(events[j].Date) <= coWatchSessionGap; j-- {
+			if k, ok := newCoWatchKey(e.SceneID, events[j].SceneID); ok {
+				add(k, 0, 1)
+			}
+		}
+		for j := i + 1; j < len(events) && events[j].Date.Sub(e.Date) <= coWatchSessionGap; j++ {
+			if events[j].New {
+				continue
+			}
+			if k, ok := newCoWatchKey(e.SceneID, events[j].SceneID); ok {
+				add(k, 0, 1)
+			}
+		}
+	}
+
+	ret := make([]*models.CoWatchPair, 0, len(counts))
+	for _, p := range counts {
+		ret = append(ret, p)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].SceneID != ret[j].SceneID {
+			return ret[i].SceneID < ret[j].SceneID
+		}
+		return ret[i].OtherSceneID < ret[j].OtherSceneID
+	})
+
+	return ret
+}
+
+// coWatchKey is a pair of scenes, with the lower ID first.
+type coWatchKey struct {
+	a, b int
+}
+
+func newCoWatchKey(a, b int) (coWatchKey, bool) {
+	if a == b {
+		return coWatchKey{}, false
+	}
+	if a > b {
+		a, b = b, a
+	}
+	return coWatchKey{a, b}, true
+}
+
+// coWatchUserPairs returns the pairs played within coWatchUserWindow of each
+// other by the new events, that the events that are not new do not already
+// pair. events must be ordered by date.
+func coWatchUserPairs(events []*models.WatchEvent) map[coWatchKey]bool {
+	// pairs made by the new events
+	ret := make(map[coWatchKey]bool)
+	newScenes := make(map[int]bool)
+	for i, e := range events {
+		if !e.New {
+			continue
+		}
+		newScenes[e.SceneID] = true
+
+		for j := i - 1; j >= 0 && e.Date.Sub(events[j].Date) <= coWatchUserWindow; j-- {
+			if k, ok := newCoWatchKey(e.SceneID, events[j].SceneID); ok {
+				ret[k] = true
+			}
+		}
+		for j := i + 1; j < len(events) && events[j].Date.Sub(e.Date) <= coWatchUserWindow; j++ {
+			if k, ok := newCoWatchKey(e.SceneID, events[j].SceneID); ok {
+				ret[k] = true
+			}
+		}
+	}
+
+	// drop the pairs already made by the other events. Only pairs with the
+	// scene of a new event can be dropped.
+	for i, e := range events {
+		if e.New || !newScenes[e.SceneID] {
+			continue
+		}
+
+		for j := i - 1; j >= 0 && e.Date.Sub(events[j].Date) <= coWatchUserWindow; j-- {
+			if events[j].New {
+				continue
+			}
+			if k, ok := newCoWatchKey(e.SceneID, events[j].SceneID); ok {
+				delete(ret, k)
+			}
+		}
+		for j := i + 1; j < len(events) && events[j].Date.Sub(e.Date) <= coWatchUserWindow; j++ {
+			if events[j].New {
+				continue
+			}
+			if k, ok := newCoWatchKey(e.SceneID, events[j].SceneID); ok {
+				delete(ret, k)
+			}
+		}
+	}
+
+	return ret
+}
+
+// CoWatchScores returns how strongly each scene is associated with the given
+// scenes by being watched alongside them, normalized to 0-1. The given scenes
+// are not included.
+func CoWatchScores(ctx context.Context, r models.CoWatchReader, sceneIDs []int) (map[int]float64, error) {
+	if len(sceneIDs) == 0 {
+		return nil, nil
+	}
+
+	pairs, err := r.Related(ctx, sceneIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	exclude := make(map[int]bool, len(sceneIDs))
+	for _, id := range sceneIDs {
+		exclude[id] = true
+	}
+
+	scores := make(map[int]float64)
+	for _, p := range pairs {
+		if exclude[p.OtherSceneID] {
+			continue
+		}
+		scores[p.OtherSceneID] += float64(p.Users) + coWatchSessionWeight*float64(p.Sessions)
+	}
+
+	return normalizeWeights(scores), nil
+}
@@ -0,0 +1,245 @@
+package recommendation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// mockCoWatchReader returns fixed pairs from Related
+type mockCoWatchReader struct {
+	models.CoWatchReader
+	pairs []*models.CoWatchPair
+}
+
+func (m *mockCoWatchReader) Related(ctx context.Context, sceneIDs []int) ([]*models.CoWatchPair, error) {
+	var ret []*models.CoWatchPair
+	for _, p := range m.pairs {
+		for _, id := range sceneIDs {
+			if p.SceneID == id {
+				ret = append(ret, p)
+			}
+		}
+	}
+	return ret, nil
+}
+
+var coWatchBase = time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+
+func watchEvent(sceneID int, hours float64, isNew bool) *models.WatchEvent {
+	return &models.WatchEvent{
+		SceneID: sceneID,
+		Date:    coWatchBase.Add(time.Duration(hours * float64(time.Hour))),
+		New:     isNew,
+	}
+}
+
+// pairCounts returns the pairs keyed by their scene IDs
+func pairCounts(pairs []*models.CoWatchPair) map[[2]int][2]int {
+	ret := make(map[[2]int][2]int)
+	for _, p := range pairs {
+		k := [2]int{p.SceneID, p.OtherSceneID}
+		c := ret[k]
+		ret[k] = [2]int{c[0] + p.Users, c[1] + p.Sessions}
+	}
+	return ret
+}
+
+func TestCoWatchPairs(t *testing.T) {
+	events := []*models.WatchEvent{
+		watchEvent(1, 0, true),
+		watchEvent(2, 1, true),
+		watchEvent(3, 24, true),
+		watchEvent(1, 25, true),
+	}
+
+	got := pairCounts(CoWatchPairs(events, true))
+
+	assert.Equal(t, map[[2]int][2]int{
+		// 1 and 2 share a user and the first session
+		{1, 2}: {1, 1},
+		// 1 and 3 share a user and the second session
+		{1, 3}: {1, 1},
+		{2, 3}: {1, 0},
+	}, got)
+}
+
+func TestCoWatchPairs_WithoutUser(t *testing.T) {
+	events := []*models.WatchEvent{
+		watchEvent(1, 0, true),
+		watchEvent(2, 1, true),
+		watchEvent(3, 24, true),
+	}
+
+	got := pairCounts(CoWatchPairs(events, false))
+
+	assert.Equal(t, map[[2]int][2]int{
+		{1, 2}: {0, 1},
+	}, got)
+}
+
+func TestCoWatchPairs_Incremental(t *testing.T) {
+	// plays of a user and the build that first sees them. Scene 5 is played
+	// before the last play of the first build, as when a play is added
+	// manually.
+	plays := []struct {
+		sceneID int
+		hours   float64
+		build   int
+	}{
+		{1, 0, 1},
+		{2, 1, 1},
+		{5, 1.5, 2},
+		{3, 2, 1},
+		{2, 30, 2},
+		{4, 31, 2},
+	}
+
+	var once, first, second []*models.WatchEvent
+	for _, p := range plays {
+		once = append(once, watchEvent(p.sceneID, p.hours, true))
+		if p.build == 1 {
+			first = append(first, watchEvent(p.sceneID, p.hours, true))
+		}
+		second = append(second, watchEvent(p.sceneID, p.hours, p.build == 2))
+	}
+
+	incremental := append(CoWatchPairs(first, true), CoWatchPairs(second, true)...)
+
+	assert.Equal(t, pairCounts(CoWatchPairs(once, true)), pairCounts(incremental))
+}
+
+func TestCoWatchPairs_UserWindow(t *testing.T) {
+	events := []*models.WatchEvent{
+		watchEvent(1, 0, true),
+		watchEvent(2, 24*6, true),
+		watchEvent(3, 24*14, true),
+	}
+
+	got := pairCounts(CoWatchPairs(events, true))
+
+	// 3 is played more than a week after the others
+	assert.Equal(t, map[[2]int][2]int{
+		{1, 2}: {1, 0},
+	}, got)
+}
+
+func TestCoWatchPairs_Removed(t *testing.T) {
+	// plays of a user, some of which are removed after they were added
+	plays := []struct {
+		sceneID int
+		hours   float64
+		removed bool
+	}{
+		{1, 0, false},
+		{2, 1, true},
+		{3, 2, false},
+		{2, 30, false},
+		{4, 31, true},
+		{4, 32, true},
+	}
+
+	var all, remaining, removal []*models.WatchEvent
+	for _, p := range plays {
+		all = append(all, watchEvent(p.sceneID, p.hours, true))
+		if !p.removed {
+			remaining = append(remaining, watchEvent(p.sceneID, p.hours, true))
+		}
+		removal = append(removal, watchEvent(p.sceneID, p.hours, p.removed))
+	}
+
+	// the counts of the removed plays are those the model loses
+	want := pairCounts(CoWatchPairs(remaining, true))
+	got := pairCounts(CoWatchPairs(all, true))
+	for k, c := range pairCounts(CoWatchPairs(removal, true)) {
+		r := got[k]
+		got[k] = [2]int{r[0] - c[0], r[1] - c[1]}
+		if got[k] == [2]int{} {
+			delete(got, k)
+		}
+	}
+
+	assert.Equal(t, want, got)
+}
+
+func TestCoWatchScores(t *testing.T) {
+	r := &mockCoWatchReader{
+		pairs: []*models.CoWatchPair{
+			{SceneID: 1, OtherSceneID: 2, Users: 2, Sessions: 2},
+			{SceneID: 1, OtherSceneID: 3, Users: 1},
+			{SceneID: 2, OtherSceneID: 1, Users: 2, Sessions: 2},
+			{SceneID: 2, OtherSceneID: 3, Users: 1},
+		},
+	}
+
+	scores, err := CoWatchScores(context.Background(), r, []int{1})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]float64{2: 1, 3: 1.0 / 3}, scores)
+
+	// the given scenes are not scored
+	scores, err = CoWatchScores(context.Background(), r, []int{1, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]float64{3: 1}, scores)
+}
+
+func TestScoreScene_CoWatch(t *testing.T) {
+	profile := &ProfileData{
+		TagWeights:       map[int]float64{1: 1.0},
+		PerformerWeights: map[int]float64{},
+		StudioWeights:    map[int]float64{},
+	}
+
+	scorer := NewScorer(profile, &mocks.SceneReaderWriter{}, nil, nil, nil)
+	scene := createMockScene(2, []int{1}, []int{}, nil)
+
+	base, _ := scorer.ScoreScene(context.Background(), scene, 0.5, 0.3, 0.2)
+
+	scorer.SetCoWatch(map[int]float64{2: 0.5}, 0.4)
+	score, reason := scorer.ScoreScene(context.Background(), scene, 0.5, 0.3, 0.2)
+
+	assert.InDelta(t, base+0.2, score, 0.0001)
+	assert.Contains(t, reason, coWatchReason)
+
+	// co-watch scores are used without a profile
+	scorer = NewScorer(nil, &mocks.SceneReaderWriter{}, nil, nil, nil)
+	scorer.SetCoWatch(map[int]float64{2: 0.5}, 0.4)
+	score, reason = scorer.ScoreScene(context.Background(), scene, 0.5, 0.3, 0.2)
+
+	assert.InDelta(t, 0.2, score, 0.0001)
+	assert.Equal(t, "Based on "+coWatchReason, reason)
+}
+
+func TestRecommendScenes_CoWatchCandidates(t *testing.T) {
+	ctx := context.Background()
+
+	profile := &ProfileData{
+		TagWeights:       map[int]float64{1: 1.0},
+		PerformerWeights: map[int]float64{},
+		StudioWeights:    map[int]float64{},
+	}
+
+	sampled := createMockScene(1, []int{1}, []int{}, nil)
+	coWatched := createMockScene(2, []int{99}, []int{}, nil)
+
+	mockSceneReader := &mocks.SceneReaderWriter{}
+	mockSceneReader.On("Query", ctx, mock.AnythingOfType("models.SceneQueryOptions")).
+		Return(createMockSceneQueryResult([]*models.Scene{sampled}), nil)
+	mockSceneReader.On("FindMany", ctx, []int{2}).
+		Return([]*models.Scene{coWatched}, nil)
+
+	scorer := NewScorer(profile, mockSceneReader, nil, nil, nil)
+	scorer.SetCoWatch(map[int]float64{2: 1}, 0.3)
+
+	results, err := scorer.RecommendScenes(ctx, 10, 0.1, true, 0.5, 0.3, 0.2)
+
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, "1", results[0].ID)
+		assert.Equal(t, "2", results[1].ID)
+	}
+}
@@ -0,0 +1,113 @@
+package embedding
+
+import (
+	"context"
+	"image"
+	"math"
+)
+
+// HistogramModel is the model name of vectors produced by HistogramBackend.
+const HistogramModel = "histogram"
+
+// Backend computes visual embedding vectors for video frames.
+type Backend interface {
+	// Name identifies the model producing the vectors. Vectors produced by
+	// different models cannot be compared with each other.
+	Name() string
+	// Embed returns one vector for each of the given frames, in order.
+	Embed(ctx context.Context, frames []image.Image) ([][]float32, error)
+}
+
+// HistogramBackend is the built-in backend, which describes each frame with
+// a 64-bin HSV colour histogram. It needs no external dependencies, and is
+// used when no other backend is configured or available.
+type HistogramBackend struct{}
+
+func (HistogramBackend) Name() string {
+	return HistogramModel
+}
+
+func (HistogramBackend) Embed(ctx context.Context, frames []image.Image) ([][]float32, error) {
+	ret := make([][]float32, len(frames))
+	for i, f := range frames {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		ret[i] = computeHistogram(f)
+	}
+	return ret, nil
+}
+
+// Mean returns the L2-normalised mean of the given frame vectors, which
+// stands for the whole scene in the nearest-neighbour index. Returns nil if
+// there are no vectors or they differ in length.
+func Mean(frames [][]float32) []float32 {
+	if len(frames) == 0 {
+		return nil
+	}
+
+	dim := len(frames[0])
+	ret := make([]float32, dim)
+	for _, f := range frames {
+		if len(f) != dim {
+			return nil
+		}
+		// normalise each frame first so that no frame dominates the mean
+		n := norm(f)
+		if n == 0 {
+			continue
+		}
+		for i, v := range f {
+			ret[i] += float32(float64(v) / n)
+		}
+	}
+
+	return Normalize(ret)
+}
+
+// Normalize scales v to unit length in place and returns it. A zero vector
+// is returned unchanged.
+func Normalize(v []float32) []float32 {
+	n := norm(v)
+	if n == 0 {
+		return v
+	}
+	for i := range v {
+		v[i] = float32(float64(v[i]) / n)
+	}
+	return v
+}
+
+func norm(v []float32) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	return math.Sqrt(sum)
+}
+
+// FrameSimilarity returns the similarity (0–1) of two scenes from their frame
+// vectors. Each frame is matched with the most similar frame of the other
+// scene, and the matches are averaged in both directions, so that scenes
+// sharing a few distinctive shots score well even if they are edited
+// differently.
+func FrameSimilarity(a, b [][]float32) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	return (bestMatchMean(a, b) + bestMatchMean(b, a)) / 2
+}
+
+func bestMatchMean(a, b [][]float32) float64 {
+	var sum float64
+	for _, fa := range a {
+		var best float64
+		for _, fb := range b {
+			if c := Cosine(fa, fb); c > best {
+				best = c
+			}
+		}
+		sum += best
+	}
+	return sum / float64(len(a))
+}
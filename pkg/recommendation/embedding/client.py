@@ -0,0 +1,157 @@
+import sys
+import json
+import logging
+import os
+import io
+
+# Force UTF-8 for stdout/stderr on Windows
+if sys.platform == "win32":
+    sys.stdout = io.TextIOWrapper(sys.stdout.buffer, encoding='utf-8')
+    sys.stderr = io.TextIOWrapper(sys.stderr.buffer, encoding='utf-8')
+
+# Keep a reference to the actual stdout for the final JSON output
+real_stdout = sys.stdout
+# Redirect standard print() calls and library stdout usage to stderr so they don't corrupt our JSON output
+sys.stdout = sys.stderr
+
+logging.basicConfig(level=logging.INFO)
+logger = logging.getLogger("embedding_client")
+
+# ImageNet/CLIP-style preprocessing used for ONNX image encoders
+ONNX_INPUT_SIZE = 224
+ONNX_MEAN = (0.48145466, 0.4578275, 0.40821073)
+ONNX_STD = (0.26862954, 0.26130258, 0.27577711)
+
+
+class OnnxModel:
+    """Runs an ONNX image encoder on the CPU.
+
+    The model must take a float32 NCHW batch of 224x224 RGB images and return
+    one vector per image as its first output. Outputs with spatial dimensions
+    are mean-pooled.
+    """
+
+    def __init__(self, path):
+        import onnxruntime
+
+        if not os.path.exists(path):
+            raise FileNotFoundError(f"ONNX model not found: {path}")
+        self.session = onnxruntime.InferenceSession(path, providers=["CPUExecutionProvider"])
+        self.input_name = self.session.get_inputs()[0].name
+
+    def embed(self, image_paths):
+        import numpy as np
+        from PIL import Image
+
+        batch = []
+        for p in image_paths:
+            img = Image.open(p).convert("RGB").resize((ONNX_INPUT_SIZE, ONNX_INPUT_SIZE), Image.BICUBIC)
+            arr = np.asarray(img, dtype=np.float32) / 255.0
+            arr = (arr - np.array(ONNX_MEAN, dtype=np.float32)) / np.array(ONNX_STD, dtype=np.float32)
+            batch.append(arr.transpose(2, 0, 1))
+
+        out = self.session.run(None, {self.input_name: np.stack(batch)})[0]
+        out = out.reshape(out.shape[0], out.shape[1], -1).mean(axis=2) if out.ndim > 2 else out
+        return out.astype(float).tolist()
+
+
+class OpenClipModel:
+    """Runs the image encoder of an open_clip model."""
+
+    def __init__(self, name):
+        import open_clip
+        import torch
+
+        self.torch = torch
+        self.model, _, self.preprocess = open_clip.create_model_and_transforms(name, pretrained="openai")
+        self.model.eval()
+
+    def embed(self, image_paths):
+        from PIL import Image
+
+        images = [self.preprocess(Image.open(p).convert("RGB")) for p in image_paths]
+        with self.torch.no_grad():
+            out = self.model.encode_image(self.torch.stack(images))
+        return out.float().tolist()
+
+
+def load_model(model):
+    if model.lower().endswith(".onnx"):
+        logger.info(f"Loading ONNX model: {model}")
+        return OnnxModel(model)
+    logger.info(f"Loading open_clip model: {model}")
+    return OpenClipModel(model)
+
+
+def embed(data):
+    """
+    Embed a batch of frames.
+
+    data: {
+        "model": str,          # Path to an ONNX image encoder, or an open_clip model name
+        "image_paths": [str]   # Paths to the frame images
+    }
+    """
+    try:
+        image_paths = data.get("image_paths") or []
+        for p in image_paths:
+            if not os.path.exists(p):
+                return {"success": False, "error": f"Image file not found: {p}"}
+
+        model = load_model(data.get("model", ""))
+        vectors = model.embed(image_paths) if image_paths else []
+
+        logger.info(f"Embedded {len(vectors)} frames")
+        return {"success": True, "vectors": vectors}
+
+    except Exception as e:
+        logger.error(f"Embedding error: {e}")
+        return {"success": False, "error": str(e)}
+
+
+def status(data):
+    """Check that the model can be loaded."""
+    try:
+        load_model(data.get("model", ""))
+        return {"success": True}
+    except Exception as e:
+        logger.error(f"Embedding status check failed: {e}")
+        return {"success": False, "error": str(e)}
+
+
+def main():
+    if len(sys.argv) < 2:
+        result = {"success": False, "error": "No command specified. Use: embed or status"}
+        real_stdout.write(json.dumps(result))
+        real_stdout.flush()
+        return
+
+    command = sys.argv[1]
+
+    # Read input JSON from stdin
+    input_data = {}
+    try:
+        input_str = sys.stdin.read()
+        if input_str.strip():
+            input_data = json.loads(input_str)
+    except json.JSONDecodeError as e:
+        result = {"success": False, "error": f"Invalid JSON input: {e}"}
+        real_stdout.write(json.dumps(result))
+        real_stdout.flush()
+        return
+
+    # Route to appropriate function
+    if command == "embed":
+        result = embed(input_data)
+    elif command == "status":
+        result = status(input_data)
+    else:
+        result = {"success": False, "error": f"Unknown command: {command}"}
+
+    # Output result to the real stdout (not redirected stderr)
+    real_stdout.write(json.dumps(result))
+    real_stdout.flush()
+
+
+if __name__ == "__main__":
+    main()
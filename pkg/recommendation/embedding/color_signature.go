@@ -1,6 +1,8 @@
-// Package embedding provides lightweight visual similarity helpers.
-// It computes a 64-bin HSV colour histogram from image bytes and offers
-// cosine-similarity comparison — no external ML libraries required.
+// Package embedding provides visual similarity helpers for scenes. Frames
+// are turned into vectors by a Backend — either the built-in 64-bin HSV
+// colour histogram or an external model run by a Python sidecar — and
+// compared by cosine similarity, with an approximate nearest-neighbour Index
+// for searching large libraries.
 package embedding
 
 import (
@@ -0,0 +1,300 @@
+package embedding
+
+import (
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+const (
+	// indexExactSize is the number of vectors below which the index is not
+	// partitioned and every search is exact.
+	indexExactSize = 2000
+
+	// indexMinProbes is the least number of clusters searched per query.
+	indexMinProbes = 8
+
+	// kmeansSampleSize and kmeansIterations bound the cost of computing the
+	// cluster centroids.
+	kmeansSampleSize = 10000
+	kmeansIterations = 6
+)
+
+// Neighbour is a search result of an Index.
+type Neighbour struct {
+	ID         int
+	Similarity float64
+}
+
+// Index is an approximate nearest-neighbour index of vectors by cosine
+// similarity. The vectors are partitioned into clusters around k-means
+// centroids, and a search only compares the query with the vectors of the
+// clusters nearest to it, which keeps searches fast on large libraries at
+// the cost of occasionally missing a neighbour lying near a cluster border.
+//
+// Index is safe for concurrent use.
+type Index struct {
+	mu sync.RWMutex
+
+	vectors   map[int][]float32
+	centroids [][]float32
+	// clusters holds the IDs in each cluster, and cluster the cluster of
+	// each ID.
+	clusters []map[int]struct{}
+	cluster  map[int]int
+	// clustered is the number of vectors when the centroids were computed.
+	clustered int
+}
+
+// NewIndex returns an index of the given vectors, keyed by ID.
+func NewIndex(vectors map[int][]float32) *Index {
+	i := &Index{}
+	i.build(vectors)
+	return i
+}
+
+func (i *Index) build(vectors map[int][]float32) {
+	i.vectors = make(map[int][]float32, len(vectors))
+	ids := make([]int, 0, len(vectors))
+	for id, v := range vectors {
+		i.vectors[id] = Normalize(append([]float32(nil), v...))
+		ids = append(ids, id)
+	}
+	// make the clustering independent of map order
+	sort.Ints(ids)
+
+	i.centroids = nil
+	if len(ids) >= indexExactSize {
+		data := make([][]float32, len(ids))
+		for j, id := range ids {
+			data[j] = i.vectors[id]
+		}
+		i.centroids = kmeans(data, int(math.Sqrt(float64(len(ids)))))
+	}
+
+	i.clusters = make([]map[int]struct{}, max(len(i.centroids), 1))
+	for c := range i.clusters {
+		i.clusters[c] = make(map[int]struct{})
+	}
+	i.cluster = make(map[int]int, len(ids))
+
+	data := make([][]float32, len(ids))
+	for j, id := range ids {
+		data[j] = i.vectors[id]
+	}
+	for j, c := range nearestCentroids(data, i.centroids) {
+		i.clusters[c][ids[j]] = struct{}{}
+		i.cluster[ids[j]] = c
+	}
+
+	i.clustered = len(ids)
+}
+
+// Len returns the number of vectors in the index.
+func (i *Index) Len() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return len(i.vectors)
+}
+
+// Set adds the vector of the given ID, replacing any existing one. The
+// clusters are recomputed once the index has doubled in size since they were
+// last computed.
+func (i *Index) Set(id int, v []float32) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.remove(id)
+	v = Normalize(append([]float32(nil), v...))
+	i.vectors[id] = v
+
+	if len(i.vectors) >= indexExactSize && len(i.vectors) > 2*i.clustered {
+		i.build(i.vectors)
+		return
+	}
+
+	c := nearestCentroids([][]float32{v}, i.centroids)[0]
+	i.clusters[c][id] = struct{}{}
+	i.cluster[id] = c
+}
+
+// Remove removes the vector of the given ID, if present.
+func (i *Index) Remove(id int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.remove(id)
+}
+
+func (i *Index) remove(id int) {
+	c, ok := i.cluster[id]
+	if !ok {
+		return
+	}
+	delete(i.clusters[c], id)
+	delete(i.cluster, id)
+	delete(i.vectors, id)
+}
+
+// IDs returns the IDs of the vectors in the index.
+func (i *Index) IDs() []int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	ret := make([]int, 0, len(i.vectors))
+	for id := range i.vectors {
+		ret = append(ret, id)
+	}
+	return ret
+}
+
+// Search returns up to k vectors most similar to q, most similar first.
+func (i *Index) Search(q []float32, k int) []Neighbour {
+	if k <= 0 {
+		return nil
+	}
+	q = Normalize(append([]float32(nil), q...))
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	var ret []Neighbour
+	for _, c := range i.probes(q) {
+		for id := range i.clusters[c] {
+			v := i.vectors[id]
+			if len(v) != len(q) {
+				continue
+			}
+			ret = append(ret, Neighbour{ID: id, Similarity: dot(q, v)})
+		}
+	}
+
+	sort.Slice(ret, func(a, b int) bool {
+		if ret[a].Similarity != ret[b].Similarity {
+			return ret[a].Similarity > ret[b].Similarity
+		}
+		return ret[a].ID < ret[b].ID
+	})
+	if len(ret) > k {
+		ret = ret[:k]
+	}
+	return ret
+}
+
+// probes returns the clusters to search for q, nearest first.
+func (i *Index) probes(q []float32) []int {
+	if len(i.centroids) == 0 {
+		return []int{0}
+	}
+
+	type scored struct {
+		c   int
+		sim float64
+	}
+	s := make([]scored, len(i.centroids))
+	for c, centroid := range i.centroids {
+		s[c] = scored{c, dot(q, centroid)}
+	}
+	sort.Slice(s, func(a, b int) bool { return s[a].sim > s[b].sim })
+
+	n := max(indexMinProbes, len(i.centroids)/16)
+	n = min(n, len(s))
+	ret := make([]int, n)
+	for j := range ret {
+		ret[j] = s[j].c
+	}
+	return ret
+}
+
+// kmeans returns k centroids of the given unit vectors, computed from a
+// sample of them with spherical k-means.
+func kmeans(data [][]float32, k int) [][]float32 {
+	r := rand.New(rand.NewSource(1))
+
+	sample := data
+	if len(sample) > kmeansSampleSize {
+		sample = make([][]float32, kmeansSampleSize)
+		for j, idx := range r.Perm(len(data))[:kmeansSampleSize] {
+			sample[j] = data[idx]
+		}
+	}
+	k = min(k, len(sample))
+
+	centroids := make([][]float32, k)
+	for j, idx := range r.Perm(len(sample))[:k] {
+		centroids[j] = append([]float32(nil), sample[idx]...)
+	}
+
+	dim := len(sample[0])
+	for iter := 0; iter < kmeansIterations; iter++ {
+		sums := make([][]float64, k)
+		for c := range sums {
+			sums[c] = make([]float64, dim)
+		}
+		counts := make([]int, k)
+		for j, c := range nearestCentroids(sample, centroids) {
+			if len(sample[j]) != dim {
+				continue
+			}
+			for d, v := range sample[j] {
+				sums[c][d] += float64(v)
+			}
+			counts[c]++
+		}
+
+		for c := range centroids {
+			// keep the previous centroid of an empty cluster
+			if counts[c] == 0 {
+				continue
+			}
+			for d := range centroids[c] {
+				centroids[c][d] = float32(sums[c][d])
+			}
+			Normalize(centroids[c])
+		}
+	}
+
+	return centroids
+}
+
+// nearestCentroids returns the index of the most similar centroid of each
+// vector, or 0 for every vector if there are no centroids.
+func nearestCentroids(data [][]float32, centroids [][]float32) []int {
+	ret := make([]int, len(data))
+	if len(centroids) == 0 {
+		return ret
+	}
+
+	workers := min(runtime.NumCPU(), len(data))
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for j := w; j < len(data); j += workers {
+				best := math.Inf(-1)
+				for c, centroid := range centroids {
+					if len(centroid) != len(data[j]) {
+						continue
+					}
+					if s := dot(data[j], centroid); s > best {
+						best = s
+						ret[j] = c
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	return ret
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
@@ -0,0 +1,113 @@
+package embedding
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// indexRefreshOverlap is how far before the previous refresh an index is
+// refreshed from, so that embeddings committed late by a transaction that
+// started before the refresh are not missed.
+const indexRefreshOverlap = 5 * time.Minute
+
+// IndexCache holds an Index of the stored embedding means of each model, so
+// that the index is only built once and then kept up to date with the store.
+type IndexCache struct {
+	mu      sync.Mutex
+	entries map[string]*indexCacheEntry
+}
+
+type indexCacheEntry struct {
+	// mu is held while the index is built or refreshed, so that requests
+	// for other models are not blocked.
+	mu        sync.Mutex
+	index     *Index
+	refreshed time.Time
+}
+
+func NewIndexCache() *IndexCache {
+	return &IndexCache{
+		entries: make(map[string]*indexCacheEntry),
+	}
+}
+
+func (c *IndexCache) entry(model string) *indexCacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := c.entries[model]
+	if e == nil {
+		e = &indexCacheEntry{}
+		c.entries[model] = e
+	}
+	return e
+}
+
+// Get returns the index of the embeddings of the given model. The index is
+// built on first use, and afterwards updated with the embeddings set since
+// the last call, and with deleted embeddings removed.
+func (c *IndexCache) Get(ctx context.Context, r models.VisualEmbeddingReader, model string) (*Index, error) {
+	e := c.entry(model)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := time.Now()
+
+	if e.index == nil {
+		all, err := r.Means(ctx, model, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+
+		e.index = NewIndex(all)
+		e.refreshed = now
+		return e.index, nil
+	}
+
+	updated, err := r.Means(ctx, model, e.refreshed.Add(-indexRefreshOverlap))
+	if err != nil {
+		return nil, err
+	}
+	for id, v := range updated {
+		e.index.Set(id, v)
+	}
+
+	count, err := r.Count(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+	if count != e.index.Len() {
+		if err := e.removeDeleted(ctx, r, model); err != nil {
+			return nil, err
+		}
+	}
+
+	e.refreshed = now
+	return e.index, nil
+}
+
+// removeDeleted removes the scenes that no longer have an embedding of the
+// model from the index.
+func (e *indexCacheEntry) removeDeleted(ctx context.Context, r models.VisualEmbeddingReader, model string) error {
+	ids, err := r.SceneIDs(ctx, model)
+	if err != nil {
+		return err
+	}
+
+	stored := make(map[int]struct{}, len(ids))
+	for _, id := range ids {
+		stored[id] = struct{}{}
+	}
+
+	for _, id := range e.index.IDs() {
+		if _, ok := stored[id]; !ok {
+			e.index.Remove(id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package embedding
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type mapEmbeddingReader struct {
+	models.VisualEmbeddingReader
+	means map[int][]float32
+}
+
+func (r *mapEmbeddingReader) Means(ctx context.Context, model string, since time.Time) (map[int][]float32, error) {
+	if !since.IsZero() {
+		return nil, nil
+	}
+	return r.means, nil
+}
+
+func (r *mapEmbeddingReader) Count(ctx context.Context, model string) (int, error) {
+	return len(r.means), nil
+}
+
+func (r *mapEmbeddingReader) SceneIDs(ctx context.Context, model string) ([]int, error) {
+	var ret []int
+	for id := range r.means {
+		ret = append(ret, id)
+	}
+	return ret, nil
+}
+
+func TestIndexCache_Delete(t *testing.T) {
+	ctx := context.Background()
+	r := &mapEmbeddingReader{means: map[int][]float32{
+		1: {1, 0},
+		2: {0, 1},
+		3: {1, 1},
+	}}
+
+	c := NewIndexCache()
+	index, err := c.Get(ctx, r, "model")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, index.Len())
+
+	delete(r.means, 2)
+	got, err := c.Get(ctx, r, "model")
+	assert.NoError(t, err)
+	// the deleted embedding is removed without rebuilding the index
+	assert.Same(t, index, got)
+	assert.ElementsMatch(t, []int{1, 3}, got.IDs())
+}
@@ -0,0 +1,133 @@
+package embedding
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// clusteredVectors returns n random vectors grouped around a number of
+// random centres, as embeddings of similar scenes are.
+func clusteredVectors(r *rand.Rand, n int, dim int, centres int) map[int][]float32 {
+	c := make([][]float32, centres)
+	for i := range c {
+		c[i] = make([]float32, dim)
+		for d := range c[i] {
+			c[i][d] = float32(r.NormFloat64())
+		}
+	}
+
+	ret := make(map[int][]float32, n)
+	for id := 0; id < n; id++ {
+		centre := c[r.Intn(centres)]
+		v := make([]float32, dim)
+		for d := range v {
+			v[d] = centre[d] + float32(r.NormFloat64()*0.3)
+		}
+		ret[id] = v
+	}
+	return ret
+}
+
+func exactSearch(vectors map[int][]float32, q []float32, k int) []int {
+	var ret []Neighbour
+	for id, v := range vectors {
+		ret = append(ret, Neighbour{ID: id, Similarity: Cosine(q, v)})
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Similarity > ret[j].Similarity })
+
+	ids := make([]int, k)
+	for i := range ids {
+		ids[i] = ret[i].ID
+	}
+	return ids
+}
+
+func TestIndex_Exact(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	vectors := clusteredVectors(r, 500, 16, 10)
+	index := NewIndex(vectors)
+
+	for q := 0; q < 10; q++ {
+		got := index.Search(vectors[q], 5)
+		var ids []int
+		for _, n := range got {
+			ids = append(ids, n.ID)
+		}
+		assert.Equal(t, exactSearch(vectors, vectors[q], 5), ids)
+		assert.InDelta(t, 1, got[0].Similarity, 0.0001)
+	}
+}
+
+func TestIndex_Recall(t *testing.T) {
+	const (
+		k       = 10
+		queries = 50
+	)
+
+	r := rand.New(rand.NewSource(1))
+	vectors := clusteredVectors(r, 20000, 32, 200)
+	index := NewIndex(vectors)
+
+	found := 0
+	for q := 0; q < queries; q++ {
+		want := make(map[int]bool)
+		for _, id := range exactSearch(vectors, vectors[q], k) {
+			want[id] = true
+		}
+		for _, n := range index.Search(vectors[q], k) {
+			if want[n.ID] {
+				found++
+			}
+		}
+	}
+
+	recall := float64(found) / float64(k*queries)
+	assert.GreaterOrEqual(t, recall, 0.9)
+}
+
+func TestIndex_SetRemove(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	vectors := clusteredVectors(r, 3000, 16, 20)
+	index := NewIndex(vectors)
+
+	q := []float32{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	index.Set(-1, q)
+	assert.Equal(t, 3001, index.Len())
+	if got := index.Search(q, 1); assert.Len(t, got, 1) {
+		assert.Equal(t, -1, got[0].ID)
+	}
+
+	index.Remove(-1)
+	assert.Equal(t, 3000, index.Len())
+	for _, n := range index.Search(q, 10) {
+		assert.NotEqual(t, -1, n.ID)
+	}
+
+	// doubling the index recomputes its clusters
+	for id, v := range clusteredVectors(r, 4000, 16, 20) {
+		index.Set(10000+id, v)
+	}
+	assert.Equal(t, 7000, index.Len())
+	assert.Greater(t, index.clustered, 6000)
+}
+
+func TestFrameSimilarity(t *testing.T) {
+	a := [][]float32{{1, 0}, {0, 1}}
+	b := [][]float32{{0, 1}}
+
+	// b matches one frame of a exactly, a matches b with one of two frames
+	assert.InDelta(t, 0.75, FrameSimilarity(a, b), 0.0001)
+	assert.InDelta(t, 1, FrameSimilarity(a, a), 0.0001)
+	assert.Equal(t, 0.0, FrameSimilarity(a, nil))
+}
+
+func TestMean(t *testing.T) {
+	got := Mean([][]float32{{2, 0}, {0, 1}})
+	assert.InDelta(t, 0.7071, got[0], 0.0001)
+	assert.InDelta(t, 0.7071, got[1], 0.0001)
+
+	assert.Nil(t, Mean([][]float32{{1, 0}, {1}}))
+}
@@ -0,0 +1,177 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/python"
+)
+
+// DefaultSidecarModel is the model used by SidecarBackend when none is
+// configured.
+const DefaultSidecarModel = "ViT-B-32"
+
+// SidecarBackend computes embeddings with a model run by a Python script.
+// The model is either the path of an ONNX image encoder, which is run on the
+// CPU with onnxruntime, or the name of an open_clip model.
+type SidecarBackend struct {
+	pythonPath string
+	scriptPath string
+	model      string
+	tempDir    string
+}
+
+func NewSidecarBackend(pythonPath string, model string, tempDir string) *SidecarBackend {
+	scriptPath := python.ServiceScript{
+		Name:      "Embedding",
+		EnvVar:    "STASH_EMBEDDING_SCRIPT",
+		Dir:       "embedding",
+		SourceDir: "pkg/recommendation/embedding",
+	}.Find()
+
+	// Default to python3 if no Python path configured
+	if pythonPath == "" {
+		pythonPath = "python3"
+	}
+
+	if model == "" {
+		model = DefaultSidecarModel
+	}
+
+	return &SidecarBackend{
+		pythonPath: pythonPath,
+		scriptPath: scriptPath,
+		model:      model,
+		tempDir:    tempDir,
+	}
+}
+
+func (b *SidecarBackend) Name() string {
+	return SidecarModelName(b.model)
+}
+
+// SidecarModelName returns the name of the vectors produced by the sidecar
+// backend for the given model: the model name prefixed with "sidecar:". For
+// ONNX files only the file name is used, so that moving the file does not
+// invalidate the stored vectors.
+func SidecarModelName(model string) string {
+	if model == "" {
+		model = DefaultSidecarModel
+	}
+	if strings.EqualFold(filepath.Ext(model), ".onnx") {
+		model = filepath.Base(model)
+	}
+	return "sidecar:" + model
+}
+
+type sidecarRequest struct {
+	Model      string   `json:"model"`
+	ImagePaths []string `json:"image_paths,omitempty"`
+}
+
+type sidecarResponse struct {
+	Success bool        `json:"success"`
+	Vectors [][]float32 `json:"vectors,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// Status returns an error if the model cannot be loaded, for example because
+// the Python dependencies are not installed.
+func (b *SidecarBackend) Status(ctx context.Context) error {
+	_, err := b.call(ctx, "status", sidecarRequest{Model: b.model})
+	return err
+}
+
+// Embed writes the frames to the temporary directory and embeds them in a
+// single call of the script, so that the model is only loaded once.
+func (b *SidecarBackend) Embed(ctx context.Context, frames []image.Image) ([][]float32, error) {
+	if len(frames) == 0 {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(b.tempDir, 0755); err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp(b.tempDir, "frames-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	paths := make([]string, len(frames))
+	for i, f := range frames {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("frame_%04d.jpg", i))
+		if err := writeJPEG(paths[i], f); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := b.call(ctx, "embed", sidecarRequest{Model: b.model, ImagePaths: paths})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Vectors) != len(frames) {
+		return nil, fmt.Errorf("embedding script returned %d vectors for %d frames", len(resp.Vectors), len(frames))
+	}
+
+	return resp.Vectors, nil
+}
+
+func writeJPEG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := jpeg.Encode(f, img, &jpeg.Options{Quality: 90}); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (b *SidecarBackend) call(ctx context.Context, command string, req sidecarRequest) (*sidecarResponse, error) {
+	reqJSON, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, b.pythonPath, b.scriptPath, command)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stdin pipe: %w", err)
+	}
+
+	cmd.Stderr = os.Stderr
+
+	go func() {
+		defer stdin.Close()
+		_, _ = stdin.Write(reqJSON)
+	}()
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("embedding script failed: %w", err)
+	}
+
+	var resp sidecarResponse
+	if err := json.Unmarshal(output, &resp); err != nil {
+		return nil, fmt.Errorf("parsing embedding script output: %w", err)
+	}
+	if !resp.Success {
+		if resp.Error == "" {
+			resp.Error = "unknown error"
+		}
+		return nil, errors.New(resp.Error)
+	}
+
+	return &resp, nil
+}
@@ -0,0 +1,56 @@
+package embedding
+
+import (
+	"context"
+	"sort"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// similarCandidateFactor is how many more candidates than requested are taken
+// from the index before they are ranked by their frames.
+const similarCandidateFactor = 4
+
+// SimilarScenes returns up to k scenes most visually similar to the scene of
+// src, most similar first. Candidates are found in the index by their mean
+// vector, then ranked by comparing the vectors of their frames.
+func SimilarScenes(ctx context.Context, r models.VisualEmbeddingReader, index *Index, src *models.VisualEmbedding, k int) ([]Neighbour, error) {
+	if k <= 0 || len(src.Mean) == 0 {
+		return nil, nil
+	}
+
+	var candidateIDs []int
+	for _, n := range index.Search(src.Mean, k*similarCandidateFactor+1) {
+		if n.ID != src.SceneID {
+			candidateIDs = append(candidateIDs, n.ID)
+		}
+	}
+	if len(candidateIDs) == 0 {
+		return nil, nil
+	}
+
+	candidates, err := r.FindMany(ctx, candidateIDs, src.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]Neighbour, 0, len(candidates))
+	for _, c := range candidates {
+		ret = append(ret, Neighbour{
+			ID:         c.SceneID,
+			Similarity: FrameSimilarity(src.Frames, c.Frames),
+		})
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Similarity != ret[j].Similarity {
+			return ret[i].Similarity > ret[j].Similarity
+		}
+		return ret[i].ID < ret[j].ID
+	})
+	if len(ret) > k {
+		ret = ret[:k]
+	}
+
+	return ret, nil
+}
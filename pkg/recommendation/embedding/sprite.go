@@ -0,0 +1,112 @@
+package embedding
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// subImager is implemented by the image types returned by the standard
+// decoders.
+type subImager interface {
+	SubImage(r image.Rectangle) image.Image
+}
+
+// SpriteFrames returns up to max frames of a scene sprite, spread evenly over
+// the scene. The frame positions are read from the #xywh fragments of the
+// sprite VTT file.
+func SpriteFrames(spritePath string, vttPath string, max int) ([]image.Image, error) {
+	rects, err := readSpriteVTT(vttPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(rects) == 0 {
+		return nil, fmt.Errorf("no frames in %s", vttPath)
+	}
+
+	f, err := os.Open(spritePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sprite, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", spritePath, err)
+	}
+	si, ok := sprite.(subImager)
+	if !ok {
+		return nil, fmt.Errorf("cannot crop %s", spritePath)
+	}
+
+	rects = sampleEvenly(rects, max)
+	ret := make([]image.Image, 0, len(rects))
+	for _, r := range rects {
+		r = r.Intersect(sprite.Bounds())
+		if r.Empty() {
+			continue
+		}
+		ret = append(ret, si.SubImage(r))
+	}
+
+	return ret, nil
+}
+
+// readSpriteVTT returns the rectangles of the frames listed in a sprite VTT
+// file, in order.
+func readSpriteVTT(vttPath string) ([]image.Rectangle, error) {
+	f, err := os.Open(vttPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var ret []image.Rectangle
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		_, fragment, found := strings.Cut(strings.TrimSpace(scanner.Text()), "#xywh=")
+		if !found {
+			continue
+		}
+
+		parts := strings.Split(fragment, ",")
+		if len(parts) != 4 {
+			continue
+		}
+		var v [4]int
+		valid := true
+		for i, p := range parts {
+			v[i], err = strconv.Atoi(p)
+			if err != nil {
+				valid = false
+				break
+			}
+		}
+		if valid {
+			ret = append(ret, image.Rect(v[0], v[1], v[0]+v[2], v[1]+v[3]))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", vttPath, err)
+	}
+
+	return ret, nil
+}
+
+// sampleEvenly returns at most max elements of s, evenly spaced. All of s is
+// returned if max is 0 or less.
+func sampleEvenly[T any](s []T, max int) []T {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+
+	ret := make([]T, max)
+	for i := range ret {
+		ret[i] = s[i*len(s)/max]
+	}
+	return ret
+}
@@ -0,0 +1,68 @@
+package embedding
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpriteFrames(t *testing.T) {
+	dir := t.TempDir()
+
+	// a 4x1 sprite of 10x10 frames in different colours
+	colours := []color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}, {255, 255, 0, 255}}
+	sprite := image.NewRGBA(image.Rect(0, 0, 40, 10))
+	for i, c := range colours {
+		for x := i * 10; x < (i+1)*10; x++ {
+			for y := 0; y < 10; y++ {
+				sprite.Set(x, y, c)
+			}
+		}
+	}
+
+	spritePath := filepath.Join(dir, "sprite.jpg")
+	f, err := os.Create(spritePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := jpeg.Encode(f, sprite, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	vtt := "WEBVTT\n\n" +
+		"00:00:00.000 --> 00:00:05.000\nsprite.jpg#xywh=0,0,10,10\n\n" +
+		"00:00:05.000 --> 00:00:10.000\nsprite.jpg#xywh=10,0,10,10\n\n" +
+		"00:00:10.000 --> 00:00:15.000\nsprite.jpg#xywh=20,0,10,10\n\n" +
+		"00:00:15.000 --> 00:00:20.000\nsprite.jpg#xywh=30,0,10,10\n"
+	vttPath := filepath.Join(dir, "thumbs.vtt")
+	if err := os.WriteFile(vttPath, []byte(vtt), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	frames, err := SpriteFrames(spritePath, vttPath, 0)
+	assert.NoError(t, err)
+	assert.Len(t, frames, 4)
+
+	// sampled frames are spread over the scene
+	frames, err = SpriteFrames(spritePath, vttPath, 2)
+	assert.NoError(t, err)
+	if assert.Len(t, frames, 2) {
+		assert.Equal(t, image.Rect(0, 0, 10, 10), frames[0].Bounds())
+		assert.Equal(t, image.Rect(20, 0, 30, 10), frames[1].Bounds())
+	}
+
+	// frames of different colours have different histograms
+	vectors, err := HistogramBackend{}.Embed(context.Background(), frames)
+	assert.NoError(t, err)
+	if assert.Len(t, vectors, 2) {
+		assert.Len(t, vectors[0], HistBins)
+		assert.Less(t, Cosine(vectors[0], vectors[1]), 0.5)
+	}
+}
@@ -1,6 +1,10 @@
 package recommendation
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/stashbox"
 )
@@ -38,3 +42,46 @@ func NewEngine(
 		StashBoxClient: stashBoxClient,
 	}
 }
+
+// UserProfile returns the content profile of the user ctx is scoped to, with
+// its weights loaded. It returns nil if the profile has not been built yet.
+func (e *Engine) UserProfile(ctx context.Context) (*models.ContentProfile, error) {
+	profile, err := e.ContentProfile.FindUserProfile(ctx)
+	if err != nil || profile == nil {
+		return nil, err
+	}
+
+	if err := e.ContentProfile.LoadWeights(ctx, profile); err != nil {
+		return nil, fmt.Errorf("loading profile weights: %w", err)
+	}
+
+	return profile, nil
+}
+
+// RebuildUserProfile recomputes the content profile of the user ctx is scoped
+// to from their own ratings and play history, and saves it.
+// Must be called inside a write transaction.
+func (e *Engine) RebuildUserProfile(ctx context.Context) (*models.ContentProfile, error) {
+	builder := NewProfileBuilder(e.SceneRepo, e.PerformerRepo, e.TagRepo, e.StudioRepo)
+	data, err := builder.BuildUserProfile(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := e.ContentProfile.GetOrCreateUserProfile(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("finding profile: %w", err)
+	}
+
+	data.setWeights(profile)
+	profile.UpdatedAt = time.Now()
+
+	if err := e.ContentProfile.Update(ctx, profile); err != nil {
+		return nil, fmt.Errorf("updating profile: %w", err)
+	}
+	if err := e.ContentProfile.SaveWeights(ctx, profile); err != nil {
+		return nil, fmt.Errorf("saving profile weights: %w", err)
+	}
+
+	return profile, nil
+}
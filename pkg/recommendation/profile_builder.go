@@ -41,6 +41,9 @@ func NewProfileBuilder(
 // - Play duration vs actual duration (engagement)
 // - Recency of play (recency boost)
 // - Performer favorites (favorite boost)
+//
+// When ctx is scoped to a user, that user's own ratings, play durations and
+// play history are used.
 func (pb *ProfileBuilder) BuildUserProfile(ctx context.Context) (*ProfileData, error) {
 	logger.Info("Building user content profile...")
 
@@ -106,12 +109,25 @@ func (pb *ProfileBuilder) BuildUserProfile(ctx context.Context) (*ProfileData, e
 			lastPlayedTimes = make([]*time.Time, len(scenes))
 		}
 
+		// Score against the current user's own ratings and play durations.
+		var userData []*models.UserData
+		if _, ok := models.UserIDFromContext(ctx); ok {
+			userData, err = pb.sceneReader.GetManyUserData(ctx, sceneIDs)
+			if err != nil {
+				return nil, fmt.Errorf("loading user data: %w", err)
+			}
+		}
+
 		for i, scene := range scenes {
 			var lastPlayed *time.Time
 			if i < len(lastPlayedTimes) {
 				lastPlayed = lastPlayedTimes[i]
 			}
-			score := pb.computeSceneScore(scene, lastPlayed)
+			scored := scene
+			if userData != nil {
+				scored = withUserData(scene, userData[i])
+			}
+			score := pb.computeSceneScore(scored, lastPlayed)
 			if score == 0 {
 				continue
 			}
@@ -256,6 +272,20 @@ const (
 	abandonPenalty        = -0.30 // score returned for an abandoned scene
 )
 
+// withUserData returns a copy of scene carrying the rating and play duration
+// from d. The scene itself may be shared with other readers and is not
+// modified.
+func withUserData(scene *models.Scene, d *models.UserData) *models.Scene {
+	ret := *scene
+	ret.Rating = nil
+	ret.PlayDuration = 0
+	if d != nil {
+		ret.Rating = d.Rating
+		ret.PlayDuration = d.PlayDuration
+	}
+	return &ret
+}
+
 // computeSceneScore calculates the engagement score for a single scene.
 // A negative return value means the scene was abandoned and acts as a
 // negative preference signal when aggregated into the profile.
@@ -438,6 +468,36 @@ type WeightedStringItem struct {
 	Weight float64
 }
 
+// setWeights replaces the weights of profile with those in pd.
+func (pd *ProfileData) setWeights(profile *models.ContentProfile) {
+	profile.TagWeights = make([]models.TagWeight, 0, len(pd.TagWeights))
+	for id, w := range pd.TagWeights {
+		profile.TagWeights = append(profile.TagWeights, models.TagWeight{ProfileID: profile.ID, TagID: id, Weight: w})
+	}
+
+	profile.PerformerWeights = make([]models.PerformerWeight, 0, len(pd.PerformerWeights))
+	for id, w := range pd.PerformerWeights {
+		profile.PerformerWeights = append(profile.PerformerWeights, models.PerformerWeight{ProfileID: profile.ID, PerformerID: id, Weight: w})
+	}
+
+	profile.StudioWeights = make([]models.StudioWeight, 0, len(pd.StudioWeights))
+	for id, w := range pd.StudioWeights {
+		profile.StudioWeights = append(profile.StudioWeights, models.StudioWeight{ProfileID: profile.ID, StudioID: id, Weight: w})
+	}
+
+	profile.AttributeWeights = nil
+	for name, valMap := range pd.AttributeWeights {
+		for val, w := range valMap {
+			profile.AttributeWeights = append(profile.AttributeWeights, models.AttributeWeight{
+				ProfileID:      profile.ID,
+				AttributeName:  name,
+				AttributeValue: val,
+				Weight:         w,
+			})
+		}
+	}
+}
+
 // --- Utility Functions ---
 
 func normalizeWeights(weights map[int]float64) map[int]float64 {
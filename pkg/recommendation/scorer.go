@@ -17,8 +17,23 @@ type Scorer struct {
 	performerReader models.PerformerReader
 	studioReader    models.StudioReader
 	tagReader       models.TagReader
+
+	// coWatch holds the co-watch scores blended into scene scores
+	coWatch       map[int]float64
+	coWatchWeight float64
+
+	// watchedPenalty is the fraction of the score removed from played scenes
+	watchedPenalty float64
 }
 
+// Names of the factors of a RecommendationBreakdown.
+const (
+	tagsFactor       = "tags"
+	performersFactor = "performers"
+	studioFactor     = "studio"
+	coWatchFactor    = "co_watch"
+)
+
 // NewScorer creates a Scorer with the given profile data.
 func NewScorer(
 	profile *ProfileData,
@@ -36,15 +51,52 @@ func NewScorer(
 	}
 }
 
+// SetCoWatch blends co-watch scores, as returned by CoWatchScores, into the
+// scores of ScoreScene with the given weight. RecommendScenes also considers
+// the co-watched scenes as candidates.
+func (s *Scorer) SetCoWatch(scores map[int]float64, weight float64) {
+	s.coWatch = scores
+	s.coWatchWeight = weight
+}
+
+// SetWatchedPenalty sets the fraction of the score removed from scenes that
+// have already been played. It is 0 by default.
+func (s *Scorer) SetWatchedPenalty(penalty float64) {
+	s.watchedPenalty = penalty
+}
+
 // ScoreScene computes a recommendation score for a scene (0-1).
 func (s *Scorer) ScoreScene(ctx context.Context, scene *models.Scene, tagWeight, perfWeight, studioWeight float64) (float64, string) {
-	if s.profile == nil {
-		return 0, ""
+	score, reason, _ := s.explainScene(ctx, scene, tagWeight, perfWeight, studioWeight)
+	return score, reason
+}
+
+// ExplainScene scores a scene as ScoreScene does, and returns the score with
+// a breakdown of the contribution of each factor.
+func (s *Scorer) ExplainScene(ctx context.Context, scene *models.Scene, tagWeight, perfWeight, studioWeight float64) models.RecommendationResult {
+	score, reason, breakdown := s.explainScene(ctx, scene, tagWeight, perfWeight, studioWeight)
+	return models.RecommendationResult{
+		Type:      "scene",
+		ID:        strconv.Itoa(scene.ID),
+		Name:      scene.GetTitle(),
+		Score:     score,
+		Reason:    reason,
+		Scene:     scene,
+		Breakdown: breakdown,
 	}
+}
+
+func (s *Scorer) explainScene(ctx context.Context, scene *models.Scene, tagWeight, perfWeight, studioWeight float64) (float64, string, *models.RecommendationBreakdown) {
+	breakdown := &models.RecommendationBreakdown{}
+	coWatchScore := s.coWatch[scene.ID] * s.coWatchWeight
 
 	// Need at least one weight source to score against
-	if len(s.profile.TagWeights) == 0 && len(s.profile.PerformerWeights) == 0 && len(s.profile.StudioWeights) == 0 {
-		return 0, ""
+	if s.profile == nil || (len(s.profile.TagWeights) == 0 && len(s.profile.PerformerWeights) == 0 && len(s.profile.StudioWeights) == 0) {
+		if coWatchScore > 0 {
+			breakdown.AddFactor(coWatchFactor, s.coWatch[scene.ID], s.coWatchWeight)
+			return s.applyWatchedPenalty(scene, coWatchScore, breakdown), "Based on " + coWatchReason, breakdown
+		}
+		return 0, "", breakdown
 	}
 
 	var totalScore float64
@@ -53,7 +105,7 @@ func (s *Scorer) ScoreScene(ctx context.Context, scene *models.Scene, tagWeight,
 	// Load relationships if not already loaded
 	if err := scene.LoadTagIDs(ctx, s.sceneReader); err != nil {
 		// Skip this scene if we can't load its tags
-		return 0, ""
+		return 0, "", breakdown
 	}
 	if err := scene.LoadPerformerIDs(ctx, s.sceneReader); err != nil {
 		// Continue without performer scoring if load fails
@@ -61,18 +113,18 @@ func (s *Scorer) ScoreScene(ctx context.Context, scene *models.Scene, tagWeight,
 
 	// Score based on tags (only if loaded)
 	var tagScore float64
-	matchedTags := 0
 	if scene.TagIDs.Loaded() {
 		for _, tagID := range scene.TagIDs.List() {
 			if weight, ok := s.profile.TagWeights[tagID]; ok {
 				tagScore += weight
-				matchedTags++
+				breakdown.Tags = append(breakdown.Tags, models.RecommendationMatch{ID: tagID, Weight: weight})
 			}
 		}
 	}
-	if matchedTags > 0 {
+	if matchedTags := len(breakdown.Tags); matchedTags > 0 {
 		tagScore /= float64(matchedTags)
 		totalScore += tagScore * tagWeight
+		breakdown.AddFactor(tagsFactor, tagScore, tagWeight)
 		if matchedTags >= 2 {
 			reasons = append(reasons, "matching tags")
 		}
@@ -80,40 +132,58 @@ func (s *Scorer) ScoreScene(ctx context.Context, scene *models.Scene, tagWeight,
 
 	// Score based on performers (only if loaded)
 	var performerScore float64
-	matchedPerformers := 0
 	if scene.PerformerIDs.Loaded() {
 		for _, performerID := range scene.PerformerIDs.List() {
 			if weight, ok := s.profile.PerformerWeights[performerID]; ok {
 				performerScore += weight
-				matchedPerformers++
+				breakdown.Performers = append(breakdown.Performers, models.RecommendationMatch{ID: performerID, Weight: weight})
 			}
 		}
 	}
-	if matchedPerformers > 0 {
+	if matchedPerformers := len(breakdown.Performers); matchedPerformers > 0 {
 		performerScore /= float64(matchedPerformers)
 		totalScore += performerScore * perfWeight
-		if matchedPerformers >= 1 {
-			reasons = append(reasons, "favorite performers")
-		}
+		breakdown.AddFactor(performersFactor, performerScore, perfWeight)
+		reasons = append(reasons, "favorite performers")
 	}
 
 	// Score based on studio
 	if scene.StudioID != nil {
 		if weight, ok := s.profile.StudioWeights[*scene.StudioID]; ok {
 			totalScore += weight * studioWeight
+			breakdown.Studio = &models.RecommendationMatch{ID: *scene.StudioID, Weight: weight}
+			breakdown.AddFactor(studioFactor, weight, studioWeight)
 			if weight > 0.5 {
 				reasons = append(reasons, "preferred studio")
 			}
 		}
 	}
 
+	// Score based on scenes watched alongside
+	if coWatchScore > 0 {
+		totalScore += coWatchScore
+		breakdown.AddFactor(coWatchFactor, s.coWatch[scene.ID], s.coWatchWeight)
+		reasons = append(reasons, coWatchReason)
+	}
+
 	// Build reason string
 	reason := ""
 	if len(reasons) > 0 {
 		reason = "Based on " + joinReasons(reasons)
 	}
 
-	return totalScore, reason
+	return s.applyWatchedPenalty(scene, totalScore, breakdown), reason, breakdown
+}
+
+// applyWatchedPenalty returns score less the watched penalty if the scene has
+// been played, recording the penalty in the breakdown.
+func (s *Scorer) applyWatchedPenalty(scene *models.Scene, score float64, breakdown *models.RecommendationBreakdown) float64 {
+	if s.watchedPenalty <= 0 || scene.PlayDuration <= 0 {
+		return score
+	}
+
+	breakdown.WatchedPenalty = score * s.watchedPenalty
+	return score - breakdown.WatchedPenalty
 }
 
 // ScorePerformer computes a recommendation score for a performer.
@@ -234,24 +304,20 @@ func (s *Scorer) RecommendScenes(ctx context.Context, limit int, minScore float6
 		return nil, err
 	}
 
+	scenes, err = s.addCoWatchCandidates(ctx, scenes)
+	if err != nil {
+		return nil, err
+	}
+
 	// Score and filter
 	var recommendations []models.RecommendationResult
 	for _, scene := range scenes {
 		// Calculate score
-		score, reason := s.ScoreScene(ctx, scene, tagW, perfW, studioW)
-		if score < minScore {
+		rec := s.ExplainScene(ctx, scene, tagW, perfW, studioW)
+		if rec.Score < minScore {
 			continue
 		}
 
-		rec := models.RecommendationResult{
-			Type:   "scene",
-			ID:     strconv.Itoa(scene.ID),
-			Name:   scene.GetTitle(),
-			Score:  score,
-			Reason: reason,
-			Scene:  scene,
-		}
-
 		recommendations = append(recommendations, rec)
 	}
 
@@ -268,6 +334,50 @@ func (s *Scorer) RecommendScenes(ctx context.Context, limit int, minScore float6
 	return recommendations, nil
 }
 
+// addCoWatchCandidates adds the scenes with the highest co-watch scores to
+// the sampled candidates, so that they are scored even when not sampled.
+func (s *Scorer) addCoWatchCandidates(ctx context.Context, scenes []*models.Scene) ([]*models.Scene, error) {
+	if len(s.coWatch) == 0 || s.coWatchWeight <= 0 {
+		return scenes, nil
+	}
+
+	have := make(map[int]bool, len(scenes))
+	for _, scene := range scenes {
+		have[scene.ID] = true
+	}
+
+	var ids []int
+	for id := range s.coWatch {
+		if !have[id] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if s.coWatch[ids[i]] != s.coWatch[ids[j]] {
+			return s.coWatch[ids[i]] > s.coWatch[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	if len(ids) > coWatchCandidateLimit {
+		ids = ids[:coWatchCandidateLimit]
+	}
+	if len(ids) == 0 {
+		return scenes, nil
+	}
+
+	extra, err := s.sceneReader.FindMany(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, scene := range extra {
+		if scene != nil {
+			scenes = append(scenes, scene)
+		}
+	}
+
+	return scenes, nil
+}
+
 // RecommendUnwatchedScenes is a convenience wrapper for RecommendScenes with includeWatched=false
 func (s *Scorer) RecommendUnwatchedScenes(ctx context.Context, limit int, minScore float64, tagW, perfW, studioW float64) ([]models.RecommendationResult, error) {
 	return s.RecommendScenes(ctx, limit, minScore, false, tagW, perfW, studioW)
@@ -360,20 +470,11 @@ func (s *Scorer) SimilarScenes(ctx context.Context, sceneID int, limit int) ([]m
 			continue
 		}
 
-		score, reason := tempScorer.ScoreScene(ctx, scene, 0.5, 0.3, 0.2)
-		if score < 0.05 {
+		rec := tempScorer.ExplainScene(ctx, scene, 0.5, 0.3, 0.2)
+		if rec.Score < 0.05 {
 			continue
 		}
 
-		rec := models.RecommendationResult{
-			Type:   "scene",
-			ID:     strconv.Itoa(scene.ID),
-			Name:   scene.GetTitle(),
-			Score:  score,
-			Reason: reason,
-			Scene:  scene,
-		}
-
 		recommendations = append(recommendations, rec)
 	}
 
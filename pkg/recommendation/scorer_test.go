@@ -231,6 +231,68 @@ func TestScoreScene_NoMatchingWeights(t *testing.T) {
 	assert.Equal(t, "", reason)
 }
 
+func TestExplainScene_Breakdown(t *testing.T) {
+	studioID := 5
+	profile := &ProfileData{
+		TagWeights:       map[int]float64{1: 0.8, 2: 0.6},
+		PerformerWeights: map[int]float64{10: 1.0},
+		StudioWeights:    map[int]float64{5: 0.9},
+	}
+
+	mockSceneReader := &mocks.SceneReaderWriter{}
+	scorer := NewScorer(profile, mockSceneReader, nil, nil, nil)
+	scorer.SetCoWatch(map[int]float64{1: 0.5}, 0.2)
+
+	scene := createMockScene(1, []int{1, 2, 3}, []int{10}, &studioID)
+
+	result := scorer.ExplainScene(context.Background(), scene, 0.5, 0.3, 0.2)
+	breakdown := result.Breakdown
+
+	// the factors sum to the score
+	var total float64
+	contributions := make(map[string]float64)
+	for _, f := range breakdown.Factors {
+		total += f.Contribution
+		contributions[f.Name] = f.Contribution
+	}
+	assert.InDelta(t, result.Score, total, 0.0001)
+	assert.InDelta(t, 0.35, contributions["tags"], 0.0001)
+	assert.InDelta(t, 0.3, contributions["performers"], 0.0001)
+	assert.InDelta(t, 0.18, contributions["studio"], 0.0001)
+	assert.InDelta(t, 0.1, contributions["co_watch"], 0.0001)
+
+	assert.Equal(t, []models.RecommendationMatch{{ID: 1, Weight: 0.8}, {ID: 2, Weight: 0.6}}, breakdown.Tags)
+	assert.Equal(t, []models.RecommendationMatch{{ID: 10, Weight: 1.0}}, breakdown.Performers)
+	assert.Equal(t, &models.RecommendationMatch{ID: 5, Weight: 0.9}, breakdown.Studio)
+	assert.Nil(t, breakdown.VisualSimilarity)
+	assert.Equal(t, 0.0, breakdown.WatchedPenalty)
+
+	// ScoreScene returns the same score
+	score, _ := scorer.ScoreScene(context.Background(), scene, 0.5, 0.3, 0.2)
+	assert.Equal(t, result.Score, score)
+}
+
+func TestExplainScene_WatchedPenalty(t *testing.T) {
+	profile := &ProfileData{
+		TagWeights: map[int]float64{1: 0.8},
+	}
+
+	mockSceneReader := &mocks.SceneReaderWriter{}
+	scorer := NewScorer(profile, mockSceneReader, nil, nil, nil)
+	scorer.SetWatchedPenalty(0.5)
+
+	unwatched := createMockScene(1, []int{1}, []int{}, nil)
+	result := scorer.ExplainScene(context.Background(), unwatched, 0.5, 0.3, 0.2)
+	assert.InDelta(t, 0.4, result.Score, 0.0001)
+	assert.Equal(t, 0.0, result.Breakdown.WatchedPenalty)
+
+	watched := createMockScene(2, []int{1}, []int{}, nil)
+	watched.PlayDuration = 120
+	result = scorer.ExplainScene(context.Background(), watched, 0.5, 0.3, 0.2)
+	assert.InDelta(t, 0.2, result.Score, 0.0001)
+	assert.InDelta(t, 0.2, result.Breakdown.WatchedPenalty, 0.0001)
+}
+
 // --- Performer Scoring Tests ---
 
 func TestScorePerformer_NilProfile(t *testing.T) {
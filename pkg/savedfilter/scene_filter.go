@@ -0,0 +1,141 @@
+package savedfilter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+var ErrNotSceneFilter = errors.New("saved filter is not a scene filter")
+
+// SceneFilter converts the criteria of a saved scene filter into a
+// SceneFilterType. Saved filters store criteria in the format used by the
+// UI, where each criterion is keyed by its filter field and holds a modifier
+// and a UI-specific value. Only the object filter is converted; the search
+// term and sort of the saved filter are ignored.
+func SceneFilter(f *models.SavedFilter) (*models.SceneFilterType, error) {
+	if f.Mode != models.FilterModeScenes {
+		return nil, ErrNotSceneFilter
+	}
+
+	fields := jsonFields(reflect.TypeOf(models.SceneFilterType{}))
+
+	input := make(map[string]interface{}, len(f.ObjectFilter))
+	for key, saved := range f.ObjectFilter {
+		t, found := fields[key]
+		if !found {
+			return nil, fmt.Errorf("unsupported criterion %q", key)
+		}
+
+		v, err := criterionInput(t, saved)
+		if err != nil {
+			return nil, fmt.Errorf("converting criterion %q: %w", key, err)
+		}
+		input[key] = v
+	}
+
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &models.SceneFilterType{}
+	if err := json.Unmarshal(data, ret); err != nil {
+		return nil, fmt.Errorf("decoding scene filter: %w", err)
+	}
+
+	return ret, nil
+}
+
+// jsonFields returns the types of the fields of t, keyed by json name.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	ret := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous {
+			// operator fields cannot be saved by the UI
+			continue
+		}
+
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		ret[name] = f.Type
+	}
+	return ret
+}
+
+// criterionInput converts a saved criterion into the value of the filter
+// field of type t.
+func criterionInput(t reflect.Type, saved interface{}) (interface{}, error) {
+	c, ok := saved.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid saved criterion %v", saved)
+	}
+
+	value := c["value"]
+
+	switch t.Elem().Kind() {
+	case reflect.Bool:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			return strconv.ParseBool(v)
+		}
+		return nil, fmt.Errorf("invalid boolean value %v", value)
+	case reflect.String:
+		return value, nil
+	}
+
+	ret := map[string]interface{}{
+		"modifier": c["modifier"],
+	}
+
+	switch v := value.(type) {
+	case nil:
+		// null and not null criteria have no value
+	case []interface{}:
+		// list of labelled IDs
+		ret["value"] = labelledIDs(v)
+	case map[string]interface{}:
+		if items, ok := v["items"].([]interface{}); ok {
+			// hierarchical list of labelled IDs
+			ret["value"] = labelledIDs(items)
+			if excluded, ok := v["excluded"].([]interface{}); ok {
+				ret["excludes"] = labelledIDs(excluded)
+			}
+			ret["depth"] = v["depth"]
+		} else if _, ok := v["stashID"]; ok {
+			ret["endpoint"] = v["endpoint"]
+			ret["stash_id"] = v["stashID"]
+		} else {
+			// number, date and timestamp values
+			for k, vv := range v {
+				ret[k] = vv
+			}
+		}
+	default:
+		ret["value"] = v
+	}
+
+	return ret, nil
+}
+
+func labelledIDs(l []interface{}) []interface{} {
+	ret := make([]interface{}, 0, len(l))
+	for _, v := range l {
+		if m, ok := v.(map[string]interface{}); ok {
+			ret = append(ret, m["id"])
+		} else {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
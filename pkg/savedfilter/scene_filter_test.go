@@ -0,0 +1,114 @@
+package savedfilter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSceneFilter(t *testing.T) {
+	// criteria in the format saved by the UI
+	const savedObjectFilter = `{
+		"organized": {"modifier": "EQUALS", "value": "true"},
+		"title": {"modifier": "INCLUDES", "value": "foo"},
+		"rating100": {"modifier": "GREATER_THAN", "value": {"value": 60}},
+		"performers": {"modifier": "INCLUDES_ALL", "value": {"items": [{"id": "1", "label": "a"}], "excluded": [{"id": "2", "label": "b"}]}},
+		"tags": {"modifier": "INCLUDES", "value": {"items": [{"id": "3", "label": "c"}], "excluded": [], "depth": -1}},
+		"galleries": {"modifier": "INCLUDES", "value": [{"id": "4", "label": "d"}]},
+		"stash_id_endpoint": {"modifier": "NOT_NULL", "value": {"endpoint": "", "stashID": ""}},
+		"is_missing": {"modifier": "EQUALS", "value": "cover"}
+	}`
+
+	var objectFilter map[string]interface{}
+	if err := json.Unmarshal([]byte(savedObjectFilter), &objectFilter); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := SceneFilter(&models.SavedFilter{
+		Mode:         models.FilterModeScenes,
+		ObjectFilter: objectFilter,
+	})
+	if err != nil {
+		t.Fatalf("SceneFilter() error = %v", err)
+	}
+
+	allDepths := -1
+	emptyString := ""
+	organized := true
+	isMissing := "cover"
+	want := &models.SceneFilterType{
+		Organized: &organized,
+		Title: &models.StringCriterionInput{
+			Value:    "foo",
+			Modifier: models.CriterionModifierIncludes,
+		},
+		Rating100: &models.IntCriterionInput{
+			Value:    60,
+			Modifier: models.CriterionModifierGreaterThan,
+		},
+		Performers: &models.MultiCriterionInput{
+			Value:    []string{"1"},
+			Excludes: []string{"2"},
+			Modifier: models.CriterionModifierIncludesAll,
+		},
+		Tags: &models.HierarchicalMultiCriterionInput{
+			Value:    []string{"3"},
+			Excludes: []string{},
+			Depth:    &allDepths,
+			Modifier: models.CriterionModifierIncludes,
+		},
+		Galleries: &models.MultiCriterionInput{
+			Value:    []string{"4"},
+			Modifier: models.CriterionModifierIncludes,
+		},
+		StashIDEndpoint: &models.StashIDCriterionInput{
+			Endpoint: &emptyString,
+			StashID:  &emptyString,
+			Modifier: models.CriterionModifierNotNull,
+		},
+		IsMissing: &isMissing,
+	}
+
+	assert.Equal(t, want, got)
+}
+
+func TestSceneFilter_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter models.SavedFilter
+	}{
+		{
+			"not a scene filter",
+			models.SavedFilter{
+				Mode: models.FilterModeImages,
+			},
+		},
+		{
+			"unsupported criterion",
+			models.SavedFilter{
+				Mode: models.FilterModeScenes,
+				ObjectFilter: map[string]interface{}{
+					"unknown": map[string]interface{}{"modifier": "EQUALS", "value": "true"},
+				},
+			},
+		},
+		{
+			"invalid value",
+			models.SavedFilter{
+				Mode: models.FilterModeScenes,
+				ObjectFilter: map[string]interface{}{
+					"organized": map[string]interface{}{"modifier": "EQUALS", "value": "maybe"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SceneFilter(&tt.filter)
+			assert.Error(t, err)
+		})
+	}
+}
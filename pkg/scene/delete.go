@@ -70,6 +70,18 @@ func (d *FileDeleter) MarkGeneratedFiles(scene *models.Scene) error {
 		files = append(files, vttPath)
 	}
 
+	bifPath := d.Paths.Scene.GetTrickPlayBIFPath(sceneHash)
+	exists, _ = fsutil.FileExists(bifPath)
+	if exists {
+		files = append(files, bifPath)
+	}
+
+	iframePath := d.Paths.Scene.GetTrickPlayIFramePath(sceneHash)
+	exists, _ = fsutil.FileExists(iframePath)
+	if exists {
+		files = append(files, iframePath)
+	}
+
 	heatmapPath := d.Paths.Scene.GetInteractiveHeatmapPath(sceneHash)
 	exists, _ = fsutil.FileExists(heatmapPath)
 	if exists {
@@ -42,6 +42,9 @@ type ScenePaths interface {
 	GetSpriteImageFilePath(checksum string) string
 	GetSpriteVttFilePath(checksum string) string
 
+	GetTrickPlayBIFPath(checksum string) string
+	GetTrickPlayIFramePath(checksum string) string
+
 	GetTranscodePath(checksum string) string
 }
 
@@ -0,0 +1,101 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/ffmpeg/transcoder"
+	"github.com/stashapp/stash/pkg/fsutil"
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+type OptimizedVersionOptions struct {
+	// Width and Height scale the video if either is non-zero
+	Width  int
+	Height int
+
+	VideoCodec ffmpeg.VideoCodec
+	// VideoBitrate is the target bit rate of the video in kbps. 0 encodes at
+	// a constant quality instead.
+	VideoBitrate int
+	// AudioBitrate is the bit rate of the audio in kbps. 0 for the default.
+	AudioBitrate int
+	// NoAudio drops the audio, for files whose audio ffmpeg cannot decode
+	NoAudio bool
+}
+
+// OptimizedVersion transcodes input to an MP4 at output, which is replaced if
+// it exists.
+func (g Generator) OptimizedVersion(ctx context.Context, input string, output string, options OptimizedVersionOptions) error {
+	lockCtx := g.LockManager.ReadLock(ctx, input)
+	defer lockCtx.Cancel()
+
+	if err := g.generateFile(lockCtx, g.ScenePaths, mp4Pattern, output, g.optimizedVersion(input, options)); err != nil {
+		return err
+	}
+
+	logger.Debug("created optimized version: ", output)
+
+	return nil
+}
+
+func (g Generator) optimizedVersion(input string, options OptimizedVersionOptions) generateFn {
+	return func(lockCtx *fsutil.LockContext, tmpFn string) error {
+		var videoArgs ffmpeg.Args
+		if options.Width != 0 || options.Height != 0 {
+			var videoFilter ffmpeg.VideoFilter
+			videoFilter = videoFilter.ScaleDimensions(options.Width, options.Height)
+			videoArgs = videoArgs.VideoFilter(videoFilter)
+		}
+
+		videoArgs = append(videoArgs,
+			"-pix_fmt", "yuv420p",
+			"-preset", "medium",
+		)
+
+		if options.VideoCodec == ffmpeg.VideoCodecLibX265 {
+			// tag as hvc1 so that Apple and Quest players recognise it
+			videoArgs = append(videoArgs, "-tag:v", "hvc1")
+		} else {
+			videoArgs = append(videoArgs, "-profile:v", "high")
+		}
+
+		if options.VideoBitrate > 0 {
+			videoArgs = append(videoArgs,
+				"-b:v", fmt.Sprintf("%dk", options.VideoBitrate),
+				"-maxrate", fmt.Sprintf("%dk", options.VideoBitrate*3/2),
+				"-bufsize", fmt.Sprintf("%dk", options.VideoBitrate*2),
+			)
+		} else {
+			videoArgs = append(videoArgs, "-crf", "23")
+		}
+
+		// the audio is skipped if the codec is not set
+		var audioCodec ffmpeg.AudioCodec
+		var audioArgs ffmpeg.Args
+		if !options.NoAudio {
+			audioCodec = ffmpeg.AudioCodecAAC
+			audioBitrate := options.AudioBitrate
+			if audioBitrate == 0 {
+				audioBitrate = 128
+			}
+			audioArgs = append(audioArgs, "-b:a", fmt.Sprintf("%dk", audioBitrate))
+		}
+
+		args := transcoder.Transcode(input, transcoder.TranscodeOptions{
+			OutputPath: tmpFn,
+			Format:     ffmpeg.FormatMP4,
+			VideoCodec: options.VideoCodec,
+			VideoArgs:  videoArgs,
+			AudioCodec: audioCodec,
+			AudioArgs:  audioArgs,
+
+			ExtraInputArgs: g.FFMpegConfig.GetTranscodeInputArgs(),
+			// move the index to the start so that the version can be streamed
+			ExtraOutputArgs: append([]string{"-movflags", "+faststart"}, g.FFMpegConfig.GetTranscodeOutputArgs()...),
+		})
+
+		return g.generate(lockCtx, args)
+	}
+}
@@ -0,0 +1,297 @@
+package generate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/fsutil"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// MimeBIF is the content type of BIF files. BIF has no registered type;
+// players recognise it by its extension.
+const MimeBIF = "application/octet-stream"
+
+const (
+	bifPattern = "*.bif"
+
+	trickPlayJPEGQuality = 80
+	// trickPlayCRF is the quality of the I-frames. They are only seen while
+	// scrubbing, so they are kept small.
+	trickPlayCRF = "28"
+)
+
+// bifMagic starts every BIF file.
+var bifMagic = []byte{0x89, 'B', 'I', 'F', '\r', '\n', 0x1a, '\n'}
+
+// SpriteFrame is a frame of a sprite, as listed in its VTT file.
+type SpriteFrame struct {
+	// Start and End are in seconds
+	Start float64
+	End   float64
+	Rect  image.Rectangle
+}
+
+// ReadSpriteVTT returns the frames listed in a sprite VTT file, in order.
+func ReadSpriteVTT(vttPath string) ([]SpriteFrame, error) {
+	f, err := os.Open(vttPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var ret []SpriteFrame
+	var cue *SpriteFrame
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if start, end, found := strings.Cut(line, "-->"); found {
+			cue = &SpriteFrame{}
+			if cue.Start, err = utils.ParseVTTTime(start); err != nil {
+				return nil, err
+			}
+			// settings may follow the end time
+			if cue.End, err = utils.ParseVTTTime(strings.Fields(end)[0]); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		_, fragment, found := strings.Cut(line, "#xywh=")
+		if !found || cue == nil {
+			continue
+		}
+
+		var x, y, w, h int
+		if _, err := fmt.Sscanf(fragment, "%d,%d,%d,%d", &x, &y, &w, &h); err != nil {
+			return nil, fmt.Errorf("invalid sprite fragment %q: %w", fragment, err)
+		}
+		cue.Rect = image.Rect(x, y, x+w, y+h)
+		ret = append(ret, *cue)
+		cue = nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+type subImager interface {
+	SubImage(r image.Rectangle) image.Image
+}
+
+// spriteFrameImages returns the frames of a sprite and their images.
+func spriteFrameImages(spritePath string, vttPath string) ([]SpriteFrame, []image.Image, error) {
+	frames, err := ReadSpriteVTT(vttPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(frames) == 0 {
+		return nil, nil, fmt.Errorf("no frames in %s", vttPath)
+	}
+
+	f, err := os.Open(spritePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	sprite, _, err := image.Decode(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decoding %s: %w", spritePath, err)
+	}
+	si, ok := sprite.(subImager)
+	if !ok {
+		return nil, nil, fmt.Errorf("cannot crop %s", spritePath)
+	}
+
+	images := make([]image.Image, len(frames))
+	for i, fr := range frames {
+		r := fr.Rect.Intersect(sprite.Bounds())
+		if r.Empty() {
+			return nil, nil, fmt.Errorf("frame %d is outside of %s", i, spritePath)
+		}
+		images[i] = si.SubImage(r)
+	}
+
+	return frames, images, nil
+}
+
+// TrickPlay generates the trick play files of a scene from its sprite: a BIF
+// archive for Roku-style players and a fragmented MP4 of I-frames for HLS
+// I-frame playlists. The sprite and its VTT must already exist.
+func (g Generator) TrickPlay(ctx context.Context, hash string) error {
+	spritePath := g.ScenePaths.GetSpriteImageFilePath(hash)
+	vttPath := g.ScenePaths.GetSpriteVttFilePath(hash)
+	bifPath := g.ScenePaths.GetTrickPlayBIFPath(hash)
+	iframePath := g.ScenePaths.GetTrickPlayIFramePath(hash)
+
+	lockCtx := g.LockManager.ReadLock(ctx, spritePath)
+	defer lockCtx.Cancel()
+
+	bifExists, _ := fsutil.FileExists(bifPath)
+	iframeExists, _ := fsutil.FileExists(iframePath)
+	if !g.Overwrite && bifExists && iframeExists {
+		return nil
+	}
+
+	frames, images, err := spriteFrameImages(spritePath, vttPath)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("[generator] generating trick play files for %s", hash)
+
+	if g.Overwrite || !bifExists {
+		if err := g.generateFile(lockCtx, g.ScenePaths, bifPattern, bifPath, g.trickPlayBIF(frames, images)); err != nil {
+			return fmt.Errorf("generating BIF: %w", err)
+		}
+	}
+
+	if g.Overwrite || !iframeExists {
+		if err := g.generateFile(lockCtx, g.ScenePaths, mp4Pattern, iframePath, g.trickPlayIFrames(frames, images)); err != nil {
+			return fmt.Errorf("generating I-frames: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// trickPlayBIF writes a BIF archive of the frames. See
+// https://developer.roku.com/docs/developer-program/media-playback/trick-mode/bif-file-creation.md
+func (g Generator) trickPlayBIF(frames []SpriteFrame, images []image.Image) generateFn {
+	return func(lockCtx *fsutil.LockContext, tmpFn string) error {
+		data := make([][]byte, len(images))
+		for i, img := range images {
+			var buf bytes.Buffer
+			if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: trickPlayJPEGQuality}); err != nil {
+				return err
+			}
+			data[i] = buf.Bytes()
+		}
+
+		const headerSize = 64
+		indexSize := (len(data) + 1) * 8
+
+		var buf bytes.Buffer
+		buf.Write(bifMagic)
+		le := binary.LittleEndian
+		_ = binary.Write(&buf, le, uint32(0)) // version
+		_ = binary.Write(&buf, le, uint32(len(data)))
+		// timestamps are in ms
+		_ = binary.Write(&buf, le, uint32(1))
+		buf.Write(make([]byte, headerSize-buf.Len()))
+
+		offset := headerSize + indexSize
+		for i, d := range data {
+			// the first frame stands for everything before the second
+			var ts uint32
+			if i > 0 {
+				ts = uint32(math.Round(frames[i].Start * 1000))
+			}
+			_ = binary.Write(&buf, le, ts)
+			_ = binary.Write(&buf, le, uint32(offset))
+			offset += len(d)
+		}
+		_ = binary.Write(&buf, le, uint32(0xffffffff))
+		_ = binary.Write(&buf, le, uint32(offset))
+
+		for _, d := range data {
+			buf.Write(d)
+		}
+
+		return os.WriteFile(tmpFn, buf.Bytes(), 0644)
+	}
+}
+
+// trickPlayIFrames encodes the frames into a fragmented MP4 with one I-frame
+// per fragment, so that an I-frame playlist can address each by byte range.
+// Each frame lasts until the next, and the first from the start of the
+// scene.
+func (g Generator) trickPlayIFrames(frames []SpriteFrame, images []image.Image) generateFn {
+	return func(lockCtx *fsutil.LockContext, tmpFn string) error {
+		var tmpFiles []string
+		defer func() { removeFiles(tmpFiles) }()
+
+		concatFile, err := g.ScenePaths.TempFile(txtPattern)
+		if err != nil {
+			return fmt.Errorf("creating concat file: %w", err)
+		}
+		tmpFiles = append(tmpFiles, concatFile.Name())
+
+		w := bufio.NewWriter(concatFile)
+		var last string
+		for i, img := range images {
+			frameFile, err := g.ScenePaths.TempFile(jpgPattern)
+			if err != nil {
+				concatFile.Close()
+				return fmt.Errorf("creating frame file: %w", err)
+			}
+			tmpFiles = append(tmpFiles, frameFile.Name())
+
+			err = jpeg.Encode(frameFile, img, &jpeg.Options{Quality: 95})
+			frameFile.Close()
+			if err != nil {
+				concatFile.Close()
+				return fmt.Errorf("writing frame file: %w", err)
+			}
+
+			duration := frames[i].End - frames[i].Start
+			if i == 0 {
+				duration = frames[i].End
+			}
+
+			// files in concat file should be relative to concat
+			last = filepath.Base(frameFile.Name())
+			fmt.Fprintf(w, "file '%s'\nduration %f\n", last, duration)
+		}
+		// the concat demuxer ignores the duration of the last file unless it
+		// is listed again
+		fmt.Fprintf(w, "file '%s'\n", last)
+
+		err = w.Flush()
+		concatFile.Close()
+		if err != nil {
+			return fmt.Errorf("writing concat file: %w", err)
+		}
+
+		var videoFilter ffmpeg.VideoFilter
+		// yuv420p needs even dimensions
+		videoFilter = videoFilter.Append("scale=trunc(iw/2)*2:trunc(ih/2)*2")
+
+		var args ffmpeg.Args
+		args = args.LogLevel(ffmpeg.LogLevelError).Overwrite()
+		args = args.Format(ffmpeg.FormatConcat)
+		args = append(args, "-safe", "0")
+		args = args.Input(concatFile.Name())
+		args = args.VSync(ffmpeg.VSyncMethodPassthrough)
+		args = args.VideoFilter(videoFilter)
+		args = args.VideoCodec(ffmpeg.VideoCodecLibX264)
+		args = append(args,
+			"-pix_fmt", "yuv420p",
+			"-crf", trickPlayCRF,
+			// every frame is a keyframe in a fragment of its own
+			"-g", "1",
+			"-bf", "0",
+			"-movflags", "frag_keyframe+empty_moov+default_base_moof",
+		)
+		args = args.Format(ffmpeg.FormatMP4)
+		args = args.Output(tmpFn)
+
+		return g.generate(lockCtx, args)
+	}
+}
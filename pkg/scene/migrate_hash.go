@@ -37,6 +37,14 @@ func MigrateHash(p *paths.Paths, oldHash string, newHash string) {
 	migrateSceneFiles(oldPath, newPath)
 	migrateVttFile(newVttPath, oldPath, newPath)
 
+	oldPath = scenePaths.GetTrickPlayBIFPath(oldHash)
+	newPath = scenePaths.GetTrickPlayBIFPath(newHash)
+	migrateSceneFiles(oldPath, newPath)
+
+	oldPath = scenePaths.GetTrickPlayIFramePath(oldHash)
+	newPath = scenePaths.GetTrickPlayIFramePath(newHash)
+	migrateSceneFiles(oldPath, newPath)
+
 	oldPath = scenePaths.GetInteractiveHeatmapPath(oldHash)
 	newPath = scenePaths.GetInteractiveHeatmapPath(newHash)
 	migrateSceneFiles(oldPath, newPath)
@@ -3,6 +3,9 @@ package scheduler
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
 	"sync"
 	"time"
 
@@ -14,24 +17,115 @@ import (
 type ScheduledTaskType string
 
 const (
-	ScheduledTaskTypeScan     ScheduledTaskType = "SCAN"
-	ScheduledTaskTypeGenerate ScheduledTaskType = "GENERATE"
-	ScheduledTaskTypeAutoTag  ScheduledTaskType = "AUTO_TAG"
-	ScheduledTaskTypeClean    ScheduledTaskType = "CLEAN"
-	ScheduledTaskTypeOptimise ScheduledTaskType = "OPTIMISE"
-	ScheduledTaskTypePlugin   ScheduledTaskType = "PLUGIN"
+	ScheduledTaskTypeScan           ScheduledTaskType = "SCAN"
+	ScheduledTaskTypeGenerate       ScheduledTaskType = "GENERATE"
+	ScheduledTaskTypeAutoTag        ScheduledTaskType = "AUTO_TAG"
+	ScheduledTaskTypeClean          ScheduledTaskType = "CLEAN"
+	ScheduledTaskTypeOptimise       ScheduledTaskType = "OPTIMISE"
+	ScheduledTaskTypePlugin         ScheduledTaskType = "PLUGIN"
+	ScheduledTaskTypeRebuildProfile ScheduledTaskType = "REBUILD_PROFILE"
+	ScheduledTaskTypeRebuildCoWatch ScheduledTaskType = "REBUILD_CO_WATCH"
+	ScheduledTaskTypeIdentify       ScheduledTaskType = "IDENTIFY"
+	ScheduledTaskTypeBackup         ScheduledTaskType = "BACKUP"
+	ScheduledTaskTypeExport         ScheduledTaskType = "EXPORT"
+	ScheduledTaskTypeStashBoxTag    ScheduledTaskType = "STASH_BOX_TAG"
+	ScheduledTaskTypeAPIHubRelink   ScheduledTaskType = "API_HUB_RELINK"
 )
 
+const (
+	// DefaultRetryDelay is the wait before the first retry of a failed task
+	// that does not set its own. Each further retry waits twice as long as
+	// the one before, up to maxRetryDelay.
+	DefaultRetryDelay = time.Minute
+	maxRetryDelay     = time.Hour
+)
+
+// ErrTaskRunning is returned when a task that skips overlapping runs is asked
+// to run while a previous run is still active.
+var ErrTaskRunning = errors.New("previous run of the task is still active")
+
+// ErrJobCancelled is returned by TaskExecutor.WaitJob for a job that was
+// cancelled. A cancelled run is not retried.
+var ErrJobCancelled = errors.New("job was cancelled")
+
 // ScheduledTask represents a task that runs on a schedule
 type ScheduledTask struct {
-	ID           string            `json:"id"`
-	Name         string            `json:"name"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// CronSchedule may be empty for a task that only runs when started
+	// manually or as part of another task's chain.
 	CronSchedule string            `json:"cronSchedule"`
 	TaskType     ScheduledTaskType `json:"taskType"`
 	Enabled      bool              `json:"enabled"`
 	Options      json.RawMessage   `json:"options,omitempty"`
 	LastRun      *time.Time        `json:"lastRun,omitempty"`
 	NextRun      *time.Time        `json:"nextRun,omitempty"`
+
+	// Then lists the IDs of the tasks to run, one after the other, once a run
+	// of this task has succeeded. The chain stops at the first that fails.
+	// Disabled tasks in the chain are skipped.
+	Then []string `json:"then,omitempty"`
+	// Retries is the number of times a failed run is retried
+	Retries int `json:"retries,omitempty"`
+	// RetryDelay is the wait before the first retry, in seconds. 0 uses
+	// DefaultRetryDelay.
+	RetryDelay int `json:"retryDelay,omitempty"`
+	// SkipIfRunning skips a run while the previous one, including its
+	// retries, is still active.
+	SkipIfRunning bool `json:"skipIfRunning,omitempty"`
+}
+
+// retryDelay returns the wait before retrying after the given failed attempt.
+func (t ScheduledTask) retryDelay(attempt int) time.Duration {
+	d := DefaultRetryDelay
+	if t.RetryDelay > 0 {
+		d = time.Duration(t.RetryDelay) * time.Second
+	}
+	for i := 1; i < attempt && d < maxRetryDelay; i++ {
+		d *= 2
+	}
+	return min(d, maxRetryDelay)
+}
+
+// TaskRunTrigger indicates what started a run
+type TaskRunTrigger string
+
+const (
+	TaskRunTriggerSchedule TaskRunTrigger = "SCHEDULE"
+	TaskRunTriggerManual   TaskRunTrigger = "MANUAL"
+	TaskRunTriggerChain    TaskRunTrigger = "CHAIN"
+)
+
+// TaskRunStatus is the outcome of a run
+type TaskRunStatus string
+
+const (
+	TaskRunStatusSucceeded TaskRunStatus = "SUCCEEDED"
+	TaskRunStatusFailed    TaskRunStatus = "FAILED"
+	TaskRunStatusCancelled TaskRunStatus = "CANCELLED"
+	TaskRunStatusSkipped   TaskRunStatus = "SKIPPED"
+)
+
+// TaskRun records one attempt at running a scheduled task. A run that is
+// retried is recorded once per attempt.
+type TaskRun struct {
+	ID       int               `json:"id"`
+	TaskID   string            `json:"taskId"`
+	TaskName string            `json:"taskName"`
+	TaskType ScheduledTaskType `json:"taskType"`
+	Trigger  TaskRunTrigger    `json:"trigger"`
+	Attempt  int               `json:"attempt"`
+	// JobID is the job the run started, nil if it failed to start one
+	JobID     *int          `json:"jobId,omitempty"`
+	Status    TaskRunStatus `json:"status"`
+	Error     string        `json:"error,omitempty"`
+	StartedAt time.Time     `json:"startedAt"`
+	EndedAt   time.Time     `json:"endedAt"`
+}
+
+// Duration returns how long the run took
+func (r TaskRun) Duration() time.Duration {
+	return r.EndedAt.Sub(r.StartedAt)
 }
 
 // ScheduledTaskCreateInput is the input for creating a scheduled task
@@ -60,7 +154,19 @@ type TaskExecutor interface {
 	ExecuteAutoTag(ctx context.Context, options json.RawMessage) (int, error)
 	ExecuteClean(ctx context.Context, options json.RawMessage) (int, error)
 	ExecuteOptimise(ctx context.Context) (int, error)
+	ExecuteRebuildProfile(ctx context.Context) (int, error)
+	ExecuteRebuildCoWatch(ctx context.Context) (int, error)
 	ExecutePlugin(ctx context.Context, options json.RawMessage) (int, error)
+	ExecuteIdentify(ctx context.Context, options json.RawMessage) (int, error)
+	ExecuteBackup(ctx context.Context) (int, error)
+	ExecuteExport(ctx context.Context) (int, error)
+	ExecuteStashBoxTag(ctx context.Context, options json.RawMessage) (int, error)
+	ExecuteAPIHubRelink(ctx context.Context) (int, error)
+
+	// WaitJob blocks until the job has ended. It returns nil if the job
+	// finished, ErrJobCancelled if it was cancelled, and the job's error if
+	// it failed.
+	WaitJob(ctx context.Context, jobID int) error
 }
 
 // TaskStorage is the interface for persisting scheduled tasks
@@ -70,28 +176,45 @@ type TaskStorage interface {
 	UpdateTaskLastRun(taskID string, lastRun time.Time) error
 }
 
+// RunHistory is the interface for persisting the runs of scheduled tasks
+type RunHistory interface {
+	RecordRun(ctx context.Context, run *TaskRun) error
+	// ListRuns returns the most recent runs, newest first, of the task with
+	// the given ID or of every task if it is empty.
+	ListRuns(ctx context.Context, taskID string, limit int) ([]TaskRun, error)
+	DeleteRuns(ctx context.Context, taskID string) error
+}
+
 // Scheduler manages scheduled task execution
 type Scheduler struct {
 	cron     *cron.Cron
 	executor TaskExecutor
 	storage  TaskStorage
+	history  RunHistory
 	entries  map[string]cron.EntryID
 	tasks    map[string]*ScheduledTask
-	mu       sync.RWMutex
-	ctx      context.Context
-	cancel   context.CancelFunc
+	// active holds the IDs of the tasks with a run in progress
+	active map[string]int
+	// after is time.After, replaced in tests
+	after  func(time.Duration) <-chan time.Time
+	mu     sync.RWMutex
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // New creates a new Scheduler instance
-func New(executor TaskExecutor, storage TaskStorage) *Scheduler {
+func New(executor TaskExecutor, storage TaskStorage, history RunHistory) *Scheduler {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &Scheduler{
 		cron:     cron.New(cron.WithSeconds()),
 		executor: executor,
 		storage:  storage,
+		history:  history,
 		entries:  make(map[string]cron.EntryID),
 		tasks:    make(map[string]*ScheduledTask),
+		active:   make(map[string]int),
+		after:    time.After,
 		ctx:      ctx,
 		cancel:   cancel,
 	}
@@ -133,6 +256,10 @@ func (s *Scheduler) AddTask(task ScheduledTask) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if err := s.validate(task); err != nil {
+		return err
+	}
+
 	s.tasks[task.ID] = &task
 
 	if task.Enabled {
@@ -149,6 +276,10 @@ func (s *Scheduler) UpdateTask(task ScheduledTask) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if err := s.validate(task); err != nil {
+		return err
+	}
+
 	// Remove existing cron entry if present
 	if entryID, ok := s.entries[task.ID]; ok {
 		s.cron.Remove(entryID)
@@ -166,7 +297,8 @@ func (s *Scheduler) UpdateTask(task ScheduledTask) error {
 	return s.saveTasks()
 }
 
-// RemoveTask removes a scheduled task
+// RemoveTask removes a scheduled task, along with its run history and any
+// place it has in the chains of other tasks
 func (s *Scheduler) RemoveTask(taskID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -177,6 +309,18 @@ func (s *Scheduler) RemoveTask(taskID string) error {
 	}
 	delete(s.tasks, taskID)
 
+	// the chains are replaced rather than edited in place, since runs under
+	// way hold copies of them
+	for _, task := range s.tasks {
+		task.Then = slices.DeleteFunc(slices.Clone(task.Then), func(id string) bool {
+			return id == taskID
+		})
+	}
+
+	if err := s.history.DeleteRuns(s.ctx, taskID); err != nil {
+		logger.Warnf("Failed to delete run history of scheduled task %s: %v", taskID, err)
+	}
+
 	return s.saveTasks()
 }
 
@@ -215,24 +359,108 @@ func (s *Scheduler) ListTasks() []ScheduledTask {
 	return tasks
 }
 
-// RunTask manually triggers a scheduled task
-func (s *Scheduler) RunTask(taskID string) (int, error) {
+// IsRunning returns whether a run of the task is in progress, including one
+// waiting to be retried
+func (s *Scheduler) IsRunning(taskID string) bool {
 	s.mu.RLock()
-	task, ok := s.tasks[taskID]
-	s.mu.RUnlock()
+	defer s.mu.RUnlock()
+
+	return s.active[taskID] > 0
+}
+
+// ListRuns returns the most recent runs, newest first, of the task with the
+// given ID or of every task if it is empty
+func (s *Scheduler) ListRuns(ctx context.Context, taskID string, limit int) ([]TaskRun, error) {
+	return s.history.ListRuns(ctx, taskID, limit)
+}
 
+// RunTask manually triggers a scheduled task. It returns the ID of the job
+// started by the task's first attempt; any retries and chained tasks follow
+// in the background.
+func (s *Scheduler) RunTask(taskID string) (int, error) {
+	t, ok := s.task(taskID)
 	if !ok {
 		return 0, nil
 	}
 
-	return s.executeTask(task)
+	return s.start(t, TaskRunTriggerManual)
 }
 
-// addTaskInternal adds a task to the cron scheduler (must hold lock)
+// task returns a copy of the task with the given ID as it is now, which is
+// safe to use while the task is edited
+func (s *Scheduler) task(taskID string) (ScheduledTask, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	task, ok := s.tasks[taskID]
+	if !ok {
+		return ScheduledTask{}, false
+	}
+
+	ret := *task
+	ret.Then = slices.Clone(task.Then)
+	return ret, true
+}
+
+// validate checks a task's retry settings and that the tasks it chains to
+// exist without the chain leading back to the task (must hold lock)
+func (s *Scheduler) validate(task ScheduledTask) error {
+	if task.Retries < 0 {
+		return errors.New("retries must not be negative")
+	}
+	if task.RetryDelay < 0 {
+		return errors.New("retry delay must not be negative")
+	}
+
+	for _, id := range task.Then {
+		if _, ok := s.tasks[id]; !ok && id != task.ID {
+			return fmt.Errorf("chained task %s not found", id)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var leadsBack func(ids []string) bool
+	leadsBack = func(ids []string) bool {
+		for _, id := range ids {
+			if id == task.ID {
+				return true
+			}
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			if next := s.tasks[id]; next != nil && leadsBack(next.Then) {
+				return true
+			}
+		}
+		return false
+	}
+	if leadsBack(task.Then) {
+		return fmt.Errorf("chain of task %q leads back to itself", task.Name)
+	}
+
+	return nil
+}
+
+// addTaskInternal adds a task to the cron scheduler (must hold lock). A task
+// without a schedule is left out.
 func (s *Scheduler) addTaskInternal(task *ScheduledTask) error {
-	taskCopy := *task
+	if task.CronSchedule == "" {
+		task.NextRun = nil
+		return nil
+	}
+
+	// the task is looked up when it runs, since its chain may have changed
+	// since it was scheduled
+	taskID := task.ID
 	entryID, err := s.cron.AddFunc(task.CronSchedule, func() {
-		s.executeTask(&taskCopy)
+		t, ok := s.task(taskID)
+		if !ok {
+			return
+		}
+		if _, err := s.start(t, TaskRunTriggerSchedule); errors.Is(err, ErrTaskRunning) {
+			logger.Infof("Skipping scheduled task %s: %v", t.Name, err)
+		}
 	})
 	if err != nil {
 		return err
@@ -248,50 +476,239 @@ func (s *Scheduler) addTaskInternal(task *ScheduledTask) error {
 	return nil
 }
 
-// executeTask runs a task and updates its last run time
-func (s *Scheduler) executeTask(task *ScheduledTask) (int, error) {
+// runAttempt is one go at running a task
+type runAttempt struct {
+	jobID   int
+	err     error
+	started time.Time
+}
+
+// start begins a run of task and returns the ID of the job it started. The
+// rest of the run - waiting for the job, retrying it and running the tasks
+// chained after it - carries on in the background.
+func (s *Scheduler) start(task ScheduledTask, trigger TaskRunTrigger) (int, error) {
+	if !s.begin(task) {
+		s.recordSkipped(task, trigger)
+		return 0, ErrTaskRunning
+	}
+
+	a := s.attempt(task)
+	go func() {
+		_ = s.complete(task, trigger, a, map[string]bool{task.ID: true})
+	}()
+
+	return a.jobID, a.err
+}
+
+// run runs task through to the end, returning nil if it succeeded
+func (s *Scheduler) run(task ScheduledTask, trigger TaskRunTrigger, chained map[string]bool) error {
+	if !s.begin(task) {
+		s.recordSkipped(task, trigger)
+		return ErrTaskRunning
+	}
+
+	return s.complete(task, trigger, s.attempt(task), chained)
+}
+
+// begin marks a run of task as active, unless the task skips overlapping runs
+// and one is already in progress
+func (s *Scheduler) begin(task ScheduledTask) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if task.SkipIfRunning && s.active[task.ID] > 0 {
+		return false
+	}
+	s.active[task.ID]++
+	return true
+}
+
+func (s *Scheduler) end(task ScheduledTask) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.active[task.ID]--
+	if s.active[task.ID] <= 0 {
+		delete(s.active, task.ID)
+	}
+}
+
+// complete sees a run through from its first attempt, then runs the tasks
+// chained after it if it succeeded. chained holds the IDs of the tasks that
+// have already run in this chain.
+func (s *Scheduler) complete(task ScheduledTask, trigger TaskRunTrigger, a runAttempt, chained map[string]bool) error {
+	err := s.finish(task, trigger, a)
+	s.end(task)
+
+	if err == nil {
+		s.runChain(task, chained)
+	}
+	return err
+}
+
+// finish waits for each attempt at a run to end, retrying as the task allows,
+// and returns nil once one has succeeded
+func (s *Scheduler) finish(task ScheduledTask, trigger TaskRunTrigger, a runAttempt) error {
+	for n := 1; ; n++ {
+		err := a.err
+		if err == nil {
+			err = s.executor.WaitJob(s.ctx, a.jobID)
+		}
+		if s.ctx.Err() != nil {
+			// the scheduler is stopping; the job may yet finish on its own
+			return s.ctx.Err()
+		}
+
+		s.record(task, trigger, n, a, err)
+
+		if err == nil || errors.Is(err, ErrJobCancelled) || n > task.Retries {
+			return err
+		}
+
+		delay := task.retryDelay(n)
+		logger.Infof("Retrying scheduled task %s in %s (attempt %d of %d)", task.Name, delay, n+1, task.Retries+1)
+
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		case <-s.after(delay):
+		}
+
+		a = s.attempt(task)
+	}
+}
+
+// runChain runs the tasks chained after task one after the other, stopping
+// at the first that does not succeed
+func (s *Scheduler) runChain(task ScheduledTask, chained map[string]bool) {
+	for _, id := range task.Then {
+		t, ok := s.task(id)
+
+		switch {
+		case !ok:
+			logger.Warnf("Scheduled task %s chains to unknown task %s", task.Name, id)
+			return
+		case !t.Enabled:
+			logger.Debugf("Skipping disabled scheduled task %s in the chain of %s", t.Name, task.Name)
+			continue
+		case chained[id]:
+			logger.Debugf("Skipping scheduled task %s in the chain of %s: it has already run", t.Name, task.Name)
+			continue
+		}
+		chained[id] = true
+
+		if err := s.run(t, TaskRunTriggerChain, chained); err != nil {
+			logger.Infof("Stopping the chain of scheduled task %s: %s did not succeed", task.Name, t.Name)
+			return
+		}
+	}
+}
+
+// attempt starts the job for a task and updates its last run time
+func (s *Scheduler) attempt(task ScheduledTask) runAttempt {
 	logger.Infof("Executing scheduled task: %s (%s)", task.Name, task.TaskType)
 
-	var jobID int
-	var err error
+	ret := runAttempt{started: time.Now()}
+	ret.jobID, ret.err = s.executeTask(task)
+	if ret.err != nil {
+		logger.Errorf("Failed to execute scheduled task %s: %v", task.Name, ret.err)
+		return ret
+	}
+
+	// Update last run time
+	s.mu.Lock()
+	if t, ok := s.tasks[task.ID]; ok {
+		t.LastRun = &ret.started
+	}
+	s.mu.Unlock()
+
+	if err := s.storage.UpdateTaskLastRun(task.ID, ret.started); err != nil {
+		logger.Warnf("Failed to update last run time for task %s: %v", task.Name, err)
+	}
+
+	logger.Infof("Scheduled task %s started with job ID %d", task.Name, ret.jobID)
+	return ret
+}
 
+// executeTask starts the job for a task
+func (s *Scheduler) executeTask(task ScheduledTask) (int, error) {
 	switch task.TaskType {
 	case ScheduledTaskTypeScan:
-		jobID, err = s.executor.ExecuteScan(s.ctx, task.Options)
+		return s.executor.ExecuteScan(s.ctx, task.Options)
 	case ScheduledTaskTypeGenerate:
-		jobID, err = s.executor.ExecuteGenerate(s.ctx, task.Options)
+		return s.executor.ExecuteGenerate(s.ctx, task.Options)
 	case ScheduledTaskTypeAutoTag:
-		jobID, err = s.executor.ExecuteAutoTag(s.ctx, task.Options)
+		return s.executor.ExecuteAutoTag(s.ctx, task.Options)
 	case ScheduledTaskTypeClean:
-		jobID, err = s.executor.ExecuteClean(s.ctx, task.Options)
+		return s.executor.ExecuteClean(s.ctx, task.Options)
 	case ScheduledTaskTypeOptimise:
-		jobID, err = s.executor.ExecuteOptimise(s.ctx)
+		return s.executor.ExecuteOptimise(s.ctx)
 	case ScheduledTaskTypePlugin:
-		jobID, err = s.executor.ExecutePlugin(s.ctx, task.Options)
+		return s.executor.ExecutePlugin(s.ctx, task.Options)
+	case ScheduledTaskTypeRebuildProfile:
+		return s.executor.ExecuteRebuildProfile(s.ctx)
+	case ScheduledTaskTypeRebuildCoWatch:
+		return s.executor.ExecuteRebuildCoWatch(s.ctx)
+	case ScheduledTaskTypeIdentify:
+		return s.executor.ExecuteIdentify(s.ctx, task.Options)
+	case ScheduledTaskTypeBackup:
+		return s.executor.ExecuteBackup(s.ctx)
+	case ScheduledTaskTypeExport:
+		return s.executor.ExecuteExport(s.ctx)
+	case ScheduledTaskTypeStashBoxTag:
+		return s.executor.ExecuteStashBoxTag(s.ctx, task.Options)
+	case ScheduledTaskTypeAPIHubRelink:
+		return s.executor.ExecuteAPIHubRelink(s.ctx)
 	default:
-		logger.Warnf("Unknown task type: %s", task.TaskType)
-		return 0, nil
+		return 0, fmt.Errorf("unknown task type: %s", task.TaskType)
 	}
+}
 
-	if err != nil {
-		logger.Errorf("Failed to execute scheduled task %s: %v", task.Name, err)
-		return 0, err
+// record adds an attempt that has ended to the run history
+func (s *Scheduler) record(task ScheduledTask, trigger TaskRunTrigger, n int, a runAttempt, err error) {
+	run := newTaskRun(task, trigger, a.started)
+	run.Attempt = n
+	if a.err == nil {
+		jobID := a.jobID
+		run.JobID = &jobID
 	}
 
-	// Update last run time
-	now := time.Now()
-	s.mu.Lock()
-	if t, ok := s.tasks[task.ID]; ok {
-		t.LastRun = &now
+	switch {
+	case err == nil:
+		run.Status = TaskRunStatusSucceeded
+	case errors.Is(err, ErrJobCancelled):
+		run.Status = TaskRunStatusCancelled
+	default:
+		run.Status = TaskRunStatusFailed
+		run.Error = err.Error()
 	}
-	s.mu.Unlock()
 
-	if err := s.storage.UpdateTaskLastRun(task.ID, now); err != nil {
-		logger.Warnf("Failed to update last run time for task %s: %v", task.Name, err)
+	s.recordRun(&run)
+}
+
+func (s *Scheduler) recordSkipped(task ScheduledTask, trigger TaskRunTrigger) {
+	run := newTaskRun(task, trigger, time.Now())
+	run.Status = TaskRunStatusSkipped
+	run.Error = ErrTaskRunning.Error()
+	s.recordRun(&run)
+}
+
+func (s *Scheduler) recordRun(run *TaskRun) {
+	if err := s.history.RecordRun(s.ctx, run); err != nil {
+		logger.Warnf("Failed to record run of scheduled task %s: %v", run.TaskName, err)
 	}
+}
 
-	logger.Infof("Scheduled task %s started with job ID %d", task.Name, jobID)
-	return jobID, nil
+func newTaskRun(task ScheduledTask, trigger TaskRunTrigger, started time.Time) TaskRun {
+	return TaskRun{
+		TaskID:    task.ID,
+		TaskName:  task.Name,
+		TaskType:  task.TaskType,
+		Trigger:   trigger,
+		Attempt:   1,
+		StartedAt: started,
+		EndedAt:   time.Now(),
+	}
 }
 
 // saveTasks persists all tasks to storage (must hold lock)
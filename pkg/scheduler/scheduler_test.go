@@ -0,0 +1,350 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testExecutor starts a job for each task and ends it with the error that
+// result returns for its task type, once released.
+type testExecutor struct {
+	mu      sync.Mutex
+	lastID  int
+	started []ScheduledTaskType
+	jobs    map[int]ScheduledTaskType
+	result  func(t ScheduledTaskType, attempt int) error
+	// release, if set, holds every job until closed
+	release chan struct{}
+}
+
+func newTestExecutor(result func(t ScheduledTaskType, attempt int) error) *testExecutor {
+	return &testExecutor{
+		jobs:   make(map[int]ScheduledTaskType),
+		result: result,
+	}
+}
+
+func (e *testExecutor) start(t ScheduledTaskType) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.lastID++
+	e.started = append(e.started, t)
+	e.jobs[e.lastID] = t
+	return e.lastID, nil
+}
+
+func (e *testExecutor) startedTypes() []ScheduledTaskType {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]ScheduledTaskType(nil), e.started...)
+}
+
+func (e *testExecutor) WaitJob(ctx context.Context, jobID int) error {
+	if e.release != nil {
+		select {
+		case <-e.release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	e.mu.Lock()
+	t := e.jobs[jobID]
+	attempt := 0
+	for _, s := range e.started {
+		if s == t {
+			attempt++
+		}
+	}
+	e.mu.Unlock()
+
+	if e.result == nil {
+		return nil
+	}
+	return e.result(t, attempt)
+}
+
+func (e *testExecutor) ExecuteScan(context.Context, json.RawMessage) (int, error) {
+	return e.start(ScheduledTaskTypeScan)
+}
+func (e *testExecutor) ExecuteGenerate(context.Context, json.RawMessage) (int, error) {
+	return e.start(ScheduledTaskTypeGenerate)
+}
+func (e *testExecutor) ExecuteAutoTag(context.Context, json.RawMessage) (int, error) {
+	return e.start(ScheduledTaskTypeAutoTag)
+}
+func (e *testExecutor) ExecuteClean(context.Context, json.RawMessage) (int, error) {
+	return e.start(ScheduledTaskTypeClean)
+}
+func (e *testExecutor) ExecuteOptimise(context.Context) (int, error) {
+	return e.start(ScheduledTaskTypeOptimise)
+}
+func (e *testExecutor) ExecuteRebuildProfile(context.Context) (int, error) {
+	return e.start(ScheduledTaskTypeRebuildProfile)
+}
+func (e *testExecutor) ExecuteRebuildCoWatch(context.Context) (int, error) {
+	return e.start(ScheduledTaskTypeRebuildCoWatch)
+}
+func (e *testExecutor) ExecutePlugin(context.Context, json.RawMessage) (int, error) {
+	return e.start(ScheduledTaskTypePlugin)
+}
+func (e *testExecutor) ExecuteIdentify(context.Context, json.RawMessage) (int, error) {
+	return e.start(ScheduledTaskTypeIdentify)
+}
+func (e *testExecutor) ExecuteBackup(context.Context) (int, error) {
+	return e.start(ScheduledTaskTypeBackup)
+}
+func (e *testExecutor) ExecuteExport(context.Context) (int, error) {
+	return e.start(ScheduledTaskTypeExport)
+}
+func (e *testExecutor) ExecuteStashBoxTag(context.Context, json.RawMessage) (int, error) {
+	return e.start(ScheduledTaskTypeStashBoxTag)
+}
+func (e *testExecutor) ExecuteAPIHubRelink(context.Context) (int, error) {
+	return e.start(ScheduledTaskTypeAPIHubRelink)
+}
+
+type testStorage struct{}
+
+func (testStorage) GetScheduledTasks() []ScheduledTask        { return nil }
+func (testStorage) SaveScheduledTasks([]ScheduledTask) error  { return nil }
+func (testStorage) UpdateTaskLastRun(string, time.Time) error { return nil }
+
+type testHistory struct {
+	mu   sync.Mutex
+	runs []TaskRun
+}
+
+func (h *testHistory) RecordRun(_ context.Context, run *TaskRun) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.runs = append(h.runs, *run)
+	return nil
+}
+
+func (h *testHistory) ListRuns(context.Context, string, int) ([]TaskRun, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]TaskRun(nil), h.runs...), nil
+}
+
+func (h *testHistory) DeleteRuns(context.Context, string) error { return nil }
+
+// wait returns the runs recorded once there are n of them.
+func (h *testHistory) wait(t *testing.T, n int) []TaskRun {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		runs, _ := h.ListRuns(context.Background(), "", 0)
+		if len(runs) >= n {
+			return runs
+		}
+		time.Sleep(time.Millisecond)
+	}
+	runs, _ := h.ListRuns(context.Background(), "", 0)
+	t.Fatalf("got %d runs, want %d", len(runs), n)
+	return nil
+}
+
+func newTestScheduler(t *testing.T, e *testExecutor, tasks ...ScheduledTask) (*Scheduler, *testHistory) {
+	t.Helper()
+	h := &testHistory{}
+	s := New(e, testStorage{}, h)
+	t.Cleanup(s.cancel)
+
+	for _, task := range tasks {
+		if err := s.AddTask(task); err != nil {
+			t.Fatalf("adding task %s: %v", task.ID, err)
+		}
+	}
+	return s, h
+}
+
+func TestChainRunsInOrderAfterSuccess(t *testing.T) {
+	e := newTestExecutor(nil)
+	s, h := newTestScheduler(t, e,
+		ScheduledTask{ID: "generate", TaskType: ScheduledTaskTypeGenerate, Enabled: true},
+		ScheduledTask{ID: "identify", TaskType: ScheduledTaskTypeIdentify, Enabled: true},
+		ScheduledTask{ID: "scan", TaskType: ScheduledTaskTypeScan, Enabled: true, Then: []string{"generate", "identify"}},
+	)
+
+	if _, err := s.RunTask("scan"); err != nil {
+		t.Fatal(err)
+	}
+	runs := h.wait(t, 3)
+
+	want := []ScheduledTaskType{ScheduledTaskTypeScan, ScheduledTaskTypeGenerate, ScheduledTaskTypeIdentify}
+	for i, run := range runs {
+		if run.TaskType != want[i] || run.Status != TaskRunStatusSucceeded {
+			t.Errorf("run %d: got %s %s, want %s to succeed", i, run.TaskType, run.Status, want[i])
+		}
+	}
+	if runs[0].Trigger != TaskRunTriggerManual || runs[1].Trigger != TaskRunTriggerChain {
+		t.Errorf("triggers = %s, %s", runs[0].Trigger, runs[1].Trigger)
+	}
+}
+
+func TestChainStopsAtFailure(t *testing.T) {
+	e := newTestExecutor(func(tt ScheduledTaskType, _ int) error {
+		if tt == ScheduledTaskTypeGenerate {
+			return errors.New("generate failed")
+		}
+		return nil
+	})
+	s, h := newTestScheduler(t, e,
+		ScheduledTask{ID: "generate", TaskType: ScheduledTaskTypeGenerate, Enabled: true},
+		ScheduledTask{ID: "identify", TaskType: ScheduledTaskTypeIdentify, Enabled: true},
+		ScheduledTask{ID: "scan", TaskType: ScheduledTaskTypeScan, Enabled: true, Then: []string{"generate", "identify"}},
+	)
+
+	if _, err := s.RunTask("scan"); err != nil {
+		t.Fatal(err)
+	}
+	runs := h.wait(t, 2)
+	time.Sleep(10 * time.Millisecond)
+
+	if got := e.startedTypes(); len(got) != 2 {
+		t.Errorf("started %v, want the chain to stop after generate", got)
+	}
+	if runs[1].Status != TaskRunStatusFailed || runs[1].Error != "generate failed" {
+		t.Errorf("generate run = %+v", runs[1])
+	}
+}
+
+// A scheduled run follows the chain as it is when the run starts, not as it
+// was when the task was scheduled.
+func TestScheduledRunSeesRemovedChainedTask(t *testing.T) {
+	e := newTestExecutor(nil)
+	s, h := newTestScheduler(t, e,
+		ScheduledTask{ID: "generate", TaskType: ScheduledTaskTypeGenerate, Enabled: true},
+		ScheduledTask{ID: "identify", TaskType: ScheduledTaskTypeIdentify, Enabled: true},
+		ScheduledTask{ID: "scan", TaskType: ScheduledTaskTypeScan, Enabled: true, CronSchedule: "0 0 3 * * *", Then: []string{"generate", "identify"}},
+	)
+
+	if err := s.RemoveTask("generate"); err != nil {
+		t.Fatal(err)
+	}
+
+	s.mu.RLock()
+	entry := s.cron.Entry(s.entries["scan"])
+	s.mu.RUnlock()
+	entry.Job.Run()
+
+	runs := h.wait(t, 2)
+	if runs[0].Trigger != TaskRunTriggerSchedule {
+		t.Errorf("trigger = %s, want %s", runs[0].Trigger, TaskRunTriggerSchedule)
+	}
+	if runs[1].TaskType != ScheduledTaskTypeIdentify {
+		t.Errorf("chained run = %s, want %s", runs[1].TaskType, ScheduledTaskTypeIdentify)
+	}
+}
+
+func TestRetryBacksOff(t *testing.T) {
+	e := newTestExecutor(func(_ ScheduledTaskType, attempt int) error {
+		if attempt < 3 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	s, h := newTestScheduler(t, e,
+		ScheduledTask{ID: "scan", TaskType: ScheduledTaskTypeScan, Enabled: true, Retries: 3, RetryDelay: 30},
+	)
+
+	var mu sync.Mutex
+	var delays []time.Duration
+	s.after = func(d time.Duration) <-chan time.Time {
+		mu.Lock()
+		delays = append(delays, d)
+		mu.Unlock()
+		return time.After(0)
+	}
+
+	if _, err := s.RunTask("scan"); err != nil {
+		t.Fatal(err)
+	}
+	runs := h.wait(t, 3)
+	time.Sleep(10 * time.Millisecond)
+
+	if len(e.startedTypes()) != 3 {
+		t.Errorf("started %d attempts, want 3", len(e.startedTypes()))
+	}
+	for i, want := range []TaskRunStatus{TaskRunStatusFailed, TaskRunStatusFailed, TaskRunStatusSucceeded} {
+		if runs[i].Status != want || runs[i].Attempt != i+1 {
+			t.Errorf("run %d: attempt %d %s, want attempt %d %s", i, runs[i].Attempt, runs[i].Status, i+1, want)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(delays) != 2 || delays[0] != 30*time.Second || delays[1] != time.Minute {
+		t.Errorf("waited %v, want 30s then 1m", delays)
+	}
+}
+
+func TestCancelledRunIsNotRetried(t *testing.T) {
+	e := newTestExecutor(func(ScheduledTaskType, int) error { return ErrJobCancelled })
+	s, h := newTestScheduler(t, e,
+		ScheduledTask{ID: "scan", TaskType: ScheduledTaskTypeScan, Enabled: true, Retries: 3},
+	)
+	s.after = func(time.Duration) <-chan time.Time { return time.After(0) }
+
+	if _, err := s.RunTask("scan"); err != nil {
+		t.Fatal(err)
+	}
+	runs := h.wait(t, 1)
+	time.Sleep(10 * time.Millisecond)
+
+	if runs[0].Status != TaskRunStatusCancelled || len(e.startedTypes()) != 1 {
+		t.Errorf("got %s after %d attempts, want one cancelled attempt", runs[0].Status, len(e.startedTypes()))
+	}
+}
+
+func TestSkipIfRunning(t *testing.T) {
+	e := newTestExecutor(nil)
+	e.release = make(chan struct{})
+	s, h := newTestScheduler(t, e,
+		ScheduledTask{ID: "scan", TaskType: ScheduledTaskTypeScan, Enabled: true, SkipIfRunning: true},
+	)
+
+	if _, err := s.RunTask("scan"); err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsRunning("scan") {
+		t.Error("task not running")
+	}
+	if _, err := s.RunTask("scan"); !errors.Is(err, ErrTaskRunning) {
+		t.Errorf("second run: got %v, want ErrTaskRunning", err)
+	}
+
+	close(e.release)
+	runs := h.wait(t, 2)
+	if runs[0].Status != TaskRunStatusSkipped || runs[1].Status != TaskRunStatusSucceeded {
+		t.Errorf("got %s then %s, want skipped then succeeded", runs[0].Status, runs[1].Status)
+	}
+}
+
+func TestChainMayNotLeadBack(t *testing.T) {
+	s, _ := newTestScheduler(t, newTestExecutor(nil),
+		ScheduledTask{ID: "a", TaskType: ScheduledTaskTypeScan},
+		ScheduledTask{ID: "b", TaskType: ScheduledTaskTypeGenerate, Then: []string{"a"}},
+	)
+
+	if err := s.UpdateTask(ScheduledTask{ID: "a", TaskType: ScheduledTaskTypeScan, Then: []string{"b"}}); err == nil {
+		t.Error("accepted a chain that loops")
+	}
+	if err := s.UpdateTask(ScheduledTask{ID: "a", TaskType: ScheduledTaskTypeScan, Then: []string{"missing"}}); err == nil {
+		t.Error("accepted a chain to a missing task")
+	}
+
+	if err := s.RemoveTask("a"); err != nil {
+		t.Fatal(err)
+	}
+	if then := s.GetTask("b").Then; len(then) != 0 {
+		t.Errorf("removed task still chained: %v", then)
+	}
+}
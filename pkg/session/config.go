@@ -25,6 +25,9 @@ type UserInfo struct {
 	Role     string
 	APIKey   *string
 	IsActive bool
+	// APIKeyScopes limits a request authenticated with a named API key. It is
+	// nil if the request is not limited.
+	APIKeyScopes []string
 }
 
 // MultiUserConfig extends SessionConfig with multi-user support
@@ -46,3 +49,26 @@ type MultiUserConfig interface {
 	// FindUserByUsername looks up a user by username
 	FindUserByUsername(ctx context.Context, username string) (*UserInfo, error)
 }
+
+// ExternalIdentity is a user authenticated by an OpenID Connect provider or a
+// trusted reverse proxy rather than by stash itself
+type ExternalIdentity struct {
+	Username string
+	Groups   []string
+}
+
+// ExternalAuthConfig extends MultiUserConfig with single sign-on support
+type ExternalAuthConfig interface {
+	MultiUserConfig
+
+	// GetOIDCSettings returns the OpenID Connect provider configuration
+	GetOIDCSettings() OIDCSettings
+
+	// GetProxyAuthSettings returns the trusted reverse proxy configuration
+	GetProxyAuthSettings() ProxyAuthSettings
+
+	// ProvisionExternalUser returns the user of an external identity, creating
+	// it or syncing its role as configured. It returns nil if the identity may
+	// not log in.
+	ProvisionExternalUser(ctx context.Context, identity ExternalIdentity) (*UserInfo, error)
+}
@@ -0,0 +1,569 @@
+package session
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+const (
+	oidcCookieName     = "oidc"
+	oidcStateKey       = "state"
+	oidcNonceKey       = "nonce"
+	oidcVerifierKey    = "verifier"
+	oidcRedirectURLKey = "redirectURL"
+	oidcReturnURLKey   = "returnURL"
+
+	// time allowed to log in at the provider
+	oidcFlowMaxAge = 10 * 60
+)
+
+var (
+	ErrOIDCDisabled     = errors.New("single sign-on is not configured")
+	ErrOIDCInvalidState = errors.New("single sign-on state mismatch")
+)
+
+// OIDCSettings configures login through an OpenID Connect provider, such as
+// Keycloak or Authelia.
+type OIDCSettings struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback URL registered with the provider. If empty,
+	// the caller derives it from the request.
+	RedirectURL   string
+	Scopes        []string
+	UsernameClaim string
+	GroupsClaim   string
+}
+
+// Enabled returns true if an OpenID Connect provider is configured.
+func (s OIDCSettings) Enabled() bool {
+	return s.IssuerURL != "" && s.ClientID != ""
+}
+
+func (s OIDCSettings) equal(o OIDCSettings) bool {
+	return s.IssuerURL == o.IssuerURL &&
+		s.ClientID == o.ClientID &&
+		s.ClientSecret == o.ClientSecret &&
+		s.RedirectURL == o.RedirectURL &&
+		slices.Equal(s.Scopes, o.Scopes) &&
+		s.UsernameClaim == o.UsernameClaim &&
+		s.GroupsClaim == o.GroupsClaim
+}
+
+// oidcDiscovery is the subset of the provider metadata used to log in.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// oidcProvider implements the authorization code flow against a provider,
+// caching its metadata and signing keys.
+type oidcProvider struct {
+	settings OIDCSettings
+	client   *http.Client
+
+	mutex     sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]interface{}
+}
+
+func newOIDCProvider(settings OIDCSettings) *oidcProvider {
+	return &oidcProvider{
+		settings: settings,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (p *oidcProvider) getJSON(ctx context.Context, u string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", u, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (p *oidcProvider) discover(ctx context.Context) (*oidcDiscovery, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	issuer := strings.TrimSuffix(p.settings.IssuerURL, "/")
+
+	var ret oidcDiscovery
+	if err := p.getJSON(ctx, issuer+"/.well-known/openid-configuration", &ret); err != nil {
+		return nil, fmt.Errorf("discovering OpenID Connect provider: %w", err)
+	}
+
+	if strings.TrimSuffix(ret.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("provider issuer %q does not match configured issuer %q", ret.Issuer, p.settings.IssuerURL)
+	}
+
+	p.discovery = &ret
+	return p.discovery, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// refreshKeys fetches the signing keys of the provider.
+func (p *oidcProvider) refreshKeys(ctx context.Context) error {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return err
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(ctx, discovery.JWKSURI, &jwks); err != nil {
+		return fmt.Errorf("fetching provider keys: %w", err)
+	}
+
+	keys := make(map[string]interface{})
+	for _, k := range jwks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.publicKey()
+		if err != nil {
+			logger.Debugf("Ignoring provider key %q: %v", k.Kid, err)
+			continue
+		}
+		keys[k.Kid] = key
+	}
+
+	p.mutex.Lock()
+	p.keys = keys
+	p.mutex.Unlock()
+
+	return nil
+}
+
+func (p *oidcProvider) cachedKey(kid string) interface{} {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key
+	}
+
+	// tokens may omit the key ID if the provider only has one key
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key
+		}
+	}
+
+	return nil
+}
+
+// key returns the signing key with the given ID, refreshing the keys once
+// if it is unknown, as providers rotate their keys.
+func (p *oidcProvider) key(ctx context.Context, kid string) (interface{}, error) {
+	if key := p.cachedKey(kid); key != nil {
+		return key, nil
+	}
+
+	if err := p.refreshKeys(ctx); err != nil {
+		return nil, err
+	}
+
+	if key := p.cachedKey(kid); key != nil {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// authCodeURL returns the URL of the provider login page.
+func (p *oidcProvider) authCodeURL(ctx context.Context, redirectURL, state, nonce, verifier string) (string, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.settings.ClientID)
+	q.Set("redirect_uri", redirectURL)
+	q.Set("scope", strings.Join(append([]string{"openid"}, p.settings.Scopes...), " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	q.Set("code_challenge_method", "S256")
+
+	u, err := url.Parse(discovery.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("parsing authorization endpoint: %w", err)
+	}
+
+	// keep any parameters of the endpoint itself
+	existing := u.Query()
+	for k, v := range q {
+		existing[k] = v
+	}
+	u.RawQuery = existing.Encode()
+
+	return u.String(), nil
+}
+
+// exchange redeems an authorization code for the ID token of the user.
+func (p *oidcProvider) exchange(ctx context.Context, code, verifier, redirectURL string) (string, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURL)
+	form.Set("code_verifier", verifier)
+	form.Set("client_id", p.settings.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.settings.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.settings.ClientID), url.QueryEscape(p.settings.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("redeeming authorization code: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("decoding token response: %w", err)
+	}
+
+	if token.Error != "" {
+		return "", fmt.Errorf("redeeming authorization code: %s: %s", token.Error, token.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("redeeming authorization code: %s", resp.Status)
+	}
+	if token.IDToken == "" {
+		return "", errors.New("token response has no id_token")
+	}
+
+	return token.IDToken, nil
+}
+
+// verify checks the signature, issuer, audience, expiry and nonce of an ID
+// token and returns its claims.
+func (p *oidcProvider) verify(ctx context.Context, idToken, nonce string) (jwt.MapClaims, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}))
+
+	claims := jwt.MapClaims{}
+	if _, err := parser.ParseWithClaims(idToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.key(ctx, kid)
+	}); err != nil {
+		return nil, fmt.Errorf("verifying id token: %w", err)
+	}
+
+	if !claims.VerifyIssuer(discovery.Issuer, true) {
+		return nil, errors.New("id token has the wrong issuer")
+	}
+	if !claims.VerifyAudience(p.settings.ClientID, true) {
+		return nil, errors.New("id token has the wrong audience")
+	}
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, errors.New("id token nonce mismatch")
+	}
+
+	return claims, nil
+}
+
+// identity returns the user described by the claims of an ID token.
+func (p *oidcProvider) identity(claims jwt.MapClaims) (*ExternalIdentity, error) {
+	username, _ := claims[p.settings.UsernameClaim].(string)
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return nil, fmt.Errorf("id token has no %q claim", p.settings.UsernameClaim)
+	}
+
+	ret := &ExternalIdentity{Username: username}
+
+	switch groups := claims[p.settings.GroupsClaim].(type) {
+	case []interface{}:
+		for _, g := range groups {
+			if s, ok := g.(string); ok {
+				ret.Groups = append(ret.Groups, s)
+			}
+		}
+	case string:
+		ret.Groups = []string{groups}
+	}
+
+	return ret, nil
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// oidc returns the provider configured for single sign-on.
+func (s *Store) oidc() (*oidcProvider, error) {
+	ext, ok := s.config.(ExternalAuthConfig)
+	if !ok {
+		return nil, ErrOIDCDisabled
+	}
+
+	settings := ext.GetOIDCSettings()
+	if !settings.Enabled() {
+		return nil, ErrOIDCDisabled
+	}
+
+	s.oidcMutex.Lock()
+	defer s.oidcMutex.Unlock()
+
+	// the configuration may have changed since the last login
+	if s.oidcProvider == nil || !s.oidcProvider.settings.equal(settings) {
+		s.oidcProvider = newOIDCProvider(settings)
+	}
+
+	return s.oidcProvider, nil
+}
+
+// OIDCEnabled returns true if login through an OpenID Connect provider is
+// configured.
+func (s *Store) OIDCEnabled() bool {
+	_, err := s.oidc()
+	return err == nil
+}
+
+// OIDCLogin starts a login through the OpenID Connect provider and returns
+// the URL of the provider login page. The provider redirects back to
+// redirectURL, unless one is configured, and the user is then sent to
+// returnURL.
+func (s *Store) OIDCLogin(w http.ResponseWriter, r *http.Request, redirectURL, returnURL string) (string, error) {
+	p, err := s.oidc()
+	if err != nil {
+		return "", err
+	}
+
+	if p.settings.RedirectURL != "" {
+		redirectURL = p.settings.RedirectURL
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	verifier, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	ret, err := p.authCodeURL(r.Context(), redirectURL, state, nonce, verifier)
+	if err != nil {
+		return "", err
+	}
+
+	// ignore error - we want a new flow regardless
+	flow, _ := s.sessionStore.Get(r, oidcCookieName)
+	flow.Options.MaxAge = oidcFlowMaxAge
+	flow.Values[oidcStateKey] = state
+	flow.Values[oidcNonceKey] = nonce
+	flow.Values[oidcVerifierKey] = verifier
+	flow.Values[oidcRedirectURLKey] = redirectURL
+	flow.Values[oidcReturnURLKey] = returnURL
+
+	if err := flow.Save(r, w); err != nil {
+		return "", err
+	}
+
+	return ret, nil
+}
+
+// OIDCCallback completes a login through the OpenID Connect provider,
+// provisioning the user as configured, and returns the URL to send the user
+// to.
+func (s *Store) OIDCCallback(w http.ResponseWriter, r *http.Request) (string, error) {
+	p, err := s.oidc()
+	if err != nil {
+		return "", err
+	}
+
+	flow, err := s.sessionStore.Get(r, oidcCookieName)
+	if err != nil || flow.IsNew {
+		return "", ErrOIDCInvalidState
+	}
+
+	state, _ := flow.Values[oidcStateKey].(string)
+	nonce, _ := flow.Values[oidcNonceKey].(string)
+	verifier, _ := flow.Values[oidcVerifierKey].(string)
+	redirectURL, _ := flow.Values[oidcRedirectURLKey].(string)
+	returnURL, _ := flow.Values[oidcReturnURLKey].(string)
+
+	// the flow can only be completed once
+	flow.Options.MaxAge = -1
+	if err := flow.Save(r, w); err != nil {
+		return "", err
+	}
+
+	q := r.URL.Query()
+	if providerErr := q.Get("error"); providerErr != "" {
+		return "", fmt.Errorf("provider returned %s: %s", providerErr, q.Get("error_description"))
+	}
+
+	if state == "" || q.Get("state") != state {
+		return "", ErrOIDCInvalidState
+	}
+
+	ctx := r.Context()
+	idToken, err := p.exchange(ctx, q.Get("code"), verifier, redirectURL)
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := p.verify(ctx, idToken, nonce)
+	if err != nil {
+		return "", err
+	}
+
+	identity, err := p.identity(claims)
+	if err != nil {
+		return "", err
+	}
+
+	userInfo, err := s.config.(ExternalAuthConfig).ProvisionExternalUser(ctx, *identity)
+	if err != nil {
+		return "", err
+	}
+	if userInfo == nil {
+		return "", &InvalidCredentialsError{Username: identity.Username}
+	}
+	if !userInfo.IsActive {
+		return "", ErrUserDisabled
+	}
+
+	logger.Infof("User '%s' logged in with single sign-on (role: %s)", userInfo.Username, userInfo.Role)
+
+	// ignore error - we want a new session regardless
+	newSession, _ := s.sessionStore.Get(r, cookieName)
+	newSession.Values[userIDKey] = userInfo.Username
+	newSession.Values[userRoleKey] = userInfo.Role
+
+	if err := newSession.Save(r, w); err != nil {
+		return "", err
+	}
+
+	return returnURL, nil
+}
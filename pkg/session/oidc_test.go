@@ -0,0 +1,279 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// mockOIDCProvider is a minimal OpenID Connect provider issuing ID tokens
+// for a single authorization code.
+type mockOIDCProvider struct {
+	*httptest.Server
+	key      *rsa.PrivateKey
+	clientID string
+	secret   string
+
+	// set by the test before redeeming the code
+	code      string
+	challenge string
+	nonce     string
+	claims    jwt.MapClaims
+}
+
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &mockOIDCProvider{
+		key:      key,
+		clientID: "stash",
+		secret:   "secret",
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.URL,
+			"authorization_endpoint": p.URL + "/authorize",
+			"token_endpoint":         p.URL + "/token",
+			"jwks_uri":               p.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "test",
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != p.clientID || secret != p.secret {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+
+		verifier := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if r.FormValue("code") != p.code || base64.RawURLEncoding.EncodeToString(verifier[:]) != p.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		claims := jwt.MapClaims{
+			"iss":   p.URL,
+			"aud":   p.clientID,
+			"sub":   "1234",
+			"nonce": p.nonce,
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"iat":   time.Now().Unix(),
+		}
+		for k, v := range p.claims {
+			claims[k] = v
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "test"
+		signed, err := token.SignedString(key)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"id_token":     signed,
+		})
+	})
+
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+
+	return p
+}
+
+type ssoConfig struct {
+	oidc       OIDCSettings
+	proxyAuth  ProxyAuthSettings
+	identities []ExternalIdentity
+}
+
+func (c *ssoConfig) GetUsername() string                                { return "" }
+func (c *ssoConfig) GetAPIKey() string                                  { return "" }
+func (c *ssoConfig) GetSessionStoreKey() []byte                         { return []byte("0123456789abcdef0123456789abcdef") }
+func (c *ssoConfig) GetMaxSessionAge() int                              { return 3600 }
+func (c *ssoConfig) ValidateCredentials(username, password string) bool { return false }
+func (c *ssoConfig) IsMultiUserEnabled() bool                           { return true }
+func (c *ssoConfig) SetMultiUserEnabled(enabled bool)                   {}
+func (c *ssoConfig) GetOIDCSettings() OIDCSettings                      { return c.oidc }
+func (c *ssoConfig) GetProxyAuthSettings() ProxyAuthSettings            { return c.proxyAuth }
+
+func (c *ssoConfig) ValidateUserCredentials(ctx context.Context, username, password string) (*UserInfo, error) {
+	return nil, nil
+}
+
+func (c *ssoConfig) FindUserByAPIKey(ctx context.Context, apiKey string) (*UserInfo, error) {
+	return nil, nil
+}
+
+func (c *ssoConfig) FindUserByUsername(ctx context.Context, username string) (*UserInfo, error) {
+	return &UserInfo{Username: username, IsActive: true}, nil
+}
+
+func (c *ssoConfig) ProvisionExternalUser(ctx context.Context, identity ExternalIdentity) (*UserInfo, error) {
+	if identity.Username == "denied" {
+		return nil, nil
+	}
+
+	c.identities = append(c.identities, identity)
+	return &UserInfo{ID: 1, Username: identity.Username, Role: "viewer", IsActive: true}, nil
+}
+
+// startOIDCLogin starts a login and returns the callback request the provider
+// would redirect the browser to.
+func startOIDCLogin(t *testing.T, s *Store, p *mockOIDCProvider) *http.Request {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "http://stash/login/oidc", nil)
+	loginURL, err := s.OIDCLogin(w, r, "http://stash/login/oidc/callback", "/scenes")
+	if err != nil {
+		t.Fatalf("OIDCLogin() error = %v", err)
+	}
+
+	u, err := url.Parse(loginURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if u.Path != "/authorize" || q.Get("client_id") != p.clientID || q.Get("code_challenge_method") != "S256" {
+		t.Fatalf("unexpected login URL %s", loginURL)
+	}
+
+	p.code = "code"
+	p.challenge = q.Get("code_challenge")
+	p.nonce = q.Get("nonce")
+
+	callback := httptest.NewRequest(http.MethodGet, "http://stash/login/oidc/callback?code=code&state="+url.QueryEscape(q.Get("state")), nil)
+	for _, c := range w.Result().Cookies() {
+		callback.AddCookie(c)
+	}
+
+	return callback
+}
+
+func TestOIDCLogin(t *testing.T) {
+	p := newMockOIDCProvider(t)
+
+	newStore := func() (*Store, *ssoConfig) {
+		c := &ssoConfig{
+			oidc: OIDCSettings{
+				IssuerURL:     p.URL,
+				ClientID:      p.clientID,
+				ClientSecret:  p.secret,
+				UsernameClaim: "preferred_username",
+				GroupsClaim:   "groups",
+			},
+		}
+		return NewStore(c), c
+	}
+
+	t.Run("provisions the user", func(t *testing.T) {
+		s, c := newStore()
+		p.claims = jwt.MapClaims{
+			"preferred_username": "alice",
+			"groups":             []string{"stash-admins", "family"},
+		}
+
+		callback := startOIDCLogin(t, s, p)
+		w := httptest.NewRecorder()
+		returnURL, err := s.OIDCCallback(w, callback)
+		if err != nil {
+			t.Fatalf("OIDCCallback() error = %v", err)
+		}
+		if returnURL != "/scenes" {
+			t.Errorf("returnURL = %q, want /scenes", returnURL)
+		}
+
+		if len(c.identities) != 1 || c.identities[0].Username != "alice" || len(c.identities[0].Groups) != 2 {
+			t.Fatalf("provisioned %+v", c.identities)
+		}
+
+		// the session cookie logs the user in
+		r := httptest.NewRequest(http.MethodGet, "http://stash/", nil)
+		for _, cookie := range w.Result().Cookies() {
+			r.AddCookie(cookie)
+		}
+		userID, _, err := s.Authenticate(httptest.NewRecorder(), r)
+		if err != nil || userID != "alice" {
+			t.Errorf("Authenticate() = %q, %v, want alice", userID, err)
+		}
+	})
+
+	t.Run("rejects a forged state", func(t *testing.T) {
+		s, _ := newStore()
+		p.claims = jwt.MapClaims{"preferred_username": "alice"}
+
+		callback := startOIDCLogin(t, s, p)
+		q := callback.URL.Query()
+		q.Set("state", "forged")
+		callback.URL.RawQuery = q.Encode()
+
+		if _, err := s.OIDCCallback(httptest.NewRecorder(), callback); !errors.Is(err, ErrOIDCInvalidState) {
+			t.Errorf("OIDCCallback() error = %v, want %v", err, ErrOIDCInvalidState)
+		}
+	})
+
+	t.Run("rejects a token for another client", func(t *testing.T) {
+		s, _ := newStore()
+		p.claims = jwt.MapClaims{"preferred_username": "alice", "aud": "other"}
+
+		callback := startOIDCLogin(t, s, p)
+		if _, err := s.OIDCCallback(httptest.NewRecorder(), callback); err == nil {
+			t.Error("OIDCCallback() expected error for the wrong audience")
+		}
+	})
+
+	t.Run("denies users not allowed in", func(t *testing.T) {
+		s, _ := newStore()
+		p.claims = jwt.MapClaims{"preferred_username": "denied"}
+
+		callback := startOIDCLogin(t, s, p)
+		var invalidCredentialsError *InvalidCredentialsError
+		if _, err := s.OIDCCallback(httptest.NewRecorder(), callback); !errors.As(err, &invalidCredentialsError) {
+			t.Errorf("OIDCCallback() error = %v, want InvalidCredentialsError", err)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		s := NewStore(&ssoConfig{})
+		if s.OIDCEnabled() {
+			t.Error("OIDCEnabled() = true without a provider")
+		}
+		r := httptest.NewRequest(http.MethodGet, "http://stash/login/oidc", nil)
+		if _, err := s.OIDCLogin(httptest.NewRecorder(), r, "", ""); !errors.Is(err, ErrOIDCDisabled) {
+			t.Errorf("OIDCLogin() error = %v, want %v", err, ErrOIDCDisabled)
+		}
+	})
+}
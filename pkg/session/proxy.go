@@ -0,0 +1,84 @@
+package session
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+// ProxyAuthSettings configures trusting a reverse proxy, such as Authelia, to
+// authenticate users and pass their name in a header.
+type ProxyAuthSettings struct {
+	UserHeader   string
+	GroupsHeader string
+	// TrustedProxies are the IP addresses and CIDR ranges the headers are
+	// accepted from. Proxy authentication is disabled if empty.
+	TrustedProxies []string
+}
+
+// Enabled returns true if proxy authentication is configured.
+func (s ProxyAuthSettings) Enabled() bool {
+	return s.UserHeader != "" && len(s.TrustedProxies) > 0
+}
+
+// isTrusted returns true if ip is one of the trusted proxies.
+func (s ProxyAuthSettings) isTrusted(ip net.IP) bool {
+	for _, p := range s.TrustedProxies {
+		if strings.Contains(p, "/") {
+			_, network, err := net.ParseCIDR(p)
+			if err != nil {
+				logger.Warnf("Ignoring invalid trusted proxy %q: %v", p, err)
+				continue
+			}
+			if network.Contains(ip) {
+				return true
+			}
+		} else if trusted := net.ParseIP(p); trusted != nil && trusted.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// proxyIdentity returns the identity asserted by a trusted reverse proxy. It
+// returns nil if proxy authentication is disabled, the header is not set, or
+// the request did not come directly from a trusted proxy.
+func proxyIdentity(s ProxyAuthSettings, r *http.Request) *ExternalIdentity {
+	if !s.Enabled() {
+		return nil
+	}
+
+	username := strings.TrimSpace(r.Header.Get(s.UserHeader))
+	if username == "" {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	// remove the IPv6 scope ID, if present
+	if i := strings.Index(host, "%"); i != -1 {
+		host = host[:i]
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !s.isTrusted(ip) {
+		logger.Warnf("Ignoring %s header from untrusted address %s", s.UserHeader, r.RemoteAddr)
+		return nil
+	}
+
+	ret := &ExternalIdentity{Username: username}
+	if s.GroupsHeader != "" {
+		for _, g := range strings.Split(r.Header.Get(s.GroupsHeader), ",") {
+			if g = strings.TrimSpace(g); g != "" {
+				ret.Groups = append(ret.Groups, g)
+			}
+		}
+	}
+
+	return ret
+}
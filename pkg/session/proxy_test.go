@@ -0,0 +1,76 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestProxyIdentity(t *testing.T) {
+	settings := ProxyAuthSettings{
+		UserHeader:     "Remote-User",
+		GroupsHeader:   "Remote-Groups",
+		TrustedProxies: []string{"10.0.0.1", "172.16.0.0/12"},
+	}
+
+	tests := []struct {
+		name       string
+		settings   ProxyAuthSettings
+		remoteAddr string
+		user       string
+		groups     string
+		want       *ExternalIdentity
+	}{
+		{"trusted address", settings, "10.0.0.1:1234", "alice", "", &ExternalIdentity{Username: "alice"}},
+		{"trusted range", settings, "172.17.0.5:1234", "alice", "admins, family,", &ExternalIdentity{Username: "alice", Groups: []string{"admins", "family"}}},
+		{"untrusted address", settings, "10.0.0.2:1234", "alice", "admins", nil},
+		{"no header", settings, "10.0.0.1:1234", "", "", nil},
+		{"disabled", ProxyAuthSettings{UserHeader: "Remote-User"}, "10.0.0.1:1234", "alice", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.user != "" {
+				r.Header.Set("Remote-User", tt.user)
+			}
+			if tt.groups != "" {
+				r.Header.Set("Remote-Groups", tt.groups)
+			}
+
+			if got := proxyIdentity(tt.settings, r); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("proxyIdentity() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticateProxyUser(t *testing.T) {
+	c := &ssoConfig{
+		proxyAuth: ProxyAuthSettings{
+			UserHeader:     "Remote-User",
+			TrustedProxies: []string{"127.0.0.1"},
+		},
+	}
+	s := NewStore(c)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "127.0.0.1:1234"
+	r.Header.Set("Remote-User", "bob")
+
+	userID, userInfo, err := s.Authenticate(httptest.NewRecorder(), r)
+	if err != nil || userID != "bob" {
+		t.Errorf("Authenticate() = %q, %v, want bob", userID, err)
+	}
+	// the provisioned user is returned so that it is not looked up again
+	if userInfo == nil || userInfo.Username != "bob" {
+		t.Errorf("Authenticate() user = %+v, want bob", userInfo)
+	}
+
+	r.Header.Set("Remote-User", "denied")
+	if _, _, err := s.Authenticate(httptest.NewRecorder(), r); err != ErrUnauthorized {
+		t.Errorf("Authenticate() error = %v, want %v", err, ErrUnauthorized)
+	}
+}
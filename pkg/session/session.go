@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"sync"
 
 	"github.com/gorilla/sessions"
 	"github.com/stashapp/stash/pkg/logger"
@@ -50,6 +51,9 @@ var ErrUserDisabled = errors.New("user account is disabled")
 type Store struct {
 	sessionStore *sessions.CookieStore
 	config       SessionConfig
+
+	oidcMutex    sync.Mutex
+	oidcProvider *oidcProvider
 }
 
 func NewStore(c SessionConfig) *Store {
@@ -201,9 +205,39 @@ func GetCurrentUserInfo(ctx context.Context) *UserInfo {
 	return nil
 }
 
-func (s *Store) Authenticate(w http.ResponseWriter, r *http.Request) (userID string, err error) {
+// FindUserInfo returns the database-backed user with the given username.
+// It returns nil when multi-user mode is disabled or the user does not exist.
+func (s *Store) FindUserInfo(ctx context.Context, username string) (*UserInfo, error) {
+	multiConfig, ok := s.config.(MultiUserConfig)
+	if !ok || !multiConfig.IsMultiUserEnabled() || username == "" {
+		return nil, nil
+	}
+
+	return multiConfig.FindUserByUsername(ctx, username)
+}
+
+// Authenticate returns the user the request is authenticated as. userInfo is
+// the user if it was loaded to authenticate the request, and is nil otherwise.
+// Its APIKeyScopes limit the request if it was authenticated with a named API
+// key.
+func (s *Store) Authenticate(w http.ResponseWriter, r *http.Request) (userID string, userInfo *UserInfo, err error) {
 	c := s.config
 
+	// trust the user authenticated by a reverse proxy, if configured
+	if ext, ok := c.(ExternalAuthConfig); ok {
+		if identity := proxyIdentity(ext.GetProxyAuthSettings(), r); identity != nil {
+			userInfo, err := ext.ProvisionExternalUser(r.Context(), *identity)
+			if err != nil {
+				return "", nil, err
+			}
+			if userInfo == nil || !userInfo.IsActive {
+				return "", nil, ErrUnauthorized
+			}
+
+			return userInfo.Username, userInfo, nil
+		}
+	}
+
 	// translate api key into current user, if present
 	apiKey := r.Header.Get(ApiKeyHeader)
 
@@ -217,13 +251,13 @@ func (s *Store) Authenticate(w http.ResponseWriter, r *http.Request) (userID str
 		if multiConfig, ok := c.(MultiUserConfig); ok && multiConfig.IsMultiUserEnabled() {
 			userInfo, findErr := multiConfig.FindUserByAPIKey(r.Context(), apiKey)
 			if findErr == nil && userInfo != nil {
-				return userInfo.Username, nil
+				return userInfo.Username, userInfo, nil
 			}
 		}
 
 		// Fall back to config-based API key
 		if c.GetAPIKey() != apiKey {
-			return "", ErrUnauthorized
+			return "", nil, ErrUnauthorized
 		}
 
 		userID = c.GetUsername()
@@ -235,17 +269,20 @@ func (s *Store) Authenticate(w http.ResponseWriter, r *http.Request) (userID str
 		// This prevents stale cookies from authenticating deleted users.
 		if err == nil && userID != "" {
 			if multiConfig, ok := c.(MultiUserConfig); ok && multiConfig.IsMultiUserEnabled() {
-				userInfo, findErr := multiConfig.FindUserByUsername(r.Context(), userID)
-				if findErr == nil && userInfo == nil {
-					// User no longer exists; treat as unauthenticated.
-					userID = ""
+				found, findErr := multiConfig.FindUserByUsername(r.Context(), userID)
+				if findErr == nil {
+					if found == nil {
+						// User no longer exists; treat as unauthenticated.
+						userID = ""
+					}
+					userInfo = found
 				}
 			}
 		}
 	}
 
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	return
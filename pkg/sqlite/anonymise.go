@@ -115,6 +115,8 @@ func (db *Anonymiser) clearOHistory() error {
 func (db *Anonymiser) clearWatchHistory() error {
 	return utils.Do([]func() error{
 		func() error { return db.truncateTable(scenesViewDatesTable) },
+		// the co-watch model is built from the history
+		func() error { return db.truncateTable(coWatchTable) },
 	})
 }
 
@@ -0,0 +1,226 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const (
+	apiKeyTable           = "api_keys"
+	apiKeysScopesTable    = "api_keys_scopes"
+	apiKeyIDColumn        = "api_key_id"
+	apiKeyScopeColumn     = "scope"
+	apiKeyUserIDColumn    = "user_id"
+	apiKeyKeyHashColumn   = "key_hash"
+	apiKeyCreatedAtColumn = "created_at"
+)
+
+var (
+	apiKeysTableMgr = &table{
+		table:    goqu.T(apiKeyTable),
+		idColumn: goqu.T(apiKeyTable).Col(idColumn),
+	}
+
+	apiKeysScopesTableMgr = &stringTable{
+		table: table{
+			table:    goqu.T(apiKeysScopesTable),
+			idColumn: goqu.T(apiKeysScopesTable).Col(apiKeyIDColumn),
+		},
+		stringColumn: goqu.T(apiKeysScopesTable).Col(apiKeyScopeColumn),
+	}
+)
+
+type apiKeyRow struct {
+	ID         int           `db:"id" goqu:"skipinsert"`
+	UserID     int           `db:"user_id"`
+	Name       string        `db:"name"`
+	Prefix     string        `db:"key_prefix"`
+	KeyHash    string        `db:"key_hash"`
+	ExpiresAt  NullTimestamp `db:"expires_at"`
+	LastUsedAt NullTimestamp `db:"last_used_at"`
+	CreatedAt  Timestamp     `db:"created_at"`
+}
+
+func (r *apiKeyRow) fromAPIKey(o models.APIKey) {
+	r.ID = o.ID
+	r.UserID = o.UserID
+	r.Name = o.Name
+	r.Prefix = o.Prefix
+	r.KeyHash = o.KeyHash
+	r.ExpiresAt = NullTimestampFromTimePtr(o.ExpiresAt)
+	r.LastUsedAt = NullTimestampFromTimePtr(o.LastUsedAt)
+	r.CreatedAt = Timestamp{Timestamp: o.CreatedAt}
+}
+
+func (r *apiKeyRow) resolve() *models.APIKey {
+	return &models.APIKey{
+		ID:         r.ID,
+		UserID:     r.UserID,
+		Name:       r.Name,
+		Prefix:     r.Prefix,
+		KeyHash:    r.KeyHash,
+		ExpiresAt:  r.ExpiresAt.TimePtr(),
+		LastUsedAt: r.LastUsedAt.TimePtr(),
+		CreatedAt:  r.CreatedAt.Timestamp,
+	}
+}
+
+// APIKeyStore provides methods for named API key database operations
+type APIKeyStore struct {
+	repository
+	tableMgr *table
+}
+
+// NewAPIKeyStore creates a new APIKeyStore
+func NewAPIKeyStore() *APIKeyStore {
+	return &APIKeyStore{
+		repository: repository{
+			tableName: apiKeyTable,
+			idColumn:  idColumn,
+		},
+		tableMgr: apiKeysTableMgr,
+	}
+}
+
+func (qb *APIKeyStore) table() exp.IdentifierExpression {
+	return qb.tableMgr.table
+}
+
+func (qb *APIKeyStore) selectDataset() *goqu.SelectDataset {
+	return dialect.From(qb.table()).Select(qb.table().All())
+}
+
+// Create creates a new API key along with its scopes
+func (qb *APIKeyStore) Create(ctx context.Context, newKey *models.APIKey) error {
+	var r apiKeyRow
+	r.fromAPIKey(*newKey)
+
+	id, err := qb.tableMgr.insertID(ctx, r)
+	if err != nil {
+		return fmt.Errorf("creating api key: %w", err)
+	}
+
+	scopes := make([]string, len(newKey.Scopes))
+	for i, s := range newKey.Scopes {
+		scopes[i] = string(s)
+	}
+
+	if err := apiKeysScopesTableMgr.insertJoins(ctx, id, scopes); err != nil {
+		return fmt.Errorf("setting api key scopes: %w", err)
+	}
+
+	updated, err := qb.Find(ctx, id)
+	if err != nil {
+		return fmt.Errorf("finding after create: %w", err)
+	}
+
+	*newKey = *updated
+
+	return nil
+}
+
+// UpdateLastUsed sets the time the API key was last used
+func (qb *APIKeyStore) UpdateLastUsed(ctx context.Context, id int, t time.Time) error {
+	return qb.tableMgr.updateByID(ctx, id, goqu.Record{
+		"last_used_at": Timestamp{Timestamp: t},
+	})
+}
+
+// Destroy deletes an API key by ID
+func (qb *APIKeyStore) Destroy(ctx context.Context, id int) error {
+	return qb.destroyExisting(ctx, []int{id})
+}
+
+// Find returns an API key by ID, or nil if not found
+func (qb *APIKeyStore) Find(ctx context.Context, id int) (*models.APIKey, error) {
+	ret, err := qb.get(ctx, qb.selectDataset().Where(qb.tableMgr.byID(id)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting api key by id %d: %w", id, err)
+	}
+
+	return ret, nil
+}
+
+// FindByHash returns the API key with the given hash, or nil if not found
+func (qb *APIKeyStore) FindByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	ret, err := qb.get(ctx, qb.selectDataset().Where(qb.table().Col(apiKeyKeyHashColumn).Eq(keyHash)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("finding api key by hash: %w", err)
+	}
+
+	return ret, nil
+}
+
+// FindByUserID returns the API keys of a user, newest first
+func (qb *APIKeyStore) FindByUserID(ctx context.Context, userID int) ([]*models.APIKey, error) {
+	q := qb.selectDataset().
+		Where(qb.table().Col(apiKeyUserIDColumn).Eq(userID)).
+		Order(qb.table().Col(apiKeyCreatedAtColumn).Desc(), qb.table().Col(idColumn).Desc())
+
+	return qb.getMany(ctx, q)
+}
+
+// FindAll returns the API keys of all users, newest first
+func (qb *APIKeyStore) FindAll(ctx context.Context) ([]*models.APIKey, error) {
+	q := qb.selectDataset().
+		Order(qb.table().Col(apiKeyCreatedAtColumn).Desc(), qb.table().Col(idColumn).Desc())
+
+	return qb.getMany(ctx, q)
+}
+
+func (qb *APIKeyStore) get(ctx context.Context, q *goqu.SelectDataset) (*models.APIKey, error) {
+	ret, err := qb.getMany(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ret) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return ret[0], nil
+}
+
+func (qb *APIKeyStore) getMany(ctx context.Context, q *goqu.SelectDataset) ([]*models.APIKey, error) {
+	const single = false
+	var ret []*models.APIKey
+	if err := queryFunc(ctx, q, single, func(r *sqlx.Rows) error {
+		var row apiKeyRow
+		if err := r.StructScan(&row); err != nil {
+			return err
+		}
+		ret = append(ret, row.resolve())
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, key := range ret {
+		scopes, err := apiKeysScopesTableMgr.get(ctx, key.ID)
+		if err != nil {
+			return nil, fmt.Errorf("getting scopes of api key %d: %w", key.ID, err)
+		}
+
+		key.Scopes = make(models.APIKeyScopes, len(scopes))
+		for i, s := range scopes {
+			key.Scopes[i] = models.APIKeyScope(s)
+		}
+	}
+
+	return ret, nil
+}
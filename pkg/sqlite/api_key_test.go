@@ -0,0 +1,79 @@
+//go:build integration
+// +build integration
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyStore(t *testing.T) {
+	withRollbackTxn(func(ctx context.Context) error {
+		assert := assert.New(t)
+		qb := db.APIKey
+
+		userCtx := createUserDataTestUser(ctx, t, "iptv")
+		userID, _ := models.UserIDFromContext(userCtx)
+
+		expires := time.Now().Add(time.Hour).Truncate(time.Second)
+		key := &models.APIKey{
+			UserID:    userID,
+			Name:      "TiviMate",
+			Prefix:    "stash_abcdef",
+			KeyHash:   "hash",
+			Scopes:    models.APIKeyScopes{models.APIKeyScopeIPTV, models.APIKeyScopeStream},
+			ExpiresAt: &expires,
+			CreatedAt: time.Now(),
+		}
+		if err := qb.Create(ctx, key); err != nil {
+			t.Errorf("APIKeyStore.Create() error = %v", err)
+			return nil
+		}
+
+		found, err := qb.FindByHash(ctx, "hash")
+		if err != nil {
+			t.Errorf("APIKeyStore.FindByHash() error = %v", err)
+			return nil
+		}
+		if assert.NotNil(found) {
+			assert.Equal(key.ID, found.ID)
+			assert.ElementsMatch(key.Scopes, found.Scopes)
+			assert.True(found.ExpiresAt.Equal(expires))
+			assert.Nil(found.LastUsedAt)
+		}
+
+		used := time.Now().Truncate(time.Second)
+		if err := qb.UpdateLastUsed(ctx, key.ID, used); err != nil {
+			t.Errorf("APIKeyStore.UpdateLastUsed() error = %v", err)
+			return nil
+		}
+
+		keys, err := qb.FindByUserID(ctx, userID)
+		if err != nil {
+			t.Errorf("APIKeyStore.FindByUserID() error = %v", err)
+			return nil
+		}
+		if assert.Len(keys, 1) && assert.NotNil(keys[0].LastUsedAt) {
+			assert.True(keys[0].LastUsedAt.Equal(used))
+		}
+
+		if err := qb.Destroy(ctx, key.ID); err != nil {
+			t.Errorf("APIKeyStore.Destroy() error = %v", err)
+			return nil
+		}
+
+		found, err = qb.FindByHash(ctx, "hash")
+		if err != nil {
+			t.Errorf("APIKeyStore.FindByHash() error = %v", err)
+			return nil
+		}
+		assert.Nil(found)
+
+		return nil
+	})
+}
@@ -0,0 +1,230 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const (
+	auditEntryTable           = "audit_entries"
+	auditEntriesEntitiesTable = "audit_entries_entities"
+	auditEntryIDColumn        = "audit_entry_id"
+	auditEntryEntityIDColumn  = "entity_id"
+	auditEntryCreatedAtColumn = "created_at"
+)
+
+var (
+	auditEntriesTableMgr = &table{
+		table:    goqu.T(auditEntryTable),
+		idColumn: goqu.T(auditEntryTable).Col(idColumn),
+	}
+
+	auditEntriesEntitiesTableMgr = &stringTable{
+		table: table{
+			table:    goqu.T(auditEntriesEntitiesTable),
+			idColumn: goqu.T(auditEntriesEntitiesTable).Col(auditEntryIDColumn),
+		},
+		stringColumn: goqu.T(auditEntriesEntitiesTable).Col(auditEntryEntityIDColumn),
+	}
+)
+
+type auditEntryRow struct {
+	ID         int         `db:"id" goqu:"skipinsert"`
+	UserID     null.Int    `db:"user_id"`
+	Username   string      `db:"username"`
+	Operation  string      `db:"operation"`
+	EntityType string      `db:"entity_type"`
+	Changes    null.String `db:"changes"`
+	Error      string      `db:"error"`
+	IPAddress  string      `db:"ip_address"`
+	CreatedAt  Timestamp   `db:"created_at"`
+}
+
+func (r *auditEntryRow) fromAuditEntry(o models.AuditEntry) {
+	r.ID = o.ID
+	r.UserID = intFromPtr(o.UserID)
+	r.Username = o.Username
+	r.Operation = o.Operation
+	r.EntityType = o.EntityType
+	if len(o.Changes) > 0 {
+		r.Changes = null.StringFrom(encodeJSONOrEmpty(o.Changes))
+	}
+	r.Error = o.Error
+	r.IPAddress = o.IPAddress
+	r.CreatedAt = Timestamp{Timestamp: o.CreatedAt}
+}
+
+func (r *auditEntryRow) resolve() *models.AuditEntry {
+	ret := &models.AuditEntry{
+		ID:         r.ID,
+		UserID:     nullIntPtr(r.UserID),
+		Username:   r.Username,
+		Operation:  r.Operation,
+		EntityType: r.EntityType,
+		Error:      r.Error,
+		IPAddress:  r.IPAddress,
+		CreatedAt:  r.CreatedAt.Timestamp,
+	}
+
+	if r.Changes.Valid {
+		decodeJSON(r.Changes.String, &ret.Changes)
+	}
+
+	return ret
+}
+
+// AuditEntryStore provides methods for audit log database operations
+type AuditEntryStore struct {
+	tableMgr *table
+}
+
+// NewAuditEntryStore creates a new AuditEntryStore
+func NewAuditEntryStore() *AuditEntryStore {
+	return &AuditEntryStore{
+		tableMgr: auditEntriesTableMgr,
+	}
+}
+
+func (qb *AuditEntryStore) table() exp.IdentifierExpression {
+	return qb.tableMgr.table
+}
+
+// Create adds an entry to the audit log along with the IDs of the entities it
+// changed
+func (qb *AuditEntryStore) Create(ctx context.Context, newEntry *models.AuditEntry) error {
+	var r auditEntryRow
+	r.fromAuditEntry(*newEntry)
+
+	id, err := qb.tableMgr.insertID(ctx, r)
+	if err != nil {
+		return fmt.Errorf("creating audit entry: %w", err)
+	}
+
+	if err := auditEntriesEntitiesTableMgr.insertJoins(ctx, id, newEntry.EntityIDs); err != nil {
+		return fmt.Errorf("setting audit entry entities: %w", err)
+	}
+
+	newEntry.ID = id
+
+	return nil
+}
+
+// DestroyBefore deletes the entries created before t
+func (qb *AuditEntryStore) DestroyBefore(ctx context.Context, t time.Time) (int64, error) {
+	q := dialect.Delete(qb.table()).Where(qb.table().Col(auditEntryCreatedAtColumn).Lt(Timestamp{Timestamp: t}))
+
+	ret, err := exec(ctx, q)
+	if err != nil {
+		return 0, fmt.Errorf("pruning audit entries: %w", err)
+	}
+
+	return ret.RowsAffected()
+}
+
+// Query returns the entries matching the filter, newest first by default,
+// along with the total number matching.
+func (qb *AuditEntryStore) Query(ctx context.Context, auditFilter *models.AuditEntryFilterType, findFilter *models.FindFilterType) ([]*models.AuditEntry, int, error) {
+	if auditFilter == nil {
+		auditFilter = &models.AuditEntryFilterType{}
+	}
+	if findFilter == nil {
+		findFilter = &models.FindFilterType{}
+	}
+
+	where, err := qb.filterWhere(auditFilter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	countQ := dialect.From(qb.table()).Select(goqu.COUNT("*")).Where(where...)
+	total, err := count(ctx, countQ)
+	if err != nil {
+		return nil, 0, fmt.Errorf("counting audit entries: %w", err)
+	}
+
+	order := qb.table().Col(auditEntryCreatedAtColumn).Desc()
+	if findFilter.Direction != nil && *findFilter.Direction == models.SortDirectionEnumAsc {
+		order = qb.table().Col(auditEntryCreatedAtColumn).Asc()
+	}
+
+	q := dialect.From(qb.table()).Select(qb.table().All()).
+		Where(where...).
+		Order(order, qb.table().Col(idColumn).Desc())
+
+	if !findFilter.IsGetAll() {
+		perPage := findFilter.GetPageSize()
+		q = q.Limit(uint(perPage)).Offset(uint((findFilter.GetPage() - 1) * perPage))
+	}
+
+	ret, err := qb.getMany(ctx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return ret, total, nil
+}
+
+func (qb *AuditEntryStore) filterWhere(f *models.AuditEntryFilterType) ([]exp.Expression, error) {
+	var ret []exp.Expression
+
+	if f.UserID != nil {
+		userID, err := strconv.Atoi(*f.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user id %q: %w", *f.UserID, err)
+		}
+		ret = append(ret, qb.table().Col("user_id").Eq(userID))
+	}
+	if f.Operation != nil {
+		ret = append(ret, qb.table().Col("operation").Eq(*f.Operation))
+	}
+	if f.EntityType != nil {
+		ret = append(ret, qb.table().Col("entity_type").Eq(*f.EntityType))
+	}
+	if f.EntityID != nil {
+		entities := goqu.T(auditEntriesEntitiesTable)
+		ret = append(ret, qb.table().Col(idColumn).In(
+			dialect.From(entities).Select(entities.Col(auditEntryIDColumn)).
+				Where(entities.Col(auditEntryEntityIDColumn).Eq(*f.EntityID)),
+		))
+	}
+	if f.CreatedAt != nil {
+		clause, args := getTimestampCriterionWhereClause(auditEntryTable+"."+auditEntryCreatedAtColumn, *f.CreatedAt)
+		ret = append(ret, goqu.L(clause, args...))
+	}
+
+	return ret, nil
+}
+
+func (qb *AuditEntryStore) getMany(ctx context.Context, q *goqu.SelectDataset) ([]*models.AuditEntry, error) {
+	const single = false
+	var ret []*models.AuditEntry
+	if err := queryFunc(ctx, q, single, func(r *sqlx.Rows) error {
+		var row auditEntryRow
+		if err := r.StructScan(&row); err != nil {
+			return err
+		}
+		ret = append(ret, row.resolve())
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, e := range ret {
+		ids, err := auditEntriesEntitiesTableMgr.get(ctx, e.ID)
+		if err != nil {
+			return nil, fmt.Errorf("getting entities of audit entry %d: %w", e.ID, err)
+		}
+		e.EntityIDs = ids
+	}
+
+	return ret, nil
+}
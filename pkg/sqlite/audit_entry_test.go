@@ -0,0 +1,92 @@
+//go:build integration
+// +build integration
+
+package sqlite_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditEntryStore(t *testing.T) {
+	withRollbackTxn(func(ctx context.Context) error {
+		assert := assert.New(t)
+		qb := db.AuditEntry
+
+		userCtx := createUserDataTestUser(ctx, t, "auditor")
+		userID, _ := models.UserIDFromContext(userCtx)
+
+		now := time.Now().Truncate(time.Second)
+		entries := []*models.AuditEntry{
+			{
+				UserID:     &userID,
+				Username:   "auditor",
+				Operation:  "performerMerge",
+				EntityType: "performer",
+				EntityIDs:  []string{"2", "3", "1"},
+				Changes:    map[string]interface{}{"destination": "1"},
+				CreatedAt:  now.AddDate(0, 0, -10),
+			},
+			{
+				UserID:     &userID,
+				Username:   "auditor",
+				Operation:  "tagDestroy",
+				EntityType: "tag",
+				EntityIDs:  []string{"3"},
+				CreatedAt:  now,
+			},
+		}
+		for _, e := range entries {
+			if err := qb.Create(ctx, e); err != nil {
+				t.Errorf("AuditEntryStore.Create() error = %v", err)
+				return nil
+			}
+		}
+
+		performer := "performer"
+		entityID := "3"
+		got, count, err := qb.Query(ctx, &models.AuditEntryFilterType{EntityType: &performer, EntityID: &entityID}, nil)
+		if err != nil {
+			t.Errorf("AuditEntryStore.Query() error = %v", err)
+			return nil
+		}
+		if assert.Equal(1, count) && assert.Len(got, 1) {
+			assert.Equal("performerMerge", got[0].Operation)
+			assert.ElementsMatch([]string{"1", "2", "3"}, got[0].EntityIDs)
+			assert.Equal("1", got[0].Changes["destination"])
+			assert.Equal(userID, *got[0].UserID)
+		}
+
+		user := strconv.Itoa(userID)
+		got, count, err = qb.Query(ctx, &models.AuditEntryFilterType{UserID: &user}, nil)
+		if err != nil {
+			t.Errorf("AuditEntryStore.Query() error = %v", err)
+			return nil
+		}
+		if assert.Equal(2, count) && assert.Len(got, 2) {
+			// newest first
+			assert.Equal("tagDestroy", got[0].Operation)
+		}
+
+		deleted, err := qb.DestroyBefore(ctx, now.AddDate(0, 0, -1))
+		if err != nil {
+			t.Errorf("AuditEntryStore.DestroyBefore() error = %v", err)
+			return nil
+		}
+		assert.Equal(int64(1), deleted)
+
+		_, count, err = qb.Query(ctx, &models.AuditEntryFilterType{UserID: &user}, nil)
+		if err != nil {
+			t.Errorf("AuditEntryStore.Query() error = %v", err)
+			return nil
+		}
+		assert.Equal(1, count)
+
+		return nil
+	})
+}
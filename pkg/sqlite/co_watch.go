@@ -0,0 +1,390 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const (
+	coWatchTable              = "scene_co_watches"
+	coWatchCursorTable        = "scene_co_watch_cursor"
+	coWatchRemovedTable       = "scene_co_watch_removed"
+	coWatchOtherSceneIDColumn = "other_scene_id"
+)
+
+type coWatchPairRow struct {
+	SceneID      int `db:"scene_id"`
+	OtherSceneID int `db:"other_scene_id"`
+	Users        int `db:"users"`
+	Sessions     int `db:"sessions"`
+}
+
+func (r *coWatchPairRow) resolve() *models.CoWatchPair {
+	return &models.CoWatchPair{
+		SceneID:      r.SceneID,
+		OtherSceneID: r.OtherSceneID,
+		Users:        r.Users,
+		Sessions:     r.Sessions,
+	}
+}
+
+// CoWatchStore provides methods for the co-watch recommendation model, which
+// counts how often pairs of scenes are watched together. The model is built
+// from the play and O history by the co-watch rebuild job. Each pair is
+// stored once, with the lower scene id first. History removed after it was
+// added to the model is recorded by triggers, so that the job can take it
+// back.
+type CoWatchStore struct{}
+
+// NewCoWatchStore creates a new CoWatchStore
+func NewCoWatchStore() *CoWatchStore {
+	return &CoWatchStore{}
+}
+
+func (qb *CoWatchStore) table() exp.IdentifierExpression {
+	return goqu.T(coWatchTable)
+}
+
+// Related returns the pairs of each of the given scenes with the scenes
+// watched alongside it.
+func (qb *CoWatchStore) Related(ctx context.Context, sceneIDs []int) ([]*models.CoWatchPair, error) {
+	table := qb.table()
+
+	var ret []*models.CoWatchPair
+	if err := batchExec(sceneIDs, defaultBatchSize, func(batch []int) error {
+		given := make(map[int]bool, len(batch))
+		for _, id := range batch {
+			given[id] = true
+		}
+
+		q := dialect.From(table).Select(table.All()).Where(goqu.Or(
+			table.Col(sceneIDColumn).In(batch),
+			table.Col(coWatchOtherSceneIDColumn).In(batch),
+		))
+
+		const single = false
+		return queryFunc(ctx, q, single, func(r *sqlx.Rows) error {
+			var row coWatchPairRow
+			if err := r.StructScan(&row); err != nil {
+				return err
+			}
+
+			// a pair of two given scenes is returned for each of them
+			p := row.resolve()
+			if given[p.SceneID] {
+				ret = append(ret, p)
+			}
+			if given[p.OtherSceneID] {
+				ret = append(ret, &models.CoWatchPair{
+					SceneID:      p.OtherSceneID,
+					OtherSceneID: p.SceneID,
+					Users:        p.Users,
+					Sessions:     p.Sessions,
+				})
+			}
+			return nil
+		})
+	}); err != nil {
+		return nil, fmt.Errorf("getting co-watched scenes: %w", err)
+	}
+
+	return ret, nil
+}
+
+// WatchedSceneIDs returns the scenes played or O'd by the current user, or by
+// anyone if the context is not scoped to a user.
+func (qb *CoWatchStore) WatchedSceneIDs(ctx context.Context) ([]int, error) {
+	views := scenesViewTableMgr
+	oDates := scenesOTableMgr
+
+	q := dialect.From(views.table.table).Select(views.idColumn).Where(views.where(ctx)...).
+		Union(dialect.From(oDates.table.table).Select(oDates.idColumn).Where(oDates.where(ctx)...))
+
+	var ret []int
+	const single = false
+	if err := queryFunc(ctx, q, single, func(r *sqlx.Rows) error {
+		var id int
+		if err := r.Scan(&id); err != nil {
+			return err
+		}
+		ret = append(ret, id)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("getting watched scenes: %w", err)
+	}
+
+	return ret, nil
+}
+
+// GetCursor returns the ids of the last history rows added to the model.
+func (qb *CoWatchStore) GetCursor(ctx context.Context) (models.CoWatchCursor, error) {
+	table := goqu.T(coWatchCursorTable)
+	q := dialect.From(table).Select(table.Col("view_date_id"), table.Col("o_date_id"))
+
+	var ret models.CoWatchCursor
+	const single = true
+	if err := queryFunc(ctx, q, single, func(r *sqlx.Rows) error {
+		return r.Scan(&ret.ViewDateID, &ret.ODateID)
+	}); err != nil {
+		return ret, fmt.Errorf("getting co-watch cursor: %w", err)
+	}
+
+	return ret, nil
+}
+
+// HistoryEnd returns the ids of the last play and O history rows.
+func (qb *CoWatchStore) HistoryEnd(ctx context.Context) (models.CoWatchCursor, error) {
+	var ret models.CoWatchCursor
+
+	for _, h := range []struct {
+		table *viewHistoryTable
+		dest  *int
+	}{
+		{scenesViewTableMgr, &ret.ViewDateID},
+		{scenesOTableMgr, &ret.ODateID},
+	} {
+		table := h.table.table.table
+		q := dialect.From(table).Select(goqu.COALESCE(goqu.MAX(table.Col(idColumn)), 0))
+		if err := querySimple(ctx, q, h.dest); err != nil {
+			return ret, fmt.Errorf("getting end of %s: %w", table.GetTable(), err)
+		}
+	}
+
+	return ret, nil
+}
+
+// coWatchHistoryRange is the range of ids of a history table lying between
+// two cursors.
+type coWatchHistoryRange struct {
+	table *viewHistoryTable
+	after int
+	upTo  int
+}
+
+func coWatchHistoryRanges(after models.CoWatchCursor, upTo models.CoWatchCursor) []coWatchHistoryRange {
+	return []coWatchHistoryRange{
+		{scenesViewTableMgr, after.ViewDateID, upTo.ViewDateID},
+		{scenesOTableMgr, after.ODateID, upTo.ODateID},
+	}
+}
+
+// HistoryUsers returns the users with history rows between after and upTo. A
+// nil entry stands for history recorded without a user.
+func (qb *CoWatchStore) HistoryUsers(ctx context.Context, after models.CoWatchCursor, upTo models.CoWatchCursor) ([]*int, error) {
+	seen := make(map[null.Int]bool)
+	var ret []*int
+
+	for _, h := range coWatchHistoryRanges(after, upTo) {
+		table := h.table.table.table
+		idCol := table.Col(idColumn)
+		q := dialect.From(table).Select(table.Col(userIDColumn)).Distinct().
+			Where(idCol.Gt(h.after), idCol.Lte(h.upTo))
+
+		const single = false
+		if err := queryFunc(ctx, q, single, func(r *sqlx.Rows) error {
+			var userID null.Int
+			if err := r.Scan(&userID); err != nil {
+				return err
+			}
+			if !seen[userID] {
+				seen[userID] = true
+				ret = append(ret, nullIntPtr(userID))
+			}
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("getting users in %s: %w", table.GetTable(), err)
+		}
+	}
+
+	return ret, nil
+}
+
+// UserHistory returns the play and O history of a user up to upTo, ordered by
+// date. Events after the after cursor are marked as new.
+func (qb *CoWatchStore) UserHistory(ctx context.Context, userID *int, after models.CoWatchCursor, upTo models.CoWatchCursor) ([]*models.WatchEvent, error) {
+	var ret []*models.WatchEvent
+
+	for _, h := range coWatchHistoryRanges(after, upTo) {
+		table := h.table.table.table
+		userCol := table.Col(userIDColumn)
+
+		where := []exp.Expression{table.Col(idColumn).Lte(h.upTo)}
+		if userID != nil {
+			where = append(where, userCol.Eq(*userID))
+		} else {
+			where = append(where, userCol.IsNull())
+		}
+
+		q := dialect.From(table).Select(table.Col(idColumn), h.table.idColumn, h.table.dateColumn).Where(where...)
+
+		const single = false
+		if err := queryFunc(ctx, q, single, func(r *sqlx.Rows) error {
+			var id, sceneID int
+			var date Timestamp
+			if err := r.Scan(&id, &sceneID, &date); err != nil {
+				return err
+			}
+			ret = append(ret, &models.WatchEvent{
+				UserID:  userID,
+				SceneID: sceneID,
+				Date:    date.Timestamp,
+				New:     id > h.after,
+			})
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("getting history from %s: %w", table.GetTable(), err)
+		}
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Date.Before(ret[j].Date)
+	})
+
+	return ret, nil
+}
+
+// RemovedHistory returns the history removed since it was added to the model,
+// as new events ordered by date.
+func (qb *CoWatchStore) RemovedHistory(ctx context.Context) ([]*models.WatchEvent, error) {
+	table := goqu.T(coWatchRemovedTable)
+	q := dialect.From(table).Select(table.Col(sceneIDColumn), table.Col("date"), table.Col(userIDColumn)).
+		Order(table.Col("date").Asc(), table.Col(idColumn).Asc())
+
+	var ret []*models.WatchEvent
+	const single = false
+	if err := queryFunc(ctx, q, single, func(r *sqlx.Rows) error {
+		var (
+			sceneID int
+			date    Timestamp
+			userID  null.Int
+		)
+		if err := r.Scan(&sceneID, &date, &userID); err != nil {
+			return err
+		}
+		ret = append(ret, &models.WatchEvent{
+			UserID:  nullIntPtr(userID),
+			SceneID: sceneID,
+			Date:    date.Timestamp,
+			New:     true,
+		})
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("getting removed history: %w", err)
+	}
+
+	return ret, nil
+}
+
+// ClearRemovedHistory forgets the removed history, once it has been taken
+// out of the model.
+func (qb *CoWatchStore) ClearRemovedHistory(ctx context.Context) error {
+	if _, err := exec(ctx, dialect.Delete(goqu.T(coWatchRemovedTable))); err != nil {
+		return fmt.Errorf("clearing removed history: %w", err)
+	}
+
+	return nil
+}
+
+// AddPairs adds the counts of the given pairs to the model.
+func (qb *CoWatchStore) AddPairs(ctx context.Context, pairs []*models.CoWatchPair) error {
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	stmt, err := dbWrapper.Prepare(ctx, fmt.Sprintf(
+		"INSERT INTO %s (%s, %s, users, sessions) VALUES (?, ?, ?, ?) "+
+			"ON CONFLICT (%[2]s, %[3]s) DO UPDATE SET users = users + excluded.users, sessions = sessions + excluded.sessions",
+		coWatchTable, sceneIDColumn, coWatchOtherSceneIDColumn,
+	))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, p := range pairs {
+		a, b := coWatchPairOrder(p)
+		if _, err := dbWrapper.ExecStmt(ctx, stmt, a, b, p.Users, p.Sessions); err != nil {
+			return fmt.Errorf("adding co-watch pair %d-%d: %w", a, b, err)
+		}
+	}
+
+	return nil
+}
+
+// RemovePairs subtracts the counts of the given pairs from the model, and
+// removes the pairs left without counts. Pairs not in the model, such as
+// those of deleted scenes, are ignored.
+func (qb *CoWatchStore) RemovePairs(ctx context.Context, pairs []*models.CoWatchPair) error {
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	stmt, err := dbWrapper.Prepare(ctx, fmt.Sprintf(
+		"UPDATE %s SET users = MAX(users - ?, 0), sessions = MAX(sessions - ?, 0) WHERE %s = ? AND %s = ?",
+		coWatchTable, sceneIDColumn, coWatchOtherSceneIDColumn,
+	))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, p := range pairs {
+		a, b := coWatchPairOrder(p)
+		if _, err := dbWrapper.ExecStmt(ctx, stmt, p.Users, p.Sessions, a, b); err != nil {
+			return fmt.Errorf("removing co-watch pair %d-%d: %w", a, b, err)
+		}
+	}
+
+	table := qb.table()
+	q := dialect.Delete(table).Where(table.Col("users").Lte(0), table.Col("sessions").Lte(0))
+	if _, err := exec(ctx, q); err != nil {
+		return fmt.Errorf("removing empty co-watch pairs: %w", err)
+	}
+
+	return nil
+}
+
+// coWatchPairOrder returns the scenes of p with the lower id first.
+func coWatchPairOrder(p *models.CoWatchPair) (int, int) {
+	if p.SceneID > p.OtherSceneID {
+		return p.OtherSceneID, p.SceneID
+	}
+	return p.SceneID, p.OtherSceneID
+}
+
+// SetCursor records the ids of the last history rows added to the model.
+func (qb *CoWatchStore) SetCursor(ctx context.Context, cursor models.CoWatchCursor) error {
+	q := dialect.Update(goqu.T(coWatchCursorTable)).Set(goqu.Record{
+		"view_date_id": cursor.ViewDateID,
+		"o_date_id":    cursor.ODateID,
+		"updated_at":   UTCTimestamp{Timestamp{time.Now()}},
+	})
+
+	if _, err := exec(ctx, q); err != nil {
+		return fmt.Errorf("setting co-watch cursor: %w", err)
+	}
+
+	return nil
+}
+
+// Reset removes every pair from the model and rewinds the cursor, so that the
+// model is rebuilt from the start of the history.
+func (qb *CoWatchStore) Reset(ctx context.Context) error {
+	if _, err := exec(ctx, dialect.Delete(qb.table())); err != nil {
+		return fmt.Errorf("clearing co-watch pairs: %w", err)
+	}
+	if err := qb.ClearRemovedHistory(ctx); err != nil {
+		return err
+	}
+
+	return qb.SetCursor(ctx, models.CoWatchCursor{})
+}
@@ -0,0 +1,187 @@
+//go:build integration
+// +build integration
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoWatchStore(t *testing.T) {
+	withRollbackTxn(func(ctx context.Context) error {
+		assert := assert.New(t)
+		qb := db.CoWatch
+
+		after, err := qb.HistoryEnd(ctx)
+		if err != nil {
+			t.Errorf("CoWatchStore.HistoryEnd() error = %v", err)
+			return nil
+		}
+
+		userCtx := createUserDataTestUser(ctx, t, "cowatcher")
+		userID, _ := models.UserIDFromContext(userCtx)
+
+		scene1 := sceneIDs[sceneIdxWithGroup]
+		scene2 := sceneIDs[sceneIdxWithGallery]
+		now := time.Now().Truncate(time.Second)
+
+		if _, err := db.Scene.AddViews(userCtx, scene1, []time.Time{now.Add(-time.Hour)}); err != nil {
+			t.Errorf("SceneStore.AddViews() error = %v", err)
+			return nil
+		}
+		if _, err := db.Scene.AddO(userCtx, scene2, []time.Time{now}); err != nil {
+			t.Errorf("SceneStore.AddO() error = %v", err)
+			return nil
+		}
+
+		upTo, err := qb.HistoryEnd(ctx)
+		if err != nil {
+			t.Errorf("CoWatchStore.HistoryEnd() error = %v", err)
+			return nil
+		}
+		assert.Greater(upTo.ViewDateID, after.ViewDateID)
+		assert.Greater(upTo.ODateID, after.ODateID)
+
+		users, err := qb.HistoryUsers(ctx, after, upTo)
+		if err != nil {
+			t.Errorf("CoWatchStore.HistoryUsers() error = %v", err)
+			return nil
+		}
+		if assert.Len(users, 1) && assert.NotNil(users[0]) {
+			assert.Equal(userID, *users[0])
+		}
+
+		events, err := qb.UserHistory(ctx, &userID, after, upTo)
+		if err != nil {
+			t.Errorf("CoWatchStore.UserHistory() error = %v", err)
+			return nil
+		}
+		if assert.Len(events, 2) {
+			assert.Equal(scene1, events[0].SceneID)
+			assert.Equal(scene2, events[1].SceneID)
+			assert.True(events[0].New)
+			assert.True(events[1].New)
+		}
+
+		watched, err := qb.WatchedSceneIDs(userCtx)
+		if err != nil {
+			t.Errorf("CoWatchStore.WatchedSceneIDs() error = %v", err)
+			return nil
+		}
+		assert.ElementsMatch([]int{scene1, scene2}, watched)
+
+		pair := &models.CoWatchPair{SceneID: scene1, OtherSceneID: scene2, Users: 1, Sessions: 1}
+		for i := 0; i < 2; i++ {
+			if err := qb.AddPairs(ctx, []*models.CoWatchPair{pair}); err != nil {
+				t.Errorf("CoWatchStore.AddPairs() error = %v", err)
+				return nil
+			}
+		}
+
+		// pairs are returned for either of their scenes and their counts
+		// are added
+		for _, id := range []int{scene1, scene2} {
+			other := scene1 + scene2 - id
+			related, err := qb.Related(ctx, []int{id})
+			if err != nil {
+				t.Errorf("CoWatchStore.Related() error = %v", err)
+				return nil
+			}
+			assert.Equal([]*models.CoWatchPair{
+				{SceneID: id, OtherSceneID: other, Users: 2, Sessions: 2},
+			}, related)
+		}
+
+		if err := qb.SetCursor(ctx, upTo); err != nil {
+			t.Errorf("CoWatchStore.SetCursor() error = %v", err)
+			return nil
+		}
+		cursor, err := qb.GetCursor(ctx)
+		if err != nil {
+			t.Errorf("CoWatchStore.GetCursor() error = %v", err)
+			return nil
+		}
+		assert.Equal(upTo, cursor)
+
+		// history up to the cursor is no longer new
+		events, err = qb.UserHistory(ctx, &userID, cursor, upTo)
+		if err != nil {
+			t.Errorf("CoWatchStore.UserHistory() error = %v", err)
+			return nil
+		}
+		for _, e := range events {
+			assert.False(e.New)
+		}
+
+		// history removed once it is in the model is recorded
+		if _, err := db.Scene.DeleteViews(userCtx, scene1, []time.Time{now.Add(-time.Hour)}); err != nil {
+			t.Errorf("SceneStore.DeleteViews() error = %v", err)
+			return nil
+		}
+		removed, err := qb.RemovedHistory(ctx)
+		if err != nil {
+			t.Errorf("CoWatchStore.RemovedHistory() error = %v", err)
+			return nil
+		}
+		if assert.Len(removed, 1) && assert.NotNil(removed[0].UserID) {
+			assert.Equal(userID, *removed[0].UserID)
+			assert.Equal(scene1, removed[0].SceneID)
+			assert.True(removed[0].New)
+		}
+
+		// pairs are removed once they have no counts left
+		if err := qb.RemovePairs(ctx, []*models.CoWatchPair{{SceneID: scene2, OtherSceneID: scene1, Users: 1, Sessions: 1}}); err != nil {
+			t.Errorf("CoWatchStore.RemovePairs() error = %v", err)
+			return nil
+		}
+		related, err := qb.Related(ctx, []int{scene1})
+		if err != nil {
+			t.Errorf("CoWatchStore.Related() error = %v", err)
+			return nil
+		}
+		assert.Equal([]*models.CoWatchPair{
+			{SceneID: scene1, OtherSceneID: scene2, Users: 1, Sessions: 1},
+		}, related)
+		if err := qb.RemovePairs(ctx, []*models.CoWatchPair{{SceneID: scene1, OtherSceneID: scene2, Users: 1, Sessions: 1}}); err != nil {
+			t.Errorf("CoWatchStore.RemovePairs() error = %v", err)
+			return nil
+		}
+		related, err = qb.Related(ctx, []int{scene1})
+		if err != nil {
+			t.Errorf("CoWatchStore.Related() error = %v", err)
+			return nil
+		}
+		assert.Empty(related)
+
+		if err := qb.Reset(ctx); err != nil {
+			t.Errorf("CoWatchStore.Reset() error = %v", err)
+			return nil
+		}
+		related, err = qb.Related(ctx, []int{scene1, scene2})
+		if err != nil {
+			t.Errorf("CoWatchStore.Related() error = %v", err)
+			return nil
+		}
+		assert.Empty(related)
+		removed, err = qb.RemovedHistory(ctx)
+		if err != nil {
+			t.Errorf("CoWatchStore.RemovedHistory() error = %v", err)
+			return nil
+		}
+		assert.Empty(removed)
+
+		cursor, err = qb.GetCursor(ctx)
+		if err != nil {
+			t.Errorf("CoWatchStore.GetCursor() error = %v", err)
+			return nil
+		}
+		assert.Equal(models.CoWatchCursor{}, cursor)
+
+		return nil
+	})
+}
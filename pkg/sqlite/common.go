@@ -6,13 +6,26 @@ import (
 
 	"github.com/doug-martin/goqu/v9"
 	"github.com/jmoiron/sqlx"
+
+	"github.com/stashapp/stash/pkg/models"
 )
 
 type oCounterManager struct {
 	tableMgr *table
+	// userData holds per-user O-counters. It is used instead of the
+	// o_counter column when the context is scoped to a user.
+	userData *userDataTable
 }
 
 func (qb *oCounterManager) getOCounter(ctx context.Context, id int) (int, error) {
+	if userID, ok := models.UserIDFromContext(ctx); ok && qb.userData != nil {
+		var ret int
+		if err := qb.userData.get(ctx, userID, id, "o_counter", &ret); err != nil {
+			return 0, err
+		}
+		return ret, nil
+	}
+
 	q := dialect.From(qb.tableMgr.table).Select("o_counter").Where(goqu.Ex{"id": id})
 
 	const single = true
@@ -34,6 +47,15 @@ func (qb *oCounterManager) IncrementOCounter(ctx context.Context, id int) (int,
 		return 0, err
 	}
 
+	if userID, ok := models.UserIDFromContext(ctx); ok && qb.userData != nil {
+		if err := qb.userData.upsert(ctx, userID, id, goqu.Record{"o_counter": 1}, goqu.Record{
+			"o_counter": goqu.L("o_counter + 1"),
+		}); err != nil {
+			return 0, err
+		}
+		return qb.getOCounter(ctx, id)
+	}
+
 	if err := qb.tableMgr.updateByID(ctx, id, goqu.Record{
 		"o_counter": goqu.L("o_counter + 1"),
 	}); err != nil {
@@ -53,6 +75,13 @@ func (qb *oCounterManager) DecrementOCounter(ctx context.Context, id int) (int,
 		"o_counter": goqu.L("o_counter - 1"),
 	}).Where(qb.tableMgr.byID(id), goqu.L("o_counter > 0"))
 
+	if userID, ok := models.UserIDFromContext(ctx); ok && qb.userData != nil {
+		table = qb.userData.table
+		q = dialect.Update(table).Set(goqu.Record{
+			"o_counter": goqu.L("o_counter - 1"),
+		}).Where(qb.userData.idColumn.Eq(id), table.Col(userIDColumn).Eq(userID), goqu.L("o_counter > 0"))
+	}
+
 	if _, err := exec(ctx, q); err != nil {
 		return 0, fmt.Errorf("updating %s: %w", table.GetTable(), err)
 	}
@@ -65,6 +94,13 @@ func (qb *oCounterManager) ResetOCounter(ctx context.Context, id int) (int, erro
 		return 0, err
 	}
 
+	if userID, ok := models.UserIDFromContext(ctx); ok && qb.userData != nil {
+		if err := qb.userData.upsert(ctx, userID, id, goqu.Record{}, goqu.Record{"o_counter": 0}); err != nil {
+			return 0, err
+		}
+		return qb.getOCounter(ctx, id)
+	}
+
 	if err := qb.tableMgr.updateByID(ctx, id, goqu.Record{
 		"o_counter": 0,
 	}); err != nil {
@@ -31,6 +31,7 @@ var (
 
 type contentProfileRow struct {
 	ID          int            `db:"id" goqu:"skipinsert"`
+	UserID      sql.NullInt64  `db:"user_id"`
 	ProfileType string         `db:"profile_type"`
 	ProfileKey  sql.NullString `db:"profile_key"`
 	CreatedAt   time.Time      `db:"created_at"`
@@ -44,6 +45,10 @@ func (r *contentProfileRow) fromContentProfile(o *models.ContentProfile) {
 	r.CreatedAt = o.CreatedAt
 	r.UpdatedAt = o.UpdatedAt
 
+	if o.UserID != nil {
+		r.UserID = sql.NullInt64{Int64: int64(*o.UserID), Valid: true}
+	}
+
 	if o.ProfileKey != nil {
 		r.ProfileKey = sql.NullString{String: *o.ProfileKey, Valid: true}
 	}
@@ -60,6 +65,10 @@ func (r *contentProfileRow) resolve() *models.ContentProfile {
 		UpdatedAt:   r.UpdatedAt,
 	}
 
+	if r.UserID.Valid {
+		userID := int(r.UserID.Int64)
+		ret.UserID = &userID
+	}
 	if r.ProfileKey.Valid {
 		ret.ProfileKey = &r.ProfileKey.String
 	}
@@ -95,7 +104,8 @@ func (qb *ContentProfileStore) selectDataset() *goqu.SelectDataset {
 	return dialect.From(qb.table()).Select(qb.table().All())
 }
 
-// FindUserProfile retrieves the global user content profile.
+// FindUserProfile retrieves the content profile of the user ctx is scoped to,
+// or the unowned profile when ctx is not scoped.
 func (qb *ContentProfileStore) FindUserProfile(ctx context.Context) (*models.ContentProfile, error) {
 	return qb.findByTypeAndKey(ctx, "user", nil)
 }
@@ -120,11 +130,18 @@ func (qb *ContentProfileStore) find(ctx context.Context, id int) (*models.Conten
 	return qb.get(ctx, q)
 }
 
-// findByTypeAndKey retrieves a profile by type and optional key.
+// findByTypeAndKey retrieves a profile of the current user by type and
+// optional key.
 func (qb *ContentProfileStore) findByTypeAndKey(ctx context.Context, profileType string, profileKey *string) (*models.ContentProfile, error) {
 	table := qb.table()
 	q := qb.selectDataset().Where(table.Col("profile_type").Eq(profileType))
 
+	if userID, ok := models.UserIDFromContext(ctx); ok {
+		q = q.Where(table.Col(userIDColumn).Eq(userID))
+	} else {
+		q = q.Where(table.Col(userIDColumn).IsNull())
+	}
+
 	if profileKey != nil {
 		q = q.Where(table.Col("profile_key").Eq(*profileKey))
 	} else {
@@ -209,7 +226,8 @@ func (qb *ContentProfileStore) Destroy(ctx context.Context, id int) error {
 	return qb.destroyExisting(ctx, []int{id})
 }
 
-// GetOrCreateUserProfile retrieves the user profile or creates one if it doesn't exist.
+// GetOrCreateUserProfile retrieves the current user's profile or creates one if
+// it doesn't exist.
 func (qb *ContentProfileStore) GetOrCreateUserProfile(ctx context.Context) (*models.ContentProfile, error) {
 	profile, err := qb.FindUserProfile(ctx)
 	if err != nil {
@@ -222,6 +240,9 @@ func (qb *ContentProfileStore) GetOrCreateUserProfile(ctx context.Context) (*mod
 
 	// Create new user profile
 	newProfile := models.NewContentProfile()
+	if userID, ok := models.UserIDFromContext(ctx); ok {
+		newProfile.UserID = &userID
+	}
 	if err := qb.Create(ctx, &newProfile); err != nil {
 		return nil, err
 	}
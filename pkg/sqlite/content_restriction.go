@@ -0,0 +1,70 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const sceneRestrictionAlias = "scenes_restriction"
+
+// sceneRestriction returns a query selecting the IDs of the scenes visible
+// under the content restriction set on ctx, along with its arguments. It
+// returns an empty query if scene reads are unrestricted.
+func sceneRestriction(ctx context.Context) (string, []interface{}, error) {
+	f := models.SceneRestrictionFromContext(ctx)
+	if f == nil {
+		return "", nil, nil
+	}
+
+	// the restriction itself must be evaluated against the whole library
+	ctx = models.WithSceneRestriction(ctx, nil)
+
+	query := sceneRepository.newQuery()
+	distinctIDs(&query, sceneTable)
+
+	filter := filterBuilderFromHandler(ctx, &sceneFilterHandler{
+		sceneFilter: f,
+	})
+
+	if err := query.addFilter(filter); err != nil {
+		return "", nil, err
+	}
+
+	const includeSortPagination = false
+	return query.toSQL(includeSortPagination), query.args, nil
+}
+
+// addSceneRestriction joins the scenes visible under the content restriction
+// set on ctx to a scene query. It must be called before any other clause
+// with arguments is added to the query.
+func addSceneRestriction(ctx context.Context, query *queryBuilder, sceneIDColumn string) error {
+	sql, args, err := sceneRestriction(ctx)
+	if err != nil || sql == "" {
+		return err
+	}
+
+	query.addJoins(join{
+		table:    "(" + sql + ")",
+		as:       sceneRestrictionAlias,
+		onClause: sceneRestrictionAlias + ".id = " + sceneIDColumn,
+		joinType: "INNER",
+		args:     args,
+	})
+
+	return nil
+}
+
+// restrictScenes limits q to the rows whose scene ID column refers to a scene
+// visible under the content restriction set on ctx.
+func restrictScenes(ctx context.Context, q *goqu.SelectDataset, sceneIDColumn exp.IdentifierExpression) (*goqu.SelectDataset, error) {
+	sql, args, err := sceneRestriction(ctx)
+	if err != nil || sql == "" {
+		return q, err
+	}
+
+	return q.Where(goqu.L("? IN ("+sql+")", append([]interface{}{sceneIDColumn}, args...)...)), nil
+}
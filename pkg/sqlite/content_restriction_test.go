@@ -0,0 +1,124 @@
+//go:build integration
+// +build integration
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserStore_UpdateContentRestriction(t *testing.T) {
+	withRollbackTxn(func(ctx context.Context) error {
+		assert := assert.New(t)
+		qb := db.User
+
+		userCtx := createUserDataTestUser(ctx, t, "restricted")
+		userID, _ := models.UserIDFromContext(userCtx)
+
+		got, err := qb.GetContentRestriction(ctx, userID)
+		if err != nil {
+			t.Errorf("UserStore.GetContentRestriction() error = %v", err)
+			return nil
+		}
+		assert.True(got.IsEmpty())
+
+		savedFilterID := savedFilterIDs[savedFilterIdxScene]
+		restriction := models.ContentRestriction{
+			SavedFilterID:     &savedFilterID,
+			ExcludedTagIDs:    []int{tagIDs[tagIdxWithScene]},
+			ExcludedStudioIDs: []int{studioIDs[studioIdxWithScene]},
+		}
+		if err := qb.UpdateContentRestriction(ctx, userID, restriction); err != nil {
+			t.Errorf("UserStore.UpdateContentRestriction() error = %v", err)
+			return nil
+		}
+
+		got, err = qb.GetContentRestriction(ctx, userID)
+		if err != nil {
+			t.Errorf("UserStore.GetContentRestriction() error = %v", err)
+			return nil
+		}
+		assert.Equal(restriction, *got)
+
+		// clearing the restriction
+		if err := qb.UpdateContentRestriction(ctx, userID, models.ContentRestriction{}); err != nil {
+			t.Errorf("UserStore.UpdateContentRestriction() error = %v", err)
+			return nil
+		}
+
+		got, err = qb.GetContentRestriction(ctx, userID)
+		if err != nil {
+			t.Errorf("UserStore.GetContentRestriction() error = %v", err)
+			return nil
+		}
+		assert.True(got.IsEmpty())
+
+		return nil
+	})
+}
+
+func TestSceneStore_ContentRestriction(t *testing.T) {
+	withTxn(func(ctx context.Context) error {
+		assert := assert.New(t)
+		qb := db.Scene
+
+		restriction := models.ContentRestriction{
+			ExcludedTagIDs: []int{tagIDs[tagIdxWithScene]},
+		}
+		restrictedCtx := models.WithSceneRestriction(ctx, restriction.SceneFilter(nil))
+
+		hiddenID := sceneIDs[sceneIdxWithTag]
+		visibleID := sceneIDs[sceneIdxWithGallery]
+
+		// hidden scenes cannot be found by ID
+		scene, err := qb.Find(restrictedCtx, hiddenID)
+		if err != nil {
+			t.Errorf("SceneStore.Find() error = %v", err)
+			return nil
+		}
+		assert.Nil(scene)
+
+		scene, err = qb.Find(restrictedCtx, visibleID)
+		if err != nil {
+			t.Errorf("SceneStore.Find() error = %v", err)
+			return nil
+		}
+		assert.NotNil(scene)
+
+		// nor by querying
+		allPages := -1
+		scenes := queryScene(restrictedCtx, t, qb, nil, &models.FindFilterType{PerPage: &allPages})
+		ids := make([]int, len(scenes))
+		for i, s := range scenes {
+			ids[i] = s.ID
+		}
+		assert.NotContains(ids, hiddenID)
+		assert.Contains(ids, visibleID)
+
+		restrictedCount, err := qb.Count(restrictedCtx)
+		if err != nil {
+			t.Errorf("SceneStore.Count() error = %v", err)
+			return nil
+		}
+		count, err := qb.Count(ctx)
+		if err != nil {
+			t.Errorf("SceneStore.Count() error = %v", err)
+			return nil
+		}
+		assert.Equal(len(scenes), restrictedCount)
+		assert.Less(restrictedCount, count)
+
+		queryCount, err := qb.QueryCount(restrictedCtx, nil, nil)
+		if err != nil {
+			t.Errorf("SceneStore.QueryCount() error = %v", err)
+			return nil
+		}
+		assert.Equal(restrictedCount, queryCount)
+
+		return nil
+	})
+}
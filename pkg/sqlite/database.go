@@ -34,7 +34,7 @@ const (
 	cacheSizeEnv = "STASH_SQLITE_CACHE_SIZE"
 )
 
-var appSchemaVersion uint = 100
+var appSchemaVersion uint = 117
 
 //go:embed migrations/*.sql
 var migrationsBox embed.FS
@@ -82,11 +82,20 @@ type storeRepository struct {
 	PotentialScene          *PotentialSceneStore
 	ContentProfile          *ContentProfileStore
 	User                    *UserStore
+	Role                    *RoleStore
+	APIKey                  *APIKeyStore
+	AuditEntry              *AuditEntryStore
 	Playlist                *PlaylistStore
 	RecycleBin              *RecycleBinStore
 	DismissedRecommendation *DismissedRecommendationStore
 	LikedRecommendation     *LikedRecommendationStore
-	VisualSignature         *VisualSignatureStore
+	VisualEmbedding         *VisualEmbeddingStore
+	CoWatch                 *CoWatchStore
+	IPTVChannel             *IPTVChannelStore
+	ScheduledTaskRun        *ScheduledTaskRunStore
+	QueuedJob               *QueuedJobStore
+	OptimizedVersion        *OptimizedVersionStore
+	SyncTarget              *SyncTargetStore
 	Analytics               *AnalyticsStore
 }
 
@@ -136,11 +145,20 @@ func NewDatabase() *Database {
 		PotentialScene:          potentialSceneStore,
 		ContentProfile:          NewContentProfileStore(),
 		User:                    NewUserStore(),
+		Role:                    NewRoleStore(),
+		APIKey:                  NewAPIKeyStore(),
+		AuditEntry:              NewAuditEntryStore(),
 		Playlist:                NewPlaylistStore(),
 		RecycleBin:              NewRecycleBinStore(),
 		DismissedRecommendation: &DismissedRecommendationStore{},
 		LikedRecommendation:     &LikedRecommendationStore{},
-		VisualSignature:         &VisualSignatureStore{},
+		VisualEmbedding:         NewVisualEmbeddingStore(),
+		CoWatch:                 NewCoWatchStore(),
+		IPTVChannel:             NewIPTVChannelStore(blobStore),
+		ScheduledTaskRun:        NewScheduledTaskRunStore(),
+		QueuedJob:               NewQueuedJobStore(),
+		OptimizedVersion:        NewOptimizedVersionStore(),
+		SyncTarget:              NewSyncTargetStore(),
 		Analytics:               NewAnalyticsStore(30 * time.Second),
 	}
 
@@ -10,15 +10,16 @@ import (
 // DismissedRecommendationStore provides read/write access to the
 // dismissed_recommendations table.  It uses the package-level dbWrapper
 // (transaction) and getDBReader (read-only) helpers shared across the package.
+// Dismissals belong to the user the context is scoped to.
 type DismissedRecommendationStore struct{}
 
 // Dismiss records that the user has dismissed an item so it will no longer
 // appear in discovery rows.  Must be called inside a write transaction.
 func (s *DismissedRecommendationStore) Dismiss(ctx context.Context, entityType, entityKey string) error {
 	_, err := dbWrapper.Exec(ctx,
-		`INSERT OR REPLACE INTO dismissed_recommendations (entity_type, entity_key, dismissed_at)
-		 VALUES (?, ?, ?)`,
-		entityType, entityKey, time.Now().UTC(),
+		`INSERT OR REPLACE INTO dismissed_recommendations (user_id, entity_type, entity_key, dismissed_at)
+		 VALUES (?, ?, ?, ?)`,
+		currentUserID(ctx), entityType, entityKey, time.Now().UTC(),
 	)
 	return err
 }
@@ -27,8 +28,8 @@ func (s *DismissedRecommendationStore) Dismiss(ctx context.Context, entityType,
 // Must be called inside a write transaction.
 func (s *DismissedRecommendationStore) Undismiss(ctx context.Context, entityType, entityKey string) error {
 	_, err := dbWrapper.Exec(ctx,
-		`DELETE FROM dismissed_recommendations WHERE entity_type = ? AND entity_key = ?`,
-		entityType, entityKey,
+		`DELETE FROM dismissed_recommendations WHERE user_id IS ? AND entity_type = ? AND entity_key = ?`,
+		currentUserID(ctx), entityType, entityKey,
 	)
 	return err
 }
@@ -47,8 +48,8 @@ func (s *DismissedRecommendationStore) ListDismissed(ctx context.Context, entity
 
 	var rows []dismissedRow
 	if err := db.SelectContext(ctx, &rows,
-		`SELECT entity_key FROM dismissed_recommendations WHERE entity_type = ?`,
-		entityType,
+		`SELECT entity_key FROM dismissed_recommendations WHERE user_id IS ? AND entity_type = ?`,
+		currentUserID(ctx), entityType,
 	); err != nil {
 		return nil, err
 	}
@@ -78,8 +79,8 @@ func (s *DismissedRecommendationStore) ListDismissedWithTime(ctx context.Context
 	var rows []dismissedWithTimeRow
 	if err := db.SelectContext(ctx, &rows,
 		`SELECT entity_key, dismissed_at FROM dismissed_recommendations
-		 WHERE entity_type = ? ORDER BY dismissed_at DESC`,
-		entityType,
+		 WHERE user_id IS ? AND entity_type = ? ORDER BY dismissed_at DESC`,
+		currentUserID(ctx), entityType,
 	); err != nil {
 		return nil, err
 	}
@@ -358,6 +358,14 @@ func (qb *GalleryStore) UpdatePartial(ctx context.Context, id int, partial model
 		},
 	}
 
+	if userID, ok := models.UserIDFromContext(ctx); ok && partial.Rating.Set {
+		rating := partial.Rating.Ptr()
+		if err := galleriesUserDataTableMgr.upsert(ctx, userID, id, goqu.Record{"rating": rating}, goqu.Record{"rating": rating}); err != nil {
+			return nil, err
+		}
+		partial.Rating = models.OptionalInt{}
+	}
+
 	r.fromPartial(partial)
 
 	if len(r.Record) > 0 {
@@ -828,7 +836,7 @@ func (qb *GalleryStore) makeQuery(ctx context.Context, galleryFilter *models.Gal
 		return nil, err
 	}
 
-	if err := qb.setGallerySort(&query, findFilter); err != nil {
+	if err := qb.setGallerySort(ctx, &query, findFilter); err != nil {
 		return nil, err
 	}
 	query.sortAndPagination += getPagination(findFilter)
@@ -858,7 +866,7 @@ func (qb *GalleryStore) Query(ctx context.Context, galleryFilter *models.Gallery
 	}
 
 	// 2. Optimize IDs
-	if qb.canUseFastIDs(galleryFilter, findFilter) {
+	if qb.canUseFastIDs(ctx, galleryFilter, findFilter) {
 		idsResult, err = qb.findIDsFast(ctx, findFilter)
 
 		// Fall back to standard ID query if fast ID query fails
@@ -881,7 +889,7 @@ func (qb *GalleryStore) Query(ctx context.Context, galleryFilter *models.Gallery
 }
 
 // canUseFastIDs checks if we can use the fast IDs query path
-func (qb *GalleryStore) canUseFastIDs(galleryFilter *models.GalleryFilterType, findFilter *models.FindFilterType) bool {
+func (qb *GalleryStore) canUseFastIDs(ctx context.Context, galleryFilter *models.GalleryFilterType, findFilter *models.FindFilterType) bool {
 	if !qb.isUnfilteredQuery(galleryFilter, findFilter) {
 		return false
 	}
@@ -891,6 +899,10 @@ func (qb *GalleryStore) canUseFastIDs(galleryFilter *models.GalleryFilterType, f
 	}
 
 	sort := *findFilter.Sort
+	if isUserDataSort(ctx, sort) {
+		return false
+	}
+
 	fastSortColumns := map[string]bool{
 		"date":       true,
 		"created_at": true,
@@ -1059,7 +1071,7 @@ var gallerySortOptions = sortOptions{
 	"updated_at",
 }
 
-func (qb *GalleryStore) setGallerySort(query *queryBuilder, findFilter *models.FindFilterType) error {
+func (qb *GalleryStore) setGallerySort(ctx context.Context, query *queryBuilder, findFilter *models.FindFilterType) error {
 	if findFilter == nil || findFilter.Sort == nil || *findFilter.Sort == "" {
 		return nil
 	}
@@ -1125,6 +1137,8 @@ func (qb *GalleryStore) setGallerySort(query *queryBuilder, findFilter *models.F
 		addFileTable()
 		addFolderTable()
 		query.sortAndPagination += " ORDER BY COALESCE(galleries.title, files.basename, basename(COALESCE(folders.path, ''))) COLLATE NATURAL_CI " + direction + ", file_folder.path COLLATE NATURAL_CI " + direction
+	case "rating":
+		query.sortAndPagination += " ORDER BY " + galleriesUserDataTableMgr.column(ctx, sort, "galleries.id", "galleries.rating") + " " + getSortDirection(direction)
 	default:
 		query.sortAndPagination += getSort(sort, direction, "galleries")
 	}
@@ -1135,6 +1149,10 @@ func (qb *GalleryStore) setGallerySort(query *queryBuilder, findFilter *models.F
 	return nil
 }
 
+func (qb *GalleryStore) GetManyUserData(ctx context.Context, ids []int) ([]*models.UserData, error) {
+	return galleriesUserDataTableMgr.getMany(ctx, ids)
+}
+
 func (qb *GalleryStore) GetURLs(ctx context.Context, galleryID int) ([]string, error) {
 	return galleriesURLsTableMgr.get(ctx, galleryID)
 }
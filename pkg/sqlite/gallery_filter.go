@@ -87,7 +87,7 @@ func (qb *galleryFilterHandler) criterionHandler() criterionHandler {
 		qb.pathCriterionHandler(filter.Path),
 		qb.parentFolderCriterionHandler(filter.ParentFolder),
 		qb.fileCountCriterionHandler(filter.FileCount),
-		intCriterionHandler(filter.Rating100, "galleries.rating", nil),
+		qb.ratingCriterionHandler(filter.Rating100),
 		qb.urlsCriterionHandler(filter.URL),
 		boolCriterionHandler(filter.Organized, "galleries.organized", nil),
 		qb.missingCriterionHandler(filter.IsMissing),
@@ -549,3 +549,12 @@ func (qb *galleryFilterHandler) averageResolutionCriterionHandler(resolution *mo
 		}
 	}
 }
+
+// ratingCriterionHandler filters on the current user's rating when the context
+// is scoped to a user, and on the gallery's own rating otherwise.
+func (qb *galleryFilterHandler) ratingCriterionHandler(c *models.IntCriterionInput) criterionHandlerFunc {
+	return func(ctx context.Context, f *filterBuilder) {
+		col := galleriesUserDataTableMgr.column(ctx, "rating", "galleries.id", "galleries.rating")
+		intCriterionHandler(c, col, nil)(ctx, f)
+	}
+}
@@ -313,6 +313,11 @@ func (qb *GroupStore) GetScenes(ctx context.Context, groupID int) ([]models.Grou
 		scenesGroupsJoinTable.Col("scene_index"),
 	).From(scenesGroupsJoinTable).Where(scenesGroupsJoinTable.Col(groupIDColumn).Eq(groupID))
 
+	query, err := restrictScenes(ctx, query, scenesGroupsJoinTable.Col(sceneIDColumn))
+	if err != nil {
+		return nil, err
+	}
+
 	var ret []models.GroupScene
 	if err := queryFunc(ctx, query, false, func(rows *sqlx.Rows) error {
 		var r models.GroupScene
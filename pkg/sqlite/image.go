@@ -206,7 +206,7 @@ type ImageStore struct {
 func NewImageStore(r *storeRepository, stats *StatsStore) *ImageStore {
 	return &ImageStore{
 		tableMgr:        imageTableMgr,
-		oCounterManager: oCounterManager{imageTableMgr},
+		oCounterManager: oCounterManager{tableMgr: imageTableMgr, userData: imagesUserDataTableMgr},
 		repo:            r,
 		stats:           stats,
 	}
@@ -314,6 +314,14 @@ func (qb *ImageStore) UpdatePartial(ctx context.Context, id int, partial models.
 		},
 	}
 
+	if userID, ok := models.UserIDFromContext(ctx); ok && partial.Rating.Set {
+		rating := partial.Rating.Ptr()
+		if err := imagesUserDataTableMgr.upsert(ctx, userID, id, goqu.Record{"rating": rating}, goqu.Record{"rating": rating}); err != nil {
+			return nil, err
+		}
+		partial.Rating = models.OptionalInt{}
+	}
+
 	r.fromPartial(partial)
 
 	if len(r.Record) > 0 {
@@ -799,6 +807,10 @@ func (qb *ImageStore) OCount(ctx context.Context) (int, error) {
 	table := qb.table()
 
 	q := dialect.Select(goqu.COALESCE(goqu.SUM("o_counter"), 0)).From(table)
+	if userID, ok := models.UserIDFromContext(ctx); ok {
+		ut := imagesUserDataTableMgr.table
+		q = dialect.Select(goqu.COALESCE(goqu.SUM(ut.Col("o_counter")), 0)).From(ut).Where(ut.Col(userIDColumn).Eq(userID))
+	}
 	var ret int
 	if err := querySimple(ctx, q, &ret); err != nil {
 		return 0, err
@@ -807,6 +819,10 @@ func (qb *ImageStore) OCount(ctx context.Context) (int, error) {
 	return ret, nil
 }
 
+func (qb *ImageStore) GetManyUserData(ctx context.Context, ids []int) ([]*models.UserData, error) {
+	return imagesUserDataTableMgr.getMany(ctx, ids)
+}
+
 func (qb *ImageStore) FindByFolderID(ctx context.Context, folderID models.FolderID) ([]*models.Image, error) {
 	table := qb.table()
 	fileTable := goqu.T(fileTable)
@@ -930,7 +946,7 @@ func (qb *ImageStore) makeQuery(ctx context.Context, imageFilter *models.ImageFi
 		return nil, err
 	}
 
-	if err := qb.setImageSortAndPagination(&query, findFilter); err != nil {
+	if err := qb.setImageSortAndPagination(ctx, &query, findFilter); err != nil {
 		return nil, err
 	}
 
@@ -958,7 +974,7 @@ func (qb *ImageStore) Query(ctx context.Context, options models.ImageQueryOption
 
 	// TWO-PHASE OPTIMIZATION: Use fast path for simple unfiltered queries
 	var idsResult []int
-	if qb.canUseFastIDs(options) {
+	if qb.canUseFastIDs(ctx, options) {
 		idsResult, err = qb.findIDsFast(ctx, options.FindFilter)
 		if err != nil {
 			logger.Warnf("Fast IDs query failed, falling back to standard: %v", err)
@@ -986,7 +1002,7 @@ func (qb *ImageStore) Query(ctx context.Context, options models.ImageQueryOption
 
 // canUseFastIDs checks if we can use the fast IDs query path
 // This is true when no filters are applied and sort is on an indexed column
-func (qb *ImageStore) canUseFastIDs(options models.ImageQueryOptions) bool {
+func (qb *ImageStore) canUseFastIDs(ctx context.Context, options models.ImageQueryOptions) bool {
 	// Must have no filter
 	if !qb.isUnfilteredQuery(options) {
 		return false
@@ -998,6 +1014,10 @@ func (qb *ImageStore) canUseFastIDs(options models.ImageQueryOptions) bool {
 	}
 
 	sort := *options.FindFilter.Sort
+	if isUserDataSort(ctx, sort) {
+		return false
+	}
+
 	// These columns have indexes and don't require JOINs
 	fastSortColumns := map[string]bool{
 		"date":       true,
@@ -1365,7 +1385,7 @@ var imageSortOptions = sortOptions{
 	"updated_at",
 }
 
-func (qb *ImageStore) setImageSortAndPagination(q *queryBuilder, findFilter *models.FindFilterType) error {
+func (qb *ImageStore) setImageSortAndPagination(ctx context.Context, q *queryBuilder, findFilter *models.FindFilterType) error {
 	sortClause := ""
 
 	if findFilter != nil && findFilter.Sort != nil && *findFilter.Sort != "" {
@@ -1431,6 +1451,8 @@ func (qb *ImageStore) setImageSortAndPagination(q *queryBuilder, findFilter *mod
 			addFilesJoin()
 			addFolderJoin()
 			sortClause = " ORDER BY images.title COLLATE NATURAL_CI " + direction + ", files.basename COLLATE NATURAL_CI " + direction + ", folders.path COLLATE NATURAL_CI " + direction
+		case "rating", "o_counter":
+			sortClause = " ORDER BY " + imagesUserDataTableMgr.column(ctx, sort, "images.id", "images."+sort) + " " + getSortDirection(direction)
 		default:
 			sortClause = getSort(sort, direction, "images")
 		}
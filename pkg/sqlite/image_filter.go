@@ -80,8 +80,8 @@ func (qb *imageFilterHandler) criterionHandler() criterionHandler {
 		pathCriterionHandler(imageFilter.Path, "folders.path", "files.basename", imageRepository.addFoldersTableInner),
 		qb.parentFolderCriterionHandler(imageFilter.ParentFolder),
 		qb.fileCountCriterionHandler(imageFilter.FileCount),
-		intCriterionHandler(imageFilter.Rating100, "images.rating", nil),
-		intCriterionHandler(imageFilter.OCounter, "images.o_counter", nil),
+		qb.userDataIntCriterionHandler(imageFilter.Rating100, "rating"),
+		qb.userDataIntCriterionHandler(imageFilter.OCounter, "o_counter"),
 		boolCriterionHandler(imageFilter.Organized, "images.organized", nil),
 		&dateCriterionHandler{imageFilter.Date, "images.date", nil},
 		qb.urlsCriterionHandler(imageFilter.URL),
@@ -375,3 +375,12 @@ func (qb *imageFilterHandler) performerTagsCriterionHandler(tags *models.Hierarc
 		joinPrimaryKey: imageIDColumn,
 	}
 }
+
+// userDataIntCriterionHandler filters on the current user's value of column
+// when the context is scoped to a user, and on the image's own column otherwise.
+func (qb *imageFilterHandler) userDataIntCriterionHandler(c *models.IntCriterionInput, column string) criterionHandlerFunc {
+	return func(ctx context.Context, f *filterBuilder) {
+		col := imagesUserDataTableMgr.column(ctx, column, "images.id", "images."+column)
+		intCriterionHandler(c, col, nil)(ctx, f)
+	}
+}
@@ -0,0 +1,335 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/guregu/null.v4"
+	"gopkg.in/guregu/null.v4/zero"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const (
+	iptvChannelTable          = "iptv_channels"
+	iptvChannelBlocksTable    = "iptv_channel_blocks"
+	iptvChannelNumberColumn   = "number"
+	iptvChannelNameColumn     = "name"
+	iptvChannelLogoBlobColumn = "logo_blob"
+)
+
+var iptvChannelsTableMgr = &table{
+	table:    goqu.T(iptvChannelTable),
+	idColumn: goqu.T(iptvChannelTable).Col(idColumn),
+}
+
+// iptvChannelBlocksTableMgr addresses blocks by their channel, so that
+// destroy removes all of a channel's blocks.
+var iptvChannelBlocksTableMgr = &table{
+	table:    goqu.T(iptvChannelBlocksTable),
+	idColumn: goqu.T(iptvChannelBlocksTable).Col("channel_id"),
+}
+
+type iptvChannelRow struct {
+	ID          int       `db:"id" goqu:"skipinsert"`
+	Name        string    `db:"name"`
+	Number      null.Int  `db:"number"`
+	SourceType  string    `db:"source_type"`
+	SourceID    int       `db:"source_id"`
+	ShuffleSeed null.Int  `db:"shuffle_seed"`
+	CreatedAt   Timestamp `db:"created_at"`
+	UpdatedAt   Timestamp `db:"updated_at"`
+
+	// not used in resolutions or updates
+	LogoBlob zero.String `db:"logo_blob"`
+}
+
+func (r *iptvChannelRow) fromIPTVChannel(o models.IPTVChannel) {
+	r.ID = o.ID
+	r.Name = o.Name
+	r.Number = intFromPtr(o.Number)
+	r.SourceType = string(o.SourceType)
+	r.SourceID = o.SourceID
+	r.ShuffleSeed = intFromPtr(o.ShuffleSeed)
+	r.CreatedAt = Timestamp{Timestamp: o.CreatedAt}
+	r.UpdatedAt = Timestamp{Timestamp: o.UpdatedAt}
+}
+
+func (r *iptvChannelRow) resolve() *models.IPTVChannel {
+	return &models.IPTVChannel{
+		ID:          r.ID,
+		Name:        r.Name,
+		Number:      nullIntPtr(r.Number),
+		SourceType:  models.IPTVChannelSource(r.SourceType),
+		SourceID:    r.SourceID,
+		ShuffleSeed: nullIntPtr(r.ShuffleSeed),
+		CreatedAt:   r.CreatedAt.Timestamp,
+		UpdatedAt:   r.UpdatedAt.Timestamp,
+	}
+}
+
+type iptvChannelBlockRow struct {
+	ID          int         `db:"id" goqu:"skipinsert"`
+	ChannelID   int         `db:"channel_id"`
+	Position    int         `db:"position"`
+	Name        zero.String `db:"name"`
+	Days        int         `db:"days"`
+	StartMinute int         `db:"start_minute"`
+	EndMinute   int         `db:"end_minute"`
+	SourceType  string      `db:"source_type"`
+	SourceID    int         `db:"source_id"`
+}
+
+func (r *iptvChannelBlockRow) fromIPTVChannelBlock(o models.IPTVChannelBlock) {
+	r.ChannelID = o.ChannelID
+	r.Name = zero.StringFrom(o.Name)
+	r.Days = 0
+	for _, d := range o.Days {
+		r.Days |= 1 << d
+	}
+	r.StartMinute = o.StartMinute
+	r.EndMinute = o.EndMinute
+	r.SourceType = string(o.SourceType)
+	r.SourceID = o.SourceID
+}
+
+func (r *iptvChannelBlockRow) resolve() *models.IPTVChannelBlock {
+	ret := &models.IPTVChannelBlock{
+		ID:          r.ID,
+		ChannelID:   r.ChannelID,
+		Name:        r.Name.String,
+		Days:        []int{},
+		StartMinute: r.StartMinute,
+		EndMinute:   r.EndMinute,
+		SourceType:  models.IPTVChannelSource(r.SourceType),
+		SourceID:    r.SourceID,
+	}
+	for d := 0; d < 7; d++ {
+		if r.Days&(1<<d) != 0 {
+			ret.Days = append(ret.Days, d)
+		}
+	}
+	return ret
+}
+
+type iptvChannelRowRecord struct {
+	updateRecord
+}
+
+func (r *iptvChannelRowRecord) fromPartial(o models.IPTVChannelPartial) {
+	r.setString("name", o.Name)
+	r.setNullInt("number", o.Number)
+	r.setString("source_type", o.SourceType)
+	r.setInt("source_id", o.SourceID)
+	r.setNullInt("shuffle_seed", o.ShuffleSeed)
+	r.setTimestamp("updated_at", o.UpdatedAt)
+}
+
+// IPTVChannelStore provides methods for user-defined IPTV channels.
+type IPTVChannelStore struct {
+	blobJoinQueryBuilder
+	repository
+
+	tableMgr *table
+}
+
+// NewIPTVChannelStore creates a new IPTVChannelStore
+func NewIPTVChannelStore(blobStore *BlobStore) *IPTVChannelStore {
+	return &IPTVChannelStore{
+		blobJoinQueryBuilder: blobJoinQueryBuilder{
+			blobStore: blobStore,
+			joinTable: iptvChannelTable,
+		},
+		repository: repository{
+			tableName: iptvChannelTable,
+			idColumn:  idColumn,
+		},
+		tableMgr: iptvChannelsTableMgr,
+	}
+}
+
+func (qb *IPTVChannelStore) table() exp.IdentifierExpression {
+	return qb.tableMgr.table
+}
+
+func (qb *IPTVChannelStore) selectDataset() *goqu.SelectDataset {
+	return dialect.From(qb.table()).Select(qb.table().All())
+}
+
+func (qb *IPTVChannelStore) Create(ctx context.Context, newChannel *models.IPTVChannel) error {
+	var r iptvChannelRow
+	r.fromIPTVChannel(*newChannel)
+
+	id, err := qb.tableMgr.insertID(ctx, r)
+	if err != nil {
+		return fmt.Errorf("creating iptv channel: %w", err)
+	}
+
+	updated, err := qb.Find(ctx, id)
+	if err != nil {
+		return fmt.Errorf("finding after create: %w", err)
+	}
+
+	*newChannel = *updated
+
+	return nil
+}
+
+func (qb *IPTVChannelStore) UpdatePartial(ctx context.Context, id int, partial models.IPTVChannelPartial) (*models.IPTVChannel, error) {
+	r := iptvChannelRowRecord{
+		updateRecord{
+			Record: make(exp.Record),
+		},
+	}
+
+	r.fromPartial(partial)
+
+	if len(r.Record) > 0 {
+		if err := qb.tableMgr.updateByID(ctx, id, r.Record); err != nil {
+			return nil, fmt.Errorf("updating iptv channel %d: %w", id, err)
+		}
+	}
+
+	return qb.Find(ctx, id)
+}
+
+func (qb *IPTVChannelStore) Destroy(ctx context.Context, id int) error {
+	// must handle logo checksums manually
+	if err := qb.DestroyImage(ctx, id, iptvChannelLogoBlobColumn); err != nil {
+		return err
+	}
+
+	return qb.destroyExisting(ctx, []int{id})
+}
+
+// Find returns an IPTV channel by ID, or nil if not found
+func (qb *IPTVChannelStore) Find(ctx context.Context, id int) (*models.IPTVChannel, error) {
+	ret, err := qb.get(ctx, qb.selectDataset().Where(qb.tableMgr.byID(id)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting iptv channel by id %d: %w", id, err)
+	}
+
+	return ret, nil
+}
+
+// FindByNumber returns the IPTV channel with the given number, or nil if
+// there is none
+func (qb *IPTVChannelStore) FindByNumber(ctx context.Context, number int) (*models.IPTVChannel, error) {
+	ret, err := qb.get(ctx, qb.selectDataset().Where(qb.table().Col(iptvChannelNumberColumn).Eq(number)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting iptv channel by number %d: %w", number, err)
+	}
+
+	return ret, nil
+}
+
+// FindAll returns all IPTV channels, numbered channels first
+func (qb *IPTVChannelStore) FindAll(ctx context.Context) ([]*models.IPTVChannel, error) {
+	table := qb.table()
+	q := qb.selectDataset().Order(
+		table.Col(iptvChannelNumberColumn).Asc().NullsLast(),
+		table.Col(iptvChannelNameColumn).Asc(),
+		table.Col(idColumn).Asc(),
+	)
+
+	return qb.getMany(ctx, q)
+}
+
+func (qb *IPTVChannelStore) GetLogo(ctx context.Context, id int) ([]byte, error) {
+	return qb.blobJoinQueryBuilder.GetImage(ctx, id, iptvChannelLogoBlobColumn)
+}
+
+func (qb *IPTVChannelStore) HasLogo(ctx context.Context, id int) (bool, error) {
+	return qb.blobJoinQueryBuilder.HasImage(ctx, id, iptvChannelLogoBlobColumn)
+}
+
+func (qb *IPTVChannelStore) UpdateLogo(ctx context.Context, id int, image []byte) error {
+	return qb.blobJoinQueryBuilder.UpdateImage(ctx, id, iptvChannelLogoBlobColumn, image)
+}
+
+// GetBlocks returns the blocks of a channel in the order they were set.
+func (qb *IPTVChannelStore) GetBlocks(ctx context.Context, id int) ([]*models.IPTVChannelBlock, error) {
+	table := iptvChannelBlocksTableMgr.table
+	q := dialect.From(table).Select(table.All()).
+		Where(iptvChannelBlocksTableMgr.byID(id)).
+		Order(table.Col("position").Asc())
+
+	const single = false
+	var ret []*models.IPTVChannelBlock
+	if err := queryFunc(ctx, q, single, func(r *sqlx.Rows) error {
+		var row iptvChannelBlockRow
+		if err := r.StructScan(&row); err != nil {
+			return err
+		}
+		ret = append(ret, row.resolve())
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("getting blocks of iptv channel %d: %w", id, err)
+	}
+
+	return ret, nil
+}
+
+// UpdateBlocks replaces the blocks of a channel.
+func (qb *IPTVChannelStore) UpdateBlocks(ctx context.Context, id int, blocks []*models.IPTVChannelBlock) error {
+	if err := iptvChannelBlocksTableMgr.destroy(ctx, []int{id}); err != nil {
+		return err
+	}
+
+	for i, b := range blocks {
+		var r iptvChannelBlockRow
+		r.fromIPTVChannelBlock(*b)
+		r.ChannelID = id
+		r.Position = i
+
+		blockID, err := iptvChannelBlocksTableMgr.insertID(ctx, r)
+		if err != nil {
+			return err
+		}
+		b.ID = blockID
+		b.ChannelID = id
+	}
+
+	return nil
+}
+
+func (qb *IPTVChannelStore) get(ctx context.Context, q *goqu.SelectDataset) (*models.IPTVChannel, error) {
+	ret, err := qb.getMany(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ret) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return ret[0], nil
+}
+
+func (qb *IPTVChannelStore) getMany(ctx context.Context, q *goqu.SelectDataset) ([]*models.IPTVChannel, error) {
+	const single = false
+	var ret []*models.IPTVChannel
+	if err := queryFunc(ctx, q, single, func(r *sqlx.Rows) error {
+		var row iptvChannelRow
+		if err := r.StructScan(&row); err != nil {
+			return err
+		}
+		ret = append(ret, row.resolve())
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,176 @@
+//go:build integration
+// +build integration
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPTVChannelStore(t *testing.T) {
+	withRollbackTxn(func(ctx context.Context) error {
+		assert := assert.New(t)
+		qb := db.IPTVChannel
+
+		number := 5
+		seed := 42
+		pinned := models.NewIPTVChannel()
+		pinned.Name = "Pinned"
+		pinned.Number = &number
+		pinned.SourceType = models.IPTVChannelSourceTag
+		pinned.SourceID = tagIDs[tagIdxWithScene]
+		pinned.ShuffleSeed = &seed
+		if err := qb.Create(ctx, &pinned); err != nil {
+			t.Errorf("IPTVChannelStore.Create() error = %v", err)
+			return nil
+		}
+
+		floating := models.NewIPTVChannel()
+		floating.Name = "Floating"
+		floating.SourceType = models.IPTVChannelSourcePerformer
+		floating.SourceID = performerIDs[performerIdxWithScene]
+		if err := qb.Create(ctx, &floating); err != nil {
+			t.Errorf("IPTVChannelStore.Create() error = %v", err)
+			return nil
+		}
+
+		// numbered channels come first
+		all, err := qb.FindAll(ctx)
+		if err != nil {
+			t.Errorf("IPTVChannelStore.FindAll() error = %v", err)
+			return nil
+		}
+		if assert.Len(all, 2) {
+			assert.Equal(pinned.ID, all[0].ID)
+			assert.Equal(floating.ID, all[1].ID)
+			assert.Equal(number, *all[0].Number)
+			assert.Equal(seed, *all[0].ShuffleSeed)
+			assert.Nil(all[1].Number)
+		}
+
+		found, err := qb.FindByNumber(ctx, number)
+		if err != nil {
+			t.Errorf("IPTVChannelStore.FindByNumber() error = %v", err)
+			return nil
+		}
+		if assert.NotNil(found) {
+			assert.Equal(pinned.ID, found.ID)
+		}
+
+		partial := models.NewIPTVChannelPartial()
+		partial.Name = models.NewOptionalString("Renamed")
+		partial.Number = models.OptionalInt{Set: true, Null: true}
+		updated, err := qb.UpdatePartial(ctx, pinned.ID, partial)
+		if err != nil {
+			t.Errorf("IPTVChannelStore.UpdatePartial() error = %v", err)
+			return nil
+		}
+		assert.Equal("Renamed", updated.Name)
+		assert.Nil(updated.Number)
+		assert.Equal(models.IPTVChannelSourceTag, updated.SourceType)
+
+		if err := qb.UpdateLogo(ctx, pinned.ID, []byte("logo")); err != nil {
+			t.Errorf("IPTVChannelStore.UpdateLogo() error = %v", err)
+			return nil
+		}
+		hasLogo, err := qb.HasLogo(ctx, pinned.ID)
+		if err != nil {
+			t.Errorf("IPTVChannelStore.HasLogo() error = %v", err)
+			return nil
+		}
+		assert.True(hasLogo)
+
+		if err := qb.Destroy(ctx, pinned.ID); err != nil {
+			t.Errorf("IPTVChannelStore.Destroy() error = %v", err)
+			return nil
+		}
+
+		found, err = qb.Find(ctx, pinned.ID)
+		if err != nil {
+			t.Errorf("IPTVChannelStore.Find() error = %v", err)
+			return nil
+		}
+		assert.Nil(found)
+
+		return nil
+	})
+}
+
+func TestIPTVChannelStoreBlocks(t *testing.T) {
+	withRollbackTxn(func(ctx context.Context) error {
+		assert := assert.New(t)
+		qb := db.IPTVChannel
+
+		c := models.NewIPTVChannel()
+		c.Name = "Blocks"
+		c.SourceType = models.IPTVChannelSourceTag
+		c.SourceID = tagIDs[tagIdxWithScene]
+		if err := qb.Create(ctx, &c); err != nil {
+			t.Errorf("IPTVChannelStore.Create() error = %v", err)
+			return nil
+		}
+
+		blocks := []*models.IPTVChannelBlock{
+			{
+				Name:        "Late",
+				Days:        []int{0, 5, 6},
+				StartMinute: 22 * 60,
+				EndMinute:   2 * 60,
+				SourceType:  models.IPTVChannelSourcePlaylist,
+				SourceID:    1,
+			},
+			{
+				Days:        []int{},
+				StartMinute: 12 * 60,
+				EndMinute:   13 * 60,
+				SourceType:  models.IPTVChannelSourcePerformer,
+				SourceID:    performerIDs[performerIdxWithScene],
+			},
+		}
+		if err := qb.UpdateBlocks(ctx, c.ID, blocks); err != nil {
+			t.Errorf("IPTVChannelStore.UpdateBlocks() error = %v", err)
+			return nil
+		}
+
+		got, err := qb.GetBlocks(ctx, c.ID)
+		if err != nil {
+			t.Errorf("IPTVChannelStore.GetBlocks() error = %v", err)
+			return nil
+		}
+		// blocks keep the order they were set in
+		assert.Equal(blocks, got)
+
+		// setting blocks replaces the previous ones
+		if err := qb.UpdateBlocks(ctx, c.ID, blocks[1:]); err != nil {
+			t.Errorf("IPTVChannelStore.UpdateBlocks() error = %v", err)
+			return nil
+		}
+		got, err = qb.GetBlocks(ctx, c.ID)
+		if err != nil {
+			t.Errorf("IPTVChannelStore.GetBlocks() error = %v", err)
+			return nil
+		}
+		if assert.Len(got, 1) {
+			assert.Empty(got[0].Days)
+			assert.Equal(blocks[1].ID, got[0].ID)
+		}
+
+		// destroying the channel removes its blocks
+		if err := qb.Destroy(ctx, c.ID); err != nil {
+			t.Errorf("IPTVChannelStore.Destroy() error = %v", err)
+			return nil
+		}
+		got, err = qb.GetBlocks(ctx, c.ID)
+		if err != nil {
+			t.Errorf("IPTVChannelStore.GetBlocks() error = %v", err)
+			return nil
+		}
+		assert.Empty(got)
+
+		return nil
+	})
+}
@@ -7,16 +7,17 @@ import (
 
 // LikedRecommendationStore persists explicit positive feedback from the user.
 // It uses the same package-level dbWrapper / getDBReader helpers as the
-// DismissedRecommendationStore.
+// DismissedRecommendationStore.  Likes belong to the user the context is
+// scoped to.
 type LikedRecommendationStore struct{}
 
 // Like records that the user liked an item.  Idempotent (INSERT OR REPLACE).
 // Must be called inside a write transaction.
 func (s *LikedRecommendationStore) Like(ctx context.Context, entityType, entityKey string) error {
 	_, err := dbWrapper.Exec(ctx,
-		`INSERT OR REPLACE INTO liked_recommendations (entity_type, entity_key, liked_at)
-		 VALUES (?, ?, ?)`,
-		entityType, entityKey, time.Now().UTC(),
+		`INSERT OR REPLACE INTO liked_recommendations (user_id, entity_type, entity_key, liked_at)
+		 VALUES (?, ?, ?, ?)`,
+		currentUserID(ctx), entityType, entityKey, time.Now().UTC(),
 	)
 	return err
 }
@@ -24,8 +25,8 @@ func (s *LikedRecommendationStore) Like(ctx context.Context, entityType, entityK
 // Unlike removes a previous like.  Must be called inside a write transaction.
 func (s *LikedRecommendationStore) Unlike(ctx context.Context, entityType, entityKey string) error {
 	_, err := dbWrapper.Exec(ctx,
-		`DELETE FROM liked_recommendations WHERE entity_type = ? AND entity_key = ?`,
-		entityType, entityKey,
+		`DELETE FROM liked_recommendations WHERE user_id IS ? AND entity_type = ? AND entity_key = ?`,
+		currentUserID(ctx), entityType, entityKey,
 	)
 	return err
 }
@@ -39,8 +40,8 @@ func (s *LikedRecommendationStore) IsLiked(ctx context.Context, entityType, enti
 	}
 	var count int
 	if err := db.GetContext(ctx, &count,
-		`SELECT COUNT(*) FROM liked_recommendations WHERE entity_type = ? AND entity_key = ?`,
-		entityType, entityKey,
+		`SELECT COUNT(*) FROM liked_recommendations WHERE user_id IS ? AND entity_type = ? AND entity_key = ?`,
+		currentUserID(ctx), entityType, entityKey,
 	); err != nil {
 		return false, err
 	}
@@ -61,8 +62,8 @@ func (s *LikedRecommendationStore) ListLiked(ctx context.Context, entityType str
 	}
 	var rows []likedRow
 	if err := db.SelectContext(ctx, &rows,
-		`SELECT entity_key FROM liked_recommendations WHERE entity_type = ?`,
-		entityType,
+		`SELECT entity_key FROM liked_recommendations WHERE user_id IS ? AND entity_type = ?`,
+		currentUserID(ctx), entityType,
 	); err != nil {
 		return nil, err
 	}
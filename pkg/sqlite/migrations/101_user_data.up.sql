@@ -0,0 +1,71 @@
+-- Migration 101: Per-user watch history, resume points, O-counts and ratings
+-- Play and O history rows are attributed to the user that recorded them.
+-- Rows with a NULL user_id were recorded without a user (single-user mode,
+-- tasks, plugins) and only count towards library-wide totals.
+
+ALTER TABLE `scenes_view_dates` ADD COLUMN `user_id` integer REFERENCES `users`(`id`) ON DELETE CASCADE;
+ALTER TABLE `scenes_o_dates` ADD COLUMN `user_id` integer REFERENCES `users`(`id`) ON DELETE CASCADE;
+
+CREATE INDEX `index_scenes_view_dates_user_scene` ON `scenes_view_dates` (`user_id`, `scene_id`);
+CREATE INDEX `index_scenes_o_dates_user_scene` ON `scenes_o_dates` (`user_id`, `scene_id`);
+
+-- Per-user scene state: rating, resume position and accumulated play time
+CREATE TABLE `scenes_users` (
+  `scene_id` integer NOT NULL,
+  `user_id` integer NOT NULL,
+  `rating` tinyint,
+  `resume_time` float NOT NULL DEFAULT 0,
+  `play_duration` float NOT NULL DEFAULT 0,
+  PRIMARY KEY (`scene_id`, `user_id`),
+  FOREIGN KEY (`scene_id`) REFERENCES `scenes`(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
+
+-- Per-user image state: rating and O-counter
+CREATE TABLE `images_users` (
+  `image_id` integer NOT NULL,
+  `user_id` integer NOT NULL,
+  `rating` tinyint,
+  `o_counter` integer NOT NULL DEFAULT 0,
+  PRIMARY KEY (`image_id`, `user_id`),
+  FOREIGN KEY (`image_id`) REFERENCES `images`(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
+
+-- Per-user gallery state: rating
+CREATE TABLE `galleries_users` (
+  `gallery_id` integer NOT NULL,
+  `user_id` integer NOT NULL,
+  `rating` tinyint,
+  PRIMARY KEY (`gallery_id`, `user_id`),
+  FOREIGN KEY (`gallery_id`) REFERENCES `galleries`(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX `index_scenes_users_user` ON `scenes_users` (`user_id`);
+CREATE INDEX `index_images_users_user` ON `images_users` (`user_id`);
+CREATE INDEX `index_galleries_users_user` ON `galleries_users` (`user_id`);
+
+-- Attribute the existing global values to the first admin account. When no
+-- admin exists yet the values stay global and are claimed by the first admin
+-- created later.
+UPDATE `scenes_view_dates` SET `user_id` = (SELECT MIN(`id`) FROM `users` WHERE `role` = 'admin');
+UPDATE `scenes_o_dates` SET `user_id` = (SELECT MIN(`id`) FROM `users` WHERE `role` = 'admin');
+
+INSERT INTO `scenes_users` (`scene_id`, `user_id`, `rating`, `resume_time`, `play_duration`)
+SELECT `scenes`.`id`, `admin`.`id`, `scenes`.`rating`, `scenes`.`resume_time`, `scenes`.`play_duration`
+FROM `scenes`
+JOIN (SELECT MIN(`id`) AS `id` FROM `users` WHERE `role` = 'admin') AS `admin` ON `admin`.`id` IS NOT NULL
+WHERE `scenes`.`rating` IS NOT NULL OR `scenes`.`resume_time` > 0 OR `scenes`.`play_duration` > 0;
+
+INSERT INTO `images_users` (`image_id`, `user_id`, `rating`, `o_counter`)
+SELECT `images`.`id`, `admin`.`id`, `images`.`rating`, `images`.`o_counter`
+FROM `images`
+JOIN (SELECT MIN(`id`) AS `id` FROM `users` WHERE `role` = 'admin') AS `admin` ON `admin`.`id` IS NOT NULL
+WHERE `images`.`rating` IS NOT NULL OR `images`.`o_counter` > 0;
+
+INSERT INTO `galleries_users` (`gallery_id`, `user_id`, `rating`)
+SELECT `galleries`.`id`, `admin`.`id`, `galleries`.`rating`
+FROM `galleries`
+JOIN (SELECT MIN(`id`) AS `id` FROM `users` WHERE `role` = 'admin') AS `admin` ON `admin`.`id` IS NOT NULL
+WHERE `galleries`.`rating` IS NOT NULL;
@@ -0,0 +1,44 @@
+-- Migration 102: Per-user content profiles and recommendation feedback
+-- Content profiles, dismissed and liked recommendations are keyed by user.
+-- Rows with a NULL user_id belong to single-user mode.
+
+ALTER TABLE `content_profiles` ADD COLUMN `user_id` integer REFERENCES `users`(`id`) ON DELETE CASCADE;
+
+-- There was a single library-wide profile. Like the dismissed and liked
+-- recommendations below, it goes to the first admin, and stays unowned in
+-- single-user mode.
+UPDATE `content_profiles` SET `user_id` = (SELECT MIN(`id`) FROM `users` WHERE `role` = 'admin')
+WHERE `id` = (SELECT MIN(`id`) FROM `content_profiles` WHERE `profile_type` = 'user');
+
+-- Keep a single unowned profile of each type; the others are rebuilt on demand
+DELETE FROM `content_profiles`
+WHERE `user_id` IS NULL AND `id` NOT IN (
+  SELECT MIN(`id`) FROM `content_profiles` WHERE `user_id` IS NULL GROUP BY `profile_type`, `profile_key`
+);
+
+DROP INDEX `idx_content_profiles_type_key`;
+CREATE UNIQUE INDEX `idx_content_profiles_user_type_key` ON `content_profiles` (IFNULL(`user_id`, 0), `profile_type`, IFNULL(`profile_key`, ''));
+
+CREATE TABLE `dismissed_recommendations_new` (
+  `user_id` integer REFERENCES `users`(`id`) ON DELETE CASCADE,
+  `entity_type` text NOT NULL,
+  `entity_key` text NOT NULL,
+  `dismissed_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO `dismissed_recommendations_new` (`user_id`, `entity_type`, `entity_key`, `dismissed_at`)
+SELECT (SELECT MIN(`id`) FROM `users` WHERE `role` = 'admin'), `entity_type`, `entity_key`, `dismissed_at` FROM `dismissed_recommendations`;
+DROP TABLE `dismissed_recommendations`;
+ALTER TABLE `dismissed_recommendations_new` RENAME TO `dismissed_recommendations`;
+CREATE UNIQUE INDEX `idx_dismissed_recommendations_user_entity` ON `dismissed_recommendations` (IFNULL(`user_id`, 0), `entity_type`, `entity_key`);
+
+CREATE TABLE `liked_recommendations_new` (
+  `user_id` integer REFERENCES `users`(`id`) ON DELETE CASCADE,
+  `entity_type` text NOT NULL,
+  `entity_key` text NOT NULL,
+  `liked_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO `liked_recommendations_new` (`user_id`, `entity_type`, `entity_key`, `liked_at`)
+SELECT (SELECT MIN(`id`) FROM `users` WHERE `role` = 'admin'), `entity_type`, `entity_key`, `liked_at` FROM `liked_recommendations`;
+DROP TABLE `liked_recommendations`;
+ALTER TABLE `liked_recommendations_new` RENAME TO `liked_recommendations`;
+CREATE UNIQUE INDEX `idx_liked_recommendations_user_entity` ON `liked_recommendations` (IFNULL(`user_id`, 0), `entity_type`, `entity_key`);
@@ -0,0 +1,29 @@
+-- Migration 103: Per-user content restrictions
+-- A restricted user only sees the scenes matching their saved filter that
+-- carry none of their excluded tags or studios.
+
+CREATE TABLE `users_content_filters` (
+  `user_id` integer NOT NULL PRIMARY KEY,
+  `saved_filter_id` integer NOT NULL,
+  FOREIGN KEY(`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE,
+  -- a saved filter in use by a restriction cannot be deleted
+  FOREIGN KEY(`saved_filter_id`) REFERENCES `saved_filters`(`id`) ON DELETE RESTRICT
+);
+
+CREATE INDEX `index_users_content_filters_on_saved_filter_id` ON `users_content_filters` (`saved_filter_id`);
+
+CREATE TABLE `users_excluded_tags` (
+  `user_id` integer NOT NULL,
+  `tag_id` integer NOT NULL,
+  FOREIGN KEY(`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE,
+  FOREIGN KEY(`tag_id`) REFERENCES `tags`(`id`) ON DELETE CASCADE,
+  PRIMARY KEY(`user_id`, `tag_id`)
+);
+
+CREATE TABLE `users_excluded_studios` (
+  `user_id` integer NOT NULL,
+  `studio_id` integer NOT NULL,
+  FOREIGN KEY(`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE,
+  FOREIGN KEY(`studio_id`) REFERENCES `studios`(`id`) ON DELETE CASCADE,
+  PRIMARY KEY(`user_id`, `studio_id`)
+);
@@ -0,0 +1,24 @@
+-- Migration 104: Custom user roles
+-- Admin-defined roles grant a set of permissions to the users assigned to
+-- them, on top of the permissions of their built-in role.
+
+CREATE TABLE `roles` (
+  `id` integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+  `name` varchar(255) NOT NULL,
+  `description` text NOT NULL DEFAULT '',
+  `created_at` datetime NOT NULL,
+  `updated_at` datetime NOT NULL
+);
+
+CREATE UNIQUE INDEX `index_roles_on_name` ON `roles` (`name` COLLATE NOCASE);
+
+CREATE TABLE `roles_permissions` (
+  `role_id` integer NOT NULL,
+  `permission` varchar(255) NOT NULL,
+  FOREIGN KEY(`role_id`) REFERENCES `roles`(`id`) ON DELETE CASCADE,
+  PRIMARY KEY(`role_id`, `permission`)
+);
+
+ALTER TABLE `users` ADD COLUMN `custom_role_id` integer REFERENCES `roles`(`id`) ON DELETE SET NULL;
+
+CREATE INDEX `index_users_on_custom_role_id` ON `users` (`custom_role_id`);
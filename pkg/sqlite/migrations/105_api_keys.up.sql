@@ -0,0 +1,25 @@
+-- Migration 105: Named API keys
+-- Users issue a key per third-party tool, each limited to a set of scopes and
+-- optionally expiring. Only a hash of the key is stored.
+
+CREATE TABLE `api_keys` (
+  `id` integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+  `user_id` integer NOT NULL,
+  `name` varchar(255) NOT NULL,
+  `key_prefix` varchar(16) NOT NULL,
+  `key_hash` varchar(64) NOT NULL,
+  `expires_at` datetime,
+  `last_used_at` datetime,
+  `created_at` datetime NOT NULL,
+  FOREIGN KEY(`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX `index_api_keys_on_key_hash` ON `api_keys` (`key_hash`);
+CREATE INDEX `index_api_keys_on_user_id` ON `api_keys` (`user_id`);
+
+CREATE TABLE `api_keys_scopes` (
+  `api_key_id` integer NOT NULL,
+  `scope` varchar(255) NOT NULL,
+  FOREIGN KEY(`api_key_id`) REFERENCES `api_keys`(`id`) ON DELETE CASCADE,
+  PRIMARY KEY(`api_key_id`, `scope`)
+);
@@ -0,0 +1,29 @@
+-- Migration 106: Audit log
+-- Records who made each GraphQL mutation and destructive REST call, and the
+-- fields it changed. Entries keep the username so they outlive the user.
+
+CREATE TABLE `audit_entries` (
+  `id` integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+  `user_id` integer,
+  `username` varchar(255) NOT NULL DEFAULT '',
+  `operation` varchar(255) NOT NULL,
+  `entity_type` varchar(255) NOT NULL DEFAULT '',
+  `changes` text,
+  `error` text NOT NULL DEFAULT '',
+  `ip_address` varchar(255) NOT NULL DEFAULT '',
+  `created_at` datetime NOT NULL,
+  FOREIGN KEY(`user_id`) REFERENCES `users`(`id`) ON DELETE SET NULL
+);
+
+CREATE INDEX `index_audit_entries_on_created_at` ON `audit_entries` (`created_at`);
+CREATE INDEX `index_audit_entries_on_user_id` ON `audit_entries` (`user_id`);
+CREATE INDEX `index_audit_entries_on_operation` ON `audit_entries` (`operation`);
+
+CREATE TABLE `audit_entries_entities` (
+  `audit_entry_id` integer NOT NULL,
+  `entity_id` varchar(255) NOT NULL,
+  FOREIGN KEY(`audit_entry_id`) REFERENCES `audit_entries`(`id`) ON DELETE CASCADE,
+  PRIMARY KEY(`audit_entry_id`, `entity_id`)
+);
+
+CREATE INDEX `index_audit_entries_entities_on_entity_id` ON `audit_entries_entities` (`entity_id`);
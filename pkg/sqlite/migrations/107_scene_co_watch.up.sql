@@ -0,0 +1,73 @@
+-- Migration 107: Co-watch recommendation model
+-- Counts how often pairs of scenes are watched by the same users or in the
+-- same session. The model is built incrementally from the play and O
+-- history, so the history tables are recreated with an id that is never
+-- reused and is kept by VACUUM.
+
+PRAGMA foreign_keys=OFF;
+
+CREATE TABLE `scenes_view_dates_new` (
+  `id` integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+  `scene_id` integer NOT NULL,
+  `view_date` datetime NOT NULL,
+  `user_id` integer REFERENCES `users`(`id`) ON DELETE CASCADE,
+  FOREIGN KEY(`scene_id`) REFERENCES `scenes`(`id`) ON DELETE CASCADE
+);
+
+INSERT INTO `scenes_view_dates_new` (`scene_id`, `view_date`, `user_id`)
+  SELECT `scene_id`, `view_date`, `user_id`
+  FROM `scenes_view_dates`
+  ORDER BY `view_date`;
+
+DROP INDEX IF EXISTS `index_scenes_view_dates`;
+DROP INDEX IF EXISTS `index_scenes_view_dates_user_scene`;
+DROP TABLE `scenes_view_dates`;
+ALTER TABLE `scenes_view_dates_new` RENAME TO `scenes_view_dates`;
+CREATE INDEX `index_scenes_view_dates` ON `scenes_view_dates` (`scene_id`);
+CREATE INDEX `index_scenes_view_dates_user_scene` ON `scenes_view_dates` (`user_id`, `scene_id`);
+
+CREATE TABLE `scenes_o_dates_new` (
+  `id` integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+  `scene_id` integer NOT NULL,
+  `o_date` datetime NOT NULL,
+  `user_id` integer REFERENCES `users`(`id`) ON DELETE CASCADE,
+  FOREIGN KEY(`scene_id`) REFERENCES `scenes`(`id`) ON DELETE CASCADE
+);
+
+INSERT INTO `scenes_o_dates_new` (`scene_id`, `o_date`, `user_id`)
+  SELECT `scene_id`, `o_date`, `user_id`
+  FROM `scenes_o_dates`
+  ORDER BY `o_date`;
+
+DROP INDEX IF EXISTS `index_scenes_o_dates`;
+DROP INDEX IF EXISTS `index_scenes_o_dates_user_scene`;
+DROP TABLE `scenes_o_dates`;
+ALTER TABLE `scenes_o_dates_new` RENAME TO `scenes_o_dates`;
+CREATE INDEX `index_scenes_o_dates` ON `scenes_o_dates` (`scene_id`);
+CREATE INDEX `index_scenes_o_dates_user_scene` ON `scenes_o_dates` (`user_id`, `scene_id`);
+
+-- Each pair is stored in both directions so that the scenes related to a
+-- scene can be read from its own rows.
+CREATE TABLE `scene_co_watches` (
+  `scene_id` integer NOT NULL,
+  `other_scene_id` integer NOT NULL,
+  `users` integer NOT NULL DEFAULT 0,
+  `sessions` integer NOT NULL DEFAULT 0,
+  PRIMARY KEY(`scene_id`, `other_scene_id`),
+  FOREIGN KEY(`scene_id`) REFERENCES `scenes`(`id`) ON DELETE CASCADE,
+  FOREIGN KEY(`other_scene_id`) REFERENCES `scenes`(`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX `index_scene_co_watches_on_other_scene_id` ON `scene_co_watches` (`other_scene_id`);
+
+-- Single row holding the ids of the last history rows added to the model
+CREATE TABLE `scene_co_watch_cursor` (
+  `id` integer NOT NULL PRIMARY KEY CHECK (`id` = 1),
+  `view_date_id` integer NOT NULL DEFAULT 0,
+  `o_date_id` integer NOT NULL DEFAULT 0,
+  `updated_at` datetime
+);
+
+INSERT INTO `scene_co_watch_cursor` (`id`) VALUES (1);
+
+PRAGMA foreign_keys=ON;
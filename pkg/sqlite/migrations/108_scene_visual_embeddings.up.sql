@@ -0,0 +1,25 @@
+-- Migration 108: Scene visual embeddings
+-- Replaces the single cover colour histogram per scene with the vectors of
+-- several frames per scene and model. frames holds the vectors of each
+-- sampled frame concatenated, and mean their normalised mean, which is what
+-- the nearest-neighbour index is built from.
+CREATE TABLE `scene_visual_embeddings` (
+  `scene_id` integer NOT NULL,
+  `model` varchar(255) NOT NULL,
+  `dimensions` integer NOT NULL,
+  `frames` blob NOT NULL,
+  `mean` blob NOT NULL,
+  `updated_at` datetime NOT NULL,
+  PRIMARY KEY (`scene_id`, `model`),
+  FOREIGN KEY(`scene_id`) REFERENCES `scenes`(`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX `index_scene_visual_embeddings_model_updated_at` ON `scene_visual_embeddings` (`model`, `updated_at`);
+
+-- single cover histograms become single-frame histogram embeddings
+INSERT INTO `scene_visual_embeddings` (`scene_id`, `model`, `dimensions`, `frames`, `mean`, `updated_at`)
+  SELECT `scene_id`, 'histogram', length(`signature`) / 4, `signature`, `signature`, `updated_at`
+  FROM `scene_visual_signatures`
+  WHERE length(`signature`) > 0;
+
+DROP TABLE `scene_visual_signatures`;
@@ -0,0 +1,18 @@
+-- User-defined IPTV channels, each airing the scenes of a saved filter, tag,
+-- performer or group
+CREATE TABLE `iptv_channels` (
+  `id` integer not null primary key autoincrement,
+  `name` varchar(255) not null,
+  -- fixed channel number; null to number the channel after the library studios
+  `number` integer unique,
+  `source_type` varchar(32) not null,
+  `source_id` integer not null,
+  -- seed of the channel's rotation; null to derive it from the channel id
+  `shuffle_seed` integer,
+  `logo_blob` varchar(255) REFERENCES `blobs`(`checksum`),
+  `created_at` datetime not null,
+  `updated_at` datetime not null,
+  CHECK (`source_type` IN ('saved_filter', 'tag', 'performer', 'group'))
+);
+
+CREATE INDEX `index_iptv_channels_on_source` ON `iptv_channels` (`source_type`, `source_id`);
@@ -0,0 +1,54 @@
+PRAGMA foreign_keys=OFF;
+
+-- Weekly time-of-day blocks of user-defined IPTV channels. A block airs the
+-- scenes of its own source at fixed times; the channel's source airs whenever
+-- no block does.
+
+-- Channels may now air a playlist as well
+DROP INDEX IF EXISTS `index_iptv_channels_on_source`;
+
+CREATE TABLE `iptv_channels_new` (
+  `id` integer not null primary key autoincrement,
+  `name` varchar(255) not null,
+  `number` integer unique,
+  `source_type` varchar(32) not null,
+  `source_id` integer not null,
+  `shuffle_seed` integer,
+  `logo_blob` varchar(255) REFERENCES `blobs`(`checksum`),
+  `created_at` datetime not null,
+  `updated_at` datetime not null,
+  CHECK (`source_type` IN ('saved_filter', 'tag', 'performer', 'group', 'playlist'))
+);
+
+INSERT INTO `iptv_channels_new`
+  SELECT `id`, `name`, `number`, `source_type`, `source_id`, `shuffle_seed`, `logo_blob`, `created_at`, `updated_at`
+  FROM `iptv_channels`;
+
+DROP TABLE `iptv_channels`;
+ALTER TABLE `iptv_channels_new` RENAME TO `iptv_channels`;
+
+CREATE INDEX `index_iptv_channels_on_source` ON `iptv_channels` (`source_type`, `source_id`);
+
+CREATE TABLE `iptv_channel_blocks` (
+  `id` integer not null primary key autoincrement,
+  `channel_id` integer not null,
+  -- order of the block within its channel
+  `position` integer not null,
+  `name` varchar(255),
+  -- bitmask of the days the block airs on, bit 0 being Sunday; 0 for every day
+  `days` integer not null default 0,
+  -- minutes since local midnight; an end at or before the start runs past
+  -- midnight
+  `start_minute` integer not null,
+  `end_minute` integer not null,
+  `source_type` varchar(32) not null,
+  `source_id` integer not null,
+  foreign key(`channel_id`) references `iptv_channels`(`id`) on delete CASCADE,
+  CHECK (`source_type` IN ('saved_filter', 'tag', 'performer', 'group', 'playlist')),
+  CHECK (`start_minute` >= 0 AND `start_minute` < 1440),
+  CHECK (`end_minute` >= 0 AND `end_minute` < 1440)
+);
+
+CREATE INDEX `index_iptv_channel_blocks_on_channel_id` ON `iptv_channel_blocks` (`channel_id`, `position`);
+
+PRAGMA foreign_keys=ON;
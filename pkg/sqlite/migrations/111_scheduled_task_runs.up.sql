@@ -0,0 +1,19 @@
+-- Run history of scheduled tasks, one row per attempt. Tasks themselves live
+-- in the config file, so task_id is not a foreign key, and the task's name and
+-- type are kept so that a run still reads sensibly after the task changes.
+CREATE TABLE `scheduled_task_runs` (
+  `id` integer not null primary key autoincrement,
+  `task_id` varchar(255) not null,
+  `task_name` varchar(255) not null,
+  `task_type` varchar(32) not null,
+  `trigger` varchar(32) not null,
+  `attempt` integer not null default 1,
+  `job_id` integer,
+  `status` varchar(32) not null,
+  `error` text not null default '',
+  `started_at` datetime not null,
+  `ended_at` datetime not null
+);
+
+CREATE INDEX `index_scheduled_task_runs_on_task_id` ON `scheduled_task_runs` (`task_id`, `started_at`);
+CREATE INDEX `index_scheduled_task_runs_on_started_at` ON `scheduled_task_runs` (`started_at`);
@@ -0,0 +1,12 @@
+-- Persistent jobs in the job queue, so that they survive a restart. A row is
+-- kept while its job is queued or running, and removed once it ends. The id is
+-- the job's ID in the queue rather than a new one.
+CREATE TABLE `job_queue` (
+  `id` integer not null primary key,
+  `type` varchar(64) not null,
+  `description` text not null,
+  `input` text not null,
+  `checkpoint` text,
+  `started` boolean not null default 0,
+  `added_at` datetime not null
+);
@@ -0,0 +1,19 @@
+-- Versions of scenes transcoded ahead of time with the optimized version
+-- profiles. Profiles live in the config file, so profile is the profile's name
+-- rather than a foreign key, and versions of removed profiles are left for the
+-- cleanup task.
+CREATE TABLE `scene_optimized_versions` (
+  `id` integer not null primary key autoincrement,
+  `scene_id` integer not null,
+  `profile` varchar(255) not null,
+  `basename` varchar(255) not null,
+  `size` integer not null,
+  `width` integer not null,
+  `height` integer not null,
+  `video_codec` varchar(64) not null,
+  `created_at` datetime not null,
+  foreign key(`scene_id`) references `scenes`(`id`) on delete CASCADE
+);
+
+CREATE UNIQUE INDEX `index_scene_optimized_versions_on_scene_id_profile` ON `scene_optimized_versions` (`scene_id`, `profile`);
+CREATE INDEX `index_scene_optimized_versions_on_profile` ON `scene_optimized_versions` (`profile`);
@@ -0,0 +1,30 @@
+-- Devices that a subset of the library is synced to for offline viewing, each
+-- taking the scenes of a saved filter or playlist. Profiles live in the config
+-- file, so profile is the optimized version profile's name, empty for the
+-- original files.
+CREATE TABLE `sync_targets` (
+  `id` integer not null primary key autoincrement,
+  `name` varchar(255) not null,
+  `source_type` varchar(32) not null,
+  `source_id` integer not null,
+  `profile` varchar(255) not null default '',
+  -- size budget of the device in bytes; 0 for no limit
+  `max_size` integer not null default 0,
+  `last_synced_at` datetime,
+  `created_at` datetime not null,
+  `updated_at` datetime not null,
+  CHECK (`source_type` IN ('saved_filter', 'playlist'))
+);
+
+-- Scenes that each device has, so that syncs only send what is new
+CREATE TABLE `sync_target_items` (
+  `sync_target_id` integer not null,
+  `scene_id` integer not null,
+  `size` integer not null,
+  `synced_at` datetime not null,
+  foreign key(`sync_target_id`) references `sync_targets`(`id`) on delete CASCADE,
+  foreign key(`scene_id`) references `scenes`(`id`) on delete CASCADE,
+  PRIMARY KEY(`sync_target_id`, `scene_id`)
+);
+
+CREATE INDEX `index_sync_target_items_on_scene_id` ON `sync_target_items` (`scene_id`);
@@ -0,0 +1,6 @@
+-- Sync targets belong to the user that created them, so that users only reach
+-- their own devices. Targets created in single-user mode have no owner, and
+-- only admins can reach them once there are users.
+ALTER TABLE `sync_targets` ADD COLUMN `user_id` integer REFERENCES `users`(`id`) ON DELETE CASCADE;
+
+CREATE INDEX `index_sync_targets_on_user_id` ON `sync_targets` (`user_id`);
@@ -0,0 +1,5 @@
+-- Users that can log in through single sign-on. Logins are matched to users
+-- by name, so local users are not linked unless configured to be, and users
+-- created by single sign-on before this are linked on their next login only
+-- then.
+ALTER TABLE `users` ADD COLUMN `external_auth` boolean not null default '0';
@@ -0,0 +1,27 @@
+-- Co-watch pairs were stored in both directions. Each pair is now stored
+-- once, with the lower scene id first.
+DELETE FROM `scene_co_watches` WHERE `scene_id` > `other_scene_id`;
+
+-- History removed after it was added to the co-watch model, so that the
+-- next update of the model can take back the counts it added. There are no
+-- foreign keys, since the scene or user may be what was removed.
+CREATE TABLE `scene_co_watch_removed` (
+  `id` integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+  `scene_id` integer NOT NULL,
+  `date` datetime NOT NULL,
+  `user_id` integer
+);
+
+CREATE TRIGGER `scenes_view_dates_co_watch_removed` AFTER DELETE ON `scenes_view_dates`
+WHEN OLD.`id` <= (SELECT `view_date_id` FROM `scene_co_watch_cursor` WHERE `id` = 1)
+BEGIN
+  INSERT INTO `scene_co_watch_removed` (`scene_id`, `date`, `user_id`)
+  VALUES (OLD.`scene_id`, OLD.`view_date`, OLD.`user_id`);
+END;
+
+CREATE TRIGGER `scenes_o_dates_co_watch_removed` AFTER DELETE ON `scenes_o_dates`
+WHEN OLD.`id` <= (SELECT `o_date_id` FROM `scene_co_watch_cursor` WHERE `id` = 1)
+BEGIN
+  INSERT INTO `scene_co_watch_removed` (`scene_id`, `date`, `user_id`)
+  VALUES (OLD.`scene_id`, OLD.`o_date`, OLD.`user_id`);
+END;
@@ -0,0 +1,188 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const (
+	optimizedVersionTable         = "scene_optimized_versions"
+	optimizedVersionProfileColumn = "profile"
+	optimizedVersionSizeColumn    = "size"
+)
+
+var optimizedVersionsTableMgr = &table{
+	table:    goqu.T(optimizedVersionTable),
+	idColumn: goqu.T(optimizedVersionTable).Col(idColumn),
+}
+
+type optimizedVersionRow struct {
+	ID         int       `db:"id" goqu:"skipinsert"`
+	SceneID    int       `db:"scene_id"`
+	Profile    string    `db:"profile"`
+	Basename   string    `db:"basename"`
+	Size       int64     `db:"size"`
+	Width      int       `db:"width"`
+	Height     int       `db:"height"`
+	VideoCodec string    `db:"video_codec"`
+	CreatedAt  Timestamp `db:"created_at"`
+}
+
+func (r *optimizedVersionRow) fromOptimizedVersion(o models.OptimizedVersion) {
+	r.ID = o.ID
+	r.SceneID = o.SceneID
+	r.Profile = o.Profile
+	r.Basename = o.Basename
+	r.Size = o.Size
+	r.Width = o.Width
+	r.Height = o.Height
+	r.VideoCodec = o.VideoCodec
+	r.CreatedAt = Timestamp{Timestamp: o.CreatedAt}
+}
+
+func (r *optimizedVersionRow) resolve() *models.OptimizedVersion {
+	return &models.OptimizedVersion{
+		ID:         r.ID,
+		SceneID:    r.SceneID,
+		Profile:    r.Profile,
+		Basename:   r.Basename,
+		Size:       r.Size,
+		Width:      r.Width,
+		Height:     r.Height,
+		VideoCodec: r.VideoCodec,
+		CreatedAt:  r.CreatedAt.Timestamp,
+	}
+}
+
+// OptimizedVersionStore provides methods for the optimized versions of scenes
+type OptimizedVersionStore struct {
+	tableMgr *table
+}
+
+// NewOptimizedVersionStore creates a new OptimizedVersionStore
+func NewOptimizedVersionStore() *OptimizedVersionStore {
+	return &OptimizedVersionStore{
+		tableMgr: optimizedVersionsTableMgr,
+	}
+}
+
+func (qb *OptimizedVersionStore) table() exp.IdentifierExpression {
+	return qb.tableMgr.table
+}
+
+// Create adds the version, replacing any of the same scene and profile
+func (qb *OptimizedVersionStore) Create(ctx context.Context, newVersion *models.OptimizedVersion) error {
+	q := dialect.Delete(qb.table()).Where(
+		qb.table().Col(sceneIDColumn).Eq(newVersion.SceneID),
+		qb.table().Col(optimizedVersionProfileColumn).Eq(newVersion.Profile),
+	)
+	if _, err := exec(ctx, q); err != nil {
+		return fmt.Errorf("replacing optimized version: %w", err)
+	}
+
+	var r optimizedVersionRow
+	r.fromOptimizedVersion(*newVersion)
+
+	id, err := qb.tableMgr.insertID(ctx, r)
+	if err != nil {
+		return fmt.Errorf("creating optimized version: %w", err)
+	}
+
+	newVersion.ID = id
+
+	return nil
+}
+
+// Destroy removes the version, if it exists
+func (qb *OptimizedVersionStore) Destroy(ctx context.Context, id int) error {
+	return qb.tableMgr.destroy(ctx, []int{id})
+}
+
+// Find returns the version with the given ID, or nil if it does not exist
+func (qb *OptimizedVersionStore) Find(ctx context.Context, id int) (*models.OptimizedVersion, error) {
+	q := dialect.From(qb.table()).Select(qb.table().All()).Where(qb.tableMgr.byID(id))
+
+	ret, err := qb.getMany(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("getting optimized version %d: %w", id, err)
+	}
+	if len(ret) == 0 {
+		return nil, nil
+	}
+
+	return ret[0], nil
+}
+
+// FindBySceneID returns the versions of a scene in order of profile
+func (qb *OptimizedVersionStore) FindBySceneID(ctx context.Context, sceneID int) ([]*models.OptimizedVersion, error) {
+	q := dialect.From(qb.table()).Select(qb.table().All()).
+		Where(qb.table().Col(sceneIDColumn).Eq(sceneID)).
+		Order(qb.table().Col(optimizedVersionProfileColumn).Asc())
+
+	return qb.getMany(ctx, q)
+}
+
+// All returns every version
+func (qb *OptimizedVersionStore) All(ctx context.Context) ([]*models.OptimizedVersion, error) {
+	q := dialect.From(qb.table()).Select(qb.table().All()).
+		Order(qb.table().Col(idColumn).Asc())
+
+	return qb.getMany(ctx, q)
+}
+
+// Storage returns the storage used by each profile's versions, in order of
+// profile
+func (qb *OptimizedVersionStore) Storage(ctx context.Context) ([]*models.OptimizedVersionStorage, error) {
+	profile := qb.table().Col(optimizedVersionProfileColumn)
+	q := dialect.From(qb.table()).Select(
+		profile,
+		goqu.COUNT("*").As("count"),
+		goqu.SUM(qb.table().Col(optimizedVersionSizeColumn)).As("size"),
+	).GroupBy(profile).Order(profile.Asc())
+
+	const single = false
+	var ret []*models.OptimizedVersionStorage
+	if err := queryFunc(ctx, q, single, func(r *sqlx.Rows) error {
+		var row struct {
+			Profile string `db:"profile"`
+			Count   int    `db:"count"`
+			Size    int64  `db:"size"`
+		}
+		if err := r.StructScan(&row); err != nil {
+			return err
+		}
+		ret = append(ret, &models.OptimizedVersionStorage{
+			Profile: row.Profile,
+			Count:   row.Count,
+			Size:    row.Size,
+		})
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (qb *OptimizedVersionStore) getMany(ctx context.Context, q *goqu.SelectDataset) ([]*models.OptimizedVersion, error) {
+	const single = false
+	var ret []*models.OptimizedVersion
+	if err := queryFunc(ctx, q, single, func(r *sqlx.Rows) error {
+		var row optimizedVersionRow
+		if err := r.StructScan(&row); err != nil {
+			return err
+		}
+		ret = append(ret, row.resolve())
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,86 @@
+//go:build integration
+// +build integration
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptimizedVersionStore(t *testing.T) {
+	withRollbackTxn(func(ctx context.Context) error {
+		assert := assert.New(t)
+		qb := db.OptimizedVersion
+
+		scene1 := sceneIDs[sceneIdxWithGroup]
+		scene2 := sceneIDs[sceneIdxWithGallery]
+
+		now := time.Now().Truncate(time.Second)
+		versions := []*models.OptimizedVersion{
+			{SceneID: scene1, Profile: "mobile", Basename: "a_mobile.mp4", Size: 100, Width: 1280, Height: 720, VideoCodec: "h264", CreatedAt: now},
+			{SceneID: scene1, Profile: "headset", Basename: "a_headset.mp4", Size: 1000, Width: 3840, Height: 1920, VideoCodec: "hevc", CreatedAt: now},
+			{SceneID: scene2, Profile: "mobile", Basename: "b_mobile.mp4", Size: 50, Width: 1280, Height: 720, VideoCodec: "h264", CreatedAt: now},
+		}
+		for _, v := range versions {
+			if err := qb.Create(ctx, v); err != nil {
+				t.Errorf("OptimizedVersionStore.Create() error = %v", err)
+				return nil
+			}
+		}
+
+		got, err := qb.FindBySceneID(ctx, scene1)
+		if err != nil {
+			t.Errorf("OptimizedVersionStore.FindBySceneID() error = %v", err)
+			return nil
+		}
+		if assert.Len(got, 2) {
+			assert.Equal("headset", got[0].Profile)
+			assert.Equal("mobile", got[1].Profile)
+			assert.Equal(int64(100), got[1].Size)
+			assert.True(now.Equal(got[1].CreatedAt))
+		}
+
+		// creating a version of the same scene and profile replaces it
+		replacement := &models.OptimizedVersion{SceneID: scene1, Profile: "mobile", Basename: "a_mobile.mp4", Size: 80, Width: 1280, Height: 720, VideoCodec: "h264", CreatedAt: now}
+		if err := qb.Create(ctx, replacement); err != nil {
+			t.Errorf("OptimizedVersionStore.Create() error = %v", err)
+			return nil
+		}
+
+		found, err := qb.Find(ctx, versions[0].ID)
+		if err != nil {
+			t.Errorf("OptimizedVersionStore.Find() error = %v", err)
+			return nil
+		}
+		assert.Nil(found)
+
+		storage, err := qb.Storage(ctx)
+		if err != nil {
+			t.Errorf("OptimizedVersionStore.Storage() error = %v", err)
+			return nil
+		}
+		assert.Equal([]*models.OptimizedVersionStorage{
+			{Profile: "headset", Count: 1, Size: 1000},
+			{Profile: "mobile", Count: 2, Size: 130},
+		}, storage)
+
+		if err := qb.Destroy(ctx, versions[1].ID); err != nil {
+			t.Errorf("OptimizedVersionStore.Destroy() error = %v", err)
+			return nil
+		}
+
+		all, err := qb.All(ctx)
+		if err != nil {
+			t.Errorf("OptimizedVersionStore.All() error = %v", err)
+			return nil
+		}
+		assert.Len(all, 2)
+
+		return nil
+	})
+}
@@ -0,0 +1,153 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const (
+	queuedJobTable            = "job_queue"
+	queuedJobStartedColumn    = "started"
+	queuedJobCheckpointColumn = "checkpoint"
+)
+
+var queuedJobsTableMgr = &table{
+	table:    goqu.T(queuedJobTable),
+	idColumn: goqu.T(queuedJobTable).Col(idColumn),
+}
+
+type queuedJobRow struct {
+	ID          int         `db:"id"`
+	Type        string      `db:"type"`
+	Description string      `db:"description"`
+	Input       string      `db:"input"`
+	Checkpoint  null.String `db:"checkpoint"`
+	Started     bool        `db:"started"`
+	AddedAt     Timestamp   `db:"added_at"`
+}
+
+func (r *queuedJobRow) fromQueuedJob(o models.QueuedJob) {
+	r.ID = o.ID
+	r.Type = o.Type
+	r.Description = o.Description
+	r.Input = string(o.Input)
+	if o.Checkpoint != nil {
+		r.Checkpoint = null.StringFrom(string(o.Checkpoint))
+	}
+	r.Started = o.Started
+	r.AddedAt = Timestamp{Timestamp: o.AddedAt}
+}
+
+func (r *queuedJobRow) resolve() *models.QueuedJob {
+	ret := &models.QueuedJob{
+		ID:          r.ID,
+		Type:        r.Type,
+		Description: r.Description,
+		Input:       []byte(r.Input),
+		Started:     r.Started,
+		AddedAt:     r.AddedAt.Timestamp,
+	}
+	if r.Checkpoint.Valid {
+		ret.Checkpoint = []byte(r.Checkpoint.String)
+	}
+	return ret
+}
+
+// QueuedJobStore provides methods for the persistent jobs in the job queue
+type QueuedJobStore struct {
+	tableMgr *table
+}
+
+// NewQueuedJobStore creates a new QueuedJobStore
+func NewQueuedJobStore() *QueuedJobStore {
+	return &QueuedJobStore{
+		tableMgr: queuedJobsTableMgr,
+	}
+}
+
+func (qb *QueuedJobStore) table() exp.IdentifierExpression {
+	return qb.tableMgr.table
+}
+
+// Save creates the job, replacing any with the same ID
+func (qb *QueuedJobStore) Save(ctx context.Context, job *models.QueuedJob) error {
+	var r queuedJobRow
+	r.fromQueuedJob(*job)
+
+	q := dialect.Insert(qb.table()).Prepared(true).Rows(r).OnConflict(goqu.DoUpdate(
+		idColumn,
+		goqu.Record{
+			"type":        r.Type,
+			"description": r.Description,
+			"input":       r.Input,
+			"checkpoint":  r.Checkpoint,
+			"started":     r.Started,
+			"added_at":    r.AddedAt,
+		},
+	))
+
+	if _, err := exec(ctx, q); err != nil {
+		return fmt.Errorf("saving queued job %d: %w", job.ID, err)
+	}
+
+	return nil
+}
+
+// SetStarted marks the job as started
+func (qb *QueuedJobStore) SetStarted(ctx context.Context, id int) error {
+	q := dialect.Update(qb.table()).Prepared(true).
+		Set(goqu.Record{queuedJobStartedColumn: true}).
+		Where(qb.tableMgr.byID(id))
+
+	if _, err := exec(ctx, q); err != nil {
+		return fmt.Errorf("updating queued job %d: %w", id, err)
+	}
+
+	return nil
+}
+
+// SetCheckpoint replaces the checkpoint of the job
+func (qb *QueuedJobStore) SetCheckpoint(ctx context.Context, id int, checkpoint []byte) error {
+	q := dialect.Update(qb.table()).Prepared(true).
+		Set(goqu.Record{queuedJobCheckpointColumn: string(checkpoint)}).
+		Where(qb.tableMgr.byID(id))
+
+	if _, err := exec(ctx, q); err != nil {
+		return fmt.Errorf("updating checkpoint of queued job %d: %w", id, err)
+	}
+
+	return nil
+}
+
+// Destroy removes the job, if it exists
+func (qb *QueuedJobStore) Destroy(ctx context.Context, id int) error {
+	return qb.tableMgr.destroy(ctx, []int{id})
+}
+
+// All returns every queued job in the order they were added
+func (qb *QueuedJobStore) All(ctx context.Context) ([]*models.QueuedJob, error) {
+	q := dialect.From(qb.table()).Select(qb.table().All()).
+		Order(qb.table().Col(idColumn).Asc())
+
+	const single = false
+	var ret []*models.QueuedJob
+	if err := queryFunc(ctx, q, single, func(r *sqlx.Rows) error {
+		var row queuedJobRow
+		if err := r.StructScan(&row); err != nil {
+			return err
+		}
+		ret = append(ret, row.resolve())
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
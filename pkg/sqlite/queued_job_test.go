@@ -0,0 +1,79 @@
+//go:build integration
+// +build integration
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueuedJobStore(t *testing.T) {
+	withRollbackTxn(func(ctx context.Context) error {
+		assert := assert.New(t)
+		qb := db.QueuedJob
+
+		now := time.Now().Truncate(time.Second)
+		jobs := []*models.QueuedJob{
+			{ID: 7, Type: "generate", Description: "Generating...", Input: []byte(`{"covers":true}`), AddedAt: now},
+			{ID: 3, Type: "scan", Description: "Scanning...", Input: []byte(`{}`), AddedAt: now.Add(-time.Hour)},
+		}
+		for _, j := range jobs {
+			if err := qb.Save(ctx, j); err != nil {
+				t.Errorf("QueuedJobStore.Save() error = %v", err)
+				return nil
+			}
+		}
+
+		if err := qb.SetStarted(ctx, 3); err != nil {
+			t.Errorf("QueuedJobStore.SetStarted() error = %v", err)
+			return nil
+		}
+		if err := qb.SetCheckpoint(ctx, 3, []byte(`{"done":12}`)); err != nil {
+			t.Errorf("QueuedJobStore.SetCheckpoint() error = %v", err)
+			return nil
+		}
+
+		got, err := qb.All(ctx)
+		if err != nil {
+			t.Errorf("QueuedJobStore.All() error = %v", err)
+			return nil
+		}
+		if assert.Len(got, 2) {
+			assert.Equal(3, got[0].ID)
+			assert.True(got[0].Started)
+			assert.Equal(`{"done":12}`, string(got[0].Checkpoint))
+			assert.Equal(7, got[1].ID)
+			assert.False(got[1].Started)
+			assert.Nil(got[1].Checkpoint)
+			assert.Equal(`{"covers":true}`, string(got[1].Input))
+			assert.True(now.Equal(got[1].AddedAt))
+		}
+
+		// saving again replaces the job
+		jobs[0].Description = "Generating covers..."
+		if err := qb.Save(ctx, jobs[0]); err != nil {
+			t.Errorf("QueuedJobStore.Save() error = %v", err)
+			return nil
+		}
+		if err := qb.Destroy(ctx, 3); err != nil {
+			t.Errorf("QueuedJobStore.Destroy() error = %v", err)
+			return nil
+		}
+
+		got, err = qb.All(ctx)
+		if err != nil {
+			t.Errorf("QueuedJobStore.All() error = %v", err)
+			return nil
+		}
+		if assert.Len(got, 1) {
+			assert.Equal("Generating covers...", got[0].Description)
+		}
+
+		return nil
+	})
+}
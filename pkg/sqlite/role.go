@@ -0,0 +1,219 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const (
+	roleTable             = "roles"
+	rolesPermissionsTable = "roles_permissions"
+	roleIDColumn          = "role_id"
+	permissionColumn      = "permission"
+)
+
+var (
+	rolesTableMgr = &table{
+		table:    goqu.T(roleTable),
+		idColumn: goqu.T(roleTable).Col(idColumn),
+	}
+
+	rolesPermissionsTableMgr = &stringTable{
+		table: table{
+			table:    goqu.T(rolesPermissionsTable),
+			idColumn: goqu.T(rolesPermissionsTable).Col(roleIDColumn),
+		},
+		stringColumn: goqu.T(rolesPermissionsTable).Col(permissionColumn),
+	}
+)
+
+type roleRow struct {
+	ID          int       `db:"id" goqu:"skipinsert"`
+	Name        string    `db:"name"`
+	Description string    `db:"description"`
+	CreatedAt   Timestamp `db:"created_at"`
+	UpdatedAt   Timestamp `db:"updated_at"`
+}
+
+func (r *roleRow) fromRole(o models.Role) {
+	r.ID = o.ID
+	r.Name = o.Name
+	r.Description = o.Description
+	r.CreatedAt = Timestamp{Timestamp: o.CreatedAt}
+	r.UpdatedAt = Timestamp{Timestamp: o.UpdatedAt}
+}
+
+func (r *roleRow) resolve() *models.Role {
+	return &models.Role{
+		ID:          r.ID,
+		Name:        r.Name,
+		Description: r.Description,
+		CreatedAt:   r.CreatedAt.Timestamp,
+		UpdatedAt:   r.UpdatedAt.Timestamp,
+	}
+}
+
+// RoleStore provides methods for custom role database operations
+type RoleStore struct {
+	repository
+	tableMgr *table
+}
+
+// NewRoleStore creates a new RoleStore
+func NewRoleStore() *RoleStore {
+	return &RoleStore{
+		repository: repository{
+			tableName: roleTable,
+			idColumn:  idColumn,
+		},
+		tableMgr: rolesTableMgr,
+	}
+}
+
+func (qb *RoleStore) table() exp.IdentifierExpression {
+	return qb.tableMgr.table
+}
+
+func (qb *RoleStore) selectDataset() *goqu.SelectDataset {
+	return dialect.From(qb.table()).Select(qb.table().All())
+}
+
+// Create creates a new role along with its permissions
+func (qb *RoleStore) Create(ctx context.Context, newRole *models.Role) error {
+	var r roleRow
+	r.fromRole(*newRole)
+
+	id, err := qb.tableMgr.insertID(ctx, r)
+	if err != nil {
+		return fmt.Errorf("creating role: %w", err)
+	}
+
+	if err := rolesPermissionsTableMgr.insertJoins(ctx, id, permissionStrings(newRole.Permissions)); err != nil {
+		return fmt.Errorf("setting role permissions: %w", err)
+	}
+
+	updated, err := qb.Find(ctx, id)
+	if err != nil {
+		return fmt.Errorf("finding after create: %w", err)
+	}
+
+	*newRole = *updated
+
+	return nil
+}
+
+// Update replaces an existing role and its permissions
+func (qb *RoleStore) Update(ctx context.Context, updatedRole *models.Role) error {
+	var r roleRow
+	r.fromRole(*updatedRole)
+
+	if err := qb.tableMgr.updateByID(ctx, updatedRole.ID, r); err != nil {
+		return fmt.Errorf("updating role: %w", err)
+	}
+
+	if err := rolesPermissionsTableMgr.replaceJoins(ctx, updatedRole.ID, permissionStrings(updatedRole.Permissions)); err != nil {
+		return fmt.Errorf("setting role permissions: %w", err)
+	}
+
+	return nil
+}
+
+// Destroy deletes a role by ID. Users assigned to the role lose it.
+func (qb *RoleStore) Destroy(ctx context.Context, id int) error {
+	return qb.destroyExisting(ctx, []int{id})
+}
+
+// Find returns a role by ID, or nil if not found
+func (qb *RoleStore) Find(ctx context.Context, id int) (*models.Role, error) {
+	ret, err := qb.get(ctx, qb.selectDataset().Where(qb.tableMgr.byID(id)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting role by id %d: %w", id, err)
+	}
+
+	return ret, nil
+}
+
+// FindByName returns a role by name (case-insensitive), or nil if not found
+func (qb *RoleStore) FindByName(ctx context.Context, name string) (*models.Role, error) {
+	q := qb.selectDataset().Where(
+		goqu.L("LOWER(name)").Eq(goqu.L("LOWER(?)", name)),
+	)
+
+	ret, err := qb.get(ctx, q)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("finding role by name: %w", err)
+	}
+
+	return ret, nil
+}
+
+// FindAll returns all roles
+func (qb *RoleStore) FindAll(ctx context.Context) ([]*models.Role, error) {
+	q := qb.selectDataset().Order(goqu.C("name").Asc())
+
+	return qb.getMany(ctx, q)
+}
+
+func (qb *RoleStore) get(ctx context.Context, q *goqu.SelectDataset) (*models.Role, error) {
+	ret, err := qb.getMany(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ret) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return ret[0], nil
+}
+
+func (qb *RoleStore) getMany(ctx context.Context, q *goqu.SelectDataset) ([]*models.Role, error) {
+	const single = false
+	var ret []*models.Role
+	if err := queryFunc(ctx, q, single, func(r *sqlx.Rows) error {
+		var row roleRow
+		if err := r.StructScan(&row); err != nil {
+			return err
+		}
+		ret = append(ret, row.resolve())
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, role := range ret {
+		permissions, err := rolesPermissionsTableMgr.get(ctx, role.ID)
+		if err != nil {
+			return nil, fmt.Errorf("getting permissions of role %d: %w", role.ID, err)
+		}
+
+		role.Permissions = make([]models.Permission, len(permissions))
+		for i, p := range permissions {
+			role.Permissions[i] = models.Permission(p)
+		}
+	}
+
+	return ret, nil
+}
+
+func permissionStrings(permissions []models.Permission) []string {
+	ret := make([]string, len(permissions))
+	for i, p := range permissions {
+		ret[i] = string(p)
+	}
+	return ret
+}
@@ -0,0 +1,88 @@
+//go:build integration
+// +build integration
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleStore(t *testing.T) {
+	withRollbackTxn(func(ctx context.Context) error {
+		assert := assert.New(t)
+		qb := db.Role
+
+		now := time.Now()
+		role := &models.Role{
+			Name:        "Editor",
+			Description: "trusted editors",
+			Permissions: []models.Permission{models.PermissionEditMetadata, models.PermissionRunJobs},
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if err := qb.Create(ctx, role); err != nil {
+			t.Errorf("RoleStore.Create() error = %v", err)
+			return nil
+		}
+
+		found, err := qb.FindByName(ctx, "editor")
+		if err != nil {
+			t.Errorf("RoleStore.FindByName() error = %v", err)
+			return nil
+		}
+		if assert.NotNil(found) {
+			assert.Equal(role.ID, found.ID)
+			assert.ElementsMatch(role.Permissions, found.Permissions)
+		}
+
+		role.Permissions = []models.Permission{models.PermissionDeleteFiles}
+		if err := qb.Update(ctx, role); err != nil {
+			t.Errorf("RoleStore.Update() error = %v", err)
+			return nil
+		}
+
+		found, err = qb.Find(ctx, role.ID)
+		if err != nil {
+			t.Errorf("RoleStore.Find() error = %v", err)
+			return nil
+		}
+		if assert.NotNil(found) {
+			assert.Equal([]models.Permission{models.PermissionDeleteFiles}, found.Permissions)
+		}
+
+		// assign the role to a user
+		userCtx := createUserDataTestUser(ctx, t, "editor")
+		userID, _ := models.UserIDFromContext(userCtx)
+
+		partial := models.NewUserPartial()
+		partial.CustomRoleID = models.NewOptionalInt(role.ID)
+		user, err := db.User.Update(ctx, userID, partial)
+		if err != nil {
+			t.Errorf("UserStore.Update() error = %v", err)
+			return nil
+		}
+		assert.Equal(&role.ID, user.CustomRoleID)
+		assert.True(user.Permissions(found).Has(models.PermissionDeleteFiles))
+		assert.False(user.Permissions(found).Has(models.PermissionEditMetadata))
+
+		// destroying the role removes it from the user
+		if err := qb.Destroy(ctx, role.ID); err != nil {
+			t.Errorf("RoleStore.Destroy() error = %v", err)
+			return nil
+		}
+
+		user, err = db.User.Find(ctx, userID)
+		if err != nil {
+			t.Errorf("UserStore.Find() error = %v", err)
+			return nil
+		}
+		assert.Nil(user.CustomRoleID)
+
+		return nil
+	})
+}
@@ -382,6 +382,14 @@ func (qb *SceneStore) UpdatePartial(ctx context.Context, id int, partial models.
 		},
 	}
 
+	if userID, ok := models.UserIDFromContext(ctx); ok && partial.Rating.Set {
+		rating := partial.Rating.Ptr()
+		if err := scenesUserDataTableMgr.upsert(ctx, userID, id, goqu.Record{"rating": rating}, goqu.Record{"rating": rating}); err != nil {
+			return nil, err
+		}
+		partial.Rating = models.OptionalInt{}
+	}
+
 	r.fromPartial(partial)
 
 	if len(r.Record) > 0 {
@@ -513,7 +521,8 @@ func (qb *SceneStore) Destroy(ctx context.Context, id int) error {
 
 // returns nil, nil if not found
 func (qb *SceneStore) Find(ctx context.Context, id int) (*models.Scene, error) {
-	if qb.caches != nil {
+	// the cache is shared by all users, so restricted reads bypass it
+	if qb.caches != nil && models.SceneRestrictionFromContext(ctx) == nil {
 		return qb.caches.Scenes.Get(ctx, id)
 	}
 	ret, err := qb.find(ctx, id)
@@ -612,6 +621,11 @@ func (qb *SceneStore) get(ctx context.Context, q *goqu.SelectDataset) (*models.S
 }
 
 func (qb *SceneStore) getMany(ctx context.Context, q *goqu.SelectDataset) ([]*models.Scene, error) {
+	q, err := restrictScenes(ctx, q, qb.table().Col(idColumn))
+	if err != nil {
+		return nil, err
+	}
+
 	const single = false
 	var ret []*models.Scene
 	var lastID int
@@ -906,6 +920,11 @@ func (qb *SceneStore) CountByPerformerID(ctx context.Context, performerID int) (
 	joinTable := scenesPerformersJoinTable
 
 	q := dialect.Select(goqu.COUNT("*")).From(joinTable).Where(joinTable.Col(performerIDColumn).Eq(performerID))
+	q, err := restrictScenes(ctx, q, joinTable.Col(sceneIDColumn))
+	if err != nil {
+		return 0, err
+	}
+
 	return count(ctx, q)
 }
 
@@ -923,6 +942,10 @@ func (qb *SceneStore) OCountByPerformerID(ctx context.Context, performerID int)
 			table.Col(idColumn).Eq(joinTable.Col(sceneIDColumn)),
 		),
 	).Where(joinTable.Col(performerIDColumn).Eq(performerID))
+	q, err := restrictScenes(ctx, q, table.Col(idColumn))
+	if err != nil {
+		return 0, err
+	}
 
 	var ret int
 	if err := querySimple(ctx, q, &ret); err != nil {
@@ -946,6 +969,10 @@ func (qb *SceneStore) OCountByGroupID(ctx context.Context, groupID int) (int, er
 			table.Col(idColumn).Eq(joinTable.Col(sceneIDColumn)),
 		),
 	).Where(joinTable.Col(groupIDColumn).Eq(groupID))
+	q, err := restrictScenes(ctx, q, table.Col(idColumn))
+	if err != nil {
+		return 0, err
+	}
 
 	var ret int
 	if err := querySimple(ctx, q, &ret); err != nil {
@@ -963,6 +990,10 @@ func (qb *SceneStore) OCountByStudioID(ctx context.Context, studioID int) (int,
 		oHistoryTable,
 		goqu.On(table.Col(idColumn).Eq(oHistoryTable.Col(sceneIDColumn))),
 	).Where(table.Col(studioIDColumn).Eq(studioID))
+	q, err := restrictScenes(ctx, q, table.Col(idColumn))
+	if err != nil {
+		return 0, err
+	}
 
 	var ret int
 	if err := querySimple(ctx, q, &ret); err != nil {
@@ -987,6 +1018,11 @@ func (qb *SceneStore) FindByGroupID(ctx context.Context, groupID int) ([]*models
 
 func (qb *SceneStore) Count(ctx context.Context) (int, error) {
 	q := dialect.Select(goqu.COUNT("*")).From(qb.table())
+	q, err := restrictScenes(ctx, q, qb.table().Col(idColumn))
+	if err != nil {
+		return 0, err
+	}
+
 	return count(ctx, q)
 }
 
@@ -1002,6 +1038,11 @@ func (qb *SceneStore) Size(ctx context.Context) (float64, error) {
 		fileTable,
 		goqu.On(scenesFilesJoinTable.Col(fileIDColumn).Eq(fileTable.Col(idColumn))),
 	)
+	q, err := restrictScenes(ctx, q, table.Col(idColumn))
+	if err != nil {
+		return 0, err
+	}
+
 	var ret float64
 	if err := querySimple(ctx, q, &ret); err != nil {
 		return 0, err
@@ -1023,6 +1064,10 @@ func (qb *SceneStore) Duration(ctx context.Context) (float64, error) {
 		videoFileTable,
 		goqu.On(videoFileTable.Col("file_id").Eq(scenesFilesJoinTable.Col("file_id"))),
 	)
+	q, err := restrictScenes(ctx, q, table.Col(idColumn))
+	if err != nil {
+		return 0, err
+	}
 
 	var ret float64
 	if err := querySimple(ctx, q, &ret); err != nil {
@@ -1036,6 +1081,16 @@ func (qb *SceneStore) PlayDuration(ctx context.Context) (float64, error) {
 	table := qb.table()
 
 	q := dialect.Select(goqu.COALESCE(goqu.SUM("play_duration"), 0)).From(table)
+	sceneIDCol := table.Col(idColumn)
+	if userID, ok := models.UserIDFromContext(ctx); ok {
+		ut := scenesUserDataTableMgr.table
+		q = dialect.Select(goqu.COALESCE(goqu.SUM(ut.Col("play_duration")), 0)).From(ut).Where(ut.Col(userIDColumn).Eq(userID))
+		sceneIDCol = ut.Col(sceneIDColumn)
+	}
+	q, err := restrictScenes(ctx, q, sceneIDCol)
+	if err != nil {
+		return 0, err
+	}
 
 	var ret float64
 	if err := querySimple(ctx, q, &ret); err != nil {
@@ -1050,6 +1105,11 @@ func (qb *SceneStore) CountByStudioID(ctx context.Context, studioID int) (int, e
 	table := qb.table()
 
 	q := dialect.Select(goqu.COUNT("*")).From(table).Where(table.Col(studioIDColumn).Eq(studioID))
+	q, err := restrictScenes(ctx, q, table.Col(idColumn))
+	if err != nil {
+		return 0, err
+	}
+
 	return count(ctx, q)
 }
 
@@ -1111,6 +1171,11 @@ func (qb *SceneStore) makeQuery(ctx context.Context, sceneFilter *models.SceneFi
 	query := sceneRepository.newQuery()
 	distinctIDs(&query, sceneTable)
 
+	// added first so that its arguments precede those of the other clauses
+	if err := addSceneRestriction(ctx, &query, "scenes.id"); err != nil {
+		return nil, err
+	}
+
 	if q := findFilter.Q; q != nil && *q != "" {
 		query.addJoins(
 			join{
@@ -1151,7 +1216,7 @@ func (qb *SceneStore) makeQuery(ctx context.Context, sceneFilter *models.SceneFi
 	// Apply exclude_ids filter from FindFilterType
 	query.applyExcludeIDs("scenes.id", findFilter.ExcludeIds)
 
-	if err := qb.setSceneSort(&query, findFilter); err != nil {
+	if err := qb.setSceneSort(ctx, &query, findFilter); err != nil {
 		return nil, err
 	}
 	query.sortAndPagination += getPagination(findFilter)
@@ -1175,7 +1240,7 @@ func (qb *SceneStore) Query(ctx context.Context, options models.SceneQueryOption
 	if qb.isUnfilteredQuery(options) {
 	}
 
-	if qb.canUseFastIDs(options) {
+	if qb.canUseFastIDs(ctx, options) {
 		idsResult, err = qb.findIDsFast(ctx, options.FindFilter)
 		if err != nil {
 			idsResult, err = query.findIDs(ctx)
@@ -1193,8 +1258,8 @@ func (qb *SceneStore) Query(ctx context.Context, options models.SceneQueryOption
 }
 
 // canUseFastIDs checks if we can use the fast IDs query path
-func (qb *SceneStore) canUseFastIDs(options models.SceneQueryOptions) bool {
-	if !qb.isUnfilteredQuery(options) {
+func (qb *SceneStore) canUseFastIDs(ctx context.Context, options models.SceneQueryOptions) bool {
+	if !qb.isUnfilteredQuery(options) || models.SceneRestrictionFromContext(ctx) != nil {
 		return false
 	}
 
@@ -1203,6 +1268,10 @@ func (qb *SceneStore) canUseFastIDs(options models.SceneQueryOptions) bool {
 	}
 
 	sort := *options.FindFilter.Sort
+	if isUserDataSort(ctx, sort) {
+		return false
+	}
+
 	fastSortColumns := map[string]bool{
 		"date":          true,
 		"created_at":    true,
@@ -1485,7 +1554,7 @@ var sceneSortOptions = sortOptions{
 	"performer_age",
 }
 
-func (qb *SceneStore) setSceneSort(query *queryBuilder, findFilter *models.FindFilterType) error {
+func (qb *SceneStore) setSceneSort(ctx context.Context, query *queryBuilder, findFilter *models.FindFilterType) error {
 	if findFilter == nil || findFilter.Sort == nil || *findFilter.Sort == "" {
 		return nil
 	}
@@ -1593,13 +1662,16 @@ func (qb *SceneStore) setSceneSort(query *queryBuilder, findFilter *models.FindF
 		addFolderTable()
 		query.sortAndPagination += " ORDER BY COALESCE(scenes.title, files.basename) COLLATE NATURAL_CI " + direction + ", folders.path COLLATE NATURAL_CI " + direction
 	case "play_count":
-		query.sortAndPagination += getCountSort(sceneTable, scenesViewDatesTable, sceneIDColumn, direction)
+		query.sortAndPagination += getCountSort(sceneTable, userHistoryTable(ctx, scenesViewDatesTable), sceneIDColumn, direction)
 	case "last_played_at":
-		query.sortAndPagination += fmt.Sprintf(" ORDER BY (SELECT MAX(view_date) FROM %s AS sort WHERE sort.%s = %s.id) %s", scenesViewDatesTable, sceneIDColumn, sceneTable, getSortDirection(direction))
+		query.sortAndPagination += fmt.Sprintf(" ORDER BY (SELECT MAX(view_date) FROM %s AS sort WHERE sort.%s = %s.id) %s", userHistoryTable(ctx, scenesViewDatesTable), sceneIDColumn, sceneTable, getSortDirection(direction))
 	case "last_o_at":
-		query.sortAndPagination += fmt.Sprintf(" ORDER BY (SELECT MAX(o_date) FROM %s AS sort WHERE sort.%s = %s.id) %s", scenesODatesTable, sceneIDColumn, sceneTable, getSortDirection(direction))
+		query.sortAndPagination += fmt.Sprintf(" ORDER BY (SELECT MAX(o_date) FROM %s AS sort WHERE sort.%s = %s.id) %s", userHistoryTable(ctx, scenesODatesTable), sceneIDColumn, sceneTable, getSortDirection(direction))
 	case "o_counter":
-		query.sortAndPagination += getCountSort(sceneTable, scenesODatesTable, sceneIDColumn, direction)
+		query.sortAndPagination += getCountSort(sceneTable, userHistoryTable(ctx, scenesODatesTable), sceneIDColumn, direction)
+	case "rating", "resume_time", "play_duration":
+		col := scenesUserDataTableMgr.column(ctx, sort, "scenes.id", "scenes."+sort)
+		query.sortAndPagination += " ORDER BY " + col + " " + getSortDirection(direction)
 	case "performer_age":
 		// Looking at the youngest performer by default
 		aggregation := "MIN"
@@ -1651,6 +1723,22 @@ func (qb *SceneStore) SaveActivity(ctx context.Context, id int, resumeTime *floa
 		record["play_duration"] = goqu.L("play_duration + ?", playDuration)
 	}
 
+	if userID, ok := models.UserIDFromContext(ctx); ok && len(record) > 0 {
+		insert := goqu.Record{}
+		if resumeTime != nil {
+			insert["resume_time"] = resumeTime
+		}
+		if playDuration != nil {
+			insert["play_duration"] = playDuration
+		}
+
+		if err := scenesUserDataTableMgr.upsert(ctx, userID, id, insert, record); err != nil {
+			return false, err
+		}
+
+		return true, nil
+	}
+
 	if len(record) > 0 {
 		if err := qb.tableMgr.updateByID(ctx, id, record); err != nil {
 			return false, err
@@ -1675,6 +1763,14 @@ func (qb *SceneStore) ResetActivity(ctx context.Context, id int, resetResume boo
 		record["play_duration"] = 0.0
 	}
 
+	if userID, ok := models.UserIDFromContext(ctx); ok && len(record) > 0 {
+		if err := scenesUserDataTableMgr.upsert(ctx, userID, id, goqu.Record{}, record); err != nil {
+			return false, err
+		}
+
+		return true, nil
+	}
+
 	if len(record) > 0 {
 		if err := qb.tableMgr.updateByID(ctx, id, record); err != nil {
 			return false, err
@@ -1684,6 +1780,10 @@ func (qb *SceneStore) ResetActivity(ctx context.Context, id int, resetResume boo
 	return true, nil
 }
 
+func (qb *SceneStore) GetManyUserData(ctx context.Context, ids []int) ([]*models.UserData, error) {
+	return scenesUserDataTableMgr.getMany(ctx, ids)
+}
+
 func (qb *SceneStore) GetURLs(ctx context.Context, sceneID int) ([]string, error) {
 	return scenesURLsTableMgr.get(ctx, sceneID)
 }
@@ -105,7 +105,7 @@ func (qb *sceneFilterHandler) criterionHandler() criterionHandler {
 			criterion: sceneFilter.PhashDistance,
 		},
 
-		intCriterionHandler(sceneFilter.Rating100, "scenes.rating", nil),
+		qb.userDataIntCriterionHandler(sceneFilter.Rating100, "rating"),
 		qb.oCountCriterionHandler(sceneFilter.OCounter),
 		boolCriterionHandler(sceneFilter.Organized, "scenes.organized", nil),
 
@@ -149,13 +149,13 @@ func (qb *sceneFilterHandler) criterionHandler() criterionHandler {
 
 		qb.captionCriterionHandler(sceneFilter.Captions),
 
-		floatIntCriterionHandler(sceneFilter.ResumeTime, "scenes.resume_time", nil),
-		floatIntCriterionHandler(sceneFilter.PlayDuration, "scenes.play_duration", nil),
+		qb.userDataFloatIntCriterionHandler(sceneFilter.ResumeTime, "resume_time"),
+		qb.userDataFloatIntCriterionHandler(sceneFilter.PlayDuration, "play_duration"),
 		qb.playCountCriterionHandler(sceneFilter.PlayCount),
 		criterionHandlerFunc(func(ctx context.Context, f *filterBuilder) {
 			if sceneFilter.LastPlayedAt != nil {
 				f.addLeftJoin(
-					fmt.Sprintf("(SELECT %s, MAX(%s) as last_played_at FROM %s GROUP BY %s)", sceneIDColumn, sceneViewDateColumn, scenesViewDatesTable, sceneIDColumn),
+					fmt.Sprintf("(SELECT %s, MAX(%s) as last_played_at FROM %s GROUP BY %s)", sceneIDColumn, sceneViewDateColumn, userHistoryTable(ctx, scenesViewDatesTable), sceneIDColumn),
 					"scene_last_view",
 					fmt.Sprintf("scene_last_view.%s = scenes.id", sceneIDColumn),
 				)
@@ -331,23 +331,43 @@ func (qb *sceneFilterHandler) addVideoFilesTable(f *filterBuilder) {
 }
 
 func (qb *sceneFilterHandler) playCountCriterionHandler(count *models.IntCriterionInput) criterionHandlerFunc {
-	h := countCriterionHandlerBuilder{
-		primaryTable: sceneTable,
-		joinTable:    scenesViewDatesTable,
-		primaryFK:    sceneIDColumn,
-	}
+	return func(ctx context.Context, f *filterBuilder) {
+		h := countCriterionHandlerBuilder{
+			primaryTable: sceneTable,
+			joinTable:    userHistoryTable(ctx, scenesViewDatesTable),
+			primaryFK:    sceneIDColumn,
+		}
 
-	return h.handler(count)
+		h.handler(count)(ctx, f)
+	}
 }
 
 func (qb *sceneFilterHandler) oCountCriterionHandler(count *models.IntCriterionInput) criterionHandlerFunc {
-	h := countCriterionHandlerBuilder{
-		primaryTable: sceneTable,
-		joinTable:    scenesODatesTable,
-		primaryFK:    sceneIDColumn,
+	return func(ctx context.Context, f *filterBuilder) {
+		h := countCriterionHandlerBuilder{
+			primaryTable: sceneTable,
+			joinTable:    userHistoryTable(ctx, scenesODatesTable),
+			primaryFK:    sceneIDColumn,
+		}
+
+		h.handler(count)(ctx, f)
+	}
+}
+
+// userDataIntCriterionHandler filters on the current user's value of column
+// when the context is scoped to a user, and on the scene's own column otherwise.
+func (qb *sceneFilterHandler) userDataIntCriterionHandler(c *models.IntCriterionInput, column string) criterionHandlerFunc {
+	return func(ctx context.Context, f *filterBuilder) {
+		col := scenesUserDataTableMgr.column(ctx, column, "scenes.id", "scenes."+column)
+		intCriterionHandler(c, col, nil)(ctx, f)
 	}
+}
 
-	return h.handler(count)
+func (qb *sceneFilterHandler) userDataFloatIntCriterionHandler(c *models.IntCriterionInput, column string) criterionHandlerFunc {
+	return func(ctx context.Context, f *filterBuilder) {
+		col := scenesUserDataTableMgr.column(ctx, column, "scenes.id", "scenes."+column)
+		floatIntCriterionHandler(c, col, nil)(ctx, f)
+	}
 }
 
 func (qb *sceneFilterHandler) fileCountCriterionHandler(fileCount *models.IntCriterionInput) criterionHandlerFunc {
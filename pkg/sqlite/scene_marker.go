@@ -252,6 +252,11 @@ func (qb *SceneMarkerStore) get(ctx context.Context, q *goqu.SelectDataset) (*mo
 }
 
 func (qb *SceneMarkerStore) getMany(ctx context.Context, q *goqu.SelectDataset) ([]*models.SceneMarker, error) {
+	q, err := restrictScenes(ctx, q, qb.table().Col(sceneIDColumn))
+	if err != nil {
+		return nil, err
+	}
+
 	const single = false
 	var ret []*models.SceneMarker
 	if err := queryFunc(ctx, q, single, func(r *sqlx.Rows) error {
@@ -329,6 +334,11 @@ func (qb *SceneMarkerStore) makeQuery(ctx context.Context, sceneMarkerFilter *mo
 	query := sceneMarkerRepository.newQuery()
 	distinctIDs(&query, sceneMarkerTable)
 
+	// added first so that its arguments precede those of the other clauses
+	if err := addSceneRestriction(ctx, &query, "scene_markers.scene_id"); err != nil {
+		return nil, err
+	}
+
 	if q := findFilter.Q; q != nil && *q != "" {
 		query.join(sceneTable, "", "scenes.id = scene_markers.scene_id")
 		query.join(tagTable, "", "scene_markers.primary_tag_id = tags.id")
@@ -474,6 +484,11 @@ func (qb *SceneMarkerStore) UpdateTags(ctx context.Context, id int, tagIDs []int
 
 func (qb *SceneMarkerStore) Count(ctx context.Context) (int, error) {
 	q := dialect.Select(goqu.COUNT("*")).From(qb.table())
+	q, err := restrictScenes(ctx, q, qb.table().Col(sceneIDColumn))
+	if err != nil {
+		return 0, err
+	}
+
 	return count(ctx, q)
 }
 
@@ -0,0 +1,164 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const (
+	scheduledTaskRunTable           = "scheduled_task_runs"
+	scheduledTaskRunTaskIDColumn    = "task_id"
+	scheduledTaskRunStartedAtColumn = "started_at"
+)
+
+var scheduledTaskRunsTableMgr = &table{
+	table:    goqu.T(scheduledTaskRunTable),
+	idColumn: goqu.T(scheduledTaskRunTable).Col(idColumn),
+}
+
+type scheduledTaskRunRow struct {
+	ID        int       `db:"id" goqu:"skipinsert"`
+	TaskID    string    `db:"task_id"`
+	TaskName  string    `db:"task_name"`
+	TaskType  string    `db:"task_type"`
+	Trigger   string    `db:"trigger"`
+	Attempt   int       `db:"attempt"`
+	JobID     null.Int  `db:"job_id"`
+	Status    string    `db:"status"`
+	Error     string    `db:"error"`
+	StartedAt Timestamp `db:"started_at"`
+	EndedAt   Timestamp `db:"ended_at"`
+}
+
+func (r *scheduledTaskRunRow) fromScheduledTaskRun(o models.ScheduledTaskRun) {
+	r.ID = o.ID
+	r.TaskID = o.TaskID
+	r.TaskName = o.TaskName
+	r.TaskType = o.TaskType
+	r.Trigger = o.Trigger
+	r.Attempt = o.Attempt
+	r.JobID = intFromPtr(o.JobID)
+	r.Status = o.Status
+	r.Error = o.Error
+	r.StartedAt = Timestamp{Timestamp: o.StartedAt}
+	r.EndedAt = Timestamp{Timestamp: o.EndedAt}
+}
+
+func (r *scheduledTaskRunRow) resolve() *models.ScheduledTaskRun {
+	return &models.ScheduledTaskRun{
+		ID:        r.ID,
+		TaskID:    r.TaskID,
+		TaskName:  r.TaskName,
+		TaskType:  r.TaskType,
+		Trigger:   r.Trigger,
+		Attempt:   r.Attempt,
+		JobID:     nullIntPtr(r.JobID),
+		Status:    r.Status,
+		Error:     r.Error,
+		StartedAt: r.StartedAt.Timestamp,
+		EndedAt:   r.EndedAt.Timestamp,
+	}
+}
+
+// ScheduledTaskRunStore provides methods for the run history of scheduled
+// tasks
+type ScheduledTaskRunStore struct {
+	tableMgr *table
+}
+
+// NewScheduledTaskRunStore creates a new ScheduledTaskRunStore
+func NewScheduledTaskRunStore() *ScheduledTaskRunStore {
+	return &ScheduledTaskRunStore{
+		tableMgr: scheduledTaskRunsTableMgr,
+	}
+}
+
+func (qb *ScheduledTaskRunStore) table() exp.IdentifierExpression {
+	return qb.tableMgr.table
+}
+
+// Create adds a run to the history
+func (qb *ScheduledTaskRunStore) Create(ctx context.Context, newRun *models.ScheduledTaskRun) error {
+	var r scheduledTaskRunRow
+	r.fromScheduledTaskRun(*newRun)
+
+	id, err := qb.tableMgr.insertID(ctx, r)
+	if err != nil {
+		return fmt.Errorf("creating scheduled task run: %w", err)
+	}
+
+	newRun.ID = id
+
+	return nil
+}
+
+// FindRecent returns the most recent runs, newest first, of the task with the
+// given ID or of every task if it is empty
+func (qb *ScheduledTaskRunStore) FindRecent(ctx context.Context, taskID string, limit int) ([]*models.ScheduledTaskRun, error) {
+	q := dialect.From(qb.table()).Select(qb.table().All()).
+		Order(qb.table().Col(scheduledTaskRunStartedAtColumn).Desc(), qb.table().Col(idColumn).Desc())
+
+	if taskID != "" {
+		q = q.Where(qb.table().Col(scheduledTaskRunTaskIDColumn).Eq(taskID))
+	}
+	if limit > 0 {
+		q = q.Limit(uint(limit))
+	}
+
+	return qb.getMany(ctx, q)
+}
+
+// DestroyByTask deletes every run of a task
+func (qb *ScheduledTaskRunStore) DestroyByTask(ctx context.Context, taskID string) error {
+	q := dialect.Delete(qb.table()).Where(qb.table().Col(scheduledTaskRunTaskIDColumn).Eq(taskID))
+
+	if _, err := exec(ctx, q); err != nil {
+		return fmt.Errorf("deleting runs of scheduled task %s: %w", taskID, err)
+	}
+
+	return nil
+}
+
+// Prune deletes all but the most recent keep runs of a task
+func (qb *ScheduledTaskRunStore) Prune(ctx context.Context, taskID string, keep int) (int64, error) {
+	kept := dialect.From(qb.table()).Select(qb.table().Col(idColumn)).
+		Where(qb.table().Col(scheduledTaskRunTaskIDColumn).Eq(taskID)).
+		Order(qb.table().Col(scheduledTaskRunStartedAtColumn).Desc(), qb.table().Col(idColumn).Desc()).
+		Limit(uint(keep))
+
+	q := dialect.Delete(qb.table()).Where(
+		qb.table().Col(scheduledTaskRunTaskIDColumn).Eq(taskID),
+		qb.table().Col(idColumn).NotIn(kept),
+	)
+
+	ret, err := exec(ctx, q)
+	if err != nil {
+		return 0, fmt.Errorf("pruning runs of scheduled task %s: %w", taskID, err)
+	}
+
+	return ret.RowsAffected()
+}
+
+func (qb *ScheduledTaskRunStore) getMany(ctx context.Context, q *goqu.SelectDataset) ([]*models.ScheduledTaskRun, error) {
+	const single = false
+	var ret []*models.ScheduledTaskRun
+	if err := queryFunc(ctx, q, single, func(r *sqlx.Rows) error {
+		var row scheduledTaskRunRow
+		if err := r.StructScan(&row); err != nil {
+			return err
+		}
+		ret = append(ret, row.resolve())
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
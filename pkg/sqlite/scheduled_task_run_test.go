@@ -0,0 +1,77 @@
+//go:build integration
+// +build integration
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduledTaskRunStore(t *testing.T) {
+	withRollbackTxn(func(ctx context.Context) error {
+		assert := assert.New(t)
+		qb := db.ScheduledTaskRun
+
+		now := time.Now().Truncate(time.Second)
+		jobID := 12
+		runs := []*models.ScheduledTaskRun{
+			{TaskID: "scan", TaskName: "Nightly scan", TaskType: "SCAN", Trigger: "SCHEDULE", Attempt: 1, Status: "FAILED", Error: "boom", StartedAt: now.Add(-3 * time.Hour), EndedAt: now.Add(-3 * time.Hour)},
+			{TaskID: "scan", TaskName: "Nightly scan", TaskType: "SCAN", Trigger: "SCHEDULE", Attempt: 2, JobID: &jobID, Status: "SUCCEEDED", StartedAt: now.Add(-2 * time.Hour), EndedAt: now.Add(-time.Hour)},
+			{TaskID: "generate", TaskName: "Generate", TaskType: "GENERATE", Trigger: "CHAIN", Attempt: 1, Status: "SUCCEEDED", StartedAt: now, EndedAt: now},
+		}
+		for _, r := range runs {
+			if err := qb.Create(ctx, r); err != nil {
+				t.Errorf("ScheduledTaskRunStore.Create() error = %v", err)
+				return nil
+			}
+		}
+
+		got, err := qb.FindRecent(ctx, "scan", 0)
+		if err != nil {
+			t.Errorf("ScheduledTaskRunStore.FindRecent() error = %v", err)
+			return nil
+		}
+		if assert.Len(got, 2) {
+			assert.Equal(2, got[0].Attempt)
+			assert.Equal(&jobID, got[0].JobID)
+			assert.Equal(time.Hour, got[0].EndedAt.Sub(got[0].StartedAt))
+			assert.Nil(got[1].JobID)
+			assert.Equal("boom", got[1].Error)
+		}
+
+		got, err = qb.FindRecent(ctx, "", 1)
+		if err != nil {
+			t.Errorf("ScheduledTaskRunStore.FindRecent() error = %v", err)
+			return nil
+		}
+		if assert.Len(got, 1) {
+			assert.Equal("generate", got[0].TaskID)
+		}
+
+		deleted, err := qb.Prune(ctx, "scan", 1)
+		if err != nil {
+			t.Errorf("ScheduledTaskRunStore.Prune() error = %v", err)
+			return nil
+		}
+		assert.Equal(int64(1), deleted)
+
+		got, _ = qb.FindRecent(ctx, "scan", 0)
+		if assert.Len(got, 1) {
+			assert.Equal("SUCCEEDED", got[0].Status)
+		}
+
+		if err := qb.DestroyByTask(ctx, "scan"); err != nil {
+			t.Errorf("ScheduledTaskRunStore.DestroyByTask() error = %v", err)
+			return nil
+		}
+		got, _ = qb.FindRecent(ctx, "", 0)
+		assert.Len(got, 1)
+
+		return nil
+	})
+}
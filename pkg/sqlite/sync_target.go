@@ -0,0 +1,288 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const (
+	syncTargetTable       = "sync_targets"
+	syncTargetItemsTable  = "sync_target_items"
+	syncTargetNameColumn  = "name"
+	syncTargetUserColumn  = "user_id"
+	syncTargetIDColumn    = "sync_target_id"
+	syncTargetSceneColumn = "scene_id"
+)
+
+var syncTargetsTableMgr = &table{
+	table:    goqu.T(syncTargetTable),
+	idColumn: goqu.T(syncTargetTable).Col(idColumn),
+}
+
+// syncTargetItemsTableMgr addresses items by their target, so that destroy
+// removes all of a target's items.
+var syncTargetItemsTableMgr = &table{
+	table:    goqu.T(syncTargetItemsTable),
+	idColumn: goqu.T(syncTargetItemsTable).Col(syncTargetIDColumn),
+}
+
+type syncTargetRow struct {
+	ID           int           `db:"id" goqu:"skipinsert"`
+	Name         string        `db:"name"`
+	SourceType   string        `db:"source_type"`
+	SourceID     int           `db:"source_id"`
+	Profile      string        `db:"profile"`
+	MaxSize      int64         `db:"max_size"`
+	LastSyncedAt NullTimestamp `db:"last_synced_at"`
+	UserID       null.Int      `db:"user_id"`
+	CreatedAt    Timestamp     `db:"created_at"`
+	UpdatedAt    Timestamp     `db:"updated_at"`
+}
+
+func (r *syncTargetRow) fromSyncTarget(o models.SyncTarget) {
+	r.ID = o.ID
+	r.Name = o.Name
+	r.SourceType = string(o.SourceType)
+	r.SourceID = o.SourceID
+	r.Profile = o.Profile
+	r.MaxSize = o.MaxSize
+	r.LastSyncedAt = NullTimestampFromTimePtr(o.LastSyncedAt)
+	r.UserID = intFromPtr(o.UserID)
+	r.CreatedAt = Timestamp{Timestamp: o.CreatedAt}
+	r.UpdatedAt = Timestamp{Timestamp: o.UpdatedAt}
+}
+
+func (r *syncTargetRow) resolve() *models.SyncTarget {
+	return &models.SyncTarget{
+		ID:           r.ID,
+		Name:         r.Name,
+		SourceType:   models.SyncTargetSource(r.SourceType),
+		SourceID:     r.SourceID,
+		Profile:      r.Profile,
+		MaxSize:      r.MaxSize,
+		LastSyncedAt: r.LastSyncedAt.TimePtr(),
+		UserID:       nullIntPtr(r.UserID),
+		CreatedAt:    r.CreatedAt.Timestamp,
+		UpdatedAt:    r.UpdatedAt.Timestamp,
+	}
+}
+
+type syncTargetItemRow struct {
+	SyncTargetID int       `db:"sync_target_id"`
+	SceneID      int       `db:"scene_id"`
+	Size         int64     `db:"size"`
+	SyncedAt     Timestamp `db:"synced_at"`
+}
+
+func (r *syncTargetItemRow) resolve() *models.SyncTargetItem {
+	return &models.SyncTargetItem{
+		SceneID:  r.SceneID,
+		Size:     r.Size,
+		SyncedAt: r.SyncedAt.Timestamp,
+	}
+}
+
+type syncTargetRowRecord struct {
+	updateRecord
+}
+
+func (r *syncTargetRowRecord) fromPartial(o models.SyncTargetPartial) {
+	r.setString("name", o.Name)
+	r.setString("source_type", o.SourceType)
+	r.setInt("source_id", o.SourceID)
+	r.setString("profile", o.Profile)
+	if o.MaxSize.Set {
+		r.set("max_size", o.MaxSize.Value)
+	}
+	r.setTimestamp("last_synced_at", o.LastSyncedAt)
+	r.setTimestamp("updated_at", o.UpdatedAt)
+}
+
+// SyncTargetStore provides methods for the devices the library is synced to.
+type SyncTargetStore struct {
+	repository
+
+	tableMgr *table
+}
+
+// NewSyncTargetStore creates a new SyncTargetStore
+func NewSyncTargetStore() *SyncTargetStore {
+	return &SyncTargetStore{
+		repository: repository{
+			tableName: syncTargetTable,
+			idColumn:  idColumn,
+		},
+		tableMgr: syncTargetsTableMgr,
+	}
+}
+
+func (qb *SyncTargetStore) table() exp.IdentifierExpression {
+	return qb.tableMgr.table
+}
+
+func (qb *SyncTargetStore) selectDataset() *goqu.SelectDataset {
+	return dialect.From(qb.table()).Select(qb.table().All())
+}
+
+func (qb *SyncTargetStore) Create(ctx context.Context, newTarget *models.SyncTarget) error {
+	var r syncTargetRow
+	r.fromSyncTarget(*newTarget)
+
+	id, err := qb.tableMgr.insertID(ctx, r)
+	if err != nil {
+		return fmt.Errorf("creating sync target: %w", err)
+	}
+
+	updated, err := qb.Find(ctx, id)
+	if err != nil {
+		return fmt.Errorf("finding after create: %w", err)
+	}
+
+	*newTarget = *updated
+
+	return nil
+}
+
+func (qb *SyncTargetStore) UpdatePartial(ctx context.Context, id int, partial models.SyncTargetPartial) (*models.SyncTarget, error) {
+	r := syncTargetRowRecord{
+		updateRecord{
+			Record: make(exp.Record),
+		},
+	}
+
+	r.fromPartial(partial)
+
+	if len(r.Record) > 0 {
+		if err := qb.tableMgr.updateByID(ctx, id, r.Record); err != nil {
+			return nil, fmt.Errorf("updating sync target %d: %w", id, err)
+		}
+	}
+
+	return qb.Find(ctx, id)
+}
+
+func (qb *SyncTargetStore) Destroy(ctx context.Context, id int) error {
+	return qb.destroyExisting(ctx, []int{id})
+}
+
+// Find returns a sync target by ID, or nil if not found
+func (qb *SyncTargetStore) Find(ctx context.Context, id int) (*models.SyncTarget, error) {
+	ret, err := qb.get(ctx, qb.selectDataset().Where(qb.tableMgr.byID(id)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting sync target by id %d: %w", id, err)
+	}
+
+	return ret, nil
+}
+
+// FindAll returns all sync targets in order of name
+func (qb *SyncTargetStore) FindAll(ctx context.Context) ([]*models.SyncTarget, error) {
+	table := qb.table()
+	q := qb.selectDataset().Order(
+		table.Col(syncTargetNameColumn).Asc(),
+		table.Col(idColumn).Asc(),
+	)
+
+	return qb.getMany(ctx, q)
+}
+
+// FindByUserID returns the sync targets of a user in order of name
+func (qb *SyncTargetStore) FindByUserID(ctx context.Context, userID int) ([]*models.SyncTarget, error) {
+	table := qb.table()
+	q := qb.selectDataset().
+		Where(table.Col(syncTargetUserColumn).Eq(userID)).
+		Order(
+			table.Col(syncTargetNameColumn).Asc(),
+			table.Col(idColumn).Asc(),
+		)
+
+	return qb.getMany(ctx, q)
+}
+
+// GetItems returns the scenes the target's device has, in the order they
+// were synced.
+func (qb *SyncTargetStore) GetItems(ctx context.Context, id int) ([]*models.SyncTargetItem, error) {
+	table := syncTargetItemsTableMgr.table
+	q := dialect.From(table).Select(table.All()).
+		Where(syncTargetItemsTableMgr.byID(id)).
+		Order(table.Col("synced_at").Asc(), table.Col(syncTargetSceneColumn).Asc())
+
+	const single = false
+	var ret []*models.SyncTargetItem
+	if err := queryFunc(ctx, q, single, func(r *sqlx.Rows) error {
+		var row syncTargetItemRow
+		if err := r.StructScan(&row); err != nil {
+			return err
+		}
+		ret = append(ret, row.resolve())
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("getting items of sync target %d: %w", id, err)
+	}
+
+	return ret, nil
+}
+
+// UpdateItems replaces the scenes the target's device has.
+func (qb *SyncTargetStore) UpdateItems(ctx context.Context, id int, items []*models.SyncTargetItem) error {
+	if err := syncTargetItemsTableMgr.destroy(ctx, []int{id}); err != nil {
+		return err
+	}
+
+	for _, i := range items {
+		r := syncTargetItemRow{
+			SyncTargetID: id,
+			SceneID:      i.SceneID,
+			Size:         i.Size,
+			SyncedAt:     Timestamp{Timestamp: i.SyncedAt},
+		}
+
+		if _, err := syncTargetItemsTableMgr.insert(ctx, r); err != nil {
+			return fmt.Errorf("adding scene %d to sync target %d: %w", i.SceneID, id, err)
+		}
+	}
+
+	return nil
+}
+
+func (qb *SyncTargetStore) get(ctx context.Context, q *goqu.SelectDataset) (*models.SyncTarget, error) {
+	ret, err := qb.getMany(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ret) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return ret[0], nil
+}
+
+func (qb *SyncTargetStore) getMany(ctx context.Context, q *goqu.SelectDataset) ([]*models.SyncTarget, error) {
+	const single = false
+	var ret []*models.SyncTarget
+	if err := queryFunc(ctx, q, single, func(r *sqlx.Rows) error {
+		var row syncTargetRow
+		if err := r.StructScan(&row); err != nil {
+			return err
+		}
+		ret = append(ret, row.resolve())
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,163 @@
+//go:build integration
+// +build integration
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncTargetStore(t *testing.T) {
+	withRollbackTxn(func(ctx context.Context) error {
+		assert := assert.New(t)
+		qb := db.SyncTarget
+
+		target := models.NewSyncTarget()
+		target.Name = "phone"
+		target.SourceType = models.SyncTargetSourcePlaylist
+		target.SourceID = 1
+		target.Profile = "mobile"
+		target.MaxSize = 1 << 30
+
+		if err := qb.Create(ctx, &target); err != nil {
+			t.Errorf("SyncTargetStore.Create() error = %v", err)
+			return nil
+		}
+
+		found, err := qb.Find(ctx, target.ID)
+		if err != nil {
+			t.Errorf("SyncTargetStore.Find() error = %v", err)
+			return nil
+		}
+		if assert.NotNil(found) {
+			assert.Equal("phone", found.Name)
+			assert.Equal(models.SyncTargetSourcePlaylist, found.SourceType)
+			assert.Equal(int64(1<<30), found.MaxSize)
+			assert.Nil(found.LastSyncedAt)
+		}
+
+		now := time.Now().Truncate(time.Second)
+		partial := models.NewSyncTargetPartial()
+		partial.MaxSize = models.NewOptionalInt64(0)
+		partial.LastSyncedAt = models.NewOptionalTime(now)
+		updated, err := qb.UpdatePartial(ctx, target.ID, partial)
+		if err != nil {
+			t.Errorf("SyncTargetStore.UpdatePartial() error = %v", err)
+			return nil
+		}
+		assert.Equal(int64(0), updated.MaxSize)
+		if assert.NotNil(updated.LastSyncedAt) {
+			assert.True(now.Equal(*updated.LastSyncedAt))
+		}
+
+		items := []*models.SyncTargetItem{
+			{SceneID: sceneIDs[sceneIdxWithGroup], Size: 100, SyncedAt: now},
+			{SceneID: sceneIDs[sceneIdxWithGallery], Size: 200, SyncedAt: now.Add(time.Minute)},
+		}
+		if err := qb.UpdateItems(ctx, target.ID, items); err != nil {
+			t.Errorf("SyncTargetStore.UpdateItems() error = %v", err)
+			return nil
+		}
+
+		got, err := qb.GetItems(ctx, target.ID)
+		if err != nil {
+			t.Errorf("SyncTargetStore.GetItems() error = %v", err)
+			return nil
+		}
+		assertSyncTargetItems(t, items, got)
+
+		// updating items replaces them
+		if err := qb.UpdateItems(ctx, target.ID, items[1:]); err != nil {
+			t.Errorf("SyncTargetStore.UpdateItems() error = %v", err)
+			return nil
+		}
+		got, err = qb.GetItems(ctx, target.ID)
+		if err != nil {
+			t.Errorf("SyncTargetStore.GetItems() error = %v", err)
+			return nil
+		}
+		assertSyncTargetItems(t, items[1:], got)
+
+		if err := qb.Destroy(ctx, target.ID); err != nil {
+			t.Errorf("SyncTargetStore.Destroy() error = %v", err)
+			return nil
+		}
+
+		found, err = qb.Find(ctx, target.ID)
+		if err != nil {
+			t.Errorf("SyncTargetStore.Find() error = %v", err)
+			return nil
+		}
+		assert.Nil(found)
+
+		got, err = qb.GetItems(ctx, target.ID)
+		if err != nil {
+			t.Errorf("SyncTargetStore.GetItems() error = %v", err)
+			return nil
+		}
+		assert.Empty(got)
+
+		return nil
+	})
+}
+
+func TestSyncTargetStoreFindByUserID(t *testing.T) {
+	withRollbackTxn(func(ctx context.Context) error {
+		assert := assert.New(t)
+		qb := db.SyncTarget
+
+		userID, _ := models.UserIDFromContext(createUserDataTestUser(ctx, t, "syncowner"))
+
+		owned := models.NewSyncTarget()
+		owned.Name = "owned"
+		owned.SourceType = models.SyncTargetSourcePlaylist
+		owned.SourceID = 1
+		owned.UserID = &userID
+
+		unowned := models.NewSyncTarget()
+		unowned.Name = "unowned"
+		unowned.SourceType = models.SyncTargetSourcePlaylist
+		unowned.SourceID = 1
+
+		for _, target := range []*models.SyncTarget{&owned, &unowned} {
+			if err := qb.Create(ctx, target); err != nil {
+				t.Errorf("SyncTargetStore.Create() error = %v", err)
+				return nil
+			}
+		}
+
+		if assert.NotNil(owned.UserID) {
+			assert.Equal(userID, *owned.UserID)
+		}
+		assert.Nil(unowned.UserID)
+
+		got, err := qb.FindByUserID(ctx, userID)
+		if err != nil {
+			t.Errorf("SyncTargetStore.FindByUserID() error = %v", err)
+			return nil
+		}
+		if assert.Len(got, 1) {
+			assert.Equal(owned.ID, got[0].ID)
+		}
+
+		return nil
+	})
+}
+
+func assertSyncTargetItems(t *testing.T, want []*models.SyncTargetItem, got []*models.SyncTargetItem) {
+	t.Helper()
+
+	if !assert.Len(t, got, len(want)) {
+		return
+	}
+	for i := range want {
+		assert.Equal(t, want[i].SceneID, got[i].SceneID)
+		assert.Equal(t, want[i].Size, got[i].Size)
+		assert.True(t, want[i].SyncedAt.Equal(got[i].SyncedAt))
+	}
+}
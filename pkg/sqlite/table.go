@@ -875,13 +875,27 @@ type viewHistoryTable struct {
 	dateColumn exp.IdentifierExpression
 }
 
+// where returns the given expressions plus, when ctx is scoped to a user, a
+// clause restricting the history to that user's rows.
+func (t *viewHistoryTable) where(ctx context.Context, ex ...exp.Expression) []exp.Expression {
+	if userID, ok := models.UserIDFromContext(ctx); ok {
+		ex = append(ex, t.table.table.Col(userIDColumn).Eq(userID))
+	}
+	return ex
+}
+
+// userValue returns the user_id value to record with new history rows.
+func (t *viewHistoryTable) userValue(ctx context.Context) interface{} {
+	return currentUserID(ctx)
+}
+
 func (t *viewHistoryTable) getDates(ctx context.Context, id int) ([]time.Time, error) {
 	table := t.table.table
 
 	q := dialect.Select(
 		t.dateColumn,
 	).From(table).Where(
-		t.idColumn.Eq(id),
+		t.where(ctx, t.idColumn.Eq(id))...,
 	).Order(t.dateColumn.Desc())
 
 	const single = false
@@ -907,7 +921,7 @@ func (t *viewHistoryTable) getManyDates(ctx context.Context, ids []int) ([][]tim
 		t.idColumn,
 		t.dateColumn,
 	).From(table).Where(
-		t.idColumn.In(ids),
+		t.where(ctx, t.idColumn.In(ids))...,
 	).Order(t.dateColumn.Desc())
 
 	ret := make([][]time.Time, len(ids))
@@ -934,7 +948,7 @@ func (t *viewHistoryTable) getManyDates(ctx context.Context, ids []int) ([][]tim
 func (t *viewHistoryTable) getLastDate(ctx context.Context, id int) (*time.Time, error) {
 	table := t.table.table
 	q := dialect.Select(t.dateColumn).From(table).Where(
-		t.idColumn.Eq(id),
+		t.where(ctx, t.idColumn.Eq(id))...,
 	).Order(t.dateColumn.Desc()).Limit(1)
 
 	var date NullTimestamp
@@ -952,7 +966,7 @@ func (t *viewHistoryTable) getManyLastDate(ctx context.Context, ids []int) ([]*t
 		t.idColumn,
 		goqu.MAX(t.dateColumn),
 	).From(table).Where(
-		t.idColumn.In(ids),
+		t.where(ctx, t.idColumn.In(ids))...,
 	).GroupBy(t.idColumn)
 
 	ret := make([]*time.Time, len(ids))
@@ -986,7 +1000,7 @@ func (t *viewHistoryTable) getManyLastDate(ctx context.Context, ids []int) ([]*t
 
 func (t *viewHistoryTable) getCount(ctx context.Context, id int) (int, error) {
 	table := t.table.table
-	q := dialect.Select(goqu.COUNT("*")).From(table).Where(t.idColumn.Eq(id))
+	q := dialect.Select(goqu.COUNT("*")).From(table).Where(t.where(ctx, t.idColumn.Eq(id))...)
 
 	const single = true
 	var ret int
@@ -1009,7 +1023,7 @@ func (t *viewHistoryTable) getManyCount(ctx context.Context, ids []int) ([]int,
 		t.idColumn,
 		goqu.COUNT(t.dateColumn),
 	).From(table).Where(
-		t.idColumn.In(ids),
+		t.where(ctx, t.idColumn.In(ids))...,
 	).GroupBy(t.idColumn)
 
 	ret := make([]int, len(ids))
@@ -1035,7 +1049,7 @@ func (t *viewHistoryTable) getManyCount(ctx context.Context, ids []int) ([]int,
 
 func (t *viewHistoryTable) getAllCount(ctx context.Context) (int, error) {
 	table := t.table.table
-	q := dialect.Select(goqu.COUNT("*")).From(table)
+	q := dialect.Select(goqu.COUNT("*")).From(table).Where(t.where(ctx)...)
 
 	const single = true
 	var ret int
@@ -1053,7 +1067,7 @@ func (t *viewHistoryTable) getAllCount(ctx context.Context) (int, error) {
 
 func (t *viewHistoryTable) getUniqueCount(ctx context.Context) (int, error) {
 	table := t.table.table
-	q := dialect.Select(goqu.COUNT(goqu.DISTINCT(t.idColumn))).From(table)
+	q := dialect.Select(goqu.COUNT(goqu.DISTINCT(t.idColumn))).From(table).Where(t.where(ctx)...)
 
 	const single = true
 	var ret int
@@ -1077,9 +1091,9 @@ func (t *viewHistoryTable) addDates(ctx context.Context, id int, dates []time.Ti
 	}
 
 	for _, d := range dates {
-		q := dialect.Insert(table).Cols(t.idColumn.GetCol(), t.dateColumn.GetCol()).Vals(
+		q := dialect.Insert(table).Cols(t.idColumn.GetCol(), t.dateColumn.GetCol(), userIDColumn).Vals(
 			// convert all dates to UTC
-			goqu.Vals{id, UTCTimestamp{Timestamp{d}}},
+			goqu.Vals{id, UTCTimestamp{Timestamp{d}}, t.userValue(ctx)},
 		)
 
 		if _, err := exec(ctx, q); err != nil {
@@ -1104,12 +1118,11 @@ func (t *viewHistoryTable) deleteDates(ctx context.Context, id int, dates []time
 		if mostRecent {
 			// delete the most recent
 			subquery = dialect.Select("rowid").From(table).Where(
-				t.idColumn.Eq(id),
+				t.where(ctx, t.idColumn.Eq(id))...,
 			).Order(t.dateColumn.Desc()).Limit(1)
 		} else {
 			subquery = dialect.Select("rowid").From(table).Where(
-				t.idColumn.Eq(id),
-				t.dateColumn.Eq(UTCTimestamp{Timestamp{date}}),
+				t.where(ctx, t.idColumn.Eq(id), t.dateColumn.Eq(UTCTimestamp{Timestamp{date}}))...,
 			).Limit(1)
 		}
 
@@ -1125,7 +1138,7 @@ func (t *viewHistoryTable) deleteDates(ctx context.Context, id int, dates []time
 
 func (t *viewHistoryTable) deleteAllDates(ctx context.Context, id int) (int, error) {
 	table := t.table.table
-	q := dialect.Delete(table).Where(t.idColumn.Eq(id))
+	q := dialect.Delete(table).Where(t.where(ctx, t.idColumn.Eq(id))...)
 
 	if _, err := exec(ctx, q); err != nil {
 		return 0, fmt.Errorf("resetting dates for id %v: %w", id, err)
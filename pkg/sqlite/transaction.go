@@ -136,11 +136,20 @@ func (db *Database) Repository() models.Repository {
 		PotentialScene:          db.PotentialScene,
 		ContentProfile:          db.ContentProfile,
 		User:                    db.User,
+		Role:                    db.Role,
+		APIKey:                  db.APIKey,
+		AuditEntry:              db.AuditEntry,
 		Playlist:                db.Playlist,
 		RecycleBin:              db.RecycleBin,
 		DismissedRecommendation: db.DismissedRecommendation,
 		LikedRecommendation:     db.LikedRecommendation,
-		VisualSignature:         db.VisualSignature,
+		VisualEmbedding:         db.VisualEmbedding,
+		CoWatch:                 db.CoWatch,
+		IPTVChannel:             db.IPTVChannel,
+		ScheduledTaskRun:        db.ScheduledTaskRun,
+		QueuedJob:               db.QueuedJob,
+		OptimizedVersion:        db.OptimizedVersion,
+		SyncTarget:              db.SyncTarget,
 		Analytics:               db.Analytics,
 	}
 }
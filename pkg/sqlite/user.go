@@ -10,6 +10,7 @@ import (
 	"github.com/doug-martin/goqu/v9"
 	"github.com/doug-martin/goqu/v9/exp"
 	"github.com/jmoiron/sqlx"
+	"gopkg.in/guregu/null.v4"
 
 	"github.com/stashapp/stash/pkg/models"
 )
@@ -17,6 +18,11 @@ import (
 const (
 	userTable        = "users"
 	userSessionTable = "user_sessions"
+
+	usersContentFiltersTable  = "users_content_filters"
+	usersExcludedTagsTable    = "users_excluded_tags"
+	usersExcludedStudiosTable = "users_excluded_studios"
+	savedFilterIDColumn       = "saved_filter_id"
 )
 
 var (
@@ -29,6 +35,27 @@ var (
 		table:    goqu.T(userSessionTable),
 		idColumn: goqu.T(userSessionTable).Col(idColumn),
 	}
+
+	usersContentFiltersTableMgr = &table{
+		table:    goqu.T(usersContentFiltersTable),
+		idColumn: goqu.T(usersContentFiltersTable).Col(userIDColumn),
+	}
+
+	usersExcludedTagsTableMgr = &joinTable{
+		table: table{
+			table:    goqu.T(usersExcludedTagsTable),
+			idColumn: goqu.T(usersExcludedTagsTable).Col(userIDColumn),
+		},
+		fkColumn: goqu.T(usersExcludedTagsTable).Col(tagIDColumn),
+	}
+
+	usersExcludedStudiosTableMgr = &joinTable{
+		table: table{
+			table:    goqu.T(usersExcludedStudiosTable),
+			idColumn: goqu.T(usersExcludedStudiosTable).Col(userIDColumn),
+		},
+		fkColumn: goqu.T(usersExcludedStudiosTable).Col(studioIDColumn),
+	}
 )
 
 type userRow struct {
@@ -41,6 +68,8 @@ type userRow struct {
 	UpdatedAt    Timestamp `db:"updated_at"`
 	LastLoginAt  *Timestamp `db:"last_login_at"`
 	IsActive     bool      `db:"is_active"`
+	CustomRoleID null.Int  `db:"custom_role_id"`
+	ExternalAuth bool      `db:"external_auth"`
 }
 
 func (r *userRow) fromUser(u models.User) {
@@ -55,6 +84,8 @@ func (r *userRow) fromUser(u models.User) {
 		r.LastLoginAt = &Timestamp{Timestamp: *u.LastLoginAt}
 	}
 	r.IsActive = u.IsActive
+	r.CustomRoleID = intFromPtr(u.CustomRoleID)
+	r.ExternalAuth = u.ExternalAuth
 }
 
 func (r *userRow) resolve() *models.User {
@@ -67,6 +98,8 @@ func (r *userRow) resolve() *models.User {
 		CreatedAt:    r.CreatedAt.Timestamp,
 		UpdatedAt:    r.UpdatedAt.Timestamp,
 		IsActive:     r.IsActive,
+		CustomRoleID: nullIntPtr(r.CustomRoleID),
+		ExternalAuth: r.ExternalAuth,
 	}
 
 	if r.LastLoginAt != nil {
@@ -204,6 +237,12 @@ func userRecordFromPartial(partial models.UserPartial) exp.Record {
 	if partial.IsActive.Set {
 		r["is_active"] = partial.IsActive.Value
 	}
+	if partial.CustomRoleID.Set {
+		r["custom_role_id"] = partial.CustomRoleID.Ptr()
+	}
+	if partial.ExternalAuth.Set {
+		r["external_auth"] = partial.ExternalAuth.Value
+	}
 
 	return r
 }
@@ -217,6 +256,95 @@ func (qb *UserStore) UpdateLastLogin(ctx context.Context, id int) error {
 	return err
 }
 
+// ClaimUnownedData attributes play and O history recorded without a user to
+// the given user, and copies the library-wide ratings, resume points, play
+// durations and O-counts into the user's own data where the user has none.
+// Unowned content profiles and recommendation feedback are claimed likewise.
+// It is used when the first admin is created on a database that was
+// previously used in single-user mode.
+func (qb *UserStore) ClaimUnownedData(ctx context.Context, userID int) error {
+	stmts := []string{
+		"UPDATE `scenes_view_dates` SET `user_id` = ? WHERE `user_id` IS NULL",
+		"UPDATE `scenes_o_dates` SET `user_id` = ? WHERE `user_id` IS NULL",
+		"INSERT OR IGNORE INTO `scenes_users` (`scene_id`, `user_id`, `rating`, `resume_time`, `play_duration`) " +
+			"SELECT `id`, ?, `rating`, `resume_time`, `play_duration` FROM `scenes` " +
+			"WHERE `rating` IS NOT NULL OR `resume_time` > 0 OR `play_duration` > 0",
+		"INSERT OR IGNORE INTO `images_users` (`image_id`, `user_id`, `rating`, `o_counter`) " +
+			"SELECT `id`, ?, `rating`, `o_counter` FROM `images` " +
+			"WHERE `rating` IS NOT NULL OR `o_counter` > 0",
+		"INSERT OR IGNORE INTO `galleries_users` (`gallery_id`, `user_id`, `rating`) " +
+			"SELECT `id`, ?, `rating` FROM `galleries` WHERE `rating` IS NOT NULL",
+		"UPDATE OR IGNORE `content_profiles` SET `user_id` = ? WHERE `user_id` IS NULL",
+		"UPDATE OR IGNORE `dismissed_recommendations` SET `user_id` = ? WHERE `user_id` IS NULL",
+		"UPDATE OR IGNORE `liked_recommendations` SET `user_id` = ? WHERE `user_id` IS NULL",
+	}
+
+	for _, stmt := range stmts {
+		if _, err := dbWrapper.Exec(ctx, stmt, userID); err != nil {
+			return fmt.Errorf("claiming unowned user data: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetContentRestriction returns the scenes the user is restricted to. The
+// returned restriction is empty if the user may see the whole library.
+func (qb *UserStore) GetContentRestriction(ctx context.Context, userID int) (*models.ContentRestriction, error) {
+	ret := &models.ContentRestriction{}
+
+	filterTable := usersContentFiltersTableMgr.table
+	q := dialect.Select(filterTable.Col(savedFilterIDColumn)).From(filterTable).Where(filterTable.Col(userIDColumn).Eq(userID))
+
+	var savedFilterID sql.NullInt64
+	if err := querySimple(ctx, q, &savedFilterID); err != nil {
+		return nil, fmt.Errorf("getting content filter for user %d: %w", userID, err)
+	}
+	if savedFilterID.Valid {
+		id := int(savedFilterID.Int64)
+		ret.SavedFilterID = &id
+	}
+
+	var err error
+	ret.ExcludedTagIDs, err = usersExcludedTagsTableMgr.get(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting excluded tags for user %d: %w", userID, err)
+	}
+
+	ret.ExcludedStudioIDs, err = usersExcludedStudiosTableMgr.get(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting excluded studios for user %d: %w", userID, err)
+	}
+
+	return ret, nil
+}
+
+// UpdateContentRestriction replaces the content restriction of the user.
+func (qb *UserStore) UpdateContentRestriction(ctx context.Context, userID int, restriction models.ContentRestriction) error {
+	if err := usersContentFiltersTableMgr.destroy(ctx, []int{userID}); err != nil {
+		return fmt.Errorf("clearing content filter for user %d: %w", userID, err)
+	}
+
+	if restriction.SavedFilterID != nil {
+		if _, err := usersContentFiltersTableMgr.insert(ctx, exp.Record{
+			userIDColumn:        userID,
+			savedFilterIDColumn: *restriction.SavedFilterID,
+		}); err != nil {
+			return fmt.Errorf("setting content filter for user %d: %w", userID, err)
+		}
+	}
+
+	if err := usersExcludedTagsTableMgr.replaceJoins(ctx, userID, restriction.ExcludedTagIDs); err != nil {
+		return fmt.Errorf("setting excluded tags for user %d: %w", userID, err)
+	}
+
+	if err := usersExcludedStudiosTableMgr.replaceJoins(ctx, userID, restriction.ExcludedStudioIDs); err != nil {
+		return fmt.Errorf("setting excluded studios for user %d: %w", userID, err)
+	}
+
+	return nil
+}
+
 // Destroy deletes a user by ID
 func (qb *UserStore) Destroy(ctx context.Context, id int) error {
 	return qb.destroyExisting(ctx, []int{id})
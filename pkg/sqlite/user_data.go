@@ -0,0 +1,195 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const (
+	userIDColumn        = "user_id"
+	scenesUsersTable    = "scenes_users"
+	imagesUsersTable    = "images_users"
+	galleriesUsersTable = "galleries_users"
+)
+
+var (
+	scenesUserDataTableMgr = &userDataTable{
+		table:    goqu.T(scenesUsersTable),
+		idColumn: goqu.T(scenesUsersTable).Col(sceneIDColumn),
+		columns:  []string{"rating", "resume_time", "play_duration"},
+	}
+
+	imagesUserDataTableMgr = &userDataTable{
+		table:    goqu.T(imagesUsersTable),
+		idColumn: goqu.T(imagesUsersTable).Col(imageIDColumn),
+		columns:  []string{"rating", "o_counter"},
+	}
+
+	galleriesUserDataTableMgr = &userDataTable{
+		table:    goqu.T(galleriesUsersTable),
+		idColumn: goqu.T(galleriesUsersTable).Col(galleryIDColumn),
+		columns:  []string{"rating"},
+	}
+)
+
+type userDataRow struct {
+	ID           int      `db:"id"`
+	UserID       int      `db:"user_id"`
+	Rating       null.Int `db:"rating"`
+	ResumeTime   float64  `db:"resume_time"`
+	PlayDuration float64  `db:"play_duration"`
+	OCounter     int      `db:"o_counter"`
+}
+
+func (r *userDataRow) resolve() *models.UserData {
+	return &models.UserData{
+		ID:           r.ID,
+		UserID:       r.UserID,
+		Rating:       nullIntPtr(r.Rating),
+		ResumeTime:   r.ResumeTime,
+		PlayDuration: r.PlayDuration,
+		OCounter:     r.OCounter,
+	}
+}
+
+// userDataTable manages a table holding one row per (entity, user) pair with
+// the user's own rating, resume point and counters for that entity.
+type userDataTable struct {
+	table    exp.IdentifierExpression
+	idColumn exp.IdentifierExpression
+	columns  []string
+}
+
+func (t *userDataTable) getMany(ctx context.Context, ids []int) ([]*models.UserData, error) {
+	ret := make([]*models.UserData, len(ids))
+
+	userID, ok := models.UserIDFromContext(ctx)
+	if !ok || len(ids) == 0 {
+		return ret, nil
+	}
+
+	cols := []interface{}{
+		t.idColumn.As("id"),
+		t.table.Col(userIDColumn),
+	}
+	for _, c := range t.columns {
+		cols = append(cols, t.table.Col(c))
+	}
+
+	q := dialect.Select(cols...).From(t.table).Where(
+		t.idColumn.In(ids),
+		t.table.Col(userIDColumn).Eq(userID),
+	)
+
+	idToIndex := idToIndexMap(ids)
+
+	const single = false
+	if err := queryFunc(ctx, q, single, func(rows *sqlx.Rows) error {
+		var row userDataRow
+		if err := rows.StructScan(&row); err != nil {
+			return err
+		}
+
+		ret[idToIndex[row.ID]] = row.resolve()
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("getting user data from %s: %w", t.table.GetTable(), err)
+	}
+
+	return ret, nil
+}
+
+// upsert creates or updates the row for the given entity and user. The insert
+// record holds the values for a new row; the update record is applied to an
+// existing row and may reference its current values.
+func (t *userDataTable) upsert(ctx context.Context, userID int, id int, insert goqu.Record, update goqu.Record) error {
+	rec := goqu.Record{
+		t.idColumn.GetCol().(string): id,
+		userIDColumn:                 userID,
+	}
+	for k, v := range insert {
+		rec[k] = v
+	}
+
+	conflict := fmt.Sprintf("%s, %s", t.idColumn.GetCol(), userIDColumn)
+	q := dialect.Insert(t.table).Rows(rec).OnConflict(goqu.DoUpdate(conflict, update))
+
+	if _, err := exec(ctx, q); err != nil {
+		return fmt.Errorf("updating %s: %w", t.table.GetTable(), err)
+	}
+
+	return nil
+}
+
+// get returns the value of a single column for the given entity and user.
+func (t *userDataTable) get(ctx context.Context, userID int, id int, column string, dest interface{}) error {
+	q := dialect.Select(goqu.COALESCE(t.table.Col(column), 0)).From(t.table).Where(
+		t.idColumn.Eq(id),
+		t.table.Col(userIDColumn).Eq(userID),
+	)
+
+	return querySimple(ctx, q, dest)
+}
+
+// column returns the SQL expression for the current user's value of column
+// on the entity identified by parentIDCol. When ctx is not scoped to a user,
+// fallback - the library-wide column - is returned unchanged.
+// Missing rows read as NULL for rating and as 0 for every other column,
+// matching the defaults of the library-wide columns.
+func (t *userDataTable) column(ctx context.Context, column string, parentIDCol string, fallback string) string {
+	userID, ok := models.UserIDFromContext(ctx)
+	if !ok {
+		return fallback
+	}
+
+	ret := fmt.Sprintf("(SELECT %[1]s.%[2]s FROM %[1]s WHERE %[1]s.%[3]s = %[4]s AND %[1]s.%[5]s = %[6]d)",
+		t.table.GetTable(), column, t.idColumn.GetCol(), parentIDCol, userIDColumn, userID)
+	if column != "rating" {
+		ret = "COALESCE(" + ret + ", 0)"
+	}
+
+	return ret
+}
+
+// userHistoryTable returns the history table to read from. When ctx is scoped
+// to a user, a subquery restricted to that user's rows is returned in place of
+// the table name so that it can be used in any FROM or JOIN clause.
+func userHistoryTable(ctx context.Context, table string) string {
+	userID, ok := models.UserIDFromContext(ctx)
+	if !ok {
+		return table
+	}
+
+	return fmt.Sprintf("(SELECT * FROM %s WHERE %s = %d)", table, userIDColumn, userID)
+}
+
+// currentUserID returns the user ctx is scoped to as a query argument, or nil
+// when it is not scoped. It is intended for use with `user_id IS ?` clauses so
+// that unscoped reads and writes apply to the rows without a user.
+func currentUserID(ctx context.Context) interface{} {
+	if userID, ok := models.UserIDFromContext(ctx); ok {
+		return userID
+	}
+	return nil
+}
+
+// isUserDataSort returns true if sorting by sort depends on per-user data.
+func isUserDataSort(ctx context.Context, sort string) bool {
+	if _, ok := models.UserIDFromContext(ctx); !ok {
+		return false
+	}
+
+	switch sort {
+	case "rating", "o_counter", "play_count", "play_duration", "resume_time", "last_played_at", "last_o_at":
+		return true
+	}
+
+	return false
+}
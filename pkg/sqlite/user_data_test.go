@@ -0,0 +1,343 @@
+//go:build integration
+// +build integration
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func createUserDataTestUser(ctx context.Context, t *testing.T, username string) context.Context {
+	t.Helper()
+
+	now := time.Now()
+	u := &models.User{
+		Username:     username,
+		PasswordHash: "hash",
+		Role:         models.UserRoleViewer,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		IsActive:     true,
+	}
+	if err := db.User.Create(ctx, u); err != nil {
+		t.Fatalf("UserStore.Create() error = %v", err)
+	}
+
+	return models.WithUserID(ctx, u.ID)
+}
+
+func TestUserData_SceneActivity(t *testing.T) {
+	withRollbackTxn(func(ctx context.Context) error {
+		assert := assert.New(t)
+		qb := db.Scene
+
+		sceneIdx := sceneIdx1WithPerformer
+		id := sceneIDs[sceneIdx]
+		userCtx := createUserDataTestUser(ctx, t, "activity")
+
+		resumeTime := 12.5
+		playDuration := 30.0
+		if _, err := qb.SaveActivity(userCtx, id, &resumeTime, &playDuration); err != nil {
+			t.Errorf("SceneStore.SaveActivity() error = %v", err)
+			return nil
+		}
+		if _, err := qb.SaveActivity(userCtx, id, nil, &playDuration); err != nil {
+			t.Errorf("SceneStore.SaveActivity() error = %v", err)
+			return nil
+		}
+
+		// library-wide values are untouched
+		scene, err := qb.Find(ctx, id)
+		if err != nil {
+			t.Errorf("SceneStore.Find() error = %v", err)
+			return nil
+		}
+		assert.Equal(getSceneResumeTime(sceneIdx), scene.ResumeTime)
+		assert.Equal(getScenePlayDuration(sceneIdx), scene.PlayDuration)
+
+		data, err := qb.GetManyUserData(userCtx, []int{id, sceneIDs[sceneIdxWithGallery]})
+		if err != nil {
+			t.Errorf("SceneStore.GetManyUserData() error = %v", err)
+			return nil
+		}
+		if assert.Len(data, 2) && assert.NotNil(data[0]) {
+			assert.Equal(resumeTime, data[0].ResumeTime)
+			assert.Equal(playDuration*2, data[0].PlayDuration)
+		}
+		assert.Nil(data[1])
+
+		// unscoped reads return no user data
+		data, err = qb.GetManyUserData(ctx, []int{id})
+		if err != nil {
+			t.Errorf("SceneStore.GetManyUserData() error = %v", err)
+			return nil
+		}
+		assert.Equal([]*models.UserData{nil}, data)
+
+		if _, err := qb.ResetActivity(userCtx, id, true, false); err != nil {
+			t.Errorf("SceneStore.ResetActivity() error = %v", err)
+			return nil
+		}
+		data, err = qb.GetManyUserData(userCtx, []int{id})
+		if err != nil {
+			t.Errorf("SceneStore.GetManyUserData() error = %v", err)
+			return nil
+		}
+		assert.Equal(0.0, data[0].ResumeTime)
+		assert.Equal(playDuration*2, data[0].PlayDuration)
+
+		return nil
+	})
+}
+
+func TestUserData_SceneRating(t *testing.T) {
+	withRollbackTxn(func(ctx context.Context) error {
+		assert := assert.New(t)
+		qb := db.Scene
+
+		sceneIdx := sceneIdxWithGallery
+		id := sceneIDs[sceneIdx]
+		userCtx := createUserDataTestUser(ctx, t, "rating")
+		otherCtx := createUserDataTestUser(ctx, t, "rating-other")
+
+		const rating = 37
+		if _, err := qb.UpdatePartial(userCtx, id, models.ScenePartial{
+			Rating: models.NewOptionalInt(rating),
+		}); err != nil {
+			t.Errorf("SceneStore.UpdatePartial() error = %v", err)
+			return nil
+		}
+
+		scene, err := qb.Find(ctx, id)
+		if err != nil {
+			t.Errorf("SceneStore.Find() error = %v", err)
+			return nil
+		}
+		assert.Equal(getIntPtr(getRating(sceneIdx)), scene.Rating)
+
+		filter := &models.SceneFilterType{
+			Rating100: &models.IntCriterionInput{
+				Value:    rating,
+				Modifier: models.CriterionModifierEquals,
+			},
+		}
+
+		scenes := queryScene(userCtx, t, qb, filter, nil)
+		if assert.Len(scenes, 1) {
+			assert.Equal(id, scenes[0].ID)
+		}
+
+		scenes = queryScene(otherCtx, t, qb, filter, nil)
+		assert.Len(scenes, 0)
+
+		return nil
+	})
+}
+
+func TestUserData_SceneViews(t *testing.T) {
+	withRollbackTxn(func(ctx context.Context) error {
+		assert := assert.New(t)
+		qb := db.Scene
+
+		id := sceneIDs[sceneIdxWithGallery]
+		userCtx := createUserDataTestUser(ctx, t, "views")
+		otherCtx := createUserDataTestUser(ctx, t, "views-other")
+
+		before, err := qb.CountViews(ctx, id)
+		if err != nil {
+			t.Errorf("SceneStore.CountViews() error = %v", err)
+			return nil
+		}
+
+		if _, err := qb.AddViews(userCtx, id, []time.Time{time.Now()}); err != nil {
+			t.Errorf("SceneStore.AddViews() error = %v", err)
+			return nil
+		}
+
+		count, err := qb.CountViews(userCtx, id)
+		if err != nil {
+			t.Errorf("SceneStore.CountViews() error = %v", err)
+			return nil
+		}
+		assert.Equal(1, count)
+
+		count, err = qb.CountViews(otherCtx, id)
+		if err != nil {
+			t.Errorf("SceneStore.CountViews() error = %v", err)
+			return nil
+		}
+		assert.Equal(0, count)
+
+		// library-wide totals include every user's views
+		count, err = qb.CountViews(ctx, id)
+		if err != nil {
+			t.Errorf("SceneStore.CountViews() error = %v", err)
+			return nil
+		}
+		assert.Equal(before+1, count)
+
+		return nil
+	})
+}
+
+func TestUserData_ImageOCounter(t *testing.T) {
+	withRollbackTxn(func(ctx context.Context) error {
+		assert := assert.New(t)
+		qb := db.Image
+
+		id := imageIDs[imageIdxWithGallery]
+		userCtx := createUserDataTestUser(ctx, t, "ocounter")
+
+		image, err := qb.Find(ctx, id)
+		if err != nil {
+			t.Errorf("ImageStore.Find() error = %v", err)
+			return nil
+		}
+		before := image.OCounter
+
+		for i := 0; i < 2; i++ {
+			if _, err := qb.IncrementOCounter(userCtx, id); err != nil {
+				t.Errorf("ImageStore.IncrementOCounter() error = %v", err)
+				return nil
+			}
+		}
+
+		count, err := qb.DecrementOCounter(userCtx, id)
+		if err != nil {
+			t.Errorf("ImageStore.DecrementOCounter() error = %v", err)
+			return nil
+		}
+		assert.Equal(1, count)
+
+		image, err = qb.Find(ctx, id)
+		if err != nil {
+			t.Errorf("ImageStore.Find() error = %v", err)
+			return nil
+		}
+		assert.Equal(before, image.OCounter)
+
+		count, err = qb.ResetOCounter(userCtx, id)
+		if err != nil {
+			t.Errorf("ImageStore.ResetOCounter() error = %v", err)
+			return nil
+		}
+		assert.Equal(0, count)
+
+		return nil
+	})
+}
+
+func TestUserData_ContentProfile(t *testing.T) {
+	withRollbackTxn(func(ctx context.Context) error {
+		assert := assert.New(t)
+		qb := db.ContentProfile
+
+		userCtx := createUserDataTestUser(ctx, t, "profile")
+		otherCtx := createUserDataTestUser(ctx, t, "profile-other")
+
+		profile, err := qb.GetOrCreateUserProfile(userCtx)
+		if err != nil {
+			t.Errorf("ContentProfileStore.GetOrCreateUserProfile() error = %v", err)
+			return nil
+		}
+		if assert.NotNil(profile.UserID) {
+			userID, _ := models.UserIDFromContext(userCtx)
+			assert.Equal(userID, *profile.UserID)
+		}
+
+		found, err := qb.FindUserProfile(userCtx)
+		if err != nil {
+			t.Errorf("ContentProfileStore.FindUserProfile() error = %v", err)
+			return nil
+		}
+		if assert.NotNil(found) {
+			assert.Equal(profile.ID, found.ID)
+		}
+
+		found, err = qb.FindUserProfile(otherCtx)
+		if err != nil {
+			t.Errorf("ContentProfileStore.FindUserProfile() error = %v", err)
+			return nil
+		}
+		assert.Nil(found)
+
+		other, err := qb.GetOrCreateUserProfile(otherCtx)
+		if err != nil {
+			t.Errorf("ContentProfileStore.GetOrCreateUserProfile() error = %v", err)
+			return nil
+		}
+		assert.NotEqual(profile.ID, other.ID)
+
+		return nil
+	})
+}
+
+func TestUserData_RecommendationFeedback(t *testing.T) {
+	withRollbackTxn(func(ctx context.Context) error {
+		assert := assert.New(t)
+
+		userCtx := createUserDataTestUser(ctx, t, "feedback")
+		otherCtx := createUserDataTestUser(ctx, t, "feedback-other")
+
+		const (
+			entityType = "scene"
+			entityKey  = "local:1"
+		)
+
+		if err := db.DismissedRecommendation.Dismiss(userCtx, entityType, entityKey); err != nil {
+			t.Errorf("DismissedRecommendationStore.Dismiss() error = %v", err)
+			return nil
+		}
+		if err := db.LikedRecommendation.Like(userCtx, entityType, entityKey); err != nil {
+			t.Errorf("LikedRecommendationStore.Like() error = %v", err)
+			return nil
+		}
+
+		dismissed, err := db.DismissedRecommendation.ListDismissed(userCtx, entityType)
+		if err != nil {
+			t.Errorf("DismissedRecommendationStore.ListDismissed() error = %v", err)
+			return nil
+		}
+		assert.Contains(dismissed, entityKey)
+
+		dismissed, err = db.DismissedRecommendation.ListDismissed(otherCtx, entityType)
+		if err != nil {
+			t.Errorf("DismissedRecommendationStore.ListDismissed() error = %v", err)
+			return nil
+		}
+		assert.NotContains(dismissed, entityKey)
+
+		liked, err := db.LikedRecommendation.IsLiked(userCtx, entityType, entityKey)
+		if err != nil {
+			t.Errorf("LikedRecommendationStore.IsLiked() error = %v", err)
+			return nil
+		}
+		assert.True(liked)
+
+		liked, err = db.LikedRecommendation.IsLiked(otherCtx, entityType, entityKey)
+		if err != nil {
+			t.Errorf("LikedRecommendationStore.IsLiked() error = %v", err)
+			return nil
+		}
+		assert.False(liked)
+
+		// undismissing for another user leaves the dismissal in place
+		if err := db.DismissedRecommendation.Undismiss(otherCtx, entityType, entityKey); err != nil {
+			t.Errorf("DismissedRecommendationStore.Undismiss() error = %v", err)
+			return nil
+		}
+		dismissed, err = db.DismissedRecommendation.ListDismissed(userCtx, entityType)
+		if err != nil {
+			t.Errorf("DismissedRecommendationStore.ListDismissed() error = %v", err)
+			return nil
+		}
+		assert.Contains(dismissed, entityKey)
+
+		return nil
+	})
+}
@@ -0,0 +1,237 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const (
+	visualEmbeddingTable       = "scene_visual_embeddings"
+	visualEmbeddingModelColumn = "model"
+)
+
+type visualEmbeddingRow struct {
+	SceneID    int          `db:"scene_id"`
+	Model      string       `db:"model"`
+	Dimensions int          `db:"dimensions"`
+	Frames     []byte       `db:"frames"`
+	Mean       []byte       `db:"mean"`
+	UpdatedAt  UTCTimestamp `db:"updated_at"`
+}
+
+func (r *visualEmbeddingRow) fromVisualEmbedding(e *models.VisualEmbedding) {
+	r.SceneID = e.SceneID
+	r.Model = e.Model
+	r.Dimensions = len(e.Mean)
+
+	var frames []float32
+	for _, f := range e.Frames {
+		frames = append(frames, f...)
+	}
+	r.Frames = float32SliceToBlob(frames)
+	r.Mean = float32SliceToBlob(e.Mean)
+	r.UpdatedAt = UTCTimestamp{Timestamp{e.UpdatedAt}}
+}
+
+func (r *visualEmbeddingRow) resolve() *models.VisualEmbedding {
+	ret := &models.VisualEmbedding{
+		SceneID:   r.SceneID,
+		Model:     r.Model,
+		Mean:      blobToFloat32Slice(r.Mean),
+		UpdatedAt: r.UpdatedAt.Timestamp.Timestamp,
+	}
+
+	frames := blobToFloat32Slice(r.Frames)
+	for dim := r.Dimensions; dim > 0 && len(frames) >= dim; frames = frames[dim:] {
+		ret.Frames = append(ret.Frames, frames[:dim])
+	}
+
+	return ret
+}
+
+// VisualEmbeddingStore provides methods for the visual embeddings of scenes,
+// which are generated by the visual embeddings task and used to find
+// visually similar scenes.
+type VisualEmbeddingStore struct{}
+
+// NewVisualEmbeddingStore creates a new VisualEmbeddingStore
+func NewVisualEmbeddingStore() *VisualEmbeddingStore {
+	return &VisualEmbeddingStore{}
+}
+
+func (qb *VisualEmbeddingStore) table() exp.IdentifierExpression {
+	return goqu.T(visualEmbeddingTable)
+}
+
+func (qb *VisualEmbeddingStore) getMany(ctx context.Context, q *goqu.SelectDataset) ([]*models.VisualEmbedding, error) {
+	var ret []*models.VisualEmbedding
+	const single = false
+	if err := queryFunc(ctx, q, single, func(r *sqlx.Rows) error {
+		var row visualEmbeddingRow
+		if err := r.StructScan(&row); err != nil {
+			return err
+		}
+		ret = append(ret, row.resolve())
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (qb *VisualEmbeddingStore) Find(ctx context.Context, sceneID int, model string) (*models.VisualEmbedding, error) {
+	table := qb.table()
+	q := dialect.From(table).Select(table.All()).Where(
+		table.Col(sceneIDColumn).Eq(sceneID),
+		table.Col(visualEmbeddingModelColumn).Eq(model),
+	)
+
+	ret, err := qb.getMany(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("getting visual embedding of scene %d: %w", sceneID, err)
+	}
+	if len(ret) == 0 {
+		return nil, nil
+	}
+
+	return ret[0], nil
+}
+
+func (qb *VisualEmbeddingStore) FindMany(ctx context.Context, sceneIDs []int, model string) ([]*models.VisualEmbedding, error) {
+	table := qb.table()
+
+	var ret []*models.VisualEmbedding
+	if err := batchExec(sceneIDs, defaultBatchSize, func(batch []int) error {
+		q := dialect.From(table).Select(table.All()).Where(
+			table.Col(sceneIDColumn).In(batch),
+			table.Col(visualEmbeddingModelColumn).Eq(model),
+		)
+
+		found, err := qb.getMany(ctx, q)
+		if err != nil {
+			return err
+		}
+		ret = append(ret, found...)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("getting visual embeddings: %w", err)
+	}
+
+	return ret, nil
+}
+
+func (qb *VisualEmbeddingStore) Means(ctx context.Context, model string, since time.Time) (map[int][]float32, error) {
+	table := qb.table()
+	q := dialect.From(table).Select(table.Col(sceneIDColumn), table.Col("mean")).Where(
+		table.Col(visualEmbeddingModelColumn).Eq(model),
+		table.Col("updated_at").Gte(UTCTimestamp{Timestamp{since}}),
+	)
+
+	ret := make(map[int][]float32)
+	const single = false
+	if err := queryFunc(ctx, q, single, func(r *sqlx.Rows) error {
+		var id int
+		var mean []byte
+		if err := r.Scan(&id, &mean); err != nil {
+			return err
+		}
+		ret[id] = blobToFloat32Slice(mean)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("getting visual embedding means: %w", err)
+	}
+
+	return ret, nil
+}
+
+func (qb *VisualEmbeddingStore) Count(ctx context.Context, model string) (int, error) {
+	table := qb.table()
+	q := dialect.From(table).Select(goqu.COUNT("*")).Where(table.Col(visualEmbeddingModelColumn).Eq(model))
+
+	var ret int
+	if err := querySimple(ctx, q, &ret); err != nil {
+		return 0, fmt.Errorf("counting visual embeddings: %w", err)
+	}
+
+	return ret, nil
+}
+
+func (qb *VisualEmbeddingStore) SceneIDs(ctx context.Context, model string) ([]int, error) {
+	table := qb.table()
+	q := dialect.From(table).Select(table.Col(sceneIDColumn)).Where(table.Col(visualEmbeddingModelColumn).Eq(model))
+
+	var ret []int
+	const single = false
+	if err := queryFunc(ctx, q, single, func(r *sqlx.Rows) error {
+		var id int
+		if err := r.Scan(&id); err != nil {
+			return err
+		}
+		ret = append(ret, id)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("getting scenes with visual embeddings: %w", err)
+	}
+
+	return ret, nil
+}
+
+func (qb *VisualEmbeddingStore) Set(ctx context.Context, e *models.VisualEmbedding) error {
+	var row visualEmbeddingRow
+	row.fromVisualEmbedding(e)
+
+	q := dialect.Insert(qb.table()).Prepared(true).Rows(row).OnConflict(goqu.DoUpdate(
+		fmt.Sprintf("%s, %s", sceneIDColumn, visualEmbeddingModelColumn),
+		goqu.Record{
+			"dimensions": row.Dimensions,
+			"frames":     row.Frames,
+			"mean":       row.Mean,
+			"updated_at": row.UpdatedAt,
+		},
+	))
+
+	if _, err := exec(ctx, q); err != nil {
+		return fmt.Errorf("setting visual embedding of scene %d: %w", e.SceneID, err)
+	}
+
+	return nil
+}
+
+func (qb *VisualEmbeddingStore) Delete(ctx context.Context, sceneID int) error {
+	table := qb.table()
+	if _, err := exec(ctx, dialect.Delete(table).Where(table.Col(sceneIDColumn).Eq(sceneID))); err != nil {
+		return fmt.Errorf("deleting visual embeddings of scene %d: %w", sceneID, err)
+	}
+
+	return nil
+}
+
+// float32SliceToBlob encodes a float32 slice as a little-endian byte slice.
+func float32SliceToBlob(data []float32) []byte {
+	buf := make([]byte, len(data)*4)
+	for i, v := range data {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(v))
+	}
+	return buf
+}
+
+// blobToFloat32Slice decodes a little-endian byte slice into a float32 slice.
+func blobToFloat32Slice(data []byte) []float32 {
+	n := len(data) / 4
+	result := make([]float32, n)
+	for i := range result {
+		bits := binary.LittleEndian.Uint32(data[i*4:])
+		result[i] = math.Float32frombits(bits)
+	}
+	return result
+}
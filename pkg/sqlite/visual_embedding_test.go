@@ -0,0 +1,116 @@
+//go:build integration
+// +build integration
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisualEmbeddingStore(t *testing.T) {
+	withRollbackTxn(func(ctx context.Context) error {
+		assert := assert.New(t)
+		qb := db.VisualEmbedding
+
+		const model = "test-model"
+		scene1 := sceneIDs[sceneIdxWithGroup]
+		scene2 := sceneIDs[sceneIdxWithGallery]
+		before := time.Now().Add(-time.Hour).Truncate(time.Second)
+		now := time.Now().Truncate(time.Second)
+
+		e1 := &models.VisualEmbedding{
+			SceneID:   scene1,
+			Model:     model,
+			Frames:    [][]float32{{1, 0, 0}, {0, 1, 0}},
+			Mean:      []float32{0.7, 0.7, 0},
+			UpdatedAt: before,
+		}
+		e2 := &models.VisualEmbedding{
+			SceneID:   scene2,
+			Model:     model,
+			Frames:    [][]float32{{0, 0, 1}},
+			Mean:      []float32{0, 0, 1},
+			UpdatedAt: now,
+		}
+		for _, e := range []*models.VisualEmbedding{e1, e2} {
+			if err := qb.Set(ctx, e); err != nil {
+				t.Errorf("VisualEmbeddingStore.Set() error = %v", err)
+				return nil
+			}
+		}
+
+		got, err := qb.Find(ctx, scene1, model)
+		if err != nil {
+			t.Errorf("VisualEmbeddingStore.Find() error = %v", err)
+			return nil
+		}
+		if assert.NotNil(got) {
+			assert.Equal(e1.Frames, got.Frames)
+			assert.Equal(e1.Mean, got.Mean)
+			assert.True(e1.UpdatedAt.Equal(got.UpdatedAt))
+		}
+
+		// other models are kept apart
+		got, err = qb.Find(ctx, scene1, "other-model")
+		if err != nil {
+			t.Errorf("VisualEmbeddingStore.Find() error = %v", err)
+			return nil
+		}
+		assert.Nil(got)
+
+		many, err := qb.FindMany(ctx, []int{scene1, scene2}, model)
+		if err != nil {
+			t.Errorf("VisualEmbeddingStore.FindMany() error = %v", err)
+			return nil
+		}
+		assert.Len(many, 2)
+
+		means, err := qb.Means(ctx, model, now)
+		if err != nil {
+			t.Errorf("VisualEmbeddingStore.Means() error = %v", err)
+			return nil
+		}
+		assert.Equal(map[int][]float32{scene2: e2.Mean}, means)
+
+		// replacing an embedding updates it
+		e1.Frames = [][]float32{{0, 1, 0}}
+		e1.Mean = []float32{0, 1, 0}
+		e1.UpdatedAt = now
+		if err := qb.Set(ctx, e1); err != nil {
+			t.Errorf("VisualEmbeddingStore.Set() error = %v", err)
+			return nil
+		}
+
+		count, err := qb.Count(ctx, model)
+		if err != nil {
+			t.Errorf("VisualEmbeddingStore.Count() error = %v", err)
+			return nil
+		}
+		assert.Equal(2, count)
+
+		means, err = qb.Means(ctx, model, now)
+		if err != nil {
+			t.Errorf("VisualEmbeddingStore.Means() error = %v", err)
+			return nil
+		}
+		assert.Len(means, 2)
+
+		if err := qb.Delete(ctx, scene1); err != nil {
+			t.Errorf("VisualEmbeddingStore.Delete() error = %v", err)
+			return nil
+		}
+		ids, err := qb.SceneIDs(ctx, model)
+		if err != nil {
+			t.Errorf("VisualEmbeddingStore.SceneIDs() error = %v", err)
+			return nil
+		}
+		assert.Equal([]int{scene2}, ids)
+
+		return nil
+	})
+}
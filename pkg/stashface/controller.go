@@ -16,6 +16,7 @@ import (
 	"sync"
 
 	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/python"
 )
 
 type Controller struct {
@@ -27,47 +28,12 @@ type Controller struct {
 }
 
 func NewController(pythonPath string, tempDir string) *Controller {
-	// Find the python script - check environment variable first (for Docker)
-	var scriptPath string
-
-	if envPath := os.Getenv("STASH_STASHFACE_SCRIPT"); envPath != "" {
-		if _, err := os.Stat(envPath); err == nil {
-			scriptPath = envPath
-			logger.Debugf("StashFace: Using script from env var: %s", scriptPath)
-		}
-	}
-
-	if scriptPath == "" {
-		wd, _ := os.Getwd()
-		// Get executable directory for native builds
-		exePath, _ := os.Executable()
-		exeDir := filepath.Dir(exePath)
-
-		searchPaths := []string{
-			// Native build: python-services folder next to executable
-			filepath.Join(exeDir, "python-services", "stashface", "client.py"),
-			// Development paths
-			filepath.Join(wd, "pkg", "stashface", "client.py"),
-			filepath.Join(wd, "..", "pkg", "stashface", "client.py"),
-			filepath.Join(wd, "..", "..", "pkg", "stashface", "client.py"),
-			// Docker path
-			"/usr/lib/stash/stashface/client.py",
-		}
-
-		for _, p := range searchPaths {
-			if _, err := os.Stat(p); err == nil {
-				scriptPath = p
-				break
-			}
-		}
-
-		if scriptPath == "" {
-			scriptPath = filepath.Join(wd, "pkg", "stashface", "client.py")
-			logger.Warnf("StashFace: client.py not found in search paths, defaulting to %s", scriptPath)
-		} else {
-			logger.Debugf("StashFace: Found client.py at %s", scriptPath)
-		}
-	}
+	scriptPath := python.ServiceScript{
+		Name:      "StashFace",
+		EnvVar:    "STASH_STASHFACE_SCRIPT",
+		Dir:       "stashface",
+		SourceDir: "pkg/stashface",
+	}.Find()
 
 	// Default to python3 if no Python path configured
 	if pythonPath == "" {
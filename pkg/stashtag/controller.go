@@ -7,9 +7,9 @@ import (
 	"io"
 	"os"
 	"os/exec"
-	"path/filepath"
 
 	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/python"
 )
 
 type Controller struct {
@@ -19,47 +19,12 @@ type Controller struct {
 }
 
 func NewController(pythonPath string, tempDir string) *Controller {
-	// Find the python script - check environment variable first (for Docker)
-	var scriptPath string
-
-	if envPath := os.Getenv("STASH_STASHTAG_SCRIPT"); envPath != "" {
-		if _, err := os.Stat(envPath); err == nil {
-			scriptPath = envPath
-			logger.Debugf("StashTag: Using script from env var: %s", scriptPath)
-		}
-	}
-
-	if scriptPath == "" {
-		wd, _ := os.Getwd()
-		// Get executable directory for native builds
-		exePath, _ := os.Executable()
-		exeDir := filepath.Dir(exePath)
-
-		searchPaths := []string{
-			// Native build: python-services folder next to executable
-			filepath.Join(exeDir, "python-services", "stashtag", "client.py"),
-			// Development paths
-			filepath.Join(wd, "pkg", "stashtag", "client.py"),
-			filepath.Join(wd, "..", "pkg", "stashtag", "client.py"),
-			filepath.Join(wd, "..", "..", "pkg", "stashtag", "client.py"),
-			// Docker path
-			"/usr/lib/stash/stashtag/client.py",
-		}
-
-		for _, p := range searchPaths {
-			if _, err := os.Stat(p); err == nil {
-				scriptPath = p
-				break
-			}
-		}
-
-		if scriptPath == "" {
-			scriptPath = filepath.Join(wd, "pkg", "stashtag", "client.py")
-			logger.Warnf("StashTag: client.py not found in search paths, defaulting to %s", scriptPath)
-		} else {
-			logger.Debugf("StashTag: Found client.py at %s", scriptPath)
-		}
-	}
+	scriptPath := python.ServiceScript{
+		Name:      "StashTag",
+		EnvVar:    "STASH_STASHTAG_SCRIPT",
+		Dir:       "stashtag",
+		SourceDir: "pkg/stashtag",
+	}.Find()
 
 	// Default to python3 if no Python path configured
 	if pythonPath == "" {
@@ -3,6 +3,8 @@ package utils
 import (
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
 )
 
 // from stdlib's time.go
@@ -35,3 +37,32 @@ func GetVTTTime(fracSeconds float64) string {
 	return fmt.Sprintf("%02d:%02d:%02d.%03d", hour, mnt, sec, msec)
 
 }
+
+// ParseVTTTime parses a VTT timestamp (hh:mm:ss.mmm or mm:ss.mmm) into
+// seconds. It is the inverse of GetVTTTime.
+func ParseVTTTime(s string) (float64, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid VTT timestamp %q", s)
+	}
+
+	var ret float64
+	for i, p := range parts {
+		last := i == len(parts)-1
+		var v float64
+		var err error
+		if last {
+			v, err = strconv.ParseFloat(p, 64)
+		} else {
+			var n int
+			n, err = strconv.Atoi(p)
+			v = float64(n)
+		}
+		if err != nil || v < 0 || (i > 0 && v >= 60) {
+			return 0, fmt.Errorf("invalid VTT timestamp %q", s)
+		}
+		ret = ret*60 + v
+	}
+
+	return ret, nil
+}
@@ -40,3 +40,20 @@ func TestInvalidTimestamp(t *testing.T) {
 		t.Errorf("TestInvalidTimestamp: GetVTTTime(-Inf) = %v; want %v", got, want)
 	}
 }
+
+func TestParseVTTTime(t *testing.T) {
+	for _, s := range []float64{0, 0.1, 61.5, 90061.1} {
+		got, err := ParseVTTTime(GetVTTTime(s))
+		if err != nil || math.Abs(got-s) > 0.001 {
+			t.Errorf("ParseVTTTime(GetVTTTime(%v)) = %v, %v; want %v", s, got, err, s)
+		}
+	}
+	if got, err := ParseVTTTime("01:30.250"); err != nil || got != 90.25 {
+		t.Errorf("ParseVTTTime(01:30.250) = %v, %v; want 90.25", got, err)
+	}
+	for _, s := range []string{"", "10", "00:61:00.000", "aa:00:00.000"} {
+		if _, err := ParseVTTTime(s); err == nil {
+			t.Errorf("ParseVTTTime(%q) succeeded", s)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package watchparty
+
+import (
+	"sync"
+	"time"
+)
+
+// clockSamples is the number of recent samples a clock estimates from.
+const clockSamples = 8
+
+type clockSample struct {
+	offset int64
+	rtt    int64
+}
+
+// Clock estimates the offset of a member's clock from the server's, NTP
+// style. The server sends its time, the member answers with the server time
+// and its own, and the sample with the shortest round trip of the recent
+// ones wins, since its answer is the least delayed.
+type Clock struct {
+	mu      sync.Mutex
+	samples []clockSample
+	next    int
+}
+
+// Add records a round trip that the server sent at sent and got the
+// member's answer to at received. clientMs is the member's time in unix ms
+// when it answered.
+func (c *Clock) Add(sent time.Time, received time.Time, clientMs int64) {
+	if received.Before(sent) {
+		return
+	}
+
+	sentMs := sent.UnixMilli()
+	receivedMs := received.UnixMilli()
+	s := clockSample{
+		rtt:    receivedMs - sentMs,
+		offset: clientMs - (sentMs+receivedMs)/2,
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.samples) < clockSamples {
+		c.samples = append(c.samples, s)
+		return
+	}
+	c.samples[c.next] = s
+	c.next = (c.next + 1) % clockSamples
+}
+
+// Offset returns how far the member's clock is ahead of the server's in ms,
+// and the round trip time of the estimate. ok is false if there are no
+// samples yet.
+func (c *Clock) Offset() (offset int64, rtt int64, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, s := range c.samples {
+		if i == 0 || s.rtt < rtt {
+			offset, rtt = s.offset, s.rtt
+		}
+	}
+	return offset, rtt, len(c.samples) > 0
+}
+
+// ToServer converts a time of the member, in unix ms, to server time.
+func (c *Clock) ToServer(clientMs int64) time.Time {
+	offset, _, _ := c.Offset()
+	return time.UnixMilli(clientMs - offset)
+}
@@ -0,0 +1,140 @@
+package watchparty
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	maxRoomIDLength = 64
+	memberBuffer    = 16
+)
+
+// Manager is the process-wide owner of the rooms. A room exists while it has
+// members.
+type Manager struct {
+	mu    sync.Mutex
+	rooms map[string]*Room
+	// followed is the room the local device follows. There is only one
+	// device, so only one room at a time can drive it.
+	followed *Room
+}
+
+var defaultManager = NewManager()
+
+// NewManager returns a manager without rooms.
+func NewManager() *Manager {
+	return &Manager{
+		rooms: make(map[string]*Room),
+	}
+}
+
+// GetManager returns the process-wide manager.
+func GetManager() *Manager {
+	return defaultManager
+}
+
+func randomID(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Join adds a member named name to the room with roomID, creating the room
+// if needed. A new room with a random ID is created if roomID is empty.
+// controlsDevice is true if the member may control the device, see
+// Member.ControlsDevice.
+func (m *Manager) Join(roomID string, name string, controlsDevice bool) (*Room, *Member, error) {
+	roomID = strings.TrimSpace(roomID)
+	if roomID == "" {
+		roomID = randomID(6)
+	}
+	if utf8.RuneCountInString(roomID) > maxRoomIDLength {
+		return nil, nil, fmt.Errorf("room id must be at most %d characters", maxRoomIDLength)
+	}
+
+	member := &Member{
+		ID:             randomID(8),
+		Name:           strings.TrimSpace(name),
+		ControlsDevice: controlsDevice,
+		updates:        make(chan Update, memberBuffer),
+	}
+	if member.Name == "" {
+		member.Name = "guest-" + member.ID[:4]
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	room := m.rooms[roomID]
+	if room == nil {
+		room = newRoom(roomID)
+		m.rooms[roomID] = room
+	}
+	room.addMember(member)
+
+	return room, member, nil
+}
+
+// Leave removes member from room. The room is removed once it is empty.
+func (m *Manager) Leave(ctx context.Context, room *Room, member *Member) {
+	m.Unfollow(ctx, room, member)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if room.removeMember(member) == 0 && m.rooms[room.ID] == room {
+		delete(m.rooms, room.ID)
+	}
+}
+
+// Room returns the room with id, or nil if it does not exist.
+func (m *Manager) Room(id string) *Room {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rooms[id]
+}
+
+// Follow makes the device f of member follow room.
+func (m *Manager) Follow(ctx context.Context, room *Room, member *Member, f Follower) error {
+	if !member.ControlsDevice {
+		return errNoDeviceControl
+	}
+
+	m.mu.Lock()
+	if m.followed != nil && m.followed != room {
+		id := m.followed.ID
+		m.mu.Unlock()
+		return fmt.Errorf("the device already follows room %q", id)
+	}
+	m.followed = room
+	m.mu.Unlock()
+
+	if err := room.setFollower(ctx, member, f); err != nil {
+		m.mu.Lock()
+		if m.followed == room && room.followerID() == "" {
+			m.followed = nil
+		}
+		m.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Unfollow stops the device of member following room.
+func (m *Manager) Unfollow(ctx context.Context, room *Room, member *Member) {
+	if !room.clearFollower(ctx, member) {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.followed == room {
+		m.followed = nil
+	}
+}
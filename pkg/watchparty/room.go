@@ -0,0 +1,367 @@
+// Package watchparty keeps the shared playback state of rooms whose members
+// watch the same scene in sync.
+package watchparty
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+// maxRate is the fastest playback rate a room accepts.
+const maxRate = 16
+
+var (
+	errNoScene         = errors.New("no scene loaded")
+	errNoDeviceControl = errors.New("not allowed to control the device that follows the room")
+)
+
+// State is the shared timeline of a room. Position is sampled at At, so a
+// member projects the current position from its own clock rather than from
+// when the message happened to arrive.
+type State struct {
+	SceneID int `json:"sceneId"`
+	// Position is the playback position in ms at At
+	Position int64   `json:"position"`
+	Playing  bool    `json:"playing"`
+	Rate     float64 `json:"rate"`
+	// At is the server time in unix ms the position was sampled at
+	At int64 `json:"at"`
+	// Version is incremented on every change, so that members can drop
+	// updates that arrive out of order.
+	Version int64 `json:"version"`
+}
+
+// PositionAt returns the position in ms at t.
+func (s State) PositionAt(t time.Time) int64 {
+	if !s.Playing {
+		return s.Position
+	}
+
+	ret := s.Position + int64(float64(t.UnixMilli()-s.At)*s.Rate)
+	if ret < 0 {
+		return 0
+	}
+	return ret
+}
+
+// Follower is a device that plays along with a room, such as a Handy.
+type Follower interface {
+	// Follow moves the device from prev to next. A next without a scene
+	// means that the device no longer follows the room.
+	Follow(ctx context.Context, prev State, next State) error
+}
+
+// MemberInfo describes a member to the other members of a room.
+type MemberInfo struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Follower is set if the member's device follows the room
+	Follower bool `json:"follower,omitempty"`
+}
+
+// Update is a snapshot of a room sent to its members after a change.
+type Update struct {
+	State   State        `json:"state"`
+	Members []MemberInfo `json:"members"`
+	// By is the name of the member that changed the state. It is empty if
+	// only the members changed.
+	By string `json:"by,omitempty"`
+}
+
+// Member is a client joined to a room.
+type Member struct {
+	ID   string
+	Name string
+	// ControlsDevice is true if the member may control the device. Only such
+	// a member can make the device follow a room, or change a room that the
+	// device follows.
+	ControlsDevice bool
+
+	updates chan Update
+}
+
+// Updates returns the channel the room's snapshots are sent to. Slow
+// members miss intermediate snapshots, but always get the latest.
+func (m *Member) Updates() <-chan Update {
+	return m.updates
+}
+
+func (m *Member) send(u Update) {
+	for {
+		select {
+		case m.updates <- u:
+			return
+		default:
+		}
+
+		// drop the oldest snapshot to make room for the newest
+		select {
+		case <-m.updates:
+		default:
+		}
+	}
+}
+
+// Room is a set of members sharing a timeline.
+type Room struct {
+	ID string
+
+	// followMu serializes changes, so that the follower sees them in order.
+	// It is held across calls to the follower, which may be slow.
+	followMu sync.Mutex
+
+	// mu guards the fields below it and is only held for short sections.
+	mu       sync.Mutex
+	state    State
+	members  []*Member
+	follower Follower
+	// followerMember is the ID of the member whose device follows the room
+	followerMember string
+}
+
+func newRoom(id string) *Room {
+	return &Room{
+		ID: id,
+		state: State{
+			Rate: 1,
+			At:   time.Now().UnixMilli(),
+		},
+	}
+}
+
+// State returns the current state of the room.
+func (r *Room) State() State {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state
+}
+
+// Members returns the members of the room in order of joining.
+func (r *Room) Members() []MemberInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.membersLocked()
+}
+
+func (r *Room) membersLocked() []MemberInfo {
+	ret := make([]MemberInfo, len(r.members))
+	for i, m := range r.members {
+		ret[i] = MemberInfo{
+			ID:       m.ID,
+			Name:     m.Name,
+			Follower: m.ID == r.followerMember,
+		}
+	}
+	return ret
+}
+
+func (r *Room) broadcastLocked(by string) {
+	u := Update{
+		State:   r.state,
+		Members: r.membersLocked(),
+		By:      by,
+	}
+	for _, m := range r.members {
+		m.send(u)
+	}
+}
+
+func (r *Room) addMember(m *Member) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.members = append(r.members, m)
+	r.broadcastLocked("")
+}
+
+// removeMember removes m and returns the number of members left.
+func (r *Room) removeMember(m *Member) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, o := range r.members {
+		if o == m {
+			r.members = append(r.members[:i], r.members[i+1:]...)
+			break
+		}
+	}
+	r.broadcastLocked("")
+	return len(r.members)
+}
+
+// change applies fn to the state and sends the result to the members and
+// the follower.
+func (r *Room) change(ctx context.Context, by *Member, fn func(s *State) error) (State, error) {
+	r.followMu.Lock()
+	defer r.followMu.Unlock()
+
+	r.mu.Lock()
+	prev := r.state
+	if r.follower != nil && !by.ControlsDevice {
+		r.mu.Unlock()
+		return prev, errNoDeviceControl
+	}
+	next := prev
+	if err := fn(&next); err != nil {
+		r.mu.Unlock()
+		return prev, err
+	}
+	next.Version++
+	r.state = next
+	f := r.follower
+	r.broadcastLocked(by.Name)
+	r.mu.Unlock()
+
+	// the room has moved on regardless, so a device that cannot follow
+	// does not fail the change for everyone
+	if f != nil {
+		if err := f.Follow(ctx, prev, next); err != nil {
+			logger.Warnf("[watch-party] room %s: follower: %v", r.ID, err)
+		}
+	}
+
+	return next, nil
+}
+
+func validatePosition(pos int64) error {
+	if pos < 0 {
+		return fmt.Errorf("position must not be negative, got %d", pos)
+	}
+	return nil
+}
+
+// Load changes the scene of the room. Playback starts paused at the
+// beginning of the scene.
+func (r *Room) Load(ctx context.Context, by *Member, sceneID int, at time.Time) (State, error) {
+	return r.change(ctx, by, func(s *State) error {
+		if sceneID <= 0 {
+			return fmt.Errorf("invalid scene id %d", sceneID)
+		}
+		s.SceneID = sceneID
+		s.Position = 0
+		s.Playing = false
+		s.At = at.UnixMilli()
+		return nil
+	})
+}
+
+// Play starts playback from pos, the position at at.
+func (r *Room) Play(ctx context.Context, by *Member, pos int64, at time.Time) (State, error) {
+	return r.change(ctx, by, func(s *State) error {
+		if s.SceneID == 0 {
+			return errNoScene
+		}
+		if err := validatePosition(pos); err != nil {
+			return err
+		}
+		s.Position = pos
+		s.Playing = true
+		s.At = at.UnixMilli()
+		return nil
+	})
+}
+
+// Pause stops playback at pos, the position at at.
+func (r *Room) Pause(ctx context.Context, by *Member, pos int64, at time.Time) (State, error) {
+	return r.change(ctx, by, func(s *State) error {
+		if s.SceneID == 0 {
+			return errNoScene
+		}
+		if err := validatePosition(pos); err != nil {
+			return err
+		}
+		s.Position = pos
+		s.Playing = false
+		s.At = at.UnixMilli()
+		return nil
+	})
+}
+
+// Seek moves playback to pos, the position at at, without changing
+// whether the room is playing.
+func (r *Room) Seek(ctx context.Context, by *Member, pos int64, at time.Time) (State, error) {
+	return r.change(ctx, by, func(s *State) error {
+		if s.SceneID == 0 {
+			return errNoScene
+		}
+		if err := validatePosition(pos); err != nil {
+			return err
+		}
+		s.Position = pos
+		s.At = at.UnixMilli()
+		return nil
+	})
+}
+
+// SetRate changes the playback rate from at onwards.
+func (r *Room) SetRate(ctx context.Context, by *Member, rate float64, at time.Time) (State, error) {
+	return r.change(ctx, by, func(s *State) error {
+		if rate <= 0 || rate > maxRate {
+			return fmt.Errorf("rate must be greater than 0 and at most %d, got %g", maxRate, rate)
+		}
+		// rebase the timeline, so that the position up to at is kept
+		s.Position = s.PositionAt(at)
+		s.At = at.UnixMilli()
+		s.Rate = rate
+		return nil
+	})
+}
+
+// followerID returns the ID of the member whose device follows the room, or
+// empty if there is none.
+func (r *Room) followerID() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.followerMember
+}
+
+func (r *Room) setFollower(ctx context.Context, m *Member, f Follower) error {
+	r.followMu.Lock()
+	defer r.followMu.Unlock()
+
+	r.mu.Lock()
+	if r.follower != nil && r.followerMember != m.ID {
+		r.mu.Unlock()
+		return errors.New("another member's device already follows the room")
+	}
+	r.follower = f
+	r.followerMember = m.ID
+	cur := r.state
+	r.broadcastLocked("")
+	r.mu.Unlock()
+
+	// catch the device up with the room
+	if cur.SceneID != 0 {
+		if err := f.Follow(ctx, State{Rate: 1}, cur); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clearFollower stops the device of m following the room. It returns false
+// if the device of m was not following it.
+func (r *Room) clearFollower(ctx context.Context, m *Member) bool {
+	r.followMu.Lock()
+	defer r.followMu.Unlock()
+
+	r.mu.Lock()
+	if r.follower == nil || r.followerMember != m.ID {
+		r.mu.Unlock()
+		return false
+	}
+	f := r.follower
+	cur := r.state
+	r.follower = nil
+	r.followerMember = ""
+	r.broadcastLocked("")
+	r.mu.Unlock()
+
+	if err := f.Follow(ctx, cur, State{Rate: 1}); err != nil {
+		logger.Warnf("[watch-party] room %s: stopping follower: %v", r.ID, err)
+	}
+	return true
+}
@@ -0,0 +1,186 @@
+package watchparty
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type recordingFollower struct {
+	calls []State
+}
+
+func (f *recordingFollower) Follow(ctx context.Context, prev State, next State) error {
+	f.calls = append(f.calls, next)
+	return nil
+}
+
+func TestStatePositionAt(t *testing.T) {
+	at := time.UnixMilli(1_000_000)
+	tests := []struct {
+		name  string
+		state State
+		t     time.Time
+		want  int64
+	}{
+		{"paused", State{Position: 5000, Rate: 1, At: at.UnixMilli()}, at.Add(time.Second), 5000},
+		{"playing", State{Position: 5000, Playing: true, Rate: 1, At: at.UnixMilli()}, at.Add(time.Second), 6000},
+		{"double rate", State{Position: 5000, Playing: true, Rate: 2, At: at.UnixMilli()}, at.Add(time.Second), 7000},
+		{"before start", State{Position: 500, Playing: true, Rate: 1, At: at.UnixMilli()}, at.Add(-time.Second), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.state.PositionAt(tt.t); got != tt.want {
+				t.Errorf("PositionAt() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoom(t *testing.T) {
+	ctx := context.Background()
+	mgr := NewManager()
+
+	room, alice, err := mgr.Join("movie-night", "alice", true)
+	if err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	again, bob, err := mgr.Join("movie-night", "bob", true)
+	if err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	if again != room {
+		t.Fatalf("Join() returned a different room for the same id")
+	}
+
+	at := time.UnixMilli(1_000_000)
+	if _, err := room.Play(ctx, alice, 0, at); err == nil {
+		t.Errorf("Play() without a scene succeeded")
+	}
+
+	if _, err := room.Load(ctx, alice, 12, at); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if _, err := room.Play(ctx, alice, 1000, at); err != nil {
+		t.Fatalf("Play() error = %v", err)
+	}
+	s, err := room.SetRate(ctx, bob, 2, at.Add(time.Second))
+	if err != nil {
+		t.Fatalf("SetRate() error = %v", err)
+	}
+	if s.Position != 2000 || s.At != at.Add(time.Second).UnixMilli() {
+		t.Errorf("SetRate() did not rebase the timeline: %+v", s)
+	}
+	if got := s.PositionAt(at.Add(2 * time.Second)); got != 4000 {
+		t.Errorf("PositionAt() after SetRate = %d, want 4000", got)
+	}
+	if _, err := room.SetRate(ctx, bob, 0, at); err == nil {
+		t.Errorf("SetRate(0) succeeded")
+	}
+	if _, err := room.Seek(ctx, bob, -1, at); err == nil {
+		t.Errorf("Seek(-1) succeeded")
+	}
+
+	// bob gets every change, latest last, made by the member who made it
+	var last Update
+	for len(bob.Updates()) > 0 {
+		last = <-bob.Updates()
+	}
+	if last.State != s || last.By != "bob" || len(last.Members) != 2 {
+		t.Errorf("last update = %+v, want state %+v by bob with 2 members", last, s)
+	}
+
+	mgr.Leave(ctx, room, alice)
+	if mgr.Room("movie-night") == nil {
+		t.Errorf("room removed while it has members")
+	}
+	mgr.Leave(ctx, room, bob)
+	if mgr.Room("movie-night") != nil {
+		t.Errorf("empty room not removed")
+	}
+}
+
+func TestFollower(t *testing.T) {
+	ctx := context.Background()
+	mgr := NewManager()
+
+	room, alice, _ := mgr.Join("a", "alice", true)
+	_, bob, _ := mgr.Join("a", "bob", true)
+	other, carol, _ := mgr.Join("b", "carol", true)
+	_, dave, _ := mgr.Join("a", "dave", false)
+
+	at := time.UnixMilli(1_000_000)
+	if _, err := room.Load(ctx, alice, 12, at); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	f := &recordingFollower{}
+	if err := mgr.Follow(ctx, room, alice, f); err != nil {
+		t.Fatalf("Follow() error = %v", err)
+	}
+	// the follower is caught up with the room straight away
+	if len(f.calls) != 1 || f.calls[0].SceneID != 12 {
+		t.Errorf("follower calls after Follow = %+v, want the current state", f.calls)
+	}
+
+	if err := mgr.Follow(ctx, room, bob, &recordingFollower{}); err == nil {
+		t.Errorf("Follow() by a second member succeeded")
+	}
+	if err := mgr.Follow(ctx, other, carol, &recordingFollower{}); err == nil {
+		t.Errorf("Follow() of a second room succeeded")
+	}
+
+	if _, err := room.Play(ctx, bob, 0, at); err != nil {
+		t.Fatalf("Play() error = %v", err)
+	}
+	if len(f.calls) != 2 || !f.calls[1].Playing {
+		t.Errorf("follower calls after Play = %+v, want a playing state", f.calls)
+	}
+
+	// a member that may not control the device cannot drive it through the
+	// room
+	if _, err := room.Pause(ctx, dave, 0, at); err == nil {
+		t.Errorf("Pause() by a member without device control succeeded")
+	}
+	if len(f.calls) != 2 || room.State().Playing != true {
+		t.Errorf("room changed by a member without device control: %+v", room.State())
+	}
+
+	// leaving stops the device following the room
+	mgr.Leave(ctx, room, alice)
+	if n := len(f.calls); n != 3 || f.calls[n-1].SceneID != 0 {
+		t.Errorf("follower calls after Leave = %+v, want a state without a scene", f.calls)
+	}
+	if _, err := room.Pause(ctx, dave, 0, at); err != nil {
+		t.Errorf("Pause() of a room without a follower error = %v", err)
+	}
+	if err := mgr.Follow(ctx, room, dave, &recordingFollower{}); err == nil {
+		t.Errorf("Follow() by a member without device control succeeded")
+	}
+	if err := mgr.Follow(ctx, other, carol, &recordingFollower{}); err != nil {
+		t.Errorf("Follow() after the device was released error = %v", err)
+	}
+}
+
+func TestClock(t *testing.T) {
+	var c Clock
+	if _, _, ok := c.Offset(); ok {
+		t.Errorf("Offset() of a new clock is ok")
+	}
+
+	sent := time.UnixMilli(1_000_000)
+	// client 500ms ahead, 100ms round trip
+	c.Add(sent, sent.Add(100*time.Millisecond), sent.UnixMilli()+50+500)
+	// a slow round trip with a skewed answer is ignored in favour of the
+	// faster one
+	c.Add(sent, sent.Add(900*time.Millisecond), sent.UnixMilli()+100+500)
+
+	offset, rtt, ok := c.Offset()
+	if !ok || offset != 500 || rtt != 100 {
+		t.Errorf("Offset() = %d, %d, %v, want 500, 100, true", offset, rtt, ok)
+	}
+
+	if got := c.ToServer(sent.UnixMilli() + 500); !got.Equal(sent) {
+		t.Errorf("ToServer() = %v, want %v", got, sent)
+	}
+}
@@ -226,7 +226,7 @@ if (!isDryRun) {
     const pythonServicesDir = path.join(distDir, 'python-services');
     
     // Create directory structure
-    const serviceDirs = ['stashface', 'stashtag', 'megaface'];
+    const serviceDirs = ['stashface', 'stashtag', 'megaface', 'embedding'];
     serviceDirs.forEach(service => {
         const serviceDir = path.join(pythonServicesDir, service);
         if (!fs.existsSync(serviceDir)) {
@@ -238,7 +238,8 @@ if (!isDryRun) {
     const pythonScripts = [
         { src: 'pkg/stashface/client.py', dest: 'python-services/stashface/client.py' },
         { src: 'pkg/stashtag/client.py', dest: 'python-services/stashtag/client.py' },
-        { src: 'pkg/megaface/client.py', dest: 'python-services/megaface/client.py' }
+        { src: 'pkg/megaface/client.py', dest: 'python-services/megaface/client.py' },
+        { src: 'pkg/recommendation/embedding/client.py', dest: 'python-services/embedding/client.py' }
     ];
     
     pythonScripts.forEach(({ src, dest }) => {
@@ -40,6 +40,7 @@ export const DiscoverPage: React.FC = () => {
     const [tagWeight, setTagWeight] = usePersistedWeight("rec_tagWeight", 0.5);
     const [performerWeight, setPerformerWeight] = usePersistedWeight("rec_performerWeight", 0.3);
     const [studioWeight, setStudioWeight] = usePersistedWeight("rec_studioWeight", 0.2);
+    const [coWatchWeight, setCoWatchWeight] = usePersistedWeight("rec_coWatchWeight", 0.2);
 
     // Cross-row deduplication: only track StashDB performer/scene IDs so the
     // same StashDB item doesn't appear in multiple rows.  Local rows are
@@ -162,6 +163,21 @@ export const DiscoverPage: React.FC = () => {
                                                 valueLabelDisplay="auto"
                                             />
                                         </Box>
+                                        <Box>
+                                            <Box display="flex" justifyContent="space-between">
+                                                <Typography variant="subtitle2">Viewers Also Watched</Typography>
+                                                <Typography variant="caption" color="text.secondary">{(coWatchWeight * 100).toFixed(0)}%</Typography>
+                                            </Box>
+                                            <Slider
+                                                value={coWatchWeight}
+                                                onChange={(_, v) => setCoWatchWeight(v as number)}
+                                                step={0.1}
+                                                min={0}
+                                                max={1}
+                                                marks
+                                                valueLabelDisplay="auto"
+                                            />
+                                        </Box>
                                     </Box>
                                 </CardContent>
                             </Card>
@@ -237,6 +253,7 @@ export const DiscoverPage: React.FC = () => {
                     tagWeight={tagWeight}
                     performerWeight={performerWeight}
                     studioWeight={studioWeight}
+                    coWatchWeight={coWatchWeight}
                 />
             </Box>
         </Box>
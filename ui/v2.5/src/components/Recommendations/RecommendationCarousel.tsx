@@ -10,6 +10,7 @@ import { AlertModal as Alert } from '../Shared/Alert';
 import { SceneCard } from '../Scenes/SceneCard';
 import Carousel from '../Shared/Carousel';
 import { RecommendationRow } from '../FrontPage/RecommendationRow';
+import { ScoreBreakdown } from './ScoreBreakdown';
 
 function scrapedToSlim(scraped: ScrapedSceneDataFragment, trailerUrl?: string): SlimSceneDataFragment {
     return {
@@ -69,6 +70,7 @@ interface RecommendationCarouselProps {
     tagWeight?: number;
     performerWeight?: number;
     studioWeight?: number;
+    coWatchWeight?: number;
     excludeIds?: string[];
     onShownIds?: (ids: string[]) => void;
 }
@@ -81,6 +83,7 @@ export const RecommendationCarousel: React.FC<RecommendationCarouselProps> = ({
     tagWeight,
     performerWeight,
     studioWeight,
+    coWatchWeight,
     excludeIds,
     onShownIds,
 }) => {
@@ -99,6 +102,7 @@ export const RecommendationCarousel: React.FC<RecommendationCarouselProps> = ({
                 tag_weight: tagWeight,
                 performer_weight: performerWeight,
                 studio_weight: studioWeight,
+                co_watch_weight: coWatchWeight,
                 exclude_ids: excludeIds,
             }
         }
@@ -191,7 +195,7 @@ export const RecommendationCarousel: React.FC<RecommendationCarouselProps> = ({
                                     {scorePct}% Match
                                 </Typography>
                                 {r.reason && (
-                                    <Tooltip title={r.reason} placement="top">
+                                    <Tooltip title={r.breakdown ? <ScoreBreakdown reason={r.reason} breakdown={r.breakdown} /> : r.reason} placement="top">
                                         <Typography variant="caption" sx={{ fontSize: '0.7rem', mt: '0.25rem', maxWidth: '100%', opacity: 0.7, overflow: 'hidden', textOverflow: 'ellipsis', whiteSpace: 'nowrap' }}>
                                             {r.reason}
                                         </Typography>
@@ -0,0 +1,58 @@
+import React from 'react';
+import { Box, Typography } from '@mui/material';
+import { RecommendationBreakdownDataFragment } from '../../core/generated-graphql';
+
+const factorLabels: Record<string, string> = {
+    tags: 'Tags',
+    performers: 'Performers',
+    studio: 'Studio',
+    co_watch: 'Viewers also watched',
+    metadata: 'Metadata',
+    phash: 'pHash',
+    visual: 'Visual',
+};
+
+function pct(value: number) {
+    return `${Math.round(value * 100)}%`;
+}
+
+interface ScoreBreakdownProps {
+    reason?: string | null;
+    breakdown: RecommendationBreakdownDataFragment;
+}
+
+// ScoreBreakdown lists the contribution of each factor to a recommendation
+// score, with the matching profile entries.
+export const ScoreBreakdown: React.FC<ScoreBreakdownProps> = ({ reason, breakdown }) => {
+    const matches = [
+        ...breakdown.matched_tags.map((m) => `${m.tag.name} (${m.weight.toFixed(2)})`),
+        ...breakdown.matched_performers.map((m) => `${m.performer.name} (${m.weight.toFixed(2)})`),
+        ...(breakdown.matched_studio ? [`${breakdown.matched_studio.studio.name} (${breakdown.matched_studio.weight.toFixed(2)})`] : []),
+    ];
+
+    return (
+        <Box sx={{ fontSize: '0.75rem' }}>
+            {reason && <Typography variant="caption" component="div" sx={{ mb: 0.5 }}>{reason}</Typography>}
+            {breakdown.factors.map((f) => (
+                <Box key={f.name} sx={{ display: 'flex', justifyContent: 'space-between', gap: 2 }}>
+                    <span>{factorLabels[f.name] ?? f.name}: {pct(f.score)} × {f.weight.toFixed(2)}</span>
+                    <span>+{pct(f.contribution)}</span>
+                </Box>
+            ))}
+            {breakdown.visual_similarity != null && (
+                <Box>Visual similarity: {pct(breakdown.visual_similarity)}</Box>
+            )}
+            {breakdown.watched_penalty > 0 && (
+                <Box sx={{ display: 'flex', justifyContent: 'space-between', gap: 2 }}>
+                    <span>Already watched</span>
+                    <span>−{pct(breakdown.watched_penalty)}</span>
+                </Box>
+            )}
+            {matches.length > 0 && (
+                <Typography variant="caption" component="div" sx={{ mt: 0.5, opacity: 0.8 }}>
+                    {matches.join(', ')}
+                </Typography>
+            )}
+        </Box>
+    );
+};
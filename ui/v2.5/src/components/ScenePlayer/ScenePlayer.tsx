@@ -811,7 +811,10 @@ export const ScenePlayer: React.FC<IScenePlayerProps> = PatchComponent(
         return (
           src.pathname.endsWith("/stream") ||
           src.pathname.endsWith("/stream.mpd") ||
-          src.pathname.endsWith("/stream.m3u8")
+          src.pathname.endsWith("/stream.m3u8") ||
+          src.pathname.endsWith("/stream_adaptive.mpd") ||
+          src.pathname.endsWith("/stream_adaptive.m3u8") ||
+          /\/stream\/optimized\/\d+$/.test(src.pathname)
         );
       }
 
@@ -0,0 +1,355 @@
+import React, { useState } from "react";
+import { FormattedMessage, useIntl } from "react-intl";
+import {
+  Alert,
+  Box,
+  Button,
+  Checkbox,
+  Chip,
+  Dialog,
+  DialogActions,
+  DialogContent,
+  DialogTitle,
+  FormControlLabel,
+  FormGroup,
+  IconButton,
+  Paper,
+  Table,
+  TableBody,
+  TableCell,
+  TableContainer,
+  TableHead,
+  TableRow,
+  TextField,
+  Tooltip,
+  Typography,
+} from "@mui/material";
+import { Add as AddIcon, Delete as DeleteIcon } from "@mui/icons-material";
+import * as GQL from "src/core/generated-graphql";
+import { useToast } from "src/hooks/Toast";
+import { SettingSection } from "./SettingSection";
+
+const scopeLabels: Record<GQL.ApiKeyScope, { id: string; defaultMessage: string }> = {
+  [GQL.ApiKeyScope.Full]: { id: "api_keys.scope.full", defaultMessage: "Full access" },
+  [GQL.ApiKeyScope.ReadOnly]: { id: "api_keys.scope.read_only", defaultMessage: "Read-only GraphQL" },
+  [GQL.ApiKeyScope.Stream]: { id: "api_keys.scope.stream", defaultMessage: "Streams and images" },
+  [GQL.ApiKeyScope.Iptv]: { id: "api_keys.scope.iptv", defaultMessage: "IPTV" },
+  [GQL.ApiKeyScope.Handy]: { id: "api_keys.scope.handy", defaultMessage: "Handy" },
+};
+
+const allScopes = Object.keys(scopeLabels) as GQL.ApiKeyScope[];
+
+interface APIKeyFormData {
+  name: string;
+  scopes: GQL.ApiKeyScope[];
+  expiresAt: string;
+}
+
+const defaultFormData: APIKeyFormData = {
+  name: "",
+  scopes: [GQL.ApiKeyScope.ReadOnly],
+  expiresAt: "",
+};
+
+interface APIKeyDialogProps {
+  open: boolean;
+  onClose: () => void;
+  onSave: (data: APIKeyFormData) => Promise<void>;
+}
+
+const APIKeyDialog: React.FC<APIKeyDialogProps> = ({ open, onClose, onSave }) => {
+  const intl = useIntl();
+  const [formData, setFormData] = useState<APIKeyFormData>(defaultFormData);
+  const [saving, setSaving] = useState(false);
+
+  React.useEffect(() => {
+    if (open) {
+      setFormData(defaultFormData);
+    }
+  }, [open]);
+
+  const toggleScope = (scope: GQL.ApiKeyScope) => {
+    const scopes = formData.scopes.includes(scope)
+      ? formData.scopes.filter((s) => s !== scope)
+      : [...formData.scopes, scope];
+    setFormData({ ...formData, scopes });
+  };
+
+  const handleSave = async () => {
+    setSaving(true);
+    try {
+      await onSave(formData);
+      onClose();
+    } finally {
+      setSaving(false);
+    }
+  };
+
+  return (
+    <Dialog open={open} onClose={onClose} maxWidth="sm" fullWidth>
+      <DialogTitle>
+        <FormattedMessage id="api_keys.create_key" defaultMessage="Create API Key" />
+      </DialogTitle>
+      <DialogContent>
+        <Box sx={{ display: "flex", flexDirection: "column", gap: 2, mt: 1 }}>
+          <TextField
+            fullWidth
+            label={intl.formatMessage({ id: "api_keys.name", defaultMessage: "Name" })}
+            helperText={intl.formatMessage({
+              id: "api_keys.name_help",
+              defaultMessage: "The app or device using the key, e.g. TiviMate",
+            })}
+            value={formData.name}
+            onChange={(e) => setFormData({ ...formData, name: e.target.value })}
+            required
+          />
+          <Typography variant="subtitle2">
+            <FormattedMessage id="api_keys.scopes" defaultMessage="Scopes" />
+          </Typography>
+          <FormGroup>
+            {allScopes.map((scope) => (
+              <FormControlLabel
+                key={scope}
+                control={
+                  <Checkbox
+                    checked={formData.scopes.includes(scope)}
+                    onChange={() => toggleScope(scope)}
+                  />
+                }
+                label={intl.formatMessage(scopeLabels[scope])}
+              />
+            ))}
+          </FormGroup>
+          <TextField
+            fullWidth
+            type="date"
+            label={intl.formatMessage({ id: "api_keys.expires_at", defaultMessage: "Expires" })}
+            helperText={intl.formatMessage({
+              id: "api_keys.expires_at_help",
+              defaultMessage: "Leave empty for a key that does not expire",
+            })}
+            InputLabelProps={{ shrink: true }}
+            value={formData.expiresAt}
+            onChange={(e) => setFormData({ ...formData, expiresAt: e.target.value })}
+          />
+        </Box>
+      </DialogContent>
+      <DialogActions>
+        <Button onClick={onClose} disabled={saving}>
+          <FormattedMessage id="actions.cancel" defaultMessage="Cancel" />
+        </Button>
+        <Button
+          onClick={handleSave}
+          variant="contained"
+          disabled={saving || !formData.name.trim() || formData.scopes.length === 0}
+        >
+          {saving ? (
+            <FormattedMessage id="actions.saving" defaultMessage="Saving..." />
+          ) : (
+            <FormattedMessage id="actions.create" defaultMessage="Create" />
+          )}
+        </Button>
+      </DialogActions>
+    </Dialog>
+  );
+};
+
+export const SettingsAPIKeysSection: React.FC = () => {
+  const intl = useIntl();
+  const Toast = useToast();
+
+  const { data, refetch } = GQL.useFindApiKeysQuery({
+    fetchPolicy: "cache-and-network",
+  });
+
+  const [apiKeyCreate] = GQL.useApiKeyCreateMutation();
+  const [apiKeyRevoke] = GQL.useApiKeyRevokeMutation();
+
+  const [dialogOpen, setDialogOpen] = useState(false);
+  const [createdKey, setCreatedKey] = useState<string | null>(null);
+  const [revokeConfirmKey, setRevokeConfirmKey] = useState<GQL.ApiKeyDataFragment | null>(null);
+
+  const keys = data?.findAPIKeys ?? [];
+
+  const handleCreateKey = async (formData: APIKeyFormData) => {
+    try {
+      const result = await apiKeyCreate({
+        variables: {
+          input: {
+            name: formData.name,
+            scopes: formData.scopes,
+            expires_at: formData.expiresAt
+              ? new Date(`${formData.expiresAt}T23:59:59`).toISOString()
+              : undefined,
+          },
+        },
+      });
+      setCreatedKey(result.data?.apiKeyCreate.key ?? null);
+      refetch();
+    } catch (e) {
+      Toast.error(e);
+      throw e;
+    }
+  };
+
+  const handleRevokeKey = async (key: GQL.ApiKeyDataFragment) => {
+    try {
+      await apiKeyRevoke({ variables: { id: key.id } });
+      Toast.success(intl.formatMessage({ id: "api_keys.revoked", defaultMessage: "API key revoked" }));
+      setRevokeConfirmKey(null);
+      refetch();
+    } catch (e) {
+      Toast.error(e);
+    }
+  };
+
+  const isExpired = (key: GQL.ApiKeyDataFragment) =>
+    !!key.expires_at && new Date(key.expires_at) < new Date();
+
+  return (
+    <>
+      <SettingSection headingID="api_keys.management" headingDefault="API Keys">
+        <Box sx={{ mb: 2, display: "flex", justifyContent: "space-between", alignItems: "center", gap: 1 }}>
+          <Typography variant="body2" color="text.secondary">
+            <FormattedMessage
+              id="api_keys.description_text"
+              defaultMessage="Give each app or device its own key, limited to what it needs, so it can be revoked on its own."
+            />
+          </Typography>
+          <Button variant="contained" startIcon={<AddIcon />} onClick={() => setDialogOpen(true)}>
+            <FormattedMessage id="api_keys.add_key" defaultMessage="Add Key" />
+          </Button>
+        </Box>
+
+        <TableContainer component={Paper}>
+          <Table>
+            <TableHead>
+              <TableRow>
+                <TableCell>
+                  <FormattedMessage id="api_keys.name" defaultMessage="Name" />
+                </TableCell>
+                <TableCell>
+                  <FormattedMessage id="api_keys.scopes" defaultMessage="Scopes" />
+                </TableCell>
+                <TableCell>
+                  <FormattedMessage id="api_keys.expires_at" defaultMessage="Expires" />
+                </TableCell>
+                <TableCell>
+                  <FormattedMessage id="api_keys.last_used_at" defaultMessage="Last Used" />
+                </TableCell>
+                <TableCell align="right">
+                  <FormattedMessage id="users.actions" defaultMessage="Actions" />
+                </TableCell>
+              </TableRow>
+            </TableHead>
+            <TableBody>
+              {keys.map((key) => (
+                <TableRow key={key.id}>
+                  <TableCell>
+                    {key.name}
+                    <Typography variant="caption" color="text.secondary" sx={{ display: "block", fontFamily: "monospace" }}>
+                      {key.prefix}…
+                    </Typography>
+                  </TableCell>
+                  <TableCell>
+                    <Box sx={{ display: "flex", flexWrap: "wrap", gap: 0.5 }}>
+                      {key.scopes.map((s) => (
+                        <Chip key={s} label={intl.formatMessage(scopeLabels[s])} size="small" />
+                      ))}
+                    </Box>
+                  </TableCell>
+                  <TableCell>
+                    {key.expires_at ? (
+                      <Typography color={isExpired(key) ? "error" : undefined} variant="body2">
+                        {new Date(key.expires_at).toLocaleDateString()}
+                      </Typography>
+                    ) : (
+                      <FormattedMessage id="api_keys.never" defaultMessage="Never" />
+                    )}
+                  </TableCell>
+                  <TableCell>
+                    {key.last_used_at ? (
+                      new Date(key.last_used_at).toLocaleString()
+                    ) : (
+                      <FormattedMessage id="api_keys.never" defaultMessage="Never" />
+                    )}
+                  </TableCell>
+                  <TableCell align="right">
+                    <Tooltip title={intl.formatMessage({ id: "api_keys.revoke", defaultMessage: "Revoke" })}>
+                      <IconButton onClick={() => setRevokeConfirmKey(key)} size="small" color="error">
+                        <DeleteIcon />
+                      </IconButton>
+                    </Tooltip>
+                  </TableCell>
+                </TableRow>
+              ))}
+              {keys.length === 0 && (
+                <TableRow>
+                  <TableCell colSpan={5} align="center">
+                    <Typography color="text.secondary">
+                      <FormattedMessage id="api_keys.no_keys" defaultMessage="No API keys created" />
+                    </Typography>
+                  </TableCell>
+                </TableRow>
+              )}
+            </TableBody>
+          </Table>
+        </TableContainer>
+      </SettingSection>
+
+      <APIKeyDialog open={dialogOpen} onClose={() => setDialogOpen(false)} onSave={handleCreateKey} />
+
+      <Dialog open={!!createdKey} onClose={() => setCreatedKey(null)} maxWidth="sm" fullWidth>
+        <DialogTitle>
+          <FormattedMessage id="api_keys.created" defaultMessage="API Key Created" />
+        </DialogTitle>
+        <DialogContent>
+          <Alert severity="warning" sx={{ mb: 2 }}>
+            <FormattedMessage
+              id="api_keys.created_warning"
+              defaultMessage="Copy the key now. It is not stored and cannot be shown again."
+            />
+          </Alert>
+          <TextField
+            fullWidth
+            value={createdKey ?? ""}
+            InputProps={{ readOnly: true, sx: { fontFamily: "monospace" } }}
+            onFocus={(e) => e.target.select()}
+          />
+        </DialogContent>
+        <DialogActions>
+          <Button onClick={() => setCreatedKey(null)} variant="contained">
+            <FormattedMessage id="actions.close" defaultMessage="Close" />
+          </Button>
+        </DialogActions>
+      </Dialog>
+
+      <Dialog open={!!revokeConfirmKey} onClose={() => setRevokeConfirmKey(null)}>
+        <DialogTitle>
+          <FormattedMessage id="api_keys.revoke_confirm_title" defaultMessage="Revoke API Key" />
+        </DialogTitle>
+        <DialogContent>
+          <Typography>
+            <FormattedMessage
+              id="api_keys.revoke_confirm_message"
+              defaultMessage="Revoke '{name}'? Apps using it will stop working."
+              values={{ name: revokeConfirmKey?.name }}
+            />
+          </Typography>
+        </DialogContent>
+        <DialogActions>
+          <Button onClick={() => setRevokeConfirmKey(null)}>
+            <FormattedMessage id="actions.cancel" defaultMessage="Cancel" />
+          </Button>
+          <Button
+            onClick={() => revokeConfirmKey && handleRevokeKey(revokeConfirmKey)}
+            color="error"
+            variant="contained"
+          >
+            <FormattedMessage id="api_keys.revoke" defaultMessage="Revoke" />
+          </Button>
+        </DialogActions>
+      </Dialog>
+    </>
+  );
+};
@@ -0,0 +1,180 @@
+import React, { useState } from "react";
+import { FormattedMessage, useIntl } from "react-intl";
+import {
+  Box,
+  Paper,
+  Table,
+  TableBody,
+  TableCell,
+  TableContainer,
+  TableHead,
+  TablePagination,
+  TableRow,
+  TextField,
+  Tooltip,
+  Typography,
+} from "@mui/material";
+import * as GQL from "src/core/generated-graphql";
+import { SettingSection } from "./SettingSection";
+
+interface AuditFilterData {
+  operation: string;
+  entityType: string;
+  entityID: string;
+}
+
+const defaultFilterData: AuditFilterData = {
+  operation: "",
+  entityType: "",
+  entityID: "",
+};
+
+function auditFilter(data: AuditFilterData): GQL.AuditEntryFilterType {
+  return {
+    operation: data.operation.trim() || undefined,
+    entity_type: data.entityType.trim() || undefined,
+    entity_id: data.entityID.trim() || undefined,
+  };
+}
+
+export const SettingsAuditLogSection: React.FC = () => {
+  const intl = useIntl();
+
+  const [filterData, setFilterData] = useState<AuditFilterData>(defaultFilterData);
+  const [page, setPage] = useState(0);
+  const [perPage, setPerPage] = useState(25);
+
+  const { data } = GQL.useFindAuditEntriesQuery({
+    variables: {
+      audit_filter: auditFilter(filterData),
+      filter: { page: page + 1, per_page: perPage },
+    },
+    fetchPolicy: "cache-and-network",
+  });
+
+  const entries = data?.findAuditEntries.audit_entries ?? [];
+  const count = data?.findAuditEntries.count ?? 0;
+
+  const setFilter = (d: Partial<AuditFilterData>) => {
+    setFilterData({ ...filterData, ...d });
+    setPage(0);
+  };
+
+  return (
+    <SettingSection headingID="audit_log.heading" headingDefault="Audit Log">
+      <Box sx={{ mb: 2, display: "flex", flexWrap: "wrap", gap: 1 }}>
+        <TextField
+          size="small"
+          label={intl.formatMessage({ id: "audit_log.operation", defaultMessage: "Operation" })}
+          placeholder="performerMerge"
+          value={filterData.operation}
+          onChange={(e) => setFilter({ operation: e.target.value })}
+        />
+        <TextField
+          size="small"
+          label={intl.formatMessage({ id: "audit_log.entity_type", defaultMessage: "Entity Type" })}
+          placeholder="performer"
+          value={filterData.entityType}
+          onChange={(e) => setFilter({ entityType: e.target.value })}
+        />
+        <TextField
+          size="small"
+          label={intl.formatMessage({ id: "audit_log.entity_id", defaultMessage: "Entity ID" })}
+          value={filterData.entityID}
+          onChange={(e) => setFilter({ entityID: e.target.value })}
+        />
+      </Box>
+
+      <TableContainer component={Paper}>
+        <Table size="small">
+          <TableHead>
+            <TableRow>
+              <TableCell>
+                <FormattedMessage id="audit_log.time" defaultMessage="Time" />
+              </TableCell>
+              <TableCell>
+                <FormattedMessage id="users.username" defaultMessage="Username" />
+              </TableCell>
+              <TableCell>
+                <FormattedMessage id="audit_log.operation" defaultMessage="Operation" />
+              </TableCell>
+              <TableCell>
+                <FormattedMessage id="audit_log.entities" defaultMessage="Entities" />
+              </TableCell>
+              <TableCell>
+                <FormattedMessage id="audit_log.changes" defaultMessage="Changes" />
+              </TableCell>
+            </TableRow>
+          </TableHead>
+          <TableBody>
+            {entries.map((e) => (
+              <TableRow key={e.id}>
+                <TableCell sx={{ whiteSpace: "nowrap" }}>
+                  {new Date(e.created_at).toLocaleString()}
+                </TableCell>
+                <TableCell>
+                  {e.username}
+                  {e.ip_address && (
+                    <Typography variant="caption" color="text.secondary" sx={{ display: "block" }}>
+                      {e.ip_address}
+                    </Typography>
+                  )}
+                </TableCell>
+                <TableCell>
+                  <Typography variant="body2" sx={{ fontFamily: "monospace" }}>
+                    {e.operation}
+                  </Typography>
+                  {e.error && (
+                    <Typography variant="caption" color="error" sx={{ display: "block" }}>
+                      {e.error}
+                    </Typography>
+                  )}
+                </TableCell>
+                <TableCell>
+                  {e.entity_type}
+                  {e.entity_ids.length > 0 && ` ${e.entity_ids.join(", ")}`}
+                </TableCell>
+                <TableCell>
+                  {e.changes && (
+                    <Tooltip
+                      title={
+                        <Box component="pre" sx={{ m: 0, fontSize: "0.75rem" }}>
+                          {JSON.stringify(e.changes, null, 2)}
+                        </Box>
+                      }
+                    >
+                      <Typography variant="body2" color="text.secondary" noWrap sx={{ maxWidth: 240 }}>
+                        {Object.keys(e.changes).join(", ")}
+                      </Typography>
+                    </Tooltip>
+                  )}
+                </TableCell>
+              </TableRow>
+            ))}
+            {entries.length === 0 && (
+              <TableRow>
+                <TableCell colSpan={5} align="center">
+                  <Typography color="text.secondary">
+                    <FormattedMessage id="audit_log.no_entries" defaultMessage="No changes recorded" />
+                  </Typography>
+                </TableCell>
+              </TableRow>
+            )}
+          </TableBody>
+        </Table>
+      </TableContainer>
+      <TablePagination
+        component="div"
+        count={count}
+        page={page}
+        rowsPerPage={perPage}
+        rowsPerPageOptions={[25, 50, 100]}
+        onPageChange={(_, p) => setPage(p)}
+        onRowsPerPageChange={(e) => {
+          setPerPage(parseInt(e.target.value, 10));
+          setPage(0);
+        }}
+      />
+    </SettingSection>
+  );
+};
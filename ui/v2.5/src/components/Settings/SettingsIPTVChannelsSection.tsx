@@ -0,0 +1,557 @@
+import React, { useState } from "react";
+import { FormattedMessage, useIntl } from "react-intl";
+import {
+  Box,
+  Button,
+  Dialog,
+  DialogActions,
+  DialogContent,
+  DialogTitle,
+  IconButton,
+  MenuItem,
+  Paper,
+  Table,
+  TableBody,
+  TableCell,
+  TableContainer,
+  TableHead,
+  TableRow,
+  TextField,
+  Tooltip,
+  Typography,
+} from "@mui/material";
+import {
+  Add as AddIcon,
+  Delete as DeleteIcon,
+  Edit as EditIcon,
+} from "@mui/icons-material";
+import * as GQL from "src/core/generated-graphql";
+import { useToast } from "src/hooks/Toast";
+import { SettingSection } from "./SettingSection";
+
+const sourceLabels: Record<GQL.IptvChannelSource, { id: string; defaultMessage: string }> = {
+  [GQL.IptvChannelSource.SavedFilter]: { id: "iptv_channels.source.saved_filter", defaultMessage: "Saved filter" },
+  [GQL.IptvChannelSource.Tag]: { id: "iptv_channels.source.tag", defaultMessage: "Tag" },
+  [GQL.IptvChannelSource.Performer]: { id: "iptv_channels.source.performer", defaultMessage: "Performer" },
+  [GQL.IptvChannelSource.Group]: { id: "iptv_channels.source.group", defaultMessage: "Group" },
+  [GQL.IptvChannelSource.Playlist]: { id: "iptv_channels.source.playlist", defaultMessage: "Playlist" },
+};
+
+const allSources = Object.keys(sourceLabels) as GQL.IptvChannelSource[];
+
+// Sunday first, matching the day numbers the server uses
+const weekdays = [0, 1, 2, 3, 4, 5, 6];
+
+interface IPTVChannelBlockFormData {
+  name: string;
+  days: number[];
+  start: string;
+  end: string;
+  sourceType: GQL.IptvChannelSource;
+  sourceID: string;
+}
+
+const defaultBlockFormData: IPTVChannelBlockFormData = {
+  name: "",
+  days: [],
+  start: "22:00",
+  end: "02:00",
+  sourceType: GQL.IptvChannelSource.SavedFilter,
+  sourceID: "",
+};
+
+interface IPTVChannelFormData {
+  name: string;
+  number: string;
+  sourceType: GQL.IptvChannelSource;
+  sourceID: string;
+  shuffleSeed: string;
+  blocks: IPTVChannelBlockFormData[];
+}
+
+const defaultFormData: IPTVChannelFormData = {
+  name: "",
+  number: "",
+  sourceType: GQL.IptvChannelSource.SavedFilter,
+  sourceID: "",
+  shuffleSeed: "",
+  blocks: [],
+};
+
+function toFormData(channel: GQL.IptvChannelDataFragment): IPTVChannelFormData {
+  return {
+    name: channel.name,
+    number: channel.number?.toString() ?? "",
+    sourceType: channel.source_type,
+    sourceID: channel.source_id,
+    shuffleSeed: channel.shuffle_seed?.toString() ?? "",
+    blocks: channel.blocks.map((b) => ({
+      name: b.name,
+      days: b.days,
+      start: b.start,
+      end: b.end,
+      sourceType: b.source_type,
+      sourceID: b.source_id,
+    })),
+  };
+}
+
+function toBlockInput(block: IPTVChannelBlockFormData): GQL.IptvChannelBlockInput {
+  return {
+    name: block.name,
+    days: block.days,
+    start: block.start,
+    end: block.end,
+    source_type: block.sourceType,
+    source_id: block.sourceID,
+  };
+}
+
+function optionalInt(value: string) {
+  return value.trim() === "" ? null : parseInt(value, 10);
+}
+
+interface IPTVSourceFieldsProps {
+  sourceType: GQL.IptvChannelSource;
+  sourceID: string;
+  savedFilters: { id: string; name: string }[];
+  onChange: (sourceType: GQL.IptvChannelSource, sourceID: string) => void;
+}
+
+const IPTVSourceFields: React.FC<IPTVSourceFieldsProps> = ({ sourceType, sourceID, savedFilters, onChange }) => {
+  const intl = useIntl();
+
+  return (
+    <>
+      <TextField
+        select
+        fullWidth
+        label={intl.formatMessage({ id: "iptv_channels.source_type", defaultMessage: "Source" })}
+        value={sourceType}
+        onChange={(e) => onChange(e.target.value as GQL.IptvChannelSource, "")}
+      >
+        {allSources.map((s) => (
+          <MenuItem key={s} value={s}>
+            {intl.formatMessage(sourceLabels[s])}
+          </MenuItem>
+        ))}
+      </TextField>
+      {sourceType === GQL.IptvChannelSource.SavedFilter ? (
+        <TextField
+          select
+          fullWidth
+          label={intl.formatMessage(sourceLabels[GQL.IptvChannelSource.SavedFilter])}
+          value={sourceID}
+          onChange={(e) => onChange(sourceType, e.target.value)}
+          required
+        >
+          {savedFilters.map((f) => (
+            <MenuItem key={f.id} value={f.id}>
+              {f.name}
+            </MenuItem>
+          ))}
+        </TextField>
+      ) : (
+        <TextField
+          fullWidth
+          label={intl.formatMessage({ id: "iptv_channels.source_id", defaultMessage: "ID" })}
+          helperText={intl.formatMessage({
+            id: "iptv_channels.source_id_help",
+            defaultMessage: "The ID shown in the address bar of the tag, performer, group or playlist page",
+          })}
+          value={sourceID}
+          onChange={(e) => onChange(sourceType, e.target.value)}
+          required
+        />
+      )}
+    </>
+  );
+};
+
+interface IPTVChannelBlockFieldsProps {
+  block: IPTVChannelBlockFormData;
+  savedFilters: { id: string; name: string }[];
+  onChange: (block: IPTVChannelBlockFormData) => void;
+  onRemove: () => void;
+}
+
+const IPTVChannelBlockFields: React.FC<IPTVChannelBlockFieldsProps> = ({ block, savedFilters, onChange, onRemove }) => {
+  const intl = useIntl();
+
+  const dayName = (d: number) =>
+    // 2023-01-01 was a Sunday
+    intl.formatDate(new Date(2023, 0, 1 + d), { weekday: "short" });
+
+  return (
+    <Paper variant="outlined" sx={{ p: 2, display: "flex", flexDirection: "column", gap: 2 }}>
+      <Box sx={{ display: "flex", gap: 1, alignItems: "center" }}>
+        <TextField
+          fullWidth
+          size="small"
+          label={intl.formatMessage({ id: "iptv_channels.block_name", defaultMessage: "Block Name" })}
+          value={block.name}
+          onChange={(e) => onChange({ ...block, name: e.target.value })}
+        />
+        <Tooltip title={intl.formatMessage({ id: "actions.remove", defaultMessage: "Remove" })}>
+          <IconButton onClick={onRemove} size="small" color="error">
+            <DeleteIcon />
+          </IconButton>
+        </Tooltip>
+      </Box>
+      <Box sx={{ display: "flex", gap: 1 }}>
+        <TextField
+          fullWidth
+          size="small"
+          type="time"
+          label={intl.formatMessage({ id: "iptv_channels.block_start", defaultMessage: "Start" })}
+          value={block.start}
+          onChange={(e) => onChange({ ...block, start: e.target.value })}
+          InputLabelProps={{ shrink: true }}
+        />
+        <TextField
+          fullWidth
+          size="small"
+          type="time"
+          label={intl.formatMessage({ id: "iptv_channels.block_end", defaultMessage: "End" })}
+          value={block.end}
+          onChange={(e) => onChange({ ...block, end: e.target.value })}
+          InputLabelProps={{ shrink: true }}
+        />
+        <TextField
+          select
+          fullWidth
+          size="small"
+          label={intl.formatMessage({ id: "iptv_channels.block_days", defaultMessage: "Days" })}
+          helperText={intl.formatMessage({ id: "iptv_channels.block_days_help", defaultMessage: "None means every day" })}
+          value={block.days}
+          SelectProps={{
+            multiple: true,
+            renderValue: (v) => (v as number[]).map(dayName).join(", "),
+          }}
+          onChange={(e) =>
+            onChange({ ...block, days: (e.target.value as unknown as number[]).slice().sort((x, y) => x - y) })
+          }
+        >
+          {weekdays.map((d) => (
+            <MenuItem key={d} value={d}>
+              {dayName(d)}
+            </MenuItem>
+          ))}
+        </TextField>
+      </Box>
+      <IPTVSourceFields
+        sourceType={block.sourceType}
+        sourceID={block.sourceID}
+        savedFilters={savedFilters}
+        onChange={(sourceType, sourceID) => onChange({ ...block, sourceType, sourceID })}
+      />
+    </Paper>
+  );
+};
+
+interface IPTVChannelDialogProps {
+  channel: GQL.IptvChannelDataFragment | null;
+  open: boolean;
+  onClose: () => void;
+  onSave: (data: IPTVChannelFormData) => Promise<void>;
+}
+
+const IPTVChannelDialog: React.FC<IPTVChannelDialogProps> = ({ channel, open, onClose, onSave }) => {
+  const intl = useIntl();
+  const [formData, setFormData] = useState<IPTVChannelFormData>(defaultFormData);
+  const [saving, setSaving] = useState(false);
+
+  const { data: filters } = GQL.useFindSavedFiltersQuery({
+    variables: { mode: GQL.FilterMode.Scenes },
+    skip: !open,
+  });
+
+  React.useEffect(() => {
+    if (open) {
+      setFormData(channel ? toFormData(channel) : defaultFormData);
+    }
+  }, [open, channel]);
+
+  const handleSave = async () => {
+    setSaving(true);
+    try {
+      await onSave(formData);
+      onClose();
+    } finally {
+      setSaving(false);
+    }
+  };
+
+  const savedFilters = filters?.findSavedFilters ?? [];
+
+  return (
+    <Dialog open={open} onClose={onClose} maxWidth="sm" fullWidth>
+      <DialogTitle>
+        {channel ? (
+          <FormattedMessage id="iptv_channels.edit_channel" defaultMessage="Edit Channel" />
+        ) : (
+          <FormattedMessage id="iptv_channels.create_channel" defaultMessage="Create Channel" />
+        )}
+      </DialogTitle>
+      <DialogContent>
+        <Box sx={{ display: "flex", flexDirection: "column", gap: 2, mt: 1 }}>
+          <TextField
+            fullWidth
+            label={intl.formatMessage({ id: "iptv_channels.name", defaultMessage: "Name" })}
+            value={formData.name}
+            onChange={(e) => setFormData({ ...formData, name: e.target.value })}
+            required
+          />
+          <TextField
+            fullWidth
+            type="number"
+            label={intl.formatMessage({ id: "iptv_channels.number", defaultMessage: "Number" })}
+            helperText={intl.formatMessage({
+              id: "iptv_channels.number_help",
+              defaultMessage: "Leave empty to number the channel after the studio channels",
+            })}
+            value={formData.number}
+            onChange={(e) => setFormData({ ...formData, number: e.target.value })}
+          />
+          <IPTVSourceFields
+            sourceType={formData.sourceType}
+            sourceID={formData.sourceID}
+            savedFilters={savedFilters}
+            onChange={(sourceType, sourceID) => setFormData({ ...formData, sourceType, sourceID })}
+          />
+          <TextField
+            fullWidth
+            type="number"
+            label={intl.formatMessage({ id: "iptv_channels.shuffle_seed", defaultMessage: "Shuffle Seed" })}
+            helperText={intl.formatMessage({
+              id: "iptv_channels.shuffle_seed_help",
+              defaultMessage: "Change to air the same scenes in a different order",
+            })}
+            value={formData.shuffleSeed}
+            onChange={(e) => setFormData({ ...formData, shuffleSeed: e.target.value })}
+          />
+          <Typography variant="subtitle1">
+            <FormattedMessage id="iptv_channels.blocks" defaultMessage="Programming Blocks" />
+          </Typography>
+          <Typography variant="body2" color="text.secondary">
+            <FormattedMessage
+              id="iptv_channels.blocks_help"
+              defaultMessage="Air a different source at set times of the week. Outside the blocks the channel airs its own source."
+            />
+          </Typography>
+          {formData.blocks.map((block, i) => (
+            <IPTVChannelBlockFields
+              key={i}
+              block={block}
+              savedFilters={savedFilters}
+              onChange={(b) =>
+                setFormData({ ...formData, blocks: formData.blocks.map((o, j) => (j === i ? b : o)) })
+              }
+              onRemove={() => setFormData({ ...formData, blocks: formData.blocks.filter((_, j) => j !== i) })}
+            />
+          ))}
+          <Box>
+            <Button
+              startIcon={<AddIcon />}
+              onClick={() => setFormData({ ...formData, blocks: [...formData.blocks, defaultBlockFormData] })}
+            >
+              <FormattedMessage id="iptv_channels.add_block" defaultMessage="Add Block" />
+            </Button>
+          </Box>
+        </Box>
+      </DialogContent>
+      <DialogActions>
+        <Button onClick={onClose} disabled={saving}>
+          <FormattedMessage id="actions.cancel" defaultMessage="Cancel" />
+        </Button>
+        <Button
+          onClick={handleSave}
+          variant="contained"
+          disabled={
+            saving ||
+            !formData.name.trim() ||
+            !formData.sourceID.trim() ||
+            formData.blocks.some((b) => !b.sourceID.trim())
+          }
+        >
+          {saving ? (
+            <FormattedMessage id="actions.saving" defaultMessage="Saving..." />
+          ) : (
+            <FormattedMessage id="actions.save" defaultMessage="Save" />
+          )}
+        </Button>
+      </DialogActions>
+    </Dialog>
+  );
+};
+
+export const SettingsIPTVChannelsSection: React.FC = () => {
+  const intl = useIntl();
+  const Toast = useToast();
+
+  const { data, refetch } = GQL.useFindIptvChannelsQuery({
+    fetchPolicy: "cache-and-network",
+  });
+
+  const [iptvChannelCreate] = GQL.useIptvChannelCreateMutation();
+  const [iptvChannelUpdate] = GQL.useIptvChannelUpdateMutation();
+  const [iptvChannelDestroy] = GQL.useIptvChannelDestroyMutation();
+
+  const [dialogOpen, setDialogOpen] = useState(false);
+  const [editing, setEditing] = useState<GQL.IptvChannelDataFragment | null>(null);
+  const [deleteConfirm, setDeleteConfirm] = useState<GQL.IptvChannelDataFragment | null>(null);
+
+  const channels = data?.findIPTVChannels ?? [];
+
+  const openDialog = (channel: GQL.IptvChannelDataFragment | null) => {
+    setEditing(channel);
+    setDialogOpen(true);
+  };
+
+  const handleSave = async (formData: IPTVChannelFormData) => {
+    const fields = {
+      name: formData.name,
+      number: optionalInt(formData.number),
+      source_type: formData.sourceType,
+      source_id: formData.sourceID,
+      shuffle_seed: optionalInt(formData.shuffleSeed),
+      blocks: formData.blocks.map(toBlockInput),
+    };
+
+    try {
+      if (editing) {
+        await iptvChannelUpdate({ variables: { input: { id: editing.id, ...fields } } });
+      } else {
+        await iptvChannelCreate({ variables: { input: fields } });
+      }
+      refetch();
+    } catch (e) {
+      Toast.error(e);
+      throw e;
+    }
+  };
+
+  const handleDelete = async (channel: GQL.IptvChannelDataFragment) => {
+    try {
+      await iptvChannelDestroy({ variables: { id: channel.id } });
+      Toast.success(intl.formatMessage({ id: "iptv_channels.deleted", defaultMessage: "Channel deleted" }));
+      setDeleteConfirm(null);
+      refetch();
+    } catch (e) {
+      Toast.error(e);
+    }
+  };
+
+  return (
+    <>
+      <SettingSection headingID="iptv_channels.management" headingDefault="IPTV Channels">
+        <Box sx={{ mb: 2, display: "flex", justifyContent: "space-between", alignItems: "center", gap: 1 }}>
+          <Typography variant="body2" color="text.secondary">
+            <FormattedMessage
+              id="iptv_channels.description_text"
+              defaultMessage="Channels airing a saved filter, tag, performer, group or playlist, alongside the studio channels."
+            />
+          </Typography>
+          <Button variant="contained" startIcon={<AddIcon />} onClick={() => openDialog(null)}>
+            <FormattedMessage id="iptv_channels.add_channel" defaultMessage="Add Channel" />
+          </Button>
+        </Box>
+
+        <TableContainer component={Paper}>
+          <Table>
+            <TableHead>
+              <TableRow>
+                <TableCell />
+                <TableCell>
+                  <FormattedMessage id="iptv_channels.number" defaultMessage="Number" />
+                </TableCell>
+                <TableCell>
+                  <FormattedMessage id="iptv_channels.name" defaultMessage="Name" />
+                </TableCell>
+                <TableCell>
+                  <FormattedMessage id="iptv_channels.source_type" defaultMessage="Source" />
+                </TableCell>
+                <TableCell>
+                  <FormattedMessage id="iptv_channels.scene_count" defaultMessage="Scenes" />
+                </TableCell>
+                <TableCell align="right">
+                  <FormattedMessage id="users.actions" defaultMessage="Actions" />
+                </TableCell>
+              </TableRow>
+            </TableHead>
+            <TableBody>
+              {channels.map((channel) => (
+                <TableRow key={channel.id}>
+                  <TableCell>
+                    <Box component="img" src={channel.logo_path} alt="" sx={{ height: 32, maxWidth: 64, objectFit: "contain" }} />
+                  </TableCell>
+                  <TableCell>{channel.number ?? "–"}</TableCell>
+                  <TableCell>{channel.name}</TableCell>
+                  <TableCell>
+                    {intl.formatMessage(sourceLabels[channel.source_type])} #{channel.source_id}
+                  </TableCell>
+                  <TableCell>{channel.scene_count}</TableCell>
+                  <TableCell align="right">
+                    <Tooltip title={intl.formatMessage({ id: "actions.edit", defaultMessage: "Edit" })}>
+                      <IconButton onClick={() => openDialog(channel)} size="small">
+                        <EditIcon />
+                      </IconButton>
+                    </Tooltip>
+                    <Tooltip title={intl.formatMessage({ id: "actions.delete", defaultMessage: "Delete" })}>
+                      <IconButton onClick={() => setDeleteConfirm(channel)} size="small" color="error">
+                        <DeleteIcon />
+                      </IconButton>
+                    </Tooltip>
+                  </TableCell>
+                </TableRow>
+              ))}
+              {channels.length === 0 && (
+                <TableRow>
+                  <TableCell colSpan={6} align="center">
+                    <Typography color="text.secondary">
+                      <FormattedMessage id="iptv_channels.no_channels" defaultMessage="No channels created" />
+                    </Typography>
+                  </TableCell>
+                </TableRow>
+              )}
+            </TableBody>
+          </Table>
+        </TableContainer>
+      </SettingSection>
+
+      <IPTVChannelDialog
+        channel={editing}
+        open={dialogOpen}
+        onClose={() => setDialogOpen(false)}
+        onSave={handleSave}
+      />
+
+      <Dialog open={!!deleteConfirm} onClose={() => setDeleteConfirm(null)}>
+        <DialogTitle>
+          <FormattedMessage id="iptv_channels.delete_confirm_title" defaultMessage="Delete Channel" />
+        </DialogTitle>
+        <DialogContent>
+          <Typography>
+            <FormattedMessage
+              id="iptv_channels.delete_confirm_message"
+              defaultMessage="Delete '{name}'? It will disappear from every IPTV client."
+              values={{ name: deleteConfirm?.name }}
+            />
+          </Typography>
+        </DialogContent>
+        <DialogActions>
+          <Button onClick={() => setDeleteConfirm(null)}>
+            <FormattedMessage id="actions.cancel" defaultMessage="Cancel" />
+          </Button>
+          <Button
+            onClick={() => deleteConfirm && handleDelete(deleteConfirm)}
+            color="error"
+            variant="contained"
+          >
+            <FormattedMessage id="actions.delete" defaultMessage="Delete" />
+          </Button>
+        </DialogActions>
+      </Dialog>
+    </>
+  );
+};
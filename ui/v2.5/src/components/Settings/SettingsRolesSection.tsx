@@ -0,0 +1,353 @@
+import React, { useState } from "react";
+import { FormattedMessage, useIntl } from "react-intl";
+import {
+  Box,
+  Button,
+  Checkbox,
+  Chip,
+  Dialog,
+  DialogActions,
+  DialogContent,
+  DialogTitle,
+  FormControlLabel,
+  FormGroup,
+  IconButton,
+  Paper,
+  Table,
+  TableBody,
+  TableCell,
+  TableContainer,
+  TableHead,
+  TableRow,
+  TextField,
+  Tooltip,
+  Typography,
+} from "@mui/material";
+import {
+  Add as AddIcon,
+  Delete as DeleteIcon,
+  Edit as EditIcon,
+} from "@mui/icons-material";
+import * as GQL from "src/core/generated-graphql";
+import { useToast } from "src/hooks/Toast";
+import { SettingSection } from "./SettingSection";
+
+const permissionLabels: Record<GQL.Permission, { id: string; defaultMessage: string }> = {
+  [GQL.Permission.EditMetadata]: { id: "roles.permission.edit_metadata", defaultMessage: "Edit metadata" },
+  [GQL.Permission.DeleteFiles]: { id: "roles.permission.delete_files", defaultMessage: "Delete files" },
+  [GQL.Permission.RunJobs]: { id: "roles.permission.run_jobs", defaultMessage: "Run scan/generate jobs" },
+  [GQL.Permission.ManagePlugins]: { id: "roles.permission.manage_plugins", defaultMessage: "Manage plugins" },
+  [GQL.Permission.ApiHubDownloads]: { id: "roles.permission.api_hub_downloads", defaultMessage: "API Hub downloads" },
+  [GQL.Permission.ControlHandy]: { id: "roles.permission.control_handy", defaultMessage: "Control the Handy" },
+  [GQL.Permission.ManageIptv]: { id: "roles.permission.manage_iptv", defaultMessage: "Manage IPTV" },
+  [GQL.Permission.ViewRecycleBin]: { id: "roles.permission.view_recycle_bin", defaultMessage: "See the recycle bin" },
+};
+
+const allPermissions = Object.keys(permissionLabels) as GQL.Permission[];
+
+interface RoleFormData {
+  name: string;
+  description: string;
+  permissions: GQL.Permission[];
+}
+
+const defaultFormData: RoleFormData = {
+  name: "",
+  description: "",
+  permissions: [],
+};
+
+interface RoleDialogProps {
+  open: boolean;
+  role: GQL.RoleDataFragment | null;
+  onClose: () => void;
+  onSave: (data: RoleFormData, roleId?: string) => Promise<void>;
+}
+
+const RoleDialog: React.FC<RoleDialogProps> = ({
+  open,
+  role,
+  onClose,
+  onSave,
+}) => {
+  const intl = useIntl();
+  const [formData, setFormData] = useState<RoleFormData>(defaultFormData);
+  const [saving, setSaving] = useState(false);
+
+  React.useEffect(() => {
+    if (open) {
+      setFormData(
+        role
+          ? { name: role.name, description: role.description, permissions: role.permissions }
+          : defaultFormData
+      );
+    }
+  }, [open, role]);
+
+  const togglePermission = (permission: GQL.Permission) => {
+    const permissions = formData.permissions.includes(permission)
+      ? formData.permissions.filter((p) => p !== permission)
+      : [...formData.permissions, permission];
+    setFormData({ ...formData, permissions });
+  };
+
+  const handleSave = async () => {
+    setSaving(true);
+    try {
+      await onSave(formData, role?.id);
+      onClose();
+    } finally {
+      setSaving(false);
+    }
+  };
+
+  return (
+    <Dialog open={open} onClose={onClose} maxWidth="sm" fullWidth>
+      <DialogTitle>
+        {role ? (
+          <FormattedMessage id="roles.edit_role" defaultMessage="Edit Role" />
+        ) : (
+          <FormattedMessage id="roles.create_role" defaultMessage="Create Role" />
+        )}
+      </DialogTitle>
+      <DialogContent>
+        <Box sx={{ display: "flex", flexDirection: "column", gap: 2, mt: 1 }}>
+          <TextField
+            fullWidth
+            label={intl.formatMessage({ id: "roles.name", defaultMessage: "Name" })}
+            value={formData.name}
+            onChange={(e) => setFormData({ ...formData, name: e.target.value })}
+            required
+          />
+          <TextField
+            fullWidth
+            label={intl.formatMessage({ id: "roles.description", defaultMessage: "Description" })}
+            value={formData.description}
+            onChange={(e) => setFormData({ ...formData, description: e.target.value })}
+          />
+          <Typography variant="subtitle2">
+            <FormattedMessage id="roles.permissions" defaultMessage="Permissions" />
+          </Typography>
+          <FormGroup>
+            {allPermissions.map((permission) => (
+              <FormControlLabel
+                key={permission}
+                control={
+                  <Checkbox
+                    checked={formData.permissions.includes(permission)}
+                    onChange={() => togglePermission(permission)}
+                  />
+                }
+                label={intl.formatMessage(permissionLabels[permission])}
+              />
+            ))}
+          </FormGroup>
+        </Box>
+      </DialogContent>
+      <DialogActions>
+        <Button onClick={onClose} disabled={saving}>
+          <FormattedMessage id="actions.cancel" defaultMessage="Cancel" />
+        </Button>
+        <Button
+          onClick={handleSave}
+          variant="contained"
+          disabled={saving || !formData.name.trim()}
+        >
+          {saving ? (
+            <FormattedMessage id="actions.saving" defaultMessage="Saving..." />
+          ) : (
+            <FormattedMessage id="actions.save" defaultMessage="Save" />
+          )}
+        </Button>
+      </DialogActions>
+    </Dialog>
+  );
+};
+
+export const SettingsRolesSection: React.FC = () => {
+  const intl = useIntl();
+  const Toast = useToast();
+
+  const { data, refetch } = GQL.useFindRolesQuery({
+    fetchPolicy: "cache-and-network",
+  });
+
+  const [roleCreate] = GQL.useRoleCreateMutation();
+  const [roleUpdate] = GQL.useRoleUpdateMutation();
+  const [roleDestroy] = GQL.useRoleDestroyMutation();
+
+  const [dialogOpen, setDialogOpen] = useState(false);
+  const [editingRole, setEditingRole] = useState<GQL.RoleDataFragment | null>(null);
+  const [deleteConfirmRole, setDeleteConfirmRole] = useState<GQL.RoleDataFragment | null>(null);
+
+  const roles = data?.findRoles ?? [];
+
+  const handleSaveRole = async (formData: RoleFormData, roleId?: string) => {
+    try {
+      if (roleId) {
+        await roleUpdate({
+          variables: {
+            input: {
+              id: roleId,
+              name: formData.name,
+              description: formData.description,
+              permissions: formData.permissions,
+            },
+          },
+        });
+        Toast.success(intl.formatMessage({ id: "roles.updated", defaultMessage: "Role updated" }));
+      } else {
+        await roleCreate({
+          variables: {
+            input: {
+              name: formData.name,
+              description: formData.description,
+              permissions: formData.permissions,
+            },
+          },
+        });
+        Toast.success(intl.formatMessage({ id: "roles.created", defaultMessage: "Role created" }));
+      }
+      refetch();
+    } catch (e) {
+      Toast.error(e);
+      throw e;
+    }
+  };
+
+  const handleDeleteRole = async (role: GQL.RoleDataFragment) => {
+    try {
+      await roleDestroy({ variables: { id: role.id } });
+      Toast.success(intl.formatMessage({ id: "roles.deleted", defaultMessage: "Role deleted" }));
+      setDeleteConfirmRole(null);
+      refetch();
+    } catch (e) {
+      Toast.error(e);
+    }
+  };
+
+  return (
+    <>
+      <SettingSection headingID="roles.management" headingDefault="Custom Roles">
+        <Box sx={{ mb: 2, display: "flex", justifyContent: "space-between", alignItems: "center", gap: 1 }}>
+          <Typography variant="body2" color="text.secondary">
+            <FormattedMessage
+              id="roles.description_text"
+              defaultMessage="Grant viewers additional capabilities without making them admins."
+            />
+          </Typography>
+          <Button
+            variant="contained"
+            startIcon={<AddIcon />}
+            onClick={() => {
+              setEditingRole(null);
+              setDialogOpen(true);
+            }}
+          >
+            <FormattedMessage id="roles.add_role" defaultMessage="Add Role" />
+          </Button>
+        </Box>
+
+        <TableContainer component={Paper}>
+          <Table>
+            <TableHead>
+              <TableRow>
+                <TableCell>
+                  <FormattedMessage id="roles.name" defaultMessage="Name" />
+                </TableCell>
+                <TableCell>
+                  <FormattedMessage id="roles.permissions" defaultMessage="Permissions" />
+                </TableCell>
+                <TableCell align="right">
+                  <FormattedMessage id="users.actions" defaultMessage="Actions" />
+                </TableCell>
+              </TableRow>
+            </TableHead>
+            <TableBody>
+              {roles.map((role) => (
+                <TableRow key={role.id}>
+                  <TableCell>
+                    {role.name}
+                    {role.description && (
+                      <Typography variant="caption" color="text.secondary" sx={{ display: "block" }}>
+                        {role.description}
+                      </Typography>
+                    )}
+                  </TableCell>
+                  <TableCell>
+                    <Box sx={{ display: "flex", flexWrap: "wrap", gap: 0.5 }}>
+                      {role.permissions.map((p) => (
+                        <Chip key={p} label={intl.formatMessage(permissionLabels[p])} size="small" />
+                      ))}
+                    </Box>
+                  </TableCell>
+                  <TableCell align="right">
+                    <Tooltip title={intl.formatMessage({ id: "actions.edit", defaultMessage: "Edit" })}>
+                      <IconButton
+                        onClick={() => {
+                          setEditingRole(role);
+                          setDialogOpen(true);
+                        }}
+                        size="small"
+                      >
+                        <EditIcon />
+                      </IconButton>
+                    </Tooltip>
+                    <Tooltip title={intl.formatMessage({ id: "actions.delete", defaultMessage: "Delete" })}>
+                      <IconButton onClick={() => setDeleteConfirmRole(role)} size="small" color="error">
+                        <DeleteIcon />
+                      </IconButton>
+                    </Tooltip>
+                  </TableCell>
+                </TableRow>
+              ))}
+              {roles.length === 0 && (
+                <TableRow>
+                  <TableCell colSpan={3} align="center">
+                    <Typography color="text.secondary">
+                      <FormattedMessage id="roles.no_roles" defaultMessage="No custom roles defined" />
+                    </Typography>
+                  </TableCell>
+                </TableRow>
+              )}
+            </TableBody>
+          </Table>
+        </TableContainer>
+      </SettingSection>
+
+      <RoleDialog
+        open={dialogOpen}
+        role={editingRole}
+        onClose={() => setDialogOpen(false)}
+        onSave={handleSaveRole}
+      />
+
+      <Dialog open={!!deleteConfirmRole} onClose={() => setDeleteConfirmRole(null)}>
+        <DialogTitle>
+          <FormattedMessage id="roles.delete_confirm_title" defaultMessage="Delete Role" />
+        </DialogTitle>
+        <DialogContent>
+          <Typography>
+            <FormattedMessage
+              id="roles.delete_confirm_message"
+              defaultMessage="Delete role '{name}'? Users assigned to it fall back to read-only access."
+              values={{ name: deleteConfirmRole?.name }}
+            />
+          </Typography>
+        </DialogContent>
+        <DialogActions>
+          <Button onClick={() => setDeleteConfirmRole(null)}>
+            <FormattedMessage id="actions.cancel" defaultMessage="Cancel" />
+          </Button>
+          <Button
+            onClick={() => deleteConfirmRole && handleDeleteRole(deleteConfirmRole)}
+            color="error"
+            variant="contained"
+          >
+            <FormattedMessage id="actions.delete" defaultMessage="Delete" />
+          </Button>
+        </DialogActions>
+      </Dialog>
+    </>
+  );
+};
@@ -5,9 +5,12 @@ import { Alert } from "@mui/material";
 import { FormattedMessage } from "react-intl";
 import { useSettings } from "./context";
 import { LoadingIndicator } from "../Shared/LoadingIndicator";
+import { useCurrentUser } from "src/hooks/UserContext";
+import { SettingsAPIKeysSection } from "./SettingsAPIKeysSection";
 
 export const SettingsSecurityPanel: React.FC = () => {
   const { general, loading, error, saveGeneral } = useSettings();
+  const { user } = useCurrentUser();
 
   if (error) return <Alert severity="error">{error.message}</Alert>;
   if (loading) return <LoadingIndicator />;
@@ -28,7 +31,15 @@ export const SettingsSecurityPanel: React.FC = () => {
           value={general.maxSessionAge ?? undefined}
           onChange={(v) => saveGeneral({ maxSessionAge: v })}
         />
+        <NumberSetting
+          id="auditLogRetentionDays"
+          headingID="config.general.auth.audit_log_retention"
+          subHeadingID="config.general.auth.audit_log_retention_desc"
+          value={general.auditLogRetentionDays ?? undefined}
+          onChange={(v) => saveGeneral({ auditLogRetentionDays: v })}
+        />
       </SettingSection>
+      {user && <SettingsAPIKeysSection />}
     </>
   );
 };
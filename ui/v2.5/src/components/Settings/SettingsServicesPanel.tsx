@@ -24,6 +24,7 @@ import { Icon } from "../Shared/Icon";
 import { LoadingIndicator } from "../Shared/LoadingIndicator";
 import { ModalComponent } from "../Shared/Modal";
 import { SettingSection } from "./SettingSection";
+import { SettingsIPTVChannelsSection } from "./SettingsIPTVChannelsSection";
 import {
   BooleanSetting,
   StringListSetting,
@@ -487,6 +488,14 @@ export const SettingsServicesPanel: React.FC = () => {
             onChange={(v) => saveDLNA({ serverName: v })}
           />
 
+          <StringSetting
+            id="dlna-user"
+            headingID="config.dlna.user"
+            subHeadingID="config.dlna.user_desc"
+            value={dlna.user ?? undefined}
+            onChange={(v) => saveDLNA({ user: v })}
+          />
+
           <NumberSetting
             headingID="config.dlna.server_port"
             subHeading={intl.formatMessage({
@@ -604,6 +613,8 @@ export const SettingsServicesPanel: React.FC = () => {
       </SettingSection>
 
       <DLNASettingsForm />
+
+      <SettingsIPTVChannelsSection />
     </Box>
   );
 };
@@ -320,6 +320,33 @@ export const SettingsConfigurationPanel: React.FC = () => {
           onChange={(v) => saveGeneral({ pythonPath: v })}
         />
 
+        <SelectSetting
+          id="visual-embedding-backend"
+          headingID="config.general.visual_embedding_backend.heading"
+          subHeadingID="config.general.visual_embedding_backend.description"
+          value={general.visualEmbeddingBackend ?? "histogram"}
+          onChange={(v) => saveGeneral({ visualEmbeddingBackend: v })}
+        >
+          <option value="histogram">
+            {intl.formatMessage({
+              id: "config.general.visual_embedding_backend.histogram",
+            })}
+          </option>
+          <option value="sidecar">
+            {intl.formatMessage({
+              id: "config.general.visual_embedding_backend.sidecar",
+            })}
+          </option>
+        </SelectSetting>
+
+        <StringSetting
+          id="visual-embedding-model"
+          headingID="config.general.visual_embedding_model.heading"
+          subHeadingID="config.general.visual_embedding_model.description"
+          value={general.visualEmbeddingModel ?? undefined}
+          onChange={(v) => saveGeneral({ visualEmbeddingModel: v })}
+        />
+
         <StringSetting
           id="backup-directory-path"
           headingID="config.general.backup_directory_path.heading"
@@ -39,19 +39,45 @@ import { useToast } from "src/hooks/Toast";
 import { LoadingIndicator } from "../Shared/LoadingIndicator";
 import { SettingSection } from "./SettingSection";
 import { useCurrentUser } from "src/hooks/UserContext";
+import { useFindSavedFilters } from "src/core/StashService";
+import { TagIDSelect } from "../Tags/TagSelect";
+import { StudioIDSelect } from "../Studios/StudioSelect";
+import { SettingsRolesSection } from "./SettingsRolesSection";
+import { SettingsAuditLogSection } from "./SettingsAuditLogSection";
 
 interface UserFormData {
   username: string;
   password: string;
   role: GQL.UserRole;
+  customRoleID: string;
+  savedFilterID: string;
+  excludedTagIDs: string[];
+  excludedStudioIDs: string[];
 }
 
 const defaultFormData: UserFormData = {
   username: "",
   password: "",
   role: GQL.UserRole.Viewer,
+  customRoleID: "",
+  savedFilterID: "",
+  excludedTagIDs: [],
+  excludedStudioIDs: [],
 };
 
+function userFormData(user: GQL.UserDataFragment): UserFormData {
+  const restriction = user.content_restriction;
+  return {
+    username: user.username,
+    password: "",
+    role: user.role,
+    customRoleID: user.custom_role?.id ?? "",
+    savedFilterID: restriction?.saved_filter?.id ?? "",
+    excludedTagIDs: restriction?.excluded_tags.map((t) => t.id) ?? [],
+    excludedStudioIDs: restriction?.excluded_studios.map((s) => s.id) ?? [],
+  };
+}
+
 interface UserDialogProps {
   open: boolean;
   user: GQL.UserDataFragment | null;
@@ -68,15 +94,13 @@ const UserDialog: React.FC<UserDialogProps> = ({
   const intl = useIntl();
   const [formData, setFormData] = useState<UserFormData>(defaultFormData);
   const [saving, setSaving] = useState(false);
+  const { data: savedFilters } = useFindSavedFilters(GQL.FilterMode.Scenes);
+  const { data: roles } = GQL.useFindRolesQuery();
 
   // Reset form data when dialog opens or user changes
   React.useEffect(() => {
     if (open) {
-      setFormData(
-        user
-          ? { username: user.username, password: "", role: user.role }
-          : defaultFormData
-      );
+      setFormData(user ? userFormData(user) : defaultFormData);
     }
   }, [open, user]);
 
@@ -144,6 +168,73 @@ const UserDialog: React.FC<UserDialogProps> = ({
               </MenuItem>
             </Select>
           </FormControl>
+          {formData.role !== GQL.UserRole.Admin && (
+            <FormControl fullWidth>
+              <InputLabel>
+                <FormattedMessage id="users.custom_role" defaultMessage="Custom Role" />
+              </InputLabel>
+              <Select
+                value={formData.customRoleID}
+                label={intl.formatMessage({ id: "users.custom_role", defaultMessage: "Custom Role" })}
+                onChange={(e) => setFormData({ ...formData, customRoleID: e.target.value })}
+              >
+                <MenuItem value="">
+                  <FormattedMessage id="users.custom_role_none" defaultMessage="None (read-only)" />
+                </MenuItem>
+                {roles?.findRoles.map((r) => (
+                  <MenuItem key={r.id} value={r.id}>
+                    {r.name}
+                  </MenuItem>
+                ))}
+              </Select>
+            </FormControl>
+          )}
+          {isEdit && (
+            <>
+              <Typography variant="subtitle2">
+                <FormattedMessage id="users.content_restriction" defaultMessage="Content Restriction" />
+              </Typography>
+              <FormControl fullWidth>
+                <InputLabel>
+                  <FormattedMessage id="users.restriction_filter" defaultMessage="Only show scenes matching" />
+                </InputLabel>
+                <Select
+                  value={formData.savedFilterID}
+                  label={intl.formatMessage({ id: "users.restriction_filter", defaultMessage: "Only show scenes matching" })}
+                  onChange={(e) => setFormData({ ...formData, savedFilterID: e.target.value })}
+                >
+                  <MenuItem value="">
+                    <FormattedMessage id="users.restriction_filter_none" defaultMessage="All scenes" />
+                  </MenuItem>
+                  {savedFilters?.findSavedFilters.map((f) => (
+                    <MenuItem key={f.id} value={f.id}>
+                      {f.name}
+                    </MenuItem>
+                  ))}
+                </Select>
+              </FormControl>
+              <Box>
+                <Typography variant="body2" gutterBottom>
+                  <FormattedMessage id="users.excluded_tags" defaultMessage="Hide scenes with tags" />
+                </Typography>
+                <TagIDSelect
+                  isMulti
+                  ids={formData.excludedTagIDs}
+                  onSelect={(items) => setFormData({ ...formData, excludedTagIDs: items.map((i) => i.id) })}
+                />
+              </Box>
+              <Box>
+                <Typography variant="body2" gutterBottom>
+                  <FormattedMessage id="users.excluded_studios" defaultMessage="Hide scenes from studios" />
+                </Typography>
+                <StudioIDSelect
+                  isMulti
+                  ids={formData.excludedStudioIDs}
+                  onSelect={(items) => setFormData({ ...formData, excludedStudioIDs: items.map((i) => i.id) })}
+                />
+              </Box>
+            </>
+          )}
         </Box>
       </DialogContent>
       <DialogActions>
@@ -233,6 +324,12 @@ export const SettingsUsersPanel: React.FC = () => {
               username: formData.username,
               password: formData.password || undefined,
               role: formData.role,
+              custom_role_id: formData.customRoleID,
+              content_restriction: {
+                saved_filter_id: formData.savedFilterID || null,
+                excluded_tag_ids: formData.excludedTagIDs,
+                excluded_studio_ids: formData.excludedStudioIDs,
+              },
             },
           },
         });
@@ -245,6 +342,7 @@ export const SettingsUsersPanel: React.FC = () => {
               username: formData.username,
               password: formData.password,
               role: formData.role,
+              custom_role_id: formData.customRoleID || undefined,
             },
           },
         });
@@ -563,6 +661,9 @@ export const SettingsUsersPanel: React.FC = () => {
                       color={user.role === GQL.UserRole.Admin ? "primary" : "default"}
                       size="small"
                     />
+                    {user.custom_role && (
+                      <Chip label={user.custom_role.name} size="small" variant="outlined" sx={{ ml: 1 }} />
+                    )}
                   </TableCell>
                   <TableCell>
                     {user.last_login_at
@@ -614,6 +715,10 @@ export const SettingsUsersPanel: React.FC = () => {
         </TableContainer>
       </SettingSection>
 
+      <SettingsRolesSection />
+
+      <SettingsAuditLogSection />
+
       <UserDialog
         open={dialogOpen}
         user={editingUser}
@@ -11,6 +11,8 @@ import {
   mutateMigrateSceneScreenshots,
   mutateMigrateBlobs,
   mutateOptimiseDatabase,
+  mutateRebuildCoWatchModel,
+  mutateGenerateVisualEmbeddings,
   mutateCleanGenerated,
 } from "src/core/StashService";
 import { useToast } from "src/hooks/Toast";
@@ -390,6 +392,42 @@ export const DataManagementTasks: React.FC<IDataManagementTasks> = ({
     }
   }
 
+  async function onRebuildCoWatchModel() {
+    try {
+      await mutateRebuildCoWatchModel(true);
+      Toast.success(
+        intl.formatMessage(
+          { id: "config.tasks.added_job_to_queue" },
+          {
+            operation_name: intl.formatMessage({
+              id: "actions.rebuild_co_watch_model",
+            }),
+          }
+        )
+      );
+    } catch (e) {
+      Toast.error(e);
+    }
+  }
+
+  async function onGenerateVisualEmbeddings() {
+    try {
+      await mutateGenerateVisualEmbeddings({});
+      Toast.success(
+        intl.formatMessage(
+          { id: "config.tasks.added_job_to_queue" },
+          {
+            operation_name: intl.formatMessage({
+              id: "actions.generate_visual_embeddings",
+            }),
+          }
+        )
+      );
+    } catch (e) {
+      Toast.error(e);
+    }
+  }
+
   async function onAnonymise(download?: boolean) {
     try {
       setIsAnonymiseRunning(true);
@@ -520,6 +558,34 @@ export const DataManagementTasks: React.FC<IDataManagementTasks> = ({
             <FormattedMessage id="actions.optimise_database" />
           </Button>
         </Setting>
+
+        <Setting
+          headingID="actions.rebuild_co_watch_model"
+          subHeadingID="config.tasks.rebuild_co_watch_model"
+        >
+          <Button
+            id="rebuildCoWatchModel"
+            variant="contained"
+            color="secondary"
+            onClick={() => onRebuildCoWatchModel()}
+          >
+            <FormattedMessage id="actions.rebuild_co_watch_model" />
+          </Button>
+        </Setting>
+
+        <Setting
+          headingID="actions.generate_visual_embeddings"
+          subHeadingID="config.tasks.generate_visual_embeddings"
+        >
+          <Button
+            id="generateVisualEmbeddings"
+            variant="contained"
+            color="secondary"
+            onClick={() => onGenerateVisualEmbeddings()}
+          >
+            <FormattedMessage id="actions.generate_visual_embeddings" />
+          </Button>
+        </Setting>
       </SettingSection>
 
       <SettingSection headingID="metadata">
@@ -21,12 +21,23 @@ import {
   faWandMagicSparkles,
   faTrash,
   faBoxArchive,
+  faPause,
+  faPlay,
+  faChevronUp,
+  faChevronDown,
+  faAnglesUp,
+  faAnglesDown,
+  faEquals,
 } from "@fortawesome/free-solid-svg-icons";
 import { formatRelativeTime } from "src/utils/date";
 import React, { useEffect, useRef, useState } from "react";
 import { useIntl } from "react-intl";
 import { Icon } from "src/components/Shared/Icon";
 import {
+  mutateMoveJob,
+  mutatePauseJob,
+  mutateResumeJob,
+  mutateSetJobPriority,
   mutateStopJob,
   useJobQueue,
   useJobsSubscribe,
@@ -101,15 +112,79 @@ type JobFragment = Pick<
   | "progress"
   | "error"
   | "startTime"
+  | "priority"
+  | "lane"
 >;
 
 interface IJob {
   job: JobFragment;
+  // moves the waiting job by the given number of places in the queue
+  onMove?: (delta: number) => void;
+  onReordered?: () => void;
+  canMoveUp?: boolean;
+  canMoveDown?: boolean;
 }
 
+const priorityRank: Record<GQL.JobPriority, number> = {
+  [GQL.JobPriority.Low]: 0,
+  [GQL.JobPriority.Normal]: 1,
+  [GQL.JobPriority.High]: 2,
+};
+
+const nextPriority: Record<GQL.JobPriority, GQL.JobPriority> = {
+  [GQL.JobPriority.Low]: GQL.JobPriority.Normal,
+  [GQL.JobPriority.Normal]: GQL.JobPriority.High,
+  [GQL.JobPriority.High]: GQL.JobPriority.Low,
+};
+
+const priorityIcon: Record<GQL.JobPriority, IconDefinition> = {
+  [GQL.JobPriority.Low]: faAnglesDown,
+  [GQL.JobPriority.Normal]: faEquals,
+  [GQL.JobPriority.High]: faAnglesUp,
+};
+
+function isEnded(job: JobFragment) {
+  return (
+    job.status === GQL.JobStatus.Finished ||
+    job.status === GQL.JobStatus.Cancelled ||
+    job.status === GQL.JobStatus.Failed
+  );
+}
+
+// insertJob adds a new job to the queue where the server puts it: after the
+// waiting jobs of the same or a higher priority
+function insertJob(queue: JobFragment[], job: JobFragment) {
+  let i = queue.length;
+  while (i > 0) {
+    const prev = queue[i - 1];
+    if (
+      isEnded(prev) ||
+      prev.status !== GQL.JobStatus.Ready ||
+      priorityRank[prev.priority] >= priorityRank[job.priority]
+    ) {
+      break;
+    }
+    i--;
+  }
+  return [...queue.slice(0, i), job, ...queue.slice(i)];
+}
+
+const controlSx = {
+  p: 0.5,
+  color: "text.disabled",
+  "&:hover": { color: "primary.main" },
+  "&.Mui-disabled": { opacity: 0.3 },
+};
+
 // ─── Task card ───────────────────────────────────────────────────────────────
 
-const Task: React.FC<IJob> = ({ job }) => {
+const Task: React.FC<IJob> = ({
+  job,
+  onMove,
+  onReordered,
+  canMoveUp,
+  canMoveDown,
+}) => {
   const [stopping, setStopping] = useState(false);
   const [className, setClassName] = useState("");
   const [subTaskHistory, setSubTaskHistory] = useState<string[]>(() => {
@@ -195,16 +270,32 @@ const Task: React.FC<IJob> = ({ job }) => {
   function canStop() {
     return (
       !stopping &&
-      (job.status === GQL.JobStatus.Ready || job.status === GQL.JobStatus.Running)
+      (job.status === GQL.JobStatus.Ready ||
+        job.status === GQL.JobStatus.Running ||
+        job.status === GQL.JobStatus.Paused)
     );
   }
 
+  async function togglePaused() {
+    if (job.status === GQL.JobStatus.Paused) {
+      await mutateResumeJob(job.id);
+    } else {
+      await mutatePauseJob(job.id);
+    }
+  }
+
+  async function cyclePriority() {
+    await mutateSetJobPriority(job.id, nextPriority[job.priority]);
+    onReordered?.();
+  }
+
   const progress = (job.progress ?? 0) * 100;
   const isRunning = job.status === GQL.JobStatus.Running;
   const isFinished = job.status === GQL.JobStatus.Finished;
   const isFailed = job.status === GQL.JobStatus.Failed;
   const isCancelled = job.status === GQL.JobStatus.Cancelled;
   const isReady = job.status === GQL.JobStatus.Ready;
+  const isPaused = job.status === GQL.JobStatus.Paused;
 
   const statusColor = isRunning
     ? "primary.main"
@@ -350,6 +441,9 @@ const Task: React.FC<IJob> = ({ job }) => {
           {isReady && (
             <Chip icon={<Icon icon={faHourglassStart} />} label="Queued" size="small" sx={{ height: 20, fontSize: "0.7rem", color: "warning.main", borderColor: "warning.main" }} variant="outlined" />
           )}
+          {isPaused && (
+            <Chip icon={<Icon icon={faPause} />} label="Paused" size="small" sx={{ height: 20, fontSize: "0.7rem", color: "warning.main", borderColor: "warning.main" }} variant="outlined" />
+          )}
 
           <Box sx={{ flex: 1 }} />
 
@@ -360,6 +454,38 @@ const Task: React.FC<IJob> = ({ job }) => {
             </Typography>
           )}
 
+          {/* Queue controls */}
+          {isReady && (
+            <>
+              <Tooltip title={`Priority: ${job.priority.toLowerCase()}`} arrow>
+                <IconButton size="small" onClick={cyclePriority} sx={controlSx}>
+                  <Icon icon={priorityIcon[job.priority]} />
+                </IconButton>
+              </Tooltip>
+              <Tooltip title="Move up" arrow>
+                <span>
+                  <IconButton size="small" onClick={() => onMove?.(-1)} disabled={!canMoveUp} sx={controlSx}>
+                    <Icon icon={faChevronUp} />
+                  </IconButton>
+                </span>
+              </Tooltip>
+              <Tooltip title="Move down" arrow>
+                <span>
+                  <IconButton size="small" onClick={() => onMove?.(1)} disabled={!canMoveDown} sx={controlSx}>
+                    <Icon icon={faChevronDown} />
+                  </IconButton>
+                </span>
+              </Tooltip>
+            </>
+          )}
+          {((isRunning && job.pausable) || isPaused) && (
+            <Tooltip title={isPaused ? "Resume job" : "Pause job"} arrow>
+              <IconButton size="small" onClick={togglePaused} sx={controlSx}>
+                <Icon icon={isPaused ? faPlay : faPause} />
+              </IconButton>
+            </Tooltip>
+          )}
+
           {/* Stop button */}
           <Tooltip title="Stop job" arrow>
             <span>
@@ -620,7 +746,7 @@ export const JobTable: React.FC = () => {
 
     switch (event.type) {
       case GQL.JobStatusUpdateType.Add:
-        setQueue((q) => q.concat([event.job]));
+        setQueue((q) => insertJob(q, event.job));
         break;
       case GQL.JobStatusUpdateType.Remove:
         updateJob();
@@ -685,12 +811,33 @@ export const JobTable: React.FC = () => {
     );
   }
 
+  // positions in the server's queue, which no longer has the ended jobs
+  const waiting = queue.filter((j) => !isEnded(j));
+
+  async function moveJob(job: JobFragment, delta: number) {
+    const position = waiting.findIndex((j) => j.id === job.id) + delta;
+    await mutateMoveJob(job.id, position);
+    jobStatus.refetch();
+  }
+
   return (
     <Box sx={{ display: "flex", flexDirection: "column", gap: 0 }}>
       <ResourceMonitor />
-      {queue.map((j) => (
-        <Task job={j} key={j.id} />
-      ))}
+      {queue.map((j) => {
+        const position = waiting.indexOf(j);
+        return (
+          <Task
+            job={j}
+            key={j.id}
+            onMove={(delta) => moveJob(j, delta)}
+            onReordered={() => jobStatus.refetch()}
+            canMoveUp={
+              position > 0 && waiting[position - 1].status === GQL.JobStatus.Ready
+            }
+            canMoveDown={position >= 0 && position < waiting.length - 1}
+          />
+        );
+      })}
     </Box>
   );
 };
@@ -25,7 +25,8 @@ import {
     Box,
     Typography,
     Divider,
-    IconButton
+    IconButton,
+    CircularProgress
 } from "@mui/material";
 import { Icon } from "src/components/Shared/Icon";
 import {
@@ -36,6 +37,7 @@ import {
     faClock,
     faCheck,
     faTimes,
+    faHistory,
 } from "@fortawesome/free-solid-svg-icons";
 import { CronInput } from "./CronInput";
 import { ScanOptions } from "./ScanOptions";
@@ -45,6 +47,7 @@ import { AutoTagOptions } from "./LibraryTasks";
 import * as GQL from "src/core/generated-graphql";
 import { usePlugins } from "src/core/StashService";
 import { useToast } from "src/hooks/Toast";
+import { useConfigurationContext } from "src/hooks/Config";
 
 // Types
 interface TaskFormData {
@@ -52,8 +55,39 @@ interface TaskFormData {
     cronSchedule: string;
     taskType: GQL.ScheduledTaskType;
     enabled: boolean;
+    then: string[];
+    retries: number;
+    retryDelay: number;
+    skipIfRunning: boolean;
 }
 
+interface StashBoxTagOptions {
+    type: "performer" | "studio";
+    stash_box_endpoint: string;
+    refresh: boolean;
+    createParent: boolean;
+}
+
+const DEFAULT_RETRY_DELAY = 60;
+
+const defaultFormData = (): TaskFormData => ({
+    name: "",
+    cronSchedule: "0 0 3 * * *",
+    taskType: GQL.ScheduledTaskType.Scan,
+    enabled: true,
+    then: [],
+    retries: 0,
+    retryDelay: DEFAULT_RETRY_DELAY,
+    skipIfRunning: false,
+});
+
+const defaultStashBoxTagOptions = (): StashBoxTagOptions => ({
+    type: "performer",
+    stash_box_endpoint: "",
+    refresh: false,
+    createParent: false,
+});
+
 const TASK_TYPES = [
     { value: GQL.ScheduledTaskType.Scan, label: "Scan Library" },
     { value: GQL.ScheduledTaskType.Generate, label: "Generate Content" },
@@ -61,28 +95,124 @@ const TASK_TYPES = [
     { value: GQL.ScheduledTaskType.Clean, label: "Clean Library" },
     { value: GQL.ScheduledTaskType.Optimise, label: "Optimise Database" },
     { value: GQL.ScheduledTaskType.Plugin, label: "Plugin Task" },
+    { value: GQL.ScheduledTaskType.RebuildProfile, label: "Rebuild Recommendation Profiles" },
+    { value: GQL.ScheduledTaskType.RebuildCoWatch, label: "Update Co-Watch Model" },
+    { value: GQL.ScheduledTaskType.Identify, label: "Identify" },
+    { value: GQL.ScheduledTaskType.Backup, label: "Backup Database" },
+    { value: GQL.ScheduledTaskType.Export, label: "Export Metadata" },
+    { value: GQL.ScheduledTaskType.StashBoxTag, label: "Stash-Box Batch Tag" },
+    { value: GQL.ScheduledTaskType.ApiHubRelink, label: "API Hub Relink" },
 ];
 
+const formatDuration = (seconds: number) => {
+    if (seconds < 60) return `${seconds.toFixed(1)}s`;
+    const m = Math.floor(seconds / 60);
+    const s = Math.round(seconds % 60);
+    if (m < 60) return `${m}m ${s}s`;
+    return `${Math.floor(m / 60)}h ${m % 60}m`;
+};
+
+const RUN_STATUS_COLORS: Record<GQL.ScheduledTaskRunStatus, "success" | "error" | "warning" | "default"> = {
+    [GQL.ScheduledTaskRunStatus.Succeeded]: "success",
+    [GQL.ScheduledTaskRunStatus.Failed]: "error",
+    [GQL.ScheduledTaskRunStatus.Cancelled]: "warning",
+    [GQL.ScheduledTaskRunStatus.Skipped]: "default",
+};
+
+const ScheduledTaskHistory: React.FC<{
+    task: GQL.ScheduledTaskDataFragment;
+    onClose: () => void;
+}> = ({ task, onClose }) => {
+    const { data, loading, error } = GQL.useScheduledTaskRunsQuery({
+        variables: { task_id: task.id, limit: 50 },
+        fetchPolicy: "network-only",
+    });
+
+    const runs = data?.scheduledTaskRuns || [];
+
+    const renderBody = () => {
+        if (loading) {
+            return <Box display="flex" justifyContent="center" py={4}><CircularProgress /></Box>;
+        }
+        if (error) {
+            return <div>Error loading history: {error.message}</div>;
+        }
+        if (runs.length === 0) {
+            return (
+                <Typography color="textSecondary" align="center" py={4}>
+                    This task has not run yet.
+                </Typography>
+            );
+        }
+
+        return (
+            <TableContainer component={Paper}>
+                <Table size="small">
+                    <TableHead>
+                        <TableRow>
+                            <TableCell>Started</TableCell>
+                            <TableCell>Trigger</TableCell>
+                            <TableCell>Attempt</TableCell>
+                            <TableCell>Status</TableCell>
+                            <TableCell>Duration</TableCell>
+                            <TableCell>Job</TableCell>
+                            <TableCell>Error</TableCell>
+                        </TableRow>
+                    </TableHead>
+                    <TableBody>
+                        {runs.map((run) => (
+                            <TableRow key={run.id}>
+                                <TableCell>{new Date(run.started_at).toLocaleString()}</TableCell>
+                                <TableCell>{run.trigger.toLowerCase()}</TableCell>
+                                <TableCell>{run.attempt}</TableCell>
+                                <TableCell>
+                                    <Chip
+                                        label={run.status.toLowerCase()}
+                                        color={RUN_STATUS_COLORS[run.status]}
+                                        size="small"
+                                    />
+                                </TableCell>
+                                <TableCell>{formatDuration(run.duration)}</TableCell>
+                                <TableCell>{run.job_id ?? "-"}</TableCell>
+                                <TableCell sx={{ color: 'error.main' }}>{run.error}</TableCell>
+                            </TableRow>
+                        ))}
+                    </TableBody>
+                </Table>
+            </TableContainer>
+        );
+    };
+
+    return (
+        <Dialog open onClose={onClose} maxWidth="lg" fullWidth>
+            <DialogTitle>Run History: {task.name}</DialogTitle>
+            <DialogContent>{renderBody()}</DialogContent>
+            <DialogActions>
+                <Button onClick={onClose} color="inherit">Close</Button>
+            </DialogActions>
+        </Dialog>
+    );
+};
+
 export const ScheduledTasks: React.FC = () => {
     const intl = useIntl();
     const plugins = usePlugins();
     const Toast = useToast();
+    const { configuration } = useConfigurationContext();
+    const stashBoxes = configuration?.general.stashBoxes ?? [];
 
     const [showModal, setShowModal] = useState(false);
-    const [editingTask, setEditingTask] = useState<GQL.ScheduledTask | null>(null);
+    const [editingTask, setEditingTask] = useState<GQL.ScheduledTaskDataFragment | null>(null);
+    const [historyTask, setHistoryTask] = useState<GQL.ScheduledTaskDataFragment | null>(null);
 
-    const [formData, setFormData] = useState<TaskFormData>({
-        name: "",
-        cronSchedule: "0 0 3 * * *",
-        taskType: GQL.ScheduledTaskType.Scan,
-        enabled: true,
-    });
+    const [formData, setFormData] = useState<TaskFormData>(defaultFormData());
 
     // Options state
     const [scanOptions, setScanOptions] = useState<GQL.ScanMetadataInput>({});
     const [generateOptions, setGenerateOptions] = useState<GQL.GenerateMetadataInput>({});
     const [cleanOptions, setCleanOptions] = useState<GQL.CleanMetadataInput>({ dryRun: false });
     const [autoTagOptions, setAutoTagOptions] = useState<GQL.AutoTagMetadataInput>({});
+    const [stashBoxTagOptions, setStashBoxTagOptions] = useState<StashBoxTagOptions>(defaultStashBoxTagOptions());
 
     // Plugin options state
     const [selectedPluginId, setSelectedPluginId] = useState<string>("");
@@ -96,6 +226,7 @@ export const ScheduledTasks: React.FC = () => {
     const [runTask] = GQL.useScheduledTaskRunMutation();
 
     const tasks = data?.scheduledTasks || [];
+    const chainableTasks = tasks.filter((t) => t.id !== editingTask?.id);
 
     const getOptionsObject = () => {
         switch (formData.taskType) {
@@ -109,6 +240,11 @@ export const ScheduledTasks: React.FC = () => {
                     taskName: selectedPluginTask,
                     args: {}
                 };
+            case GQL.ScheduledTaskType.StashBoxTag:
+                return {
+                    ...stashBoxTagOptions,
+                    stash_box_endpoint: stashBoxTagOptions.stash_box_endpoint || stashBoxes[0]?.endpoint,
+                };
             default: return {};
         }
     };
@@ -122,6 +258,10 @@ export const ScheduledTasks: React.FC = () => {
                 task_type: formData.taskType,
                 enabled: formData.enabled,
                 options: JSON.stringify(getOptionsObject()),
+                then: formData.then,
+                retries: formData.retries,
+                retry_delay: formData.retryDelay,
+                skip_if_running: formData.skipIfRunning,
             };
 
             await createTask({ variables: { input } });
@@ -147,6 +287,10 @@ export const ScheduledTasks: React.FC = () => {
                 task_type: formData.taskType,
                 enabled: formData.enabled,
                 options: JSON.stringify(getOptionsObject()),
+                then: formData.then,
+                retries: formData.retries,
+                retry_delay: formData.retryDelay,
+                skip_if_running: formData.skipIfRunning,
             };
 
             await updateTask({ variables: { input } });
@@ -183,6 +327,7 @@ export const ScheduledTasks: React.FC = () => {
             const result = await runTask({ variables: { id: taskId } });
             const jobId = result.data?.scheduledTaskRun;
             Toast.success(`Task started with job ID: ${jobId}`);
+            refetch();
         } catch (error) {
             console.error("Failed to run scheduled task:", error);
             Toast.error("Failed to run task");
@@ -190,7 +335,7 @@ export const ScheduledTasks: React.FC = () => {
     };
 
     // Toggle enabled
-    const handleToggleEnabled = async (task: GQL.ScheduledTask) => {
+    const handleToggleEnabled = async (task: GQL.ScheduledTaskDataFragment) => {
         try {
             await updateTask({
                 variables: {
@@ -208,27 +353,27 @@ export const ScheduledTasks: React.FC = () => {
     };
 
     const resetForm = () => {
-        setFormData({
-            name: "",
-            cronSchedule: "0 0 3 * * *",
-            taskType: GQL.ScheduledTaskType.Scan,
-            enabled: true,
-        });
+        setFormData(defaultFormData());
         setScanOptions({});
         setGenerateOptions({});
         setCleanOptions({ dryRun: false });
         setAutoTagOptions({});
+        setStashBoxTagOptions(defaultStashBoxTagOptions());
         setSelectedPluginId("");
         setSelectedPluginTask("");
     };
 
-    const openEditModal = (task: GQL.ScheduledTask) => {
+    const openEditModal = (task: GQL.ScheduledTaskDataFragment) => {
         setEditingTask(task);
         setFormData({
             name: task.name,
             cronSchedule: task.cron_schedule,
             taskType: task.task_type,
             enabled: task.enabled,
+            then: task.then,
+            retries: task.retries,
+            retryDelay: task.retry_delay,
+            skipIfRunning: task.skip_if_running,
         });
 
         // Populate options based on type
@@ -250,6 +395,9 @@ export const ScheduledTasks: React.FC = () => {
                 setSelectedPluginId(opts.pluginId || "");
                 setSelectedPluginTask(opts.taskName || "");
                 break;
+            case GQL.ScheduledTaskType.StashBoxTag:
+                setStashBoxTagOptions({ ...defaultStashBoxTagOptions(), ...opts });
+                break;
         }
 
         setShowModal(true);
@@ -282,6 +430,86 @@ export const ScheduledTasks: React.FC = () => {
                 return <AutoTagOptions options={autoTagOptions} setOptions={setAutoTagOptions} keyPrefix="scheduled-task-" />;
             case GQL.ScheduledTaskType.Optimise:
                 return <div>No options available for Optimise Database task.</div>;
+            case GQL.ScheduledTaskType.RebuildProfile:
+                return <div>No options available for Rebuild Recommendation Profiles task.</div>;
+            case GQL.ScheduledTaskType.RebuildCoWatch:
+                return <div>No options available for Update Co-Watch Model task.</div>;
+            case GQL.ScheduledTaskType.Identify:
+                return <div>Identify uses the default sources and options from the Identify task settings.</div>;
+            case GQL.ScheduledTaskType.Backup:
+                return <div>No options available for Backup Database task.</div>;
+            case GQL.ScheduledTaskType.Export:
+                return <div>No options available for Export Metadata task.</div>;
+            case GQL.ScheduledTaskType.ApiHubRelink:
+                return <div>No options available for API Hub Relink task.</div>;
+            case GQL.ScheduledTaskType.StashBoxTag:
+                return (
+                    <div className="stash-box-tag-options">
+                        <FormControl fullWidth variant="outlined" sx={{ mb: 3 }}>
+                            <InputLabel id="stash-box-tag-type-label">Tag</InputLabel>
+                            <Select
+                                labelId="stash-box-tag-type-label"
+                                value={stashBoxTagOptions.type}
+                                onChange={(e) =>
+                                    setStashBoxTagOptions({ ...stashBoxTagOptions, type: e.target.value as StashBoxTagOptions["type"] })
+                                }
+                                label="Tag"
+                            >
+                                <MenuItem value="performer">Performers</MenuItem>
+                                <MenuItem value="studio">Studios</MenuItem>
+                            </Select>
+                        </FormControl>
+
+                        <FormControl fullWidth variant="outlined" sx={{ mb: 3 }}>
+                            <InputLabel id="stash-box-label">Stash-Box</InputLabel>
+                            <Select
+                                labelId="stash-box-label"
+                                value={stashBoxTagOptions.stash_box_endpoint || stashBoxes[0]?.endpoint || ""}
+                                onChange={(e) =>
+                                    setStashBoxTagOptions({ ...stashBoxTagOptions, stash_box_endpoint: e.target.value as string })
+                                }
+                                label="Stash-Box"
+                                disabled={stashBoxes.length === 0}
+                            >
+                                {stashBoxes.map((box) => (
+                                    <MenuItem key={box.endpoint} value={box.endpoint}>
+                                        {box.name || box.endpoint}
+                                    </MenuItem>
+                                ))}
+                            </Select>
+                            {stashBoxes.length === 0 && (
+                                <Typography variant="caption" color="textSecondary" sx={{ mt: 1 }}>
+                                    No stash-box instances are configured.
+                                </Typography>
+                            )}
+                        </FormControl>
+
+                        <FormControlLabel
+                            control={
+                                <Switch
+                                    checked={stashBoxTagOptions.refresh}
+                                    onChange={(e) =>
+                                        setStashBoxTagOptions({ ...stashBoxTagOptions, refresh: e.target.checked })
+                                    }
+                                />
+                            }
+                            label="Refresh items already tagged"
+                        />
+                        {stashBoxTagOptions.type === "studio" && (
+                            <FormControlLabel
+                                control={
+                                    <Switch
+                                        checked={stashBoxTagOptions.createParent}
+                                        onChange={(e) =>
+                                            setStashBoxTagOptions({ ...stashBoxTagOptions, createParent: e.target.checked })
+                                        }
+                                    />
+                                }
+                                label="Create parent studios"
+                            />
+                        )}
+                    </div>
+                );
             case GQL.ScheduledTaskType.Plugin:
                 const availablePlugins = plugins.data?.plugins || [];
                 const taskPlugins = availablePlugins.filter(p => p.enabled && p.tasks && p.tasks.length > 0);
@@ -382,7 +610,16 @@ export const ScheduledTasks: React.FC = () => {
                                         <Chip label={getTaskTypeLabel(task.task_type)} color="default" variant="outlined" size="small" />
                                     </TableCell>
                                     <TableCell>
-                                        <code>{task.cron_schedule}</code>
+                                        {task.cron_schedule ? (
+                                            <code>{task.cron_schedule}</code>
+                                        ) : (
+                                            <Typography variant="body2" color="textSecondary">Manual</Typography>
+                                        )}
+                                        {task.then.length > 0 && (
+                                            <Typography variant="caption" color="textSecondary" display="block">
+                                                then {task.then.map((id) => tasks.find((t) => t.id === id)?.name ?? id).join(", ")}
+                                            </Typography>
+                                        )}
                                     </TableCell>
                                     <TableCell>
                                         <Button
@@ -395,6 +632,9 @@ export const ScheduledTasks: React.FC = () => {
                                         >
                                             {task.enabled ? "Enabled" : "Disabled"}
                                         </Button>
+                                        {task.running && (
+                                            <Chip label="Running" color="info" size="small" sx={{ ml: 1 }} />
+                                        )}
                                     </TableCell>
                                     <TableCell sx={{ color: 'text.secondary' }}>{formatDate(task.last_run)}</TableCell>
                                     <TableCell sx={{ color: 'text.secondary' }}>{formatDate(task.next_run)}</TableCell>
@@ -413,6 +653,12 @@ export const ScheduledTasks: React.FC = () => {
                                             >
                                                 <Icon icon={faEdit} />
                                             </IconButton>
+                                            <IconButton
+                                                onClick={() => setHistoryTask(task)}
+                                                title="History"
+                                            >
+                                                <Icon icon={faHistory} />
+                                            </IconButton>
                                             <IconButton
                                                 color="error"
                                                 onClick={() => handleDelete(task.id)}
@@ -468,12 +714,93 @@ export const ScheduledTasks: React.FC = () => {
 
                         <Box mt={2} mb={2}>
                             <Typography variant="subtitle2" gutterBottom>Schedule</Typography>
-                            <CronInput
-                                value={formData.cronSchedule}
-                                onChange={(v) => setFormData({ ...formData, cronSchedule: v })}
+                            <FormControlLabel
+                                control={
+                                    <Switch
+                                        checked={!!formData.cronSchedule}
+                                        onChange={(e) =>
+                                            setFormData({ ...formData, cronSchedule: e.target.checked ? "0 0 3 * * *" : "" })
+                                        }
+                                    />
+                                }
+                                label="Run on a schedule"
+                            />
+                            {formData.cronSchedule ? (
+                                <CronInput
+                                    value={formData.cronSchedule}
+                                    onChange={(v) => setFormData({ ...formData, cronSchedule: v })}
+                                />
+                            ) : (
+                                <Typography variant="caption" color="textSecondary" display="block">
+                                    The task only runs when started manually or chained after another task.
+                                </Typography>
+                            )}
+                        </Box>
+
+                        <FormControl fullWidth margin="normal" variant="outlined">
+                            <InputLabel id="then-label">Then Run</InputLabel>
+                            <Select
+                                labelId="then-label"
+                                multiple
+                                value={formData.then}
+                                onChange={(e) => {
+                                    const v = e.target.value;
+                                    setFormData({ ...formData, then: typeof v === "string" ? v.split(",") : v });
+                                }}
+                                label="Then Run"
+                                renderValue={(ids) =>
+                                    ids.map((id) => tasks.find((t) => t.id === id)?.name ?? id).join(" → ")
+                                }
+                            >
+                                {chainableTasks.map((t) => (
+                                    <MenuItem key={t.id} value={t.id}>{t.name}</MenuItem>
+                                ))}
+                            </Select>
+                            <Typography variant="caption" color="textSecondary" sx={{ mt: 1 }}>
+                                Tasks to run in order after this one succeeds.
+                            </Typography>
+                        </FormControl>
+
+                        <Box display="flex" gap={2}>
+                            <TextField
+                                type="number"
+                                label="Retries"
+                                value={formData.retries}
+                                onChange={(e) =>
+                                    setFormData({ ...formData, retries: Math.max(0, parseInt(e.target.value, 10) || 0) })
+                                }
+                                inputProps={{ min: 0 }}
+                                margin="normal"
+                                variant="outlined"
+                            />
+                            <TextField
+                                type="number"
+                                label="Retry Delay (seconds)"
+                                value={formData.retryDelay}
+                                onChange={(e) =>
+                                    setFormData({ ...formData, retryDelay: Math.max(0, parseInt(e.target.value, 10) || 0) })
+                                }
+                                inputProps={{ min: 0 }}
+                                helperText="Doubles after each failed attempt"
+                                disabled={formData.retries === 0}
+                                margin="normal"
+                                variant="outlined"
                             />
                         </Box>
 
+                        <FormControlLabel
+                            control={
+                                <Switch
+                                    checked={formData.skipIfRunning}
+                                    onChange={(e) =>
+                                        setFormData({ ...formData, skipIfRunning: e.target.checked })
+                                    }
+                                    color="primary"
+                                />
+                            }
+                            label="Skip if already running"
+                        />
+
                         <FormControlLabel
                             control={
                                 <Switch
@@ -502,12 +829,16 @@ export const ScheduledTasks: React.FC = () => {
                         onClick={editingTask ? handleUpdate : handleCreate}
                         color="primary"
                         variant="contained"
-                        disabled={!formData.name || !formData.cronSchedule}
+                        disabled={!formData.name}
                     >
                         {editingTask ? "Update" : "Create"}
                     </Button>
                 </DialogActions>
             </Dialog>
+
+            {historyTask && (
+                <ScheduledTaskHistory task={historyTask} onClose={() => setHistoryTask(null)} />
+            )}
         </div>
     );
 };
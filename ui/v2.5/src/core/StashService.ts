@@ -2617,6 +2617,11 @@ export const useDLNAStatus = () =>
     fetchPolicy: "no-cache",
   });
 
+export const useOptimizedVersionStorage = () =>
+  GQL.useOptimizedVersionStorageQuery({
+    fetchPolicy: "no-cache",
+  });
+
 export const useJobQueue = () =>
   GQL.useJobQueueQuery({
     fetchPolicy: "no-cache",
@@ -2804,6 +2809,33 @@ export const mutateStopJob = (jobID: string) =>
     variables: { job_id: jobID },
   });
 
+export const mutatePauseJob = (jobID: string) =>
+  client.mutate<GQL.PauseJobMutation>({
+    mutation: GQL.PauseJobDocument,
+    variables: { job_id: jobID },
+  });
+
+export const mutateResumeJob = (jobID: string) =>
+  client.mutate<GQL.ResumeJobMutation>({
+    mutation: GQL.ResumeJobDocument,
+    variables: { job_id: jobID },
+  });
+
+export const mutateSetJobPriority = (
+  jobID: string,
+  priority: GQL.JobPriority
+) =>
+  client.mutate<GQL.SetJobPriorityMutation>({
+    mutation: GQL.SetJobPriorityDocument,
+    variables: { job_id: jobID, priority },
+  });
+
+export const mutateMoveJob = (jobID: string, position: number) =>
+  client.mutate<GQL.MoveJobMutation>({
+    mutation: GQL.MoveJobDocument,
+    variables: { job_id: jobID, position },
+  });
+
 const setupMutationImpactedQueries = [
   GQL.ConfigurationDocument,
   GQL.SystemStatusDocument,
@@ -2982,6 +3014,19 @@ export const mutateCleanGenerated = (input: GQL.CleanGeneratedInput) =>
     variables: { input },
   });
 
+export const mutateGenerateOptimizedVersions = (
+  input: GQL.GenerateOptimizedVersionsInput
+) =>
+  client.mutate<GQL.GenerateOptimizedVersionsMutation>({
+    mutation: GQL.GenerateOptimizedVersionsDocument,
+    variables: { input },
+  });
+
+export const mutateCleanOptimizedVersions = () =>
+  client.mutate<GQL.CleanOptimizedVersionsMutation>({
+    mutation: GQL.CleanOptimizedVersionsDocument,
+  });
+
 export const mutateRunPluginTask = (
   pluginId: string,
   taskName: string,
@@ -3031,6 +3076,20 @@ export const mutateOptimiseDatabase = () =>
     mutation: GQL.OptimiseDatabaseDocument,
   });
 
+export const mutateGenerateVisualEmbeddings = (
+  input: GQL.GenerateVisualEmbeddingsInput
+) =>
+  client.mutate<GQL.GenerateVisualEmbeddingsMutation>({
+    mutation: GQL.GenerateVisualEmbeddingsDocument,
+    variables: { input },
+  });
+
+export const mutateRebuildCoWatchModel = (full?: boolean) =>
+  client.mutate<GQL.RebuildCoWatchModelMutation>({
+    mutation: GQL.RebuildCoWatchModelDocument,
+    variables: { full },
+  });
+
 export const mutateMigrateHashNaming = () =>
   client.mutate<GQL.MigrateHashNamingMutation>({
     mutation: GQL.MigrateHashNamingDocument,
@@ -136,6 +136,7 @@ describe("UserContext", () => {
         id: "1",
         username: "admin",
         role: GQL.UserRole.Admin,
+        custom_role: null,
         is_active: true,
         created_at: "2025-01-01T00:00:00Z",
         permissions: {
@@ -145,6 +146,12 @@ describe("UserContext", () => {
           can_manage_users: true,
           can_run_tasks: true,
           can_modify_settings: true,
+          can_manage_plugins: true,
+          can_use_api_hub: true,
+          can_control_handy: true,
+          can_manage_iptv: true,
+          can_view_recycle_bin: true,
+          content_restricted: false,
         },
       };
 
@@ -180,6 +187,7 @@ describe("UserContext", () => {
         id: "2",
         username: "viewer",
         role: GQL.UserRole.Viewer,
+        custom_role: null,
         is_active: true,
         created_at: "2025-01-01T00:00:00Z",
         permissions: {
@@ -189,6 +197,12 @@ describe("UserContext", () => {
           can_manage_users: false,
           can_run_tasks: false,
           can_modify_settings: false,
+          can_manage_plugins: false,
+          can_use_api_hub: false,
+          can_control_handy: false,
+          can_manage_iptv: false,
+          can_view_recycle_bin: false,
+          content_restricted: false,
         },
       };
 
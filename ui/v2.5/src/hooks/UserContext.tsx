@@ -10,6 +10,11 @@ interface UserContextType {
   canManageUsers: boolean;
   canRunTasks: boolean;
   canModifySettings: boolean;
+  canManagePlugins: boolean;
+  canUseAPIHub: boolean;
+  canControlHandy: boolean;
+  canManageIPTV: boolean;
+  canViewRecycleBin: boolean;
   loading: boolean;
   /** True when no users exist in the system (first-time setup mode) */
   isSetupMode: boolean;
@@ -25,6 +30,11 @@ const defaultContext: UserContextType = {
   canManageUsers: false,
   canRunTasks: false,
   canModifySettings: false,
+  canManagePlugins: false,
+  canUseAPIHub: false,
+  canControlHandy: false,
+  canManageIPTV: false,
+  canViewRecycleBin: false,
   loading: true,
   isSetupMode: false,
   refetch: () => {},
@@ -64,6 +74,11 @@ export const UserProvider: React.FC<{ children: React.ReactNode }> = ({
         canManageUsers: true,
         canRunTasks: true,
         canModifySettings: true,
+        canManagePlugins: true,
+        canUseAPIHub: true,
+        canControlHandy: true,
+        canManageIPTV: true,
+        canViewRecycleBin: true,
         loading,
         isSetupMode,
         refetch,
@@ -80,6 +95,11 @@ export const UserProvider: React.FC<{ children: React.ReactNode }> = ({
       canManageUsers: perms?.can_manage_users ?? false,
       canRunTasks: perms?.can_run_tasks ?? true,
       canModifySettings: perms?.can_modify_settings ?? true,
+      canManagePlugins: perms?.can_manage_plugins ?? true,
+      canUseAPIHub: perms?.can_use_api_hub ?? true,
+      canControlHandy: perms?.can_control_handy ?? true,
+      canManageIPTV: perms?.can_manage_iptv ?? true,
+      canViewRecycleBin: perms?.can_view_recycle_bin ?? true,
       loading,
       isSetupMode: false,
       refetch,